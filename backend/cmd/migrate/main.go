@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"routrapp-api/internal/config"
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories/postgres"
+)
+
+// Exit codes returned to deploy pipelines
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+const usage = `Usage: migrate [flags] <command> [arguments]
+
+Commands:
+  create <name>       create a new pair of up and down migration files
+  up                  apply all pending migrations
+  down [n]            roll back the n most recent migrations (default 1)
+  goto <version>      apply or roll back migrations until exactly those up to version are applied
+  status              list applied and pending migrations
+  validate            check that every migration has up and down SQL
+  baseline [version]  mark migrations up to version (default all) as applied without running them
+  diff <name>         generate a migration from the differences between the models and the database
+
+Flags:
+`
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run executes the command in args and returns the process exit code
+func run(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	path := flags.String("path", "", "migrations directory (default: database.migrations_path)")
+	dryRun := flags.Bool("dry-run", false, "print the migrations that would run without executing them")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	// Load configuration
+	cfg := config.Load()
+	logger.InitLogger(cfg.Environment)
+	if *path == "" {
+		*path = cfg.Database.MigrationsPath
+	}
+
+	command, arguments := flags.Arg(0), flags.Args()[1:]
+	manager := postgres.NewMigrationManager(*path)
+
+	// Commands working on the migration files only don't need a database
+	switch command {
+	case "create":
+		if len(arguments) != 1 {
+			return usageError(flags, "create requires a migration name")
+		}
+		upPath, downPath, err := manager.CreateMigration(arguments[0])
+		if err != nil {
+			return failure(err)
+		}
+		fmt.Printf("Created %s\nCreated %s\n", upPath, downPath)
+		return exitOK
+	case "validate":
+		if err := manager.ValidateMigrations(); err != nil {
+			return failure(err)
+		}
+		fmt.Println("All migrations are valid")
+		return exitOK
+	}
+
+	var n int
+	switch command {
+	case "up", "status":
+		if len(arguments) != 0 {
+			return usageError(flags, command+" takes no arguments")
+		}
+	case "diff":
+		if len(arguments) != 1 {
+			return usageError(flags, "diff requires a migration name")
+		}
+	case "down", "goto", "baseline":
+		if len(arguments) > 1 || (command == "goto" && len(arguments) == 0) {
+			return usageError(flags, command+" takes a single version or count")
+		}
+		if command == "down" {
+			n = 1
+		}
+		if len(arguments) == 1 {
+			var err error
+			if n, err = strconv.Atoi(arguments[0]); err != nil || n < 0 || (command == "down" && n == 0) {
+				return usageError(flags, fmt.Sprintf("invalid number %q", arguments[0]))
+			}
+		}
+	default:
+		return usageError(flags, fmt.Sprintf("unknown command %q", command))
+	}
+
+	db, err := config.InitDatabase(&cfg.Database)
+	if err != nil {
+		return failure(err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	// Cancel on interrupt so the current migration's transaction is rolled back
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	runner := postgres.NewMigrationRunner(db, *path)
+	runner.SetDryRun(*dryRun)
+
+	var steps []postgres.MigrationStep
+	switch command {
+	case "status":
+		return printStatus(ctx, runner)
+	case "diff":
+		return generateDiff(ctx, postgres.NewSchemaDiffer(db, models.AllModels()...), manager, arguments[0], *dryRun)
+	case "up":
+		steps, err = runner.Up(ctx)
+	case "down":
+		steps, err = runner.Down(ctx, n)
+	case "goto":
+		steps, err = runner.To(ctx, n)
+	case "baseline":
+		steps, err = runner.Baseline(ctx, n)
+	}
+	printSteps(command, steps, *dryRun)
+	if err != nil {
+		return failure(err)
+	}
+	return exitOK
+}
+
+// printSteps prints the executed or, in dry-run mode, planned steps
+func printSteps(command string, steps []postgres.MigrationStep, dryRun bool) {
+	if len(steps) == 0 {
+		fmt.Println("No migrations to run")
+		return
+	}
+
+	verb := map[postgres.MigrationDirection]string{postgres.MigrationUp: "Applied", postgres.MigrationDown: "Rolled back"}
+	if command == "baseline" {
+		verb[postgres.MigrationUp] = "Marked as applied"
+	}
+	for _, step := range steps {
+		prefix := verb[step.Direction]
+		if dryRun {
+			prefix = "Would run: " + strings.ToLower(prefix)
+		}
+		fmt.Printf("%s %03d_%s\n", prefix, step.Version, step.Name)
+	}
+}
+
+// printStatus prints the migrations as a table; modified or missing migrations fail the command
+func printStatus(ctx context.Context, runner *postgres.MigrationRunner) int {
+	statuses, err := runner.Status(ctx)
+	if err != nil {
+		return failure(err)
+	}
+
+	healthy := true
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", "-"
+		if status.Applied {
+			state = "applied"
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+		}
+		switch {
+		case status.Missing:
+			state, healthy = "applied (file missing)", false
+		case status.Modified:
+			state, healthy = "applied (modified)", false
+		}
+		fmt.Fprintf(w, "%03d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	w.Flush()
+
+	if !healthy {
+		fmt.Fprintln(os.Stderr, "Error: applied migrations were modified or removed")
+		return exitFailure
+	}
+	return exitOK
+}
+
+// generateDiff writes the differences between the models and the database as a new migration,
+// or prints them in dry-run mode
+func generateDiff(ctx context.Context, differ *postgres.SchemaDiffer, manager *postgres.MigrationManager, name string, dryRun bool) int {
+	diff, err := differ.Diff(ctx)
+	if err != nil {
+		return failure(err)
+	}
+	for _, note := range diff.Notes {
+		fmt.Fprintf(os.Stderr, "Note: %s\n", note)
+	}
+	if diff.Empty() {
+		fmt.Println("The database schema matches the models")
+		return exitOK
+	}
+
+	if dryRun {
+		fmt.Printf("-- Up\n%s\n-- Down\n%s", diff.UpSQL(), diff.DownSQL())
+		return exitOK
+	}
+	upPath, downPath, err := manager.CreateMigrationWithSQL(name, diff.UpSQL(), diff.DownSQL())
+	if err != nil {
+		return failure(err)
+	}
+	fmt.Printf("Created %s\nCreated %s\nReview the generated SQL before applying it\n", upPath, downPath)
+	return exitOK
+}
+
+// failure prints err and returns the failure exit code
+func failure(err error) int {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	return exitFailure
+}
+
+// usageError prints message with the usage and returns the usage exit code
+func usageError(flags *flag.FlagSet, message string) int {
+	fmt.Fprintf(os.Stderr, "Error: %s\n\n", message)
+	flags.Usage()
+	return exitUsage
+}
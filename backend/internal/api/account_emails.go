@@ -0,0 +1,402 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"routrapp-api/internal/lockout"
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/mailer"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tenant"
+	"routrapp-api/internal/utils/auth"
+	"routrapp-api/internal/utils/constants"
+	"routrapp-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxResetOrganizations caps the reset emails sent for an address registered with several organizations
+const maxResetOrganizations = 10
+
+// errUserTokenUsed is returned when a token was redeemed by a concurrent request
+var errUserTokenUsed = errors.New("user token has already been used")
+
+// ForgotPassword handles POST /api/v1/auth/forgot-password
+// The response is the same whether or not the email is registered, so it can't be used to discover accounts.
+// Requests are throttled per address and per IP address, and the emails are sent in the background so the
+// response time doesn't depend on the mail server either.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req validation.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	// Unregistered addresses are counted too, so throttling doesn't reveal which accounts exist
+	status, err := h.options.Lockout.Throttle(c.Request.Context(), lockout.EmailKey(req.Email), c.ClientIP())
+	if err != nil {
+		// Without the counters the requests can't be throttled, so no email is sent
+		logger.WithContext(c).Errorf("Failed to throttle password reset request: %v", err)
+		respondWithError(c, http.StatusServiceUnavailable, "Password reset is temporarily unavailable; try again later", "LOCKOUT_UNAVAILABLE")
+		return
+	}
+	if !status.Allowed() {
+		logger.WithContext(c).Warnf("Password reset refused: %s must wait %s after repeated requests", c.ClientIP(), status.RetryAfter.Round(time.Second))
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(status.RetryAfter.Seconds()))))
+		respondWithError(c, http.StatusTooManyRequests, "Too many password reset requests; try again later", "TOO_MANY_REQUESTS")
+		return
+	}
+
+	organizationIDs, err := h.emailOrganizations(c, req.Email, req.SubDomain, maxResetOrganizations)
+	if err != nil {
+		logger.WithContext(c).Errorf("Database error resolving organizations for password reset: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+		return
+	}
+
+	// An address registered with several organizations gets a link for each of its accounts
+	for _, organizationID := range organizationIDs {
+		db := h.db.WithContext(tenant.WithOrganization(c.Request.Context(), organizationID))
+
+		var user models.User
+		if err := db.Where("email = ? AND active = ?", req.Email, true).First(&user).Error; err != nil {
+			if err != gorm.ErrRecordNotFound {
+				logger.WithContext(c).Errorf("Database error finding user for password reset: %v", err)
+			}
+			continue
+		}
+		var organization models.Organization
+		if err := db.Select("id", "name").First(&organization, organizationID).Error; err != nil {
+			logger.WithContext(c).Errorf("Failed to load organization %d for password reset: %v", organizationID, err)
+			continue
+		}
+
+		token, err := issueUserToken(db, user, models.UserTokenPasswordReset, constants.PasswordResetTokenExpiry)
+		if err != nil {
+			logger.WithContext(c).Errorf("Failed to issue password reset token for user %d: %v", user.ID, err)
+			continue
+		}
+		message := mailer.Message{
+			To:      user.Email,
+			Subject: fmt.Sprintf("Reset your %s password", organization.Name),
+			Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your %s account. "+
+				"To choose a new password, open this link within %s:\n\n%s\n\n"+
+				"If you didn't ask for this, you can ignore this email; your password won't change.\n",
+				user.FirstName, organization.Name, formatExpiry(constants.PasswordResetTokenExpiry),
+				h.accountLink("/reset-password", token)),
+		}
+		if err := h.queueMail(c, message); err != nil {
+			logger.WithContext(c).Errorf("Failed to queue password reset email to user %d: %v", user.ID, err)
+			continue
+		}
+		logger.WithContext(c).Infof("Password reset link queued for user %d", user.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "If the email address is registered, a password reset link has been sent to it",
+	})
+}
+
+// ResetPassword handles POST /api/v1/auth/reset-password
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req validation.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+	if err := auth.ValidatePassword(req.NewPassword); err != nil {
+		respondWithError(c, http.StatusBadRequest, "New password does not meet security requirements: "+err.Error(), "WEAK_PASSWORD")
+		return
+	}
+	if auth.IsCommonPassword(req.NewPassword) {
+		respondWithError(c, http.StatusBadRequest, "New password is too common, please choose a more secure password", "COMMON_PASSWORD")
+		return
+	}
+
+	userToken, user, ok := h.redeemableToken(c, req.Token, models.UserTokenPasswordReset, "Invalid or expired password reset token", "INVALID_RESET_TOKEN")
+	if !ok {
+		return
+	}
+	if !user.Active {
+		logger.WithContext(c).Warnf("Password reset failed: user %d is inactive", user.ID)
+		respondWithError(c, http.StatusForbidden, "Account is disabled", "ACCOUNT_DISABLED")
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to hash new password: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to process new password", "PASSWORD_PROCESSING_ERROR")
+		return
+	}
+
+	// Set the password and sign out every session, e.g. of whoever the password leaked to
+	now := time.Now()
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := markUserTokenUsed(tx, userToken); err != nil {
+			return err
+		}
+		updates := map[string]interface{}{
+			"password":   hashedPassword,
+			"updated_at": now,
+		}
+		// Following the link proves the address, sparing its owner a separate verification
+		if !user.IsEmailVerified() && user.Email == userToken.Email {
+			updates["email_verified_at"] = now
+		}
+		if err := tx.Model(user).Updates(updates).Error; err != nil {
+			return err
+		}
+		_, err := revokeSessions(tx, user.ID, models.SessionRevokedPasswordReset)
+		return err
+	})
+	if err == errUserTokenUsed {
+		respondWithError(c, http.StatusBadRequest, "Invalid or expired password reset token", "INVALID_RESET_TOKEN")
+		return
+	}
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to reset password of user %d: %v", user.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to update password", "PASSWORD_UPDATE_ERROR")
+		return
+	}
+	revokeUserTokens(c, user.ID)
+
+	logger.WithContext(c).Infof("Password reset for user %d", user.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Password has been reset. Please log in again.",
+	})
+}
+
+// VerifyEmail handles POST /api/v1/auth/verify-email
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req validation.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	userToken, user, ok := h.redeemableToken(c, req.Token, models.UserTokenEmailVerification, "Invalid or expired verification token", "INVALID_VERIFICATION_TOKEN")
+	if !ok {
+		return
+	}
+	// A link sent before the address was changed doesn't verify the new one
+	if user.Email != userToken.Email {
+		logger.WithContext(c).Warnf("Email verification failed: user %d changed their address since the token was sent", user.ID)
+		respondWithError(c, http.StatusBadRequest, "Invalid or expired verification token", "INVALID_VERIFICATION_TOKEN")
+		return
+	}
+
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := markUserTokenUsed(tx, userToken); err != nil {
+			return err
+		}
+		if user.IsEmailVerified() {
+			return nil
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		return tx.Model(user).Update("email_verified_at", now).Error
+	})
+	if err == errUserTokenUsed {
+		respondWithError(c, http.StatusBadRequest, "Invalid or expired verification token", "INVALID_VERIFICATION_TOKEN")
+		return
+	}
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to verify email of user %d: %v", user.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to verify email address", "INTERNAL_ERROR")
+		return
+	}
+
+	logger.WithContext(c).Infof("Email address verified for user %d", user.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newUserResponse(*user),
+		"message": "Email address verified",
+	})
+}
+
+// ResendVerificationEmail handles POST /api/v1/auth/resend-verification
+func (h *AuthHandler) ResendVerificationEmail(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		respondWithError(c, http.StatusUnauthorized, "Authentication required", "AUTHENTICATION_REQUIRED")
+		return
+	}
+
+	var user models.User
+	if err := requestDB(c, h.db).First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondWithError(c, http.StatusNotFound, "User not found", "USER_NOT_FOUND")
+			return
+		}
+		logger.WithContext(c).Errorf("Database error loading user %d: %v", userID, err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+		return
+	}
+	if user.IsEmailVerified() {
+		respondWithError(c, http.StatusConflict, "Email address is already verified", "EMAIL_ALREADY_VERIFIED")
+		return
+	}
+
+	if err := h.sendVerificationEmail(c, requestDB(c, h.db), user); err != nil {
+		logger.WithContext(c).Errorf("Failed to send verification email to user %d: %v", user.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to send verification email", "EMAIL_SEND_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Verification email sent",
+	})
+}
+
+// sendVerificationEmail emails the user a link confirming their address. db must be scoped to the
+// user's organization.
+func (h *AuthHandler) sendVerificationEmail(c *gin.Context, db *gorm.DB, user models.User) error {
+	token, err := issueUserToken(db, user, models.UserTokenEmailVerification, constants.EmailVerificationTokenExpiry)
+	if err != nil {
+		return err
+	}
+	message := mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening this link within %s:\n\n%s\n\n"+
+			"If you didn't create an account, you can ignore this email.\n",
+			user.FirstName, formatExpiry(constants.EmailVerificationTokenExpiry), h.accountLink("/verify-email", token)),
+	}
+	if err := h.sendMail(c, message); err != nil {
+		return err
+	}
+	logger.WithContext(c).Infof("Verification email sent to user %d", user.ID)
+	return nil
+}
+
+// redeemableToken looks up an emailed token and its user, and scopes the request to their organization.
+// The lookup by hash is unscoped since the token is all the request carries. It writes the error
+// response itself and returns false when the token is unknown, used or expired.
+func (h *AuthHandler) redeemableToken(c *gin.Context, token string, purpose models.UserTokenPurpose, message, code string) (*models.UserToken, *models.User, bool) {
+	var userToken models.UserToken
+	if err := h.db.WithContext(tenant.WithoutScope(c.Request.Context())).
+		Where("token_hash = ? AND purpose = ?", auth.HashToken(token), purpose).
+		First(&userToken).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			logger.WithContext(c).Warnf("Unknown %s token presented", purpose)
+			respondWithError(c, http.StatusBadRequest, message, code)
+			return nil, nil, false
+		}
+		logger.WithContext(c).Errorf("Database error loading %s token: %v", purpose, err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+		return nil, nil, false
+	}
+	if !userToken.IsRedeemable(time.Now()) {
+		logger.WithContext(c).Warnf("Used or expired %s token presented for user %d", purpose, userToken.UserID)
+		respondWithError(c, http.StatusBadRequest, message, code)
+		return nil, nil, false
+	}
+
+	middleware.SetRequestTenant(c, userToken.OrganizationID)
+
+	var user models.User
+	if err := requestDB(c, h.db).Preload("Role").First(&user, userToken.UserID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondWithError(c, http.StatusBadRequest, message, code)
+			return nil, nil, false
+		}
+		logger.WithContext(c).Errorf("Database error loading user %d: %v", userToken.UserID, err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+		return nil, nil, false
+	}
+	return &userToken, &user, true
+}
+
+// issueUserToken creates a token for the user and returns it. Earlier unused tokens for the same purpose
+// are retired, so only the most recent email works. db must be scoped to the user's organization.
+func issueUserToken(db *gorm.DB, user models.User, purpose models.UserTokenPurpose, expiry time.Duration) (string, error) {
+	token, err := auth.NewRandomToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, purpose).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.UserToken{
+			Base: models.Base{
+				OrganizationID: user.OrganizationID,
+			},
+			UserID:    user.ID,
+			Purpose:   purpose,
+			TokenHash: auth.HashToken(token),
+			Email:     user.Email,
+			ExpiresAt: now.Add(expiry),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// markUserTokenUsed redeems the token. Being unused is part of the condition, so of two requests
+// racing with the same token only one redeems it.
+func markUserTokenUsed(tx *gorm.DB, userToken *models.UserToken) error {
+	result := tx.Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL", userToken.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errUserTokenUsed
+	}
+	return nil
+}
+
+// sendMail delivers an account email; without a mailer configured the email is dropped with a warning
+func (h *AuthHandler) sendMail(c *gin.Context, message mailer.Message) error {
+	if h.mailer == nil {
+		logger.WithContext(c).Warnf("No mailer configured, dropping email %q to %s", message.Subject, message.To)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), constants.MailSendTimeout)
+	defer cancel()
+	return h.mailer.Send(ctx, message)
+}
+
+// queueMail sends an account email in the background when a mail queue is configured, and like sendMail
+// otherwise
+func (h *AuthHandler) queueMail(c *gin.Context, message mailer.Message) error {
+	if h.options.MailQueue == nil {
+		return h.sendMail(c, message)
+	}
+	return h.options.MailQueue.Send(c.Request.Context(), message)
+}
+
+// accountLink builds the frontend link carrying an emailed token
+func (h *AuthHandler) accountLink(path, token string) string {
+	return strings.TrimRight(h.options.LinkBaseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// formatExpiry describes a token lifetime in an email, e.g. "1 hour" or "48 hours"
+func formatExpiry(d time.Duration) string {
+	hours := int(d / time.Hour)
+	if hours == 1 {
+		return "1 hour"
+	}
+	return fmt.Sprintf("%d hours", hours)
+}
@@ -0,0 +1,519 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/storage"
+	"routrapp-api/internal/tenant"
+	"routrapp-api/internal/utils/exif"
+	"routrapp-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// attachmentTypes maps the accepted (sniffed) content types to their attachment kind and file extension
+var attachmentTypes = map[string]struct {
+	kind      models.AttachmentKind
+	extension string
+}{
+	"image/jpeg":      {models.AttachmentKindPhoto, ".jpg"},
+	"image/png":       {models.AttachmentKindPhoto, ".png"},
+	"image/webp":      {models.AttachmentKindPhoto, ".webp"},
+	"application/pdf": {models.AttachmentKindDocument, ".pdf"},
+}
+
+// multipartOverhead is the allowance for multipart headers and boundaries on top of the file itself
+const multipartOverhead = 1 << 20
+
+// AttachmentOptions holds the upload limits enforced by the attachment handler
+type AttachmentOptions struct {
+	MaxUploadSize int64 // in bytes, per file
+	DefaultQuota  int64 // in bytes, for organizations without their own quota
+}
+
+// AttachmentHandler handles stop photo and document uploads
+type AttachmentHandler struct {
+	db      *gorm.DB
+	routes  *RouteHandler
+	storage storage.Storage
+	signer  *storage.URLSigner
+	options AttachmentOptions
+}
+
+// NewAttachmentHandler creates a new attachment handler storing blobs in store
+func NewAttachmentHandler(db *gorm.DB, store storage.Storage, signer *storage.URLSigner, options AttachmentOptions) *AttachmentHandler {
+	return &AttachmentHandler{
+		db:      db,
+		routes:  NewRouteHandler(db),
+		storage: store,
+		signer:  signer,
+		options: options,
+	}
+}
+
+// UploadStopAttachment handles POST /api/v1/routes/:id/stops/:stop_id/attachments
+// The file is sent as multipart/form-data in the "file" field.
+func (h *AttachmentHandler) UploadStopAttachment(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	route, stop, ok := h.loadStop(c, organizationID)
+	if !ok {
+		return
+	}
+
+	if !middleware.HasPermission(c, "routes.update") && !h.routes.isAssignedTechnician(c, route) {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.options.MaxUploadSize+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.respondFileTooLarge(c)
+			return
+		}
+		respondWithError(c, http.StatusBadRequest, "A file is required in the \"file\" form field", "FILE_REQUIRED")
+		return
+	}
+	if header.Size > h.options.MaxUploadSize {
+		h.respondFileTooLarge(c)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to open uploaded file: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Failed to read uploaded file", "INVALID_FILE")
+		return
+	}
+	defer file.Close()
+
+	contentType, err := sniffContentType(file)
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to read uploaded file: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Failed to read uploaded file", "INVALID_FILE")
+		return
+	}
+	fileType, supported := attachmentTypes[contentType]
+	if !supported {
+		respondWithErrorDetails(c, http.StatusUnsupportedMediaType, "Unsupported file type "+contentType, "UNSUPPORTED_MEDIA_TYPE", map[string]interface{}{
+			"content_type":  contentType,
+			"allowed_types": []string{"image/jpeg", "image/png", "image/webp", "application/pdf"},
+		})
+		return
+	}
+
+	if !h.withinQuota(c, organizationID, header.Size) {
+		return
+	}
+
+	attachment := models.StopAttachment{
+		Base: models.Base{
+			OrganizationID: organizationID,
+		},
+		RouteID:     route.ID,
+		RouteStopID: stop.ID,
+		Kind:        fileType.kind,
+		FileName:    attachmentFileName(header),
+		ContentType: contentType,
+	}
+	attachment.UploadedByID, _ = middleware.GetUserID(c)
+
+	if contentType == "image/jpeg" {
+		if metadata, err := exif.Decode(io.NewSectionReader(file, 0, header.Size)); err == nil {
+			attachment.CapturedAt = metadata.CapturedAt
+			attachment.Lat = metadata.Lat
+			attachment.Lng = metadata.Lng
+		}
+	}
+
+	if !h.putBlob(c, &attachment, fileType.extension, io.NewSectionReader(file, 0, header.Size)) {
+		return
+	}
+
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := h.reserveQuota(tx, organizationID, attachment.Size); err != nil {
+			return err
+		}
+		if err := tx.Create(&attachment).Error; err != nil {
+			return err
+		}
+		if attachment.Kind != models.AttachmentKindPhoto {
+			return nil
+		}
+		return tx.Model(&models.RouteStop{}).Where("id = ?", stop.ID).
+			Update("photos_count", gorm.Expr("photos_count + ?", 1)).Error
+	})
+	var exceeded *quotaExceededError
+	if errors.As(err, &exceeded) {
+		h.deleteBlob(c, attachment.StorageKey)
+		h.respondQuotaError(c, organizationID, err)
+		return
+	}
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to save attachment for stop %d: %v", stop.ID, err)
+		h.deleteBlob(c, attachment.StorageKey)
+		respondWithError(c, http.StatusInternalServerError, "Failed to save attachment", "ATTACHMENT_CREATION_ERROR")
+		return
+	}
+
+	logger.WithContext(c).Infof("Stored %s attachment %d (%d bytes) for stop %d", attachment.Kind, attachment.ID, attachment.Size, stop.ID)
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    h.newStopAttachmentResponse(attachment),
+		"message": "File uploaded successfully",
+	})
+}
+
+// ListStopAttachments handles GET /api/v1/routes/:id/stops/:stop_id/attachments
+func (h *AttachmentHandler) ListStopAttachments(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	_, stop, ok := h.loadStop(c, organizationID)
+	if !ok {
+		return
+	}
+
+	var attachments []models.StopAttachment
+	if err := requestDB(c, h.db).
+		Where("organization_id = ? AND route_stop_id = ?", organizationID, stop.ID).
+		Order("created_at ASC, id ASC").
+		Find(&attachments).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to list attachments for stop %d: %v", stop.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch attachments", "DATABASE_ERROR")
+		return
+	}
+
+	responses := make([]validation.StopAttachmentResponse, 0, len(attachments))
+	for _, attachment := range attachments {
+		responses = append(responses, h.newStopAttachmentResponse(attachment))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    responses,
+	})
+}
+
+// DeleteStopAttachment handles DELETE /api/v1/routes/:id/stops/:stop_id/attachments/:attachment_id
+func (h *AttachmentHandler) DeleteStopAttachment(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	_, stop, ok := h.loadStop(c, organizationID)
+	if !ok {
+		return
+	}
+
+	attachmentID, ok := parseIDParam(c, "attachment_id")
+	if !ok {
+		return
+	}
+
+	var attachment models.StopAttachment
+	if err := requestDB(c, h.db).Where("id = ? AND organization_id = ? AND route_stop_id = ?", attachmentID, organizationID, stop.ID).First(&attachment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondWithError(c, http.StatusNotFound, "Attachment not found", "ATTACHMENT_NOT_FOUND")
+			return
+		}
+		logger.WithContext(c).Errorf("Database error fetching attachment %d: %v", attachmentID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch attachment", "DATABASE_ERROR")
+		return
+	}
+
+	// Signatures are part of a proof-of-service record and are kept with it
+	if attachment.Kind == models.AttachmentKindSignature {
+		respondWithError(c, http.StatusConflict, "Signatures cannot be deleted from a proof-of-service record", "ATTACHMENT_IN_USE")
+		return
+	}
+
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&attachment).Error; err != nil {
+			return err
+		}
+		if attachment.Kind != models.AttachmentKindPhoto {
+			return nil
+		}
+		return tx.Model(&models.RouteStop{}).Where("id = ? AND photos_count > 0", stop.ID).
+			Update("photos_count", gorm.Expr("photos_count - ?", 1)).Error
+	})
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to delete attachment %d: %v", attachmentID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to delete attachment", "ATTACHMENT_DELETION_ERROR")
+		return
+	}
+	h.deleteBlob(c, attachment.StorageKey)
+
+	logger.WithContext(c).Infof("Deleted attachment %d from stop %d", attachmentID, stop.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Attachment deleted successfully",
+	})
+}
+
+// DownloadAttachment handles GET /api/v1/attachments/:id/content
+// It requires no Authorization header: access is granted by the signed URL's expires and signature parameters.
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	attachmentID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if !h.signer.Verify(attachmentID, c.Query("expires"), c.Query("signature"), time.Now()) {
+		logger.WithContext(c).Warnf("Rejected download of attachment %d with an invalid or expired signature", attachmentID)
+		respondWithError(c, http.StatusForbidden, "Download link is invalid or has expired", "INVALID_SIGNATURE")
+		return
+	}
+
+	// The signature, not a bearer token, authorizes the download, so the attachment is
+	// looked up without a tenant scope
+	var attachment models.StopAttachment
+	if err := h.db.WithContext(tenant.WithoutScope(c.Request.Context())).First(&attachment, attachmentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondWithError(c, http.StatusNotFound, "Attachment not found", "ATTACHMENT_NOT_FOUND")
+			return
+		}
+		logger.WithContext(c).Errorf("Database error fetching attachment %d: %v", attachmentID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch attachment", "DATABASE_ERROR")
+		return
+	}
+
+	blob, err := h.storage.Open(c.Request.Context(), attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			logger.WithContext(c).Errorf("Blob for attachment %d is missing from storage", attachmentID)
+			respondWithError(c, http.StatusNotFound, "Attachment not found", "ATTACHMENT_NOT_FOUND")
+			return
+		}
+		logger.WithContext(c).Errorf("Failed to open attachment %d: %v", attachmentID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to read attachment", "STORAGE_ERROR")
+		return
+	}
+	defer blob.Close()
+
+	disposition := "attachment"
+	if attachment.Kind == models.AttachmentKindPhoto {
+		disposition = "inline"
+	}
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, blob, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}),
+		"ETag":                   `"` + attachment.Checksum + `"`,
+		"Cache-Control":          "private",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// loadStop loads the route from the :id parameter and its stop from the :stop_id parameter.
+// It writes the error response itself and returns false when either cannot be loaded.
+func (h *AttachmentHandler) loadStop(c *gin.Context, organizationID uint) (*models.Route, *models.RouteStop, bool) {
+	routeID, ok := parseIDParam(c, "id")
+	if !ok {
+		return nil, nil, false
+	}
+	stopID, ok := parseIDParam(c, "stop_id")
+	if !ok {
+		return nil, nil, false
+	}
+
+	route, ok := h.routes.loadRoute(c, organizationID, routeID)
+	if !ok {
+		return nil, nil, false
+	}
+	for i := range route.Stops {
+		if route.Stops[i].ID == stopID {
+			return route, &route.Stops[i], true
+		}
+	}
+
+	respondWithError(c, http.StatusNotFound, "Stop not found", "STOP_NOT_FOUND")
+	return nil, nil, false
+}
+
+// withinQuota checks that storing size more bytes keeps the organization within its storage quota,
+// writing the error response itself otherwise. It rejects uploads early, before their file is stored;
+// the check that counts is repeated with reserveQuota when the attachment is saved.
+func (h *AttachmentHandler) withinQuota(c *gin.Context, organizationID uint, size int64) bool {
+	err := h.checkQuota(requestDB(c, h.db), organizationID, size)
+	if err != nil {
+		h.respondQuotaError(c, organizationID, err)
+		return false
+	}
+	return true
+}
+
+// reserveQuota checks the quota like withinQuota within the transaction saving an attachment. The
+// organization's row is locked first, so that concurrent uploads are counted one after the other
+// instead of all fitting in the space left before any of them was saved.
+func (h *AttachmentHandler) reserveQuota(tx *gorm.DB, organizationID uint, size int64) error {
+	return h.checkQuota(tx.Clauses(clause.Locking{Strength: "UPDATE"}), organizationID, size)
+}
+
+// quotaExceededError is returned when storing an attachment would take the organization over its quota
+type quotaExceededError struct {
+	quota int64
+	used  int64
+	size  int64
+}
+
+func (e *quotaExceededError) Error() string {
+	return fmt.Sprintf("storage quota exceeded: %d of %d bytes used, %d more requested", e.used, e.quota, e.size)
+}
+
+// checkQuota returns a quotaExceededError when storing size more bytes takes the organization over its
+// storage quota. The organization is read through db, which may carry a locking clause.
+func (h *AttachmentHandler) checkQuota(db *gorm.DB, organizationID uint, size int64) error {
+	var organization models.Organization
+	if err := db.Select("id", "storage_quota").First(&organization, organizationID).Error; err != nil {
+		return fmt.Errorf("loading organization %d: %w", organizationID, err)
+	}
+	quota := organization.StorageQuota
+	if quota <= 0 {
+		quota = h.options.DefaultQuota
+	}
+
+	var used int64
+	if err := db.Session(&gorm.Session{NewDB: true}).Model(&models.StopAttachment{}).
+		Where("organization_id = ?", organizationID).
+		Select("COALESCE(SUM(size), 0)").
+		Scan(&used).Error; err != nil {
+		return fmt.Errorf("summing storage usage of organization %d: %w", organizationID, err)
+	}
+
+	if used+size > quota {
+		return &quotaExceededError{quota: quota, used: used, size: size}
+	}
+	return nil
+}
+
+// respondQuotaError writes the error response for a failed quota check
+func (h *AttachmentHandler) respondQuotaError(c *gin.Context, organizationID uint, err error) {
+	var exceeded *quotaExceededError
+	if !errors.As(err, &exceeded) {
+		logger.WithContext(c).Errorf("Failed to check the storage quota of organization %d: %v", organizationID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to check storage quota", "DATABASE_ERROR")
+		return
+	}
+	logger.WithContext(c).Warnf("Organization %d is over its storage quota (%d of %d bytes used)", organizationID, exceeded.used, exceeded.quota)
+	respondWithErrorDetails(c, http.StatusRequestEntityTooLarge, "Organization storage quota exceeded", "STORAGE_QUOTA_EXCEEDED", map[string]interface{}{
+		"quota": exceeded.quota,
+		"used":  exceeded.used,
+		"size":  exceeded.size,
+	})
+}
+
+// putBlob stores the blob read from r under a new storage key, recording the key, size and checksum
+// on the attachment. It writes the error response itself and returns false when storing fails.
+func (h *AttachmentHandler) putBlob(c *gin.Context, attachment *models.StopAttachment, extension string, r io.Reader) bool {
+	key, err := attachmentStorageKey(attachment.OrganizationID, attachment.RouteID, attachment.RouteStopID, extension)
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to generate storage key: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to store file", "STORAGE_ERROR")
+		return false
+	}
+
+	checksum := sha256.New()
+	size, err := h.storage.Put(c.Request.Context(), key, io.TeeReader(r, checksum))
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to store attachment for stop %d: %v", attachment.RouteStopID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to store file", "STORAGE_ERROR")
+		return false
+	}
+
+	attachment.StorageKey = key
+	attachment.Size = size
+	attachment.Checksum = hex.EncodeToString(checksum.Sum(nil))
+	return true
+}
+
+// respondFileTooLarge writes the error response for files above the upload limit
+func (h *AttachmentHandler) respondFileTooLarge(c *gin.Context) {
+	respondWithErrorDetails(c, http.StatusRequestEntityTooLarge, "File exceeds the maximum upload size", "FILE_TOO_LARGE", map[string]interface{}{
+		"max_size": h.options.MaxUploadSize,
+	})
+}
+
+// deleteBlob removes a blob that is no longer referenced, logging failures since the caller has already succeeded or failed
+func (h *AttachmentHandler) deleteBlob(c *gin.Context, key string) {
+	if err := h.storage.Delete(c.Request.Context(), key); err != nil {
+		logger.WithContext(c).Errorf("Failed to delete blob %s: %v", key, err)
+	}
+}
+
+// newStopAttachmentResponse converts an attachment model into its API representation with a fresh signed download URL
+func (h *AttachmentHandler) newStopAttachmentResponse(attachment models.StopAttachment) validation.StopAttachmentResponse {
+	downloadURL, expiresAt := h.signer.SignedURL(fmt.Sprintf("/api/v1/attachments/%d/content", attachment.ID), attachment.ID, time.Now())
+	return validation.StopAttachmentResponse{
+		ID:           attachment.ID,
+		RouteID:      attachment.RouteID,
+		RouteStopID:  attachment.RouteStopID,
+		UploadedByID: attachment.UploadedByID,
+		Kind:         attachment.Kind,
+		FileName:     attachment.FileName,
+		ContentType:  attachment.ContentType,
+		Size:         attachment.Size,
+		Checksum:     attachment.Checksum,
+		CapturedAt:   attachment.CapturedAt,
+		Lat:          attachment.Lat,
+		Lng:          attachment.Lng,
+		DownloadURL:  downloadURL,
+		URLExpiresAt: expiresAt,
+		CreatedAt:    attachment.CreatedAt,
+	}
+}
+
+// sniffContentType detects the content type from the file's leading bytes rather than trusting the client's header
+func sniffContentType(file multipart.File) (string, error) {
+	head := make([]byte, 512)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	contentType, _, _ := strings.Cut(http.DetectContentType(head[:n]), ";")
+	return contentType, nil
+}
+
+// attachmentFileName returns the base name of the uploaded file, trimmed to fit the column
+func attachmentFileName(header *multipart.FileHeader) string {
+	name := filepath.Base(strings.ReplaceAll(header.Filename, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		name = "upload"
+	}
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	return name
+}
+
+// attachmentStorageKey builds a unique, unguessable storage key for a stop attachment
+func attachmentStorageKey(organizationID, routeID, stopID uint, extension string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("organizations/%d/routes/%d/stops/%d/%s%s", organizationID, routeID, stopID, hex.EncodeToString(random), extension), nil
+}
@@ -6,9 +6,12 @@ import (
 	"time"
 
 	"routrapp-api/internal/errors"
+	"routrapp-api/internal/lockout"
 	"routrapp-api/internal/logger"
+	"routrapp-api/internal/mailer"
 	"routrapp-api/internal/middleware"
 	"routrapp-api/internal/models"
+	"routrapp-api/internal/tenant"
 	"routrapp-api/internal/utils/auth"
 	"routrapp-api/internal/utils/constants"
 	"routrapp-api/internal/validation"
@@ -21,21 +24,45 @@ import (
 type AuthHandler struct {
 	db         *gorm.DB
 	jwtService *auth.JWTService
+	mailer     mailer.Mailer
+	options    AuthOptions
+	mfaSecrets *auth.SecretBox
+}
+
+// AuthOptions configures the emails, second factors and login throttling of the auth handler
+type AuthOptions struct {
+	LinkBaseURL  string         // frontend URL the /reset-password and /verify-email pages are served from
+	MFAIssuer    string         // shown next to the account in authenticator apps
+	MFASecretKey string         // encrypts stored TOTP secrets; defaults to the JWT secret
+	Lockout      *lockout.Guard // throttles failed logins and password reset requests; nil allows every attempt
+	MailQueue    *mailer.Queue  // sends password reset emails in the background; nil sends them before responding
 }
 
 // NewAuthHandler creates a new auth handler with default JWT service
 func NewAuthHandler(db *gorm.DB) *AuthHandler {
-	return &AuthHandler{
-		db:         db,
-		jwtService: auth.DefaultJWTService(),
-	}
+	return NewAuthHandlerWithMailer(db, auth.DefaultJWTService(), nil, AuthOptions{})
 }
 
 // NewAuthHandlerWithJWT creates a new auth handler with provided JWT service
 func NewAuthHandlerWithJWT(db *gorm.DB, jwtService *auth.JWTService) *AuthHandler {
+	return NewAuthHandlerWithMailer(db, jwtService, nil, AuthOptions{})
+}
+
+// NewAuthHandlerWithMailer creates a new auth handler sending password reset and email verification
+// links through mail
+func NewAuthHandlerWithMailer(db *gorm.DB, jwtService *auth.JWTService, mail mailer.Mailer, options AuthOptions) *AuthHandler {
+	if options.MFAIssuer == "" {
+		options.MFAIssuer = constants.DefaultMFAIssuer
+	}
+	if options.MFASecretKey == "" {
+		options.MFASecretKey = constants.JWT_SECRET()
+	}
 	return &AuthHandler{
 		db:         db,
 		jwtService: jwtService,
+		mailer:     mail,
+		options:    options,
+		mfaSecrets: auth.NewSecretBox(options.MFASecretKey),
 	}
 }
 
@@ -88,9 +115,12 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	// Scope the registration to the requested organization
+	middleware.SetRequestTenant(c, req.TenantID)
+
 	// Verify organization exists
 	var organization models.Organization
-	if err := h.db.Where("id = ? AND active = ?", req.TenantID, true).First(&organization).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND active = ?", req.TenantID, true).First(&organization).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			logger.WithContext(c).Warnf("Registration failed: organization not found %d", req.TenantID)
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -119,7 +149,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	// Check if user already exists
 	var existingUser models.User
-	err := h.db.Where("organization_id = ? AND email = ?", req.TenantID, req.Email).First(&existingUser).Error
+	err := requestDB(c, h.db).Where("organization_id = ? AND email = ?", req.TenantID, req.Email).First(&existingUser).Error
 	if err == nil {
 		logger.WithContext(c).Warnf("Registration failed: email already exists %s", req.Email)
 		c.JSON(http.StatusConflict, gin.H{
@@ -148,7 +178,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	// Find the appropriate role
 	var role models.Role
-	if err := h.db.Where("organization_id = ? AND name = ? AND active = ?", req.TenantID, req.Role.String(), true).First(&role).Error; err != nil {
+	if err := requestDB(c, h.db).Where("organization_id = ? AND name = ? AND active = ?", req.TenantID, req.Role.String(), true).First(&role).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			logger.WithContext(c).Warnf("Registration failed: role not found %s for organization %d", req.Role.String(), req.TenantID)
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -204,7 +234,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Active:    true,
 	}
 
-	if err := h.db.Create(&user).Error; err != nil {
+	if err := requestDB(c, h.db).Create(&user).Error; err != nil {
 		logger.WithContext(c).Errorf("Failed to create user: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": errors.NewAppErrorWithDetails(
@@ -219,7 +249,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	// Load the role for response
-	if err := h.db.Preload("Role").First(&user, user.ID).Error; err != nil {
+	if err := requestDB(c, h.db).Preload("Role").First(&user, user.ID).Error; err != nil {
 		logger.WithContext(c).Errorf("Failed to load user role after creation: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": errors.NewAppErrorWithDetails(
@@ -240,11 +270,18 @@ func (h *AuthHandler) Register(c *gin.Context) {
 			CreatedAt: user.CreatedAt,
 			UpdatedAt: user.UpdatedAt,
 		},
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Active:    user.Active,
-		Role:      user.Role.Name.String(),
+		Email:         user.Email,
+		EmailVerified: user.IsEmailVerified(),
+		MFAEnabled:    user.IsMFAEnabled(),
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Active:        user.Active,
+		Role:          user.Role.Name.String(),
+	}
+
+	// The account is usable without a verified address, so a failed email doesn't fail the registration
+	if err := h.sendVerificationEmail(c, requestDB(c, h.db), user); err != nil {
+		logger.WithContext(c).Errorf("Failed to send verification email to user %d: %v", user.ID, err)
 	}
 
 	logger.WithContext(c).Infof("User %s registered successfully", req.Email)
@@ -352,7 +389,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 
 	// Find user and verify current password
 	var user models.User
-	if err := h.db.Where("id = ?", userID).First(&user).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ?", userID).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			logger.WithContext(c).Warnf("Password change failed: user not found %d", userID)
 			c.JSON(http.StatusNotFound, gin.H{
@@ -425,12 +462,17 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	// Update password in database and clear refresh tokens for security
-	if err := h.db.Model(&user).Updates(map[string]interface{}{
-		"password":      hashedPassword,
-		"refresh_token": "", // Force re-login for security
-		"updated_at":    time.Now(),
-	}).Error; err != nil {
+	// Update password in database and revoke every session for security
+	if err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password":   hashedPassword,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		_, err := revokeSessions(tx, user.ID, models.SessionRevokedPasswordChange)
+		return err
+	}); err != nil {
 		logger.WithContext(c).Errorf("Failed to update password: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": errors.NewAppErrorWithDetails(
@@ -443,6 +485,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		})
 		return
 	}
+	revokeUserTokens(c, user.ID)
 
 	logger.WithContext(c).Infof("Password changed successfully for user %d", userID)
 	c.JSON(http.StatusOK, gin.H{
@@ -470,11 +513,26 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	logger.WithContext(c).Infof("Login attempt for email: %s", req.Email)
 
-	// Find user by email
+	// Resolve the organization the user is signing in to
+	organizationID, ok := h.loginOrganization(c, req)
+	if !ok {
+		return
+	}
+	middleware.SetRequestTenant(c, organizationID)
+
+	// Refuse attempts while the account or address is locked out or backing off after failures
+	accountKey := lockout.AccountKey(organizationID, req.Email)
+	if !h.allowLoginAttempt(c, accountKey) {
+		return
+	}
+	defer h.releaseLoginAttempt(c)
+
+	// Find user by email within the organization
 	var user models.User
-	if err := h.db.Preload("Role").Where("email = ?", req.Email).First(&user).Error; err != nil {
+	if err := requestDB(c, h.db).Preload("Role").Where("organization_id = ? AND email = ?", organizationID, req.Email).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			logger.WithContext(c).Warnf("Login failed: user not found for email %s", req.Email)
+			h.recordLoginFailure(c, accountKey, nil)
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": errors.NewAppErrorWithDetails(
 					http.StatusUnauthorized,
@@ -517,6 +575,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	// Verify password
 	if err := auth.VerifyPassword(req.Password, user.Password); err != nil {
 		logger.WithContext(c).Warnf("Login failed: invalid password for email %s", req.Email)
+		h.recordLoginFailure(c, accountKey, &user)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": errors.NewAppErrorWithDetails(
 				http.StatusUnauthorized,
@@ -544,29 +603,25 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Generate tokens
-	accessToken, err := h.jwtService.GenerateAccessToken(user.ID, user.OrganizationID, user.Email, user.Role.Name.String())
-	if err != nil {
-		logger.WithContext(c).Errorf("Failed to generate access token: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewAppErrorWithDetails(
-				http.StatusInternalServerError,
-				"Failed to generate access token",
-				map[string]interface{}{
-					"code": "TOKEN_GENERATION_ERROR",
-				},
-			),
-		})
+	// Owners and users who set up two-factor authentication continue at /auth/mfa/verify
+	if h.challengeSecondFactor(c, user) {
 		return
 	}
 
-	refreshToken, err := h.jwtService.GenerateRefreshToken(user.ID, user.OrganizationID, user.Email, user.Role.Name.String())
+	h.completeLogin(c, user, req.DeviceName, nil)
+}
+
+// completeLogin starts a session for a user who proved their identity and responds with its tokens.
+// Recovery codes generated while signing in are included in the response.
+func (h *AuthHandler) completeLogin(c *gin.Context, user models.User, deviceName string, recoveryCodes []string) {
+	// Start a session for the device signing in
+	accessToken, refreshToken, err := h.startSession(c, requestDB(c, h.db), user, deviceName)
 	if err != nil {
-		logger.WithContext(c).Errorf("Failed to generate refresh token: %v", err)
+		logger.WithContext(c).Errorf("Failed to start session: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": errors.NewAppErrorWithDetails(
 				http.StatusInternalServerError,
-				"Failed to generate refresh token",
+				"Failed to generate tokens",
 				map[string]interface{}{
 					"code": "TOKEN_GENERATION_ERROR",
 				},
@@ -575,12 +630,16 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Update user's refresh token and last login time
+	// Forget the failed attempts before this login
+	if err := h.options.Lockout.Succeed(c.Request.Context(), lockout.AccountKey(user.OrganizationID, user.Email)); err != nil {
+		logger.WithContext(c).Errorf("Failed to reset failed logins of user %d: %v", user.ID, err)
+	}
+
+	// Update user's last login time
 	now := time.Now()
-	user.RefreshToken = refreshToken
 	user.LastLoginAt = &now
-	
-	if err := h.db.Save(&user).Error; err != nil {
+
+	if err := requestDB(c, h.db).Model(&user).Update("last_login_at", now).Error; err != nil {
 		logger.WithContext(c).Errorf("Failed to update user login info: %v", err)
 		// Don't fail the login for this, just log the error
 	}
@@ -592,22 +651,25 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			CreatedAt: user.CreatedAt,
 			UpdatedAt: user.UpdatedAt,
 		},
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Active:    user.Active,
-		Role:      user.Role.Name.String(),
+		Email:         user.Email,
+		EmailVerified: user.IsEmailVerified(),
+		MFAEnabled:    user.IsMFAEnabled(),
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Active:        user.Active,
+		Role:          user.Role.Name.String(),
 	}
 
 	loginResponse := validation.LoginResponse{
-		User:         userResponse,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		TokenType:    "Bearer",
-		ExpiresIn:    constants.JWT_ACCESS_TOKEN_EXPIRY,
+		User:          userResponse,
+		AccessToken:   accessToken,
+		RefreshToken:  refreshToken,
+		TokenType:     "Bearer",
+		ExpiresIn:     constants.JWT_ACCESS_TOKEN_EXPIRY,
+		RecoveryCodes: recoveryCodes,
 	}
 
-	logger.WithContext(c).Infof("User %s logged in successfully", req.Email)
+	logger.WithContext(c).Infof("User %s logged in successfully", user.Email)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    loginResponse,
@@ -615,10 +677,68 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	})
 }
 
+// loginOrganization resolves the organization a login is for: the tenant resolved from the request's
+// subdomain, then the sub_domain field, then the only organization the email is registered with.
+// It writes the error response itself and returns false when the organization cannot be resolved.
+// An email registered with several organizations is refused like an unknown one, so the response
+// doesn't tell whether an address is registered anywhere.
+func (h *AuthHandler) loginOrganization(c *gin.Context, req validation.UserLoginRequest) (uint, bool) {
+	organizationIDs, err := h.emailOrganizations(c, req.Email, req.SubDomain, 2)
+	if err != nil {
+		logger.WithContext(c).Errorf("Database error resolving login organization: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+		return 0, false
+	}
+
+	if len(organizationIDs) == 1 {
+		return organizationIDs[0], true
+	}
+	if len(organizationIDs) == 0 {
+		logger.WithContext(c).Warnf("Login failed: no organization found for email %s", req.Email)
+	} else {
+		logger.WithContext(c).Warnf("Login failed: email %s is registered with several organizations and no sub_domain was given", req.Email)
+	}
+	h.recordLoginFailure(c, "", nil)
+	respondWithError(c, http.StatusUnauthorized, "Invalid credentials", "INVALID_CREDENTIALS")
+	return 0, false
+}
+
+// emailOrganizations returns the organizations an email may belong to: the tenant resolved from the
+// request's subdomain, then the organization with the given subdomain, then up to limit organizations
+// the email is registered with. It returns none when the subdomain is unknown.
+func (h *AuthHandler) emailOrganizations(c *gin.Context, email, subDomain string, limit int) ([]uint, error) {
+	if tenantCtx, ok := middleware.GetTenantContext(c); ok && tenantCtx.OrganizationID != 0 {
+		return []uint{tenantCtx.OrganizationID}, nil
+	}
+
+	if subDomain != "" {
+		var organization models.Organization
+		if err := requestDB(c, h.db).Select("id").Where("sub_domain = ? AND active = ?", subDomain, true).First(&organization).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return []uint{organization.ID}, nil
+	}
+
+	// Without a tenant the email is looked up across organizations. This is the only unscoped
+	// query on the login and forgot-password paths.
+	var organizationIDs []uint
+	if err := h.db.WithContext(tenant.WithoutScope(c.Request.Context())).
+		Model(&models.User{}).
+		Where("email = ?", email).
+		Limit(limit).
+		Pluck("organization_id", &organizationIDs).Error; err != nil {
+		return nil, err
+	}
+	return organizationIDs, nil
+}
+
 // Logout handles POST /api/v1/auth/logout
 func (h *AuthHandler) Logout(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
-	userID, exists := c.Get("user_id")
+	userID, exists := middleware.GetUserID(c)
 	if !exists {
 		logger.WithContext(c).Error("User ID not found in context during logout")
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -635,9 +755,16 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 
 	logger.WithContext(c).Infof("Logout request for user ID: %v", userID)
 
-	// Clear refresh token from database
-	if err := h.db.Model(&models.User{}).Where("id = ?", userID).Update("refresh_token", "").Error; err != nil {
-		logger.WithContext(c).Errorf("Failed to clear refresh token during logout: %v", err)
+	// Revoke the session of the access token, or every session for tokens issued outside one
+	var err error
+	familyID, inSession := middleware.GetSessionFamilyID(c)
+	if inSession {
+		_, err = revokeSessions(requestDB(c, h.db), userID, models.SessionRevokedLogout, "family_id = ?", familyID)
+	} else {
+		_, err = revokeSessions(requestDB(c, h.db), userID, models.SessionRevokedLogout)
+	}
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to revoke session during logout: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": errors.NewAppErrorWithDetails(
 				http.StatusInternalServerError,
@@ -650,6 +777,16 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
+	// The access token is stateless, so it stays valid until it expires unless it is denied
+	if err := middleware.RevokeCurrentToken(c); err != nil {
+		logger.WithContext(c).Errorf("Failed to revoke the access token during logout: %v", err)
+	}
+	if inSession {
+		revokeSessionTokens(c, familyID)
+	} else {
+		revokeUserTokens(c, userID)
+	}
+
 	logger.WithContext(c).Infof("User %v logged out successfully", userID)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -678,7 +815,7 @@ func (h *AuthHandler) RegisterOrganization(c *gin.Context) {
 
 	// Check if subdomain is already taken
 	var existingOrg models.Organization
-	if err := h.db.Where("sub_domain = ? AND deleted_at IS NULL", req.SubDomain).First(&existingOrg).Error; err == nil {
+	if err := requestDB(c, h.db).Where("sub_domain = ? AND deleted_at IS NULL", req.SubDomain).First(&existingOrg).Error; err == nil {
 		logger.WithContext(c).Warnf("Registration failed: subdomain %s already exists", req.SubDomain)
 		c.JSON(http.StatusConflict, gin.H{
 			"error": errors.NewAppErrorWithDetails(
@@ -721,7 +858,7 @@ func (h *AuthHandler) RegisterOrganization(c *gin.Context) {
 	}
 
 	// Begin transaction for creating organization and user atomically
-	tx := h.db.Begin()
+	tx := requestDB(c, h.db).Begin()
 	if tx.Error != nil {
 		logger.WithContext(c).Errorf("Failed to begin transaction: %v", tx.Error)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -793,6 +930,10 @@ func (h *AuthHandler) RegisterOrganization(c *gin.Context) {
 		return
 	}
 
+	// Scope the rest of the registration to the new organization
+	middleware.SetRequestTenant(c, org.ID)
+	tx = tx.WithContext(c.Request.Context())
+
 	// Create default roles for the organization
 	ownerRole := models.Role{
 		Base: models.Base{
@@ -934,31 +1075,15 @@ func (h *AuthHandler) RegisterOrganization(c *gin.Context) {
 		return
 	}
 
-	// Generate tokens
-	accessToken, err := h.jwtService.GenerateAccessToken(user.ID, user.OrganizationID, user.Email, user.Role.Name.String())
-	if err != nil {
-		tx.Rollback()
-		logger.WithContext(c).Errorf("Failed to generate access token: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewAppErrorWithDetails(
-				http.StatusInternalServerError,
-				"Failed to generate access token",
-				map[string]interface{}{
-					"code": "TOKEN_GENERATION_ERROR",
-				},
-			),
-		})
-		return
-	}
-
-	refreshToken, err := h.jwtService.GenerateRefreshToken(user.ID, user.OrganizationID, user.Email, user.Role.Name.String())
+	// Start a session for the device registering
+	accessToken, refreshToken, err := h.startSession(c, tx, user, req.DeviceName)
 	if err != nil {
 		tx.Rollback()
-		logger.WithContext(c).Errorf("Failed to generate refresh token: %v", err)
+		logger.WithContext(c).Errorf("Failed to start session: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": errors.NewAppErrorWithDetails(
 				http.StatusInternalServerError,
-				"Failed to generate refresh token",
+				"Failed to generate tokens",
 				map[string]interface{}{
 					"code": "TOKEN_GENERATION_ERROR",
 				},
@@ -967,9 +1092,8 @@ func (h *AuthHandler) RegisterOrganization(c *gin.Context) {
 		return
 	}
 
-	// Update user's refresh token and last login time
+	// Update user's last login time
 	now := time.Now()
-	user.RefreshToken = refreshToken
 	user.LastLoginAt = &now
 	
 	if err := tx.Save(&user).Error; err != nil {
@@ -1007,6 +1131,9 @@ func (h *AuthHandler) RegisterOrganization(c *gin.Context) {
 		return
 	}
 
+	// A lookup of the subdomain before registration may have cached it as unknown
+	middleware.InvalidateTenant(c, org.SubDomain)
+
 	// Prepare response
 	userResponse := validation.UserResponse{
 		BaseResponse: validation.BaseResponse{
@@ -1014,11 +1141,13 @@ func (h *AuthHandler) RegisterOrganization(c *gin.Context) {
 			CreatedAt: user.CreatedAt,
 			UpdatedAt: user.UpdatedAt,
 		},
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Active:    user.Active,
-		Role:      user.Role.Name.String(),
+		Email:         user.Email,
+		EmailVerified: user.IsEmailVerified(),
+		MFAEnabled:    user.IsMFAEnabled(),
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Active:        user.Active,
+		Role:          user.Role.Name.String(),
 	}
 
 	orgResponse := validation.OrganizationResponse{
@@ -1032,6 +1161,7 @@ func (h *AuthHandler) RegisterOrganization(c *gin.Context) {
 		SecondaryColor: org.SecondaryColor,
 		Active:         org.Active,
 		PlanType:       org.PlanType,
+		RequireMFA:     org.RequireMFA,
 		CreatedAt:      org.CreatedAt,
 		UpdatedAt:      org.UpdatedAt,
 	}
@@ -1045,6 +1175,11 @@ func (h *AuthHandler) RegisterOrganization(c *gin.Context) {
 		ExpiresIn:    constants.JWT_ACCESS_TOKEN_EXPIRY,
 	}
 
+	// The account is usable without a verified address, so a failed email doesn't fail the registration
+	if err := h.sendVerificationEmail(c, requestDB(c, h.db), user); err != nil {
+		logger.WithContext(c).Errorf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+
 	logger.WithContext(c).Infof("User %s registered successfully for organization %s", req.Email, req.OrganizationName)
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
@@ -1103,9 +1238,19 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
+	// Refresh tokens are only accepted on their own organization's subdomain
+	if tenantCtx, ok := middleware.GetTenantContext(c); ok && tenantCtx.OrganizationID != 0 && tenantCtx.OrganizationID != claims.OrganizationID {
+		logger.WithContext(c).Warnf("Refresh token for organization %d presented for organization %d", claims.OrganizationID, tenantCtx.OrganizationID)
+		respondWithError(c, http.StatusForbidden, "Token was not issued for this organization", "TENANT_MISMATCH")
+		return
+	}
+
+	// Scope the refresh to the organization the token was issued for
+	middleware.SetRequestTenant(c, claims.OrganizationID)
+
 	// Find user first to check if they are active
 	var user models.User
-	if err := h.db.Preload("Role").Where("id = ?", claims.UserID).First(&user).Error; err != nil {
+	if err := requestDB(c, h.db).Preload("Role").Where("id = ? AND organization_id = ?", claims.UserID, claims.OrganizationID).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			logger.WithContext(c).Warnf("User not found for refresh token: %d", claims.UserID)
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -1147,21 +1292,6 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// Verify refresh token matches stored one
-	if user.RefreshToken != req.RefreshToken {
-		logger.WithContext(c).Warnf("Refresh token mismatch for user %d", claims.UserID)
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": errors.NewAppErrorWithDetails(
-				http.StatusUnauthorized,
-				"Invalid refresh token",
-				map[string]interface{}{
-					"code": "INVALID_REFRESH_TOKEN",
-				},
-			),
-		})
-		return
-	}
-
 	// Check if user has a valid role
 	if user.Role.Name == "" {
 		logger.WithContext(c).Errorf("User %d has no associated role during token refresh", user.ID)
@@ -1177,14 +1307,20 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// Generate new access token
-	accessToken, err := h.jwtService.GenerateAccessToken(user.ID, user.OrganizationID, user.Email, user.Role.Name.String())
+	// Find the session the token was issued for
+	session, ok := h.refreshSession(c, claims, req.RefreshToken)
+	if !ok {
+		return
+	}
+
+	// Rotate the session's tokens
+	accessToken, refreshToken, err := h.jwtService.GenerateSessionTokens(session.FamilyID, user.ID, user.OrganizationID, user.Email, user.Role.Name.String())
 	if err != nil {
-		logger.WithContext(c).Errorf("Failed to generate new access token: %v", err)
+		logger.WithContext(c).Errorf("Failed to generate new tokens: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": errors.NewAppErrorWithDetails(
 				http.StatusInternalServerError,
-				"Failed to generate access token",
+				"Failed to generate tokens",
 				map[string]interface{}{
 					"code": "TOKEN_GENERATION_ERROR",
 				},
@@ -1192,12 +1328,16 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		})
 		return
 	}
+	if !h.rotateSession(c, session, req.RefreshToken, refreshToken) {
+		return
+	}
 
 	// Prepare response
 	tokenResponse := validation.TokenResponse{
-		AccessToken: accessToken,
-		TokenType:   "Bearer",
-		ExpiresIn:   constants.JWT_ACCESS_TOKEN_EXPIRY,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    constants.JWT_ACCESS_TOKEN_EXPIRY,
 	}
 
 	logger.WithContext(c).Infof("Token refreshed successfully for user %d", user.ID)
@@ -1231,7 +1371,7 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 
 	// Fetch full user details from database
 	var user models.User
-	if err := h.db.Preload("Role").Where("id = ?", userID).First(&user).Error; err != nil {
+	if err := requestDB(c, h.db).Preload("Role").Where("id = ?", userID).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			logger.WithContext(c).Errorf("User not found in database: %d", userID)
 			c.JSON(http.StatusNotFound, gin.H{
@@ -1265,11 +1405,13 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 			CreatedAt: user.CreatedAt,
 			UpdatedAt: user.UpdatedAt,
 		},
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Active:    user.Active,
-		Role:      user.Role.Name.String(),
+		Email:         user.Email,
+		EmailVerified: user.IsEmailVerified(),
+		MFAEnabled:    user.IsMFAEnabled(),
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Active:        user.Active,
+		Role:          user.Role.Name.String(),
 	}
 
 	logger.WithContext(c).Infof("User %s retrieved their profile", email)
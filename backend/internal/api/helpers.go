@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"routrapp-api/internal/errors"
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// respondWithError writes an error response using the structured AppError format shared by all handlers
+func respondWithError(c *gin.Context, status int, message, code string) {
+	c.JSON(status, gin.H{
+		"error": errors.NewAppErrorWithDetails(
+			status,
+			message,
+			map[string]interface{}{
+				"code": code,
+			},
+		),
+	})
+}
+
+// respondWithErrorDetails writes an error response with additional details alongside the error code
+func respondWithErrorDetails(c *gin.Context, status int, message, code string, details map[string]interface{}) {
+	payload := map[string]interface{}{
+		"code": code,
+	}
+	for key, value := range details {
+		payload[key] = value
+	}
+	c.JSON(status, gin.H{
+		"error": errors.NewAppErrorWithDetails(status, message, payload),
+	})
+}
+
+// requireOrganizationID returns the caller's organization ID or writes an authentication error
+func requireOrganizationID(c *gin.Context) (uint, bool) {
+	organizationID, exists := middleware.GetOrganizationID(c)
+	if !exists || organizationID == 0 {
+		logger.WithContext(c).Error("Organization ID not found in context")
+		respondWithError(c, http.StatusUnauthorized, "Organization context required", "ORGANIZATION_REQUIRED")
+		return 0, false
+	}
+	return organizationID, true
+}
+
+// requestDB binds the database to the request's context so the tenant plugin scopes its
+// statements to the caller's organization
+func requestDB(c *gin.Context, db *gorm.DB) *gorm.DB {
+	return db.WithContext(c.Request.Context())
+}
+
+// parseIDParam parses a positive numeric path parameter or writes a validation error
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil || id == 0 {
+		respondWithError(c, http.StatusBadRequest, "Invalid "+name+": must be a positive integer", "INVALID_ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// newUserResponse converts a user model into its API representation
+func newUserResponse(user models.User) validation.UserResponse {
+	return validation.UserResponse{
+		BaseResponse: validation.BaseResponse{
+			ID:        user.ID,
+			CreatedAt: user.CreatedAt,
+			UpdatedAt: user.UpdatedAt,
+		},
+		Email:         user.Email,
+		EmailVerified: user.IsEmailVerified(),
+		MFAEnabled:    user.IsMFAEnabled(),
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Active:        user.Active,
+		Role:          user.Role.Name.String(),
+	}
+}
+
+// newTechnicianResponse converts a technician model (with its user preloaded) into its API representation
+func newTechnicianResponse(technician models.Technician) validation.TechnicianResponse {
+	response := validation.TechnicianResponse{
+		ID:          technician.ID,
+		User:        newUserResponse(technician.User),
+		Status:      technician.Status,
+		PhoneNumber: technician.PhoneNumber,
+		Notes:       technician.Notes,
+		LastLat:     technician.CurrentLat,
+		LastLng:     technician.CurrentLng,
+		CreatedAt:   technician.CreatedAt,
+		UpdatedAt:   technician.UpdatedAt,
+	}
+	if technician.LastLocationAt != nil {
+		lastSeen := time.Unix(*technician.LastLocationAt, 0)
+		response.LastSeen = &lastSeen
+	}
+	return response
+}
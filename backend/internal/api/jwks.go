@@ -0,0 +1,19 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"routrapp-api/internal/utils/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS handles GET /.well-known/jwks.json, publishing the public keys other services verify access
+// tokens with. It is empty while tokens are signed with the shared secret.
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	// Verifiers cache the keys briefly and fetch them again when a token names an unknown key. The key
+	// ring publishes the next key this long before it signs.
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(constants.JWKSCacheMaxAge.Seconds())))
+	c.JSON(http.StatusOK, h.jwtService.JWKS())
+}
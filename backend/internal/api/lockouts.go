@@ -0,0 +1,188 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"routrapp-api/internal/lockout"
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loginAttemptContextKey holds the login attempt reserved with the lockout guard for the request
+const loginAttemptContextKey = "login_attempt"
+
+// loginAttempt is a login attempt reserved with the lockout guard and not released yet
+type loginAttempt struct {
+	accountKey string
+	ip         string
+}
+
+// allowLoginAttempt reserves the login attempt with the lockout guard before credentials are
+// verified, so parallel attempts are throttled before the first of them fails. It responds with
+// ACCOUNT_LOCKED or TOO_MANY_ATTEMPTS while the account or the client's address must wait, and with
+// LOCKOUT_UNAVAILABLE when the guard's store can't be reached, and returns false. An allowed attempt
+// must be released with releaseLoginAttempt once it concluded, which callers defer.
+func (h *AuthHandler) allowLoginAttempt(c *gin.Context, accountKey string) bool {
+	status, err := h.options.Lockout.Reserve(c.Request.Context(), accountKey, c.ClientIP())
+	if err != nil {
+		// Without the counters failed logins can't be throttled, so none are attempted
+		logger.WithContext(c).Errorf("Failed to reserve login attempt: %v", err)
+		respondWithError(c, http.StatusServiceUnavailable, "Sign in is temporarily unavailable; try again later", "LOCKOUT_UNAVAILABLE")
+		return false
+	}
+	if status.Allowed() {
+		c.Set(loginAttemptContextKey, &loginAttempt{accountKey: accountKey, ip: c.ClientIP()})
+		return true
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(status.RetryAfter.Seconds()))))
+	if status.Locked {
+		logger.WithContext(c).Warnf("Login refused: account is locked for %s", status.RetryAfter.Round(time.Second))
+		respondWithError(c, http.StatusLocked, "Account is temporarily locked after too many failed login attempts", "ACCOUNT_LOCKED")
+		return false
+	}
+	logger.WithContext(c).Warnf("Login refused: %s must wait %s after failed attempts", c.ClientIP(), status.RetryAfter.Round(time.Second))
+	respondWithError(c, http.StatusTooManyRequests, "Too many failed login attempts; try again later", "TOO_MANY_ATTEMPTS")
+	return false
+}
+
+// releaseLoginAttempt releases the login attempt reserved by allowLoginAttempt, if any. Releasing
+// it again does nothing.
+func (h *AuthHandler) releaseLoginAttempt(c *gin.Context) {
+	value, _ := c.Get(loginAttemptContextKey)
+	attempt, _ := value.(*loginAttempt)
+	if attempt == nil {
+		return
+	}
+	c.Set(loginAttemptContextKey, (*loginAttempt)(nil))
+	if err := h.options.Lockout.Release(c.Request.Context(), attempt.accountKey, attempt.ip); err != nil {
+		logger.WithContext(c).Errorf("Failed to release login attempt: %v", err)
+	}
+}
+
+// recordLoginFailure counts a failed login to the account, which may be empty when no account was
+// found, and to the client's address. A lockout of an existing user is kept as an audit record.
+func (h *AuthHandler) recordLoginFailure(c *gin.Context, accountKey string, user *models.User) {
+	// The failure replaces the reservation in the counts
+	defer h.releaseLoginAttempt(c)
+
+	failure, err := h.options.Lockout.Fail(c.Request.Context(), accountKey, c.ClientIP())
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to count failed login: %v", err)
+		return
+	}
+	if failure.IPBlocked {
+		logger.WithContext(c).Warnf("Blocked logins from %s for %s after repeated failures", c.ClientIP(), h.options.Lockout.LockDuration())
+	}
+	if !failure.AccountLocked {
+		return
+	}
+	if user == nil {
+		logger.WithContext(c).Warnf("Locked logins to %s after %d failed attempts", accountKey, failure.Failures)
+		return
+	}
+
+	logger.WithContext(c).Warnf("Locked the account of user %d after %d failed logins, the last from %s", user.ID, failure.Failures, c.ClientIP())
+	record := models.AccountLockout{
+		Base: models.Base{
+			OrganizationID: user.OrganizationID,
+		},
+		UserID:         user.ID,
+		IPAddress:      c.ClientIP(),
+		FailedAttempts: failure.Failures,
+		LockedUntil:    time.Now().Add(h.options.Lockout.LockDuration()),
+	}
+	if err := requestDB(c, h.db).Create(&record).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to record lockout of user %d: %v", user.ID, err)
+	}
+}
+
+// UnlockUser handles POST /api/v1/users/:id/unlock, lifting the lockout of an account before it ends.
+// The caller must hold every permission of the user's role, so that only owners unlock an owner.
+func (h *AuthHandler) UnlockUser(c *gin.Context) {
+	adminID, exists := middleware.GetUserID(c)
+	if !exists {
+		respondWithError(c, http.StatusUnauthorized, "Authentication required", "AUTHENTICATION_REQUIRED")
+		return
+	}
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	user, ok := h.findUser(c, userID)
+	if !ok {
+		return
+	}
+	if !requireRolePermissionsHeld(c, &user.Role) {
+		return
+	}
+
+	if err := h.options.Lockout.Unlock(c.Request.Context(), lockout.AccountKey(user.OrganizationID, user.Email)); err != nil {
+		logger.WithContext(c).Errorf("Failed to unlock user %d: %v", user.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to unlock account", "INTERNAL_ERROR")
+		return
+	}
+	now := time.Now()
+	if err := requestDB(c, h.db).Model(&models.AccountLockout{}).
+		Where("user_id = ? AND unlocked_at IS NULL AND locked_until > ?", user.ID, now).
+		Updates(map[string]interface{}{
+			"unlocked_at":    now,
+			"unlocked_by_id": adminID,
+		}).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to record unlock of user %d: %v", user.ID, err)
+	}
+
+	logger.WithContext(c).Warnf("User %d unlocked the account of user %d", adminID, user.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newUserResponse(*user),
+		"message": "Account unlocked",
+	})
+}
+
+// ListUserLockouts handles GET /api/v1/users/:id/lockouts, the audit trail of an account's lockouts
+func (h *AuthHandler) ListUserLockouts(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	user, ok := h.findUser(c, userID)
+	if !ok {
+		return
+	}
+
+	var lockouts []models.AccountLockout
+	if err := requestDB(c, h.db).Where("user_id = ?", user.ID).Order("created_at DESC, id DESC").Find(&lockouts).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to list lockouts of user %d: %v", user.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to list lockouts", "INTERNAL_ERROR")
+		return
+	}
+
+	now := time.Now()
+	response := make([]validation.AccountLockoutResponse, 0, len(lockouts))
+	for _, record := range lockouts {
+		response = append(response, validation.AccountLockoutResponse{
+			ID:             record.ID,
+			UserID:         record.UserID,
+			IPAddress:      record.IPAddress,
+			FailedAttempts: record.FailedAttempts,
+			Active:         record.IsActive(now),
+			LockedAt:       record.CreatedAt,
+			LockedUntil:    record.LockedUntil,
+			UnlockedAt:     record.UnlockedAt,
+			UnlockedByID:   record.UnlockedByID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    response,
+	})
+}
@@ -0,0 +1,568 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"routrapp-api/internal/lockout"
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/utils/auth"
+	"routrapp-api/internal/utils/constants"
+	"routrapp-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Errors of a second factor check
+var (
+	errInvalidMFACode    = errors.New("invalid or already used authentication code")
+	errMFANotSetUp       = errors.New("no authenticator app has been set up")
+	errMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+)
+
+// challengeSecondFactor stops a login whose password was verified when the user must also provide
+// a second factor: users who set one up, and owners of organizations that require one. It responds
+// with an MFA token for /auth/mfa/verify and returns true when the login can't complete yet.
+func (h *AuthHandler) challengeSecondFactor(c *gin.Context, user models.User) bool {
+	enrollment := false
+	if !user.IsMFAEnabled() {
+		if !user.IsOwner() {
+			return false
+		}
+		var organization models.Organization
+		if err := requestDB(c, h.db).Select("id", "require_mfa").First(&organization, user.OrganizationID).Error; err != nil {
+			logger.WithContext(c).Errorf("Failed to load the MFA policy of organization %d: %v", user.OrganizationID, err)
+			respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+			return true
+		}
+		if !organization.RequireMFA {
+			return false
+		}
+		enrollment = true
+	}
+
+	mfaToken, err := h.jwtService.GenerateMFAToken(user.ID, user.OrganizationID, user.Email, user.Role.Name.String())
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to generate MFA token: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to generate tokens", "TOKEN_GENERATION_ERROR")
+		return true
+	}
+
+	message := "Enter the code from your authenticator app"
+	if enrollment {
+		message = "Your organization requires two-factor authentication; set up an authenticator app to continue"
+	}
+	logger.WithContext(c).Infof("Login of user %d requires a second factor", user.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": validation.MFAChallengeResponse{
+			MFARequired:        true,
+			EnrollmentRequired: enrollment,
+			MFAToken:           mfaToken,
+			ExpiresIn:          constants.JWT_MFA_TOKEN_EXPIRY,
+		},
+		"message": message,
+	})
+	return true
+}
+
+// VerifyMFA handles POST /api/v1/auth/mfa/verify, completing a login with a code from the authenticator
+// app or a recovery code. During enrollment the code confirms the new secret, and the recovery codes
+// are returned with the session tokens.
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req validation.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+	user, ok := h.mfaTokenUser(c, req.MFAToken)
+	if !ok {
+		return
+	}
+	// Codes are throttled like passwords, so the MFA token can't be used to guess them
+	accountKey := lockout.AccountKey(user.OrganizationID, user.Email)
+	if !h.allowLoginAttempt(c, accountKey) {
+		return
+	}
+	defer h.releaseLoginAttempt(c)
+
+	var recoveryCodes []string
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		switch {
+		case user.IsMFAEnabled() && req.RecoveryCode != "":
+			return redeemRecoveryCode(tx, user, req.RecoveryCode)
+		case user.IsMFAEnabled():
+			return h.acceptTOTPCode(tx, user, req.Code)
+		case user.TOTPSecret == "":
+			return errMFANotSetUp
+		case req.Code == "":
+			return errInvalidMFACode // recovery codes don't exist before enrollment
+		default:
+			if err := h.acceptTOTPCode(tx, user, req.Code); err != nil {
+				return err
+			}
+			var err error
+			recoveryCodes, err = enableMFA(tx, user)
+			return err
+		}
+	})
+	if err == errInvalidMFACode {
+		h.recordLoginFailure(c, accountKey, user)
+	}
+	if !h.handleMFAError(c, user, err) {
+		return
+	}
+
+	if recoveryCodes != nil {
+		logger.WithContext(c).Infof("User %d enabled two-factor authentication while signing in", user.ID)
+	}
+	h.completeLogin(c, *user, req.DeviceName, recoveryCodes)
+}
+
+// EnrollMFA handles POST /api/v1/auth/mfa/enroll, starting the enrollment a login requires with the MFA token
+func (h *AuthHandler) EnrollMFA(c *gin.Context) {
+	var req validation.MFAEnrollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+	user, ok := h.mfaTokenUser(c, req.MFAToken)
+	if !ok {
+		return
+	}
+	h.startMFASetup(c, user)
+}
+
+// SetupMFA handles POST /api/v1/auth/mfa/setup, generating a TOTP secret for the signed in user.
+// Two-factor authentication is only turned on once a code from the app is confirmed at /auth/mfa/enable.
+func (h *AuthHandler) SetupMFA(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	h.startMFASetup(c, user)
+}
+
+// EnableMFA handles POST /api/v1/auth/mfa/enable
+func (h *AuthHandler) EnableMFA(c *gin.Context) {
+	var req validation.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	var recoveryCodes []string
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		switch {
+		case user.IsMFAEnabled():
+			return errMFAAlreadyEnabled
+		case user.TOTPSecret == "":
+			return errMFANotSetUp
+		}
+		if err := h.acceptTOTPCode(tx, user, req.Code); err != nil {
+			return err
+		}
+		var err error
+		recoveryCodes, err = enableMFA(tx, user)
+		return err
+	})
+	if !h.handleMFAError(c, user, err) {
+		return
+	}
+
+	logger.WithContext(c).Infof("User %d enabled two-factor authentication", user.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    validation.MFARecoveryCodesResponse{RecoveryCodes: recoveryCodes},
+		"message": "Two-factor authentication enabled. Store the recovery codes somewhere safe; they won't be shown again.",
+	})
+}
+
+// DisableMFA handles POST /api/v1/auth/mfa/disable
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	var req validation.MFADisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.IsMFAEnabled() {
+		respondWithError(c, http.StatusBadRequest, "Two-factor authentication is not enabled", "MFA_NOT_ENABLED")
+		return
+	}
+	if err := auth.VerifyPassword(req.Password, user.Password); err != nil {
+		logger.WithContext(c).Warnf("Disabling MFA failed: invalid password for user %d", user.ID)
+		respondWithError(c, http.StatusUnauthorized, "Current password is incorrect", "INVALID_CURRENT_PASSWORD")
+		return
+	}
+	if user.IsOwner() {
+		var organization models.Organization
+		if err := requestDB(c, h.db).Select("id", "require_mfa").First(&organization, user.OrganizationID).Error; err != nil {
+			logger.WithContext(c).Errorf("Failed to load the MFA policy of organization %d: %v", user.OrganizationID, err)
+			respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+			return
+		}
+		if organization.RequireMFA {
+			respondWithError(c, http.StatusForbidden, "Your organization requires two-factor authentication for owners", "MFA_REQUIRED_BY_ORGANIZATION")
+			return
+		}
+	}
+
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := h.acceptTOTPCode(tx, user, req.Code); err != nil {
+			return err
+		}
+		return disableMFA(tx, user)
+	})
+	if !h.handleMFAError(c, user, err) {
+		return
+	}
+
+	logger.WithContext(c).Infof("User %d disabled two-factor authentication", user.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes handles POST /api/v1/auth/mfa/recovery-codes, replacing all recovery codes
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req validation.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.IsMFAEnabled() {
+		respondWithError(c, http.StatusBadRequest, "Two-factor authentication is not enabled", "MFA_NOT_ENABLED")
+		return
+	}
+
+	var recoveryCodes []string
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := h.acceptTOTPCode(tx, user, req.Code); err != nil {
+			return err
+		}
+		var err error
+		recoveryCodes, err = replaceRecoveryCodes(tx, user)
+		return err
+	})
+	if !h.handleMFAError(c, user, err) {
+		return
+	}
+
+	logger.WithContext(c).Infof("User %d regenerated their recovery codes", user.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    validation.MFARecoveryCodesResponse{RecoveryCodes: recoveryCodes},
+		"message": "Recovery codes replaced; the previous codes no longer work",
+	})
+}
+
+// ResetUserMFA handles DELETE /api/v1/users/:id/mfa, turning off the second factor of a user who lost
+// their authenticator app and recovery codes. The user's sessions are revoked; owners of organizations
+// requiring a second factor set up a new one at their next login. The caller must hold every permission of
+// the user's role, so that only owners reset the second factor of an owner.
+func (h *AuthHandler) ResetUserMFA(c *gin.Context) {
+	adminID, exists := middleware.GetUserID(c)
+	if !exists {
+		respondWithError(c, http.StatusUnauthorized, "Authentication required", "AUTHENTICATION_REQUIRED")
+		return
+	}
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if userID == adminID {
+		respondWithError(c, http.StatusBadRequest, "Use /auth/mfa/disable to turn off your own second factor", "CANNOT_RESET_OWN_MFA")
+		return
+	}
+
+	user, ok := h.findUser(c, userID)
+	if !ok {
+		return
+	}
+	if !requireRolePermissionsHeld(c, &user.Role) {
+		return
+	}
+
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := disableMFA(tx, user); err != nil {
+			return err
+		}
+		_, err := revokeSessions(tx, user.ID, models.SessionRevokedMFAReset)
+		return err
+	})
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to reset MFA of user %d: %v", user.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to reset two-factor authentication", "INTERNAL_ERROR")
+		return
+	}
+	revokeUserTokens(c, user.ID)
+
+	logger.WithContext(c).Warnf("User %d reset the two-factor authentication of user %d", adminID, user.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newUserResponse(*user),
+		"message": "Two-factor authentication reset",
+	})
+}
+
+// GetMFAPolicy handles GET /api/v1/auth/mfa/policy
+func (h *AuthHandler) GetMFAPolicy(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+	var organization models.Organization
+	if err := requestDB(c, h.db).Select("id", "require_mfa").First(&organization, organizationID).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to load the MFA policy of organization %d: %v", organizationID, err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    validation.MFAPolicyResponse{RequireMFA: organization.RequireMFA},
+	})
+}
+
+// UpdateMFAPolicy handles PUT /api/v1/auth/mfa/policy
+func (h *AuthHandler) UpdateMFAPolicy(c *gin.Context) {
+	var req validation.MFAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	if err := requestDB(c, h.db).Model(&models.Organization{}).Where("id = ?", organizationID).Update("require_mfa", *req.RequireMFA).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to update the MFA policy of organization %d: %v", organizationID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to update two-factor authentication policy", "INTERNAL_ERROR")
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	logger.WithContext(c).Warnf("User %d set require_mfa=%t for organization %d", userID, *req.RequireMFA, organizationID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    validation.MFAPolicyResponse{RequireMFA: *req.RequireMFA},
+		"message": "Two-factor authentication policy updated",
+	})
+}
+
+// startMFASetup stores a new, not yet confirmed TOTP secret for the user and responds with it
+func (h *AuthHandler) startMFASetup(c *gin.Context, user *models.User) {
+	if user.IsMFAEnabled() {
+		respondWithError(c, http.StatusConflict, "Two-factor authentication is already enabled", "MFA_ALREADY_ENABLED")
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to generate TOTP secret: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to set up two-factor authentication", "INTERNAL_ERROR")
+		return
+	}
+	sealed, err := h.mfaSecrets.Seal(secret)
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to encrypt TOTP secret: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to set up two-factor authentication", "INTERNAL_ERROR")
+		return
+	}
+	if err := requestDB(c, h.db).Model(user).Updates(map[string]interface{}{
+		"totp_secret":    sealed,
+		"totp_last_step": 0,
+	}).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to store TOTP secret of user %d: %v", user.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to set up two-factor authentication", "INTERNAL_ERROR")
+		return
+	}
+
+	logger.WithContext(c).Infof("User %d started setting up two-factor authentication", user.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": validation.MFASetupResponse{
+			Secret:          secret,
+			ProvisioningURI: auth.TOTPProvisioningURI(h.options.MFAIssuer, user.Email, secret),
+		},
+		"message": "Scan the QR code with your authenticator app and confirm with a code",
+	})
+}
+
+// mfaTokenUser validates an MFA token and loads its user, scoping the request to the user's organization.
+// It writes the error response itself and returns false when the token or the user is not valid.
+func (h *AuthHandler) mfaTokenUser(c *gin.Context, mfaToken string) (*models.User, bool) {
+	claims, err := h.jwtService.ValidateToken(mfaToken)
+	if err != nil || !claims.IsMFAToken() {
+		logger.WithContext(c).Warnf("Invalid MFA token presented")
+		respondWithError(c, http.StatusUnauthorized, "Invalid or expired MFA token; sign in again", "INVALID_MFA_TOKEN")
+		return nil, false
+	}
+	middleware.SetRequestTenant(c, claims.OrganizationID)
+
+	var user models.User
+	if err := requestDB(c, h.db).Preload("Role").First(&user, claims.UserID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondWithError(c, http.StatusUnauthorized, "Invalid or expired MFA token; sign in again", "INVALID_MFA_TOKEN")
+			return nil, false
+		}
+		logger.WithContext(c).Errorf("Database error loading user %d: %v", claims.UserID, err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+		return nil, false
+	}
+	if !user.Active {
+		respondWithError(c, http.StatusUnauthorized, "Account is disabled", "ACCOUNT_DISABLED")
+		return nil, false
+	}
+	return &user, true
+}
+
+// currentUser loads the authenticated user. It writes the error response itself and returns false
+// when the user can't be loaded.
+func (h *AuthHandler) currentUser(c *gin.Context) (*models.User, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		respondWithError(c, http.StatusUnauthorized, "Authentication required", "AUTHENTICATION_REQUIRED")
+		return nil, false
+	}
+	return h.findUser(c, userID)
+}
+
+// findUser loads a user of the request's organization with their role. It writes the error response
+// itself and returns false when the user can't be loaded.
+func (h *AuthHandler) findUser(c *gin.Context, userID uint) (*models.User, bool) {
+	var user models.User
+	if err := requestDB(c, h.db).Preload("Role").First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondWithError(c, http.StatusNotFound, "User not found", "USER_NOT_FOUND")
+			return nil, false
+		}
+		logger.WithContext(c).Errorf("Database error loading user %d: %v", userID, err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+		return nil, false
+	}
+	return &user, true
+}
+
+// handleMFAError writes the response for an error of a second factor check and returns whether there was none
+func (h *AuthHandler) handleMFAError(c *gin.Context, user *models.User, err error) bool {
+	switch err {
+	case nil:
+		return true
+	case errInvalidMFACode:
+		logger.WithContext(c).Warnf("Invalid second factor presented for user %d", user.ID)
+		respondWithError(c, http.StatusUnauthorized, "Invalid authentication code", "INVALID_MFA_CODE")
+	case errMFANotSetUp:
+		respondWithError(c, http.StatusBadRequest, "Set up an authenticator app first", "MFA_SETUP_REQUIRED")
+	case errMFAAlreadyEnabled:
+		respondWithError(c, http.StatusConflict, "Two-factor authentication is already enabled", "MFA_ALREADY_ENABLED")
+	default:
+		logger.WithContext(c).Errorf("Failed to check the second factor of user %d: %v", user.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+	}
+	return false
+}
+
+// acceptTOTPCode checks a code from the user's authenticator app and records its time step. Steps
+// must increase, so of two requests racing with the same code only one is accepted.
+func (h *AuthHandler) acceptTOTPCode(tx *gorm.DB, user *models.User, code string) error {
+	secret, err := h.mfaSecrets.Open(user.TOTPSecret)
+	if err != nil {
+		return err
+	}
+	step, ok := auth.ValidateTOTP(secret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return errInvalidMFACode
+	}
+	result := tx.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", user.ID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errInvalidMFACode
+	}
+	user.TOTPLastStep = step
+	return nil
+}
+
+// redeemRecoveryCode uses up one of the user's recovery codes
+func redeemRecoveryCode(tx *gorm.DB, user *models.User, code string) error {
+	result := tx.Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, auth.HashToken(auth.NormalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errInvalidMFACode
+	}
+	return nil
+}
+
+// enableMFA turns on the second factor whose secret was confirmed and returns new recovery codes
+func enableMFA(tx *gorm.DB, user *models.User) ([]string, error) {
+	now := time.Now()
+	if err := tx.Model(user).Update("totp_enabled_at", now).Error; err != nil {
+		return nil, err
+	}
+	user.TOTPEnabledAt = &now
+	return replaceRecoveryCodes(tx, user)
+}
+
+// disableMFA removes the user's second factor and recovery codes
+func disableMFA(tx *gorm.DB, user *models.User) error {
+	if err := tx.Model(user).Updates(map[string]interface{}{
+		"totp_secret":     "",
+		"totp_enabled_at": nil,
+		"totp_last_step":  0,
+	}).Error; err != nil {
+		return err
+	}
+	user.TOTPSecret, user.TOTPEnabledAt, user.TOTPLastStep = "", nil, 0
+	return tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.UserRecoveryCode{}).Error
+}
+
+// replaceRecoveryCodes generates the user's recovery codes, discarding earlier ones, and returns them
+func replaceRecoveryCodes(tx *gorm.DB, user *models.User) ([]string, error) {
+	codes, err := auth.GenerateRecoveryCodes(constants.RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	records := make([]models.UserRecoveryCode, len(codes))
+	for i, code := range codes {
+		records[i] = models.UserRecoveryCode{
+			Base: models.Base{
+				OrganizationID: user.OrganizationID,
+			},
+			UserID:   user.ID,
+			CodeHash: auth.HashToken(code),
+		}
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
@@ -0,0 +1,510 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RoleHandler handles per-organization role management and role assignment
+type RoleHandler struct {
+	db *gorm.DB
+}
+
+// NewRoleHandler creates a new role handler
+func NewRoleHandler(db *gorm.DB) *RoleHandler {
+	return &RoleHandler{
+		db: db,
+	}
+}
+
+// ListPermissions handles GET /api/v1/permissions
+// It publishes the catalogue of permissions that can be granted to roles.
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    models.PermissionCatalogue,
+	})
+}
+
+// ListRoles handles GET /api/v1/roles
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	var roles []models.Role
+	if err := requestDB(c, h.db).Where("organization_id = ?", organizationID).Order("id ASC").Find(&roles).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to list roles: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch roles", "DATABASE_ERROR")
+		return
+	}
+
+	var counts []struct {
+		RoleID uint
+		Count  int64
+	}
+	if err := requestDB(c, h.db).Model(&models.User{}).
+		Select("role_id, COUNT(*) AS count").
+		Where("organization_id = ?", organizationID).
+		Group("role_id").
+		Scan(&counts).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to count role members: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch roles", "DATABASE_ERROR")
+		return
+	}
+	userCounts := make(map[uint]int64, len(counts))
+	for _, count := range counts {
+		userCounts[count.RoleID] = count.Count
+	}
+
+	responses := make([]validation.RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		responses = append(responses, newRoleResponse(role, userCounts[role.ID]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    responses,
+	})
+}
+
+// GetRole handles GET /api/v1/roles/:id
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	roleID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	role, ok := h.loadRole(c, organizationID, roleID)
+	if !ok {
+		return
+	}
+	userCount, ok := h.countRoleUsers(c, role)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newRoleResponse(*role, userCount),
+	})
+}
+
+// CreateRole handles POST /api/v1/roles
+// Callers can only grant permissions they hold themselves.
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	var req validation.RoleCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithContext(c).Errorf("Invalid role creation request: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	name := models.RoleType(req.Name)
+	if name.IsBuiltIn() {
+		respondWithError(c, http.StatusBadRequest, "Role name "+req.Name+" is reserved", "INVALID_ROLE_NAME")
+		return
+	}
+	if !name.IsValid() {
+		respondWithError(c, http.StatusBadRequest, "Role name must start with a lowercase letter and contain only lowercase letters, digits and underscores", "INVALID_ROLE_NAME")
+		return
+	}
+
+	permissions, ok := grantablePermissions(c, req.Permissions)
+	if !ok {
+		return
+	}
+
+	var existing int64
+	if err := requestDB(c, h.db).Model(&models.Role{}).Where("organization_id = ? AND name = ?", organizationID, name).Count(&existing).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to check role name %s: %v", name, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to create role", "DATABASE_ERROR")
+		return
+	}
+	if existing > 0 {
+		respondWithError(c, http.StatusConflict, "A role named "+req.Name+" already exists", "ROLE_NAME_TAKEN")
+		return
+	}
+
+	role := models.Role{
+		Base: models.Base{
+			OrganizationID: organizationID,
+		},
+		Name:        name,
+		DisplayName: req.DisplayName,
+		Description: req.Description,
+		Active:      true,
+	}
+	if err := role.SetPermissions(permissions); err != nil {
+		logger.WithContext(c).Errorf("Failed to encode role permissions: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to create role", "ROLE_CREATION_ERROR")
+		return
+	}
+
+	if err := requestDB(c, h.db).Create(&role).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to create role %s: %v", name, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to create role", "ROLE_CREATION_ERROR")
+		return
+	}
+
+	logger.WithContext(c).Infof("Created role %d (%s) with %d permissions", role.ID, role.Name, len(permissions))
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    newRoleResponse(role, 0),
+		"message": "Role created successfully",
+	})
+}
+
+// UpdateRole handles PATCH /api/v1/roles/:id
+// The owner role always keeps its full permissions and cannot be deactivated.
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	roleID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req validation.RoleUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithContext(c).Errorf("Invalid role update request: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+	if req.DisplayName == nil && req.Description == nil && req.Permissions == nil && req.Active == nil {
+		respondWithError(c, http.StatusBadRequest, "At least one field must be provided for update", "NO_FIELDS_PROVIDED")
+		return
+	}
+
+	role, ok := h.loadRole(c, organizationID, roleID)
+	if !ok {
+		return
+	}
+
+	if role.Name == models.RoleTypeOwner && (req.Permissions != nil || (req.Active != nil && !*req.Active)) {
+		respondWithError(c, http.StatusConflict, "The owner role's permissions cannot be changed", "ROLE_IMMUTABLE")
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.DisplayName != nil {
+		updates["display_name"] = *req.DisplayName
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Active != nil {
+		updates["active"] = *req.Active
+	}
+	if req.Permissions != nil {
+		permissions, ok := grantablePermissions(c, *req.Permissions)
+		if !ok {
+			return
+		}
+		if err := role.SetPermissions(permissions); err != nil {
+			logger.WithContext(c).Errorf("Failed to encode role permissions: %v", err)
+			respondWithError(c, http.StatusInternalServerError, "Failed to update role", "ROLE_UPDATE_ERROR")
+			return
+		}
+		updates["permissions"] = role.Permissions
+	}
+
+	if err := requestDB(c, h.db).Model(&models.Role{}).Where("id = ?", role.ID).Updates(updates).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to update role %d: %v", roleID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to update role", "ROLE_UPDATE_ERROR")
+		return
+	}
+	middleware.InvalidateRolePermissions(c, role.ID)
+
+	role, ok = h.loadRole(c, organizationID, roleID)
+	if !ok {
+		return
+	}
+	userCount, ok := h.countRoleUsers(c, role)
+	if !ok {
+		return
+	}
+
+	logger.WithContext(c).Infof("Updated role %d (%s)", role.ID, role.Name)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newRoleResponse(*role, userCount),
+		"message": "Role updated successfully",
+	})
+}
+
+// DeleteRole handles DELETE /api/v1/roles/:id
+// Built-in roles and roles that still have users cannot be deleted.
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	roleID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	role, ok := h.loadRole(c, organizationID, roleID)
+	if !ok {
+		return
+	}
+
+	if role.Name.IsBuiltIn() {
+		respondWithError(c, http.StatusConflict, "Built-in roles cannot be deleted", "ROLE_BUILT_IN")
+		return
+	}
+
+	userCount, ok := h.countRoleUsers(c, role)
+	if !ok {
+		return
+	}
+	if userCount > 0 {
+		respondWithErrorDetails(c, http.StatusConflict, "Move the role's users to another role before deleting it", "ROLE_IN_USE", map[string]interface{}{
+			"user_count": userCount,
+		})
+		return
+	}
+
+	if err := requestDB(c, h.db).Delete(role).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to delete role %d: %v", roleID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to delete role", "ROLE_DELETION_ERROR")
+		return
+	}
+	middleware.InvalidateRolePermissions(c, role.ID)
+
+	logger.WithContext(c).Infof("Deleted role %d (%s)", role.ID, role.Name)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Role deleted successfully",
+	})
+}
+
+// AssignUserRole handles PUT /api/v1/users/:id/role
+// Callers can only assign roles whose permissions they hold, and the last active owner cannot be moved.
+func (h *RoleHandler) AssignUserRole(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req validation.UserRoleAssignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithContext(c).Errorf("Invalid role assignment request: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	var user models.User
+	if err := requestDB(c, h.db).Preload("Role").Where("id = ? AND organization_id = ?", userID, organizationID).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondWithError(c, http.StatusNotFound, "User not found", "USER_NOT_FOUND")
+			return
+		}
+		logger.WithContext(c).Errorf("Database error fetching user %d: %v", userID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch user", "DATABASE_ERROR")
+		return
+	}
+
+	role, ok := h.loadRole(c, organizationID, req.RoleID)
+	if !ok {
+		return
+	}
+	if !role.Active {
+		respondWithError(c, http.StatusBadRequest, "Users cannot be assigned to an inactive role", "ROLE_INACTIVE")
+		return
+	}
+	if _, ok := grantablePermissions(c, role.PermissionList()); !ok {
+		return
+	}
+
+	if user.RoleID != role.ID {
+		err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+			if user.Role.Name == models.RoleTypeOwner && role.Name != models.RoleTypeOwner {
+				if err := requireOtherActiveOwner(tx, organizationID, user.RoleID, user.ID); err != nil {
+					return err
+				}
+			}
+			return tx.Model(&models.User{}).Where("id = ?", user.ID).Update("role_id", role.ID).Error
+		})
+		if errors.Is(err, errLastOwner) {
+			respondWithError(c, http.StatusConflict, "The organization must keep at least one active owner", "LAST_OWNER")
+			return
+		}
+		if err != nil {
+			logger.WithContext(c).Errorf("Failed to assign role %d to user %d: %v", role.ID, userID, err)
+			respondWithError(c, http.StatusInternalServerError, "Failed to assign role", "ROLE_ASSIGNMENT_ERROR")
+			return
+		}
+		middleware.InvalidateUserPermissions(c, user.ID)
+		revokeUserTokens(c, user.ID) // the user's tokens name the previous role
+		logger.WithContext(c).Infof("Moved user %d from role %d to role %d", user.ID, user.RoleID, role.ID)
+	}
+
+	user.RoleID = role.ID
+	user.Role = *role
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newUserResponse(user),
+		"message": "Role assigned successfully",
+	})
+}
+
+// errLastOwner aborts a role assignment that would leave the organization without an active owner
+var errLastOwner = errors.New("organization must keep an active owner")
+
+// requireOtherActiveOwner fails with errLastOwner unless an active user other than userID holds the
+// owner role. The owners' rows are locked before they are counted, so that two requests removing
+// different owners cannot both see the other one and leave the organization without any.
+func requireOtherActiveOwner(tx *gorm.DB, organizationID, ownerRoleID, userID uint) error {
+	var ownerIDs []uint
+	if err := tx.Model(&models.User{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ? AND role_id = ? AND active = ?", organizationID, ownerRoleID, true).
+		Order("id").
+		Pluck("id", &ownerIDs).Error; err != nil {
+		return err
+	}
+	for _, ownerID := range ownerIDs {
+		if ownerID != userID {
+			return nil
+		}
+	}
+	return errLastOwner
+}
+
+// loadRole fetches a role of the organization.
+// It writes the error response itself and returns false when the role cannot be loaded.
+func (h *RoleHandler) loadRole(c *gin.Context, organizationID, roleID uint) (*models.Role, bool) {
+	var role models.Role
+	if err := requestDB(c, h.db).Where("id = ? AND organization_id = ?", roleID, organizationID).First(&role).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondWithError(c, http.StatusNotFound, "Role not found", "ROLE_NOT_FOUND")
+			return nil, false
+		}
+		logger.WithContext(c).Errorf("Database error fetching role %d: %v", roleID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch role", "DATABASE_ERROR")
+		return nil, false
+	}
+	return &role, true
+}
+
+// countRoleUsers counts the users assigned to the role, writing the error response itself on failure
+func (h *RoleHandler) countRoleUsers(c *gin.Context, role *models.Role) (int64, bool) {
+	var count int64
+	if err := requestDB(c, h.db).Model(&models.User{}).Where("organization_id = ? AND role_id = ?", role.OrganizationID, role.ID).Count(&count).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to count users of role %d: %v", role.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch role", "DATABASE_ERROR")
+		return 0, false
+	}
+	return count, true
+}
+
+// grantablePermissions de-duplicates the permissions and checks that each is in the catalogue and held by
+// the caller, so roles cannot be used to escalate privileges. It writes the error response itself otherwise.
+func grantablePermissions(c *gin.Context, permissions []string) ([]string, bool) {
+	seen := make(map[string]bool, len(permissions))
+	unique := make([]string, 0, len(permissions))
+	unknown := []string{}
+	for _, permission := range permissions {
+		if seen[permission] {
+			continue
+		}
+		seen[permission] = true
+		if !models.IsKnownPermission(permission) {
+			unknown = append(unknown, permission)
+			continue
+		}
+		unique = append(unique, permission)
+	}
+	if len(unknown) > 0 {
+		respondWithErrorDetails(c, http.StatusBadRequest, "Unknown permissions; see GET /api/v1/permissions", "INVALID_PERMISSIONS", map[string]interface{}{
+			"unknown": unknown,
+		})
+		return nil, false
+	}
+
+	if missing := missingPermissions(c, unique); len(missing) > 0 {
+		respondWithErrorDetails(c, http.StatusForbidden, "You cannot grant permissions you do not hold", "PERMISSION_ESCALATION", map[string]interface{}{
+			"permissions": missing,
+		})
+		return nil, false
+	}
+
+	sort.Strings(unique)
+	return unique, true
+}
+
+// requireRolePermissionsHeld checks that the caller holds every permission of the role of a user they act on,
+// so that account administration cannot be turned against more privileged users such as the owners.
+// It writes the error response itself otherwise.
+func requireRolePermissionsHeld(c *gin.Context, role *models.Role) bool {
+	if missing := missingPermissions(c, role.PermissionList()); len(missing) > 0 {
+		respondWithErrorDetails(c, http.StatusForbidden, "You cannot manage users holding permissions you do not hold", "PERMISSION_ESCALATION", map[string]interface{}{
+			"permissions": missing,
+		})
+		return false
+	}
+	return true
+}
+
+// missingPermissions returns the permissions the caller does not hold
+func missingPermissions(c *gin.Context, permissions []string) []string {
+	missing := []string{}
+	for _, permission := range permissions {
+		if !middleware.HasPermission(c, permission) {
+			missing = append(missing, permission)
+		}
+	}
+	return missing
+}
+
+// newRoleResponse converts a role model into its API representation
+func newRoleResponse(role models.Role, userCount int64) validation.RoleResponse {
+	permissions := role.PermissionList()
+	if permissions == nil {
+		permissions = []string{}
+	}
+	return validation.RoleResponse{
+		ID:          role.ID,
+		Name:        role.Name.String(),
+		DisplayName: role.DisplayName,
+		Description: role.Description,
+		Permissions: permissions,
+		Active:      role.Active,
+		BuiltIn:     role.Name.IsBuiltIn(),
+		UserCount:   userCount,
+		CreatedAt:   role.CreatedAt,
+		UpdatedAt:   role.UpdatedAt,
+	}
+}
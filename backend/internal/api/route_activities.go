@@ -0,0 +1,310 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"routrapp-api/internal/events"
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+	"routrapp-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateRouteActivity handles POST /api/v1/routes/:id/activities
+// Only the technician assigned to the route can record activities, and only while it is in progress.
+func (h *RouteHandler) CreateRouteActivity(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	routeID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req validation.RouteActivityCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithContext(c).Errorf("Invalid route activity request: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	switch {
+	case req.ActivityType == models.ActivityComplete && req.RouteStopID == nil:
+		respondWithError(c, http.StatusBadRequest, "route_stop_id is required to complete a stop", "STOP_REQUIRED")
+		return
+	case req.ActivityType == models.ActivityNote && req.Notes == "":
+		respondWithError(c, http.StatusBadRequest, "notes are required for a note activity", "NOTES_REQUIRED")
+		return
+	case req.ActivityType == models.ActivityPhoto && req.PhotoURL == "":
+		respondWithError(c, http.StatusBadRequest, "photo_url is required for a photo activity", "PHOTO_URL_REQUIRED")
+		return
+	}
+
+	now := time.Now()
+	timestamp := now
+	if req.Timestamp != nil {
+		timestamp = *req.Timestamp
+	}
+	if timestamp.After(now.Add(maxClientClockSkew)) {
+		respondWithError(c, http.StatusBadRequest, "Activity timestamp is in the future", "INVALID_ACTIVITY_TIMESTAMP")
+		return
+	}
+
+	route, ok := h.loadRoute(c, organizationID, routeID)
+	if !ok {
+		return
+	}
+
+	if !h.isAssignedTechnician(c, route) {
+		return
+	}
+
+	if !route.Status.IsInProgress() {
+		respondWithErrorDetails(c, http.StatusConflict, "Activities can only be recorded while the route is in progress", "ROUTE_NOT_IN_PROGRESS", map[string]interface{}{
+			"current_status": route.Status,
+		})
+		return
+	}
+
+	var stop *models.RouteStop
+	if req.RouteStopID != nil {
+		for i := range route.Stops {
+			if route.Stops[i].ID == *req.RouteStopID {
+				stop = &route.Stops[i]
+				break
+			}
+		}
+		if stop == nil {
+			respondWithError(c, http.StatusBadRequest, "Stop does not belong to this route", "STOP_NOT_FOUND")
+			return
+		}
+		if req.ActivityType == models.ActivityComplete {
+			if stop.IsCompleted {
+				respondWithError(c, http.StatusConflict, "Stop is already completed", "STOP_ALREADY_COMPLETED")
+				return
+			}
+			if !allowCompletionWithoutProof(c, requestDB(c, h.db), organizationID, route.ID, stop.ID, stop.StopType) {
+				return
+			}
+		}
+	}
+
+	activity := models.RouteActivity{
+		Base: models.Base{
+			OrganizationID: organizationID,
+		},
+		RouteID:      route.ID,
+		RouteStopID:  req.RouteStopID,
+		TechnicianID: *route.TechnicianID,
+		ActivityType: req.ActivityType,
+		Notes:        req.Notes,
+		Lat:          req.Lat,
+		Lng:          req.Lng,
+		PhotoURL:     req.PhotoURL,
+		Timestamp:    timestamp,
+	}
+
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&activity).Error; err != nil {
+			return err
+		}
+		if stop == nil {
+			return nil
+		}
+
+		stopUpdates := activityStopUpdates(activity)
+		if len(stopUpdates) == 0 {
+			return nil
+		}
+		if activity.ActivityType == models.ActivityComplete {
+			if err := updateOpenStop(tx, stop.ID, stopUpdates); err != nil {
+				return err
+			}
+		} else if err := tx.Model(&models.RouteStop{}).Where("id = ?", stop.ID).Updates(stopUpdates).Error; err != nil {
+			return err
+		}
+		return tx.First(stop, stop.ID).Error
+	})
+	if errors.Is(err, repositories.ErrStopAlreadyCompleted) {
+		respondWithError(c, http.StatusConflict, "Stop is already completed", "STOP_ALREADY_COMPLETED")
+		return
+	}
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to record activity on route %d: %v", routeID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to record activity", "ACTIVITY_CREATION_ERROR")
+		return
+	}
+
+	h.events.Publish(organizationID, events.EventRouteActivity, events.RouteActivityEvent{
+		ActivityID:   activity.ID,
+		RouteID:      activity.RouteID,
+		RouteStopID:  activity.RouteStopID,
+		TechnicianID: activity.TechnicianID,
+		ActivityType: activity.ActivityType,
+		Timestamp:    activity.Timestamp,
+	})
+
+	response := validation.RouteActivityResultResponse{
+		Activity: newRouteActivityResponse(activity),
+	}
+	if stop != nil {
+		if req.ActivityType == models.ActivityComplete {
+			h.events.Publish(organizationID, events.EventStopCompleted, events.StopCompletedEvent{
+				RouteID:     route.ID,
+				StopID:      stop.ID,
+				IsCompleted: stop.IsCompleted,
+				CompletedAt: stop.CompletedAt,
+			})
+		}
+		stopResponse := newRouteStopResponse(*stop)
+		response.Stop = &stopResponse
+	}
+
+	logger.WithContext(c).Infof("Recorded %s activity %d on route %d", activity.ActivityType, activity.ID, routeID)
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    response,
+		"message": "Activity recorded successfully",
+	})
+}
+
+// ListRouteActivities handles GET /api/v1/routes/:id/activities
+func (h *RouteHandler) ListRouteActivities(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	routeID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, ok := h.loadRoute(c, organizationID, routeID); !ok {
+		return
+	}
+
+	listRouteActivities(c, h.activities, repositories.ActivityFilter{RouteID: &routeID})
+}
+
+// ListTechnicianActivities handles GET /api/v1/technicians/:id/activities
+// Callers with only technicians.read_own may fetch their own timeline.
+func (h *TechnicianHandler) ListTechnicianActivities(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	technicianID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	technician, ok := h.loadTechnician(c, organizationID, technicianID)
+	if !ok {
+		return
+	}
+
+	if !middleware.HasPermission(c, "technicians.read") && !h.isOwnProfile(c, technician) {
+		return
+	}
+
+	listRouteActivities(c, h.activities, repositories.ActivityFilter{TechnicianID: &technicianID})
+}
+
+// listRouteActivities responds with a chronological, paginated page of the activities
+// matched by the scope and the request's filters
+func listRouteActivities(c *gin.Context, activities repositories.ActivityRepository, scope repositories.ActivityFilter) {
+	var pagination validation.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		respondWithError(c, http.StatusBadRequest, "Invalid pagination parameters: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	var filters validation.RouteActivityFilterRequest
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		respondWithError(c, http.StatusBadRequest, "Invalid filter parameters: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	if filters.DateFrom != nil && filters.DateTo != nil && filters.DateTo.Before(*filters.DateFrom) {
+		respondWithError(c, http.StatusBadRequest, "date_to must be after date_from", "INVALID_DATE_RANGE")
+		return
+	}
+
+	filter := scope
+	filter.Types = filters.ActivityType
+	filter.RouteStopID = filters.RouteStopID
+	filter.From = filters.DateFrom
+	filter.To = filters.DateTo
+
+	var page []models.RouteActivity
+	var total int64
+	// A route_id filter naming another route than the scope matches nothing
+	if filters.RouteID == nil || scope.RouteID == nil || *filters.RouteID == *scope.RouteID {
+		if filters.RouteID != nil {
+			filter.RouteID = filters.RouteID
+		}
+
+		var err error
+		page, total, err = activities.List(c.Request.Context(), filter, repositories.Page{Page: pagination.Page, PageSize: pagination.PageSize})
+		if err != nil {
+			logger.WithContext(c).Errorf("Failed to list route activities: %v", err)
+			respondWithError(c, http.StatusInternalServerError, "Failed to fetch activities", "DATABASE_ERROR")
+			return
+		}
+	}
+
+	responses := make([]validation.RouteActivityResponse, 0, len(page))
+	for _, activity := range page {
+		responses = append(responses, newRouteActivityResponse(activity))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       responses,
+		"pagination": validation.CalculatePagination(pagination.Page, pagination.PageSize, total),
+	})
+}
+
+// activityStopUpdates collects the stop column updates implied by an activity: completing the
+// stop, and counting the notes and photos attached to it
+func activityStopUpdates(activity models.RouteActivity) map[string]interface{} {
+	updates := make(map[string]interface{})
+	if activity.ActivityType == models.ActivityComplete {
+		updates["is_completed"] = true
+		updates["completed_at"] = activity.Timestamp
+	}
+	if activity.Notes != "" {
+		updates["notes_count"] = gorm.Expr("notes_count + ?", 1)
+	}
+	if activity.PhotoURL != "" {
+		updates["photos_count"] = gorm.Expr("photos_count + ?", 1)
+	}
+	return updates
+}
+
+// newRouteActivityResponse converts a route activity model into its API representation
+func newRouteActivityResponse(activity models.RouteActivity) validation.RouteActivityResponse {
+	return validation.RouteActivityResponse{
+		ID:           activity.ID,
+		RouteID:      activity.RouteID,
+		RouteStopID:  activity.RouteStopID,
+		TechnicianID: activity.TechnicianID,
+		ActivityType: activity.ActivityType,
+		Notes:        activity.Notes,
+		Lat:          activity.Lat,
+		Lng:          activity.Lng,
+		PhotoURL:     activity.PhotoURL,
+		Timestamp:    activity.Timestamp,
+		CreatedAt:    activity.CreatedAt,
+	}
+}
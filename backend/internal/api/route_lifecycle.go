@@ -0,0 +1,293 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"routrapp-api/internal/events"
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errRouteStatusChanged aborts a transition when another request changed the route first
+var errRouteStatusChanged = errors.New("route status changed concurrently")
+
+// routeActionActivities maps lifecycle actions to the activity recorded for them
+var routeActionActivities = map[models.RouteAction]string{
+	models.RouteActionStart:    models.ActivityRouteStarted,
+	models.RouteActionPause:    models.ActivityRoutePaused,
+	models.RouteActionResume:   models.ActivityRouteResumed,
+	models.RouteActionComplete: models.ActivityRouteCompleted,
+	models.RouteActionCancel:   models.ActivityRouteCancelled,
+}
+
+// routeActionMessages holds the success message for each lifecycle action
+var routeActionMessages = map[models.RouteAction]string{
+	models.RouteActionStart:    "Route started successfully",
+	models.RouteActionPause:    "Route paused successfully",
+	models.RouteActionResume:   "Route resumed successfully",
+	models.RouteActionComplete: "Route completed successfully",
+	models.RouteActionCancel:   "Route cancelled successfully",
+}
+
+// StartRoute handles POST /api/v1/routes/:id/start
+func (h *RouteHandler) StartRoute(c *gin.Context) {
+	h.transitionRoute(c, models.RouteActionStart)
+}
+
+// PauseRoute handles POST /api/v1/routes/:id/pause
+func (h *RouteHandler) PauseRoute(c *gin.Context) {
+	h.transitionRoute(c, models.RouteActionPause)
+}
+
+// ResumeRoute handles POST /api/v1/routes/:id/resume
+func (h *RouteHandler) ResumeRoute(c *gin.Context) {
+	h.transitionRoute(c, models.RouteActionResume)
+}
+
+// CompleteRoute handles POST /api/v1/routes/:id/complete
+func (h *RouteHandler) CompleteRoute(c *gin.Context) {
+	h.transitionRoute(c, models.RouteActionComplete)
+}
+
+// CancelRoute handles POST /api/v1/routes/:id/cancel
+func (h *RouteHandler) CancelRoute(c *gin.Context) {
+	h.transitionRoute(c, models.RouteActionCancel)
+}
+
+// transitionRoute applies a lifecycle action to a route: it checks the transition table, stamps
+// the lifecycle timestamps, records a route activity and keeps the technician's status in step.
+// Callers without routes.update may only act on routes assigned to them.
+func (h *RouteHandler) transitionRoute(c *gin.Context, action models.RouteAction) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	routeID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req validation.RouteTransitionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.WithContext(c).Errorf("Invalid route %s request: %v", action, err)
+			respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+			return
+		}
+	}
+
+	route, ok := h.loadRoute(c, organizationID, routeID)
+	if !ok {
+		return
+	}
+
+	if !middleware.HasPermission(c, "routes.update") && !h.isAssignedTechnician(c, route) {
+		return
+	}
+
+	next, err := route.Status.Apply(action)
+	if err != nil {
+		logger.WithContext(c).Warnf("Route %d %s rejected: %v", routeID, action, err)
+		var transitionErr *models.RouteTransitionError
+		errors.As(err, &transitionErr)
+		respondWithTransitionError(c, transitionErr)
+		return
+	}
+
+	var technician *models.Technician
+	if route.TechnicianID != nil {
+		technician = &models.Technician{}
+		if err := requestDB(c, h.db).Where("id = ? AND organization_id = ?", *route.TechnicianID, organizationID).First(technician).Error; err != nil {
+			logger.WithContext(c).Errorf("Database error fetching technician %d: %v", *route.TechnicianID, err)
+			respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+			return
+		}
+	}
+
+	if next == models.RouteStatusStarted {
+		if !h.technicianCanDrive(c, route, technician) {
+			return
+		}
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status": next,
+	}
+	switch action {
+	case models.RouteActionStart:
+		updates["started_at"] = now
+	case models.RouteActionComplete:
+		updates["completed_at"] = now
+	case models.RouteActionCancel:
+		updates["cancelled_at"] = now
+	}
+
+	previousTechnicianStatus := models.TechnicianStatus("")
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		// The status predicate makes concurrent transitions of the same route fail instead of both applying
+		result := tx.Model(&models.Route{}).
+			Where("id = ? AND organization_id = ? AND status = ?", route.ID, organizationID, route.Status).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errRouteStatusChanged
+		}
+
+		if technician == nil {
+			return nil
+		}
+
+		activity := models.RouteActivity{
+			Base: models.Base{
+				OrganizationID: organizationID,
+			},
+			RouteID:      route.ID,
+			TechnicianID: technician.ID,
+			ActivityType: routeActionActivities[action],
+			Notes:        req.Notes,
+			Lat:          req.Lat,
+			Lng:          req.Lng,
+			Timestamp:    now,
+		}
+		if err := tx.Create(&activity).Error; err != nil {
+			return err
+		}
+
+		status, err := technicianStatusAfter(tx, technician, route.ID, next)
+		if err != nil {
+			return err
+		}
+		if status != technician.Status {
+			previousTechnicianStatus = technician.Status
+			if err := tx.Model(technician).Update("status", status).Error; err != nil {
+				return err
+			}
+			technician.Status = status
+		}
+		return nil
+	})
+	if errors.Is(err, errRouteStatusChanged) {
+		logger.WithContext(c).Warnf("Route %d %s rejected: status changed concurrently", routeID, action)
+		respondWithError(c, http.StatusConflict, "Route status changed, please retry", "ROUTE_STATUS_CHANGED")
+		return
+	}
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to %s route %d: %v", action, routeID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to update route", "ROUTE_UPDATE_ERROR")
+		return
+	}
+
+	updated, ok := h.loadRoute(c, organizationID, routeID)
+	if !ok {
+		return
+	}
+
+	h.publishRouteChanges(*route, *updated)
+	if previousTechnicianStatus != "" {
+		h.events.Publish(organizationID, events.EventTechnicianStatus, events.TechnicianStatusEvent{
+			TechnicianID:   technician.ID,
+			Status:         technician.Status,
+			PreviousStatus: previousTechnicianStatus,
+		})
+	}
+
+	logger.WithContext(c).Infof("Route %d moved from %s to %s", routeID, route.Status, next)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newRouteResponse(*updated),
+		"message": routeActionMessages[action],
+	})
+}
+
+// isAssignedTechnician checks that the caller is the technician assigned to the route.
+// It writes the error response itself and returns false otherwise.
+func (h *RouteHandler) isAssignedTechnician(c *gin.Context, route *models.Route) bool {
+	userID, _ := middleware.GetUserID(c)
+	if route.TechnicianID != nil {
+		var count int64
+		if err := requestDB(c, h.db).Model(&models.Technician{}).
+			Where("id = ? AND user_id = ? AND organization_id = ?", *route.TechnicianID, userID, route.OrganizationID).
+			Count(&count).Error; err != nil {
+			logger.WithContext(c).Errorf("Database error checking technician for user %d: %v", userID, err)
+			respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+			return false
+		}
+		if count > 0 {
+			return true
+		}
+	}
+
+	logger.WithContext(c).Warnf("User %d denied access to route %d", userID, route.ID)
+	respondWithError(c, http.StatusForbidden, "Access denied. You can only update routes assigned to you", "RESOURCE_ACCESS_DENIED")
+	return false
+}
+
+// technicianCanDrive checks that the route's technician can start or resume it: the technician
+// must not be deactivated and must not be driving another route. It writes the error response
+// itself and returns false otherwise.
+func (h *RouteHandler) technicianCanDrive(c *gin.Context, route *models.Route, technician *models.Technician) bool {
+	if technician == nil {
+		respondWithError(c, http.StatusConflict, "Route has no assigned technician", "TECHNICIAN_REQUIRED")
+		return false
+	}
+	if technician.Status == models.TechnicianStatusInactive {
+		respondWithError(c, http.StatusConflict, "Assigned technician is inactive", "TECHNICIAN_INACTIVE")
+		return false
+	}
+
+	var inProgress int64
+	if err := requestDB(c, h.db).Model(&models.Route{}).
+		Where("organization_id = ? AND technician_id = ? AND id <> ? AND status IN ?", route.OrganizationID, technician.ID, route.ID,
+			[]models.RouteStatus{models.RouteStatusStarted, models.RouteStatusPaused}).
+		Count(&inProgress).Error; err != nil {
+		logger.WithContext(c).Errorf("Database error checking routes for technician %d: %v", technician.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+		return false
+	}
+	if inProgress > 0 {
+		respondWithError(c, http.StatusConflict, "Technician already has another route in progress", "TECHNICIAN_ON_ROUTE")
+		return false
+	}
+	return true
+}
+
+// technicianStatusAfter returns the technician's status once the route reaches the given status.
+// Technicians are on_route while driving and return to active when their last route in progress ends.
+func technicianStatusAfter(tx *gorm.DB, technician *models.Technician, routeID uint, routeStatus models.RouteStatus) (models.TechnicianStatus, error) {
+	switch {
+	case routeStatus == models.RouteStatusStarted:
+		return models.TechnicianStatusOnRoute, nil
+	case routeStatus.IsTerminal() && technician.Status == models.TechnicianStatusOnRoute:
+		var inProgress int64
+		if err := tx.Model(&models.Route{}).
+			Where("technician_id = ? AND id <> ? AND status IN ?", technician.ID, routeID,
+				[]models.RouteStatus{models.RouteStatusStarted, models.RouteStatusPaused}).
+			Count(&inProgress).Error; err != nil {
+			return technician.Status, err
+		}
+		if inProgress == 0 {
+			return models.TechnicianStatusActive, nil
+		}
+	}
+	return technician.Status, nil
+}
+
+// respondWithTransitionError writes a conflict response for an illegal route status transition
+func respondWithTransitionError(c *gin.Context, err *models.RouteTransitionError) {
+	respondWithErrorDetails(c, http.StatusConflict, "Invalid route status transition: "+err.Error(), "INVALID_STATUS_TRANSITION", map[string]interface{}{
+		"current_status":  err.From,
+		"action":          err.Action,
+		"allowed_actions": err.From.AllowedActions(),
+	})
+}
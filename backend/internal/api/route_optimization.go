@@ -0,0 +1,314 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/optimizer"
+	"routrapp-api/internal/repositories"
+	"routrapp-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OptimizeRoute handles POST /api/v1/routes/:id/optimize
+// Completed stops of a started or paused route stay at the front in their current order.
+func (h *RouteHandler) OptimizeRoute(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	routeID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	// The request body is optional; an empty body uses the default options
+	var req validation.RouteOptimizeRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.WithContext(c).Errorf("Invalid route optimization request: %v", err)
+			respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+			return
+		}
+	}
+
+	route, ok := h.loadRoute(c, organizationID, routeID)
+	if !ok {
+		return
+	}
+
+	if route.Status == models.RouteStatusCompleted || route.Status == models.RouteStatusCancelled {
+		logger.WithContext(c).Warnf("Route optimization rejected: route %d is %s", routeID, route.Status)
+		respondWithError(c, http.StatusConflict, "Cannot optimize a "+string(route.Status)+" route", "ROUTE_NOT_OPTIMIZABLE")
+		return
+	}
+
+	if len(route.Stops) == 0 {
+		respondWithError(c, http.StatusBadRequest, "Route has no stops to optimize", "ROUTE_HAS_NO_STOPS")
+		return
+	}
+
+	// Stops already completed on a route in progress keep their place at the front of the route and
+	// only the remaining stops are re-sequenced
+	routeStops, completed := completedStopsFirst(route.Stops)
+	stops := newOptimizerStops(routeStops)
+
+	opts := optimizer.DefaultOptions()
+	opts.PinFirst = req.PinFirst
+	opts.PinLast = req.PinLast
+	opts.FixedStops = completed
+	if req.AverageSpeedKmh != nil {
+		opts.AverageSpeedKmh = *req.AverageSpeedKmh
+	}
+	if req.LatePenaltyPerMinute != nil {
+		opts.LatePenaltyPerMinute = *req.LatePenaltyPerMinute
+	}
+
+	startTime := req.StartTime
+	if startTime == nil {
+		startTime = route.ScheduledDate
+	}
+	if startTime != nil {
+		opts.StartTime = *startTime
+	}
+
+	// Time windows are enforced as hard constraints by default once a start time is known
+	opts.TimeWindows = optimizer.TimeWindowMode(req.TimeWindowMode)
+	if opts.TimeWindows == "" {
+		opts.TimeWindows = optimizer.TimeWindowsIgnore
+		if startTime != nil && hasTimeWindows(route.Stops) {
+			opts.TimeWindows = optimizer.TimeWindowsHard
+		}
+	}
+	if opts.TimeWindows != optimizer.TimeWindowsIgnore && startTime == nil {
+		respondWithError(c, http.StatusBadRequest, "A start time or scheduled date is required to enforce time windows", "START_TIME_REQUIRED")
+		return
+	}
+
+	result, err := optimizer.Optimize(stops, opts)
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to optimize route %d: %v", routeID, err)
+		respondWithError(c, http.StatusBadRequest, "Failed to optimize route: "+err.Error(), "OPTIMIZATION_ERROR")
+		return
+	}
+
+	schedule := newRouteScheduleResponse(route, result, opts.StartTime)
+
+	if opts.TimeWindows == optimizer.TimeWindowsHard && !result.Feasible() {
+		logger.WithContext(c).Warnf("Route %d cannot meet time windows for stops %v", routeID, result.LateStops)
+		respondWithErrorDetails(c, http.StatusUnprocessableEntity, "No stop sequence meets every time window", "TIME_WINDOWS_INFEASIBLE", map[string]interface{}{
+			"late_stops": result.LateStops,
+			"schedule":   schedule,
+		})
+		return
+	}
+
+	previousDistance := optimizer.RouteDistance(newOptimizerStops(route.Stops))
+	response := validation.RouteOptimizationResponse{
+		PreviousDistance: previousDistance,
+		DistanceSaved:    math.Round((previousDistance-result.TotalDistanceKm)*100) / 100,
+		Schedule:         schedule,
+	}
+
+	if req.DryRun {
+		preview := *route
+		preview.Stops = routeStops
+		response.Route = newRouteResponse(previewOptimizedRoute(preview, result))
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    response,
+			"message": "Route optimization preview generated",
+		})
+		return
+	}
+
+	sequence := make(map[uint]int, len(result.Stops))
+	for position, stop := range result.Stops {
+		sequence[stop.ID] = position + 1
+	}
+
+	err = h.routes.Update(c.Request.Context(), route.ID, repositories.RouteUpdate{
+		Optimization: &repositories.RouteOptimization{
+			Sequence:      sequence,
+			TotalDistance: result.TotalDistanceKm,
+			TotalDuration: result.TotalDurationSeconds,
+		},
+	})
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to save optimized route %d: %v", routeID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to save optimized route", "ROUTE_UPDATE_ERROR")
+		return
+	}
+
+	optimized, ok := h.loadRoute(c, organizationID, routeID)
+	if !ok {
+		return
+	}
+	response.Route = newRouteResponse(*optimized)
+
+	logger.WithContext(c).Infof("Route %d optimized: %.2f km -> %.2f km", routeID, previousDistance, result.TotalDistanceKm)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    response,
+		"message": "Route optimized successfully",
+	})
+}
+
+// GetRouteSchedule handles GET /api/v1/routes/:id/schedule
+func (h *RouteHandler) GetRouteSchedule(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	routeID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req validation.RouteScheduleRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, "Invalid query parameters: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	route, ok := h.loadRoute(c, organizationID, routeID)
+	if !ok {
+		return
+	}
+
+	startTime := req.StartTime
+	if startTime == nil {
+		startTime = route.ScheduledDate
+	}
+	if startTime == nil {
+		respondWithError(c, http.StatusBadRequest, "A start time or scheduled date is required to compute a schedule", "START_TIME_REQUIRED")
+		return
+	}
+
+	opts := optimizer.DefaultOptions()
+	opts.StartTime = *startTime
+	if req.AverageSpeedKmh != nil {
+		opts.AverageSpeedKmh = *req.AverageSpeedKmh
+	}
+
+	result, err := optimizer.Evaluate(newOptimizerStops(route.Stops), opts)
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to compute schedule for route %d: %v", routeID, err)
+		respondWithError(c, http.StatusBadRequest, "Failed to compute schedule: "+err.Error(), "SCHEDULE_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newRouteScheduleResponse(route, result, opts.StartTime),
+	})
+}
+
+// newOptimizerStops converts route stops into optimizer input, keeping their current order
+func newOptimizerStops(routeStops []models.RouteStop) []optimizer.Stop {
+	stops := make([]optimizer.Stop, 0, len(routeStops))
+	for _, stop := range routeStops {
+		input := optimizer.Stop{
+			ID:             stop.ID,
+			Lat:            stop.Lat,
+			Lng:            stop.Lng,
+			ServiceMinutes: stop.Duration,
+		}
+		if stop.TimeWindow != nil {
+			input.WindowStart = stop.TimeWindow.StartTime
+			input.WindowEnd = stop.TimeWindow.EndTime
+		}
+		stops = append(stops, input)
+	}
+	return stops
+}
+
+// completedStopsFirst returns the stops with the completed ones moved ahead of the others, both in
+// their current order, and the number of completed stops
+func completedStopsFirst(routeStops []models.RouteStop) ([]models.RouteStop, int) {
+	ordered := make([]models.RouteStop, 0, len(routeStops))
+	for _, stop := range routeStops {
+		if stop.IsCompleted {
+			ordered = append(ordered, stop)
+		}
+	}
+	completed := len(ordered)
+	for _, stop := range routeStops {
+		if !stop.IsCompleted {
+			ordered = append(ordered, stop)
+		}
+	}
+	return ordered, completed
+}
+
+// hasTimeWindows reports whether any stop carries a time window bound
+func hasTimeWindows(stops []models.RouteStop) bool {
+	for _, stop := range stops {
+		if stop.TimeWindow != nil && (stop.TimeWindow.StartTime != nil || stop.TimeWindow.EndTime != nil) {
+			return true
+		}
+	}
+	return false
+}
+
+// previewOptimizedRoute applies an optimizer result to a copy of the route without saving it
+func previewOptimizedRoute(route models.Route, result *optimizer.Result) models.Route {
+	stops := make([]models.RouteStop, 0, len(result.Order))
+	for position, index := range result.Order {
+		stop := route.Stops[index]
+		stop.SequenceNum = position + 1
+		stops = append(stops, stop)
+	}
+
+	route.Stops = stops
+	route.IsOptimized = true
+	route.TotalDistance = result.TotalDistanceKm
+	route.TotalDuration = result.TotalDurationSeconds
+	return route
+}
+
+// newRouteScheduleResponse converts an optimizer schedule to its API representation.
+// It returns nil when the result carries no schedule.
+func newRouteScheduleResponse(route *models.Route, result *optimizer.Result, startTime time.Time) *validation.RouteScheduleResponse {
+	if len(result.Schedule) == 0 {
+		return nil
+	}
+
+	names := make(map[uint]string, len(route.Stops))
+	for _, stop := range route.Stops {
+		names[stop.ID] = stop.Name
+	}
+
+	response := &validation.RouteScheduleResponse{
+		RouteID:       route.ID,
+		StartTime:     startTime,
+		EndTime:       result.Schedule[len(result.Schedule)-1].Departure,
+		TotalDuration: result.TotalDurationSeconds,
+		Feasible:      result.Feasible(),
+		LateStops:     []uint{},
+		Stops:         make([]validation.StopScheduleResponse, 0, len(result.Schedule)),
+	}
+	response.LateStops = append(response.LateStops, result.LateStops...)
+
+	for position, entry := range result.Schedule {
+		response.Stops = append(response.Stops, validation.StopScheduleResponse{
+			StopID:        entry.StopID,
+			SequenceNum:   position + 1,
+			Name:          names[entry.StopID],
+			ArrivalTime:   entry.Arrival,
+			ServiceStart:  entry.ServiceStart,
+			DepartureTime: entry.Departure,
+			WaitSeconds:   entry.WaitSeconds,
+			LateSeconds:   entry.LateSeconds,
+			IsLate:        entry.IsLate(),
+		})
+	}
+
+	return response
+}
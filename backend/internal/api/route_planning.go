@@ -0,0 +1,274 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"routrapp-api/internal/events"
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/optimizer"
+	"routrapp-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PlanRoutes handles POST /api/v1/routes/plan
+func (h *RouteHandler) PlanRoutes(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	var req validation.RoutePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithContext(c).Errorf("Invalid route plan request: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	for i, stop := range req.Stops {
+		if err := validation.ValidateTimeWindow(stop.TimeWindow); err != nil {
+			respondWithError(c, http.StatusBadRequest, fmt.Sprintf("invalid time window for stop %d: %v", i, err), "INVALID_ROUTE_STOPS")
+			return
+		}
+	}
+
+	technicians, skipped, ok := h.availableTechnicians(c, organizationID, req.TechnicianIDs)
+	if !ok {
+		return
+	}
+	if len(technicians) == 0 {
+		logger.WithContext(c).Warnf("Route planning failed: no available technicians in organization %d", organizationID)
+		respondWithErrorDetails(c, http.StatusBadRequest, "No active technicians with a known location", "NO_AVAILABLE_TECHNICIANS", map[string]interface{}{
+			"skipped_technicians": skipped,
+		})
+		return
+	}
+
+	stops := make([]optimizer.Stop, 0, len(req.Stops))
+	windows := false
+	for i, stop := range req.Stops {
+		input := optimizer.Stop{
+			ID:             uint(i),
+			Lat:            stop.Lat,
+			Lng:            stop.Lng,
+			ServiceMinutes: stop.Duration,
+		}
+		if stop.TimeWindow != nil {
+			input.WindowStart = stop.TimeWindow.StartTime
+			input.WindowEnd = stop.TimeWindow.EndTime
+			windows = windows || input.WindowStart != nil || input.WindowEnd != nil
+		}
+		stops = append(stops, input)
+	}
+
+	vehicles := make([]optimizer.Vehicle, 0, len(technicians))
+	for _, technician := range technicians {
+		vehicles = append(vehicles, optimizer.Vehicle{
+			ID:  technician.ID,
+			Lat: *technician.CurrentLat,
+			Lng: *technician.CurrentLng,
+		})
+	}
+
+	opts := optimizer.DefaultPlanOptions()
+	if req.MaxStopsPerRoute != nil {
+		opts.MaxStopsPerRoute = *req.MaxStopsPerRoute
+	}
+	if req.AverageSpeedKmh != nil {
+		opts.AverageSpeedKmh = *req.AverageSpeedKmh
+	}
+	if req.BalanceWeight != nil {
+		opts.BalanceWeight = *req.BalanceWeight
+	}
+	if req.LatePenaltyPerMinute != nil {
+		opts.LatePenaltyPerMinute = *req.LatePenaltyPerMinute
+	}
+
+	startTime := req.StartTime
+	if startTime == nil {
+		startTime = req.ScheduledDate
+	}
+	if startTime != nil {
+		opts.StartTime = *startTime
+	}
+
+	// Time windows are hard constraints unless the caller opts out, so planning stops with
+	// windows needs a start time
+	opts.TimeWindows = optimizer.TimeWindowMode(req.TimeWindowMode)
+	if opts.TimeWindows == "" {
+		opts.TimeWindows = optimizer.TimeWindowsIgnore
+		if windows {
+			opts.TimeWindows = optimizer.TimeWindowsHard
+		}
+	}
+	if opts.TimeWindows != optimizer.TimeWindowsIgnore && startTime == nil {
+		respondWithError(c, http.StatusBadRequest, "A start time or scheduled date is required to enforce time windows", "START_TIME_REQUIRED")
+		return
+	}
+
+	plan, err := optimizer.PlanRoutes(stops, vehicles, opts)
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to plan routes: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Failed to plan routes: "+err.Error(), "OPTIMIZATION_ERROR")
+		return
+	}
+
+	lateStops := make([]int, 0)
+	for _, planned := range plan.Routes {
+		for _, id := range planned.LateStops {
+			lateStops = append(lateStops, int(id))
+		}
+	}
+	sort.Ints(lateStops)
+
+	if opts.TimeWindows == optimizer.TimeWindowsHard && len(lateStops) > 0 {
+		logger.WithContext(c).Warnf("Route plan cannot meet time windows for stops %v", lateStops)
+		respondWithErrorDetails(c, http.StatusUnprocessableEntity, "No plan meets every time window", "TIME_WINDOWS_INFEASIBLE", map[string]interface{}{
+			"late_stops": lateStops,
+		})
+		return
+	}
+
+	// Technicians left without stops do not get an empty route
+	routes := make([]models.Route, 0, len(plan.Routes))
+	for i, planned := range plan.Routes {
+		if len(planned.Order) == 0 {
+			continue
+		}
+		routes = append(routes, newPlannedRoute(organizationID, req, technicians[i], planned))
+	}
+
+	if !req.DryRun && len(routes) > 0 {
+		err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+			for i := range routes {
+				// The technician is already loaded; skip upserting it alongside the route
+				if err := tx.Omit("Technician").Create(&routes[i]).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			logger.WithContext(c).Errorf("Failed to create planned routes: %v", err)
+			respondWithError(c, http.StatusInternalServerError, "Failed to create routes", "ROUTE_CREATION_ERROR")
+			return
+		}
+		// Dispatchers watching the stream see the routes once they were committed
+		for _, route := range routes {
+			h.events.Publish(organizationID, events.EventRouteStatus, events.RouteStatusEvent{
+				RouteID:      route.ID,
+				TechnicianID: route.TechnicianID,
+				Status:       route.Status,
+			})
+		}
+	}
+
+	response := validation.RoutePlanResponse{
+		Routes:             make([]validation.RouteResponse, 0, len(routes)),
+		UnassignedStops:    append([]int{}, plan.Unassigned...),
+		SkippedTechnicians: skipped,
+		LateStops:          lateStops,
+		TotalDistance:      plan.TotalDistanceKm,
+		MaxDuration:        plan.MaxDurationSeconds,
+	}
+	for _, route := range routes {
+		response.Routes = append(response.Routes, newRouteResponse(route))
+	}
+
+	status, message := http.StatusCreated, "Routes planned successfully"
+	if req.DryRun {
+		status, message = http.StatusOK, "Route plan preview generated"
+	}
+
+	logger.WithContext(c).Infof("Planned %d stops into %d routes (%d unassigned, dry run: %t)", len(req.Stops), len(routes), len(plan.Unassigned), req.DryRun)
+	c.JSON(status, gin.H{
+		"success": true,
+		"data":    response,
+		"message": message,
+	})
+}
+
+// availableTechnicians loads the technicians a plan can use: active and with a known location.
+// Requested technicians that exist but are unusable are returned as skipped. It writes the
+// error response itself and returns false when the technicians cannot be loaded.
+func (h *RouteHandler) availableTechnicians(c *gin.Context, organizationID uint, technicianIDs []uint) ([]models.Technician, []uint, bool) {
+	query := requestDB(c, h.db).Preload("User").Where("organization_id = ?", organizationID)
+	if len(technicianIDs) > 0 {
+		query = query.Where("id IN ?", technicianIDs)
+	}
+
+	var technicians []models.Technician
+	if err := query.Order("id ASC").Find(&technicians).Error; err != nil {
+		logger.WithContext(c).Errorf("Database error loading technicians: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to load technicians", "DATABASE_ERROR")
+		return nil, nil, false
+	}
+
+	if len(technicianIDs) > 0 {
+		found := make(map[uint]bool, len(technicians))
+		for _, technician := range technicians {
+			found[technician.ID] = true
+		}
+		for _, id := range technicianIDs {
+			if !found[id] {
+				logger.WithContext(c).Warnf("Technician %d not found in organization %d", id, organizationID)
+				respondWithError(c, http.StatusBadRequest, fmt.Sprintf("Technician %d not found in organization", id), "INVALID_TECHNICIAN")
+				return nil, nil, false
+			}
+		}
+	}
+
+	available := make([]models.Technician, 0, len(technicians))
+	skipped := make([]uint, 0)
+	for _, technician := range technicians {
+		if technician.Status != models.TechnicianStatusActive || technician.CurrentLat == nil || technician.CurrentLng == nil {
+			// Only report technicians the caller asked for; inactive staff are expected to be left out
+			if len(technicianIDs) > 0 || technician.Status == models.TechnicianStatusActive {
+				skipped = append(skipped, technician.ID)
+			}
+			continue
+		}
+		available = append(available, technician)
+	}
+
+	return available, skipped, true
+}
+
+// newPlannedRoute builds an assigned route for a technician from a planned stop sequence
+func newPlannedRoute(organizationID uint, req validation.RoutePlanRequest, technician models.Technician, planned optimizer.PlannedRoute) models.Route {
+	route := models.Route{
+		Base: models.Base{
+			OrganizationID: organizationID,
+		},
+		Name:          fmt.Sprintf("%s - %s", req.Name, technician.User.GetFullName()),
+		TechnicianID:  &technician.ID,
+		Technician:    &technician,
+		Status:        models.RouteStatusAssigned,
+		ScheduledDate: req.ScheduledDate,
+		Notes:         req.Notes,
+		IsOptimized:   true,
+		TotalDistance: planned.DistanceKm,
+		TotalDuration: planned.DurationSeconds,
+	}
+
+	for position, index := range planned.Order {
+		stop := req.Stops[index]
+		route.Stops = append(route.Stops, newRouteStop(organizationID, validation.RouteStopCreateRequest{
+			Name:        stop.Name,
+			Address:     stop.Address,
+			Lat:         stop.Lat,
+			Lng:         stop.Lng,
+			SequenceNum: position + 1,
+			StopType:    stop.StopType,
+			Duration:    stop.Duration,
+			Notes:       stop.Notes,
+			TimeWindow:  stop.TimeWindow,
+		}))
+	}
+
+	return route
+}
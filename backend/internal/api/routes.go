@@ -0,0 +1,609 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"routrapp-api/internal/events"
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+	"routrapp-api/internal/repositories/postgres"
+	"routrapp-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RouteHandler handles route management requests
+type RouteHandler struct {
+	db          *gorm.DB
+	events      *events.Hub
+	routes      repositories.RouteRepository
+	technicians repositories.TechnicianRepository
+	activities  repositories.ActivityRepository
+}
+
+// NewRouteHandler creates a new route handler that does not publish events
+func NewRouteHandler(db *gorm.DB) *RouteHandler {
+	return NewRouteHandlerWithRepositories(db, postgres.NewRepositories(db), nil)
+}
+
+// NewRouteHandlerWithEvents creates a new route handler that publishes changes to the event hub
+func NewRouteHandlerWithEvents(db *gorm.DB, hub *events.Hub) *RouteHandler {
+	return NewRouteHandlerWithRepositories(db, postgres.NewRepositories(db), hub)
+}
+
+// NewRouteHandlerWithRepositories creates a route handler that lists, creates, updates and deletes
+// routes and reads activity timelines through the repositories. db is still used for the stop
+// checklists and by the lifecycle, activity, optimization and proof endpoints. hub may be nil.
+func NewRouteHandlerWithRepositories(db *gorm.DB, repos repositories.Repositories, hub *events.Hub) *RouteHandler {
+	return &RouteHandler{
+		db:          db,
+		events:      hub,
+		routes:      repos.Routes,
+		technicians: repos.Technicians,
+		activities:  repos.Activities,
+	}
+}
+
+// ListRoutes handles GET /api/v1/routes
+func (h *RouteHandler) ListRoutes(c *gin.Context) {
+	if _, ok := requireOrganizationID(c); !ok {
+		return
+	}
+
+	var pagination validation.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		logger.WithContext(c).Errorf("Invalid pagination parameters: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid pagination parameters: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	var filters validation.RouteFilterRequest
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		logger.WithContext(c).Errorf("Invalid route filter parameters: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid filter parameters: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	if filters.DateFrom != nil && filters.DateTo != nil && filters.DateTo.Before(*filters.DateFrom) {
+		respondWithError(c, http.StatusBadRequest, "date_to must be after date_from", "INVALID_DATE_RANGE")
+		return
+	}
+
+	filter := repositories.RouteFilter{
+		Statuses:     filters.Status,
+		TechnicianID: filters.TechnicianID,
+		DateFrom:     filters.DateFrom,
+		DateTo:       filters.DateTo,
+		Search:       filters.Search,
+		SortBy:       filters.SortBy,
+		SortDesc:     filters.SortDesc,
+	}
+	routes, total, err := h.routes.List(c.Request.Context(), filter, repositories.Page{Page: pagination.Page, PageSize: pagination.PageSize})
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to list routes: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch routes", "DATABASE_ERROR")
+		return
+	}
+
+	routeIDs := make([]uint, 0, len(routes))
+	for _, route := range routes {
+		routeIDs = append(routeIDs, route.ID)
+	}
+	stopCounts, err := h.routes.CountStops(c.Request.Context(), routeIDs)
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to count route stops: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch routes", "DATABASE_ERROR")
+		return
+	}
+
+	routeResponses := make([]validation.RouteResponse, 0, len(routes))
+	for _, route := range routes {
+		response := newRouteResponse(route)
+		response.StopsCount = stopCounts[route.ID]
+		routeResponses = append(routeResponses, response)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       routeResponses,
+		"pagination": validation.CalculatePagination(pagination.Page, pagination.PageSize, total),
+	})
+}
+
+// GetRoute handles GET /api/v1/routes/:id
+func (h *RouteHandler) GetRoute(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	routeID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	route, ok := h.loadRoute(c, organizationID, routeID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newRouteResponse(*route),
+	})
+}
+
+// CreateRoute handles POST /api/v1/routes
+func (h *RouteHandler) CreateRoute(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	var req validation.RouteCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithContext(c).Errorf("Invalid route creation request: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	if err := validation.ValidateRouteStops(req.Stops); err != nil {
+		logger.WithContext(c).Warnf("Route creation failed: invalid stops: %v", err)
+		respondWithError(c, http.StatusBadRequest, err.Error(), "INVALID_ROUTE_STOPS")
+		return
+	}
+
+	if req.TechnicianID != nil {
+		if !h.technicianAssignable(c, organizationID, *req.TechnicianID) {
+			return
+		}
+	}
+
+	route := models.Route{
+		Base: models.Base{
+			OrganizationID: organizationID,
+		},
+		Name:          req.Name,
+		Description:   req.Description,
+		TechnicianID:  req.TechnicianID,
+		Status:        models.RouteStatusPending,
+		ScheduledDate: req.ScheduledDate,
+		Notes:         req.Notes,
+	}
+	if req.TechnicianID != nil {
+		route.Status = models.RouteStatusAssigned
+	}
+
+	for _, stopReq := range req.Stops {
+		route.Stops = append(route.Stops, newRouteStop(organizationID, stopReq))
+	}
+
+	if err := h.routes.Create(c.Request.Context(), &route); err != nil {
+		logger.WithContext(c).Errorf("Failed to create route: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to create route", "ROUTE_CREATION_ERROR")
+		return
+	}
+
+	created, ok := h.loadRoute(c, organizationID, route.ID)
+	if !ok {
+		return
+	}
+
+	logger.WithContext(c).Infof("Route %d created with %d stops", route.ID, len(route.Stops))
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    newRouteResponse(*created),
+		"message": "Route created successfully",
+	})
+}
+
+// UpdateRoute handles PATCH /api/v1/routes/:id
+func (h *RouteHandler) UpdateRoute(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	routeID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req validation.RouteUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithContext(c).Errorf("Invalid route update request: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	if req.Name == nil && req.Description == nil && req.TechnicianID == nil && req.Status == nil &&
+		req.ScheduledDate == nil && req.Notes == nil && len(req.Stops) == 0 {
+		respondWithError(c, http.StatusBadRequest, "At least one field must be provided for update", "NO_FIELDS_PROVIDED")
+		return
+	}
+
+	if err := validation.ValidateUpdateRouteStops(req.Stops); err != nil {
+		logger.WithContext(c).Warnf("Route update failed: invalid stops: %v", err)
+		respondWithError(c, http.StatusBadRequest, err.Error(), "INVALID_ROUTE_STOPS")
+		return
+	}
+
+	route, ok := h.loadRoute(c, organizationID, routeID)
+	if !ok {
+		return
+	}
+
+	if req.TechnicianID != nil {
+		// Reassigning would leave the current technician marked on_route
+		if route.Status.IsInProgress() && (route.TechnicianID == nil || *route.TechnicianID != *req.TechnicianID) {
+			respondWithError(c, http.StatusConflict, "Cannot reassign a route that is in progress", "ROUTE_IN_PROGRESS")
+			return
+		}
+		if !h.technicianAssignable(c, organizationID, *req.TechnicianID) {
+			return
+		}
+	}
+
+	if req.Status != nil && *req.Status != route.Status && !h.checkStatusUpdate(c, route, *req.Status, req.TechnicianID) {
+		return
+	}
+
+	update := repositories.RouteUpdate{
+		Name:          req.Name,
+		Description:   req.Description,
+		TechnicianID:  req.TechnicianID,
+		Status:        req.Status,
+		ScheduledDate: req.ScheduledDate,
+		Notes:         req.Notes,
+	}
+	if req.TechnicianID != nil && route.Status == models.RouteStatusPending && req.Status == nil {
+		assigned := models.RouteStatusAssigned
+		update.Status = &assigned
+	}
+	// Moving back to pending releases the technician
+	if req.Status != nil && *req.Status == models.RouteStatusPending && route.Status != models.RouteStatusPending && req.TechnicianID == nil {
+		update.UnassignTechnician = true
+	}
+
+	stopsByID := make(map[uint]models.RouteStop, len(route.Stops))
+	sequence := make(map[uint]int, len(route.Stops))
+	for _, stop := range route.Stops {
+		stopsByID[stop.ID] = stop
+		sequence[stop.ID] = stop.SequenceNum
+	}
+	for _, stopReq := range req.Stops {
+		stop, exists := stopsByID[stopReq.ID]
+		if !exists {
+			respondWithError(c, http.StatusBadRequest, fmt.Sprintf("Stop %d does not belong to this route", stopReq.ID), "STOP_NOT_FOUND")
+			return
+		}
+		stopUpdate := newRouteStopUpdate(stopReq)
+		if stopReq.SequenceNum != nil {
+			sequence[stopReq.ID] = *stopReq.SequenceNum
+			if *stopReq.SequenceNum == stop.SequenceNum {
+				stopUpdate.SequenceNum = nil
+			}
+		}
+		if completesStop(stopReq, stop) {
+			stopType := stop.StopType
+			if stopReq.StopType != nil {
+				stopType = *stopReq.StopType
+			}
+			if !allowCompletionWithoutProof(c, requestDB(c, h.db), organizationID, route.ID, stopReq.ID, stopType) {
+				return
+			}
+		} else if stopReq.IsCompleted != nil && *stopReq.IsCompleted {
+			// Already completed; completing it again would move its completion time
+			stopUpdate.IsCompleted = nil
+		}
+		update.Stops = append(update.Stops, stopUpdate)
+	}
+	if err := checkUniqueSequence(sequence); err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error(), "INVALID_ROUTE_STOPS")
+		return
+	}
+
+	err := h.routes.Update(c.Request.Context(), route.ID, update)
+	if errors.Is(err, repositories.ErrStopAlreadyCompleted) {
+		respondWithError(c, http.StatusConflict, "Stop is already completed", "STOP_ALREADY_COMPLETED")
+		return
+	}
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to update route %d: %v", routeID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to update route", "ROUTE_UPDATE_ERROR")
+		return
+	}
+
+	updated, ok := h.loadRoute(c, organizationID, routeID)
+	if !ok {
+		return
+	}
+
+	h.publishRouteChanges(*route, *updated)
+
+	logger.WithContext(c).Infof("Route %d updated successfully", routeID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newRouteResponse(*updated),
+		"message": "Route updated successfully",
+	})
+}
+
+// DeleteRoute handles DELETE /api/v1/routes/:id
+func (h *RouteHandler) DeleteRoute(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	routeID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	route, ok := h.loadRoute(c, organizationID, routeID)
+	if !ok {
+		return
+	}
+
+	if route.Status == models.RouteStatusStarted || route.Status == models.RouteStatusPaused {
+		logger.WithContext(c).Warnf("Route deletion rejected: route %d is in progress", routeID)
+		respondWithError(c, http.StatusConflict, "Cannot delete a route that is in progress", "ROUTE_IN_PROGRESS")
+		return
+	}
+
+	if err := h.routes.Delete(c.Request.Context(), route.ID); err != nil {
+		logger.WithContext(c).Errorf("Failed to delete route %d: %v", routeID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to delete route", "ROUTE_DELETE_ERROR")
+		return
+	}
+
+	logger.WithContext(c).Infof("Route %d deleted successfully", routeID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Route deleted successfully",
+	})
+}
+
+// checkStatusUpdate validates a status change requested through UpdateRoute. Only the planning
+// transitions (assign and unassign) can be made this way; driving statuses have dedicated
+// endpoints. It writes the error response itself and returns false when the change is rejected.
+func (h *RouteHandler) checkStatusUpdate(c *gin.Context, route *models.Route, status models.RouteStatus, technicianID *uint) bool {
+	action := models.RouteActionUnassign
+	if status == models.RouteStatusAssigned {
+		action = models.RouteActionAssign
+	}
+
+	if status != models.RouteStatusPending && status != models.RouteStatusAssigned {
+		respondWithErrorDetails(c, http.StatusConflict, "Use the route lifecycle endpoints to change the status to "+string(status), "INVALID_STATUS_TRANSITION", map[string]interface{}{
+			"current_status":  route.Status,
+			"allowed_actions": route.Status.AllowedActions(),
+		})
+		return false
+	}
+
+	if _, err := route.Status.Apply(action); err != nil {
+		var transitionErr *models.RouteTransitionError
+		errors.As(err, &transitionErr)
+		respondWithTransitionError(c, transitionErr)
+		return false
+	}
+
+	if action == models.RouteActionAssign && route.TechnicianID == nil && technicianID == nil {
+		respondWithError(c, http.StatusBadRequest, "A technician is required to assign a route", "TECHNICIAN_REQUIRED")
+		return false
+	}
+	return true
+}
+
+// loadRoute fetches a route with its ordered stops and technician, scoped to the organization.
+// It writes the error response itself and returns false when the route cannot be loaded.
+func (h *RouteHandler) loadRoute(c *gin.Context, organizationID, routeID uint) (*models.Route, bool) {
+	route, err := h.routes.FindByID(c.Request.Context(), routeID)
+	if err != nil {
+		if err == repositories.ErrNotFound {
+			logger.WithContext(c).Warnf("Route %d not found in organization %d", routeID, organizationID)
+			respondWithError(c, http.StatusNotFound, "Route not found", "ROUTE_NOT_FOUND")
+			return nil, false
+		}
+		logger.WithContext(c).Errorf("Database error fetching route %d: %v", routeID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch route", "DATABASE_ERROR")
+		return nil, false
+	}
+	return route, true
+}
+
+// technicianAssignable verifies that the technician belongs to the organization and was not deactivated,
+// writing an error response if not
+func (h *RouteHandler) technicianAssignable(c *gin.Context, organizationID, technicianID uint) bool {
+	technician, err := h.technicians.FindByID(c.Request.Context(), technicianID)
+	if err != nil && err != repositories.ErrNotFound {
+		logger.WithContext(c).Errorf("Database error checking technician %d: %v", technicianID, err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+		return false
+	}
+	if err == repositories.ErrNotFound {
+		logger.WithContext(c).Warnf("Technician %d not found in organization %d", technicianID, organizationID)
+		respondWithError(c, http.StatusBadRequest, "Technician not found in organization", "INVALID_TECHNICIAN")
+		return false
+	}
+	if technician.Status == models.TechnicianStatusInactive {
+		respondWithError(c, http.StatusBadRequest, "Inactive technicians cannot be assigned routes", "TECHNICIAN_INACTIVE")
+		return false
+	}
+	return true
+}
+
+// checkUniqueSequence ensures no two stops end up sharing a sequence number
+func checkUniqueSequence(sequence map[uint]int) error {
+	stopIDs := make([]uint, 0, len(sequence))
+	for stopID := range sequence {
+		stopIDs = append(stopIDs, stopID)
+	}
+	sort.Slice(stopIDs, func(i, j int) bool { return stopIDs[i] < stopIDs[j] })
+
+	seen := make(map[int]uint, len(sequence))
+	for _, stopID := range stopIDs {
+		sequenceNum := sequence[stopID]
+		if otherID, exists := seen[sequenceNum]; exists {
+			return fmt.Errorf("stops %d and %d would share sequence number %d", otherID, stopID, sequenceNum)
+		}
+		seen[sequenceNum] = stopID
+	}
+	return nil
+}
+
+// newRouteStop builds a route stop model from a creation request
+func newRouteStop(organizationID uint, req validation.RouteStopCreateRequest) models.RouteStop {
+	return models.RouteStop{
+		Base: models.Base{
+			OrganizationID: organizationID,
+		},
+		Name:        req.Name,
+		Address:     req.Address,
+		Lat:         req.Lat,
+		Lng:         req.Lng,
+		SequenceNum: req.SequenceNum,
+		StopType:    req.StopType,
+		Duration:    req.Duration,
+		Notes:       req.Notes,
+		TimeWindow:  newTimeWindow(req.TimeWindow),
+	}
+}
+
+// publishRouteChanges publishes the status and stop completion changes between two
+// versions of a route
+func (h *RouteHandler) publishRouteChanges(before, after models.Route) {
+	if before.Status != after.Status {
+		h.events.Publish(after.OrganizationID, events.EventRouteStatus, events.RouteStatusEvent{
+			RouteID:        after.ID,
+			TechnicianID:   after.TechnicianID,
+			Status:         after.Status,
+			PreviousStatus: before.Status,
+		})
+	}
+
+	completed := make(map[uint]bool, len(before.Stops))
+	for _, stop := range before.Stops {
+		completed[stop.ID] = stop.IsCompleted
+	}
+	for _, stop := range after.Stops {
+		if stop.IsCompleted != completed[stop.ID] {
+			h.events.Publish(after.OrganizationID, events.EventStopCompleted, events.StopCompletedEvent{
+				RouteID:     after.ID,
+				StopID:      stop.ID,
+				IsCompleted: stop.IsCompleted,
+				CompletedAt: stop.CompletedAt,
+			})
+		}
+	}
+}
+
+// newRouteStopUpdate converts a stop update request into the repository's stop update
+func newRouteStopUpdate(req validation.RouteStopUpdateRequest) repositories.RouteStopUpdate {
+	update := repositories.RouteStopUpdate{
+		ID:          req.ID,
+		Name:        req.Name,
+		Address:     req.Address,
+		Lat:         req.Lat,
+		Lng:         req.Lng,
+		StopType:    req.StopType,
+		Duration:    req.Duration,
+		Notes:       req.Notes,
+		SequenceNum: req.SequenceNum,
+		IsCompleted: req.IsCompleted,
+	}
+	if req.TimeWindow != nil {
+		update.TimeWindow = &models.TimeWindow{
+			StartTime: req.TimeWindow.StartTime,
+			EndTime:   req.TimeWindow.EndTime,
+		}
+	}
+	return update
+}
+
+// completesStop reports whether a stop update marks a stop completed that is still open
+func completesStop(req validation.RouteStopUpdateRequest, stop models.RouteStop) bool {
+	return req.IsCompleted != nil && *req.IsCompleted && !stop.IsCompleted
+}
+
+// newTimeWindow converts a time window request into the embedded model type
+func newTimeWindow(req *validation.TimeWindowRequest) *models.TimeWindow {
+	if req == nil || (req.StartTime == nil && req.EndTime == nil) {
+		return nil
+	}
+	return &models.TimeWindow{
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+	}
+}
+
+// newRouteResponse converts a route model into its API representation
+func newRouteResponse(route models.Route) validation.RouteResponse {
+	response := validation.RouteResponse{
+		BaseResponse: validation.BaseResponse{
+			ID:        route.ID,
+			CreatedAt: route.CreatedAt,
+			UpdatedAt: route.UpdatedAt,
+		},
+		Name:          route.Name,
+		Description:   route.Description,
+		Status:        route.Status,
+		TechnicianID:  route.TechnicianID,
+		ScheduledDate: route.ScheduledDate,
+		StartedAt:     route.StartedAt,
+		CompletedAt:   route.CompletedAt,
+		CancelledAt:   route.CancelledAt,
+		IsOptimized:   route.IsOptimized,
+		TotalDistance: route.TotalDistance,
+		TotalDuration: route.TotalDuration,
+		Notes:         route.Notes,
+		StopsCount:    len(route.Stops),
+	}
+
+	if route.Technician != nil && route.Technician.ID != 0 {
+		technician := newTechnicianResponse(*route.Technician)
+		response.Technician = &technician
+	}
+
+	for _, stop := range route.Stops {
+		response.Stops = append(response.Stops, newRouteStopResponse(stop))
+	}
+
+	return response
+}
+
+// newRouteStopResponse converts a route stop model into its API representation
+func newRouteStopResponse(stop models.RouteStop) validation.RouteStopResponse {
+	response := validation.RouteStopResponse{
+		ID:          stop.ID,
+		Name:        stop.Name,
+		Address:     stop.Address,
+		Lat:         stop.Lat,
+		Lng:         stop.Lng,
+		SequenceNum: stop.SequenceNum,
+		StopType:    stop.StopType,
+		Duration:    stop.Duration,
+		Notes:       stop.Notes,
+		IsCompleted: stop.IsCompleted,
+		CompletedAt: stop.CompletedAt,
+		PhotosCount: stop.PhotosCount,
+		NotesCount:  stop.NotesCount,
+		CreatedAt:   stop.CreatedAt,
+		UpdatedAt:   stop.UpdatedAt,
+	}
+
+	if stop.TimeWindow != nil && (stop.TimeWindow.StartTime != nil || stop.TimeWindow.EndTime != nil) {
+		response.TimeWindow = &validation.TimeWindowResponse{
+			StartTime: stop.TimeWindow.StartTime,
+			EndTime:   stop.TimeWindow.EndTime,
+		}
+	}
+
+	return response
+}
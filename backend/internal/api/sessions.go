@@ -0,0 +1,246 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/utils/auth"
+	"routrapp-api/internal/utils/constants"
+	"routrapp-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// startSession records a new session for the user signing in on the request's device and returns its tokens.
+// db must be scoped to the user's organization.
+func (h *AuthHandler) startSession(c *gin.Context, db *gorm.DB, user models.User, deviceName string) (accessToken, refreshToken string, err error) {
+	familyID, err := auth.NewRandomToken()
+	if err != nil {
+		return "", "", err
+	}
+	accessToken, refreshToken, err = h.jwtService.GenerateSessionTokens(familyID, user.ID, user.OrganizationID, user.Email, user.Role.Name.String())
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	session := models.UserSession{
+		Base: models.Base{
+			OrganizationID: user.OrganizationID,
+		},
+		UserID:           user.ID,
+		FamilyID:         familyID,
+		RefreshTokenHash: auth.HashToken(refreshToken),
+		DeviceName:       deviceName,
+		IPAddress:        c.ClientIP(),
+		UserAgent:        c.Request.UserAgent(),
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(time.Duration(constants.JWT_REFRESH_TOKEN_EXPIRY) * time.Second),
+	}
+	if err := db.Create(&session).Error; err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
+
+// refreshSession loads the active session a refresh token was issued for. A token that is not the
+// session's current one was already rotated, so whoever presents it holds a copy: the session is
+// revoked, signing out both the legitimate device and the copy. It writes the error response itself
+// and returns false when the token cannot be refreshed.
+func (h *AuthHandler) refreshSession(c *gin.Context, claims *auth.JWTClaims, refreshToken string) (*models.UserSession, bool) {
+	if claims.FamilyID == "" {
+		logger.WithContext(c).Warnf("Refresh token of user %d was not issued for a session", claims.UserID)
+		respondWithError(c, http.StatusUnauthorized, "Invalid refresh token", "INVALID_REFRESH_TOKEN")
+		return nil, false
+	}
+
+	var session models.UserSession
+	if err := requestDB(c, h.db).Where("family_id = ? AND user_id = ?", claims.FamilyID, claims.UserID).First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			logger.WithContext(c).Warnf("Session not found for refresh token of user %d", claims.UserID)
+			respondWithError(c, http.StatusUnauthorized, "Invalid refresh token", "INVALID_REFRESH_TOKEN")
+			return nil, false
+		}
+		logger.WithContext(c).Errorf("Database error loading session: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+		return nil, false
+	}
+
+	if !session.IsActive(time.Now()) {
+		logger.WithContext(c).Warnf("Refresh token presented for inactive session %d of user %d", session.ID, session.UserID)
+		respondWithError(c, http.StatusUnauthorized, "Session has been revoked or has expired", "INVALID_REFRESH_TOKEN")
+		return nil, false
+	}
+	if session.RefreshTokenHash != auth.HashToken(refreshToken) {
+		h.rejectReusedToken(c, &session)
+		return nil, false
+	}
+	return &session, true
+}
+
+// rotateSession replaces the session's refresh token. The current token is part of the condition, so
+// of two requests racing with the same token only one rotates and the other is treated as reuse.
+func (h *AuthHandler) rotateSession(c *gin.Context, session *models.UserSession, presentedToken, refreshToken string) bool {
+	now := time.Now()
+	result := requestDB(c, h.db).Model(&models.UserSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, auth.HashToken(presentedToken)).
+		Updates(map[string]interface{}{
+			"refresh_token_hash": auth.HashToken(refreshToken),
+			"ip_address":         c.ClientIP(),
+			"user_agent":         c.Request.UserAgent(),
+			"last_used_at":       now,
+			"expires_at":         now.Add(time.Duration(constants.JWT_REFRESH_TOKEN_EXPIRY) * time.Second),
+		})
+	if result.Error != nil {
+		logger.WithContext(c).Errorf("Failed to rotate session %d: %v", session.ID, result.Error)
+		respondWithError(c, http.StatusInternalServerError, "Failed to refresh token", "INTERNAL_ERROR")
+		return false
+	}
+	if result.RowsAffected == 0 {
+		h.rejectReusedToken(c, session)
+		return false
+	}
+	return true
+}
+
+// rejectReusedToken revokes a session whose rotated refresh token was presented again
+func (h *AuthHandler) rejectReusedToken(c *gin.Context, session *models.UserSession) {
+	logger.WithContext(c).Warnf("Reused refresh token for session %d of user %d from %s, revoking the session", session.ID, session.UserID, c.ClientIP())
+	if _, err := revokeSessions(requestDB(c, h.db), session.UserID, models.SessionRevokedTokenReuse, "id = ?", session.ID); err != nil {
+		logger.WithContext(c).Errorf("Failed to revoke session %d: %v", session.ID, err)
+	}
+	revokeSessionTokens(c, session.FamilyID)
+	respondWithError(c, http.StatusUnauthorized, "Refresh token has already been used; the session was revoked", "REFRESH_TOKEN_REUSED")
+}
+
+// revokeSessions revokes the user's active sessions matching the optional extra condition
+func revokeSessions(db *gorm.DB, userID uint, reason string, conditions ...interface{}) (int64, error) {
+	query := db.Model(&models.UserSession{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+	if len(conditions) > 0 {
+		query = query.Where(conditions[0], conditions[1:]...)
+	}
+	result := query.Updates(map[string]interface{}{
+		"revoked_at":     time.Now(),
+		"revoked_reason": reason,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// revokeSessionTokens puts the access tokens of a revoked session on the deny list, so they stop
+// working before they expire. A failure is logged: the session is revoked either way.
+func revokeSessionTokens(c *gin.Context, familyID string) {
+	if err := middleware.RevokeSessionTokens(c, familyID); err != nil {
+		logger.WithContext(c).Errorf("Failed to revoke the access tokens of session %s: %v", familyID, err)
+	}
+}
+
+// revokeUserTokens puts the access tokens issued to the user so far on the deny list
+func revokeUserTokens(c *gin.Context, userID uint) {
+	if err := middleware.RevokeUserTokens(c, userID); err != nil {
+		logger.WithContext(c).Errorf("Failed to revoke the access tokens of user %d: %v", userID, err)
+	}
+}
+
+// ListSessions handles GET /api/v1/auth/sessions
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		respondWithError(c, http.StatusUnauthorized, "Authentication required", "AUTHENTICATION_REQUIRED")
+		return
+	}
+
+	var sessions []models.UserSession
+	if err := requestDB(c, h.db).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to list sessions of user %d: %v", userID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to list sessions", "INTERNAL_ERROR")
+		return
+	}
+
+	currentFamilyID, _ := middleware.GetSessionFamilyID(c)
+	response := make([]validation.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, validation.SessionResponse{
+			ID:         session.ID,
+			DeviceName: session.DeviceName,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			Current:    session.FamilyID == currentFamilyID,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    response,
+	})
+}
+
+// RevokeSession handles DELETE /api/v1/auth/sessions/:id
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		respondWithError(c, http.StatusUnauthorized, "Authentication required", "AUTHENTICATION_REQUIRED")
+		return
+	}
+	sessionID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var session models.UserSession
+	if err := requestDB(c, h.db).Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondWithError(c, http.StatusNotFound, "Session not found", "SESSION_NOT_FOUND")
+			return
+		}
+		logger.WithContext(c).Errorf("Failed to load session %d of user %d: %v", sessionID, userID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to revoke session", "INTERNAL_ERROR")
+		return
+	}
+
+	if _, err := revokeSessions(requestDB(c, h.db), userID, models.SessionRevokedByUser, "id = ?", session.ID); err != nil {
+		logger.WithContext(c).Errorf("Failed to revoke session %d of user %d: %v", sessionID, userID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to revoke session", "INTERNAL_ERROR")
+		return
+	}
+	revokeSessionTokens(c, session.FamilyID)
+
+	logger.WithContext(c).Infof("User %d revoked session %d", userID, sessionID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Session revoked",
+	})
+}
+
+// RevokeAllSessions handles DELETE /api/v1/auth/sessions, signing the user out on every device
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		respondWithError(c, http.StatusUnauthorized, "Authentication required", "AUTHENTICATION_REQUIRED")
+		return
+	}
+
+	revoked, err := revokeSessions(requestDB(c, h.db), userID, models.SessionRevokedByUser)
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to revoke sessions of user %d: %v", userID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to revoke sessions", "INTERNAL_ERROR")
+		return
+	}
+	revokeUserTokens(c, userID)
+
+	logger.WithContext(c).Infof("User %d revoked all %d sessions", userID, revoked)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"revoked": revoked},
+		"message": "Signed out on all devices",
+	})
+}
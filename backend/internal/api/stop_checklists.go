@@ -0,0 +1,215 @@
+package api
+
+import (
+	"net/http"
+
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// StopChecklistHandler handles the per-organization proof-of-service checklists of each stop type
+type StopChecklistHandler struct {
+	db *gorm.DB
+}
+
+// NewStopChecklistHandler creates a new stop checklist handler
+func NewStopChecklistHandler(db *gorm.DB) *StopChecklistHandler {
+	return &StopChecklistHandler{
+		db: db,
+	}
+}
+
+// ListStopChecklists handles GET /api/v1/stop-checklists
+func (h *StopChecklistHandler) ListStopChecklists(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	var checklists []models.StopChecklist
+	if err := requestDB(c, h.db).Where("organization_id = ?", organizationID).Order("stop_type ASC").Find(&checklists).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to list stop checklists: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch checklists", "DATABASE_ERROR")
+		return
+	}
+
+	responses := make([]validation.StopChecklistResponse, 0, len(checklists))
+	for _, checklist := range checklists {
+		responses = append(responses, newStopChecklistResponse(checklist))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    responses,
+	})
+}
+
+// GetStopChecklist handles GET /api/v1/stop-checklists/:stop_type
+func (h *StopChecklistHandler) GetStopChecklist(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	stopType, ok := parseStopTypeParam(c)
+	if !ok {
+		return
+	}
+
+	checklist, err := findStopChecklist(requestDB(c, h.db), organizationID, stopType)
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to fetch %s checklist: %v", stopType, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch checklist", "DATABASE_ERROR")
+		return
+	}
+	if checklist == nil {
+		respondWithError(c, http.StatusNotFound, "No checklist is defined for "+stopType+" stops", "CHECKLIST_NOT_FOUND")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newStopChecklistResponse(*checklist),
+	})
+}
+
+// PutStopChecklist handles PUT /api/v1/stop-checklists/:stop_type
+// It replaces the checklist of the stop type, creating it if needed.
+func (h *StopChecklistHandler) PutStopChecklist(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	stopType, ok := parseStopTypeParam(c)
+	if !ok {
+		return
+	}
+
+	var req validation.StopChecklistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithContext(c).Errorf("Invalid stop checklist request: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+	if err := validation.ValidateStopChecklist(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error(), "INVALID_CHECKLIST")
+		return
+	}
+
+	items := make(models.ChecklistItems, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, models.ChecklistItem{
+			Key:      item.Key,
+			Label:    item.Label,
+			Type:     models.ChecklistItemType(item.Type),
+			Required: item.Required,
+		})
+	}
+
+	checklist, err := findStopChecklist(requestDB(c, h.db), organizationID, stopType)
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to fetch %s checklist: %v", stopType, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch checklist", "DATABASE_ERROR")
+		return
+	}
+
+	status := http.StatusOK
+	if checklist == nil {
+		status = http.StatusCreated
+		checklist = &models.StopChecklist{
+			Base: models.Base{
+				OrganizationID: organizationID,
+			},
+			StopType: stopType,
+		}
+	}
+	checklist.RequireSignature = req.RequireSignature
+	checklist.RequireRecipientName = req.RequireRecipientName
+	checklist.Items = items
+
+	if err := requestDB(c, h.db).Save(checklist).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to save %s checklist: %v", stopType, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to save checklist", "CHECKLIST_UPDATE_ERROR")
+		return
+	}
+
+	logger.WithContext(c).Infof("Saved %s checklist with %d items", stopType, len(items))
+	c.JSON(status, gin.H{
+		"success": true,
+		"data":    newStopChecklistResponse(*checklist),
+		"message": "Checklist saved successfully",
+	})
+}
+
+// DeleteStopChecklist handles DELETE /api/v1/stop-checklists/:stop_type
+func (h *StopChecklistHandler) DeleteStopChecklist(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	stopType, ok := parseStopTypeParam(c)
+	if !ok {
+		return
+	}
+
+	result := requestDB(c, h.db).Where("organization_id = ? AND stop_type = ?", organizationID, stopType).Delete(&models.StopChecklist{})
+	if result.Error != nil {
+		logger.WithContext(c).Errorf("Failed to delete %s checklist: %v", stopType, result.Error)
+		respondWithError(c, http.StatusInternalServerError, "Failed to delete checklist", "CHECKLIST_DELETION_ERROR")
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondWithError(c, http.StatusNotFound, "No checklist is defined for "+stopType+" stops", "CHECKLIST_NOT_FOUND")
+		return
+	}
+
+	logger.WithContext(c).Infof("Deleted %s checklist", stopType)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Checklist deleted successfully",
+	})
+}
+
+// parseStopTypeParam reads the :stop_type path parameter or writes a validation error
+func parseStopTypeParam(c *gin.Context) (string, bool) {
+	stopType := c.Param("stop_type")
+	if !models.IsValidStopType(stopType) {
+		respondWithError(c, http.StatusBadRequest, "Invalid stop_type: must be one of pickup, delivery, service, maintenance", "INVALID_STOP_TYPE")
+		return "", false
+	}
+	return stopType, true
+}
+
+// findStopChecklist returns the organization's checklist for the stop type, or nil if none is defined
+func findStopChecklist(db *gorm.DB, organizationID uint, stopType string) (*models.StopChecklist, error) {
+	var checklist models.StopChecklist
+	err := db.Where("organization_id = ? AND stop_type = ?", organizationID, stopType).First(&checklist).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &checklist, nil
+}
+
+// newStopChecklistResponse converts a stop checklist model into its API representation
+func newStopChecklistResponse(checklist models.StopChecklist) validation.StopChecklistResponse {
+	items := []models.ChecklistItem(checklist.Items)
+	if items == nil {
+		items = []models.ChecklistItem{}
+	}
+	return validation.StopChecklistResponse{
+		StopType:             checklist.StopType,
+		RequireSignature:     checklist.RequireSignature,
+		RequireRecipientName: checklist.RequireRecipientName,
+		Items:                items,
+		UpdatedAt:            checklist.UpdatedAt,
+	}
+}
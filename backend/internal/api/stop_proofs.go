@@ -0,0 +1,436 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"routrapp-api/internal/events"
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+	"routrapp-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// signatureTypes maps the accepted (sniffed) signature image types to their file extension
+var signatureTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+}
+
+// ProofHandler handles proof-of-service capture when technicians complete or fail stops
+type ProofHandler struct {
+	db          *gorm.DB
+	attachments *AttachmentHandler
+	events      *events.Hub
+}
+
+// NewProofHandler creates a new proof handler storing signatures through the attachment handler
+func NewProofHandler(db *gorm.DB, attachments *AttachmentHandler, hub *events.Hub) *ProofHandler {
+	return &ProofHandler{
+		db:          db,
+		attachments: attachments,
+		events:      hub,
+	}
+}
+
+// CompleteStop handles POST /api/v1/routes/:id/stops/:stop_id/complete
+// It records a successful or failed attempt at the stop. Successful attempts must satisfy the
+// organization's checklist for the stop type; failed attempts must give a reason.
+func (h *ProofHandler) CompleteStop(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	var req validation.StopCompletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithContext(c).Errorf("Invalid stop completion request: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	outcome := models.StopOutcome(req.Outcome)
+	failureReason := models.FailureReason(req.FailureReason)
+	switch {
+	case outcome == models.StopOutcomeFailed && failureReason == "":
+		respondWithError(c, http.StatusBadRequest, "failure_reason is required for a failed stop", "FAILURE_REASON_REQUIRED")
+		return
+	case failureReason == models.FailureOther && req.Notes == "":
+		respondWithError(c, http.StatusBadRequest, "notes are required when the failure reason is other", "NOTES_REQUIRED")
+		return
+	case outcome == models.StopOutcomeCompleted && failureReason != "":
+		respondWithError(c, http.StatusBadRequest, "failure_reason is only allowed for a failed stop", "INVALID_FAILURE_REASON")
+		return
+	}
+
+	now := time.Now()
+	recordedAt := now
+	if req.Timestamp != nil {
+		recordedAt = *req.Timestamp
+	}
+	if recordedAt.After(now.Add(maxClientClockSkew)) {
+		respondWithError(c, http.StatusBadRequest, "Completion timestamp is in the future", "INVALID_COMPLETION_TIMESTAMP")
+		return
+	}
+
+	route, stop, ok := h.attachments.loadStop(c, organizationID)
+	if !ok {
+		return
+	}
+
+	if !h.attachments.routes.isAssignedTechnician(c, route) {
+		return
+	}
+
+	if !route.Status.IsInProgress() {
+		respondWithErrorDetails(c, http.StatusConflict, "Stops can only be completed while the route is in progress", "ROUTE_NOT_IN_PROGRESS", map[string]interface{}{
+			"current_status": route.Status,
+		})
+		return
+	}
+	if stop.IsCompleted {
+		respondWithError(c, http.StatusConflict, "Stop is already completed", "STOP_ALREADY_COMPLETED")
+		return
+	}
+
+	checklist, err := findStopChecklist(requestDB(c, h.db), organizationID, stop.StopType)
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to fetch %s checklist: %v", stop.StopType, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch checklist", "DATABASE_ERROR")
+		return
+	}
+	if checklist == nil {
+		checklist = &models.StopChecklist{}
+	}
+
+	if outcome == models.StopOutcomeCompleted {
+		if checklist.RequireSignature && req.Signature == "" {
+			respondWithError(c, http.StatusBadRequest, "A signature is required to complete "+stop.StopType+" stops", "SIGNATURE_REQUIRED")
+			return
+		}
+		if checklist.RequireRecipientName && strings.TrimSpace(req.RecipientName) == "" {
+			respondWithError(c, http.StatusBadRequest, "A recipient name is required to complete "+stop.StopType+" stops", "RECIPIENT_NAME_REQUIRED")
+			return
+		}
+	}
+
+	answers, ok := checklistAnswers(c, checklist.Items, req.Checklist, outcome == models.StopOutcomeCompleted)
+	if !ok {
+		return
+	}
+
+	proof := models.StopProof{
+		Base: models.Base{
+			OrganizationID: organizationID,
+		},
+		RouteID:       route.ID,
+		RouteStopID:   stop.ID,
+		TechnicianID:  *route.TechnicianID,
+		Outcome:       outcome,
+		RecipientName: strings.TrimSpace(req.RecipientName),
+		Checklist:     answers,
+		FailureReason: failureReason,
+		Notes:         req.Notes,
+		Lat:           req.Lat,
+		Lng:           req.Lng,
+		RecordedAt:    recordedAt,
+	}
+
+	var signature *models.StopAttachment
+	if req.Signature != "" {
+		if signature, ok = h.storeSignature(c, route, stop, req.Signature); !ok {
+			return
+		}
+		proof.SignatureAttachment = signature
+	}
+
+	activityType := models.ActivityComplete
+	if outcome == models.StopOutcomeFailed {
+		activityType = models.ActivityStopFailed
+	}
+	activity := models.RouteActivity{
+		Base: models.Base{
+			OrganizationID: organizationID,
+		},
+		RouteID:      route.ID,
+		RouteStopID:  &stop.ID,
+		TechnicianID: *route.TechnicianID,
+		ActivityType: activityType,
+		Notes:        req.Notes,
+		Lat:          req.Lat,
+		Lng:          req.Lng,
+		Timestamp:    recordedAt,
+	}
+
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if signature != nil {
+			if err := h.attachments.reserveQuota(tx, organizationID, signature.Size); err != nil {
+				return err
+			}
+		}
+		// Creating the proof also creates its signature attachment through the association
+		if err := tx.Create(&proof).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&activity).Error; err != nil {
+			return err
+		}
+
+		// Of two attempts racing at the same stop only one is recorded once it is completed
+		stopUpdates := activityStopUpdates(activity)
+		stopUpdates["updated_at"] = now
+		if err := updateOpenStop(tx, stop.ID, stopUpdates); err != nil {
+			return err
+		}
+		return tx.First(stop, stop.ID).Error
+	})
+	if err != nil {
+		if signature != nil {
+			h.attachments.deleteBlob(c, signature.StorageKey)
+		}
+		if errors.Is(err, repositories.ErrStopAlreadyCompleted) {
+			respondWithError(c, http.StatusConflict, "Stop is already completed", "STOP_ALREADY_COMPLETED")
+			return
+		}
+		var exceeded *quotaExceededError
+		if errors.As(err, &exceeded) {
+			h.attachments.respondQuotaError(c, organizationID, err)
+			return
+		}
+		logger.WithContext(c).Errorf("Failed to record proof for stop %d: %v", stop.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to record stop completion", "PROOF_CREATION_ERROR")
+		return
+	}
+
+	h.events.Publish(organizationID, events.EventRouteActivity, events.RouteActivityEvent{
+		ActivityID:   activity.ID,
+		RouteID:      activity.RouteID,
+		RouteStopID:  activity.RouteStopID,
+		TechnicianID: activity.TechnicianID,
+		ActivityType: activity.ActivityType,
+		Timestamp:    activity.Timestamp,
+	})
+	if outcome == models.StopOutcomeCompleted {
+		h.events.Publish(organizationID, events.EventStopCompleted, events.StopCompletedEvent{
+			RouteID:     route.ID,
+			StopID:      stop.ID,
+			IsCompleted: stop.IsCompleted,
+			CompletedAt: stop.CompletedAt,
+		})
+	}
+
+	logger.WithContext(c).Infof("Recorded %s proof %d for stop %d on route %d", proof.Outcome, proof.ID, stop.ID, route.ID)
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    h.newStopProofResponse(proof),
+		"message": "Stop attempt recorded successfully",
+	})
+}
+
+// GetStopProof handles GET /api/v1/routes/:id/stops/:stop_id/proof
+// It returns the stop together with every recorded attempt, oldest first.
+func (h *ProofHandler) GetStopProof(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	_, stop, ok := h.attachments.loadStop(c, organizationID)
+	if !ok {
+		return
+	}
+
+	var proofs []models.StopProof
+	if err := requestDB(c, h.db).
+		Preload("SignatureAttachment").
+		Where("organization_id = ? AND route_stop_id = ?", organizationID, stop.ID).
+		Order("recorded_at ASC, id ASC").
+		Find(&proofs).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to list proofs for stop %d: %v", stop.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch proof of service", "DATABASE_ERROR")
+		return
+	}
+
+	attempts := make([]validation.StopProofResponse, 0, len(proofs))
+	for _, proof := range proofs {
+		attempts = append(attempts, h.newStopProofResponse(proof))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": validation.StopProofRecordResponse{
+			Stop:     newRouteStopResponse(*stop),
+			Attempts: attempts,
+		},
+	})
+}
+
+// storeSignature decodes the base64 signature image and stores it as a signature attachment of the stop.
+// The returned attachment is not yet saved. It writes the error response itself and returns false on failure.
+func (h *ProofHandler) storeSignature(c *gin.Context, route *models.Route, stop *models.RouteStop, encoded string) (*models.StopAttachment, bool) {
+	// Accept data URLs such as "data:image/png;base64,..." as produced by canvas.toDataURL
+	if strings.HasPrefix(encoded, "data:") {
+		if _, data, found := strings.Cut(encoded, ","); found {
+			encoded = data
+		}
+	}
+
+	image, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(image) == 0 {
+		respondWithError(c, http.StatusBadRequest, "Signature must be a base64 encoded PNG or JPEG image", "INVALID_SIGNATURE_IMAGE")
+		return nil, false
+	}
+	if int64(len(image)) > h.attachments.options.MaxUploadSize {
+		h.attachments.respondFileTooLarge(c)
+		return nil, false
+	}
+
+	contentType, _, _ := strings.Cut(http.DetectContentType(image), ";")
+	extension, supported := signatureTypes[contentType]
+	if !supported {
+		respondWithErrorDetails(c, http.StatusUnsupportedMediaType, "Unsupported signature type "+contentType, "UNSUPPORTED_MEDIA_TYPE", map[string]interface{}{
+			"content_type":  contentType,
+			"allowed_types": []string{"image/png", "image/jpeg"},
+		})
+		return nil, false
+	}
+
+	if !h.attachments.withinQuota(c, route.OrganizationID, int64(len(image))) {
+		return nil, false
+	}
+
+	attachment := &models.StopAttachment{
+		Base: models.Base{
+			OrganizationID: route.OrganizationID,
+		},
+		RouteID:     route.ID,
+		RouteStopID: stop.ID,
+		Kind:        models.AttachmentKindSignature,
+		FileName:    "signature" + extension,
+		ContentType: contentType,
+	}
+	attachment.UploadedByID, _ = middleware.GetUserID(c)
+
+	if !h.attachments.putBlob(c, attachment, extension, bytes.NewReader(image)) {
+		return nil, false
+	}
+	return attachment, true
+}
+
+// checklistAnswers validates the submitted answers against the checklist items and snapshots them with
+// their labels, in checklist order. Required items are only enforced when requireAll is set. It writes
+// the error response itself and returns false when any answer is missing, unknown or invalid.
+func checklistAnswers(c *gin.Context, items models.ChecklistItems, submitted map[string]interface{}, requireAll bool) (models.ChecklistAnswers, bool) {
+	known := make(map[string]bool, len(items))
+	missing := []string{}
+	invalid := []string{}
+	answers := models.ChecklistAnswers{}
+
+	for _, item := range items {
+		known[item.Key] = true
+		value, answered := submitted[item.Key]
+		if !answered || value == nil {
+			if item.Required && requireAll {
+				missing = append(missing, item.Key)
+			}
+			continue
+		}
+		if !item.Type.Accepts(value) {
+			invalid = append(invalid, item.Key)
+			continue
+		}
+		answers = append(answers, models.ChecklistAnswer{
+			Key:   item.Key,
+			Label: item.Label,
+			Value: value,
+		})
+	}
+
+	unknown := []string{}
+	for key := range submitted {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+
+	if len(missing) > 0 || len(invalid) > 0 || len(unknown) > 0 {
+		respondWithErrorDetails(c, http.StatusBadRequest, "Checklist answers are incomplete or invalid", "INVALID_CHECKLIST", map[string]interface{}{
+			"missing": missing,
+			"invalid": invalid,
+			"unknown": unknown,
+		})
+		return nil, false
+	}
+	return answers, true
+}
+
+// newStopProofResponse converts a stop proof model into its API representation
+func (h *ProofHandler) newStopProofResponse(proof models.StopProof) validation.StopProofResponse {
+	response := validation.StopProofResponse{
+		ID:            proof.ID,
+		RouteID:       proof.RouteID,
+		RouteStopID:   proof.RouteStopID,
+		TechnicianID:  proof.TechnicianID,
+		Outcome:       proof.Outcome,
+		RecipientName: proof.RecipientName,
+		Checklist:     proof.Checklist,
+		FailureReason: proof.FailureReason,
+		Notes:         proof.Notes,
+		Lat:           proof.Lat,
+		Lng:           proof.Lng,
+		RecordedAt:    proof.RecordedAt,
+		CreatedAt:     proof.CreatedAt,
+	}
+	if response.Checklist == nil {
+		response.Checklist = []models.ChecklistAnswer{}
+	}
+	if proof.SignatureAttachment != nil {
+		signature := h.attachments.newStopAttachmentResponse(*proof.SignatureAttachment)
+		response.Signature = &signature
+	}
+	return response
+}
+
+// updateOpenStop applies the updates to a stop that is not completed yet. It fails with
+// repositories.ErrStopAlreadyCompleted when the stop was completed in the meantime.
+func updateOpenStop(tx *gorm.DB, stopID uint, updates map[string]interface{}) error {
+	result := tx.Model(&models.RouteStop{}).Where("id = ? AND is_completed = ?", stopID, false).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repositories.ErrStopAlreadyCompleted
+	}
+	return nil
+}
+
+// allowCompletionWithoutProof checks that a stop of the given type may be marked completed outside
+// the stop completion endpoint, which is the only one capturing proof of service. It writes the
+// error response itself and returns false when the organization's checklist requires proof.
+func allowCompletionWithoutProof(c *gin.Context, db *gorm.DB, organizationID, routeID uint, stopID uint, stopType string) bool {
+	checklist, err := findStopChecklist(db, organizationID, stopType)
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to fetch %s checklist: %v", stopType, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch checklist", "DATABASE_ERROR")
+		return false
+	}
+	if checklist.RequiresProof() {
+		respondWithErrorDetails(c, http.StatusBadRequest, "Proof of service is required to complete "+stopType+" stops", "PROOF_REQUIRED", map[string]interface{}{
+			"stop_id":       stopID,
+			"complete_path": fmt.Sprintf("/api/v1/routes/%d/stops/%d/complete", routeID, stopID),
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"routrapp-api/internal/events"
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeatInterval keeps idle connections open through proxies
+const streamHeartbeatInterval = 25 * time.Second
+
+// StreamHandler serves the real-time event stream
+type StreamHandler struct {
+	events    *events.Hub
+	heartbeat time.Duration
+}
+
+// NewStreamHandler creates a new stream handler
+func NewStreamHandler(hub *events.Hub) *StreamHandler {
+	return NewStreamHandlerWithHeartbeat(hub, streamHeartbeatInterval)
+}
+
+// NewStreamHandlerWithHeartbeat creates a stream handler pinging idle connections, and checking
+// that the caller's token wasn't revoked, at the given interval
+func NewStreamHandlerWithHeartbeat(hub *events.Hub, heartbeat time.Duration) *StreamHandler {
+	return &StreamHandler{
+		events:    hub,
+		heartbeat: heartbeat,
+	}
+}
+
+// Stream handles GET /api/v1/stream
+// It sends the caller's organization events as Server-Sent Events until the client disconnects.
+// An optional types query parameter (comma separated) limits the event types sent.
+// The stream ends with a close event once the caller's access token expires or is revoked, so
+// signing out or losing access also stops the events; clients reconnect with a fresh token.
+func (h *StreamHandler) Stream(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	var types map[events.EventType]bool
+	if raw := c.Query("types"); raw != "" {
+		types = make(map[events.EventType]bool)
+		for _, value := range strings.Split(raw, ",") {
+			types[events.EventType(strings.TrimSpace(value))] = true
+		}
+	}
+
+	// The stream outlives the server's write timeout; not every writer supports clearing it
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	sub := h.events.Subscribe(organizationID)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	c.SSEvent("ready", gin.H{"organization_id": organizationID})
+	c.Writer.Flush()
+
+	logger.WithContext(c).Infof("Event stream opened for organization %d", organizationID)
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	// A nil channel never fires, for tokens without an expiry
+	var expired <-chan time.Time
+	if expiresAt, ok := middleware.TokenExpiresAt(c); ok {
+		expiry := time.NewTimer(time.Until(expiresAt))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			logger.WithContext(c).Infof("Event stream closed for organization %d", organizationID)
+			return
+		case event, open := <-sub.Events():
+			if !open {
+				// Closed by the hub because the client fell behind or the server is shutting down
+				logger.WithContext(c).Warnf("Event stream for organization %d disconnected by hub", organizationID)
+				return
+			}
+			if types != nil && !types[event.Type] {
+				continue
+			}
+			c.SSEvent(string(event.Type), event)
+			c.Writer.Flush()
+		case <-expired:
+			logger.WithContext(c).Infof("Event stream closed for organization %d: token expired", organizationID)
+			closeStream(c, "token_expired")
+			return
+		case <-heartbeat.C:
+			revoked, err := middleware.IsCurrentTokenRevoked(c)
+			if err != nil {
+				closeStream(c, "revocation_unavailable")
+				return
+			}
+			if revoked {
+				logger.WithContext(c).Infof("Event stream closed for organization %d: token revoked", organizationID)
+				closeStream(c, "token_revoked")
+				return
+			}
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// closeStream tells the client why the stream ends
+func closeStream(c *gin.Context, reason string) {
+	c.SSEvent("close", gin.H{"reason": reason})
+	c.Writer.Flush()
+}
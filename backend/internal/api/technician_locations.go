@@ -0,0 +1,356 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"routrapp-api/internal/events"
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/optimizer"
+	"routrapp-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxClientClockSkew tolerates device clocks running slightly ahead of the server
+	maxClientClockSkew = 2 * time.Minute
+
+	// defaultTrailRange is the time range returned when no start is given
+	defaultTrailRange = 24 * time.Hour
+
+	// maxTrailRange bounds a single location trail query
+	maxTrailRange = 31 * 24 * time.Hour
+)
+
+// RecordMyLocation handles POST /api/v1/technicians/me/location
+// The body is either a single point or {"points": [...]} with buffered points.
+func (h *TechnicianHandler) RecordMyLocation(c *gin.Context) {
+	technician, ok := h.loadOwnTechnician(c)
+	if !ok {
+		return
+	}
+
+	if technician.Status == models.TechnicianStatusInactive {
+		respondWithError(c, http.StatusForbidden, "Inactive technicians cannot report locations", "TECHNICIAN_INACTIVE")
+		return
+	}
+
+	points, ok := bindLocationPoints(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	locations := make([]models.TechnicianLocation, 0, len(points))
+	for i, point := range points {
+		recordedAt := now
+		if point.RecordedAt != nil {
+			recordedAt = *point.RecordedAt
+		}
+		if recordedAt.After(now.Add(maxClientClockSkew)) {
+			respondWithError(c, http.StatusBadRequest, fmt.Sprintf("Point %d is recorded in the future", i), "INVALID_LOCATION_TIMESTAMP")
+			return
+		}
+
+		locations = append(locations, models.TechnicianLocation{
+			Base: models.Base{
+				OrganizationID: technician.OrganizationID,
+			},
+			TechnicianID: technician.ID,
+			Lat:          point.Lat,
+			Lng:          point.Lng,
+			Accuracy:     point.Accuracy,
+			Speed:        point.Speed,
+			Heading:      point.Heading,
+			RecordedAt:   recordedAt,
+		})
+	}
+	sort.SliceStable(locations, func(i, j int) bool {
+		return locations[i].RecordedAt.Before(locations[j].RecordedAt)
+	})
+
+	// Points are tagged with the route in progress so trails can be filtered per route
+	var activeRoute models.Route
+	if err := requestDB(c, h.db).
+		Where("organization_id = ? AND technician_id = ? AND status IN ?", technician.OrganizationID, technician.ID,
+			[]models.RouteStatus{models.RouteStatusStarted, models.RouteStatusPaused}).
+		Order("started_at DESC").
+		Limit(1).
+		Find(&activeRoute).Error; err != nil {
+		logger.WithContext(c).Errorf("Database error fetching active route for technician %d: %v", technician.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+		return
+	}
+	if activeRoute.ID != 0 {
+		for i := range locations {
+			locations[i].RouteID = &activeRoute.ID
+		}
+	}
+
+	latest := locations[len(locations)-1]
+	moved := false
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(&locations, 100).Error; err != nil {
+			return err
+		}
+
+		// Late-arriving buffered points extend the trail but must not move the current position backwards
+		if technician.LastLocationAt != nil && latest.RecordedAt.Unix() < *technician.LastLocationAt {
+			return nil
+		}
+		lastLocationAt := latest.RecordedAt.Unix()
+		if err := tx.Model(technician).Updates(map[string]interface{}{
+			"current_lat":      latest.Lat,
+			"current_lng":      latest.Lng,
+			"last_location_at": lastLocationAt,
+		}).Error; err != nil {
+			return err
+		}
+		technician.CurrentLat = &latest.Lat
+		technician.CurrentLng = &latest.Lng
+		technician.LastLocationAt = &lastLocationAt
+		moved = true
+		return nil
+	})
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to record locations for technician %d: %v", technician.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to record location", "LOCATION_UPDATE_ERROR")
+		return
+	}
+
+	if moved {
+		h.events.Publish(technician.OrganizationID, events.EventTechnicianLocation, events.TechnicianLocationEvent{
+			TechnicianID: technician.ID,
+			Lat:          latest.Lat,
+			Lng:          latest.Lng,
+			RouteID:      latest.RouteID,
+			RecordedAt:   latest.RecordedAt,
+		})
+	}
+
+	response := validation.LocationIngestResponse{
+		Accepted: len(locations),
+		LastLat:  technician.CurrentLat,
+		LastLng:  technician.CurrentLng,
+	}
+	if technician.LastLocationAt != nil {
+		lastSeen := time.Unix(*technician.LastLocationAt, 0)
+		response.LastSeen = &lastSeen
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    response,
+		"message": "Location recorded successfully",
+	})
+}
+
+// GetLocationTrail handles GET /api/v1/technicians/:id/locations
+// Callers with only technicians.read_own may fetch their own trail.
+func (h *TechnicianHandler) GetLocationTrail(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	technicianID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req validation.LocationHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, "Invalid query parameters: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	technician, ok := h.loadTechnician(c, organizationID, technicianID)
+	if !ok {
+		return
+	}
+
+	if !middleware.HasPermission(c, "technicians.read") && !h.isOwnProfile(c, technician) {
+		return
+	}
+
+	to := time.Now()
+	if req.To != nil {
+		to = *req.To
+	}
+	from := to.Add(-defaultTrailRange)
+	if req.From != nil {
+		from = *req.From
+	}
+	if to.Before(from) {
+		respondWithError(c, http.StatusBadRequest, "to must be after from", "INVALID_DATE_RANGE")
+		return
+	}
+	if to.Sub(from) > maxTrailRange {
+		respondWithError(c, http.StatusBadRequest, "Time range cannot exceed 31 days", "INVALID_DATE_RANGE")
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.TechnicianLocation{}).
+		Where("organization_id = ? AND technician_id = ? AND recorded_at BETWEEN ? AND ?", organizationID, technicianID, from, to)
+	if req.RouteID != nil {
+		query = query.Where("route_id = ?", *req.RouteID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to count locations for technician %d: %v", technicianID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch location trail", "DATABASE_ERROR")
+		return
+	}
+
+	// Rows are streamed so long ranges are downsampled without loading every point
+	rows, err := query.Order("recorded_at ASC").Rows()
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to fetch locations for technician %d: %v", technicianID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch location trail", "DATABASE_ERROR")
+		return
+	}
+	defer rows.Close()
+
+	trail := newTrailSampler(from, to, int(total), req.MaxPoints)
+	for rows.Next() {
+		var location models.TechnicianLocation
+		if err := requestDB(c, h.db).ScanRows(rows, &location); err != nil {
+			logger.WithContext(c).Errorf("Failed to read location for technician %d: %v", technicianID, err)
+			respondWithError(c, http.StatusInternalServerError, "Failed to fetch location trail", "DATABASE_ERROR")
+			return
+		}
+		trail.add(location)
+	}
+	if err := rows.Err(); err != nil {
+		logger.WithContext(c).Errorf("Failed to read locations for technician %d: %v", technicianID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch location trail", "DATABASE_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": validation.LocationTrailResponse{
+			TechnicianID: technicianID,
+			From:         from,
+			To:           to,
+			TotalPoints:  int(total),
+			Downsampled:  trail.downsampled(),
+			Distance:     roundKm(trail.distanceKm),
+			Points:       trail.points(),
+		},
+	})
+}
+
+// bindLocationPoints reads either a single location point or a batch of points.
+// It writes the error response itself and returns false when the body is invalid.
+func bindLocationPoints(c *gin.Context) ([]validation.LocationUpdateRequest, bool) {
+	var batch validation.LocationBatchRequest
+	if err := c.ShouldBindBodyWith(&batch, binding.JSON); err != nil {
+		logger.WithContext(c).Errorf("Invalid location batch request: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return nil, false
+	}
+	if len(batch.Points) > 0 {
+		return batch.Points, true
+	}
+
+	var point validation.LocationUpdateRequest
+	if err := c.ShouldBindBodyWith(&point, binding.JSON); err != nil {
+		logger.WithContext(c).Errorf("Invalid location update request: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return nil, false
+	}
+	return []validation.LocationUpdateRequest{point}, true
+}
+
+// trailSampler downsamples a time-ordered location stream to at most maxPoints by keeping
+// the first point of each equal time bucket plus the final point. Distance is measured
+// over every point, not just the ones kept.
+type trailSampler struct {
+	from       time.Time
+	bucket     time.Duration
+	buckets    int
+	sampling   bool
+	lastBucket int
+	kept       []models.TechnicianLocation
+	previous   *models.TechnicianLocation
+	distanceKm float64
+}
+
+func newTrailSampler(from, to time.Time, total, maxPoints int) *trailSampler {
+	sampler := &trailSampler{
+		from:       from,
+		sampling:   total > maxPoints,
+		lastBucket: -1,
+	}
+	if sampler.sampling {
+		// One slot is reserved for the final point
+		sampler.buckets = maxPoints - 1
+		sampler.bucket = to.Sub(from) / time.Duration(sampler.buckets)
+		if sampler.bucket <= 0 {
+			sampler.bucket = 1
+		}
+		sampler.kept = make([]models.TechnicianLocation, 0, maxPoints)
+	}
+	return sampler
+}
+
+func (s *trailSampler) add(location models.TechnicianLocation) {
+	if s.previous != nil {
+		s.distanceKm += optimizer.HaversineDistance(s.previous.Lat, s.previous.Lng, location.Lat, location.Lng)
+	}
+
+	if !s.sampling {
+		s.kept = append(s.kept, location)
+	} else {
+		bucket := int(location.RecordedAt.Sub(s.from) / s.bucket)
+		if bucket >= s.buckets {
+			bucket = s.buckets - 1
+		}
+		if bucket != s.lastBucket {
+			s.kept = append(s.kept, location)
+			s.lastBucket = bucket
+		}
+	}
+
+	s.previous = &location
+}
+
+func (s *trailSampler) downsampled() bool {
+	return s.sampling
+}
+
+// points returns the kept points, always ending with the most recent one
+func (s *trailSampler) points() []validation.LocationPointResponse {
+	kept := s.kept
+	if s.sampling && s.previous != nil && (len(kept) == 0 || kept[len(kept)-1].ID != s.previous.ID) {
+		kept = append(kept, *s.previous)
+	}
+
+	points := make([]validation.LocationPointResponse, 0, len(kept))
+	for _, location := range kept {
+		points = append(points, validation.LocationPointResponse{
+			Lat:        location.Lat,
+			Lng:        location.Lng,
+			Accuracy:   location.Accuracy,
+			Speed:      location.Speed,
+			Heading:    location.Heading,
+			RouteID:    location.RouteID,
+			RecordedAt: location.RecordedAt,
+		})
+	}
+	return points
+}
+
+// roundKm rounds a distance to two decimal places
+func roundKm(km float64) float64 {
+	return float64(int64(km*100+0.5)) / 100
+}
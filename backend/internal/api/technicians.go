@@ -0,0 +1,425 @@
+package api
+
+import (
+	"net/http"
+
+	"routrapp-api/internal/events"
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+	"routrapp-api/internal/repositories/postgres"
+	"routrapp-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TechnicianHandler handles technician management requests
+type TechnicianHandler struct {
+	db          *gorm.DB
+	events      *events.Hub
+	technicians repositories.TechnicianRepository
+	users       repositories.UserRepository
+	routes      repositories.RouteRepository
+	activities  repositories.ActivityRepository
+}
+
+// NewTechnicianHandler creates a new technician handler that does not publish events
+func NewTechnicianHandler(db *gorm.DB) *TechnicianHandler {
+	return NewTechnicianHandlerWithRepositories(db, postgres.NewRepositories(db), nil)
+}
+
+// NewTechnicianHandlerWithEvents creates a new technician handler that publishes changes to the event hub
+func NewTechnicianHandlerWithEvents(db *gorm.DB, hub *events.Hub) *TechnicianHandler {
+	return NewTechnicianHandlerWithRepositories(db, postgres.NewRepositories(db), hub)
+}
+
+// NewTechnicianHandlerWithRepositories creates a technician handler whose profile and activity endpoints
+// use the repositories; the location endpoints still query db directly. hub may be nil.
+func NewTechnicianHandlerWithRepositories(db *gorm.DB, repos repositories.Repositories, hub *events.Hub) *TechnicianHandler {
+	return &TechnicianHandler{
+		db:          db,
+		events:      hub,
+		technicians: repos.Technicians,
+		users:       repos.Users,
+		routes:      repos.Routes,
+		activities:  repos.Activities,
+	}
+}
+
+// ListTechnicians handles GET /api/v1/technicians
+func (h *TechnicianHandler) ListTechnicians(c *gin.Context) {
+	if _, ok := requireOrganizationID(c); !ok {
+		return
+	}
+
+	var pagination validation.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		logger.WithContext(c).Errorf("Invalid pagination parameters: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid pagination parameters: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	var filters validation.TechnicianFilterRequest
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		logger.WithContext(c).Errorf("Invalid technician filter parameters: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid filter parameters: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	technicians, total, err := h.technicians.List(c.Request.Context(), repositories.TechnicianFilter{
+		Statuses: filters.Status,
+		Active:   filters.Active,
+		Search:   filters.Search,
+		SortBy:   filters.SortBy,
+		SortDesc: filters.SortDesc,
+	}, repositories.Page{Page: pagination.Page, PageSize: pagination.PageSize})
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to list technicians: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch technicians", "DATABASE_ERROR")
+		return
+	}
+
+	responses := make([]validation.TechnicianResponse, 0, len(technicians))
+	for _, technician := range technicians {
+		responses = append(responses, newTechnicianResponse(technician))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       responses,
+		"pagination": validation.CalculatePagination(pagination.Page, pagination.PageSize, total),
+	})
+}
+
+// GetTechnician handles GET /api/v1/technicians/:id
+// Callers with only technicians.read_own may fetch their own profile.
+func (h *TechnicianHandler) GetTechnician(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	technicianID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	technician, ok := h.loadTechnician(c, organizationID, technicianID)
+	if !ok {
+		return
+	}
+
+	if !middleware.HasPermission(c, "technicians.read") && !h.isOwnProfile(c, technician) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newTechnicianResponse(*technician),
+	})
+}
+
+// CreateTechnician handles POST /api/v1/technicians
+func (h *TechnicianHandler) CreateTechnician(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	var req validation.TechnicianCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithContext(c).Errorf("Invalid technician creation request: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	user, err := h.users.FindByID(c.Request.Context(), req.UserID)
+	if err != nil {
+		if err == repositories.ErrNotFound {
+			logger.WithContext(c).Warnf("Technician creation failed: user %d not found in organization %d", req.UserID, organizationID)
+			respondWithError(c, http.StatusBadRequest, "User not found in organization", "INVALID_USER")
+			return
+		}
+		logger.WithContext(c).Errorf("Database error fetching user %d: %v", req.UserID, err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+		return
+	}
+	if !user.Active {
+		respondWithError(c, http.StatusBadRequest, "Cannot create a technician profile for an inactive user", "USER_INACTIVE")
+		return
+	}
+
+	exists, err := h.technicians.ExistsForUser(c.Request.Context(), req.UserID)
+	if err != nil {
+		logger.WithContext(c).Errorf("Database error checking technician for user %d: %v", req.UserID, err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+		return
+	}
+	if exists {
+		respondWithError(c, http.StatusConflict, "User already has a technician profile", "TECHNICIAN_EXISTS")
+		return
+	}
+
+	technician := models.Technician{
+		Base: models.Base{
+			OrganizationID: organizationID,
+		},
+		UserID:      req.UserID,
+		Status:      models.TechnicianStatusActive,
+		PhoneNumber: req.PhoneNumber,
+		Notes:       req.Notes,
+	}
+	if err := h.technicians.Create(c.Request.Context(), &technician); err != nil {
+		logger.WithContext(c).Errorf("Failed to create technician: %v", err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to create technician", "TECHNICIAN_CREATION_ERROR")
+		return
+	}
+
+	created, ok := h.loadTechnician(c, organizationID, technician.ID)
+	if !ok {
+		return
+	}
+
+	logger.WithContext(c).Infof("Technician %d created for user %d", technician.ID, req.UserID)
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    newTechnicianResponse(*created),
+		"message": "Technician created successfully",
+	})
+}
+
+// UpdateTechnician handles PATCH /api/v1/technicians/:id
+// Callers with only technicians.update_own may update their own profile, with the same
+// restrictions as UpdateMyProfile.
+func (h *TechnicianHandler) UpdateTechnician(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	technicianID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	technician, ok := h.loadTechnician(c, organizationID, technicianID)
+	if !ok {
+		return
+	}
+
+	if !middleware.HasPermission(c, "technicians.update") {
+		if h.isOwnProfile(c, technician) {
+			h.updateOwnProfile(c, technician)
+		}
+		return
+	}
+
+	var req validation.TechnicianUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithContext(c).Errorf("Invalid technician update request: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	if req.Status != nil && *req.Status == models.TechnicianStatusInactive && technician.Status != models.TechnicianStatusInactive {
+		if !h.canDeactivate(c, technicianID) {
+			return
+		}
+	}
+
+	h.applyTechnicianUpdates(c, technician, repositories.TechnicianUpdate{Status: req.Status, PhoneNumber: req.PhoneNumber, Notes: req.Notes})
+}
+
+// DeactivateTechnician handles POST /api/v1/technicians/:id/deactivate
+// Technicians with a route in progress or assigned routes cannot be deactivated.
+func (h *TechnicianHandler) DeactivateTechnician(c *gin.Context) {
+	organizationID, ok := requireOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	technicianID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	technician, ok := h.loadTechnician(c, organizationID, technicianID)
+	if !ok {
+		return
+	}
+
+	if !h.canDeactivate(c, technicianID) {
+		return
+	}
+
+	status := models.TechnicianStatusInactive
+	h.applyTechnicianUpdates(c, technician, repositories.TechnicianUpdate{Status: &status})
+}
+
+// canDeactivate checks that the technician has no route in progress or waiting for them,
+// writing the error response itself otherwise
+func (h *TechnicianHandler) canDeactivate(c *gin.Context, technicianID uint) bool {
+	// A technician in the middle of a route has to finish or cancel it first
+	activeRoutes, err := h.routes.CountByTechnician(c.Request.Context(), technicianID, models.RouteStatusStarted, models.RouteStatusPaused)
+	if err != nil {
+		logger.WithContext(c).Errorf("Database error checking routes for technician %d: %v", technicianID, err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+		return false
+	}
+	if activeRoutes > 0 {
+		logger.WithContext(c).Warnf("Technician %d deactivation rejected: route in progress", technicianID)
+		respondWithError(c, http.StatusConflict, "Technician has a route in progress", "TECHNICIAN_ON_ROUTE")
+		return false
+	}
+
+	// Routes waiting for the technician would never be driven, so they are reassigned first
+	assignedRoutes, err := h.routes.CountByTechnician(c.Request.Context(), technicianID, models.RouteStatusAssigned)
+	if err != nil {
+		logger.WithContext(c).Errorf("Database error checking routes for technician %d: %v", technicianID, err)
+		respondWithError(c, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+		return false
+	}
+	if assignedRoutes > 0 {
+		logger.WithContext(c).Warnf("Technician %d deactivation rejected: %d assigned routes", technicianID, assignedRoutes)
+		respondWithErrorDetails(c, http.StatusConflict, "Technician has assigned routes; reassign or unassign them first", "TECHNICIAN_HAS_ASSIGNED_ROUTES", map[string]interface{}{
+			"assigned_routes": assignedRoutes,
+		})
+		return false
+	}
+	return true
+}
+
+// GetMyProfile handles GET /api/v1/technicians/me
+func (h *TechnicianHandler) GetMyProfile(c *gin.Context) {
+	technician, ok := h.loadOwnTechnician(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newTechnicianResponse(*technician),
+	})
+}
+
+// UpdateMyProfile handles PATCH /api/v1/technicians/me
+func (h *TechnicianHandler) UpdateMyProfile(c *gin.Context) {
+	technician, ok := h.loadOwnTechnician(c)
+	if !ok {
+		return
+	}
+
+	h.updateOwnProfile(c, technician)
+}
+
+// updateOwnProfile applies a technician's update to their own profile
+func (h *TechnicianHandler) updateOwnProfile(c *gin.Context, technician *models.Technician) {
+	var req validation.TechnicianSelfUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithContext(c).Errorf("Invalid technician profile update request: %v", err)
+		respondWithError(c, http.StatusBadRequest, "Invalid request data: "+err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	// Deactivated technicians cannot put themselves back on duty
+	if req.Status != nil && technician.Status == models.TechnicianStatusInactive {
+		respondWithError(c, http.StatusForbidden, "Inactive technicians cannot change their status", "TECHNICIAN_INACTIVE")
+		return
+	}
+
+	h.applyTechnicianUpdates(c, technician, repositories.TechnicianUpdate{Status: req.Status, PhoneNumber: req.PhoneNumber, Notes: req.Notes})
+}
+
+// applyTechnicianUpdates saves the update and responds with the refreshed technician
+func (h *TechnicianHandler) applyTechnicianUpdates(c *gin.Context, technician *models.Technician, update repositories.TechnicianUpdate) {
+	if update.Empty() {
+		respondWithError(c, http.StatusBadRequest, "At least one field must be provided for update", "NO_FIELDS_PROVIDED")
+		return
+	}
+
+	previousStatus := technician.Status
+	if err := h.technicians.Update(c.Request.Context(), technician.ID, update); err != nil {
+		logger.WithContext(c).Errorf("Failed to update technician %d: %v", technician.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to update technician", "TECHNICIAN_UPDATE_ERROR")
+		return
+	}
+
+	updated, ok := h.loadTechnician(c, technician.OrganizationID, technician.ID)
+	if !ok {
+		return
+	}
+
+	if updated.Status != previousStatus {
+		h.events.Publish(updated.OrganizationID, events.EventTechnicianStatus, events.TechnicianStatusEvent{
+			TechnicianID:   updated.ID,
+			Status:         updated.Status,
+			PreviousStatus: previousStatus,
+		})
+	}
+
+	logger.WithContext(c).Infof("Technician %d updated", technician.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newTechnicianResponse(*updated),
+		"message": "Technician updated successfully",
+	})
+}
+
+// loadTechnician fetches a technician with its user, scoped to the organization.
+// It writes the error response itself and returns false when the technician cannot be loaded.
+func (h *TechnicianHandler) loadTechnician(c *gin.Context, organizationID, technicianID uint) (*models.Technician, bool) {
+	technician, err := h.technicians.FindByID(c.Request.Context(), technicianID)
+	if err != nil {
+		if err == repositories.ErrNotFound {
+			logger.WithContext(c).Warnf("Technician %d not found in organization %d", technicianID, organizationID)
+			respondWithError(c, http.StatusNotFound, "Technician not found", "TECHNICIAN_NOT_FOUND")
+			return nil, false
+		}
+		logger.WithContext(c).Errorf("Database error fetching technician %d: %v", technicianID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch technician", "DATABASE_ERROR")
+		return nil, false
+	}
+	return technician, true
+}
+
+// loadOwnTechnician fetches the technician profile of the authenticated user.
+// It writes the error response itself and returns false when there is no profile.
+func (h *TechnicianHandler) loadOwnTechnician(c *gin.Context) (*models.Technician, bool) {
+	if _, ok := requireOrganizationID(c); !ok {
+		return nil, false
+	}
+
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		respondWithError(c, http.StatusUnauthorized, "Authentication required", "AUTHENTICATION_REQUIRED")
+		return nil, false
+	}
+
+	technician, err := h.technicians.FindByUserID(c.Request.Context(), userID)
+	if err != nil {
+		if err == repositories.ErrNotFound {
+			logger.WithContext(c).Warnf("No technician profile for user %d", userID)
+			respondWithError(c, http.StatusNotFound, "Technician profile not found", "TECHNICIAN_PROFILE_NOT_FOUND")
+			return nil, false
+		}
+		logger.WithContext(c).Errorf("Database error fetching technician profile for user %d: %v", userID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to fetch technician", "DATABASE_ERROR")
+		return nil, false
+	}
+	return technician, true
+}
+
+// isOwnProfile reports whether the technician belongs to the authenticated user,
+// writing an access denied response if not
+func (h *TechnicianHandler) isOwnProfile(c *gin.Context, technician *models.Technician) bool {
+	userID, exists := middleware.GetUserID(c)
+	if exists && technician.UserID == userID {
+		return true
+	}
+
+	logger.WithContext(c).Warnf("User %d denied access to technician %d", userID, technician.ID)
+	respondWithError(c, http.StatusForbidden, "Access denied. You can only access your own technician profile", "RESOURCE_ACCESS_DENIED")
+	return false
+}
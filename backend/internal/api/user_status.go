@@ -0,0 +1,101 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DeactivateUser handles POST /api/v1/users/:id/deactivate. The user can no longer sign in, their
+// sessions are revoked and the access tokens they hold stop working at once. The caller must hold
+// every permission of the user's role, so that only owners deactivate an owner.
+func (h *AuthHandler) DeactivateUser(c *gin.Context) {
+	adminID, exists := middleware.GetUserID(c)
+	if !exists {
+		respondWithError(c, http.StatusUnauthorized, "Authentication required", "AUTHENTICATION_REQUIRED")
+		return
+	}
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	user, ok := h.findUser(c, userID)
+	if !ok {
+		return
+	}
+	if !requireRolePermissionsHeld(c, &user.Role) {
+		return
+	}
+
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if user.Active && user.Role.Name == models.RoleTypeOwner {
+			if err := requireOtherActiveOwner(tx, user.OrganizationID, user.RoleID, user.ID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("active", false).Error; err != nil {
+			return err
+		}
+		_, err := revokeSessions(tx, user.ID, models.SessionRevokedDeactivated)
+		return err
+	})
+	if errors.Is(err, errLastOwner) {
+		respondWithError(c, http.StatusConflict, "The organization must keep at least one active owner", "LAST_OWNER")
+		return
+	}
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to deactivate user %d: %v", user.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to deactivate user", "INTERNAL_ERROR")
+		return
+	}
+	revokeUserTokens(c, user.ID)
+
+	logger.WithContext(c).Warnf("User %d deactivated user %d", adminID, user.ID)
+	user.Active = false
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newUserResponse(*user),
+		"message": "User deactivated",
+	})
+}
+
+// ActivateUser handles POST /api/v1/users/:id/activate, allowing a deactivated user to sign in again.
+// As for deactivation, the caller must hold every permission of the user's role.
+func (h *AuthHandler) ActivateUser(c *gin.Context) {
+	adminID, exists := middleware.GetUserID(c)
+	if !exists {
+		respondWithError(c, http.StatusUnauthorized, "Authentication required", "AUTHENTICATION_REQUIRED")
+		return
+	}
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	user, ok := h.findUser(c, userID)
+	if !ok {
+		return
+	}
+	if !requireRolePermissionsHeld(c, &user.Role) {
+		return
+	}
+
+	if err := requestDB(c, h.db).Model(&models.User{}).Where("id = ?", user.ID).Update("active", true).Error; err != nil {
+		logger.WithContext(c).Errorf("Failed to activate user %d: %v", user.ID, err)
+		respondWithError(c, http.StatusInternalServerError, "Failed to activate user", "INTERNAL_ERROR")
+		return
+	}
+
+	logger.WithContext(c).Infof("User %d activated user %d", adminID, user.ID)
+	user.Active = true
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newUserResponse(*user),
+		"message": "User activated",
+	})
+}
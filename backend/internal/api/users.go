@@ -7,7 +7,8 @@ import (
 	"routrapp-api/internal/errors"
 	"routrapp-api/internal/logger"
 	"routrapp-api/internal/middleware"
-	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+	"routrapp-api/internal/repositories/postgres"
 	"routrapp-api/internal/validation"
 
 	"github.com/gin-gonic/gin"
@@ -16,13 +17,18 @@ import (
 
 // UserHandler handles user-related requests
 type UserHandler struct {
-	db *gorm.DB
+	users repositories.UserRepository
 }
 
 // NewUserHandler creates a new user handler
 func NewUserHandler(db *gorm.DB) *UserHandler {
+	return NewUserHandlerWithRepository(postgres.NewUserRepository(db))
+}
+
+// NewUserHandlerWithRepository creates a new user handler backed by the user repository
+func NewUserHandlerWithRepository(users repositories.UserRepository) *UserHandler {
 	return &UserHandler{
-		db: db,
+		users: users,
 	}
 }
 
@@ -92,9 +98,9 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	}
 
 	// Find user
-	var user models.User
-	if err := h.db.Preload("Role").Where("id = ?", userID).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	user, err := h.users.FindByID(c.Request.Context(), userID)
+	if err != nil {
+		if err == repositories.ErrNotFound {
 			logger.WithContext(c).Warnf("Profile update failed: user not found %d", userID)
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": errors.NewAppErrorWithDetails(
@@ -135,17 +141,12 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	// Prepare update data
-	updateData := make(map[string]interface{})
-	if req.FirstName != nil {
-		updateData["first_name"] = *req.FirstName
-	}
-	if req.LastName != nil {
-		updateData["last_name"] = *req.LastName
-	}
-
 	// Update user in database
-	if err := h.db.Model(&user).Updates(updateData).Error; err != nil {
+	update := repositories.UserUpdate{
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+	}
+	if err := h.users.Update(c.Request.Context(), user.ID, update); err != nil {
 		logger.WithContext(c).Errorf("Failed to update user profile: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": errors.NewAppErrorWithDetails(
@@ -160,7 +161,8 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	}
 
 	// Reload user to get updated data
-	if err := h.db.Preload("Role").First(&user, user.ID).Error; err != nil {
+	user, err = h.users.FindByID(c.Request.Context(), user.ID)
+	if err != nil {
 		logger.WithContext(c).Errorf("Failed to reload user after profile update: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": errors.NewAppErrorWithDetails(
@@ -181,11 +183,13 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 			CreatedAt: user.CreatedAt,
 			UpdatedAt: user.UpdatedAt,
 		},
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Active:    user.Active,
-		Role:      user.Role.Name.String(),
+		Email:         user.Email,
+		EmailVerified: user.IsEmailVerified(),
+		MFAEnabled:    user.IsMFAEnabled(),
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Active:        user.Active,
+		Role:          user.Role.Name.String(),
 	}
 
 	logger.WithContext(c).Infof("Profile updated successfully for user %d", userID)
@@ -4,23 +4,43 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"routrapp-api/internal/config"
+	"routrapp-api/internal/events"
+	"routrapp-api/internal/lockout"
 	"routrapp-api/internal/logger"
+	"routrapp-api/internal/mailer"
 	"routrapp-api/internal/middleware"
 	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories/postgres"
+	"routrapp-api/internal/revocation"
+	"routrapp-api/internal/storage"
+	"routrapp-api/internal/tenant"
 	"routrapp-api/internal/utils/auth"
+	"routrapp-api/internal/utils/constants"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type App struct {
-	config     *config.Config
-	server     *http.Server
-	db         *gorm.DB
-	router     *gin.Engine
-	jwtService *auth.JWTService
+	config      *config.Config
+	server      *http.Server
+	db          *gorm.DB
+	router      *gin.Engine
+	jwtService  *auth.JWTService
+	events      *events.Hub
+	storage     storage.Storage
+	mailer      mailer.Mailer
+	mailQueue   *mailer.Queue
+	lockout     *lockout.Guard
+	revocations *revocation.List
+	urlSigner   *storage.URLSigner
+	permissions *middleware.DBPermissionChecker
+	tenants     *middleware.TenantResolver
+
+	stopKeyRotation context.CancelFunc // stops rotating the JWT signing keys; nil when tokens are signed with the secret
 }
 
 func NewApp(cfg *config.Config) (*App, error) {
@@ -32,6 +52,12 @@ func NewApp(cfg *config.Config) (*App, error) {
 	logger.InitLogger(cfg.Environment)
 	logger.Infof("Starting application in %s environment", cfg.Environment)
 
+	// Refuse to start with configuration that isn't safe to run with
+	if err := cfg.Validate(); err != nil {
+		logger.Errorf("Invalid configuration: %v", err)
+		return nil, err
+	}
+
 	// Set Gin mode based on environment before creating any engine
 	switch cfg.Environment {
 	case "production", "staging":
@@ -53,9 +79,79 @@ func NewApp(cfg *config.Config) (*App, error) {
 	app.db = db
 	logger.Info("Database connection established")
 
-	// Initialize JWT service with configuration
-	app.jwtService = auth.NewJWTService(cfg.JWT.Secret)
-	logger.Infof("JWT service initialized with secret from configuration")
+	// Scope every statement on tenant models to the organization in the statement's context
+	if err := app.db.Use(tenant.NewPlugin()); err != nil {
+		logger.Errorf("Failed to register tenant scoping: %v", err)
+		return nil, err
+	}
+
+	// Initialize JWT service with configuration, signing with the secret or with rotating key pairs
+	switch cfg.JWT.Algorithm {
+	case "", auth.AlgorithmHS256:
+		app.jwtService = auth.NewJWTService(cfg.JWT.Secret)
+		logger.Infof("JWT service initialized with secret from configuration")
+	default:
+		keys, err := auth.NewKeyRing(cfg.JWT.Algorithm, cfg.JWT.KeysPath, cfg.JWT.RotationInterval)
+		if err != nil {
+			logger.Errorf("Failed to initialize JWT signing keys: %v", err)
+			return nil, err
+		}
+		if cfg.JWT.KeysPath == "" {
+			logger.Warn("JWT signing keys are kept in memory; tokens become invalid on restart and aren't shared between replicas")
+		}
+		app.jwtService = auth.NewJWTServiceWithKeys(keys)
+		var ctx context.Context
+		ctx, app.stopKeyRotation = context.WithCancel(context.Background())
+		go app.rotateSigningKeys(ctx, keys)
+		logger.Infof("JWT service initialized with %s key %s", cfg.JWT.Algorithm, keys.Current().ID)
+	}
+
+	// Initialize the event hub backing the real-time stream
+	app.events = events.NewHub()
+
+	// Initialize blob storage for stop attachments
+	app.storage, err = storage.New(cfg.Storage)
+	if err != nil {
+		logger.Errorf("Failed to initialize storage: %v", err)
+		return nil, err
+	}
+	signingSecret := cfg.Storage.SigningSecret
+	if signingSecret == "" {
+		signingSecret = cfg.JWT.Secret
+	}
+	app.urlSigner = storage.NewURLSigner(signingSecret, cfg.Storage.URLExpiry)
+	logger.Infof("Storage initialized with %s driver", cfg.Storage.Driver)
+
+	// Initialize the mailer sending password reset and verification emails
+	app.mailer, err = mailer.New(cfg.Mail)
+	if err != nil {
+		logger.Errorf("Failed to initialize mailer: %v", err)
+		return nil, err
+	}
+	app.mailQueue = mailer.NewQueue(app.mailer, constants.MailQueueSize, constants.MailSendTimeout)
+	logger.Infof("Mailer initialized with %s driver", cfg.Mail.Driver)
+
+	// Initialize the guard throttling failed logins
+	app.lockout, err = lockout.New(cfg.Lockout, app.db)
+	if err != nil {
+		logger.Errorf("Failed to initialize login lockout: %v", err)
+		return nil, err
+	}
+	logger.Infof("Login lockout initialized with %s store", cfg.Lockout.Store)
+
+	// Initialize the deny list of revoked access tokens
+	app.revocations, err = revocation.New(cfg.Revocation, app.db)
+	if err != nil {
+		logger.Errorf("Failed to initialize token revocation: %v", err)
+		return nil, err
+	}
+	logger.Infof("Token revocation initialized with %s store", cfg.Revocation.Store)
+
+	// Initialize the permission checker resolving callers' roles from the database
+	app.permissions = middleware.NewDBPermissionChecker(app.db, constants.DefaultPermissionCacheTTL)
+
+	// Initialize the resolver mapping tenant subdomains to organizations
+	app.tenants = middleware.NewTenantResolver(postgres.NewOrganizationRepository(app.db), cfg.Tenant.BaseDomain, cfg.Tenant.CacheTTL)
 
 	// Auto-migrate models in development environment
 	if app.config.Environment == "development" {
@@ -66,6 +162,17 @@ func NewApp(cfg *config.Config) (*App, error) {
 			return nil, fmt.Errorf("failed to auto migrate: %w", err)
 		}
 		logger.Info("Database migrations completed")
+	} else if cfg.Database.AutoMigrate {
+		// Other environments apply the versioned SQL migrations; replicas starting together
+		// wait on the runner's advisory lock instead of migrating concurrently
+		logger.Infof("Applying SQL migrations from %s", cfg.Database.MigrationsPath)
+		runner := postgres.NewMigrationRunner(app.db, cfg.Database.MigrationsPath)
+		applied, err := runner.Up(context.Background())
+		if err != nil {
+			logger.Errorf("Failed to apply migrations: %v", err)
+			return nil, fmt.Errorf("failed to apply migrations: %w", err)
+		}
+		logger.Infof("Applied %d SQL migrations", len(applied))
 	}
 
 	app.setupRouter()
@@ -89,11 +196,14 @@ func (a *App) setupRouter() {
 	a.router = gin.New()
 	
 	// Add middleware stack in correct order
-	a.router.Use(middleware.RecoveryMiddleware())       // First: Recovery from panics
-	a.router.Use(middleware.RequestIDMiddleware())      // Second: Add request IDs
-	a.router.Use(middleware.LoggerMiddleware())         // Third: Log requests
-	a.router.Use(middleware.CORSMiddleware(a.config))   // Fourth: CORS handling
-	a.router.Use(middleware.ErrorHandlerMiddleware())   // Last: Error handling
+	a.router.Use(middleware.RecoveryMiddleware())                       // First: Recovery from panics
+	a.router.Use(middleware.RequestIDMiddleware())                      // Second: Add request IDs
+	a.router.Use(middleware.LoggerMiddleware())                         // Third: Log requests
+	a.router.Use(middleware.CORSMiddleware(a.config))                   // Fourth: CORS handling
+	a.router.Use(middleware.TenantMiddleware(a.tenants, a.jwtService))  // Fifth: Resolve the tenant from the subdomain
+	a.router.Use(middleware.PermissionCheckerMiddleware(a.permissions)) // Sixth: Role-based permission checks
+	a.router.Use(middleware.RevocationMiddleware(a.revocations))        // Seventh: Deny list of revoked access tokens
+	a.router.Use(middleware.ErrorHandlerMiddleware())                   // Last: Error handling
 	
 	// Root endpoint
 	a.router.GET("/", func(c *gin.Context) {
@@ -118,5 +228,39 @@ func (a *App) Start() error {
 // Shutdown gracefully shuts down the server
 func (a *App) Shutdown(ctx context.Context) error {
 	logger.Info("🛑 Shutting down server...")
-	return a.server.Shutdown(ctx)
-}
\ No newline at end of file
+	// Open event streams would otherwise keep the server from shutting down
+	a.events.Close()
+	if a.stopKeyRotation != nil {
+		a.stopKeyRotation()
+	}
+	if err := a.server.Shutdown(ctx); err != nil {
+		return err
+	}
+	// Emails queued by the last requests are still sent
+	return a.mailQueue.Close(ctx)
+}
+
+// rotateSigningKeys checks the JWT signing keys until ctx is done, publishing the next key when the
+// current one is due and picking up the keys other replicas generated
+func (a *App) rotateSigningKeys(ctx context.Context, keys *auth.KeyRing) {
+	ticker := time.NewTicker(constants.JWTKeyCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			rotated, err := keys.Rotate(now)
+			if err != nil {
+				logger.Errorf("Failed to rotate JWT signing keys: %v", err)
+				continue
+			}
+			if next := keys.Next(); rotated && next != nil {
+				logger.Infof("Published JWT signing key %s; it signs from %s", next.ID, next.CreatedAt.Format(time.RFC3339))
+			} else if rotated {
+				logger.Infof("Rotated JWT signing keys; signing with key %s", keys.Current().ID)
+			}
+		}
+	}
+}
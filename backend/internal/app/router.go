@@ -13,8 +13,47 @@ func (a *App) RegisterRoutes() {
 	// User handler
 	userHandler := api.NewUserHandler(a.db)
 
-	// Auth handler with configured JWT service
-	authHandler := api.NewAuthHandlerWithJWT(a.db, a.jwtService)
+	// Auth handler with configured JWT service, emailing password reset and verification links and throttling failed logins
+	linkBaseURL := a.config.Mail.LinkBaseURL
+	if linkBaseURL == "" {
+		linkBaseURL = a.config.CORS.FrontendURL
+	}
+	mfaSecretKey := a.config.MFA.SecretKey
+	if mfaSecretKey == "" {
+		mfaSecretKey = a.config.JWT.Secret
+	}
+	authHandler := api.NewAuthHandlerWithMailer(a.db, a.jwtService, a.mailer, api.AuthOptions{
+		LinkBaseURL:  linkBaseURL,
+		MFAIssuer:    a.config.MFA.Issuer,
+		MFASecretKey: mfaSecretKey,
+		Lockout:      a.lockout,
+		MailQueue:    a.mailQueue,
+	})
+
+	// Route handler
+	routeHandler := api.NewRouteHandlerWithEvents(a.db, a.events)
+
+	// Technician handler
+	technicianHandler := api.NewTechnicianHandlerWithEvents(a.db, a.events)
+
+	// Attachment handler for stop photos and documents
+	attachmentHandler := api.NewAttachmentHandler(a.db, a.storage, a.urlSigner, api.AttachmentOptions{
+		MaxUploadSize: a.config.Storage.MaxUploadSize,
+		DefaultQuota:  a.config.Storage.DefaultOrgQuota,
+	})
+
+	// Proof-of-service handlers for stop completion and per-stop-type checklists
+	proofHandler := api.NewProofHandler(a.db, attachmentHandler, a.events)
+	stopChecklistHandler := api.NewStopChecklistHandler(a.db)
+
+	// Role handler for custom per-organization roles
+	roleHandler := api.NewRoleHandler(a.db)
+
+	// Stream handler for real-time events
+	streamHandler := api.NewStreamHandler(a.events)
+
+	// Public keys verifying access tokens, for other services
+	a.router.GET("/.well-known/jwks.json", authHandler.JWKS)
 
 	// API group
 	api := a.router.Group("/api")
@@ -32,9 +71,24 @@ func (a *App) RegisterRoutes() {
 				auth.POST("/register-user", authHandler.Register)         // POST /api/v1/auth/register-user (user registration to existing org)
 				auth.POST("/login", authHandler.Login)                    // POST /api/v1/auth/login
 				auth.POST("/refresh", authHandler.RefreshToken)           // POST /api/v1/auth/refresh
+				auth.POST("/forgot-password", authHandler.ForgotPassword) // POST /api/v1/auth/forgot-password
+				auth.POST("/reset-password", authHandler.ResetPassword)   // POST /api/v1/auth/reset-password
+				auth.POST("/verify-email", authHandler.VerifyEmail)       // POST /api/v1/auth/verify-email
+				auth.POST("/resend-verification", middleware.AuthMiddlewareWithJWT(a.jwtService), authHandler.ResendVerificationEmail) // POST /api/v1/auth/resend-verification (requires auth)
 				auth.GET("/me", middleware.AuthMiddlewareWithJWT(a.jwtService), authHandler.GetCurrentUser) // GET /api/v1/auth/me (requires auth)
 				auth.POST("/logout", middleware.AuthMiddlewareWithJWT(a.jwtService), authHandler.Logout) // POST /api/v1/auth/logout (requires auth)
 				auth.POST("/change-password", middleware.AuthMiddlewareWithJWT(a.jwtService), authHandler.ChangePassword) // POST /api/v1/auth/change-password (requires auth)
+				auth.GET("/sessions", middleware.AuthMiddlewareWithJWT(a.jwtService), authHandler.ListSessions) // GET /api/v1/auth/sessions (requires auth)
+				auth.DELETE("/sessions", middleware.AuthMiddlewareWithJWT(a.jwtService), authHandler.RevokeAllSessions) // DELETE /api/v1/auth/sessions (requires auth)
+				auth.DELETE("/sessions/:id", middleware.AuthMiddlewareWithJWT(a.jwtService), authHandler.RevokeSession) // DELETE /api/v1/auth/sessions/:id (requires auth)
+				auth.POST("/mfa/verify", authHandler.VerifyMFA) // POST /api/v1/auth/mfa/verify (second step of login, with the MFA token)
+				auth.POST("/mfa/enroll", authHandler.EnrollMFA) // POST /api/v1/auth/mfa/enroll (enrollment required by login, with the MFA token)
+				auth.POST("/mfa/setup", middleware.AuthMiddlewareWithJWT(a.jwtService), authHandler.SetupMFA) // POST /api/v1/auth/mfa/setup (requires auth)
+				auth.POST("/mfa/enable", middleware.AuthMiddlewareWithJWT(a.jwtService), authHandler.EnableMFA) // POST /api/v1/auth/mfa/enable (requires auth)
+				auth.POST("/mfa/disable", middleware.AuthMiddlewareWithJWT(a.jwtService), authHandler.DisableMFA) // POST /api/v1/auth/mfa/disable (requires auth)
+				auth.POST("/mfa/recovery-codes", middleware.AuthMiddlewareWithJWT(a.jwtService), authHandler.RegenerateRecoveryCodes) // POST /api/v1/auth/mfa/recovery-codes (requires auth)
+				auth.GET("/mfa/policy", middleware.AuthMiddlewareWithJWT(a.jwtService), middleware.RequirePermission("organizations.read"), authHandler.GetMFAPolicy) // GET /api/v1/auth/mfa/policy
+				auth.PUT("/mfa/policy", middleware.AuthMiddlewareWithJWT(a.jwtService), middleware.RequirePermission("organizations.update"), authHandler.UpdateMFAPolicy) // PUT /api/v1/auth/mfa/policy
 			}
 
 			// User endpoints
@@ -45,8 +99,85 @@ func (a *App) RegisterRoutes() {
 				users.GET("/", userHandler.GetUserWithEmptyID)                                     // GET /api/v1/users/ - Bad request
 				users.GET("/:id", userHandler.GetUser)                                             // GET /api/v1/users/:id
 				users.PUT("/profile", middleware.AuthMiddlewareWithJWT(a.jwtService), userHandler.UpdateProfile)     // PUT /api/v1/users/profile (requires auth)
+				users.PUT("/:id/role", middleware.AuthMiddlewareWithJWT(a.jwtService), middleware.RequirePermission("roles.assign"), roleHandler.AssignUserRole) // PUT /api/v1/users/:id/role
+				users.DELETE("/:id/mfa", middleware.AuthMiddlewareWithJWT(a.jwtService), middleware.RequirePermission("users.update"), authHandler.ResetUserMFA) // DELETE /api/v1/users/:id/mfa
+				users.POST("/:id/unlock", middleware.AuthMiddlewareWithJWT(a.jwtService), middleware.RequirePermission("users.update"), authHandler.UnlockUser) // POST /api/v1/users/:id/unlock
+				users.GET("/:id/lockouts", middleware.AuthMiddlewareWithJWT(a.jwtService), middleware.RequirePermission("users.read"), authHandler.ListUserLockouts) // GET /api/v1/users/:id/lockouts
+				users.POST("/:id/deactivate", middleware.AuthMiddlewareWithJWT(a.jwtService), middleware.RequirePermission("users.update"), authHandler.DeactivateUser) // POST /api/v1/users/:id/deactivate
+				users.POST("/:id/activate", middleware.AuthMiddlewareWithJWT(a.jwtService), middleware.RequirePermission("users.update"), authHandler.ActivateUser) // POST /api/v1/users/:id/activate
+			}
+
+			// Route endpoints (all require authentication and are scoped to the caller's organization)
+			routes := v1.Group("/routes")
+			routes.Use(middleware.AuthMiddlewareWithJWT(a.jwtService))
+			{
+				routes.GET("", middleware.RequirePermission("routes.read"), routeHandler.ListRoutes)            // GET /api/v1/routes
+				routes.POST("", middleware.RequirePermission("routes.create"), routeHandler.CreateRoute)        // POST /api/v1/routes
+				routes.POST("/plan", middleware.RequirePermission("routes.create"), routeHandler.PlanRoutes)    // POST /api/v1/routes/plan
+				routes.GET("/:id", middleware.RequirePermission("routes.read"), routeHandler.GetRoute)          // GET /api/v1/routes/:id
+				routes.PATCH("/:id", middleware.RequirePermission("routes.update"), routeHandler.UpdateRoute)   // PATCH /api/v1/routes/:id
+				routes.DELETE("/:id", middleware.RequirePermission("routes.delete"), routeHandler.DeleteRoute)  // DELETE /api/v1/routes/:id
+				routes.POST("/:id/optimize", middleware.RequirePermission("routes.optimize"), routeHandler.OptimizeRoute) // POST /api/v1/routes/:id/optimize
+				routes.GET("/:id/schedule", middleware.RequirePermission("routes.read"), routeHandler.GetRouteSchedule)   // GET /api/v1/routes/:id/schedule
+				routes.POST("/:id/start", middleware.RequirePermission("routes.update_status"), routeHandler.StartRoute)       // POST /api/v1/routes/:id/start
+				routes.POST("/:id/pause", middleware.RequirePermission("routes.update_status"), routeHandler.PauseRoute)       // POST /api/v1/routes/:id/pause
+				routes.POST("/:id/resume", middleware.RequirePermission("routes.update_status"), routeHandler.ResumeRoute)     // POST /api/v1/routes/:id/resume
+				routes.POST("/:id/complete", middleware.RequirePermission("routes.update_status"), routeHandler.CompleteRoute) // POST /api/v1/routes/:id/complete
+				routes.POST("/:id/cancel", middleware.RequirePermission("routes.update"), routeHandler.CancelRoute)            // POST /api/v1/routes/:id/cancel
+				routes.GET("/:id/activities", middleware.RequirePermission("routes.read"), routeHandler.ListRouteActivities)           // GET /api/v1/routes/:id/activities
+				routes.POST("/:id/activities", middleware.RequirePermission("routes.update_status"), routeHandler.CreateRouteActivity) // POST /api/v1/routes/:id/activities
+				routes.GET("/:id/stops/:stop_id/attachments", middleware.RequirePermission("routes.read"), attachmentHandler.ListStopAttachments)                           // GET /api/v1/routes/:id/stops/:stop_id/attachments
+				routes.POST("/:id/stops/:stop_id/attachments", middleware.RequirePermission("routes.update_status"), attachmentHandler.UploadStopAttachment)                // POST /api/v1/routes/:id/stops/:stop_id/attachments
+				routes.DELETE("/:id/stops/:stop_id/attachments/:attachment_id", middleware.RequirePermission("routes.update"), attachmentHandler.DeleteStopAttachment)     // DELETE /api/v1/routes/:id/stops/:stop_id/attachments/:attachment_id
+				routes.POST("/:id/stops/:stop_id/complete", middleware.RequirePermission("routes.update_status"), proofHandler.CompleteStop) // POST /api/v1/routes/:id/stops/:stop_id/complete
+				routes.GET("/:id/stops/:stop_id/proof", middleware.RequirePermission("routes.read"), proofHandler.GetStopProof)             // GET /api/v1/routes/:id/stops/:stop_id/proof
+			}
+
+
+			// Technician endpoints (owners manage all technicians; technicians manage their own profile)
+			technicians := v1.Group("/technicians")
+			technicians.Use(middleware.AuthMiddlewareWithJWT(a.jwtService))
+			{
+				technicians.GET("", middleware.RequirePermission("technicians.read"), technicianHandler.ListTechnicians)                        // GET /api/v1/technicians
+				technicians.POST("", middleware.RequirePermission("technicians.create"), technicianHandler.CreateTechnician)                    // POST /api/v1/technicians
+				technicians.GET("/me", middleware.RequirePermission("technicians.read_own"), technicianHandler.GetMyProfile)                   // GET /api/v1/technicians/me
+				technicians.PATCH("/me", middleware.RequirePermission("technicians.update_own"), technicianHandler.UpdateMyProfile)            // PATCH /api/v1/technicians/me
+				technicians.POST("/me/location", middleware.RequirePermission("technicians.update_own"), technicianHandler.RecordMyLocation)   // POST /api/v1/technicians/me/location
+				technicians.GET("/:id", middleware.RequireAnyPermission("technicians.read", "technicians.read_own"), technicianHandler.GetTechnician)         // GET /api/v1/technicians/:id
+				technicians.PATCH("/:id", middleware.RequireAnyPermission("technicians.update", "technicians.update_own"), technicianHandler.UpdateTechnician) // PATCH /api/v1/technicians/:id
+				technicians.POST("/:id/deactivate", middleware.RequirePermission("technicians.deactivate"), technicianHandler.DeactivateTechnician) // POST /api/v1/technicians/:id/deactivate
+				technicians.GET("/:id/locations", middleware.RequireAnyPermission("technicians.read", "technicians.read_own"), technicianHandler.GetLocationTrail) // GET /api/v1/technicians/:id/locations
+				technicians.GET("/:id/activities", middleware.RequireAnyPermission("technicians.read", "technicians.read_own"), technicianHandler.ListTechnicianActivities) // GET /api/v1/technicians/:id/activities
 			}
 			
+			// Stop checklist endpoints (owners define what technicians capture for each stop type)
+			stopChecklists := v1.Group("/stop-checklists")
+			stopChecklists.Use(middleware.AuthMiddlewareWithJWT(a.jwtService))
+			{
+				stopChecklists.GET("", middleware.RequirePermission("routes.read"), stopChecklistHandler.ListStopChecklists)                          // GET /api/v1/stop-checklists
+				stopChecklists.GET("/:stop_type", middleware.RequirePermission("routes.read"), stopChecklistHandler.GetStopChecklist)                // GET /api/v1/stop-checklists/:stop_type
+				stopChecklists.PUT("/:stop_type", middleware.RequirePermission("organizations.update"), stopChecklistHandler.PutStopChecklist)       // PUT /api/v1/stop-checklists/:stop_type
+				stopChecklists.DELETE("/:stop_type", middleware.RequirePermission("organizations.update"), stopChecklistHandler.DeleteStopChecklist) // DELETE /api/v1/stop-checklists/:stop_type
+			}
+
+			// Role endpoints (organizations define custom roles from the permission catalogue)
+			v1.GET("/permissions", middleware.AuthMiddlewareWithJWT(a.jwtService), middleware.RequirePermission("roles.read"), roleHandler.ListPermissions) // GET /api/v1/permissions
+			roles := v1.Group("/roles")
+			roles.Use(middleware.AuthMiddlewareWithJWT(a.jwtService))
+			{
+				roles.GET("", middleware.RequirePermission("roles.read"), roleHandler.ListRoles)         // GET /api/v1/roles
+				roles.POST("", middleware.RequirePermission("roles.create"), roleHandler.CreateRole)     // POST /api/v1/roles
+				roles.GET("/:id", middleware.RequirePermission("roles.read"), roleHandler.GetRole)       // GET /api/v1/roles/:id
+				roles.PATCH("/:id", middleware.RequirePermission("roles.update"), roleHandler.UpdateRole) // PATCH /api/v1/roles/:id
+				roles.DELETE("/:id", middleware.RequirePermission("roles.delete"), roleHandler.DeleteRole) // DELETE /api/v1/roles/:id
+			}
+
+			// Attachment downloads (authorized by the signed URL rather than a bearer token)
+			v1.GET("/attachments/:id/content", attachmentHandler.DownloadAttachment) // GET /api/v1/attachments/:id/content
+
+			// Real-time dispatch stream (Server-Sent Events, scoped to the caller's organization)
+			v1.GET("/stream", middleware.AuthMiddlewareWithJWT(a.jwtService), middleware.RequirePermission("technicians.read"), streamHandler.Stream) // GET /api/v1/stream
+
 			// Panic endpoint for testing recovery middleware
 			v1.GET("/panic", userHandler.TriggerPanic) // GET /api/v1/panic
 		}
@@ -12,10 +12,16 @@ import (
 )
 
 type Config struct {
-	Server      ServerConfig   `yaml:"server"`
-	CORS        CORSConfig     `yaml:"cors"`
-	JWT         JWTConfig      `yaml:"jwt"`
-	Database    DatabaseConfig `yaml:"database"`
+	Server      ServerConfig     `yaml:"server"`
+	CORS        CORSConfig       `yaml:"cors"`
+	JWT         JWTConfig        `yaml:"jwt"`
+	Database    DatabaseConfig   `yaml:"database"`
+	Storage     StorageConfig    `yaml:"storage"`
+	Tenant      TenantConfig     `yaml:"tenant"`
+	Mail        MailConfig       `yaml:"mail"`
+	MFA         MFAConfig        `yaml:"mfa"`
+	Lockout     LockoutConfig    `yaml:"lockout"`
+	Revocation  RevocationConfig `yaml:"revocation"`
 	Environment string
 }
 
@@ -30,9 +36,58 @@ type CORSConfig struct {
 }
 
 type JWTConfig struct {
-	Secret              string `yaml:"secret"`
-	AccessTokenExpiry   int    `yaml:"access_token_expiry"`   // in seconds
-	RefreshTokenExpiry  int    `yaml:"refresh_token_expiry"`  // in seconds
+	Secret             string        `yaml:"secret"`
+	AccessTokenExpiry  int           `yaml:"access_token_expiry"`  // in seconds
+	RefreshTokenExpiry int           `yaml:"refresh_token_expiry"` // in seconds
+	Algorithm          string        `yaml:"algorithm"`            // "HS256" signs with the secret, "RS256" and "EdDSA" with key pairs
+	KeysPath           string        `yaml:"keys_path"`            // directory of the key pairs, shared by replicas; empty keeps them in memory
+	RotationInterval   time.Duration `yaml:"rotation_interval"`    // how long a key pair signs before it is replaced; 0 never replaces it
+}
+
+type StorageConfig struct {
+	Driver          string        `yaml:"driver"`            // only "local" is supported for now
+	LocalPath       string        `yaml:"local_path"`        // root directory for the local driver
+	SigningSecret   string        `yaml:"signing_secret"`    // signs download URLs; falls back to the JWT secret in development
+	URLExpiry       time.Duration `yaml:"url_expiry"`        // lifetime of signed download URLs
+	MaxUploadSize   int64         `yaml:"max_upload_size"`   // in bytes
+	DefaultOrgQuota int64         `yaml:"default_org_quota"` // in bytes, for organizations without their own quota
+}
+
+type TenantConfig struct {
+	BaseDomain string        `yaml:"base_domain"` // tenants are served from <subdomain>.<base_domain>
+	CacheTTL   time.Duration `yaml:"cache_ttl"`   // how long subdomain lookups are cached
+}
+
+type MailConfig struct {
+	Driver       string `yaml:"driver"`        // "outbox" keeps messages locally, "smtp" delivers them
+	From         string `yaml:"from"`          // sender, e.g. "RoutrApp <no-reply@example.com>"
+	LinkBaseURL  string `yaml:"link_base_url"` // frontend URL the links in emails point to; defaults to cors.frontend_url
+	OutboxPath   string `yaml:"outbox_path"`   // directory of the outbox driver; empty keeps messages in memory
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+}
+
+type MFAConfig struct {
+	Issuer    string `yaml:"issuer"`     // shown next to the account in authenticator apps
+	SecretKey string `yaml:"secret_key"` // encrypts stored TOTP secrets; falls back to the JWT secret in development, changing it makes them unreadable
+}
+
+type LockoutConfig struct {
+	Store          string        `yaml:"store"`            // "memory" counts failures per replica, "database" shares them between replicas
+	MaxAttempts    int           `yaml:"max_attempts"`     // failed logins that lock an account; 0 disables lockouts
+	BackoffAfter   int           `yaml:"backoff_after"`    // failed logins of an account before retries are delayed
+	IPMaxAttempts  int           `yaml:"ip_max_attempts"`  // failed logins that block an IP address; 0 disables blocking
+	IPBackoffAfter int           `yaml:"ip_backoff_after"` // failed logins from an IP address before retries are delayed
+	BaseDelay      time.Duration `yaml:"base_delay"`       // first delay, doubled with every further failure
+	MaxDelay       time.Duration `yaml:"max_delay"`
+	Duration       time.Duration `yaml:"duration"` // how long a lockout lasts
+	Window         time.Duration `yaml:"window"`   // failures are forgotten after this long without another
+}
+
+type RevocationConfig struct {
+	Store string `yaml:"store"` // "memory" revokes access tokens per replica, "database" shares revocations between replicas
 }
 
 // Load loads the configuration from YAML files with environment variable expansion for production
@@ -47,9 +102,12 @@ func Load() *Config {
 			FrontendURL: constants.DefaultFrontendURL,
 		},
 		JWT: JWTConfig{
-			Secret:             constants.DEFAULT_JWT_SECRET,
+			Secret:             constants.JWT_SECRET(),
 			AccessTokenExpiry:  constants.JWT_ACCESS_TOKEN_EXPIRY,
 			RefreshTokenExpiry: constants.JWT_REFRESH_TOKEN_EXPIRY,
+			Algorithm:          constants.DefaultJWTAlgorithm,
+			KeysPath:           constants.DefaultJWTKeysPath,
+			RotationInterval:   constants.DefaultJWTRotationInterval,
 		},
 		Database: DatabaseConfig{
 			Host:         constants.DefaultDBHost,
@@ -61,6 +119,42 @@ func Load() *Config {
 			MaxIdleConns: constants.DefaultDBMaxIdleConns,
 			MaxOpenConns: constants.DefaultDBMaxOpenConns,
 			ConnMaxLife:  time.Duration(constants.DefaultDBConnMaxLife) * time.Second,
+
+			MigrationsPath: constants.DefaultDBMigrationsPath,
+		},
+		Storage: StorageConfig{
+			Driver:          constants.DefaultStorageDriver,
+			LocalPath:       constants.DefaultStorageLocalPath,
+			URLExpiry:       constants.DefaultStorageURLExpiry,
+			MaxUploadSize:   constants.DefaultMaxUploadSize,
+			DefaultOrgQuota: constants.DefaultOrgStorageQuota,
+		},
+		Tenant: TenantConfig{
+			BaseDomain: constants.DefaultTenantBaseDomain,
+			CacheTTL:   constants.DefaultTenantCacheTTL,
+		},
+		Mail: MailConfig{
+			Driver:     constants.DefaultMailDriver,
+			From:       constants.DefaultMailFrom,
+			OutboxPath: constants.DefaultMailOutboxPath,
+			SMTPPort:   constants.DefaultSMTPPort,
+		},
+		MFA: MFAConfig{
+			Issuer: constants.DefaultMFAIssuer,
+		},
+		Lockout: LockoutConfig{
+			Store:          constants.DefaultLockoutStore,
+			MaxAttempts:    constants.DefaultLockoutMaxAttempts,
+			BackoffAfter:   constants.DefaultLockoutBackoffAfter,
+			IPMaxAttempts:  constants.DefaultLockoutIPMaxAttempts,
+			IPBackoffAfter: constants.DefaultLockoutIPBackoffAfter,
+			BaseDelay:      constants.DefaultLockoutBaseDelay,
+			MaxDelay:       constants.DefaultLockoutMaxDelay,
+			Duration:       constants.DefaultLockoutDuration,
+			Window:         constants.DefaultLockoutWindow,
+		},
+		Revocation: RevocationConfig{
+			Store: constants.DefaultRevocationStore,
 		},
 	}
 
@@ -95,17 +189,55 @@ func Load() *Config {
 	return config
 }
 
+// Validate reports configuration the application must not start with
+func (c *Config) Validate() error {
+	// Local environments may run with the published defaults
+	if c.Environment == "development" || c.Environment == "test" {
+		return nil
+	}
+
+	// Tokens signed with the published default secret could be forged by anyone
+	if c.JWT.Secret == "" || c.JWT.Secret == constants.DEFAULT_JWT_SECRET {
+		return fmt.Errorf("jwt.secret must be set to a secret of its own in %s", c.Environment)
+	}
+	// Falling back to the JWT secret would tie them to it: rotating the JWT secret would then make
+	// every stored TOTP secret unreadable and every issued download URL invalid
+	if c.MFA.SecretKey == "" {
+		return fmt.Errorf("mfa.secret_key must be set in %s", c.Environment)
+	}
+	if c.Storage.SigningSecret == "" {
+		return fmt.Errorf("storage.signing_secret must be set in %s", c.Environment)
+	}
+	return nil
+}
+
 // expandEnvVars expands environment variables in config string fields (for production use)
 func expandEnvVars(c *Config) {
 	c.Server.Port = os.ExpandEnv(c.Server.Port)
 	c.CORS.FrontendURL = os.ExpandEnv(c.CORS.FrontendURL)
 	c.JWT.Secret = os.ExpandEnv(c.JWT.Secret)
+	c.JWT.Algorithm = os.ExpandEnv(c.JWT.Algorithm)
+	c.JWT.KeysPath = os.ExpandEnv(c.JWT.KeysPath)
 	c.Database.Host = os.ExpandEnv(c.Database.Host)
 	c.Database.Port = os.ExpandEnv(c.Database.Port)
 	c.Database.User = os.ExpandEnv(c.Database.User)
 	c.Database.Password = os.ExpandEnv(c.Database.Password)
 	c.Database.DatabaseName = os.ExpandEnv(c.Database.DatabaseName)
 	c.Database.SSLMode = os.ExpandEnv(c.Database.SSLMode)
+	c.Database.MigrationsPath = os.ExpandEnv(c.Database.MigrationsPath)
+	c.Storage.LocalPath = os.ExpandEnv(c.Storage.LocalPath)
+	c.Storage.SigningSecret = os.ExpandEnv(c.Storage.SigningSecret)
+	c.Tenant.BaseDomain = os.ExpandEnv(c.Tenant.BaseDomain)
+	c.Mail.From = os.ExpandEnv(c.Mail.From)
+	c.Mail.LinkBaseURL = os.ExpandEnv(c.Mail.LinkBaseURL)
+	c.Mail.OutboxPath = os.ExpandEnv(c.Mail.OutboxPath)
+	c.Mail.SMTPHost = os.ExpandEnv(c.Mail.SMTPHost)
+	c.Mail.SMTPUsername = os.ExpandEnv(c.Mail.SMTPUsername)
+	c.Mail.SMTPPassword = os.ExpandEnv(c.Mail.SMTPPassword)
+	c.MFA.Issuer = os.ExpandEnv(c.MFA.Issuer)
+	c.MFA.SecretKey = os.ExpandEnv(c.MFA.SecretKey)
+	c.Lockout.Store = os.ExpandEnv(c.Lockout.Store)
+	c.Revocation.Store = os.ExpandEnv(c.Revocation.Store)
 }
 
 // loadConfigFromYAML attempts to load configuration from YAML files
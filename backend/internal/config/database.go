@@ -21,6 +21,9 @@ type DatabaseConfig struct {
 	MaxIdleConns int           `yaml:"max_idle_conns"`
 	MaxOpenConns int           `yaml:"max_open_conns"`
 	ConnMaxLife  time.Duration `yaml:"conn_max_life"`
+
+	MigrationsPath string `yaml:"migrations_path"` // directory of the SQL migration files
+	AutoMigrate    bool   `yaml:"auto_migrate"`    // apply pending migrations on startup outside development
 }
 
 // GetDSN returns the PostgreSQL connection string
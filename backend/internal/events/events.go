@@ -0,0 +1,71 @@
+package events
+
+import (
+	"time"
+
+	"routrapp-api/internal/models"
+)
+
+// EventType identifies the kind of change an event describes
+type EventType string
+
+// Event type constants
+const (
+	EventTechnicianLocation EventType = "technician.location"
+	EventTechnicianStatus   EventType = "technician.status"
+	EventRouteStatus        EventType = "route.status"
+	EventStopCompleted      EventType = "route_stop.completed"
+	EventRouteActivity      EventType = "route.activity"
+)
+
+// Event is a change published to the subscribers of a single organization
+type Event struct {
+	ID             uint64      `json:"id"`
+	Type           EventType   `json:"type"`
+	OrganizationID uint        `json:"organization_id"`
+	Timestamp      time.Time   `json:"timestamp"`
+	Data           interface{} `json:"data"`
+}
+
+// TechnicianLocationEvent is published when a technician's current position moves
+type TechnicianLocationEvent struct {
+	TechnicianID uint      `json:"technician_id"`
+	Lat          float64   `json:"lat"`
+	Lng          float64   `json:"lng"`
+	RouteID      *uint     `json:"route_id,omitempty"`
+	RecordedAt   time.Time `json:"recorded_at"`
+}
+
+// TechnicianStatusEvent is published when a technician's status changes
+type TechnicianStatusEvent struct {
+	TechnicianID   uint                    `json:"technician_id"`
+	Status         models.TechnicianStatus `json:"status"`
+	PreviousStatus models.TechnicianStatus `json:"previous_status"`
+}
+
+// RouteStatusEvent is published when a route's status changes, and when route planning creates
+// an assigned route, with an empty previous status
+type RouteStatusEvent struct {
+	RouteID        uint               `json:"route_id"`
+	TechnicianID   *uint              `json:"technician_id,omitempty"`
+	Status         models.RouteStatus `json:"status"`
+	PreviousStatus models.RouteStatus `json:"previous_status"`
+}
+
+// StopCompletedEvent is published when a stop is marked completed or reopened
+type StopCompletedEvent struct {
+	RouteID     uint       `json:"route_id"`
+	StopID      uint       `json:"stop_id"`
+	IsCompleted bool       `json:"is_completed"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// RouteActivityEvent is published when a technician records an activity on a route
+type RouteActivityEvent struct {
+	ActivityID   uint      `json:"activity_id"`
+	RouteID      uint      `json:"route_id"`
+	RouteStopID  *uint     `json:"route_stop_id,omitempty"`
+	TechnicianID uint      `json:"technician_id"`
+	ActivityType string    `json:"activity_type"`
+	Timestamp    time.Time `json:"timestamp"`
+}
@@ -0,0 +1,149 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultBufferSize is the number of undelivered events a subscription can hold
+// before it is considered too slow and disconnected
+const DefaultBufferSize = 64
+
+// Hub fans out events to subscribers, strictly partitioned by organization.
+// A nil *Hub is valid and discards everything published to it.
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[uint]map[*Subscription]struct{}
+	nextID      uint64
+	bufferSize  int
+	closed      bool
+}
+
+// Subscription receives the events of a single organization
+type Subscription struct {
+	hub            *Hub
+	organizationID uint
+	events         chan Event
+	once           sync.Once
+}
+
+// NewHub creates an event hub with the default subscription buffer size
+func NewHub() *Hub {
+	return NewHubWithBufferSize(DefaultBufferSize)
+}
+
+// NewHubWithBufferSize creates an event hub with the given subscription buffer size
+func NewHubWithBufferSize(bufferSize int) *Hub {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	return &Hub{
+		subscribers: make(map[uint]map[*Subscription]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+// Subscribe registers a subscription for an organization's events.
+// The subscription's channel is closed if the hub is closed or the subscriber falls behind.
+func (h *Hub) Subscribe(organizationID uint) *Subscription {
+	sub := &Subscription{
+		hub:            h,
+		organizationID: organizationID,
+		events:         make(chan Event, h.bufferSize),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(sub.events)
+		return sub
+	}
+	if h.subscribers[organizationID] == nil {
+		h.subscribers[organizationID] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[organizationID][sub] = struct{}{}
+	return sub
+}
+
+// Publish delivers an event to every subscriber of the organization without blocking.
+// Subscribers whose buffer is full are disconnected so they can reconnect and resync
+// instead of silently missing events.
+func (h *Hub) Publish(organizationID uint, eventType EventType, data interface{}) {
+	if h == nil || organizationID == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+
+	h.nextID++
+	event := Event{
+		ID:             h.nextID,
+		Type:           eventType,
+		OrganizationID: organizationID,
+		Timestamp:      time.Now(),
+		Data:           data,
+	}
+
+	for sub := range h.subscribers[organizationID] {
+		select {
+		case sub.events <- event:
+		default:
+			h.removeLocked(sub)
+		}
+	}
+}
+
+// SubscriberCount returns the number of active subscriptions for an organization
+func (h *Hub) SubscriberCount(organizationID uint) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers[organizationID])
+}
+
+// Close disconnects every subscriber; later publishes are discarded
+func (h *Hub) Close() {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.subscribers {
+		for sub := range subs {
+			h.removeLocked(sub)
+		}
+	}
+}
+
+// removeLocked unregisters a subscription and closes its channel; h.mu must be held
+func (h *Hub) removeLocked(sub *Subscription) {
+	subs := h.subscribers[sub.organizationID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subscribers, sub.organizationID)
+	}
+	sub.once.Do(func() { close(sub.events) })
+}
+
+// Events returns the channel events are delivered on
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close unsubscribes from the hub; it is safe to call more than once
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.removeLocked(s)
+}
@@ -0,0 +1,113 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"routrapp-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DatabaseStore keeps the failed login counters in the login_attempts table, so every replica
+// sees the failures counted by the others
+type DatabaseStore struct {
+	db *gorm.DB
+}
+
+// NewDatabaseStore creates a store on the database
+func NewDatabaseStore(db *gorm.DB) *DatabaseStore {
+	return &DatabaseStore{db: db}
+}
+
+// Get implements Store
+func (s *DatabaseStore) Get(ctx context.Context, key string) (Entry, error) {
+	var attempt models.LoginAttempt
+	if err := s.db.WithContext(ctx).Where("key = ?", key).First(&attempt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Entry{}, nil
+		}
+		return Entry{}, err
+	}
+	return newEntry(attempt), nil
+}
+
+// AddFailure implements Store. The count is incremented in a single upsert, so failures counted
+// concurrently by several replicas are not lost.
+func (s *DatabaseStore) AddFailure(ctx context.Context, key string, now time.Time, window time.Duration) (Entry, error) {
+	failures := gorm.Expr("login_attempts.failures + 1")
+	if window > 0 {
+		failures = gorm.Expr("CASE WHEN login_attempts.last_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END", now.Add(-window))
+	}
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"failures":        failures,
+			"last_failure_at": now,
+		}),
+	}).Create(&models.LoginAttempt{Key: key, Failures: 1, LastFailureAt: now}).Error
+	if err != nil {
+		return Entry{}, err
+	}
+	return s.Get(ctx, key)
+}
+
+// Reserve implements Store. Like AddFailure it counts in a single upsert.
+func (s *DatabaseStore) Reserve(ctx context.Context, key string, now time.Time, ttl time.Duration) (Entry, error) {
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"pending":     gorm.Expr("CASE WHEN login_attempts.reserved_at IS NULL OR login_attempts.reserved_at < ? THEN 1 ELSE login_attempts.pending + 1 END", now.Add(-ttl)),
+			"reserved_at": now,
+		}),
+	}).Create(&models.LoginAttempt{Key: key, Pending: 1, ReservedAt: &now}).Error
+	if err != nil {
+		return Entry{}, err
+	}
+	return s.Get(ctx, key)
+}
+
+// Release implements Store
+func (s *DatabaseStore) Release(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Model(&models.LoginAttempt{}).
+		Where("key = ? AND pending > 0", key).
+		Update("pending", gorm.Expr("pending - 1")).Error
+}
+
+// Lock implements Store
+func (s *DatabaseStore) Lock(ctx context.Context, key string, until time.Time) error {
+	return s.db.WithContext(ctx).Model(&models.LoginAttempt{}).Where("key = ?", key).Updates(map[string]interface{}{
+		"failures":     0,
+		"locked_until": until,
+	}).Error
+}
+
+// Reset implements Store
+func (s *DatabaseStore) Reset(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where("key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+// Prune implements Store
+func (s *DatabaseStore) Prune(ctx context.Context, before time.Time) error {
+	return s.db.WithContext(ctx).
+		Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?) AND (reserved_at IS NULL OR reserved_at < ?)", before, before, before).
+		Delete(&models.LoginAttempt{}).Error
+}
+
+// newEntry converts a stored login attempt to an Entry
+func newEntry(attempt models.LoginAttempt) Entry {
+	entry := Entry{
+		Failures:      attempt.Failures,
+		LastFailureAt: attempt.LastFailureAt,
+	}
+	if attempt.LockedUntil != nil {
+		entry.LockedUntil = *attempt.LockedUntil
+	}
+	if attempt.ReservedAt != nil {
+		entry.Pending = attempt.Pending
+		entry.ReservedAt = *attempt.ReservedAt
+	}
+	return entry
+}
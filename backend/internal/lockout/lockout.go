@@ -0,0 +1,383 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"routrapp-api/internal/config"
+
+	"gorm.io/gorm"
+)
+
+// reservationTTL bounds how long a reserved attempt counts as in progress, so attempts whose request
+// never concluded them don't hold back their key for longer
+const reservationTTL = time.Minute
+
+// Entry is the failed login state of an account or IP address
+type Entry struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time // zero when the key was never locked
+	Pending       int       // attempts reserved and not concluded yet
+	ReservedAt    time.Time // time of the last reservation
+}
+
+// Store keeps the failed login counters. Keys are built with AccountKey and IPKey.
+type Store interface {
+	// Get returns the entry of key, or a zero Entry when no failure was recorded
+	Get(ctx context.Context, key string) (Entry, error)
+	// AddFailure counts a failed login at now and returns the updated entry. The count restarts
+	// when the previous failure is older than window.
+	AddFailure(ctx context.Context, key string, now time.Time, window time.Duration) (Entry, error)
+	// Reserve counts a login attempt in progress at now and returns the updated entry. Reservations
+	// are forgotten once the last of them is older than ttl.
+	Reserve(ctx context.Context, key string, now time.Time, ttl time.Duration) (Entry, error)
+	// Release ends a reservation made with Reserve
+	Release(ctx context.Context, key string) error
+	// Lock locks key until the given time and restarts its count
+	Lock(ctx context.Context, key string, until time.Time) error
+	// Reset forgets key, lifting its lockout
+	Reset(ctx context.Context, key string) error
+	// Prune forgets the keys whose last failure and lockout both ended before the given time
+	Prune(ctx context.Context, before time.Time) error
+}
+
+// AccountKey returns the key counting the failed logins of an email address in an organization.
+// Addresses that aren't registered are counted too, so lockouts don't reveal which accounts exist.
+func AccountKey(organizationID uint, email string) string {
+	return fmt.Sprintf("account:%d:%s", organizationID, strings.ToLower(strings.TrimSpace(email)))
+}
+
+// IPKey returns the key counting the failed logins from an IP address
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// EmailKey returns the key counting the account emails, such as password reset links, requested for
+// an address. It isn't tied to an organization, since a single request may cover several.
+func EmailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// emailIPKey returns the key counting the account emails requested from an IP address
+func emailIPKey(ip string) string {
+	return "email-ip:" + ip
+}
+
+// Status tells whether a login may be attempted
+type Status struct {
+	Locked     bool          // the account is locked; it can be unlocked by an owner
+	RetryAfter time.Duration // zero when a login may be attempted now
+}
+
+// Allowed reports whether a login may be attempted now
+func (s Status) Allowed() bool {
+	return s.RetryAfter <= 0
+}
+
+// Failure is the outcome of counting a failed login
+type Failure struct {
+	Failures      int  // failed logins of the account in the current window
+	AccountLocked bool // this failure locked the account
+	IPBlocked     bool // this failure blocked the IP address
+}
+
+// Guard throttles failed logins per account and per IP address. Once a key reaches its backoff
+// threshold every further failure doubles the wait before the next attempt, and once it reaches
+// its maximum the key is locked for the configured duration.
+// A nil *Guard is valid and allows every login.
+type Guard struct {
+	store  Store
+	config config.LockoutConfig
+	now    func() time.Time
+
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+// New creates a guard with the store selected by the configuration
+func New(cfg config.LockoutConfig, db *gorm.DB) (*Guard, error) {
+	switch cfg.Store {
+	case "", "memory":
+		return NewGuard(NewMemoryStore(), cfg), nil
+	case "database":
+		return NewGuard(NewDatabaseStore(db), cfg), nil
+	default:
+		return nil, fmt.Errorf("unsupported lockout store %q", cfg.Store)
+	}
+}
+
+// NewGuard creates a guard keeping its counters in store
+func NewGuard(store Store, cfg config.LockoutConfig) *Guard {
+	return NewGuardWithClock(store, cfg, time.Now)
+}
+
+// NewGuardWithClock creates a guard reading the current time from now
+func NewGuardWithClock(store Store, cfg config.LockoutConfig, now func() time.Time) *Guard {
+	return &Guard{store: store, config: cfg, now: now}
+}
+
+// Check tells whether a login to the account from the IP address may be attempted now, without
+// reserving it. Either may be empty when it is not known.
+func (g *Guard) Check(ctx context.Context, account, ip string) (Status, error) {
+	if g == nil {
+		return Status{}, nil
+	}
+	now := g.now()
+
+	if account != "" {
+		entry, err := g.store.Get(ctx, account)
+		if err != nil {
+			return Status{}, err
+		}
+		if status := g.accountStatus(entry, entry.Pending, now); !status.Allowed() {
+			return status, nil
+		}
+	}
+
+	if ip != "" {
+		entry, err := g.store.Get(ctx, IPKey(ip))
+		if err != nil {
+			return Status{}, err
+		}
+		if status := g.ipStatus(entry, entry.Pending, now); !status.Allowed() {
+			return status, nil
+		}
+	}
+
+	return Status{}, nil
+}
+
+// Reserve checks like Check whether a login may be attempted now and, when it may, reserves the
+// attempt until it is concluded with Fail or Succeed and released with Release. Attempts reserved
+// and not concluded yet count as failures, so parallel attempts can't all be checked before the
+// first of them fails. Either key may be empty when it is not known.
+func (g *Guard) Reserve(ctx context.Context, account, ip string) (Status, error) {
+	if g == nil {
+		return Status{}, nil
+	}
+	now := g.now()
+
+	if account != "" {
+		entry, err := g.store.Reserve(ctx, account, now, reservationTTL)
+		if err != nil {
+			return Status{}, err
+		}
+		if status := g.accountStatus(entry, entry.Pending-1, now); !status.Allowed() {
+			return status, g.Release(ctx, account, "")
+		}
+	}
+
+	if ip != "" {
+		entry, err := g.store.Reserve(ctx, IPKey(ip), now, reservationTTL)
+		if err != nil {
+			// The IP address wasn't reserved, only the account needs to be released
+			if releaseErr := g.Release(ctx, account, ""); releaseErr != nil {
+				return Status{}, releaseErr
+			}
+			return Status{}, err
+		}
+		if status := g.ipStatus(entry, entry.Pending-1, now); !status.Allowed() {
+			return status, g.Release(ctx, account, ip)
+		}
+	}
+
+	return Status{}, nil
+}
+
+// Release ends the reservation of an attempt made with Reserve, once the attempt was concluded or
+// abandoned. Either key may be empty when it was not reserved.
+func (g *Guard) Release(ctx context.Context, account, ip string) error {
+	if g == nil {
+		return nil
+	}
+	if account != "" {
+		if err := g.store.Release(ctx, account); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		return g.store.Release(ctx, IPKey(ip))
+	}
+	return nil
+}
+
+// Fail counts a failed login to the account from the IP address, locking either once it reaches
+// its maximum. Either may be empty when it is not known.
+func (g *Guard) Fail(ctx context.Context, account, ip string) (Failure, error) {
+	var failure Failure
+	if g == nil {
+		return failure, nil
+	}
+	now := g.now()
+	g.prune(ctx, now)
+
+	if account != "" {
+		entry, err := g.store.AddFailure(ctx, account, now, g.config.Window)
+		if err != nil {
+			return failure, err
+		}
+		failure.Failures = entry.Failures
+		if g.config.MaxAttempts > 0 && entry.Failures >= g.config.MaxAttempts {
+			if err := g.store.Lock(ctx, account, now.Add(g.config.Duration)); err != nil {
+				return failure, err
+			}
+			failure.AccountLocked = true
+		}
+	}
+
+	if ip != "" {
+		entry, err := g.store.AddFailure(ctx, IPKey(ip), now, g.config.Window)
+		if err != nil {
+			return failure, err
+		}
+		if g.config.IPMaxAttempts > 0 && entry.Failures >= g.config.IPMaxAttempts {
+			if err := g.store.Lock(ctx, IPKey(ip), now.Add(g.config.Duration)); err != nil {
+				return failure, err
+			}
+			failure.IPBlocked = true
+		}
+	}
+
+	return failure, nil
+}
+
+// Succeed forgets the failed logins of an account once a login to it completed. The count of the
+// IP address is kept, so signing in to one account doesn't allow guessing the passwords of others.
+func (g *Guard) Succeed(ctx context.Context, account string) error {
+	if g == nil {
+		return nil
+	}
+	return g.store.Reset(ctx, account)
+}
+
+// Throttle counts a request for an account email to the address, built with EmailKey, from the IP
+// address, and tells whether the email may be sent. Requests are limited like failed logins, with the
+// account maximum for the address and the IP maximum for the IP address, but under keys of their own
+// so they don't hold back logins. Refused requests count against both too, so a client that keeps
+// asking stays refused. Either may be empty when it is not known.
+func (g *Guard) Throttle(ctx context.Context, address, ip string) (Status, error) {
+	if g == nil {
+		return Status{}, nil
+	}
+	now := g.now()
+	g.prune(ctx, now)
+
+	type limit struct {
+		key         string
+		maxAttempts int
+	}
+	var limits []limit
+	if address != "" {
+		limits = append(limits, limit{address, g.config.MaxAttempts})
+	}
+	if ip != "" {
+		limits = append(limits, limit{emailIPKey(ip), g.config.IPMaxAttempts})
+	}
+	var status Status
+	for _, limit := range limits {
+		// Counting first keeps concurrent requests from all passing the check
+		entry, err := g.store.AddFailure(ctx, limit.key, now, g.config.Window)
+		if err != nil {
+			return Status{}, err
+		}
+		wait := entry.LockedUntil.Sub(now)
+		if wait <= 0 && limit.maxAttempts > 0 && entry.Failures > limit.maxAttempts {
+			if err := g.store.Lock(ctx, limit.key, now.Add(g.config.Duration)); err != nil {
+				return Status{}, err
+			}
+			wait = g.config.Duration
+		}
+		if wait > status.RetryAfter {
+			status.RetryAfter = wait
+		}
+	}
+
+	return status, nil
+}
+
+// Unlock lifts the lockout of an account and forgets its failed logins
+func (g *Guard) Unlock(ctx context.Context, account string) error {
+	if g == nil {
+		return nil
+	}
+	return g.store.Reset(ctx, account)
+}
+
+// LockDuration returns how long lockouts last
+func (g *Guard) LockDuration() time.Duration {
+	if g == nil {
+		return 0
+	}
+	return g.config.Duration
+}
+
+// accountStatus tells whether a login to an account with the entry may be attempted now, counting
+// inFlight attempts in progress as failures
+func (g *Guard) accountStatus(entry Entry, inFlight int, now time.Time) Status {
+	if now.Before(entry.LockedUntil) {
+		return Status{Locked: true, RetryAfter: entry.LockedUntil.Sub(now)}
+	}
+	return Status{RetryAfter: g.wait(entry, inFlight, g.config.BackoffAfter, g.config.MaxAttempts, now)}
+}
+
+// ipStatus tells whether a login from an IP address with the entry may be attempted now, counting
+// inFlight attempts in progress as failures. A blocked address doesn't lock the accounts it tries.
+func (g *Guard) ipStatus(entry Entry, inFlight int, now time.Time) Status {
+	if now.Before(entry.LockedUntil) {
+		return Status{RetryAfter: entry.LockedUntil.Sub(now)}
+	}
+	return Status{RetryAfter: g.wait(entry, inFlight, g.config.IPBackoffAfter, g.config.IPMaxAttempts, now)}
+}
+
+// wait returns how long to wait before the next login to a key with the entry, counting inFlight
+// attempts in progress as failures made now. An attempt that would follow enough attempts in
+// progress to lock the key waits for them to conclude.
+func (g *Guard) wait(entry Entry, inFlight int, threshold, maxAttempts int, now time.Time) time.Duration {
+	if inFlight <= 0 {
+		return g.backoff(entry, threshold, now)
+	}
+	if maxAttempts > 0 && entry.Failures+inFlight >= maxAttempts {
+		if g.config.BaseDelay > time.Second {
+			return g.config.BaseDelay
+		}
+		return time.Second
+	}
+	return g.backoff(Entry{Failures: entry.Failures + inFlight, LastFailureAt: now}, threshold, now)
+}
+
+// backoff returns how long to wait before the next login to a key with the entry. The first delay
+// is the base delay, once the key reached the threshold, and every further failure doubles it.
+func (g *Guard) backoff(entry Entry, threshold int, now time.Time) time.Duration {
+	if entry.Failures == 0 || entry.Failures < threshold || g.config.BaseDelay <= 0 {
+		return 0
+	}
+	delay := g.config.MaxDelay
+	if doublings := entry.Failures - threshold; doublings < 32 {
+		if d := g.config.BaseDelay << doublings; d > 0 && (delay <= 0 || d < delay) {
+			delay = d
+		}
+	}
+	return entry.LastFailureAt.Add(delay).Sub(now)
+}
+
+// prune forgets stale keys at most once per window, so the store doesn't grow with every address
+// and email that ever failed to sign in
+func (g *Guard) prune(ctx context.Context, now time.Time) {
+	if g.config.Window <= 0 {
+		return
+	}
+	g.mu.Lock()
+	if now.Sub(g.lastPruned) < g.config.Window {
+		g.mu.Unlock()
+		return
+	}
+	g.lastPruned = now
+	g.mu.Unlock()
+
+	// Pruning is housekeeping; a failure leaves the stale keys for the next run
+	_ = g.store.Prune(ctx, now.Add(-g.config.Window))
+}
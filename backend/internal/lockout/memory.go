@@ -0,0 +1,98 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the failed login counters in the process. Each replica counts separately, so
+// deployments with several replicas should use the DatabaseStore.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]Entry)}
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(ctx context.Context, key string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entries[key], nil
+}
+
+// AddFailure implements Store
+func (s *MemoryStore) AddFailure(ctx context.Context, key string, now time.Time, window time.Duration) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := s.entries[key]
+	if window > 0 && now.Sub(entry.LastFailureAt) > window {
+		entry.Failures = 0
+	}
+	entry.Failures++
+	entry.LastFailureAt = now
+	s.entries[key] = entry
+	return entry, nil
+}
+
+// Reserve implements Store
+func (s *MemoryStore) Reserve(ctx context.Context, key string, now time.Time, ttl time.Duration) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := s.entries[key]
+	if now.Sub(entry.ReservedAt) > ttl {
+		entry.Pending = 0
+	}
+	entry.Pending++
+	entry.ReservedAt = now
+	s.entries[key] = entry
+	return entry, nil
+}
+
+// Release implements Store
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok || entry.Pending == 0 {
+		return nil
+	}
+	entry.Pending--
+	s.entries[key] = entry
+	return nil
+}
+
+// Lock implements Store
+func (s *MemoryStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := s.entries[key]
+	entry.Failures = 0
+	entry.LockedUntil = until
+	s.entries[key] = entry
+	return nil
+}
+
+// Reset implements Store
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// Prune implements Store
+func (s *MemoryStore) Prune(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, entry := range s.entries {
+		if entry.LastFailureAt.Before(before) && entry.LockedUntil.Before(before) && entry.ReservedAt.Before(before) {
+			delete(s.entries, key)
+		}
+	}
+	return nil
+}
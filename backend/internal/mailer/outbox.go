@@ -0,0 +1,70 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Outbox keeps messages instead of delivering them, for local development and tests.
+// With a directory, every message is also written there as a file that can be opened in a mail client.
+type Outbox struct {
+	dir      string
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewOutbox creates an outbox writing to dir, creating the directory if needed; an empty dir keeps
+// messages in memory only
+func NewOutbox(dir string) (*Outbox, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("mailer: create outbox directory: %w", err)
+		}
+	}
+	return &Outbox{dir: dir}, nil
+}
+
+// Send records the message
+func (o *Outbox) Send(ctx context.Context, message Message) error {
+	if err := validateHeaders(message.To, message.Subject); err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, message)
+	if o.dir == "" {
+		return nil
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%04d.eml", now.UTC().Format("20060102T150405"), len(o.messages))
+	data := formatMessage("outbox@localhost", message, now)
+	if err := os.WriteFile(filepath.Join(o.dir, name), data, 0o640); err != nil {
+		return fmt.Errorf("mailer: write outbox message: %w", err)
+	}
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first
+func (o *Outbox) Messages() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]Message(nil), o.messages...)
+}
+
+// Last returns the most recently sent message to the address
+func (o *Outbox) Last(to string) (Message, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := len(o.messages) - 1; i >= 0; i-- {
+		if o.messages[i].To == to {
+			return o.messages[i], true
+		}
+	}
+	return Message{}, false
+}
@@ -0,0 +1,128 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"routrapp-api/internal/logger"
+)
+
+var (
+	// ErrQueueFull is returned when a message can't be queued because too many are waiting
+	ErrQueueFull = errors.New("mailer: queue is full")
+
+	// ErrQueueClosed is returned when a message is queued after the queue was closed
+	ErrQueueClosed = errors.New("mailer: queue is closed")
+)
+
+// queuedMessage is a message waiting in a queue, or a marker closing done once every message
+// queued before it was handed to the mailer
+type queuedMessage struct {
+	message Message
+	done    chan struct{}
+}
+
+// Queue delivers messages through another mailer in the background, so requests don't wait for the
+// mail server. Messages that can't be delivered are logged and dropped.
+type Queue struct {
+	next    Mailer
+	timeout time.Duration
+	items   chan queuedMessage
+	stopped chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewQueue creates a queue holding up to size messages and starts delivering them through next,
+// allowing each send the given timeout
+func NewQueue(next Mailer, size int, timeout time.Duration) *Queue {
+	q := &Queue{
+		next:    next,
+		timeout: timeout,
+		items:   make(chan queuedMessage, size),
+		stopped: make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Send queues the message without waiting for it to be delivered. Invalid headers are reported
+// right away; delivery errors are only logged.
+func (q *Queue) Send(ctx context.Context, message Message) error {
+	if err := validateHeaders(message.To, message.Subject); err != nil {
+		return err
+	}
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	select {
+	case q.items <- queuedMessage{message: message}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Flush waits until every message queued so far was handed to the mailer
+func (q *Queue) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+
+	q.mu.RLock()
+	if q.closed {
+		q.mu.RUnlock()
+		return ErrQueueClosed
+	}
+	select {
+	case q.items <- queuedMessage{done: done}:
+		q.mu.RUnlock()
+	case <-ctx.Done():
+		q.mu.RUnlock()
+		return ctx.Err()
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting messages and waits until the queued ones were handed to the mailer
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.items)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run delivers the queued messages one at a time until the queue is closed
+func (q *Queue) run() {
+	defer close(q.stopped)
+	for item := range q.items {
+		if item.done != nil {
+			close(item.done)
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), q.timeout)
+		if err := q.next.Send(ctx, item.message); err != nil {
+			logger.Errorf("Failed to send queued email %q to %s: %v", item.message.Subject, item.message.To, err)
+		}
+		cancel()
+	}
+}
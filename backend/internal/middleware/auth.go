@@ -74,14 +74,13 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Set user context in Gin context
-		c.Set(constants.USER_CONTEXT_KEY, claims.GetUserContext())
-		
-		// Also set individual claims for easy access
-		c.Set("user_id", claims.UserID)
-		c.Set("organization_id", claims.OrganizationID)
-		c.Set("user_email", claims.Email)
-		c.Set("user_role", claims.Role)
+		// Reject tokens revoked by signing out, a password or role change or deactivation
+		if abortRevokedToken(c, claims) {
+			return
+		}
+
+		// Set the caller's claims in Gin context
+		setTokenContext(c, claims)
 
 		c.Next()
 	}
@@ -149,14 +148,13 @@ func AuthMiddlewareWithJWT(jwtService *auth.JWTService) gin.HandlerFunc {
 			return
 		}
 
-		// Set user context in Gin context
-		c.Set(constants.USER_CONTEXT_KEY, claims.GetUserContext())
-		
-		// Also set individual claims for easy access
-		c.Set("user_id", claims.UserID)
-		c.Set("organization_id", claims.OrganizationID)
-		c.Set("user_email", claims.Email)
-		c.Set("user_role", claims.Role)
+		// Reject tokens revoked by signing out, a password or role change or deactivation
+		if abortRevokedToken(c, claims) {
+			return
+		}
+
+		// Set the caller's claims in Gin context
+		setTokenContext(c, claims)
 
 		c.Next()
 	}
@@ -198,14 +196,14 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Set user context in Gin context
-		c.Set(constants.USER_CONTEXT_KEY, claims.GetUserContext())
-		
-		// Also set individual claims for easy access
-		c.Set("user_id", claims.UserID)
-		c.Set("organization_id", claims.OrganizationID)
-		c.Set("user_email", claims.Email)
-		c.Set("user_role", claims.Role)
+		// Revoked token, or one whose revocation can't be checked, continue without setting user context
+		if revoked, err := isTokenRevoked(c, claims); revoked || err != nil {
+			c.Next()
+			return
+		}
+
+		// Set the caller's claims in Gin context
+		setTokenContext(c, claims)
 
 		c.Next()
 	}
@@ -261,6 +259,17 @@ func GetUserRole(c *gin.Context) (string, bool) {
 	return "", false
 }
 
+// GetSessionFamilyID retrieves the family ID of the caller's session from Gin's context; tokens issued
+// outside a session have none
+func GetSessionFamilyID(c *gin.Context) (string, bool) {
+	if familyID, exists := c.Get("session_family_id"); exists {
+		if id, ok := familyID.(string); ok && id != "" {
+			return id, true
+		}
+	}
+	return "", false
+}
+
 // RequireRole creates middleware that requires a specific role
 func RequireRole(requiredRole string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tenant"
+	"routrapp-api/internal/utils/constants"
+)
+
+// PermissionCacheInvalidator is implemented by permission checkers that cache role lookups
+type PermissionCacheInvalidator interface {
+	InvalidateRole(roleID uint)
+	InvalidateUser(userID uint)
+}
+
+// DBPermissionChecker resolves the caller's role from the database and evaluates the permissions stored on it.
+// The role name from the token passed to HasPermission is ignored: the role is looked up from the user's
+// row in the organization, so role changes take effect without reissuing tokens. Lookups are cached for ttl.
+type DBPermissionChecker struct {
+	db  *gorm.DB
+	ttl time.Duration
+
+	mu    sync.RWMutex
+	users map[userRoleKey]cachedUserRole
+	roles map[uint]cachedRole
+}
+
+// userRoleKey identifies a user within an organization
+type userRoleKey struct {
+	userID         uint
+	organizationID uint
+}
+
+// cachedUserRole is the cached role assignment of a user
+type cachedUserRole struct {
+	roleID    uint
+	expiresAt time.Time
+}
+
+// cachedRole is a cached role row; found is false for roles that do not exist
+type cachedRole struct {
+	role      models.Role
+	found     bool
+	expiresAt time.Time
+}
+
+// NewDBPermissionChecker creates a permission checker backed by the roles table, caching lookups for ttl
+func NewDBPermissionChecker(db *gorm.DB, ttl time.Duration) *DBPermissionChecker {
+	return &DBPermissionChecker{
+		db:    db,
+		ttl:   ttl,
+		users: make(map[userRoleKey]cachedUserRole),
+		roles: make(map[uint]cachedRole),
+	}
+}
+
+// HasPermission checks if the user's role in the organization grants the permission
+func (dpc *DBPermissionChecker) HasPermission(userRole string, userID, organizationID uint, permission string) bool {
+	roleID, ok := dpc.userRoleID(userID, organizationID)
+	if !ok {
+		return false
+	}
+
+	role, ok := dpc.role(roleID, organizationID)
+	if !ok || !role.Active || role.OrganizationID != organizationID {
+		return false
+	}
+
+	return role.HasPermission(permission)
+}
+
+// InvalidateRole drops the cached role so the next check reloads its permissions
+func (dpc *DBPermissionChecker) InvalidateRole(roleID uint) {
+	dpc.mu.Lock()
+	defer dpc.mu.Unlock()
+	delete(dpc.roles, roleID)
+}
+
+// InvalidateUser drops the cached role assignments of the user in every organization
+func (dpc *DBPermissionChecker) InvalidateUser(userID uint) {
+	dpc.mu.Lock()
+	defer dpc.mu.Unlock()
+	for key := range dpc.users {
+		if key.userID == userID {
+			delete(dpc.users, key)
+		}
+	}
+}
+
+// userRoleID returns the role ID assigned to the user in the organization
+func (dpc *DBPermissionChecker) userRoleID(userID, organizationID uint) (uint, bool) {
+	key := userRoleKey{userID: userID, organizationID: organizationID}
+	now := time.Now()
+
+	dpc.mu.RLock()
+	cached, hit := dpc.users[key]
+	dpc.mu.RUnlock()
+	if hit && now.Before(cached.expiresAt) {
+		return cached.roleID, cached.roleID != 0
+	}
+
+	var user models.User
+	err := dpc.db.WithContext(tenant.WithOrganization(context.Background(), organizationID)).
+		Select("id", "role_id").
+		Where("id = ? AND organization_id = ?", userID, organizationID).
+		First(&user).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		// Do not cache lookup failures so the next request retries
+		logger.Errorf("Failed to resolve role of user %d in organization %d: %v", userID, organizationID, err)
+		return 0, false
+	}
+
+	dpc.mu.Lock()
+	dpc.users[key] = cachedUserRole{roleID: user.RoleID, expiresAt: now.Add(dpc.ttl)}
+	dpc.mu.Unlock()
+	return user.RoleID, user.RoleID != 0
+}
+
+// role returns the role row with the given ID in the organization
+func (dpc *DBPermissionChecker) role(roleID, organizationID uint) (models.Role, bool) {
+	now := time.Now()
+
+	dpc.mu.RLock()
+	cached, hit := dpc.roles[roleID]
+	dpc.mu.RUnlock()
+	if hit && now.Before(cached.expiresAt) {
+		return cached.role, cached.found
+	}
+
+	var role models.Role
+	err := dpc.db.WithContext(tenant.WithOrganization(context.Background(), organizationID)).First(&role, roleID).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		logger.Errorf("Failed to load role %d: %v", roleID, err)
+		return models.Role{}, false
+	}
+
+	found := err == nil
+	dpc.mu.Lock()
+	dpc.roles[roleID] = cachedRole{role: role, found: found, expiresAt: now.Add(dpc.ttl)}
+	dpc.mu.Unlock()
+	return role, found
+}
+
+// PermissionCheckerMiddleware installs the permission checker used by the RBAC middleware and
+// HasPermission for the rest of the request
+func PermissionCheckerMiddleware(checker PermissionChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(constants.PERMISSION_CHECKER_CONTEXT_KEY, checker)
+		c.Next()
+	}
+}
+
+// GetPermissionChecker returns the permission checker installed on the request,
+// falling back to the DefaultPermissionChecker role defaults
+func GetPermissionChecker(c *gin.Context) PermissionChecker {
+	if checker, exists := c.Get(constants.PERMISSION_CHECKER_CONTEXT_KEY); exists {
+		if pc, ok := checker.(PermissionChecker); ok {
+			return pc
+		}
+	}
+	return &DefaultPermissionChecker{}
+}
+
+// InvalidateRolePermissions drops any cached permissions of the role after it has been changed
+func InvalidateRolePermissions(c *gin.Context, roleID uint) {
+	if invalidator, ok := GetPermissionChecker(c).(PermissionCacheInvalidator); ok {
+		invalidator.InvalidateRole(roleID)
+	}
+}
+
+// InvalidateUserPermissions drops the cached role assignment of the user after it has been changed
+func InvalidateUserPermissions(c *gin.Context, userID uint) {
+	if invalidator, ok := GetPermissionChecker(c).(PermissionCacheInvalidator); ok {
+		invalidator.InvalidateUser(userID)
+	}
+}
@@ -11,28 +11,20 @@ import (
 	"routrapp-api/internal/models"
 )
 
-// PermissionChecker interface allows for different permission checking strategies
+// PermissionChecker interface allows for different permission checking strategies.
+// userRole is the role name from the caller's token.
 type PermissionChecker interface {
-	HasPermission(userRole, userID, organizationID uint, permission string) bool
+	HasPermission(userRole string, userID, organizationID uint, permission string) bool
 }
 
-// DefaultPermissionChecker uses the default role-based permission system
+// DefaultPermissionChecker evaluates the default permissions of the built-in role types without a database.
+// It is the fallback when no checker has been installed with PermissionCheckerMiddleware.
 type DefaultPermissionChecker struct{}
 
-// HasPermission checks if a user has a specific permission based on their role
-func (dpc *DefaultPermissionChecker) HasPermission(userRole, userID, organizationID uint, permission string) bool {
-	// Get default permissions for the role type
-	var roleType models.RoleType
-	switch userRole {
-	case 1: // Assuming role ID 1 is owner - this could be improved with a role lookup
-		roleType = models.RoleTypeOwner
-	case 2: // Assuming role ID 2 is technician
-		roleType = models.RoleTypeTechnician
-	default:
-		return false
-	}
-
-	defaultPerms := models.GetDefaultPermissions(roleType)
+// HasPermission checks if a user has a specific permission based on their role.
+// Role names other than the built-in ones grant nothing.
+func (dpc *DefaultPermissionChecker) HasPermission(userRole string, userID, organizationID uint, permission string) bool {
+	defaultPerms := models.GetDefaultPermissions(models.RoleType(userRole))
 	
 	// Check if permission matches any of the default permissions
 	for _, perm := range defaultPerms {
@@ -68,13 +60,20 @@ func PermissionMatches(storedPerm, requestedPerm string) bool {
 }
 
 // RequirePermission creates middleware that requires a specific permission
+// using the checker installed on the request (see PermissionCheckerMiddleware)
 func RequirePermission(permission string) gin.HandlerFunc {
-	return RequirePermissionWithChecker(permission, &DefaultPermissionChecker{})
+	return RequirePermissionWithChecker(permission, nil)
 }
 
-// RequirePermissionWithChecker creates middleware that requires a specific permission using a custom checker
+// RequirePermissionWithChecker creates middleware that requires a specific permission using a custom checker.
+// A nil checker uses the checker installed on the request.
 func RequirePermissionWithChecker(permission string, checker PermissionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		checker := checker
+		if checker == nil {
+			checker = GetPermissionChecker(c)
+		}
+
 		// Ensure user is authenticated first
 		userID, exists := GetUserID(c)
 		if !exists {
@@ -105,7 +104,6 @@ func RequirePermissionWithChecker(permission string, checker PermissionChecker)
 		}
 
 		// Get user role for permission checking
-		// Note: This is a simplified approach - in production you might want to get the actual role ID
 		userRole, exists := GetUserRole(c)
 		if !exists {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
@@ -120,29 +118,8 @@ func RequirePermissionWithChecker(permission string, checker PermissionChecker)
 			return
 		}
 
-		// Convert role string to role ID for checker
-		var roleID uint
-		switch userRole {
-		case "owner":
-			roleID = 1 // This should be improved with actual role lookup
-		case "technician":
-			roleID = 2
-		default:
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-				"error": errors.NewAppErrorWithDetails(
-					http.StatusForbidden,
-					"Unknown user role",
-					map[string]interface{}{
-						"code": "UNKNOWN_ROLE",
-						"role": userRole,
-					},
-				),
-			})
-			return
-		}
-
 		// Check if user has the required permission
-		if !checker.HasPermission(roleID, userID, organizationID, permission) {
+		if !checker.HasPermission(userRole, userID, organizationID, permission) {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 				"error": errors.NewAppErrorWithDetails(
 					http.StatusForbidden,
@@ -223,32 +200,11 @@ func RequireAnyPermission(permissions ...string) gin.HandlerFunc {
 			return
 		}
 
-		// Convert role string to role ID for checker
-		var roleID uint
-		switch userRole {
-		case "owner":
-			roleID = 1
-		case "technician":
-			roleID = 2
-		default:
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-				"error": errors.NewAppErrorWithDetails(
-					http.StatusForbidden,
-					"Unknown user role",
-					map[string]interface{}{
-						"code": "UNKNOWN_ROLE",
-						"role": userRole,
-					},
-				),
-			})
-			return
-		}
-
-		checker := &DefaultPermissionChecker{}
+		checker := GetPermissionChecker(c)
 
 		// Check each permission until one is found
 		for _, permission := range permissions {
-			if checker.HasPermission(roleID, userID, organizationID, permission) {
+			if checker.HasPermission(userRole, userID, organizationID, permission) {
 				// User has this permission, continue
 				c.Next()
 				return
@@ -331,31 +287,11 @@ func RequireAllPermissions(permissions ...string) gin.HandlerFunc {
 			return
 		}
 
-		var roleID uint
-		switch userRole {
-		case "owner":
-			roleID = 1
-		case "technician":
-			roleID = 2
-		default:
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-				"error": errors.NewAppErrorWithDetails(
-					http.StatusForbidden,
-					"Unknown user role",
-					map[string]interface{}{
-						"code": "UNKNOWN_ROLE",
-						"role": userRole,
-					},
-				),
-			})
-			return
-		}
-
-		checker := &DefaultPermissionChecker{}
+		checker := GetPermissionChecker(c)
 
 		// Check all permissions - all must be satisfied
 		for _, permission := range permissions {
-			if !checker.HasPermission(roleID, userID, organizationID, permission) {
+			if !checker.HasPermission(userRole, userID, organizationID, permission) {
 				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 					"error": errors.NewAppErrorWithDetails(
 						http.StatusForbidden,
@@ -551,16 +487,5 @@ func HasPermission(c *gin.Context, permission string) bool {
 		return false
 	}
 
-	var roleID uint
-	switch userRole {
-	case "owner":
-		roleID = 1
-	case "technician":
-		roleID = 2
-	default:
-		return false
-	}
-
-	checker := &DefaultPermissionChecker{}
-	return checker.HasPermission(roleID, userID, organizationID, permission)
+	return GetPermissionChecker(c).HasPermission(userRole, userID, organizationID, permission)
 } 
\ No newline at end of file
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"routrapp-api/internal/errors"
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/revocation"
+	"routrapp-api/internal/utils/auth"
+	"routrapp-api/internal/utils/constants"
+)
+
+// RevocationMiddleware installs the deny list of access tokens checked by the auth middleware and
+// updated by handlers signing users out, for the rest of the request
+func RevocationMiddleware(list *revocation.List) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(constants.REVOCATION_LIST_CONTEXT_KEY, list)
+		c.Next()
+	}
+}
+
+// GetRevocationList returns the deny list installed on the request, or nil when there is none.
+// A nil list revokes nothing.
+func GetRevocationList(c *gin.Context) *revocation.List {
+	if list, exists := c.Get(constants.REVOCATION_LIST_CONTEXT_KEY); exists {
+		if l, ok := list.(*revocation.List); ok {
+			return l
+		}
+	}
+	return nil
+}
+
+// isTokenRevoked checks the token against the deny list. The store's errors are logged and
+// returned, and callers must then refuse the token: an outage of the store must not let revoked
+// tokens back in.
+func isTokenRevoked(c *gin.Context, claims *auth.JWTClaims) (bool, error) {
+	revoked, err := GetRevocationList(c).IsRevoked(c.Request.Context(), claims)
+	if err != nil {
+		logger.WithContext(c).Errorf("Failed to check the revocation of a token of user %d: %v", claims.UserID, err)
+	}
+	return revoked, err
+}
+
+// abortRevokedToken aborts the request with 401 when the token was revoked, or with 503 when its
+// revocation can't be checked. It reports whether the request was aborted.
+func abortRevokedToken(c *gin.Context, claims *auth.JWTClaims) bool {
+	revoked, err := isTokenRevoked(c, claims)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error": errors.NewAppErrorWithDetails(
+				http.StatusServiceUnavailable,
+				"Unable to verify the token, please retry",
+				map[string]interface{}{
+					"code": "REVOCATION_UNAVAILABLE",
+				},
+			),
+		})
+		return true
+	}
+	if revoked {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewAppErrorWithDetails(
+				http.StatusUnauthorized,
+				"Token has been revoked",
+				map[string]interface{}{
+					"code": "TOKEN_REVOKED",
+				},
+			),
+		})
+		return true
+	}
+	return false
+}
+
+// setTokenContext sets the claims of an authenticated request in Gin's context
+func setTokenContext(c *gin.Context, claims *auth.JWTClaims) {
+	// Set user context in Gin context
+	c.Set(constants.USER_CONTEXT_KEY, claims.GetUserContext())
+
+	// Also set individual claims for easy access
+	c.Set("user_id", claims.UserID)
+	c.Set("organization_id", claims.OrganizationID)
+	c.Set("user_email", claims.Email)
+	c.Set("user_role", claims.Role)
+	c.Set("session_family_id", claims.FamilyID)
+	c.Set("token_id", claims.ID)
+	c.Set("token_claims", claims)
+	if claims.ExpiresAt != nil {
+		c.Set("token_expires_at", claims.ExpiresAt.Time)
+	}
+
+	// Scope the request's database queries to the caller's organization
+	SetRequestTenant(c, claims.OrganizationID)
+}
+
+// RevokeCurrentToken revokes the access token the request was authenticated with until it expires
+func RevokeCurrentToken(c *gin.Context) error {
+	tokenID := c.GetString("token_id")
+	expiresAt, ok := c.Get("token_expires_at")
+	if tokenID == "" || !ok {
+		return nil
+	}
+	return GetRevocationList(c).RevokeToken(c.Request.Context(), tokenID, expiresAt.(time.Time))
+}
+
+// TokenExpiresAt returns when the access token the request was authenticated with expires
+func TokenExpiresAt(c *gin.Context) (time.Time, bool) {
+	expiresAt, ok := c.Get("token_expires_at")
+	if !ok {
+		return time.Time{}, false
+	}
+	t, ok := expiresAt.(time.Time)
+	return t, ok
+}
+
+// IsCurrentTokenRevoked checks again whether the access token the request was authenticated with
+// was revoked, for requests such as event streams that outlive the check made when they started.
+// As for the middleware, callers must refuse the token when the check fails.
+func IsCurrentTokenRevoked(c *gin.Context) (bool, error) {
+	value, _ := c.Get("token_claims")
+	claims, ok := value.(*auth.JWTClaims)
+	if !ok {
+		return false, nil
+	}
+	return isTokenRevoked(c, claims)
+}
+
+// RevokeSessionTokens revokes the access tokens of a user session after the session was revoked
+func RevokeSessionTokens(c *gin.Context, familyID string) error {
+	return GetRevocationList(c).RevokeSession(c.Request.Context(), familyID)
+}
+
+// RevokeUserTokens revokes the access tokens issued to a user so far, after the user signed out
+// everywhere or their password, role or status changed
+func RevokeUserTokens(c *gin.Context, userID uint) error {
+	return GetRevocationList(c).RevokeUser(c.Request.Context(), userID)
+}
@@ -1,13 +1,18 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
-	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"routrapp-api/internal/errors"
+	"routrapp-api/internal/logger"
+	"routrapp-api/internal/repositories"
+	"routrapp-api/internal/tenant"
 	"routrapp-api/internal/utils/auth"
 	"routrapp-api/internal/utils/constants"
 )
@@ -18,70 +23,139 @@ type TenantContext struct {
 	SubDomain      string
 }
 
-// TenantMiddleware extracts organization ID from JWT or subdomain and sets it in context
-func TenantMiddleware() gin.HandlerFunc {
+// TenantResolver resolves subdomains to organizations, caching lookups for ttl
+type TenantResolver struct {
+	organizations repositories.OrganizationRepository
+	baseDomain    string
+	ttl           time.Duration
+
+	mu      sync.RWMutex
+	tenants map[string]cachedTenant
+}
+
+// cachedTenant is a cached subdomain lookup; found is false for subdomains without an organization
+type cachedTenant struct {
+	organizationID uint
+	active         bool
+	found          bool
+	expiresAt      time.Time
+}
+
+// NewTenantResolver creates a resolver for tenants served from <subdomain>.<baseDomain>.
+// With an empty base domain the first label of any host with at least three labels is used.
+func NewTenantResolver(organizations repositories.OrganizationRepository, baseDomain string, ttl time.Duration) *TenantResolver {
+	return &TenantResolver{
+		organizations: organizations,
+		baseDomain:    strings.ToLower(strings.Trim(baseDomain, ".")),
+		ttl:           ttl,
+		tenants:       make(map[string]cachedTenant),
+	}
+}
+
+// Subdomain returns the tenant subdomain of the host, or "" when the host is not a tenant host
+func (r *TenantResolver) Subdomain(host string) string {
+	return extractSubdomain(host, r.baseDomain)
+}
+
+// Resolve looks up the organization serving the subdomain.
+// It returns found false for unknown subdomains and active false for deactivated organizations.
+func (r *TenantResolver) Resolve(ctx context.Context, subdomain string) (organizationID uint, active, found bool, err error) {
+	now := time.Now()
+
+	r.mu.RLock()
+	cached, hit := r.tenants[subdomain]
+	r.mu.RUnlock()
+	if hit && now.Before(cached.expiresAt) {
+		return cached.organizationID, cached.active, cached.found, nil
+	}
+
+	organization, err := r.organizations.FindBySubDomain(ctx, subdomain)
+	if err != nil && err != repositories.ErrNotFound {
+		// Do not cache lookup failures so the next request retries
+		return 0, false, false, err
+	}
+
+	entry := cachedTenant{
+		found:     err == nil,
+		expiresAt: now.Add(r.ttl),
+	}
+	if entry.found {
+		entry.organizationID = organization.ID
+		entry.active = organization.Active
+	}
+	r.mu.Lock()
+	r.tenants[subdomain] = entry
+	r.mu.Unlock()
+	return entry.organizationID, entry.active, entry.found, nil
+}
+
+// Invalidate drops the cached lookup of the subdomain after an organization was registered with it,
+// or the organization serving it was deactivated or moved to another subdomain
+func (r *TenantResolver) Invalidate(subdomain string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tenants, strings.ToLower(subdomain))
+}
+
+// TenantMiddleware resolves the organization from the subdomain or the JWT and sets it in context.
+// A subdomain must belong to an active organization, and a JWT presented on a tenant subdomain must
+// have been issued for that organization.
+func TenantMiddleware(resolver *TenantResolver, jwtService *auth.JWTService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		c.Set(constants.TENANT_RESOLVER_CONTEXT_KEY, resolver)
+
 		var tenantCtx TenantContext
 
 		// First try to get organization ID from JWT if user is authenticated
+		var tokenOrganizationID uint
 		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
-			jwtService := auth.DefaultJWTService()
-			
 			// Extract token from Bearer header
 			if tokenString, err := auth.ExtractTokenFromHeader(authHeader); err == nil {
 				// Validate the token using our JWT service
 				if claims, err := jwtService.ValidateToken(tokenString); err == nil {
-					tenantCtx.OrganizationID = claims.OrganizationID
+					tokenOrganizationID = claims.OrganizationID
 				}
 			}
 		}
 
-		// If no organization ID from JWT, try to get it from subdomain
-		if tenantCtx.OrganizationID == 0 {
-			host := c.Request.Host
-			subdomain := extractSubdomain(host)
-			
-			if subdomain != "" {
-				tenantCtx.SubDomain = subdomain
-				// In a real app, you would look up the organization ID from the database
-				// using the subdomain
-				// For now, we'll set a placeholder error
-				c.Set(constants.TENANT_CONTEXT_KEY, tenantCtx)
-				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-					"error": errors.NewAppErrorWithDetails(
-						http.StatusBadRequest,
-						"Organization not found for subdomain: "+subdomain,
-						map[string]interface{}{
-							"code": "TENANT_NOT_FOUND",
-						},
-					),
-				})
+		// Resolve the organization serving the subdomain, if any
+		if subdomain := resolver.Subdomain(c.Request.Host); subdomain != "" {
+			tenantCtx.SubDomain = subdomain
+
+			organizationID, active, found, err := resolver.Resolve(c.Request.Context(), subdomain)
+			if err != nil {
+				logger.WithContext(c).Errorf("Failed to resolve organization for subdomain %s: %v", subdomain, err)
+				abortTenantError(c, http.StatusInternalServerError, "Failed to resolve organization", "TENANT_LOOKUP_ERROR")
 				return
 			}
-		}
-
-		// If we still don't have an organization ID, check if it's explicitly set in query params
-		// (useful for public APIs or testing)
-		if tenantCtx.OrganizationID == 0 && c.Query("organization_id") != "" {
-			if orgID, err := strconv.ParseUint(c.Query("organization_id"), 10, 32); err == nil {
-				tenantCtx.OrganizationID = uint(orgID)
+			if !found {
+				abortTenantError(c, http.StatusNotFound, "Organization not found for subdomain: "+subdomain, "TENANT_NOT_FOUND")
+				return
 			}
+			if !active {
+				abortTenantError(c, http.StatusForbidden, "Organization is inactive", "TENANT_INACTIVE")
+				return
+			}
+			if tokenOrganizationID != 0 && tokenOrganizationID != organizationID {
+				logger.WithContext(c).Warnf("Token for organization %d presented on subdomain %s of organization %d", tokenOrganizationID, subdomain, organizationID)
+				abortTenantError(c, http.StatusForbidden, "Token was not issued for this organization", "TENANT_MISMATCH")
+				return
+			}
+			tenantCtx.OrganizationID = organizationID
+		} else {
+			tenantCtx.OrganizationID = tokenOrganizationID
 		}
 
 		// Set the tenant context in Gin's context
 		c.Set(constants.TENANT_CONTEXT_KEY, tenantCtx)
+		if tenantCtx.OrganizationID != 0 {
+			SetRequestTenant(c, tenantCtx.OrganizationID)
+		}
 
-		// If we have a path that requires tenant context but we don't have it, abort
-		if requiresTenantContext(c.FullPath()) && tenantCtx.OrganizationID == 0 {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"error": errors.NewAppErrorWithDetails(
-					http.StatusBadRequest,
-					"Organization context is required for this endpoint",
-					map[string]interface{}{
-						"code": "TENANT_REQUIRED",
-					},
-				),
-			})
+		// If we have a path that requires tenant context but we don't have it, abort.
+		// Requests carrying a token that could not be validated are left to the auth middleware to reject.
+		if requiresTenantContext(c.FullPath()) && tenantCtx.OrganizationID == 0 && c.GetHeader("Authorization") == "" {
+			abortTenantError(c, http.StatusBadRequest, "Organization context is required for this endpoint", "TENANT_REQUIRED")
 			return
 		}
 
@@ -99,20 +173,59 @@ func GetTenantContext(c *gin.Context) (TenantContext, bool) {
 	return TenantContext{}, false
 }
 
-// extractSubdomain extracts subdomain from host
-func extractSubdomain(host string) string {
+// InvalidateTenant drops the cached lookup of the subdomain from the resolver installed on the
+// request, if any, after the organization serving it changed
+func InvalidateTenant(c *gin.Context, subdomain string) {
+	if resolver, exists := c.Get(constants.TENANT_RESOLVER_CONTEXT_KEY); exists {
+		if r, ok := resolver.(*TenantResolver); ok {
+			r.Invalidate(subdomain)
+		}
+	}
+}
+
+// SetRequestTenant scopes the database queries made with the request's context to the organization
+func SetRequestTenant(c *gin.Context, organizationID uint) {
+	c.Request = c.Request.WithContext(tenant.WithOrganization(c.Request.Context(), organizationID))
+}
+
+// abortTenantError aborts the request with a tenant resolution error
+func abortTenantError(c *gin.Context, status int, message, code string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"error": errors.NewAppErrorWithDetails(
+			status,
+			message,
+			map[string]interface{}{
+				"code": code,
+			},
+		),
+	})
+}
+
+// extractSubdomain extracts the tenant subdomain from host.
+// With a base domain only hosts of the form <subdomain>.<baseDomain> have a subdomain, which
+// supports multi-level base domains such as app.example.co.uk; nested subdomains are ignored.
+func extractSubdomain(host, baseDomain string) string {
 	// Remove port if present
 	if colonIndex := strings.Index(host, ":"); colonIndex != -1 {
 		host = host[:colonIndex]
 	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if baseDomain != "" {
+		subdomain := strings.TrimSuffix(host, "."+baseDomain)
+		if subdomain == host || subdomain == "" || strings.Contains(subdomain, ".") {
+			return ""
+		}
+		return subdomain
+	}
 
 	parts := strings.Split(host, ".")
-	
+
 	// Handle localhost separately
 	if host == "localhost" || len(parts) < 3 {
 		return ""
 	}
-	
+
 	// Return first part as subdomain
 	return parts[0]
 }
@@ -124,6 +237,13 @@ func requiresTenantContext(path string) bool {
 		"/api/v1/health",
 		"/api/v1/auth/login",
 		"/api/v1/auth/register",
+		"/api/v1/auth/refresh", // the refresh token carries the organization
+		"/api/v1/auth/forgot-password",
+		"/api/v1/auth/reset-password", // the emailed token carries the organization
+		"/api/v1/auth/verify-email",
+		"/api/v1/auth/mfa/verify", // the MFA token carries the organization
+		"/api/v1/auth/mfa/enroll",
+		"/api/v1/attachments/", // downloads are authorized by a signed URL
 	}
 
 	for _, publicPath := range publicPaths {
@@ -134,4 +254,4 @@ func requiresTenantContext(path string) bool {
 
 	// All other API paths require tenant context
 	return strings.HasPrefix(path, "/api/v1/")
-}
\ No newline at end of file
+}
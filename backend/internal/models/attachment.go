@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// AttachmentKind distinguishes proof-of-work photos, captured signatures and other documents
+type AttachmentKind string
+
+// Attachment kind constants
+const (
+	AttachmentKindPhoto     AttachmentKind = "photo"
+	AttachmentKindDocument  AttachmentKind = "document"
+	AttachmentKindSignature AttachmentKind = "signature"
+)
+
+// StopAttachment is a photo or document uploaded against a route stop.
+// The blob lives in the storage backend under StorageKey; this row holds its metadata.
+type StopAttachment struct {
+	Base
+	RouteID      uint           `gorm:"index" json:"route_id"`
+	RouteStopID  uint           `gorm:"index" json:"route_stop_id"`
+	UploadedByID uint           `json:"uploaded_by_id"` // user who uploaded the file
+	Kind         AttachmentKind `gorm:"type:varchar(20)" json:"kind"`
+	FileName     string         `gorm:"type:varchar(255)" json:"file_name"`
+	ContentType  string         `gorm:"type:varchar(100)" json:"content_type"`
+	Size         int64          `json:"size"`                             // in bytes
+	Checksum     string         `gorm:"type:varchar(64)" json:"checksum"` // hex encoded SHA-256
+	StorageKey   string         `gorm:"type:varchar(255)" json:"-"`
+	CapturedAt   *time.Time     `json:"captured_at,omitempty"` // from EXIF, when present
+	Lat          *float64       `json:"lat,omitempty"`         // from EXIF, when present
+	Lng          *float64       `json:"lng,omitempty"`         // from EXIF, when present
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// LoginAttempt counts the failed logins of an account or IP address, for deployments sharing the
+// counts between replicas through the database. It is keyed by the lockout key and not tenant scoped.
+type LoginAttempt struct {
+	Key           string     `gorm:"primaryKey;type:varchar(255)" json:"key"`
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time  `gorm:"not null;index" json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+	Pending       int        `gorm:"not null;default:0" json:"pending"` // attempts in progress, counted before their password is checked
+	ReservedAt    *time.Time `json:"reserved_at,omitempty"`
+}
+
+// TableName returns the table name for LoginAttempt
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// AccountLockout records that an account was locked after repeated failed logins, and who unlocked it
+type AccountLockout struct {
+	Base
+	UserID         uint       `gorm:"not null;index" json:"user_id"`
+	IPAddress      string     `gorm:"type:varchar(50)" json:"ip_address"` // address of the failure that locked the account
+	FailedAttempts int        `gorm:"not null" json:"failed_attempts"`
+	LockedUntil    time.Time  `gorm:"not null" json:"locked_until"`
+	UnlockedAt     *time.Time `json:"unlocked_at,omitempty"`
+	UnlockedByID   *uint      `json:"unlocked_by_id,omitempty"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// TableName returns the table name for AccountLockout
+func (AccountLockout) TableName() string {
+	return "account_lockouts"
+}
+
+// IsActive reports whether the lockout is still in force at the given time
+func (l *AccountLockout) IsActive(now time.Time) bool {
+	return l.UnlockedAt == nil && now.Before(l.LockedUntil)
+}
@@ -6,12 +6,22 @@ package models
 // Re-export all model types for convenience
 type (
 	// Core entities
-	OrganizationModel = Organization
-	UserModel         = User
-	RoleModel         = Role
-	TechnicianModel   = Technician
-	RouteModel        = Route
-	RouteStopModel    = RouteStop
+	OrganizationModel       = Organization
+	UserModel               = User
+	RoleModel               = Role
+	TechnicianModel         = Technician
+	RouteModel              = Route
+	RouteStopModel          = RouteStop
+	TechnicianLocationModel = TechnicianLocation
+	StopAttachmentModel     = StopAttachment
+	StopChecklistModel      = StopChecklist
+	StopProofModel          = StopProof
+	UserSessionModel        = UserSession
+	UserTokenModel          = UserToken
+	UserRecoveryCodeModel   = UserRecoveryCode
+	LoginAttemptModel       = LoginAttempt
+	AccountLockoutModel     = AccountLockout
+	RevokedTokenModel       = RevokedToken
 	
 	// Enums and types
 	RoleTypeEnum         = RoleType
@@ -55,5 +65,15 @@ func AllModels() []interface{} {
 		&Route{},
 		&RouteStop{},
 		&RouteActivity{},
+		&TechnicianLocation{},
+		&StopAttachment{},
+		&StopChecklist{},
+		&StopProof{},
+		&UserSession{},
+		&UserToken{},
+		&UserRecoveryCode{},
+		&LoginAttempt{},
+		&AccountLockout{},
+		&RevokedToken{},
 	}
 } 
\ No newline at end of file
@@ -19,6 +19,8 @@ type Organization struct {
 	SecondaryColor string         `gorm:"type:varchar(20)" json:"secondary_color,omitempty"`
 	Active         bool           `gorm:"default:true" json:"active"`
 	PlanType       string         `gorm:"type:varchar(20);default:'basic'" json:"plan_type"`
+	StorageQuota   int64          `gorm:"default:0" json:"storage_quota"` // in bytes; 0 uses the configured default
+	RequireMFA     bool           `gorm:"default:false" json:"require_mfa"`
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
 	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
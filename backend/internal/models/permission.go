@@ -0,0 +1,56 @@
+package models
+
+import "strings"
+
+// PermissionDefinition describes a permission that can be granted to a role
+type PermissionDefinition struct {
+	Name        string `json:"name"`
+	Resource    string `json:"resource"`
+	Description string `json:"description"`
+}
+
+// PermissionCatalogue lists every permission checked by the API, grouped by resource.
+// Roles may be granted any of these, or "<resource>.*" for all permissions of a resource.
+var PermissionCatalogue = []PermissionDefinition{
+	{Name: "organizations.read", Resource: "organizations", Description: "View organization settings"},
+	{Name: "organizations.update", Resource: "organizations", Description: "Update organization settings and stop checklists"},
+
+	{Name: "users.read", Resource: "users", Description: "View users"},
+	{Name: "users.create", Resource: "users", Description: "Invite and create users"},
+	{Name: "users.update", Resource: "users", Description: "Update users"},
+	{Name: "users.delete", Resource: "users", Description: "Remove users"},
+
+	{Name: "roles.read", Resource: "roles", Description: "View roles and the permission catalogue"},
+	{Name: "roles.create", Resource: "roles", Description: "Create custom roles"},
+	{Name: "roles.update", Resource: "roles", Description: "Update role names and permissions"},
+	{Name: "roles.delete", Resource: "roles", Description: "Delete custom roles"},
+	{Name: "roles.assign", Resource: "roles", Description: "Move users between roles"},
+
+	{Name: "technicians.read", Resource: "technicians", Description: "View all technicians, their locations and timelines"},
+	{Name: "technicians.read_own", Resource: "technicians", Description: "View your own technician profile"},
+	{Name: "technicians.create", Resource: "technicians", Description: "Create technicians"},
+	{Name: "technicians.update", Resource: "technicians", Description: "Update any technician"},
+	{Name: "technicians.update_own", Resource: "technicians", Description: "Update your own technician profile and report location"},
+	{Name: "technicians.deactivate", Resource: "technicians", Description: "Deactivate technicians"},
+
+	{Name: "routes.read", Resource: "routes", Description: "View routes, stops, activities and proof of service"},
+	{Name: "routes.create", Resource: "routes", Description: "Create and plan routes"},
+	{Name: "routes.update", Resource: "routes", Description: "Update, reassign and cancel routes and remove attachments"},
+	{Name: "routes.delete", Resource: "routes", Description: "Delete routes"},
+	{Name: "routes.optimize", Resource: "routes", Description: "Optimize stop order"},
+	{Name: "routes.update_status", Resource: "routes", Description: "Start, pause and complete assigned routes and record stop activity"},
+}
+
+// IsKnownPermission checks if the permission is in the catalogue or is a wildcard over a catalogued resource
+func IsKnownPermission(permission string) bool {
+	resource, isWildcard := strings.CutSuffix(permission, ".*")
+	for _, definition := range PermissionCatalogue {
+		if isWildcard && definition.Resource == resource {
+			return true
+		}
+		if definition.Name == permission {
+			return true
+		}
+	}
+	return false
+}
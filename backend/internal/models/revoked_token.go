@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RevokedToken revokes the access tokens of a key issued up to NotBefore, for deployments sharing
+// the deny list between replicas through the database. Keys name a token (jti), a user session or
+// a user; they are not tenant scoped.
+type RevokedToken struct {
+	Key       string    `gorm:"primaryKey;type:varchar(255)" json:"key"`
+	NotBefore time.Time `gorm:"not null" json:"not_before"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"` // the revocation is dropped once every token it covers expired
+}
+
+// TableName returns the table name for RevokedToken
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
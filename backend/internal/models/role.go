@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 )
 
@@ -53,8 +54,16 @@ func (r *RoleType) Scan(value interface{}) error {
 	return nil
 }
 
-// IsValid checks if the role type is valid
+// customRoleNamePattern restricts custom role names to lowercase slugs that fit the name column
+var customRoleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,19}$`)
+
+// IsValid checks if the role type is a built-in role or a well-formed custom role name
 func (r RoleType) IsValid() bool {
+	return r.IsBuiltIn() || customRoleNamePattern.MatchString(string(r))
+}
+
+// IsBuiltIn checks if the role type is one of the roles every organization is created with
+func (r RoleType) IsBuiltIn() bool {
 	switch r {
 	case RoleTypeOwner, RoleTypeTechnician:
 		return true
@@ -112,6 +121,28 @@ func (r *Role) HasPermission(permission string) bool {
 	return false
 }
 
+// PermissionList returns the permissions granted by the role, falling back to the
+// default permissions of the role type when none are stored
+func (r *Role) PermissionList() []string {
+	var permissions []string
+	if r.Permissions != "" {
+		if err := json.Unmarshal([]byte(r.Permissions), &permissions); err == nil {
+			return permissions
+		}
+	}
+	return GetDefaultPermissions(r.Name)
+}
+
+// SetPermissions stores the permissions as the role's JSON permissions
+func (r *Role) SetPermissions(permissions []string) error {
+	encoded, err := json.Marshal(permissions)
+	if err != nil {
+		return err
+	}
+	r.Permissions = string(encoded)
+	return nil
+}
+
 // hasDefaultPermission checks if the permission is granted by default for the role type
 func (r *Role) hasDefaultPermission(permission string) bool {
 	defaultPerms := GetDefaultPermissions(r.Name)
@@ -29,7 +29,7 @@ type Route struct {
 	CancelledAt   *time.Time  `json:"cancelled_at,omitempty"`
 	Stops         []RouteStop `gorm:"foreignKey:RouteID" json:"stops,omitempty"`
 	IsOptimized   bool        `gorm:"default:false" json:"is_optimized"`
-	TotalDistance float64     `gorm:"type:decimal(10,2)" json:"total_distance"`
+	TotalDistance float64     `gorm:"type:decimal(10,2)" json:"total_distance"` // in kilometers
 	TotalDuration int         `json:"total_duration"` // in seconds
 	Notes         string      `gorm:"type:text" json:"notes,omitempty"`
 }
@@ -0,0 +1,131 @@
+package models
+
+import "fmt"
+
+// RouteAction represents a lifecycle action that moves a route between statuses
+type RouteAction string
+
+// Route action constants
+const (
+	RouteActionAssign   RouteAction = "assign"
+	RouteActionUnassign RouteAction = "unassign"
+	RouteActionStart    RouteAction = "start"
+	RouteActionPause    RouteAction = "pause"
+	RouteActionResume   RouteAction = "resume"
+	RouteActionComplete RouteAction = "complete"
+	RouteActionCancel   RouteAction = "cancel"
+)
+
+// Route activity types recorded for lifecycle actions
+const (
+	ActivityRouteStarted   = "route_started"
+	ActivityRoutePaused    = "route_paused"
+	ActivityRouteResumed   = "route_resumed"
+	ActivityRouteCompleted = "route_completed"
+	ActivityRouteCancelled = "route_cancelled"
+)
+
+// Route activity types posted by technicians while working a route
+const (
+	ActivityStart    = "start"
+	ActivityStop     = "stop"
+	ActivityComplete = "complete"
+	ActivityPause    = "pause"
+	ActivityResume   = "resume"
+	ActivityNote     = "note"
+	ActivityPhoto    = "photo"
+)
+
+// routeTransition is a single edge of the route lifecycle
+type routeTransition struct {
+	from RouteStatus
+	to   RouteStatus
+}
+
+// routeTransitions is the route lifecycle:
+// pending -> assigned -> started <-> paused -> completed, and cancel from any non-terminal status
+var routeTransitions = map[RouteAction][]routeTransition{
+	RouteActionAssign:   {{RouteStatusPending, RouteStatusAssigned}},
+	RouteActionUnassign: {{RouteStatusAssigned, RouteStatusPending}},
+	RouteActionStart:    {{RouteStatusAssigned, RouteStatusStarted}},
+	RouteActionPause:    {{RouteStatusStarted, RouteStatusPaused}},
+	RouteActionResume:   {{RouteStatusPaused, RouteStatusStarted}},
+	RouteActionComplete: {{RouteStatusStarted, RouteStatusCompleted}, {RouteStatusPaused, RouteStatusCompleted}},
+	RouteActionCancel: {
+		{RouteStatusPending, RouteStatusCancelled},
+		{RouteStatusAssigned, RouteStatusCancelled},
+		{RouteStatusStarted, RouteStatusCancelled},
+		{RouteStatusPaused, RouteStatusCancelled},
+	},
+}
+
+// routeActionOrder keeps AllowedActions deterministic
+var routeActionOrder = []RouteAction{
+	RouteActionAssign, RouteActionUnassign, RouteActionStart, RouteActionPause,
+	RouteActionResume, RouteActionComplete, RouteActionCancel,
+}
+
+// RouteTransitionError is returned when an action is not allowed from a route's current status
+type RouteTransitionError struct {
+	From   RouteStatus
+	Action RouteAction
+}
+
+// Error implements the error interface
+func (e *RouteTransitionError) Error() string {
+	return fmt.Sprintf("cannot %s a route that is %s", e.Action, e.From)
+}
+
+// IsValid checks if the route status is valid
+func (s RouteStatus) IsValid() bool {
+	switch s {
+	case RouteStatusPending, RouteStatusAssigned, RouteStatusStarted,
+		RouteStatusPaused, RouteStatusCompleted, RouteStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsTerminal reports whether no further transitions are possible
+func (s RouteStatus) IsTerminal() bool {
+	return s == RouteStatusCompleted || s == RouteStatusCancelled
+}
+
+// IsInProgress reports whether the route is being driven
+func (s RouteStatus) IsInProgress() bool {
+	return s == RouteStatusStarted || s == RouteStatusPaused
+}
+
+// Apply returns the status reached by performing the action, or a *RouteTransitionError
+func (s RouteStatus) Apply(action RouteAction) (RouteStatus, error) {
+	for _, transition := range routeTransitions[action] {
+		if transition.from == s {
+			return transition.to, nil
+		}
+	}
+	return s, &RouteTransitionError{From: s, Action: action}
+}
+
+// CanTransitionTo reports whether any action moves the route from s to next
+func (s RouteStatus) CanTransitionTo(next RouteStatus) bool {
+	for _, transitions := range routeTransitions {
+		for _, transition := range transitions {
+			if transition.from == s && transition.to == next {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AllowedActions returns the actions that can be performed from the status
+func (s RouteStatus) AllowedActions() []RouteAction {
+	actions := make([]RouteAction, 0)
+	for _, action := range routeActionOrder {
+		if _, err := s.Apply(action); err == nil {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
@@ -0,0 +1,199 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Stop type constants
+const (
+	StopTypePickup      = "pickup"
+	StopTypeDelivery    = "delivery"
+	StopTypeService     = "service"
+	StopTypeMaintenance = "maintenance"
+)
+
+// IsValidStopType checks if the stop type is one of the known stop types
+func IsValidStopType(stopType string) bool {
+	switch stopType {
+	case StopTypePickup, StopTypeDelivery, StopTypeService, StopTypeMaintenance:
+		return true
+	default:
+		return false
+	}
+}
+
+// ChecklistItemType is the kind of answer a checklist item expects
+type ChecklistItemType string
+
+// Checklist item type constants
+const (
+	ChecklistItemBoolean ChecklistItemType = "boolean"
+	ChecklistItemText    ChecklistItemType = "text"
+	ChecklistItemNumber  ChecklistItemType = "number"
+)
+
+// Accepts reports whether value (as decoded from JSON) is a valid answer for the item type
+func (t ChecklistItemType) Accepts(value interface{}) bool {
+	switch t {
+	case ChecklistItemBoolean:
+		_, ok := value.(bool)
+		return ok
+	case ChecklistItemText:
+		text, ok := value.(string)
+		return ok && text != ""
+	case ChecklistItemNumber:
+		_, ok := value.(float64)
+		return ok
+	default:
+		return false
+	}
+}
+
+// ChecklistItem is a single question on a stop checklist
+type ChecklistItem struct {
+	Key      string            `json:"key"`
+	Label    string            `json:"label"`
+	Type     ChecklistItemType `json:"type"`
+	Required bool              `json:"required"`
+}
+
+// ChecklistItems is a list of checklist items stored as a JSON column
+type ChecklistItems []ChecklistItem
+
+// Value implements the driver.Valuer interface for database storage
+func (items ChecklistItems) Value() (driver.Value, error) {
+	return jsonValue(items)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (items *ChecklistItems) Scan(value interface{}) error {
+	return scanJSON(value, items)
+}
+
+// StopChecklist defines what a technician must capture to complete stops of one type in an organization
+type StopChecklist struct {
+	Base
+	StopType             string         `gorm:"type:varchar(20);not null" json:"stop_type"`
+	RequireSignature     bool           `gorm:"default:false" json:"require_signature"`
+	RequireRecipientName bool           `gorm:"default:false" json:"require_recipient_name"`
+	Items                ChecklistItems `gorm:"type:text" json:"items"`
+}
+
+// RequiresProof reports whether completing a stop of the checklist's type needs a signature, a
+// recipient name or answers to required items. A nil checklist requires nothing.
+func (c *StopChecklist) RequiresProof() bool {
+	if c == nil {
+		return false
+	}
+	if c.RequireSignature || c.RequireRecipientName {
+		return true
+	}
+	for _, item := range c.Items {
+		if item.Required {
+			return true
+		}
+	}
+	return false
+}
+
+// Indexes returns the database indexes for the StopChecklist model
+func (StopChecklist) Indexes() []string {
+	return []string{
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_stop_checklists_org_stop_type ON stop_checklists(organization_id, stop_type) WHERE deleted_at IS NULL",
+	}
+}
+
+// StopOutcome is the result of a stop attempt
+type StopOutcome string
+
+// Stop outcome constants
+const (
+	StopOutcomeCompleted StopOutcome = "completed"
+	StopOutcomeFailed    StopOutcome = "failed"
+)
+
+// FailureReason explains why a stop attempt was unsuccessful
+type FailureReason string
+
+// Failure reason constants
+const (
+	FailureRecipientUnavailable FailureReason = "recipient_unavailable"
+	FailureAccessDenied         FailureReason = "access_denied"
+	FailureAddressNotFound      FailureReason = "address_not_found"
+	FailureRefused              FailureReason = "refused"
+	FailureDamaged              FailureReason = "damaged"
+	FailureWeather              FailureReason = "weather"
+	FailureVehicleIssue         FailureReason = "vehicle_issue"
+	FailureOther                FailureReason = "other"
+)
+
+// ActivityStopFailed is the route activity recorded for an unsuccessful stop attempt
+const ActivityStopFailed = "stop_failed"
+
+// ChecklistAnswer is a checklist answer together with the question it answered, so proof records
+// stay readable after the organization changes its checklist
+type ChecklistAnswer struct {
+	Key   string      `json:"key"`
+	Label string      `json:"label"`
+	Value interface{} `json:"value"`
+}
+
+// ChecklistAnswers is a list of checklist answers stored as a JSON column
+type ChecklistAnswers []ChecklistAnswer
+
+// Value implements the driver.Valuer interface for database storage
+func (answers ChecklistAnswers) Value() (driver.Value, error) {
+	return jsonValue(answers)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (answers *ChecklistAnswers) Scan(value interface{}) error {
+	return scanJSON(value, answers)
+}
+
+// StopProof is the proof-of-service record of a single attempt at a stop
+type StopProof struct {
+	Base
+	RouteID               uint             `gorm:"index" json:"route_id"`
+	RouteStopID           uint             `gorm:"index" json:"route_stop_id"`
+	TechnicianID          uint             `gorm:"index" json:"technician_id"`
+	Outcome               StopOutcome      `gorm:"type:varchar(20)" json:"outcome"`
+	RecipientName         string           `gorm:"type:varchar(100)" json:"recipient_name,omitempty"`
+	SignatureAttachmentID *uint            `json:"signature_attachment_id,omitempty"`
+	SignatureAttachment   *StopAttachment  `gorm:"foreignKey:SignatureAttachmentID" json:"signature_attachment,omitempty"`
+	Checklist             ChecklistAnswers `gorm:"type:text" json:"checklist"`
+	FailureReason         FailureReason    `gorm:"type:varchar(50)" json:"failure_reason,omitempty"`
+	Notes                 string           `gorm:"type:text" json:"notes,omitempty"`
+	Lat                   *float64         `json:"lat,omitempty"`
+	Lng                   *float64         `json:"lng,omitempty"`
+	RecordedAt            time.Time        `json:"recorded_at"`
+}
+
+// jsonValue encodes a JSON column, storing nil slices as an empty array
+func jsonValue(value interface{}) (driver.Value, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if string(encoded) == "null" {
+		return "[]", nil
+	}
+	return string(encoded), nil
+}
+
+// scanJSON decodes a JSON column into dest
+func scanJSON(value interface{}, dest interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	case []byte:
+		return json.Unmarshal(v, dest)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", value, dest)
+	}
+}
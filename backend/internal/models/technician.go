@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // TechnicianStatus represents the current status of a technician
 type TechnicianStatus string
 
@@ -23,4 +25,24 @@ type Technician struct {
 	CurrentLng     *float64         `json:"current_lng,omitempty"`
 	LastLocationAt *int64           `json:"last_location_at,omitempty"`
 	Notes          string           `gorm:"type:text" json:"notes,omitempty"`
-} 
\ No newline at end of file
+}
+
+// TechnicianLocation is a single point in a technician's location history
+type TechnicianLocation struct {
+	Base
+	TechnicianID uint      `gorm:"index" json:"technician_id"`
+	RouteID      *uint     `gorm:"index" json:"route_id,omitempty"` // route in progress when the point was recorded
+	Lat          float64   `json:"lat"`
+	Lng          float64   `json:"lng"`
+	Accuracy     *float64  `json:"accuracy,omitempty"` // in meters
+	Speed        *float64  `json:"speed,omitempty"`    // in meters per second
+	Heading      *float64  `json:"heading,omitempty"`  // in degrees clockwise from north
+	RecordedAt   time.Time `gorm:"index" json:"recorded_at"`
+}
+
+// Indexes returns the database indexes for the TechnicianLocation model
+func (TechnicianLocation) Indexes() []string {
+	return []string{
+		"CREATE INDEX IF NOT EXISTS idx_technician_locations_trail ON technician_locations(technician_id, recorded_at)",
+	}
+}
@@ -5,14 +5,20 @@ import "time"
 // User represents a user in the system
 type User struct {
 	Base
-	Email        string     `gorm:"type:varchar(100)" json:"email"`
-	Password     string     `gorm:"type:varchar(255)" json:"-"`
-	FirstName    string     `gorm:"type:varchar(100)" json:"first_name"`
-	LastName     string     `gorm:"type:varchar(100)" json:"last_name"`
-	RoleID       uint       `gorm:"not null;index" json:"role_id"`
-	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
-	RefreshToken string     `gorm:"type:varchar(1000)" json:"-"`
-	Active       bool       `gorm:"default:true" json:"active"`
+	Email       string     `gorm:"type:varchar(100)" json:"email"`
+	Password    string     `gorm:"type:varchar(255)" json:"-"`
+	FirstName   string     `gorm:"type:varchar(100)" json:"first_name"`
+	LastName    string     `gorm:"type:varchar(100)" json:"last_name"`
+	RoleID      uint       `gorm:"not null;index" json:"role_id"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	Active      bool       `gorm:"default:true" json:"active"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+
+	// Two-factor authentication; the secret is encrypted and set from the start of enrollment
+	TOTPSecret    string     `gorm:"column:totp_secret;type:varchar(255)" json:"-"`
+	TOTPEnabledAt *time.Time `gorm:"column:totp_enabled_at" json:"totp_enabled_at,omitempty"`
+	TOTPLastStep  int64      `gorm:"column:totp_last_step;not null;default:0" json:"-"` // time step of the last accepted code, so codes can't be replayed
 
 	// Relationships
 	Role        Role        `gorm:"foreignKey:RoleID" json:"role,omitempty"`
@@ -52,6 +58,16 @@ func (u *User) IsOwner() bool {
 	return u.Role.Name == RoleTypeOwner
 }
 
+// IsEmailVerified reports whether the user has confirmed their current email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsMFAEnabled reports whether the user signs in with a second factor
+func (u *User) IsMFAEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
 // IsTechnician checks if the user has technician role
 func (u *User) IsTechnician() bool {
 	if u.Role.Name == "" {
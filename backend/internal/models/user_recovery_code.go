@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// UserRecoveryCode is a single-use code that replaces the authenticator app when it is lost. Only its
+// hash is stored; the codes are shown once, when two-factor authentication is enabled.
+type UserRecoveryCode struct {
+	Base
+	UserID   uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash string     `gorm:"type:varchar(64);not null" json:"-"`
+	UsedAt   *time.Time `json:"used_at,omitempty"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// TableName returns the table name for UserRecoveryCode
+func (UserRecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Reasons a user session was revoked
+const (
+	SessionRevokedLogout         = "logout"
+	SessionRevokedByUser         = "revoked"
+	SessionRevokedPasswordChange = "password_change"
+	SessionRevokedPasswordReset  = "password_reset"
+	SessionRevokedMFAReset       = "mfa_reset"
+	SessionRevokedDeactivated    = "deactivated"
+	SessionRevokedTokenReuse     = "token_reuse" // a rotated refresh token was presented again
+)
+
+// UserSession is a signed-in device. Its refresh token is rotated on every use and only the hash
+// of the current one is stored; the tokens of a session share its family ID, so presenting an
+// earlier token of the family reveals that it was copied and revokes the session.
+type UserSession struct {
+	Base
+	UserID           uint       `gorm:"not null;index" json:"user_id"`
+	FamilyID         string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	RefreshTokenHash string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	DeviceName       string     `gorm:"type:varchar(100)" json:"device_name,omitempty"`
+	IPAddress        string     `gorm:"type:varchar(50)" json:"ip_address,omitempty"`
+	UserAgent        string     `gorm:"type:text" json:"user_agent,omitempty"`
+	LastUsedAt       time.Time  `json:"last_used_at"`
+	ExpiresAt        time.Time  `gorm:"not null;index" json:"expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason    string     `gorm:"type:varchar(50)" json:"revoked_reason,omitempty"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// TableName returns the table name for UserSession
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+// IsActive reports whether the session can still be refreshed at the given time
+func (s *UserSession) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
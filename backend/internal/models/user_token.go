@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// UserTokenPurpose is what a user token can be redeemed for
+type UserTokenPurpose string
+
+// User token purposes
+const (
+	UserTokenPasswordReset     UserTokenPurpose = "password_reset"
+	UserTokenEmailVerification UserTokenPurpose = "email_verification"
+)
+
+// UserToken is a single-use token emailed to a user, e.g. to reset their password. Only its hash is
+// stored, so the token itself exists only in the email.
+type UserToken struct {
+	Base
+	UserID    uint             `gorm:"not null;index" json:"user_id"`
+	Purpose   UserTokenPurpose `gorm:"type:varchar(30);not null" json:"purpose"`
+	TokenHash string           `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	Email     string           `gorm:"type:varchar(100);not null" json:"email"` // address the token was sent to
+	ExpiresAt time.Time        `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time       `json:"used_at,omitempty"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// TableName returns the table name for UserToken
+func (UserToken) TableName() string {
+	return "user_tokens"
+}
+
+// IsRedeemable reports whether the token is unused and unexpired at the given time
+func (t *UserToken) IsRedeemable(now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,46 @@
+package optimizer
+
+import "math"
+
+// EarthRadiusKm is the mean Earth radius used for great-circle distances
+const EarthRadiusKm = 6371.0
+
+// HaversineDistance returns the great-circle distance in kilometers between two coordinates
+func HaversineDistance(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return EarthRadiusKm * c
+}
+
+// toRadians converts degrees to radians
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// distanceMatrix precomputes pairwise distances between stops
+func distanceMatrix(stops []Stop) [][]float64 {
+	matrix := make([][]float64, len(stops))
+	for i := range stops {
+		matrix[i] = make([]float64, len(stops))
+		for j := range stops {
+			if i != j {
+				matrix[i][j] = HaversineDistance(stops[i].Lat, stops[i].Lng, stops[j].Lat, stops[j].Lng)
+			}
+		}
+	}
+	return matrix
+}
+
+// RouteDistance returns the total distance in kilometers of visiting the stops in the given order
+func RouteDistance(stops []Stop) float64 {
+	total := 0.0
+	for i := 1; i < len(stops); i++ {
+		total += HaversineDistance(stops[i-1].Lat, stops[i-1].Lng, stops[i].Lat, stops[i].Lng)
+	}
+	return roundTo(total, 2)
+}
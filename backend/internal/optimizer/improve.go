@@ -0,0 +1,122 @@
+package optimizer
+
+// maxOrOptSegment is the longest run of consecutive stops relocated by an Or-opt move
+const maxOrOptSegment = 3
+
+// costFunc scores a candidate path; lower is better
+type costFunc func(path []int) float64
+
+// improve applies 2-opt and Or-opt moves until no move lowers the cost or the
+// iteration budget is exhausted. Pinned endpoints never move.
+func improve(path []int, opts Options, cost costFunc) []int {
+	if len(path) < 3 {
+		return path
+	}
+
+	best := append([]int(nil), path...)
+	bestCost := cost(best)
+
+	for iteration := 0; iteration < opts.MaxIterations; iteration++ {
+		improved := false
+
+		if candidate, candidateCost, ok := twoOpt(best, bestCost, opts, cost); ok {
+			best, bestCost, improved = candidate, candidateCost, true
+		}
+		if candidate, candidateCost, ok := orOpt(best, bestCost, opts, cost); ok {
+			best, bestCost, improved = candidate, candidateCost, true
+		}
+
+		if !improved {
+			break
+		}
+	}
+
+	return best
+}
+
+// movableRange returns the inclusive index range of stops that may be repositioned
+func movableRange(n int, opts Options) (int, int) {
+	lo, hi := 0, n-1
+	if opts.PinFirst {
+		lo = 1
+	}
+	if opts.PinLast {
+		hi = n - 2
+	}
+	// Fixed stops stay ahead of the movable range, which is empty once they cover the route
+	if opts.FixedStops > lo {
+		lo = min(opts.FixedStops, hi+1)
+	}
+	return lo, hi
+}
+
+// twoOpt reverses path segments, returning the first improving candidate found
+func twoOpt(path []int, currentCost float64, opts Options, cost costFunc) ([]int, float64, bool) {
+	lo, hi := movableRange(len(path), opts)
+	candidate := make([]int, len(path))
+
+	for i := lo; i < hi; i++ {
+		for j := i + 1; j <= hi; j++ {
+			copy(candidate, path)
+			reverse(candidate[i : j+1])
+
+			if candidateCost := cost(candidate); candidateCost < currentCost-improvementEpsilon {
+				return append([]int(nil), candidate...), candidateCost, true
+			}
+		}
+	}
+
+	return nil, currentCost, false
+}
+
+// orOpt relocates short segments of consecutive stops (optionally reversed) to another
+// position in the path, returning the first improving candidate found
+func orOpt(path []int, currentCost float64, opts Options, cost costFunc) ([]int, float64, bool) {
+	lo, hi := movableRange(len(path), opts)
+
+	for length := 1; length <= maxOrOptSegment; length++ {
+		for start := lo; start+length-1 <= hi; start++ {
+			segment := append([]int(nil), path[start:start+length]...)
+
+			remainder := make([]int, 0, len(path)-length)
+			remainder = append(remainder, path[:start]...)
+			remainder = append(remainder, path[start+length:]...)
+
+			// Insert positions are bounded so pinned endpoints stay in place
+			insertLo, insertHi := lo, hi-length+1
+			for insert := insertLo; insert <= insertHi; insert++ {
+				if insert == start {
+					continue
+				}
+				for _, reversed := range []bool{false, true} {
+					if reversed && length == 1 {
+						continue
+					}
+
+					moved := append([]int(nil), segment...)
+					if reversed {
+						reverse(moved)
+					}
+
+					candidate := make([]int, 0, len(path))
+					candidate = append(candidate, remainder[:insert]...)
+					candidate = append(candidate, moved...)
+					candidate = append(candidate, remainder[insert:]...)
+
+					if candidateCost := cost(candidate); candidateCost < currentCost-improvementEpsilon {
+						return candidate, candidateCost, true
+					}
+				}
+			}
+		}
+	}
+
+	return nil, currentCost, false
+}
+
+// reverse reverses a slice in place
+func reverse(s []int) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
@@ -0,0 +1,328 @@
+// Package optimizer sequences route stops to minimise travel distance.
+//
+// Routes are treated as open paths: the technician starts at the first stop and
+// finishes at the last one without returning. A nearest-neighbour tour is built
+// first and then improved with 2-opt and Or-opt moves until no further gain is found.
+//
+// When a start time is given, stop time windows are taken into account: arrival and
+// departure times are simulated along the path and late arrivals are either forbidden
+// (hard windows) or penalised (soft windows).
+package optimizer
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+const (
+	// DefaultAverageSpeedKmh is the assumed driving speed used to estimate travel time
+	DefaultAverageSpeedKmh = 40.0
+
+	// DefaultMaxIterations bounds the number of improvement passes
+	DefaultMaxIterations = 100
+
+	// improvementEpsilon ignores floating point noise when comparing costs
+	improvementEpsilon = 1e-9
+)
+
+var (
+	// ErrInvalidSpeed is returned when the configured average speed is not positive
+	ErrInvalidSpeed = errors.New("average speed must be greater than zero")
+
+	// ErrStartTimeRequired is returned when time windows are enforced without a route start time
+	ErrStartTimeRequired = errors.New("a start time is required to enforce time windows")
+
+	// ErrInvalidTimeWindowMode is returned for an unknown time window mode
+	ErrInvalidTimeWindowMode = errors.New("invalid time window mode")
+)
+
+// Stop is a location to be visited on a route
+type Stop struct {
+	ID             uint
+	Lat            float64
+	Lng            float64
+	ServiceMinutes int
+	// WindowStart is the earliest time service may begin; arriving earlier means waiting
+	WindowStart *time.Time
+	// WindowEnd is the latest time service should begin
+	WindowEnd *time.Time
+}
+
+// Options controls how stops are sequenced
+type Options struct {
+	// PinFirst keeps the first stop in place as the starting depot
+	PinFirst bool
+	// PinLast keeps the last stop in place as the ending depot
+	PinLast bool
+	// FixedStops keeps that many leading stops in place and in order, such as the stops already
+	// visited on a route in progress
+	FixedStops int
+	// AverageSpeedKmh is used to convert distances into travel time
+	AverageSpeedKmh float64
+	// MaxIterations bounds the number of improvement passes
+	MaxIterations int
+	// StartTime is when the technician arrives at the first stop; a zero value disables scheduling
+	StartTime time.Time
+	// TimeWindows controls how stop time windows constrain the sequence
+	TimeWindows TimeWindowMode
+	// LatePenaltyPerMinute is the cost in kilometers added per minute late under soft windows
+	LatePenaltyPerMinute float64
+}
+
+// DefaultOptions returns the default optimizer options
+func DefaultOptions() Options {
+	return Options{
+		AverageSpeedKmh:      DefaultAverageSpeedKmh,
+		MaxIterations:        DefaultMaxIterations,
+		TimeWindows:          TimeWindowsIgnore,
+		LatePenaltyPerMinute: DefaultLatePenaltyPerMinute,
+	}
+}
+
+// Result describes an optimized stop sequence
+type Result struct {
+	// Order holds indices into the input stops in visiting order
+	Order []int
+	// Stops holds the input stops in visiting order
+	Stops []Stop
+	// TotalDistanceKm is the total driving distance in kilometers
+	TotalDistanceKm float64
+	// TotalDurationSeconds is the driving time plus time spent at stops, including any
+	// waiting for time windows to open when a schedule is computed
+	TotalDurationSeconds int
+	// Schedule holds the simulated timings per stop; empty when no start time is set
+	Schedule []StopSchedule
+	// LateStops lists the IDs of stops whose time window would be missed
+	LateStops []uint
+}
+
+// Feasible reports whether every stop is reached within its time window
+func (r *Result) Feasible() bool {
+	return len(r.LateStops) == 0
+}
+
+// Optimize computes a short visiting order for the given stops
+func Optimize(stops []Stop, opts Options) (*Result, error) {
+	opts, err := normalizeOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stops) == 0 {
+		return &Result{Order: []int{}, Stops: []Stop{}}, nil
+	}
+
+	matrix := distanceMatrix(stops)
+	cost := pathCost(stops, matrix, opts)
+
+	path := nearestNeighbour(matrix, opts, cost)
+	if opts.TimeWindows != TimeWindowsIgnore {
+		if seed := deadlineOrder(stops, opts); cost(seed) < cost(path) {
+			path = seed
+		}
+	}
+	path = improve(path, opts, cost)
+
+	return buildResult(stops, matrix, path, opts), nil
+}
+
+// Evaluate computes distance, duration and schedule for the stops in their given order
+func Evaluate(stops []Stop, opts Options) (*Result, error) {
+	opts, err := normalizeOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	path := make([]int, len(stops))
+	for i := range path {
+		path[i] = i
+	}
+	return buildResult(stops, distanceMatrix(stops), path, opts), nil
+}
+
+// normalizeOptions fills in defaults and rejects invalid options
+func normalizeOptions(opts Options) (Options, error) {
+	if opts.AverageSpeedKmh == 0 {
+		opts.AverageSpeedKmh = DefaultAverageSpeedKmh
+	}
+	if opts.AverageSpeedKmh < 0 {
+		return opts, ErrInvalidSpeed
+	}
+	if opts.MaxIterations <= 0 {
+		opts.MaxIterations = DefaultMaxIterations
+	}
+	if opts.TimeWindows == "" {
+		opts.TimeWindows = TimeWindowsIgnore
+	}
+	if !opts.TimeWindows.IsValid() {
+		return opts, ErrInvalidTimeWindowMode
+	}
+	if opts.TimeWindows != TimeWindowsIgnore && opts.StartTime.IsZero() {
+		return opts, ErrStartTimeRequired
+	}
+	if opts.LatePenaltyPerMinute <= 0 {
+		opts.LatePenaltyPerMinute = DefaultLatePenaltyPerMinute
+	}
+	return opts, nil
+}
+
+// pathCost returns the cost function minimised by the optimizer: distance, plus a
+// lateness penalty when time windows are enforced
+func pathCost(stops []Stop, matrix [][]float64, opts Options) costFunc {
+	if opts.TimeWindows == TimeWindowsIgnore {
+		return func(path []int) float64 {
+			return pathDistance(matrix, path)
+		}
+	}
+
+	penalty := opts.LatePenaltyPerMinute
+	if opts.TimeWindows == TimeWindowsHard {
+		penalty = hardLatePenaltyPerMinute
+	}
+	return func(path []int) float64 {
+		return pathDistance(matrix, path) + penalty*lateMinutes(stops, matrix, path, opts)
+	}
+}
+
+// nearestNeighbour builds an initial path by repeatedly visiting the closest unvisited stop.
+// When the start is not pinned every stop is tried as a starting point and the cheapest path wins.
+func nearestNeighbour(matrix [][]float64, opts Options, cost costFunc) []int {
+	n := len(matrix)
+	if n == 1 {
+		return []int{0}
+	}
+
+	last := -1
+	if opts.PinLast {
+		last = n - 1
+	}
+
+	if opts.FixedStops > 0 {
+		fixed := make([]int, min(opts.FixedStops, n))
+		for i := range fixed {
+			fixed[i] = i
+		}
+		return nearestNeighbourFrom(matrix, fixed, last)
+	}
+
+	starts := []int{0}
+	if !opts.PinFirst {
+		starts = starts[:0]
+		for i := 0; i < n; i++ {
+			if i != last {
+				starts = append(starts, i)
+			}
+		}
+	}
+
+	var best []int
+	bestCost := math.Inf(1)
+	for _, start := range starts {
+		path := nearestNeighbourFrom(matrix, []int{start}, last)
+		if c := cost(path); c < bestCost-improvementEpsilon {
+			best = path
+			bestCost = c
+		}
+	}
+	return best
+}
+
+// nearestNeighbourFrom builds a nearest-neighbour path continuing the given prefix, appending end
+// last when end >= 0 and the prefix doesn't already hold it
+func nearestNeighbourFrom(matrix [][]float64, prefix []int, end int) []int {
+	n := len(matrix)
+	visited := make([]bool, n)
+	path := make([]int, 0, n)
+
+	for _, stop := range prefix {
+		path = append(path, stop)
+		visited[stop] = true
+	}
+	appendEnd := end >= 0 && !visited[end]
+	if appendEnd {
+		visited[end] = true
+	}
+
+	current := prefix[len(prefix)-1]
+	for len(path) < n-boolToInt(appendEnd) {
+		next := -1
+		nextDistance := math.Inf(1)
+		for candidate := 0; candidate < n; candidate++ {
+			if visited[candidate] {
+				continue
+			}
+			if matrix[current][candidate] < nextDistance {
+				next = candidate
+				nextDistance = matrix[current][candidate]
+			}
+		}
+		if next == -1 {
+			break
+		}
+		path = append(path, next)
+		visited[next] = true
+		current = next
+	}
+
+	if appendEnd {
+		path = append(path, end)
+	}
+	return path
+}
+
+// pathDistance returns the total distance of an open path
+func pathDistance(matrix [][]float64, path []int) float64 {
+	total := 0.0
+	for i := 1; i < len(path); i++ {
+		total += matrix[path[i-1]][path[i]]
+	}
+	return total
+}
+
+// buildResult assembles the optimizer result and computes route totals
+func buildResult(stops []Stop, matrix [][]float64, path []int, opts Options) *Result {
+	result := &Result{
+		Order: path,
+		Stops: make([]Stop, 0, len(path)),
+	}
+
+	serviceSeconds := 0
+	for _, index := range path {
+		result.Stops = append(result.Stops, stops[index])
+		serviceSeconds += stops[index].ServiceMinutes * 60
+	}
+
+	result.TotalDistanceKm = roundTo(pathDistance(matrix, path), 2)
+	result.TotalDurationSeconds = travelSeconds(result.TotalDistanceKm, opts.AverageSpeedKmh) + serviceSeconds
+
+	if !opts.StartTime.IsZero() && len(path) > 0 {
+		result.Schedule = buildSchedule(stops, matrix, path, opts)
+		for _, entry := range result.Schedule {
+			if entry.LateSeconds > 0 {
+				result.LateStops = append(result.LateStops, entry.StopID)
+			}
+		}
+		finish := result.Schedule[len(result.Schedule)-1].Departure
+		result.TotalDurationSeconds = int(finish.Sub(opts.StartTime).Seconds())
+	}
+	return result
+}
+
+// travelSeconds converts a distance into driving time at the given speed
+func travelSeconds(distanceKm, speedKmh float64) int {
+	return int(math.Round(distanceKm / speedKmh * 3600))
+}
+
+// roundTo rounds a value to the given number of decimal places
+func roundTo(value float64, places int) float64 {
+	factor := math.Pow(10, float64(places))
+	return math.Round(value*factor) / factor
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
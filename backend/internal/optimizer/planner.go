@@ -0,0 +1,677 @@
+package optimizer
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultBalanceWeight is how strongly the longest route counts against total drive time
+	DefaultBalanceWeight = 2.0
+
+	// DefaultPlanRounds bounds the alternation between inter-route moves and per-route sequencing
+	DefaultPlanRounds = 20
+)
+
+var (
+	// ErrNoVehicles is returned when there is nobody to assign stops to
+	ErrNoVehicles = errors.New("at least one vehicle is required")
+
+	// ErrInvalidBalanceWeight is returned when the balance weight is negative
+	ErrInvalidBalanceWeight = errors.New("balance weight must not be negative")
+)
+
+// Vehicle is a technician available to run a route, starting from their current location
+type Vehicle struct {
+	ID  uint
+	Lat float64
+	Lng float64
+}
+
+// PlanOptions controls how stops are split across vehicles
+type PlanOptions struct {
+	// AverageSpeedKmh is used to convert distances into travel time
+	AverageSpeedKmh float64
+	// MaxStopsPerRoute caps the number of stops per vehicle; zero means no limit
+	MaxStopsPerRoute int
+	// BalanceWeight scales the duration of the longest route in the objective. Zero
+	// minimises total drive time only; larger values spread work more evenly.
+	BalanceWeight float64
+	// MaxIterations bounds the improvement passes for each route's sequence
+	MaxIterations int
+	// StartTime is when every vehicle leaves its start location; a zero value disables scheduling
+	StartTime time.Time
+	// TimeWindows controls how stop time windows constrain the plan, as for Optimize
+	TimeWindows TimeWindowMode
+	// LatePenaltyPerMinute is the cost in kilometers added per minute late under soft windows
+	LatePenaltyPerMinute float64
+}
+
+// DefaultPlanOptions returns the default planner options
+func DefaultPlanOptions() PlanOptions {
+	return PlanOptions{
+		AverageSpeedKmh:      DefaultAverageSpeedKmh,
+		BalanceWeight:        DefaultBalanceWeight,
+		MaxIterations:        DefaultMaxIterations,
+		TimeWindows:          TimeWindowsIgnore,
+		LatePenaltyPerMinute: DefaultLatePenaltyPerMinute,
+	}
+}
+
+// PlannedRoute is the sequence of stops assigned to one vehicle
+type PlannedRoute struct {
+	VehicleID uint
+	// Order holds indices into the input stops in visiting order
+	Order []int
+	// Stops holds the assigned stops in visiting order
+	Stops []Stop
+	// DistanceKm includes the drive from the vehicle's start location to the first stop
+	DistanceKm float64
+	// DurationSeconds is the driving time plus time spent at stops, including any waiting for
+	// time windows to open when a schedule is computed
+	DurationSeconds int
+	// Schedule holds the simulated timings per stop; empty when no start time is set
+	Schedule []StopSchedule
+	// LateStops lists the IDs of stops whose time window would be missed
+	LateStops []uint
+}
+
+// Plan is the outcome of splitting a stop pool across vehicles
+type Plan struct {
+	// Routes holds one entry per input vehicle, in input order; a route may be empty
+	Routes []PlannedRoute
+	// Unassigned holds indices of stops that could not be placed within the stop limit
+	Unassigned []int
+	// TotalDistanceKm is the sum of all route distances
+	TotalDistanceKm float64
+	// MaxDurationSeconds is the duration of the longest route
+	MaxDurationSeconds int
+}
+
+// planner holds the working state for a multi-vehicle plan. Nodes 0..len(vehicles)-1
+// are vehicle start locations; the stop with input index i is node len(vehicles)+i.
+type planner struct {
+	stops    []Stop
+	vehicles []Vehicle
+	opts     PlanOptions
+	nodes    []Stop
+	matrix   [][]float64
+	routes   [][]int
+	drive    []float64
+	service  []float64
+	late     []float64
+
+	// schedule simulates the routes from the vehicles' start locations
+	schedule Options
+	// latePenalty is the cost in seconds of a minute late; zero when time windows are ignored
+	latePenalty float64
+	// opens, closes and serviceMinutes hold each node's time window and service time in
+	// minutes after the start time; missing bounds are infinite
+	opens          []float64
+	closes         []float64
+	serviceMinutes []float64
+	// starts holds the service start of every position of each route, and lateFrom the
+	// minutes late from each position to the end, so a candidate move only re-simulates
+	// the stops it shifts
+	starts   [][]float64
+	lateFrom [][]float64
+}
+
+// PlanRoutes splits the stops across vehicles, minimising total drive time while keeping
+// the longest route short. Routes are open paths starting at each vehicle's location.
+// When a start time is given, time windows are enforced as Optimize does: hard windows
+// minimise lateness before anything else and soft windows trade it against drive time.
+func PlanRoutes(stops []Stop, vehicles []Vehicle, opts PlanOptions) (*Plan, error) {
+	if len(vehicles) == 0 {
+		return nil, ErrNoVehicles
+	}
+	if opts.AverageSpeedKmh == 0 {
+		opts.AverageSpeedKmh = DefaultAverageSpeedKmh
+	}
+	if opts.AverageSpeedKmh < 0 {
+		return nil, ErrInvalidSpeed
+	}
+	if opts.BalanceWeight < 0 {
+		return nil, ErrInvalidBalanceWeight
+	}
+	if opts.MaxIterations <= 0 {
+		opts.MaxIterations = DefaultMaxIterations
+	}
+	schedule, err := normalizeOptions(Options{
+		PinFirst:             true,
+		AverageSpeedKmh:      opts.AverageSpeedKmh,
+		MaxIterations:        opts.MaxIterations,
+		StartTime:            opts.StartTime,
+		TimeWindows:          opts.TimeWindows,
+		LatePenaltyPerMinute: opts.LatePenaltyPerMinute,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p := newPlanner(stops, vehicles, opts, schedule)
+	unassigned := p.construct()
+
+	for round := 0; round < DefaultPlanRounds; round++ {
+		moved := p.localSearch()
+		resequenced := p.resequence()
+		if !moved && !resequenced {
+			break
+		}
+	}
+
+	return p.result(unassigned), nil
+}
+
+func newPlanner(stops []Stop, vehicles []Vehicle, opts PlanOptions, schedule Options) *planner {
+	nodes := make([]Stop, 0, len(vehicles)+len(stops))
+	for _, vehicle := range vehicles {
+		nodes = append(nodes, Stop{Lat: vehicle.Lat, Lng: vehicle.Lng})
+	}
+	nodes = append(nodes, stops...)
+
+	// The lateness penalty is expressed in kilometers, like for Optimize; the planner's
+	// objective is in seconds so it is converted at the average speed
+	latePenalty := 0.0
+	switch schedule.TimeWindows {
+	case TimeWindowsSoft:
+		latePenalty = schedule.LatePenaltyPerMinute / opts.AverageSpeedKmh * 3600
+	case TimeWindowsHard:
+		latePenalty = hardLatePenaltyPerMinute / opts.AverageSpeedKmh * 3600
+	}
+
+	p := &planner{
+		stops:          stops,
+		vehicles:       vehicles,
+		opts:           opts,
+		nodes:          nodes,
+		matrix:         distanceMatrix(nodes),
+		routes:         make([][]int, len(vehicles)),
+		drive:          make([]float64, len(vehicles)),
+		service:        make([]float64, len(vehicles)),
+		late:           make([]float64, len(vehicles)),
+		schedule:       schedule,
+		latePenalty:    latePenalty,
+		opens:          make([]float64, len(nodes)),
+		closes:         make([]float64, len(nodes)),
+		serviceMinutes: make([]float64, len(nodes)),
+		starts:         make([][]float64, len(vehicles)),
+		lateFrom:       make([][]float64, len(vehicles)),
+	}
+	for i, node := range nodes {
+		p.opens[i], p.closes[i] = math.Inf(-1), math.Inf(1)
+		if node.WindowStart != nil {
+			p.opens[i] = node.WindowStart.Sub(schedule.StartTime).Minutes()
+		}
+		if node.WindowEnd != nil {
+			p.closes[i] = node.WindowEnd.Sub(schedule.StartTime).Minutes()
+		}
+		p.serviceMinutes[i] = float64(node.ServiceMinutes)
+	}
+	for r := range p.routes {
+		p.refreshTiming(r)
+	}
+	return p
+}
+
+// node returns the matrix index of the stop with the given input index
+func (p *planner) node(stop int) int {
+	return len(p.vehicles) + stop
+}
+
+// serviceSeconds returns the time spent at a stop node
+func (p *planner) serviceSeconds(node int) float64 {
+	return float64(p.stops[node-len(p.vehicles)].ServiceMinutes * 60)
+}
+
+// duration returns a route's duration in seconds from its drive distance and service time
+func (p *planner) duration(driveKm, serviceSeconds float64) float64 {
+	return driveKm/p.opts.AverageSpeedKmh*3600 + serviceSeconds
+}
+
+// cost scores a route from its drive distance, service time and minutes late
+func (p *planner) cost(driveKm, serviceSeconds, lateMinutes float64) float64 {
+	return p.duration(driveKm, serviceSeconds) + p.latePenalty*lateMinutes
+}
+
+// objective scores the plan given the costs of every route
+func (p *planner) objective(costs []float64) float64 {
+	total, longest := 0.0, 0.0
+	for _, c := range costs {
+		total += c
+		if c > longest {
+			longest = c
+		}
+	}
+	return total + p.opts.BalanceWeight*longest
+}
+
+// costs returns the current cost of every route
+func (p *planner) costs() []float64 {
+	costs := make([]float64, len(p.routes))
+	for r := range p.routes {
+		costs[r] = p.cost(p.drive[r], p.service[r], p.late[r])
+	}
+	return costs
+}
+
+// visit drives from prev to node leaving at clock, and returns when service at node
+// starts and ends and how many minutes late it starts
+func (p *planner) visit(prev, node int, clock float64) (float64, float64, float64) {
+	start := clock + p.matrix[prev][node]/p.opts.AverageSpeedKmh*60
+	if start < p.opens[node] {
+		start = p.opens[node]
+	}
+	late := 0.0
+	if start > p.closes[node] {
+		late = start - p.closes[node]
+	}
+	return start, start + p.serviceMinutes[node], late
+}
+
+// refreshTiming re-simulates route r after it changed; it is a no-op when time windows
+// are ignored
+func (p *planner) refreshTiming(r int) {
+	if p.latePenalty == 0 {
+		return
+	}
+	route := p.routes[r]
+	starts, lateFrom := make([]float64, len(route)), make([]float64, len(route)+1)
+	prev, clock := r, 0.0
+	for k, node := range route {
+		var late float64
+		starts[k], clock, late = p.visit(prev, node, clock)
+		lateFrom[k] = late
+		prev = node
+	}
+	for k := len(route) - 1; k >= 0; k-- {
+		lateFrom[k] += lateFrom[k+1]
+	}
+	p.starts[r], p.lateFrom[r] = starts, lateFrom
+	p.late[r] = lateFrom[0]
+}
+
+// spliceLateness returns the minutes late along route r once positions [i, j) are replaced
+// by nodes; it is zero when time windows are ignored
+func (p *planner) spliceLateness(r, i, j int, nodes ...int) float64 {
+	return p.simulateSplice(r, i, j, nodes, math.Inf(1))
+}
+
+// simulateSplice computes spliceLateness. Simulation stops at the first later stop whose
+// service start is unchanged, since everything after it is too. When the splice can only
+// delay the later stops their current lateness is a lower bound, and simulation also gives
+// up once that bound exceeds limit.
+func (p *planner) simulateSplice(r, i, j int, nodes []int, limit float64) float64 {
+	if p.latePenalty == 0 {
+		return 0
+	}
+	route, starts, lateFrom := p.routes[r], p.starts[r], p.lateFrom[r]
+	late := lateFrom[0] - lateFrom[i]
+	prev, clock := r, 0.0
+	if i > 0 {
+		prev = route[i-1]
+		clock = starts[i-1] + p.serviceMinutes[prev]
+	}
+	for _, node := range nodes {
+		_, departure, lateness := p.visit(prev, node, clock)
+		prev, clock, late = node, departure, late+lateness
+	}
+	for k := j; k < len(route); k++ {
+		start, departure, lateness := p.visit(prev, route[k], clock)
+		if start == starts[k] || late+lateFrom[k] > limit {
+			return late + lateFrom[k]
+		}
+		prev, clock, late = route[k], departure, late+lateness
+	}
+	return late
+}
+
+// neighbours returns the nodes before and after position i of route r
+func (p *planner) neighbours(r, i int) (int, int) {
+	prev := r
+	if i > 0 {
+		prev = p.routes[r][i-1]
+	}
+	next := -1
+	if i < len(p.routes[r]) {
+		next = p.routes[r][i]
+	}
+	return prev, next
+}
+
+// insertDelta is the change in drive distance from inserting node before position i of route r
+func (p *planner) insertDelta(r, i, node int) float64 {
+	prev, next := p.neighbours(r, i)
+	delta := p.matrix[prev][node]
+	if next >= 0 {
+		delta += p.matrix[node][next] - p.matrix[prev][next]
+	}
+	return delta
+}
+
+// removeDelta is the change in drive distance from removing the node at position i of route r
+func (p *planner) removeDelta(r, i int) float64 {
+	node := p.routes[r][i]
+	prev := r
+	if i > 0 {
+		prev = p.routes[r][i-1]
+	}
+	delta := -p.matrix[prev][node]
+	if i+1 < len(p.routes[r]) {
+		next := p.routes[r][i+1]
+		delta += p.matrix[prev][next] - p.matrix[node][next]
+	}
+	return delta
+}
+
+// hasRoom reports whether route r can take another stop
+func (p *planner) hasRoom(r int) bool {
+	return p.opts.MaxStopsPerRoute <= 0 || len(p.routes[r]) < p.opts.MaxStopsPerRoute
+}
+
+// construct assigns stops by regret insertion: the stop that would lose the most by not
+// getting its best route is placed first. It returns the stops that did not fit.
+func (p *planner) construct() []int {
+	pending := make(map[int]bool, len(p.stops))
+	for i := range p.stops {
+		pending[i] = true
+	}
+
+	for len(pending) > 0 {
+		bestStop, bestRoute, bestPos := -1, -1, -1
+		bestRegret, bestCost := math.Inf(-1), math.Inf(1)
+
+		costs := p.costs()
+		base := p.objective(costs)
+
+		for _, stop := range sortedKeys(pending) {
+			node := p.node(stop)
+			first, second := math.Inf(1), math.Inf(1)
+			firstRoute, firstPos := -1, -1
+
+			// Regret compares the best position in the best route against the best
+			// position in the runner-up route
+			for r := range p.routes {
+				if !p.hasRoom(r) {
+					continue
+				}
+				routeCost, routePos := p.bestInsertion(costs, base, r, node)
+				switch {
+				case routeCost < first-improvementEpsilon:
+					second = first
+					first, firstRoute, firstPos = routeCost, r, routePos
+				case routeCost < second:
+					second = routeCost
+				}
+			}
+
+			if firstRoute < 0 {
+				continue
+			}
+			regret := second - first
+			if math.IsInf(second, 1) {
+				regret = math.MaxFloat64
+			}
+			if regret > bestRegret+improvementEpsilon || (math.Abs(regret-bestRegret) <= improvementEpsilon && first < bestCost) {
+				bestStop, bestRoute, bestPos = stop, firstRoute, firstPos
+				bestRegret, bestCost = regret, first
+			}
+		}
+
+		if bestStop < 0 {
+			break
+		}
+		p.insert(bestRoute, bestPos, p.node(bestStop))
+		delete(pending, bestStop)
+	}
+
+	return sortedKeys(pending)
+}
+
+// bestInsertion returns the cheapest objective increase and position for inserting node into route r
+func (p *planner) bestInsertion(costs []float64, base float64, r, node int) (float64, int) {
+	original := costs[r]
+	defer func() { costs[r] = original }()
+
+	bestCost, bestPos := math.Inf(1), -1
+	for i := 0; i <= len(p.routes[r]); i++ {
+		drive, service := p.drive[r]+p.insertDelta(r, i, node), p.service[r]+p.serviceSeconds(node)
+		costs[r] = p.cost(drive, service, p.late[r])
+		slack := bestCost - improvementEpsilon - (p.objective(costs) - base)
+		if slack <= 0 {
+			continue
+		}
+		costs[r] = p.cost(drive, service, p.insertLateness(r, i, node, p.lateBudget(r, slack)))
+		if cost := p.objective(costs) - base; cost < bestCost-improvementEpsilon {
+			bestCost, bestPos = cost, i
+		}
+	}
+	return bestCost, bestPos
+}
+
+// insertLateness returns the minutes late along route r with node inserted before position i.
+// Inserting a stop never makes the stops after it earlier, so it is at least the route's current
+// lateness; callers use that bound to skip simulating positions that cannot win. Once the
+// result is known to exceed limit simulation stops and a lower bound above limit is returned.
+func (p *planner) insertLateness(r, i, node int, limit float64) float64 {
+	return p.simulateSplice(r, i, i, []int{node}, limit)
+}
+
+// lateBudget returns the minutes late at which route r's extra lateness alone would cost more
+// than slack
+func (p *planner) lateBudget(r int, slack float64) float64 {
+	if p.latePenalty == 0 {
+		return math.Inf(1)
+	}
+	return p.late[r] + slack/p.latePenalty
+}
+
+// insert places node before position i of route r
+func (p *planner) insert(r, i, node int) {
+	p.drive[r] += p.insertDelta(r, i, node)
+	p.service[r] += p.serviceSeconds(node)
+
+	route := append(p.routes[r], 0)
+	copy(route[i+1:], route[i:])
+	route[i] = node
+	p.routes[r] = route
+	p.refreshTiming(r)
+}
+
+// remove takes the node at position i out of route r and returns it
+func (p *planner) remove(r, i int) int {
+	node := p.routes[r][i]
+	p.drive[r] += p.removeDelta(r, i)
+	p.service[r] -= p.serviceSeconds(node)
+	p.routes[r] = append(p.routes[r][:i], p.routes[r][i+1:]...)
+	p.refreshTiming(r)
+	return node
+}
+
+// localSearch applies improving relocate and swap moves between routes until none remain
+func (p *planner) localSearch() bool {
+	changed := false
+	for p.relocate() || p.swap() {
+		changed = true
+	}
+	return changed
+}
+
+// relocate moves a single stop to the best position in another route, applying the first
+// move that lowers the objective
+func (p *planner) relocate() bool {
+	costs := p.costs()
+	base := p.objective(costs)
+
+	for a := range p.routes {
+		for i := range p.routes[a] {
+			node := p.routes[a][i]
+			removed := p.cost(p.drive[a]+p.removeDelta(a, i), p.service[a]-p.serviceSeconds(node), p.spliceLateness(a, i, i+1))
+
+			for b := range p.routes {
+				if a == b || !p.hasRoom(b) {
+					continue
+				}
+				for j := 0; j <= len(p.routes[b]); j++ {
+					driveB, serviceB := p.drive[b]+p.insertDelta(b, j, node), p.service[b]+p.serviceSeconds(node)
+
+					originalA, originalB := costs[a], costs[b]
+					costs[a] = removed
+					costs[b] = p.cost(driveB, serviceB, p.late[b])
+					cost := p.objective(costs)
+					if cost < base-improvementEpsilon && p.latePenalty > 0 {
+						limit := p.lateBudget(b, base-improvementEpsilon-cost)
+						costs[b] = p.cost(driveB, serviceB, p.insertLateness(b, j, node, limit))
+						cost = p.objective(costs)
+					}
+					costs[a], costs[b] = originalA, originalB
+
+					if cost < base-improvementEpsilon {
+						p.remove(a, i)
+						p.insert(b, j, node)
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// swap exchanges two stops between routes in place, applying the first move that lowers
+// the objective
+func (p *planner) swap() bool {
+	costs := p.costs()
+	base := p.objective(costs)
+
+	for a := range p.routes {
+		for b := a + 1; b < len(p.routes); b++ {
+			for i, nodeA := range p.routes[a] {
+				for j, nodeB := range p.routes[b] {
+					driveA := p.drive[a] + p.replaceDelta(a, i, nodeB)
+					driveB := p.drive[b] + p.replaceDelta(b, j, nodeA)
+					serviceShift := p.serviceSeconds(nodeB) - p.serviceSeconds(nodeA)
+
+					lateA, lateB := p.spliceLateness(a, i, i+1, nodeB), p.spliceLateness(b, j, j+1, nodeA)
+
+					originalA, originalB := costs[a], costs[b]
+					costs[a] = p.cost(driveA, p.service[a]+serviceShift, lateA)
+					costs[b] = p.cost(driveB, p.service[b]-serviceShift, lateB)
+					cost := p.objective(costs)
+					costs[a], costs[b] = originalA, originalB
+
+					if cost < base-improvementEpsilon {
+						p.routes[a][i], p.routes[b][j] = nodeB, nodeA
+						p.drive[a], p.drive[b] = driveA, driveB
+						p.service[a] += serviceShift
+						p.service[b] -= serviceShift
+						p.refreshTiming(a)
+						p.refreshTiming(b)
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// replaceDelta is the change in drive distance from replacing the node at position i of route r
+func (p *planner) replaceDelta(r, i, node int) float64 {
+	current := p.routes[r][i]
+	prev := r
+	if i > 0 {
+		prev = p.routes[r][i-1]
+	}
+	delta := p.matrix[prev][node] - p.matrix[prev][current]
+	if i+1 < len(p.routes[r]) {
+		next := p.routes[r][i+1]
+		delta += p.matrix[node][next] - p.matrix[current][next]
+	}
+	return delta
+}
+
+// resequence reorders each route with 2-opt and Or-opt, keeping the vehicle start in place.
+// Routes are sequenced by distance plus the lateness penalty, as Optimize does for a single route.
+func (p *planner) resequence() bool {
+	cost := pathCost(p.nodes, p.matrix, p.schedule)
+	if p.schedule.TimeWindows == TimeWindowsIgnore {
+		// Lateness is not penalised when windows are ignored, even with a start time
+		cost = func(path []int) float64 {
+			return pathDistance(p.matrix, path)
+		}
+	}
+
+	changed := false
+	for r, route := range p.routes {
+		if len(route) < 2 {
+			continue
+		}
+
+		path := append([]int{r}, route...)
+		improved := improve(path, p.schedule, cost)
+		if cost(improved) < cost(path)-improvementEpsilon {
+			p.routes[r] = append([]int(nil), improved[1:]...)
+			p.drive[r] = pathDistance(p.matrix, improved)
+			p.refreshTiming(r)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// result assembles the plan from the working state
+func (p *planner) result(unassigned []int) *Plan {
+	plan := &Plan{
+		Routes:     make([]PlannedRoute, 0, len(p.vehicles)),
+		Unassigned: unassigned,
+	}
+
+	for r, vehicle := range p.vehicles {
+		route := PlannedRoute{
+			VehicleID:       vehicle.ID,
+			Order:           make([]int, 0, len(p.routes[r])),
+			Stops:           make([]Stop, 0, len(p.routes[r])),
+			DistanceKm:      roundTo(p.drive[r], 2),
+			DurationSeconds: int(math.Round(p.duration(p.drive[r], p.service[r]))),
+		}
+		for _, node := range p.routes[r] {
+			index := node - len(p.vehicles)
+			route.Order = append(route.Order, index)
+			route.Stops = append(route.Stops, p.stops[index])
+		}
+		if !p.schedule.StartTime.IsZero() && len(p.routes[r]) > 0 {
+			// The vehicle's start location comes first in the simulated path
+			path := append([]int{r}, p.routes[r]...)
+			route.Schedule = buildSchedule(p.nodes, p.matrix, path, p.schedule)[1:]
+			for _, entry := range route.Schedule {
+				if entry.IsLate() {
+					route.LateStops = append(route.LateStops, entry.StopID)
+				}
+			}
+			finish := route.Schedule[len(route.Schedule)-1].Departure
+			route.DurationSeconds = int(finish.Sub(p.schedule.StartTime).Seconds())
+		}
+
+		plan.TotalDistanceKm += route.DistanceKm
+		if route.DurationSeconds > plan.MaxDurationSeconds {
+			plan.MaxDurationSeconds = route.DurationSeconds
+		}
+		plan.Routes = append(plan.Routes, route)
+	}
+
+	plan.TotalDistanceKm = roundTo(plan.TotalDistanceKm, 2)
+	return plan
+}
+
+// sortedKeys returns the keys of a set in ascending order so planning is deterministic
+func sortedKeys(set map[int]bool) []int {
+	keys := make([]int, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
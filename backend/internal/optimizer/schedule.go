@@ -0,0 +1,137 @@
+package optimizer
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// TimeWindowMode controls how stop time windows constrain the sequence
+type TimeWindowMode string
+
+const (
+	// TimeWindowsIgnore sequences by distance only; lateness is still reported when a start time is set
+	TimeWindowsIgnore TimeWindowMode = "ignore"
+	// TimeWindowsHard minimises lateness before distance; any remaining lateness makes the route infeasible
+	TimeWindowsHard TimeWindowMode = "hard"
+	// TimeWindowsSoft trades lateness against distance using LatePenaltyPerMinute
+	TimeWindowsSoft TimeWindowMode = "soft"
+)
+
+const (
+	// DefaultLatePenaltyPerMinute is the soft window penalty, in kilometers per minute late
+	DefaultLatePenaltyPerMinute = 1.0
+
+	// hardLatePenaltyPerMinute dominates any realistic route distance so lateness is always minimised first
+	hardLatePenaltyPerMinute = 1e6
+)
+
+// IsValid checks if the time window mode is valid
+func (m TimeWindowMode) IsValid() bool {
+	switch m {
+	case TimeWindowsIgnore, TimeWindowsHard, TimeWindowsSoft:
+		return true
+	default:
+		return false
+	}
+}
+
+// StopSchedule holds the simulated timings for a single stop
+type StopSchedule struct {
+	StopID uint
+	// Arrival is when the technician reaches the stop
+	Arrival time.Time
+	// ServiceStart is when work begins, after waiting for the window to open
+	ServiceStart time.Time
+	// Departure is when the technician leaves the stop
+	Departure time.Time
+	// WaitSeconds is the time spent waiting for the window to open
+	WaitSeconds int
+	// LateSeconds is how long after the window closed service began
+	LateSeconds int
+}
+
+// IsLate reports whether the stop's time window is missed
+func (s StopSchedule) IsLate() bool {
+	return s.LateSeconds > 0
+}
+
+// buildSchedule simulates arrival, service and departure times along the path
+func buildSchedule(stops []Stop, matrix [][]float64, path []int, opts Options) []StopSchedule {
+	schedule := make([]StopSchedule, 0, len(path))
+	clock := opts.StartTime
+
+	for i, index := range path {
+		if i > 0 {
+			clock = clock.Add(travelDuration(matrix[path[i-1]][index], opts.AverageSpeedKmh))
+		}
+
+		stop := stops[index]
+		entry := StopSchedule{StopID: stop.ID, Arrival: clock, ServiceStart: clock}
+		if stop.WindowStart != nil && clock.Before(*stop.WindowStart) {
+			entry.ServiceStart = *stop.WindowStart
+			entry.WaitSeconds = int(entry.ServiceStart.Sub(clock).Seconds())
+		}
+		if stop.WindowEnd != nil && entry.ServiceStart.After(*stop.WindowEnd) {
+			entry.LateSeconds = int(math.Ceil(entry.ServiceStart.Sub(*stop.WindowEnd).Seconds()))
+		}
+
+		entry.Departure = entry.ServiceStart.Add(time.Duration(stop.ServiceMinutes) * time.Minute)
+		clock = entry.Departure
+		schedule = append(schedule, entry)
+	}
+
+	return schedule
+}
+
+// lateMinutes returns the total lateness along the path. It mirrors buildSchedule
+// without allocating so it can be evaluated for every candidate move.
+func lateMinutes(stops []Stop, matrix [][]float64, path []int, opts Options) float64 {
+	late := 0.0
+	clock := opts.StartTime
+
+	for i, index := range path {
+		if i > 0 {
+			clock = clock.Add(travelDuration(matrix[path[i-1]][index], opts.AverageSpeedKmh))
+		}
+
+		stop := stops[index]
+		if stop.WindowStart != nil && clock.Before(*stop.WindowStart) {
+			clock = *stop.WindowStart
+		}
+		if stop.WindowEnd != nil && clock.After(*stop.WindowEnd) {
+			late += clock.Sub(*stop.WindowEnd).Minutes()
+		}
+		clock = clock.Add(time.Duration(stop.ServiceMinutes) * time.Minute)
+	}
+
+	return late
+}
+
+// deadlineOrder seeds the search by visiting stops in order of window close time.
+// Stops without a deadline go last; pinned endpoints stay in place.
+func deadlineOrder(stops []Stop, opts Options) []int {
+	path := make([]int, len(stops))
+	for i := range path {
+		path[i] = i
+	}
+	if len(path) < 2 {
+		return path
+	}
+
+	lo, hi := movableRange(len(path), opts)
+	movable := path[lo : hi+1]
+	sort.SliceStable(movable, func(a, b int) bool {
+		endA, endB := stops[movable[a]].WindowEnd, stops[movable[b]].WindowEnd
+		if endA == nil || endB == nil {
+			return endA != nil
+		}
+		return endA.Before(*endB)
+	})
+	return path
+}
+
+// travelDuration converts a distance into driving time at the given speed
+func travelDuration(distanceKm, speedKmh float64) time.Duration {
+	return time.Duration(distanceKm / speedKmh * float64(time.Hour))
+}
@@ -0,0 +1,211 @@
+// Package repositories defines the data access interfaces used by the API handlers.
+//
+// The postgres package implements them with GORM and the memory package keeps records in
+// process so handler tests can run without a database. Repositories of tenant-scoped models
+// only see the organization carried by the context (see the tenant package) and fail with
+// tenant.ErrMissingTenant when the context has none.
+//
+// The repositories cover the users, organizations, routes, technicians and route activities.
+// Workflows writing several of them in one transaction, such as route lifecycle transitions and
+// stop completions with their proofs, and the authentication tables (sessions, one-time tokens,
+// second factors and lockouts) still go through GORM in the handlers.
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"routrapp-api/internal/models"
+)
+
+// ErrNotFound is returned when the requested record does not exist in the context's organization
+var ErrNotFound = errors.New("repositories: record not found")
+
+// ErrDuplicate is returned when a create conflicts with a record of the organization, such as a
+// user with the same email
+var ErrDuplicate = errors.New("repositories: duplicate record")
+
+// ErrStopAlreadyCompleted is returned when an update completes a stop that is already completed
+var ErrStopAlreadyCompleted = errors.New("repositories: stop is already completed")
+
+// Repositories bundles the repositories a handler may depend on
+type Repositories struct {
+	Users         UserRepository
+	Organizations OrganizationRepository
+	Routes        RouteRepository
+	Technicians   TechnicianRepository
+	Activities    ActivityRepository
+}
+
+// Page selects a page of a list; Page is 1-based
+type Page struct {
+	Page     int
+	PageSize int
+}
+
+// Offset returns the number of records before the page
+func (p Page) Offset() int {
+	if p.Page < 1 {
+		return 0
+	}
+	return (p.Page - 1) * p.PageSize
+}
+
+// UserRepository stores the users of an organization
+type UserRepository interface {
+	// FindByID returns the user with its role
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	// FindByEmail returns the user with the email, with its role
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	// Create stores the user; it fails with ErrDuplicate when the organization has a user with the email
+	Create(ctx context.Context, user *models.User) error
+	Update(ctx context.Context, id uint, update UserUpdate) error
+}
+
+// UserUpdate holds the user fields to change; nil fields are left untouched
+type UserUpdate struct {
+	FirstName *string
+	LastName  *string
+}
+
+// Empty reports whether the update changes nothing
+func (u UserUpdate) Empty() bool {
+	return u.FirstName == nil && u.LastName == nil
+}
+
+// OrganizationRepository stores organizations; organizations are not tenant-scoped
+type OrganizationRepository interface {
+	FindByID(ctx context.Context, id uint) (*models.Organization, error)
+	FindBySubDomain(ctx context.Context, subDomain string) (*models.Organization, error)
+	Create(ctx context.Context, organization *models.Organization) error
+}
+
+// TechnicianRepository stores the technician profiles of an organization
+type TechnicianRepository interface {
+	// List returns a page of the technicians matching the filter, with their user and role,
+	// and the total number of matches
+	List(ctx context.Context, filter TechnicianFilter, page Page) ([]models.Technician, int64, error)
+	// FindByID returns the technician with its user and role
+	FindByID(ctx context.Context, id uint) (*models.Technician, error)
+	// FindByUserID returns the technician profile of the user with its user and role
+	FindByUserID(ctx context.Context, userID uint) (*models.Technician, error)
+	// ExistsForUser reports whether the user has a technician profile, including deleted ones
+	ExistsForUser(ctx context.Context, userID uint) (bool, error)
+	Create(ctx context.Context, technician *models.Technician) error
+	Update(ctx context.Context, id uint, update TechnicianUpdate) error
+}
+
+// TechnicianFilter narrows a technician list. Search matches the user's name and email and the
+// phone number; SortBy is one of id, name, created_at and updated_at, and the list is sorted
+// newest first when it is empty.
+type TechnicianFilter struct {
+	Statuses []models.TechnicianStatus
+	Active   *bool
+	Search   string
+	SortBy   string
+	SortDesc bool
+}
+
+// TechnicianUpdate holds the technician fields to change; nil fields are left untouched
+type TechnicianUpdate struct {
+	Status      *models.TechnicianStatus
+	PhoneNumber *string
+	Notes       *string
+}
+
+// Empty reports whether the update changes nothing
+func (u TechnicianUpdate) Empty() bool {
+	return u.Status == nil && u.PhoneNumber == nil && u.Notes == nil
+}
+
+// RouteRepository stores the routes of an organization
+type RouteRepository interface {
+	// List returns a page of the routes matching the filter, with their technician's user but
+	// without stops, and the total number of matches
+	List(ctx context.Context, filter RouteFilter, page Page) ([]models.Route, int64, error)
+	// FindByID returns the route with its stops in sequence order and its technician's user
+	FindByID(ctx context.Context, id uint) (*models.Route, error)
+	// CountByTechnician counts the technician's routes in any of the statuses
+	CountByTechnician(ctx context.Context, technicianID uint, statuses ...models.RouteStatus) (int64, error)
+	// CountStops returns the number of stops of each of the routes
+	CountStops(ctx context.Context, routeIDs []uint) (map[uint]int, error)
+	// Create stores the route together with its stops
+	Create(ctx context.Context, route *models.Route) error
+	// Update changes the route and its stops atomically. It fails with ErrNotFound when a stop
+	// is not on the route and with ErrStopAlreadyCompleted when a stop it completes already is.
+	Update(ctx context.Context, id uint, update RouteUpdate) error
+	// Delete removes the route together with its stops
+	Delete(ctx context.Context, id uint) error
+}
+
+// RouteFilter narrows a route list; zero fields match every route. Search matches the route's
+// name; SortBy is one of id, name, created_at and updated_at, and the list is sorted newest first
+// when it is empty.
+type RouteFilter struct {
+	Statuses     []models.RouteStatus
+	TechnicianID *uint
+	DateFrom     *time.Time
+	DateTo       *time.Time
+	Search       string
+	SortBy       string
+	SortDesc     bool
+}
+
+// RouteUpdate holds the route fields to change; nil fields are left untouched
+type RouteUpdate struct {
+	Name        *string
+	Description *string
+	// TechnicianID assigns the route; UnassignTechnician clears it when TechnicianID is nil
+	TechnicianID       *uint
+	UnassignTechnician bool
+	Status             *models.RouteStatus
+	ScheduledDate      *time.Time
+	Notes              *string
+	Stops              []RouteStopUpdate
+	// Optimization stores an optimized stop sequence, applied after the stop updates
+	Optimization *RouteOptimization
+}
+
+// RouteOptimization holds an optimized stop sequence and the route totals it yields; storing it
+// marks the route as optimized
+type RouteOptimization struct {
+	// Sequence maps the IDs of the route's stops to their new sequence numbers
+	Sequence      map[uint]int
+	TotalDistance float64
+	TotalDuration int
+}
+
+// RouteStopUpdate holds the fields to change on a stop of the route; nil fields are left untouched.
+// TimeWindow replaces both times of the stop's window, and setting SequenceNum moves the stop and
+// marks the route as no longer optimized.
+type RouteStopUpdate struct {
+	ID          uint
+	Name        *string
+	Address     *string
+	Lat         *float64
+	Lng         *float64
+	StopType    *string
+	Duration    *int
+	Notes       *string
+	TimeWindow  *models.TimeWindow
+	SequenceNum *int
+	IsCompleted *bool
+}
+
+// ActivityRepository stores the route activity timeline of an organization
+type ActivityRepository interface {
+	// List returns a chronological page of the activities matching the filter and the total number of matches
+	List(ctx context.Context, filter ActivityFilter, page Page) ([]models.RouteActivity, int64, error)
+	Create(ctx context.Context, activity *models.RouteActivity) error
+}
+
+// ActivityFilter narrows an activity timeline; zero fields match every activity
+type ActivityFilter struct {
+	RouteID      *uint
+	RouteStopID  *uint
+	TechnicianID *uint
+	Types        []string
+	From         *time.Time
+	To           *time.Time
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+)
+
+// ActivityRepository implements repositories.ActivityRepository in memory
+type ActivityRepository struct {
+	store *Store
+}
+
+// List implements repositories.ActivityRepository
+func (r *ActivityRepository) List(ctx context.Context, filter repositories.ActivityFilter, page repositories.Page) ([]models.RouteActivity, int64, error) {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	var activities []models.RouteActivity
+	for _, activity := range r.store.activities {
+		if scope.sees(activity.Base) && matchesActivityFilter(activity, filter) {
+			activities = append(activities, activity)
+		}
+	}
+
+	sort.Slice(activities, func(i, j int) bool {
+		if !activities[i].Timestamp.Equal(activities[j].Timestamp) {
+			return activities[i].Timestamp.Before(activities[j].Timestamp)
+		}
+		return activities[i].ID < activities[j].ID
+	})
+	return paginate(activities, page), int64(len(activities)), nil
+}
+
+// Create implements repositories.ActivityRepository
+func (r *ActivityRepository) Create(ctx context.Context, activity *models.RouteActivity) error {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if err := scope.assign(&activity.Base, r.store.id(), time.Now()); err != nil {
+		return err
+	}
+	r.store.activities[activity.ID] = *activity
+	return nil
+}
+
+// matchesActivityFilter applies the filter the way the SQL implementation does
+func matchesActivityFilter(activity models.RouteActivity, filter repositories.ActivityFilter) bool {
+	if filter.RouteID != nil && activity.RouteID != *filter.RouteID {
+		return false
+	}
+	if filter.RouteStopID != nil && (activity.RouteStopID == nil || *activity.RouteStopID != *filter.RouteStopID) {
+		return false
+	}
+	if filter.TechnicianID != nil && activity.TechnicianID != *filter.TechnicianID {
+		return false
+	}
+	if len(filter.Types) > 0 {
+		matched := false
+		for _, activityType := range filter.Types {
+			matched = matched || activity.ActivityType == activityType
+		}
+		if !matched {
+			return false
+		}
+	}
+	if filter.From != nil && activity.Timestamp.Before(*filter.From) {
+		return false
+	}
+	if filter.To != nil && activity.Timestamp.After(*filter.To) {
+		return false
+	}
+	return true
+}
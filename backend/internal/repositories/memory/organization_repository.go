@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+)
+
+// OrganizationRepository implements repositories.OrganizationRepository in memory
+type OrganizationRepository struct {
+	store *Store
+}
+
+// FindByID implements repositories.OrganizationRepository
+func (r *OrganizationRepository) FindByID(ctx context.Context, id uint) (*models.Organization, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	organization, ok := r.store.organizations[id]
+	if !ok || organization.DeletedAt.Valid {
+		return nil, repositories.ErrNotFound
+	}
+	return &organization, nil
+}
+
+// FindBySubDomain implements repositories.OrganizationRepository
+func (r *OrganizationRepository) FindBySubDomain(ctx context.Context, subDomain string) (*models.Organization, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	for _, organization := range r.store.organizations {
+		if organization.SubDomain == subDomain && !organization.DeletedAt.Valid {
+			return &organization, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+// Create implements repositories.OrganizationRepository
+func (r *OrganizationRepository) Create(ctx context.Context, organization *models.Organization) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	now := time.Now()
+	organization.ID = r.store.id()
+	organization.CreatedAt = now
+	organization.UpdatedAt = now
+	r.store.organizations[organization.ID] = *organization
+	return nil
+}
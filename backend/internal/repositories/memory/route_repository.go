@@ -0,0 +1,355 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+)
+
+// RouteRepository implements repositories.RouteRepository in memory
+type RouteRepository struct {
+	store *Store
+}
+
+// List implements repositories.RouteRepository
+func (r *RouteRepository) List(ctx context.Context, filter repositories.RouteFilter, page repositories.Page) ([]models.Route, int64, error) {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	var routes []models.Route
+	for _, route := range r.store.routes {
+		if !scope.sees(route.Base) || !matchesRouteFilter(route, filter) {
+			continue
+		}
+		route.Stops = nil
+		route.Technician = r.store.routeTechnician(scope, route)
+		routes = append(routes, route)
+	}
+
+	sortRoutes(routes, filter)
+	return paginate(routes, page), int64(len(routes)), nil
+}
+
+// FindByID implements repositories.RouteRepository
+func (r *RouteRepository) FindByID(ctx context.Context, id uint) (*models.Route, error) {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	route, ok := r.store.routes[id]
+	if !ok || !scope.sees(route.Base) {
+		return nil, repositories.ErrNotFound
+	}
+
+	route.Stops = append([]models.RouteStop(nil), route.Stops...)
+	sort.SliceStable(route.Stops, func(i, j int) bool {
+		return route.Stops[i].SequenceNum < route.Stops[j].SequenceNum
+	})
+	route.Technician = r.store.routeTechnician(scope, route)
+	return &route, nil
+}
+
+// CountByTechnician implements repositories.RouteRepository
+func (r *RouteRepository) CountByTechnician(ctx context.Context, technicianID uint, statuses ...models.RouteStatus) (int64, error) {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	var count int64
+	for _, route := range r.store.routes {
+		if !scope.sees(route.Base) || route.TechnicianID == nil || *route.TechnicianID != technicianID {
+			continue
+		}
+		if len(statuses) == 0 {
+			count++
+			continue
+		}
+		for _, status := range statuses {
+			if route.Status == status {
+				count++
+				break
+			}
+		}
+	}
+	return count, nil
+}
+
+// CountStops implements repositories.RouteRepository
+func (r *RouteRepository) CountStops(ctx context.Context, routeIDs []uint) (map[uint]int, error) {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	counts := make(map[uint]int, len(routeIDs))
+	for _, id := range routeIDs {
+		route, ok := r.store.routes[id]
+		if !ok || !scope.sees(route.Base) {
+			continue
+		}
+		counts[id] = len(route.Stops)
+	}
+	return counts, nil
+}
+
+// Create implements repositories.RouteRepository
+func (r *RouteRepository) Create(ctx context.Context, route *models.Route) error {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	now := time.Now()
+	if err := scope.assign(&route.Base, r.store.id(), now); err != nil {
+		return err
+	}
+	if route.Status == "" {
+		route.Status = models.RouteStatusPending
+	}
+	for i := range route.Stops {
+		stopScope := scope
+		if stopScope.unscoped {
+			// Stops belong to the route's organization like the route itself
+			stopScope = tenantScope{organizationID: route.OrganizationID}
+		}
+		if err := stopScope.assign(&route.Stops[i].Base, r.store.id(), now); err != nil {
+			return err
+		}
+		route.Stops[i].RouteID = route.ID
+	}
+
+	stored := *route
+	stored.Technician = nil
+	stored.Stops = append([]models.RouteStop(nil), route.Stops...)
+	r.store.routes[route.ID] = stored
+	return nil
+}
+
+// Update implements repositories.RouteRepository
+func (r *RouteRepository) Update(ctx context.Context, id uint, update repositories.RouteUpdate) error {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	route, ok := r.store.routes[id]
+	if !ok || !scope.sees(route.Base) {
+		return repositories.ErrNotFound
+	}
+
+	// Changes are made on copies and only stored once every stop update succeeded
+	now := time.Now()
+	route.Stops = append([]models.RouteStop(nil), route.Stops...)
+	positions := make(map[uint]int, len(route.Stops))
+	for i, stop := range route.Stops {
+		positions[stop.ID] = i
+	}
+	for _, stopUpdate := range update.Stops {
+		i, ok := positions[stopUpdate.ID]
+		if !ok {
+			return repositories.ErrNotFound
+		}
+		if err := applyStopUpdate(&route.Stops[i], stopUpdate, now); err != nil {
+			return err
+		}
+		if stopUpdate.SequenceNum != nil {
+			// Manual reordering invalidates any previously computed optimization
+			route.IsOptimized = false
+		}
+	}
+	if update.Optimization != nil {
+		for stopID, sequenceNum := range update.Optimization.Sequence {
+			i, ok := positions[stopID]
+			if !ok {
+				return repositories.ErrNotFound
+			}
+			route.Stops[i].SequenceNum = sequenceNum
+			route.Stops[i].UpdatedAt = now
+		}
+		route.IsOptimized = true
+		route.TotalDistance = update.Optimization.TotalDistance
+		route.TotalDuration = update.Optimization.TotalDuration
+	}
+
+	if update.Name != nil {
+		route.Name = *update.Name
+	}
+	if update.Description != nil {
+		route.Description = *update.Description
+	}
+	if update.TechnicianID != nil {
+		technicianID := *update.TechnicianID
+		route.TechnicianID = &technicianID
+	} else if update.UnassignTechnician {
+		route.TechnicianID = nil
+	}
+	if update.Status != nil {
+		route.Status = *update.Status
+	}
+	if update.ScheduledDate != nil {
+		scheduledDate := *update.ScheduledDate
+		route.ScheduledDate = &scheduledDate
+	}
+	if update.Notes != nil {
+		route.Notes = *update.Notes
+	}
+	route.UpdatedAt = now
+	r.store.routes[id] = route
+	return nil
+}
+
+// Delete implements repositories.RouteRepository
+func (r *RouteRepository) Delete(ctx context.Context, id uint) error {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	route, ok := r.store.routes[id]
+	if !ok || !scope.sees(route.Base) {
+		return repositories.ErrNotFound
+	}
+
+	// Soft-deleted like the GORM models; the stops are stored with the route and go with it
+	route.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.store.routes[id] = route
+	return nil
+}
+
+// routeTechnician returns the route's technician with its user and role; callers must hold the lock
+func (s *Store) routeTechnician(scope tenantScope, route models.Route) *models.Technician {
+	if route.TechnicianID == nil {
+		return nil
+	}
+	technician, ok := s.technicians[*route.TechnicianID]
+	if !ok || !scope.sees(technician.Base) {
+		return nil
+	}
+	technician = s.technicianWithUser(technician)
+	return &technician
+}
+
+// applyStopUpdate changes the stop the way the SQL implementation does
+func applyStopUpdate(stop *models.RouteStop, update repositories.RouteStopUpdate, now time.Time) error {
+	if update.IsCompleted != nil {
+		if *update.IsCompleted && stop.IsCompleted {
+			return repositories.ErrStopAlreadyCompleted
+		}
+		stop.IsCompleted = *update.IsCompleted
+		stop.CompletedAt = nil
+		if stop.IsCompleted {
+			completedAt := now
+			stop.CompletedAt = &completedAt
+		}
+	}
+	if update.Name != nil {
+		stop.Name = *update.Name
+	}
+	if update.Address != nil {
+		stop.Address = *update.Address
+	}
+	if update.Lat != nil {
+		stop.Lat = *update.Lat
+	}
+	if update.Lng != nil {
+		stop.Lng = *update.Lng
+	}
+	if update.StopType != nil {
+		stop.StopType = *update.StopType
+	}
+	if update.Duration != nil {
+		stop.Duration = *update.Duration
+	}
+	if update.Notes != nil {
+		stop.Notes = *update.Notes
+	}
+	if update.TimeWindow != nil {
+		timeWindow := *update.TimeWindow
+		stop.TimeWindow = &timeWindow
+	}
+	if update.SequenceNum != nil {
+		stop.SequenceNum = *update.SequenceNum
+	}
+	stop.UpdatedAt = now
+	return nil
+}
+
+// matchesRouteFilter applies the filter the way the SQL implementation does
+func matchesRouteFilter(route models.Route, filter repositories.RouteFilter) bool {
+	if len(filter.Statuses) > 0 {
+		matched := false
+		for _, status := range filter.Statuses {
+			matched = matched || route.Status == status
+		}
+		if !matched {
+			return false
+		}
+	}
+	if filter.TechnicianID != nil && (route.TechnicianID == nil || *route.TechnicianID != *filter.TechnicianID) {
+		return false
+	}
+	if filter.DateFrom != nil && (route.ScheduledDate == nil || route.ScheduledDate.Before(*filter.DateFrom)) {
+		return false
+	}
+	if filter.DateTo != nil && (route.ScheduledDate == nil || route.ScheduledDate.After(*filter.DateTo)) {
+		return false
+	}
+	if filter.Search != "" && !strings.Contains(strings.ToLower(route.Name), strings.ToLower(filter.Search)) {
+		return false
+	}
+	return true
+}
+
+// sortRoutes orders the routes by the filter's sort column, breaking ties by ID
+func sortRoutes(routes []models.Route, filter repositories.RouteFilter) {
+	less := func(a, b models.Route) bool {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	switch filter.SortBy {
+	case "id":
+		less = func(a, b models.Route) bool { return false }
+	case "name":
+		less = func(a, b models.Route) bool { return a.Name < b.Name }
+	case "updated_at":
+		less = func(a, b models.Route) bool { return a.UpdatedAt.Before(b.UpdatedAt) }
+	}
+	descending := filter.SortDesc || filter.SortBy == ""
+
+	sort.Slice(routes, func(i, j int) bool {
+		a, b := routes[i], routes[j]
+		if descending {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return a.ID < b.ID
+	})
+}
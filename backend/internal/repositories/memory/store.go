@@ -0,0 +1,116 @@
+// Package memory implements the repositories interfaces in process, for tests that should not
+// need a database. It mirrors the tenant plugin: records of tenant-scoped models are only visible
+// to the organization on the context, creates are assigned to it, and contexts without an
+// organization fail with tenant.ErrMissingTenant unless they were created by tenant.WithoutScope.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+	"routrapp-api/internal/tenant"
+)
+
+// Store holds the records shared by the repositories created from it, so that for example
+// technicians are returned with the users created through the user repository
+type Store struct {
+	mu     sync.RWMutex
+	nextID uint
+
+	organizations map[uint]models.Organization
+	roles         map[uint]models.Role
+	users         map[uint]models.User
+	technicians   map[uint]models.Technician
+	routes        map[uint]models.Route
+	activities    map[uint]models.RouteActivity
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{
+		organizations: make(map[uint]models.Organization),
+		roles:         make(map[uint]models.Role),
+		users:         make(map[uint]models.User),
+		technicians:   make(map[uint]models.Technician),
+		routes:        make(map[uint]models.Route),
+		activities:    make(map[uint]models.RouteActivity),
+	}
+}
+
+// NewRepositories creates the in-memory repositories over a new, empty store
+func NewRepositories() repositories.Repositories {
+	return NewStore().Repositories()
+}
+
+// Repositories returns the in-memory repositories backed by the store
+func (s *Store) Repositories() repositories.Repositories {
+	return repositories.Repositories{
+		Users:         &UserRepository{store: s},
+		Organizations: &OrganizationRepository{store: s},
+		Routes:        &RouteRepository{store: s},
+		Technicians:   &TechnicianRepository{store: s},
+		Activities:    &ActivityRepository{store: s},
+	}
+}
+
+// id returns the next record ID; callers must hold the write lock
+func (s *Store) id() uint {
+	s.nextID++
+	return s.nextID
+}
+
+// tenantScope is the organization a repository call is scoped to
+type tenantScope struct {
+	organizationID uint
+	unscoped       bool
+}
+
+// scopeOf returns the scope of the context, failing closed like the tenant plugin
+func scopeOf(ctx context.Context) (tenantScope, error) {
+	if organizationID, ok := tenant.OrganizationFromContext(ctx); ok {
+		return tenantScope{organizationID: organizationID}, nil
+	}
+	if tenant.IsUnscoped(ctx) {
+		return tenantScope{unscoped: true}, nil
+	}
+	return tenantScope{}, tenant.ErrMissingTenant
+}
+
+// sees reports whether a live record of the organization is visible in the scope
+func (sc tenantScope) sees(base models.Base) bool {
+	return !base.DeletedAt.Valid && (sc.unscoped || base.OrganizationID == sc.organizationID)
+}
+
+// assign prepares the base of a record being created in the scope
+func (sc tenantScope) assign(base *models.Base, id uint, now time.Time) error {
+	if !sc.unscoped {
+		if base.OrganizationID == 0 {
+			base.OrganizationID = sc.organizationID
+		} else if base.OrganizationID != sc.organizationID {
+			return tenant.ErrTenantMismatch
+		}
+	}
+	base.ID = id
+	base.CreatedAt = now
+	base.UpdatedAt = now
+	return nil
+}
+
+// userWithRole returns the stored user with its role; callers must hold the lock
+func (s *Store) userWithRole(id uint) (models.User, bool) {
+	user, ok := s.users[id]
+	if !ok {
+		return models.User{}, false
+	}
+	user.Role = s.roles[user.RoleID]
+	return user, true
+}
+
+// technicianWithUser returns the technician with its user and role; callers must hold the lock
+func (s *Store) technicianWithUser(technician models.Technician) models.Technician {
+	technician.User, _ = s.userWithRole(technician.UserID)
+	return technician
+}
@@ -0,0 +1,217 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+)
+
+// TechnicianRepository implements repositories.TechnicianRepository in memory
+type TechnicianRepository struct {
+	store *Store
+}
+
+// List implements repositories.TechnicianRepository
+func (r *TechnicianRepository) List(ctx context.Context, filter repositories.TechnicianFilter, page repositories.Page) ([]models.Technician, int64, error) {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	var technicians []models.Technician
+	for _, stored := range r.store.technicians {
+		if !scope.sees(stored.Base) {
+			continue
+		}
+		technician := r.store.technicianWithUser(stored)
+		if technician.User.ID == 0 || technician.User.DeletedAt.Valid || !matchesTechnicianFilter(technician, filter) {
+			continue
+		}
+		technicians = append(technicians, technician)
+	}
+
+	sortTechnicians(technicians, filter)
+	return paginate(technicians, page), int64(len(technicians)), nil
+}
+
+// FindByID implements repositories.TechnicianRepository
+func (r *TechnicianRepository) FindByID(ctx context.Context, id uint) (*models.Technician, error) {
+	return r.find(ctx, func(technician models.Technician) bool {
+		return technician.ID == id
+	})
+}
+
+// FindByUserID implements repositories.TechnicianRepository
+func (r *TechnicianRepository) FindByUserID(ctx context.Context, userID uint) (*models.Technician, error) {
+	return r.find(ctx, func(technician models.Technician) bool {
+		return technician.UserID == userID
+	})
+}
+
+// ExistsForUser implements repositories.TechnicianRepository
+func (r *TechnicianRepository) ExistsForUser(ctx context.Context, userID uint) (bool, error) {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	for _, technician := range r.store.technicians {
+		// Deleted profiles count, as user_id is unique across all rows
+		if technician.UserID == userID && (scope.unscoped || technician.OrganizationID == scope.organizationID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Create implements repositories.TechnicianRepository
+func (r *TechnicianRepository) Create(ctx context.Context, technician *models.Technician) error {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if err := scope.assign(&technician.Base, r.store.id(), time.Now()); err != nil {
+		return err
+	}
+	if technician.Status == "" {
+		technician.Status = models.TechnicianStatusInactive
+	}
+
+	stored := *technician
+	stored.User = models.User{}
+	r.store.technicians[technician.ID] = stored
+	return nil
+}
+
+// Update implements repositories.TechnicianRepository
+func (r *TechnicianRepository) Update(ctx context.Context, id uint, update repositories.TechnicianUpdate) error {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return err
+	}
+	if update.Empty() {
+		return nil
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	technician, ok := r.store.technicians[id]
+	if !ok || !scope.sees(technician.Base) {
+		return repositories.ErrNotFound
+	}
+	if update.Status != nil {
+		technician.Status = *update.Status
+	}
+	if update.PhoneNumber != nil {
+		technician.PhoneNumber = *update.PhoneNumber
+	}
+	if update.Notes != nil {
+		technician.Notes = *update.Notes
+	}
+	technician.UpdatedAt = time.Now()
+	r.store.technicians[id] = technician
+	return nil
+}
+
+// find returns the technician with the lowest ID that matches, with its user and role
+func (r *TechnicianRepository) find(ctx context.Context, matches func(models.Technician) bool) (*models.Technician, error) {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	var found *models.Technician
+	for _, stored := range r.store.technicians {
+		if !scope.sees(stored.Base) || !matches(stored) || (found != nil && found.ID < stored.ID) {
+			continue
+		}
+		technician := r.store.technicianWithUser(stored)
+		found = &technician
+	}
+	if found == nil {
+		return nil, repositories.ErrNotFound
+	}
+	return found, nil
+}
+
+// matchesTechnicianFilter applies the filter the way the SQL implementation does
+func matchesTechnicianFilter(technician models.Technician, filter repositories.TechnicianFilter) bool {
+	if len(filter.Statuses) > 0 {
+		matched := false
+		for _, status := range filter.Statuses {
+			matched = matched || technician.Status == status
+		}
+		if !matched {
+			return false
+		}
+	}
+	if filter.Active != nil && technician.User.Active != *filter.Active {
+		return false
+	}
+	if filter.Search != "" {
+		search := strings.ToLower(filter.Search)
+		if !strings.Contains(strings.ToLower(technician.User.FirstName), search) &&
+			!strings.Contains(strings.ToLower(technician.User.LastName), search) &&
+			!strings.Contains(strings.ToLower(technician.User.Email), search) &&
+			!strings.Contains(technician.PhoneNumber, search) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortTechnicians orders the technicians by the filter's sort column, breaking ties by ID
+func sortTechnicians(technicians []models.Technician, filter repositories.TechnicianFilter) {
+	less := func(a, b models.Technician) bool {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	switch filter.SortBy {
+	case "id":
+		less = func(a, b models.Technician) bool { return false }
+	case "name":
+		less = func(a, b models.Technician) bool { return a.User.FirstName < b.User.FirstName }
+	case "updated_at":
+		less = func(a, b models.Technician) bool { return a.UpdatedAt.Before(b.UpdatedAt) }
+	}
+	descending := filter.SortDesc || filter.SortBy == ""
+
+	sort.Slice(technicians, func(i, j int) bool {
+		a, b := technicians[i], technicians[j]
+		if descending {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return a.ID < b.ID
+	})
+}
+
+// paginate returns the page of the records; a page size of zero returns every record
+func paginate[T any](records []T, page repositories.Page) []T {
+	offset := page.Offset()
+	if offset >= len(records) {
+		return []T{}
+	}
+	records = records[offset:]
+	if page.PageSize > 0 && page.PageSize < len(records) {
+		records = records[:page.PageSize]
+	}
+	return records
+}
@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+)
+
+// UserRepository implements repositories.UserRepository in memory
+type UserRepository struct {
+	store *Store
+}
+
+// FindByID implements repositories.UserRepository
+func (r *UserRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	user, ok := r.store.userWithRole(id)
+	if !ok || !scope.sees(user.Base) {
+		return nil, repositories.ErrNotFound
+	}
+	return &user, nil
+}
+
+// FindByEmail implements repositories.UserRepository
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	var found *models.User
+	for id, stored := range r.store.users {
+		if stored.Email != email || !scope.sees(stored.Base) || (found != nil && found.ID < id) {
+			continue
+		}
+		user, _ := r.store.userWithRole(id)
+		found = &user
+	}
+	if found == nil {
+		return nil, repositories.ErrNotFound
+	}
+	return found, nil
+}
+
+// Create implements repositories.UserRepository.
+// A role set on the user is stored with it, and given an ID if it has none, so later lookups
+// return the user with its role.
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	organizationID := user.OrganizationID
+	if organizationID == 0 {
+		organizationID = scope.organizationID
+	}
+	for _, stored := range r.store.users {
+		// Mirrors the unique (organization_id, email) index on live users
+		if stored.Email == user.Email && stored.OrganizationID == organizationID && !stored.DeletedAt.Valid {
+			return repositories.ErrDuplicate
+		}
+	}
+
+	now := time.Now()
+	if err := scope.assign(&user.Base, r.store.id(), now); err != nil {
+		return err
+	}
+	if user.Role.ID == 0 && user.Role.Name != "" {
+		user.Role.Base = models.Base{OrganizationID: user.OrganizationID}
+		if err := scope.assign(&user.Role.Base, r.store.id(), now); err != nil {
+			return err
+		}
+	}
+	if user.Role.ID != 0 {
+		r.store.roles[user.Role.ID] = user.Role
+		user.RoleID = user.Role.ID
+	}
+
+	stored := *user
+	stored.Role = models.Role{}
+	stored.Technician = nil
+	r.store.users[user.ID] = stored
+	return nil
+}
+
+// Update implements repositories.UserRepository
+func (r *UserRepository) Update(ctx context.Context, id uint, update repositories.UserUpdate) error {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return err
+	}
+	if update.Empty() {
+		return nil
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	user, ok := r.store.users[id]
+	if !ok || !scope.sees(user.Base) {
+		return repositories.ErrNotFound
+	}
+	if update.FirstName != nil {
+		user.FirstName = *update.FirstName
+	}
+	if update.LastName != nil {
+		user.LastName = *update.LastName
+	}
+	user.UpdatedAt = time.Now()
+	r.store.users[id] = user
+	return nil
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+)
+
+// ActivityRepository implements repositories.ActivityRepository with GORM
+type ActivityRepository struct {
+	db *gorm.DB
+}
+
+// NewActivityRepository creates a route activity repository backed by db
+func NewActivityRepository(db *gorm.DB) *ActivityRepository {
+	return &ActivityRepository{
+		db: db,
+	}
+}
+
+// List implements repositories.ActivityRepository
+func (r *ActivityRepository) List(ctx context.Context, filter repositories.ActivityFilter, page repositories.Page) ([]models.RouteActivity, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.RouteActivity{})
+	if filter.RouteID != nil {
+		query = query.Where("route_id = ?", *filter.RouteID)
+	}
+	if filter.RouteStopID != nil {
+		query = query.Where("route_stop_id = ?", *filter.RouteStopID)
+	}
+	if filter.TechnicianID != nil {
+		query = query.Where("technician_id = ?", *filter.TechnicianID)
+	}
+	if len(filter.Types) > 0 {
+		query = query.Where("activity_type IN ?", filter.Types)
+	}
+	if filter.From != nil {
+		query = query.Where("timestamp >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("timestamp <= ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var activities []models.RouteActivity
+	if err := query.
+		Order("timestamp ASC, id ASC").
+		Offset(page.Offset()).
+		Limit(page.PageSize).
+		Find(&activities).Error; err != nil {
+		return nil, 0, err
+	}
+	return activities, total, nil
+}
+
+// Create implements repositories.ActivityRepository
+func (r *ActivityRepository) Create(ctx context.Context, activity *models.RouteActivity) error {
+	return r.db.WithContext(ctx).Create(activity).Error
+}
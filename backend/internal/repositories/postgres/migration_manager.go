@@ -1,6 +1,8 @@
 package postgres
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -19,11 +21,26 @@ type Migration struct {
 	Timestamp time.Time
 }
 
+// Checksum returns the SHA-256 of the up SQL, recorded when the migration is applied so that
+// later edits to the file can be detected
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.UpSQL))
+	return hex.EncodeToString(sum[:])
+}
+
+// MigrationStatus describes a migration and whether it has been applied
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+	Modified  bool // the up SQL changed since the migration was applied
+	Missing   bool // the migration was applied but its files no longer exist
+}
+
 // MigrationManager handles database migrations
 type MigrationManager struct {
 	migrationsPath string
-	// db will be added when database connection is implemented
-	// db *sql.DB
+	tracker        MigrationTracker
 }
 
 // NewMigrationManager creates a new migration manager
@@ -33,6 +50,14 @@ func NewMigrationManager(migrationsPath string) *MigrationManager {
 	}
 }
 
+// NewMigrationManagerWithTracker creates a migration manager that reports applied migrations from tracker
+func NewMigrationManagerWithTracker(migrationsPath string, tracker MigrationTracker) *MigrationManager {
+	return &MigrationManager{
+		migrationsPath: migrationsPath,
+		tracker:        tracker,
+	}
+}
+
 // LoadMigrations loads all migration files from the migrations directory
 func (mm *MigrationManager) LoadMigrations() ([]Migration, error) {
 	var migrations []Migration
@@ -92,6 +117,23 @@ func (mm *MigrationManager) LoadMigrations() ([]Migration, error) {
 
 // CreateMigration creates a new migration file pair (up and down)
 func (mm *MigrationManager) CreateMigration(name string) (string, string, error) {
+	upSQL := `-- Add your SQL statements here to apply this migration
+-- Example:
+-- CREATE TABLE example_table (
+--     id SERIAL PRIMARY KEY,
+--     name VARCHAR(255) NOT NULL,
+--     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+-- );
+`
+	downSQL := `-- Add your SQL statements here to rollback this migration
+-- Example:
+-- DROP TABLE IF EXISTS example_table;
+`
+	return mm.CreateMigrationWithSQL(name, upSQL, downSQL)
+}
+
+// CreateMigrationWithSQL creates a new migration file pair with the given up and down SQL
+func (mm *MigrationManager) CreateMigrationWithSQL(name, upSQL, downSQL string) (string, string, error) {
 	// Get next version number
 	migrations, err := mm.LoadMigrations()
 	if err != nil {
@@ -110,6 +152,7 @@ func (mm *MigrationManager) CreateMigration(name string) (string, string, error)
 	
 	upPath := filepath.Join(mm.migrationsPath, upFilename)
 	downPath := filepath.Join(mm.migrationsPath, downFilename)
+	created := time.Now().Format("2006-01-02 15:04:05")
 	
 	// Create up migration file
 	upContent := fmt.Sprintf(`-- Migration: %s
@@ -117,14 +160,7 @@ func (mm *MigrationManager) CreateMigration(name string) (string, string, error)
 -- Created: %s
 -- Direction: UP
 
--- Add your SQL statements here to apply this migration
--- Example:
--- CREATE TABLE example_table (
---     id SERIAL PRIMARY KEY,
---     name VARCHAR(255) NOT NULL,
---     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
--- );
-`, name, nextVersion, time.Now().Format("2006-01-02 15:04:05"))
+%s`, name, nextVersion, created, upSQL)
 	
 	if err := os.WriteFile(upPath, []byte(upContent), 0644); err != nil {
 		return "", "", fmt.Errorf("failed to create up migration file: %w", err)
@@ -136,10 +172,7 @@ func (mm *MigrationManager) CreateMigration(name string) (string, string, error)
 -- Created: %s
 -- Direction: DOWN
 
--- Add your SQL statements here to rollback this migration
--- Example:
--- DROP TABLE IF EXISTS example_table;
-`, name, nextVersion, time.Now().Format("2006-01-02 15:04:05"))
+%s`, name, nextVersion, created, downSQL)
 	
 	if err := os.WriteFile(downPath, []byte(downContent), 0644); err != nil {
 		return "", "", fmt.Errorf("failed to create down migration file: %w", err)
@@ -167,19 +200,60 @@ func (mm *MigrationManager) ValidateMigrations() error {
 	return nil
 }
 
-// GetMigrationStatus returns the current state of migrations
-// This will be enhanced when database connection is added
-func (mm *MigrationManager) GetMigrationStatus() ([]Migration, error) {
+// GetMigrationStatus returns the migrations with the state recorded by the tracker.
+// Without a tracker every migration is reported as pending.
+func (mm *MigrationManager) GetMigrationStatus() ([]MigrationStatus, error) {
 	migrations, err := mm.LoadMigrations()
 	if err != nil {
 		return nil, err
 	}
-	
-	// TODO: When database connection is implemented, check which migrations 
-	// have been applied by querying the migrations table
-	// For now, just return all available migrations
-	
-	return migrations, nil
+
+	var applied []MigrationRecord
+	if mm.tracker != nil {
+		applied, err = mm.tracker.GetAppliedMigrations()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buildMigrationStatus(migrations, applied), nil
+}
+
+// buildMigrationStatus matches migrations to their applied records, ordered by version
+func buildMigrationStatus(migrations []Migration, applied []MigrationRecord) []MigrationStatus {
+	records := make(map[int]MigrationRecord, len(applied))
+	for _, record := range applied {
+		records[record.Version] = record
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Migration: migration}
+		if record, ok := records[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			// Migrations applied before checksums were recorded can't be verified
+			status.Modified = record.Checksum != "" && record.Checksum != migration.Checksum()
+			delete(records, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, record := range records {
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, MigrationStatus{
+			Migration: Migration{Version: record.Version, Name: record.Description},
+			Applied:   true,
+			AppliedAt: &appliedAt,
+			Missing:   true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses
 }
 
 // parseMigrationFilename parses migration filename and extracts version, name, and direction
@@ -0,0 +1,355 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+
+	"routrapp-api/internal/logger"
+)
+
+// migrationLockKey is the advisory lock held while migrating ("routrapp" in ASCII)
+const migrationLockKey int64 = 0x726f757472617070
+
+var (
+	// ErrChecksumMismatch is returned when an applied migration's up SQL has been edited
+	ErrChecksumMismatch = errors.New("migration was modified after it was applied")
+	// ErrMigrationNotFound is returned when a migration to run has no files
+	ErrMigrationNotFound = errors.New("migration not found")
+)
+
+// MigrationDirection is the direction a migration is run in
+type MigrationDirection string
+
+const (
+	MigrationUp   MigrationDirection = "up"
+	MigrationDown MigrationDirection = "down"
+)
+
+// MigrationStep is a migration run, or planned to run in dry-run mode, in one direction
+type MigrationStep struct {
+	Migration
+	Direction MigrationDirection
+}
+
+// MigrationRunner applies and rolls back the SQL migrations in a directory.
+// Each migration runs in its own transaction, and runs are serialized across processes with a
+// PostgreSQL advisory lock so that replicas starting together don't migrate concurrently.
+type MigrationRunner struct {
+	db      *gorm.DB
+	manager *MigrationManager
+	dryRun  bool
+}
+
+// NewMigrationRunner creates a runner for the migrations in migrationsPath
+func NewMigrationRunner(db *gorm.DB, migrationsPath string) *MigrationRunner {
+	return &MigrationRunner{
+		db:      db,
+		manager: NewMigrationManager(migrationsPath),
+	}
+}
+
+// SetDryRun makes the runner plan migrations without executing them or writing to the database
+func (r *MigrationRunner) SetDryRun(dryRun bool) {
+	r.dryRun = dryRun
+}
+
+// Manager returns the manager of the runner's migration files
+func (r *MigrationRunner) Manager() *MigrationManager {
+	return r.manager
+}
+
+// Status returns the migrations with their applied state
+func (r *MigrationRunner) Status(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := r.manager.LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := r.appliedMigrations(r.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return buildMigrationStatus(migrations, applied), nil
+}
+
+// Verify checks that no applied migration has been modified since it was applied
+func (r *MigrationRunner) Verify(ctx context.Context) error {
+	statuses, err := r.Status(ctx)
+	if err != nil {
+		return err
+	}
+	return verifyChecksums(statuses)
+}
+
+// Up applies all pending migrations in version order
+func (r *MigrationRunner) Up(ctx context.Context) ([]MigrationStep, error) {
+	return r.run(ctx, func(statuses []MigrationStatus) ([]MigrationStep, error) {
+		var steps []MigrationStep
+		for _, status := range statuses {
+			if !status.Applied {
+				step, err := upStep(status)
+				if err != nil {
+					return nil, err
+				}
+				steps = append(steps, step)
+			}
+		}
+		return steps, nil
+	})
+}
+
+// Down rolls back the n most recently applied migrations
+func (r *MigrationRunner) Down(ctx context.Context, n int) ([]MigrationStep, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("number of migrations to roll back must be positive, got %d", n)
+	}
+	return r.run(ctx, func(statuses []MigrationStatus) ([]MigrationStep, error) {
+		var steps []MigrationStep
+		for i := len(statuses) - 1; i >= 0 && len(steps) < n; i-- {
+			if statuses[i].Applied {
+				step, err := downStep(statuses[i])
+				if err != nil {
+					return nil, err
+				}
+				steps = append(steps, step)
+			}
+		}
+		return steps, nil
+	})
+}
+
+// To migrates up or down so that exactly the migrations up to version are applied
+func (r *MigrationRunner) To(ctx context.Context, version int) ([]MigrationStep, error) {
+	if version < 0 {
+		return nil, fmt.Errorf("invalid target version %d", version)
+	}
+	return r.run(ctx, func(statuses []MigrationStatus) ([]MigrationStep, error) {
+		if version > 0 && !hasVersion(statuses, version) {
+			return nil, fmt.Errorf("%w: version %d", ErrMigrationNotFound, version)
+		}
+
+		var steps []MigrationStep
+		for i := len(statuses) - 1; i >= 0; i-- {
+			if statuses[i].Applied && statuses[i].Version > version {
+				step, err := downStep(statuses[i])
+				if err != nil {
+					return nil, err
+				}
+				steps = append(steps, step)
+			}
+		}
+		for _, status := range statuses {
+			if !status.Applied && status.Version <= version {
+				step, err := upStep(status)
+				if err != nil {
+					return nil, err
+				}
+				steps = append(steps, step)
+			}
+		}
+		return steps, nil
+	})
+}
+
+// Baseline records the migrations up to version as applied without running them, for databases
+// whose schema was created before migrations were tracked. A version of 0 baselines all migrations.
+func (r *MigrationRunner) Baseline(ctx context.Context, version int) ([]MigrationStep, error) {
+	if version < 0 {
+		return nil, fmt.Errorf("invalid baseline version %d", version)
+	}
+
+	unlock, err := r.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	db := r.db.WithContext(ctx)
+	if !r.dryRun {
+		if err := NewPostgresMigrationTracker(db).InitializeTrackingTable(); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses, err := r.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if version > 0 && !hasVersion(statuses, version) {
+		return nil, fmt.Errorf("%w: version %d", ErrMigrationNotFound, version)
+	}
+
+	var steps []MigrationStep
+	for _, status := range statuses {
+		if !status.Applied && (version == 0 || status.Version <= version) {
+			steps = append(steps, MigrationStep{Migration: status.Migration, Direction: MigrationUp})
+		}
+	}
+	if r.dryRun {
+		for _, step := range steps {
+			logger.Infof("Dry run: would mark %03d_%s as applied", step.Version, step.Name)
+		}
+		return steps, nil
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		tracker := NewPostgresMigrationTracker(tx)
+		for _, step := range steps {
+			if err := tracker.MarkMigrationApplied(step.Version, step.Name, step.Checksum()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// run plans the steps from the current status and executes them while holding the migration lock.
+// Executed steps are returned even when a later step fails.
+func (r *MigrationRunner) run(ctx context.Context, plan func([]MigrationStatus) ([]MigrationStep, error)) ([]MigrationStep, error) {
+	unlock, err := r.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	db := r.db.WithContext(ctx)
+	if !r.dryRun {
+		if err := NewPostgresMigrationTracker(db).InitializeTrackingTable(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Load the status only once the lock is held, as another process may just have migrated
+	statuses, err := r.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyChecksums(statuses); err != nil {
+		return nil, err
+	}
+	steps, err := plan(statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.dryRun {
+		for _, step := range steps {
+			logger.Infof("Dry run: would migrate %s %03d_%s", step.Direction, step.Version, step.Name)
+		}
+		return steps, nil
+	}
+
+	var executed []MigrationStep
+	for _, step := range steps {
+		logger.Infof("Migrating %s %03d_%s", step.Direction, step.Version, step.Name)
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return executeStep(tx, step)
+		}); err != nil {
+			return executed, fmt.Errorf("failed to migrate %s %03d_%s: %w", step.Direction, step.Version, step.Name, err)
+		}
+		executed = append(executed, step)
+	}
+	return executed, nil
+}
+
+// executeStep runs the step's SQL and records the result in the same transaction
+func executeStep(tx *gorm.DB, step MigrationStep) error {
+	tracker := NewPostgresMigrationTracker(tx)
+	if step.Direction == MigrationUp {
+		if err := tx.Exec(step.UpSQL).Error; err != nil {
+			return err
+		}
+		return tracker.MarkMigrationApplied(step.Version, step.Name, step.Checksum())
+	}
+
+	if err := tx.Exec(step.DownSQL).Error; err != nil {
+		return err
+	}
+	// Rolling back the initial migration drops the tracking table itself
+	if !tracker.tableExists() {
+		return nil
+	}
+	return tracker.MarkMigrationRolledBack(step.Version)
+}
+
+// appliedMigrations returns the applied migrations, treating a missing tracking table as none applied
+func (r *MigrationRunner) appliedMigrations(db *gorm.DB) ([]MigrationRecord, error) {
+	tracker := NewPostgresMigrationTracker(db)
+	if !tracker.tableExists() {
+		return nil, nil
+	}
+	return tracker.GetAppliedMigrations()
+}
+
+// lock takes the session-level advisory lock on a dedicated connection and returns its release.
+// Dry runs don't write and other databases run single-process in tests, so neither takes the lock.
+func (r *MigrationRunner) lock(ctx context.Context) (func(), error) {
+	if r.dryRun || r.db.Dialector.Name() != "postgres" {
+		return func() {}, nil
+	}
+
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	return func() {
+		// Use a fresh context so the lock is released even when ctx was cancelled
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey); err != nil {
+			logger.Errorf("Failed to release migration lock: %v", err)
+		}
+		conn.Close()
+	}, nil
+}
+
+// verifyChecksums returns ErrChecksumMismatch for the first modified migration
+func verifyChecksums(statuses []MigrationStatus) error {
+	for _, status := range statuses {
+		if status.Modified {
+			return fmt.Errorf("%w: %03d_%s", ErrChecksumMismatch, status.Version, status.Name)
+		}
+	}
+	return nil
+}
+
+// upStep returns the step applying a pending migration, which requires its up SQL
+func upStep(status MigrationStatus) (MigrationStep, error) {
+	if status.UpSQL == "" {
+		return MigrationStep{}, fmt.Errorf("migration %03d_%s is missing up SQL", status.Version, status.Name)
+	}
+	return MigrationStep{Migration: status.Migration, Direction: MigrationUp}, nil
+}
+
+// downStep returns the step rolling back an applied migration, which requires its down SQL
+func downStep(status MigrationStatus) (MigrationStep, error) {
+	if status.Missing {
+		return MigrationStep{}, fmt.Errorf("%w: applied version %d has no migration files", ErrMigrationNotFound, status.Version)
+	}
+	if status.DownSQL == "" {
+		return MigrationStep{}, fmt.Errorf("migration %03d_%s is missing down SQL", status.Version, status.Name)
+	}
+	return MigrationStep{Migration: status.Migration, Direction: MigrationDown}, nil
+}
+
+// hasVersion reports whether statuses contain the version
+func hasVersion(statuses []MigrationStatus, version int) bool {
+	i := sort.Search(len(statuses), func(i int) bool {
+		return statuses[i].Version >= version
+	})
+	return i < len(statuses) && statuses[i].Version == version
+}
@@ -1,7 +1,11 @@
 package postgres
 
 import (
+	"fmt"
+	"sort"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // MigrationRecord represents a migration record in the database
@@ -9,10 +13,10 @@ type MigrationRecord struct {
 	Version     int       `json:"version"`
 	Description string    `json:"description"`
 	AppliedAt   time.Time `json:"applied_at"`
+	Checksum    string    `json:"checksum,omitempty"` // empty for migrations applied before checksums were recorded
 }
 
 // MigrationTracker interface defines methods for tracking migration state
-// This will be implemented when database connection is added
 type MigrationTracker interface {
 	// GetAppliedMigrations returns all migrations that have been applied
 	GetAppliedMigrations() ([]MigrationRecord, error)
@@ -20,8 +24,8 @@ type MigrationTracker interface {
 	// IsMigrationApplied checks if a specific migration version has been applied
 	IsMigrationApplied(version int) (bool, error)
 	
-	// MarkMigrationApplied marks a migration as applied
-	MarkMigrationApplied(version int, description string) error
+	// MarkMigrationApplied marks a migration as applied with the checksum of its up SQL
+	MarkMigrationApplied(version int, description, checksum string) error
 	
 	// MarkMigrationRolledBack removes a migration record (for rollbacks)
 	MarkMigrationRolledBack(version int) error
@@ -34,7 +38,6 @@ type MigrationTracker interface {
 }
 
 // MockMigrationTracker is a mock implementation for testing and development
-// This can be used until database connection is implemented
 type MockMigrationTracker struct {
 	appliedMigrations map[int]MigrationRecord
 }
@@ -52,6 +55,9 @@ func (mt *MockMigrationTracker) GetAppliedMigrations() ([]MigrationRecord, error
 	for _, record := range mt.appliedMigrations {
 		records = append(records, record)
 	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Version < records[j].Version
+	})
 	return records, nil
 }
 
@@ -62,11 +68,12 @@ func (mt *MockMigrationTracker) IsMigrationApplied(version int) (bool, error) {
 }
 
 // MarkMigrationApplied marks a migration as applied in the mock tracker
-func (mt *MockMigrationTracker) MarkMigrationApplied(version int, description string) error {
+func (mt *MockMigrationTracker) MarkMigrationApplied(version int, description, checksum string) error {
 	mt.appliedMigrations[version] = MigrationRecord{
 		Version:     version,
 		Description: description,
 		AppliedAt:   time.Now(),
+		Checksum:    checksum,
 	}
 	return nil
 }
@@ -93,16 +100,138 @@ func (mt *MockMigrationTracker) InitializeTrackingTable() error {
 	return nil
 }
 
-// Future implementation note:
-// When database connection is added, create a PostgresMigrationTracker
-// that implements the MigrationTracker interface with actual database operations:
-//
-// type PostgresMigrationTracker struct {
-//     db *sql.DB
-// }
-//
-// func NewPostgresMigrationTracker(db *sql.DB) *PostgresMigrationTracker {
-//     return &PostgresMigrationTracker{db: db}
-// }
-//
-// Then implement all the interface methods with actual SQL queries 
\ No newline at end of file
+// PostgresMigrationTracker tracks applied migrations in the schema_migrations table.
+// Bind it to a transaction to record a migration atomically with its SQL.
+type PostgresMigrationTracker struct {
+	db *gorm.DB
+}
+
+// NewPostgresMigrationTracker creates a tracker using db, which may be a transaction
+func NewPostgresMigrationTracker(db *gorm.DB) *PostgresMigrationTracker {
+	return &PostgresMigrationTracker{
+		db: db,
+	}
+}
+
+// GetAppliedMigrations returns the applied migrations ordered by version
+func (pt *PostgresMigrationTracker) GetAppliedMigrations() ([]MigrationRecord, error) {
+	rows, err := pt.db.Raw(`SELECT version, COALESCE(description, ''), applied_at, COALESCE(checksum, '')
+		FROM schema_migrations ORDER BY version`).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var records []MigrationRecord
+	for rows.Next() {
+		var record MigrationRecord
+		var at appliedAt
+		if err := rows.Scan(&record.Version, &record.Description, &at, &record.Checksum); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		record.AppliedAt = at.Time
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	return records, nil
+}
+
+// IsMigrationApplied checks if a specific migration version has been applied
+func (pt *PostgresMigrationTracker) IsMigrationApplied(version int) (bool, error) {
+	var count int64
+	if err := pt.db.Raw("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", version).Scan(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to query migration %d: %w", version, err)
+	}
+	return count > 0, nil
+}
+
+// MarkMigrationApplied records the migration. The migration files insert their own record, so an
+// existing record keeps its description and gets the checksum.
+func (pt *PostgresMigrationTracker) MarkMigrationApplied(version int, description, checksum string) error {
+	err := pt.db.Exec(`INSERT INTO schema_migrations (version, description, checksum, applied_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (version) DO UPDATE SET checksum = EXCLUDED.checksum,
+		description = COALESCE(schema_migrations.description, EXCLUDED.description)`,
+		version, description, checksum, time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", version, err)
+	}
+	return nil
+}
+
+// MarkMigrationRolledBack removes the migration record
+func (pt *PostgresMigrationTracker) MarkMigrationRolledBack(version int) error {
+	if err := pt.db.Exec("DELETE FROM schema_migrations WHERE version = ?", version).Error; err != nil {
+		return fmt.Errorf("failed to remove migration %d: %w", version, err)
+	}
+	return nil
+}
+
+// GetCurrentSchemaVersion returns the highest applied migration version, or 0 when none is applied
+func (pt *PostgresMigrationTracker) GetCurrentSchemaVersion() (int, error) {
+	var version int
+	if err := pt.db.Raw("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version).Error; err != nil {
+		return 0, fmt.Errorf("failed to query schema version: %w", err)
+	}
+	return version, nil
+}
+
+// InitializeTrackingTable creates the schema_migrations table if it doesn't exist and adds the
+// checksum column to tables created by the initial migration
+func (pt *PostgresMigrationTracker) InitializeTrackingTable() error {
+	err := pt.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+		description TEXT,
+		checksum VARCHAR(64)
+	)`).Error
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	if !pt.db.Migrator().HasColumn("schema_migrations", "checksum") {
+		if err := pt.db.Exec("ALTER TABLE schema_migrations ADD COLUMN checksum VARCHAR(64)").Error; err != nil {
+			return fmt.Errorf("failed to add checksum column to schema_migrations: %w", err)
+		}
+	}
+	return nil
+}
+
+// tableExists reports whether the schema_migrations table exists
+func (pt *PostgresMigrationTracker) tableExists() bool {
+	return pt.db.Migrator().HasTable("schema_migrations")
+}
+
+// appliedAt scans applied_at, which SQLite returns as text for TIMESTAMP WITH TIME ZONE columns
+type appliedAt struct {
+	time.Time
+}
+
+// Scan implements sql.Scanner
+func (a *appliedAt) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		a.Time = time.Time{}
+	case time.Time:
+		a.Time = v
+	case string:
+		return a.parse(v)
+	case []byte:
+		return a.parse(string(v))
+	default:
+		return fmt.Errorf("cannot scan %T into applied_at", value)
+	}
+	return nil
+}
+
+// parse parses the textual timestamp formats written by SQLite drivers
+func (a *appliedAt) parse(value string) error {
+	for _, layout := range []string{"2006-01-02 15:04:05.999999999-07:00", time.RFC3339Nano, "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			a.Time = t
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse applied_at %q", value)
+}
@@ -0,0 +1,14 @@
+-- Migration: add_technician_locations
+-- Version: 6
+-- Created: 2025-08-04 09:00:00
+-- Direction: DOWN
+
+-- Remove migration record
+DELETE FROM schema_migrations WHERE version = 6;
+
+-- Drop technician locations table
+DROP INDEX IF EXISTS idx_technician_locations_deleted_at;
+DROP INDEX IF EXISTS idx_technician_locations_trail;
+DROP INDEX IF EXISTS idx_technician_locations_route_id;
+DROP INDEX IF EXISTS idx_technician_locations_organization_id;
+DROP TABLE IF EXISTS technician_locations CASCADE;
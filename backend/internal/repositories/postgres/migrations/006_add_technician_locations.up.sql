@@ -0,0 +1,32 @@
+-- Migration: add_technician_locations
+-- Version: 6
+-- Created: 2025-08-04 09:00:00
+-- Direction: UP
+
+-- Create technician location history table for breadcrumb trails
+CREATE TABLE IF NOT EXISTS technician_locations (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    technician_id INTEGER NOT NULL REFERENCES technicians(id) ON DELETE CASCADE,
+    route_id INTEGER REFERENCES routes(id) ON DELETE SET NULL,
+    lat DECIMAL(10, 8) NOT NULL,
+    lng DECIMAL(11, 8) NOT NULL,
+    accuracy DOUBLE PRECISION, -- in meters
+    speed DOUBLE PRECISION, -- in meters per second
+    heading DOUBLE PRECISION, -- in degrees clockwise from north
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes for technician locations
+CREATE INDEX IF NOT EXISTS idx_technician_locations_organization_id ON technician_locations(organization_id);
+CREATE INDEX IF NOT EXISTS idx_technician_locations_route_id ON technician_locations(route_id);
+CREATE INDEX IF NOT EXISTS idx_technician_locations_trail ON technician_locations(technician_id, recorded_at);
+CREATE INDEX IF NOT EXISTS idx_technician_locations_deleted_at ON technician_locations(deleted_at);
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description) 
+VALUES (6, 'Add technician_locations table for location history')
+ON CONFLICT (version) DO NOTHING;
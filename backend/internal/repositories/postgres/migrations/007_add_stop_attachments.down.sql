@@ -0,0 +1,17 @@
+-- Migration: add_stop_attachments
+-- Version: 7
+-- Created: 2025-08-11 09:00:00
+-- Direction: DOWN
+
+-- Remove migration record
+DELETE FROM schema_migrations WHERE version = 7;
+
+-- Remove organization storage quota
+ALTER TABLE organizations DROP COLUMN IF EXISTS storage_quota;
+
+-- Drop stop attachments table
+DROP INDEX IF EXISTS idx_stop_attachments_deleted_at;
+DROP INDEX IF EXISTS idx_stop_attachments_route_stop_id;
+DROP INDEX IF EXISTS idx_stop_attachments_route_id;
+DROP INDEX IF EXISTS idx_stop_attachments_organization_id;
+DROP TABLE IF EXISTS stop_attachments CASCADE;
@@ -0,0 +1,39 @@
+-- Migration: add_stop_attachments
+-- Version: 7
+-- Created: 2025-08-11 09:00:00
+-- Direction: UP
+
+-- Create stop attachments table for photo and document uploads
+CREATE TABLE IF NOT EXISTS stop_attachments (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    route_id INTEGER NOT NULL REFERENCES routes(id) ON DELETE CASCADE,
+    route_stop_id INTEGER NOT NULL REFERENCES route_stops(id) ON DELETE CASCADE,
+    uploaded_by_id INTEGER NOT NULL REFERENCES users(id),
+    kind VARCHAR(20) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL, -- in bytes
+    checksum VARCHAR(64) NOT NULL, -- hex encoded SHA-256
+    storage_key VARCHAR(255) NOT NULL,
+    captured_at TIMESTAMP WITH TIME ZONE,
+    lat DECIMAL(10, 8),
+    lng DECIMAL(11, 8),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes for stop attachments
+CREATE INDEX IF NOT EXISTS idx_stop_attachments_organization_id ON stop_attachments(organization_id);
+CREATE INDEX IF NOT EXISTS idx_stop_attachments_route_id ON stop_attachments(route_id);
+CREATE INDEX IF NOT EXISTS idx_stop_attachments_route_stop_id ON stop_attachments(route_stop_id);
+CREATE INDEX IF NOT EXISTS idx_stop_attachments_deleted_at ON stop_attachments(deleted_at);
+
+-- Per-organization storage quota in bytes (0 uses the configured default)
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS storage_quota BIGINT NOT NULL DEFAULT 0;
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description) 
+VALUES (7, 'Add stop_attachments table and organization storage quota')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,21 @@
+-- Migration: add_stop_proofs
+-- Version: 8
+-- Created: 2025-08-18 09:00:00
+-- Direction: DOWN
+
+-- Remove migration record
+DELETE FROM schema_migrations WHERE version = 8;
+
+-- Drop stop proofs table
+DROP INDEX IF EXISTS idx_stop_proofs_deleted_at;
+DROP INDEX IF EXISTS idx_stop_proofs_technician_id;
+DROP INDEX IF EXISTS idx_stop_proofs_route_stop_id;
+DROP INDEX IF EXISTS idx_stop_proofs_route_id;
+DROP INDEX IF EXISTS idx_stop_proofs_organization_id;
+DROP TABLE IF EXISTS stop_proofs CASCADE;
+
+-- Drop stop checklists table
+DROP INDEX IF EXISTS idx_stop_checklists_org_stop_type;
+DROP INDEX IF EXISTS idx_stop_checklists_deleted_at;
+DROP INDEX IF EXISTS idx_stop_checklists_organization_id;
+DROP TABLE IF EXISTS stop_checklists CASCADE;
@@ -0,0 +1,55 @@
+-- Migration: add_stop_proofs
+-- Version: 8
+-- Created: 2025-08-18 09:00:00
+-- Direction: UP
+
+-- Create stop checklists table for per-organization proof-of-service requirements
+CREATE TABLE IF NOT EXISTS stop_checklists (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    stop_type VARCHAR(20) NOT NULL,
+    require_signature BOOLEAN DEFAULT FALSE,
+    require_recipient_name BOOLEAN DEFAULT FALSE,
+    items TEXT NOT NULL DEFAULT '[]', -- JSON array of checklist items
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes for stop checklists (one active checklist per stop type)
+CREATE INDEX IF NOT EXISTS idx_stop_checklists_organization_id ON stop_checklists(organization_id);
+CREATE INDEX IF NOT EXISTS idx_stop_checklists_deleted_at ON stop_checklists(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stop_checklists_org_stop_type ON stop_checklists(organization_id, stop_type) WHERE deleted_at IS NULL;
+
+-- Create stop proofs table for proof-of-service records of each stop attempt
+CREATE TABLE IF NOT EXISTS stop_proofs (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    route_id INTEGER NOT NULL REFERENCES routes(id) ON DELETE CASCADE,
+    route_stop_id INTEGER NOT NULL REFERENCES route_stops(id) ON DELETE CASCADE,
+    technician_id INTEGER NOT NULL REFERENCES technicians(id),
+    outcome VARCHAR(20) NOT NULL,
+    recipient_name VARCHAR(100),
+    signature_attachment_id INTEGER REFERENCES stop_attachments(id) ON DELETE SET NULL,
+    checklist TEXT NOT NULL DEFAULT '[]', -- JSON array of answers with their labels
+    failure_reason VARCHAR(50),
+    notes TEXT,
+    lat DECIMAL(10, 8),
+    lng DECIMAL(11, 8),
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes for stop proofs
+CREATE INDEX IF NOT EXISTS idx_stop_proofs_organization_id ON stop_proofs(organization_id);
+CREATE INDEX IF NOT EXISTS idx_stop_proofs_route_id ON stop_proofs(route_id);
+CREATE INDEX IF NOT EXISTS idx_stop_proofs_route_stop_id ON stop_proofs(route_stop_id);
+CREATE INDEX IF NOT EXISTS idx_stop_proofs_technician_id ON stop_proofs(technician_id);
+CREATE INDEX IF NOT EXISTS idx_stop_proofs_deleted_at ON stop_proofs(deleted_at);
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description) 
+VALUES (8, 'Add stop_checklists and stop_proofs tables')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,32 @@
+-- Migration: rotate_refresh_tokens
+-- Version: 9
+-- Created: 2025-08-25 09:00:00
+-- Direction: DOWN
+
+-- Remove migration record
+DELETE FROM schema_migrations WHERE version = 9;
+
+-- Restore the single refresh token per user
+ALTER TABLE users ADD COLUMN IF NOT EXISTS refresh_token VARCHAR(255);
+
+-- Hashed tokens cannot be restored, so sessions are dropped and users sign in again
+DELETE FROM user_sessions;
+
+-- Drop the session rotation columns
+DROP INDEX IF EXISTS idx_user_sessions_deleted_at;
+DROP INDEX IF EXISTS idx_user_sessions_refresh_token_hash;
+DROP INDEX IF EXISTS idx_user_sessions_family_id;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS revoked_reason;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS revoked_at;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS last_used_at;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS device_name;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS refresh_token_hash;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS family_id;
+
+-- Restore the original columns and indexes
+ALTER TABLE user_sessions ADD COLUMN refresh_token VARCHAR(255) NOT NULL;
+ALTER TABLE user_sessions ADD COLUMN access_token_hash VARCHAR(255) NOT NULL;
+ALTER TABLE user_sessions ADD COLUMN is_valid BOOLEAN DEFAULT true;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_sessions_refresh_token ON user_sessions(refresh_token);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_is_valid ON user_sessions(is_valid);
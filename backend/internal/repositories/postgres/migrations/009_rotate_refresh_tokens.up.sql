@@ -0,0 +1,37 @@
+-- Migration: rotate_refresh_tokens
+-- Version: 9
+-- Created: 2025-08-25 09:00:00
+-- Direction: UP
+
+-- Sessions were never written before refresh tokens moved to this table, and the
+-- tokens kept on users are invalidated below, so existing rows carry nothing to keep
+DELETE FROM user_sessions;
+
+-- Store a hash of the session's current refresh token instead of the token itself
+DROP INDEX IF EXISTS idx_user_sessions_refresh_token;
+DROP INDEX IF EXISTS idx_user_sessions_is_valid;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS refresh_token;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS access_token_hash;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS is_valid;
+
+-- Add the rotation family, device details and revocation of each session
+ALTER TABLE user_sessions ADD COLUMN family_id VARCHAR(64) NOT NULL;
+ALTER TABLE user_sessions ADD COLUMN refresh_token_hash VARCHAR(64) NOT NULL;
+ALTER TABLE user_sessions ADD COLUMN device_name VARCHAR(100);
+ALTER TABLE user_sessions ADD COLUMN last_used_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE user_sessions ADD COLUMN revoked_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE user_sessions ADD COLUMN revoked_reason VARCHAR(50);
+ALTER TABLE user_sessions ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Create indexes for user sessions
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_sessions_family_id ON user_sessions(family_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_sessions_refresh_token_hash ON user_sessions(refresh_token_hash);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_deleted_at ON user_sessions(deleted_at);
+
+-- Refresh tokens are kept per session now; signing in again is required once
+ALTER TABLE users DROP COLUMN IF EXISTS refresh_token;
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description) 
+VALUES (9, 'Rotate hashed refresh tokens per user session')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,17 @@
+-- Migration: add_user_tokens
+-- Version: 10
+-- Created: 2025-09-01 09:00:00
+-- Direction: DOWN
+
+-- Remove migration record
+DELETE FROM schema_migrations WHERE version = 10;
+
+-- Drop email verification
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+
+-- Drop user tokens table
+DROP INDEX IF EXISTS idx_user_tokens_deleted_at;
+DROP INDEX IF EXISTS idx_user_tokens_token_hash;
+DROP INDEX IF EXISTS idx_user_tokens_user_id;
+DROP INDEX IF EXISTS idx_user_tokens_organization_id;
+DROP TABLE IF EXISTS user_tokens CASCADE;
@@ -0,0 +1,33 @@
+-- Migration: add_user_tokens
+-- Version: 10
+-- Created: 2025-09-01 09:00:00
+-- Direction: UP
+
+-- Create user tokens table for password resets and email verification
+CREATE TABLE IF NOT EXISTS user_tokens (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(30) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL, -- SHA-256 of the emailed token
+    email VARCHAR(100) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes for user tokens
+CREATE INDEX IF NOT EXISTS idx_user_tokens_organization_id ON user_tokens(organization_id);
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON user_tokens(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_tokens_token_hash ON user_tokens(token_hash);
+CREATE INDEX IF NOT EXISTS idx_user_tokens_deleted_at ON user_tokens(deleted_at);
+
+-- Track when users confirmed their email address
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description) 
+VALUES (10, 'Add user_tokens table and email verification')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,21 @@
+-- Migration: add_two_factor_auth
+-- Version: 11
+-- Created: 2025-09-08 09:00:00
+-- Direction: DOWN
+
+-- Remove migration record
+DELETE FROM schema_migrations WHERE version = 11;
+
+-- Drop recovery codes table
+DROP INDEX IF EXISTS idx_user_recovery_codes_deleted_at;
+DROP INDEX IF EXISTS idx_user_recovery_codes_user_id;
+DROP INDEX IF EXISTS idx_user_recovery_codes_organization_id;
+DROP TABLE IF EXISTS user_recovery_codes CASCADE;
+
+-- Drop the organization policy
+ALTER TABLE organizations DROP COLUMN IF EXISTS require_mfa;
+
+-- Drop TOTP enrollment
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
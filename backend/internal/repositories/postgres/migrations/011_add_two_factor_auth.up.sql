@@ -0,0 +1,34 @@
+-- Migration: add_two_factor_auth
+-- Version: 11
+-- Created: 2025-09-08 09:00:00
+-- Direction: UP
+
+-- Track TOTP enrollment; the secret is encrypted by the application
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- Let organizations require a second factor for owners
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS require_mfa BOOLEAN DEFAULT false;
+
+-- Create recovery codes table
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL, -- SHA-256 of the normalized code
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes for recovery codes
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_organization_id ON user_recovery_codes(organization_id);
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_deleted_at ON user_recovery_codes(deleted_at);
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description) 
+VALUES (11, 'Add TOTP two-factor authentication and recovery codes')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,17 @@
+-- Migration: add_login_lockouts
+-- Version: 12
+-- Created: 2025-09-15 09:00:00
+-- Direction: DOWN
+
+-- Remove migration record
+DELETE FROM schema_migrations WHERE version = 12;
+
+-- Drop account lockouts table
+DROP INDEX IF EXISTS idx_account_lockouts_deleted_at;
+DROP INDEX IF EXISTS idx_account_lockouts_user_id;
+DROP INDEX IF EXISTS idx_account_lockouts_organization_id;
+DROP TABLE IF EXISTS account_lockouts CASCADE;
+
+-- Drop failed login counters
+DROP INDEX IF EXISTS idx_login_attempts_last_failure_at;
+DROP TABLE IF EXISTS login_attempts CASCADE;
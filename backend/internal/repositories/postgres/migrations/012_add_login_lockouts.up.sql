@@ -0,0 +1,39 @@
+-- Migration: add_login_lockouts
+-- Version: 12
+-- Created: 2025-09-15 09:00:00
+-- Direction: UP
+
+-- Create failed login counters shared by replicas using the database lockout store
+CREATE TABLE IF NOT EXISTS login_attempts (
+    key VARCHAR(255) PRIMARY KEY, -- account or IP address the failures are counted for
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failure_at ON login_attempts(last_failure_at);
+
+-- Create account lockouts table, the audit trail of lockouts and unlocks
+CREATE TABLE IF NOT EXISTS account_lockouts (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ip_address VARCHAR(50),
+    failed_attempts INTEGER NOT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    unlocked_at TIMESTAMP WITH TIME ZONE,
+    unlocked_by_id INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes for account lockouts
+CREATE INDEX IF NOT EXISTS idx_account_lockouts_organization_id ON account_lockouts(organization_id);
+CREATE INDEX IF NOT EXISTS idx_account_lockouts_user_id ON account_lockouts(user_id);
+CREATE INDEX IF NOT EXISTS idx_account_lockouts_deleted_at ON account_lockouts(deleted_at);
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description) 
+VALUES (12, 'Add login attempt counters and account lockouts')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,11 @@
+-- Migration: add_revoked_tokens
+-- Version: 13
+-- Created: 2025-09-22 09:00:00
+-- Direction: DOWN
+
+-- Remove migration record
+DELETE FROM schema_migrations WHERE version = 13;
+
+-- Drop the deny list of access tokens
+DROP INDEX IF EXISTS idx_revoked_tokens_expires_at;
+DROP TABLE IF EXISTS revoked_tokens CASCADE;
@@ -0,0 +1,18 @@
+-- Migration: add_revoked_tokens
+-- Version: 13
+-- Created: 2025-09-22 09:00:00
+-- Direction: UP
+
+-- Create the deny list of access tokens shared by replicas using the database revocation store
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    key VARCHAR(255) PRIMARY KEY, -- token (jti), user session or user the revocation applies to
+    not_before TIMESTAMP WITH TIME ZONE NOT NULL, -- tokens issued before this time are rejected
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description) 
+VALUES (13, 'Add revoked access tokens')
+ON CONFLICT (version) DO NOTHING;
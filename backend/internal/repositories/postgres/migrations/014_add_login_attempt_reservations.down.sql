@@ -0,0 +1,11 @@
+-- Migration: add_login_attempt_reservations
+-- Version: 14
+-- Created: 2025-09-29 09:00:00
+-- Direction: DOWN
+
+-- Remove migration record
+DELETE FROM schema_migrations WHERE version = 14;
+
+-- Drop the reservations of login attempts in progress
+ALTER TABLE login_attempts DROP COLUMN IF EXISTS reserved_at;
+ALTER TABLE login_attempts DROP COLUMN IF EXISTS pending;
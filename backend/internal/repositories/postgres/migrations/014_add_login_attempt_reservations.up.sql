@@ -0,0 +1,13 @@
+-- Migration: add_login_attempt_reservations
+-- Version: 14
+-- Created: 2025-09-29 09:00:00
+-- Direction: UP
+
+-- Count login attempts in progress, so parallel attempts are throttled before any of them fails
+ALTER TABLE login_attempts ADD COLUMN IF NOT EXISTS pending INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE login_attempts ADD COLUMN IF NOT EXISTS reserved_at TIMESTAMP WITH TIME ZONE;
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description) 
+VALUES (14, 'Add reservations of login attempts in progress')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"routrapp-api/internal/models"
+)
+
+// OrganizationRepository implements repositories.OrganizationRepository with GORM
+type OrganizationRepository struct {
+	db *gorm.DB
+}
+
+// NewOrganizationRepository creates an organization repository backed by db
+func NewOrganizationRepository(db *gorm.DB) *OrganizationRepository {
+	return &OrganizationRepository{
+		db: db,
+	}
+}
+
+// FindByID implements repositories.OrganizationRepository
+func (r *OrganizationRepository) FindByID(ctx context.Context, id uint) (*models.Organization, error) {
+	var organization models.Organization
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&organization).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &organization, nil
+}
+
+// FindBySubDomain implements repositories.OrganizationRepository
+func (r *OrganizationRepository) FindBySubDomain(ctx context.Context, subDomain string) (*models.Organization, error) {
+	var organization models.Organization
+	if err := r.db.WithContext(ctx).Where("sub_domain = ?", subDomain).First(&organization).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &organization, nil
+}
+
+// Create implements repositories.OrganizationRepository
+func (r *OrganizationRepository) Create(ctx context.Context, organization *models.Organization) error {
+	return r.db.WithContext(ctx).Create(organization).Error
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"routrapp-api/internal/repositories"
+)
+
+// The GORM repositories implement the repositories interfaces
+var (
+	_ repositories.UserRepository         = (*UserRepository)(nil)
+	_ repositories.OrganizationRepository = (*OrganizationRepository)(nil)
+	_ repositories.RouteRepository        = (*RouteRepository)(nil)
+	_ repositories.TechnicianRepository   = (*TechnicianRepository)(nil)
+	_ repositories.ActivityRepository     = (*ActivityRepository)(nil)
+)
+
+// NewRepositories creates the GORM repositories backed by db.
+// The tenant plugin must be registered on db for the repositories to be scoped to an organization.
+func NewRepositories(db *gorm.DB) repositories.Repositories {
+	return repositories.Repositories{
+		Users:         NewUserRepository(db),
+		Organizations: NewOrganizationRepository(db),
+		Routes:        NewRouteRepository(db),
+		Technicians:   NewTechnicianRepository(db),
+		Activities:    NewActivityRepository(db),
+	}
+}
+
+// translateError maps GORM's not found error to repositories.ErrNotFound and unique constraint
+// violations to repositories.ErrDuplicate
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return repositories.ErrNotFound
+	}
+	if isUniqueViolation(err) {
+		return repositories.ErrDuplicate
+	}
+	return err
+}
+
+// isUniqueViolation reports whether the error is a unique constraint violation; drivers only
+// return gorm.ErrDuplicatedKey when GORM is configured to translate errors
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "duplicate key") || strings.Contains(message, "unique constraint")
+}
+
+// requireAffected returns repositories.ErrNotFound when a write matched no rows
+func requireAffected(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repositories.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+)
+
+// routeSortColumns maps the sort_by filter values to route table columns
+var routeSortColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// RouteRepository implements repositories.RouteRepository with GORM
+type RouteRepository struct {
+	db *gorm.DB
+}
+
+// NewRouteRepository creates a route repository backed by db
+func NewRouteRepository(db *gorm.DB) *RouteRepository {
+	return &RouteRepository{
+		db: db,
+	}
+}
+
+// List implements repositories.RouteRepository
+func (r *RouteRepository) List(ctx context.Context, filter repositories.RouteFilter, page repositories.Page) ([]models.Route, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Route{})
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.TechnicianID != nil {
+		query = query.Where("technician_id = ?", *filter.TechnicianID)
+	}
+	if filter.DateFrom != nil {
+		query = query.Where("scheduled_date >= ?", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		query = query.Where("scheduled_date <= ?", *filter.DateTo)
+	}
+	if filter.Search != "" {
+		query = query.Where("LOWER(name) LIKE ?", "%"+strings.ToLower(filter.Search)+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	orderColumn := "created_at"
+	if column, exists := routeSortColumns[filter.SortBy]; exists {
+		orderColumn = column
+	}
+	orderDirection := "ASC"
+	if filter.SortDesc || filter.SortBy == "" {
+		orderDirection = "DESC"
+	}
+
+	var routes []models.Route
+	if err := query.
+		Preload("Technician.User").
+		Order(fmt.Sprintf("%s %s", orderColumn, orderDirection)).
+		Offset(page.Offset()).
+		Limit(page.PageSize).
+		Find(&routes).Error; err != nil {
+		return nil, 0, err
+	}
+	return routes, total, nil
+}
+
+// FindByID implements repositories.RouteRepository
+func (r *RouteRepository) FindByID(ctx context.Context, id uint) (*models.Route, error) {
+	var route models.Route
+	err := r.db.WithContext(ctx).
+		Preload("Stops", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence_num ASC")
+		}).
+		Preload("Technician.User").
+		Where("id = ?", id).
+		First(&route).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &route, nil
+}
+
+// CountByTechnician implements repositories.RouteRepository
+func (r *RouteRepository) CountByTechnician(ctx context.Context, technicianID uint, statuses ...models.RouteStatus) (int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Route{}).Where("technician_id = ?", technicianID)
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// CountStops implements repositories.RouteRepository
+func (r *RouteRepository) CountStops(ctx context.Context, routeIDs []uint) (map[uint]int, error) {
+	counts := make(map[uint]int, len(routeIDs))
+	if len(routeIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		RouteID uint
+		Count   int
+	}
+	if err := r.db.WithContext(ctx).Model(&models.RouteStop{}).
+		Select("route_id, COUNT(*) AS count").
+		Where("route_id IN ?", routeIDs).
+		Group("route_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.RouteID] = row.Count
+	}
+	return counts, nil
+}
+
+// Create implements repositories.RouteRepository
+func (r *RouteRepository) Create(ctx context.Context, route *models.Route) error {
+	return r.db.WithContext(ctx).Omit("Technician").Create(route).Error
+}
+
+// Update implements repositories.RouteRepository
+func (r *RouteRepository) Update(ctx context.Context, id uint, update repositories.RouteUpdate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Updating through a bare model keeps the route's associations from being written back
+		if updates := routeUpdates(update); len(updates) > 0 {
+			if err := requireAffected(tx.Model(&models.Route{}).Where("id = ?", id).Updates(updates)); err != nil {
+				return err
+			}
+		}
+
+		sequence := make(map[uint]int)
+		for _, stop := range update.Stops {
+			if err := updateStop(tx, id, stop); err != nil {
+				return err
+			}
+			if stop.SequenceNum != nil {
+				sequence[stop.ID] = *stop.SequenceNum
+			}
+		}
+		if len(sequence) > 0 {
+			if err := applyStopSequence(tx, id, sequence); err != nil {
+				return err
+			}
+			// Manual reordering invalidates any previously computed optimization
+			if err := tx.Model(&models.Route{}).Where("id = ?", id).Update("is_optimized", false).Error; err != nil {
+				return err
+			}
+		}
+
+		if update.Optimization == nil {
+			return nil
+		}
+		if err := applyStopSequence(tx, id, update.Optimization.Sequence); err != nil {
+			return err
+		}
+		return tx.Model(&models.Route{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"is_optimized":   true,
+			"total_distance": update.Optimization.TotalDistance,
+			"total_duration": update.Optimization.TotalDuration,
+		}).Error
+	})
+}
+
+// Delete implements repositories.RouteRepository
+func (r *RouteRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("route_id = ?", id).Delete(&models.RouteStop{}).Error; err != nil {
+			return err
+		}
+		return requireAffected(tx.Where("id = ?", id).Delete(&models.Route{}))
+	})
+}
+
+// routeUpdates collects the column updates of a route update
+func routeUpdates(update repositories.RouteUpdate) map[string]interface{} {
+	updates := make(map[string]interface{})
+	if update.Name != nil {
+		updates["name"] = *update.Name
+	}
+	if update.Description != nil {
+		updates["description"] = *update.Description
+	}
+	if update.TechnicianID != nil {
+		updates["technician_id"] = *update.TechnicianID
+	} else if update.UnassignTechnician {
+		updates["technician_id"] = nil
+	}
+	if update.Status != nil {
+		updates["status"] = *update.Status
+	}
+	if update.ScheduledDate != nil {
+		updates["scheduled_date"] = *update.ScheduledDate
+	}
+	if update.Notes != nil {
+		updates["notes"] = *update.Notes
+	}
+	return updates
+}
+
+// updateStop applies the column updates of a stop on the route, excluding the sequence number
+// which is applied separately by applyStopSequence. Completing the stop only matches it while
+// it is still open, so that two concurrent completions cannot both succeed.
+func updateStop(tx *gorm.DB, routeID uint, update repositories.RouteStopUpdate) error {
+	updates := make(map[string]interface{})
+	if update.Name != nil {
+		updates["name"] = *update.Name
+	}
+	if update.Address != nil {
+		updates["address"] = *update.Address
+	}
+	if update.Lat != nil {
+		updates["lat"] = *update.Lat
+	}
+	if update.Lng != nil {
+		updates["lng"] = *update.Lng
+	}
+	if update.StopType != nil {
+		updates["stop_type"] = *update.StopType
+	}
+	if update.Duration != nil {
+		updates["duration"] = *update.Duration
+	}
+	if update.Notes != nil {
+		updates["notes"] = *update.Notes
+	}
+	if update.TimeWindow != nil {
+		updates["start_time"] = update.TimeWindow.StartTime
+		updates["end_time"] = update.TimeWindow.EndTime
+	}
+
+	query := tx.Model(&models.RouteStop{}).Where("id = ? AND route_id = ?", update.ID, routeID)
+	if update.IsCompleted != nil {
+		updates["is_completed"] = *update.IsCompleted
+		if *update.IsCompleted {
+			updates["completed_at"] = time.Now()
+			result := query.Where("is_completed = ?", false).Updates(updates)
+			if result.Error != nil || result.RowsAffected > 0 {
+				return result.Error
+			}
+			var count int64
+			if err := tx.Model(&models.RouteStop{}).Where("id = ? AND route_id = ?", update.ID, routeID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return repositories.ErrNotFound
+			}
+			return repositories.ErrStopAlreadyCompleted
+		}
+		updates["completed_at"] = nil
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return requireAffected(query.Updates(updates))
+}
+
+// applyStopSequence rewrites the sequence numbers of the route's stops in two passes so that
+// swapping positions never violates the unique (route_id, sequence_num) constraint
+func applyStopSequence(tx *gorm.DB, routeID uint, sequence map[uint]int) error {
+	for _, sign := range []int{-1, 1} {
+		for stopID, sequenceNum := range sequence {
+			if err := requireAffected(tx.Model(&models.RouteStop{}).
+				Where("id = ? AND route_id = ?", stopID, routeID).
+				Update("sequence_num", sign*sequenceNum)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// migrationsTable is tracked by the migration runner rather than a model
+const migrationsTable = "schema_migrations"
+
+// indexNamePattern extracts the index name from a CREATE INDEX statement
+var indexNamePattern = regexp.MustCompile(`(?i)\bINDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?"?(\w+)"?\s+ON\b`)
+
+// modelIndexes is implemented by models declaring indexes GORM tags can't express, such as partial indexes
+type modelIndexes interface {
+	Indexes() []string
+}
+
+// SchemaDiff is the DDL bringing a database schema in line with the models
+type SchemaDiff struct {
+	Up    []string
+	Down  []string // in the order the statements must run
+	Notes []string // differences that are reported but not migrated, such as columns the models no longer have
+}
+
+// Empty reports whether the database schema already matches the models
+func (d *SchemaDiff) Empty() bool {
+	return len(d.Up) == 0
+}
+
+// UpSQL returns the up statements, preceded by the notes as comments
+func (d *SchemaDiff) UpSQL() string {
+	var sql strings.Builder
+	for _, note := range d.Notes {
+		sql.WriteString("-- NOTE: " + note + "\n")
+	}
+	if len(d.Notes) > 0 {
+		sql.WriteString("\n")
+	}
+	sql.WriteString(joinStatements(d.Up))
+	return sql.String()
+}
+
+// DownSQL returns the down statements
+func (d *SchemaDiff) DownSQL() string {
+	return joinStatements(d.Down)
+}
+
+// SchemaDiffer compares model definitions against the schema of a database.
+// Missing tables, columns and indexes, including those of the models' Indexes methods, are
+// generated with GORM's migrator for the database's dialect. Columns and tables the models don't
+// have are only reported, as dropping them could lose data, and column type changes are not detected.
+type SchemaDiffer struct {
+	db     *gorm.DB
+	models []interface{}
+}
+
+// NewSchemaDiffer creates a differ for the models, e.g. models.AllModels()
+func NewSchemaDiffer(db *gorm.DB, models ...interface{}) *SchemaDiffer {
+	return &SchemaDiffer{
+		db:     db,
+		models: models,
+	}
+}
+
+// Diff compares the models against the database
+func (d *SchemaDiffer) Diff(ctx context.Context) (*SchemaDiff, error) {
+	recorder := &statementRecorder{}
+	live := d.db.WithContext(ctx).Migrator()
+	dryRunDB := d.db.Session(&gorm.Session{DryRun: true, Logger: recorder, Context: ctx})
+	dryRun := dryRunDB.Migrator()
+
+	diff := &SchemaDiff{}
+	var downs [][]string
+	change := func(up func() error, down func() error) error {
+		upStatements, err := recorder.record(up)
+		if err != nil {
+			return err
+		}
+		downStatements, err := recorder.record(down)
+		if err != nil {
+			return err
+		}
+		diff.Up = append(diff.Up, upStatements...)
+		downs = append(downs, downStatements)
+		return nil
+	}
+
+	modelTables := map[string]bool{migrationsTable: true}
+	for _, model := range d.models {
+		stmt := &gorm.Statement{DB: d.db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("failed to parse model %T: %w", model, err)
+		}
+		table := stmt.Schema.Table
+		modelTables[table] = true
+
+		if !live.HasTable(model) {
+			err := change(
+				func() error { return dryRun.CreateTable(model) },
+				func() error { return dryRun.DropTable(model) },
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate table %s: %w", table, err)
+			}
+		} else {
+			if err := diffColumns(live, dryRunDB, model, stmt.Schema, change, diff); err != nil {
+				return nil, err
+			}
+			for _, index := range stmt.Schema.ParseIndexes() {
+				if live.HasIndex(model, index.Name) {
+					continue
+				}
+				name := index.Name
+				err := change(
+					func() error { return dryRun.CreateIndex(model, name) },
+					func() error { return dryRun.DropIndex(model, name) },
+				)
+				if err != nil {
+					return nil, fmt.Errorf("failed to generate index %s: %w", name, err)
+				}
+			}
+		}
+
+		// Indexes declared in SQL are created for new tables too, as CreateTable doesn't know them
+		indexer, ok := model.(modelIndexes)
+		if !ok {
+			continue
+		}
+		for _, statement := range indexer.Indexes() {
+			match := indexNamePattern.FindStringSubmatch(statement)
+			if match == nil {
+				diff.Notes = append(diff.Notes, fmt.Sprintf("could not find the index name of %q on %s", statement, table))
+				continue
+			}
+			name := match[1]
+			if live.HasIndex(model, name) {
+				continue
+			}
+			err := change(
+				func() error { return recorder.exec(statement) },
+				func() error { return dryRun.DropIndex(model, name) },
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate index %s: %w", name, err)
+			}
+		}
+	}
+
+	tables, err := live.GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	for _, table := range tables {
+		// SQLite keeps its own bookkeeping in sqlite_ tables
+		if !modelTables[table] && !strings.HasPrefix(table, "sqlite_") {
+			diff.Notes = append(diff.Notes, fmt.Sprintf("table %s has no model", table))
+		}
+	}
+
+	// Undo the changes in reverse order
+	for i := len(downs) - 1; i >= 0; i-- {
+		diff.Down = append(diff.Down, downs[i]...)
+	}
+	return diff, nil
+}
+
+// diffColumns adds the model's missing columns and notes the columns the model doesn't have
+func diffColumns(live gorm.Migrator, dryRun *gorm.DB, model interface{}, s *schema.Schema, change func(up, down func() error) error, diff *SchemaDiff) error {
+	for _, dbName := range s.DBNames {
+		field := s.FieldsByDBName[dbName]
+		if field.IgnoreMigration || live.HasColumn(model, dbName) {
+			continue
+		}
+		err := change(
+			func() error { return dryRun.Migrator().AddColumn(model, dbName) },
+			// Written out as the SQLite migrator drops columns by rebuilding the table from the live schema
+			func() error {
+				return dryRun.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: s.Table}, clause.Column{Name: dbName}).Error
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to generate column %s.%s: %w", s.Table, dbName, err)
+		}
+	}
+
+	columns, err := live.ColumnTypes(model)
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", s.Table, err)
+	}
+	for _, column := range columns {
+		if _, ok := s.FieldsByDBName[column.Name()]; !ok {
+			diff.Notes = append(diff.Notes, fmt.Sprintf("column %s.%s has no model field", s.Table, column.Name()))
+		}
+	}
+	return nil
+}
+
+// statementRecorder is a GORM logger collecting the DDL of dry-run migrator calls
+type statementRecorder struct {
+	statements []string
+}
+
+// record returns the statements issued by fn
+func (r *statementRecorder) record(fn func() error) ([]string, error) {
+	r.statements = nil
+	if err := fn(); err != nil {
+		return nil, err
+	}
+	return r.statements, nil
+}
+
+// exec records a statement written by hand
+func (r *statementRecorder) exec(sql string) error {
+	r.statements = append(r.statements, sql)
+	return nil
+}
+
+func (r *statementRecorder) LogMode(logger.LogLevel) logger.Interface { return r }
+
+func (r *statementRecorder) Info(context.Context, string, ...interface{}) {}
+
+func (r *statementRecorder) Warn(context.Context, string, ...interface{}) {}
+
+func (r *statementRecorder) Error(context.Context, string, ...interface{}) {}
+
+// Trace records the statements; the migrators' own schema lookups bypass the dry run and are skipped
+func (r *statementRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	words := strings.Fields(sql)
+	if err != nil || len(words) == 0 {
+		return
+	}
+	if keyword := strings.ToUpper(words[0]); keyword == "SELECT" || keyword == "PRAGMA" {
+		return
+	}
+	r.statements = append(r.statements, sql)
+}
+
+// joinStatements joins SQL statements into a script
+func joinStatements(statements []string) string {
+	var sql strings.Builder
+	for i, statement := range statements {
+		if i > 0 {
+			sql.WriteString("\n")
+		}
+		sql.WriteString(strings.TrimSuffix(strings.TrimSpace(statement), ";") + ";\n")
+	}
+	return sql.String()
+}
@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+)
+
+// technicianSortColumns maps the sort_by filter values to technician list columns
+var technicianSortColumns = map[string]string{
+	"id":         "technicians.id",
+	"name":       "users.first_name",
+	"created_at": "technicians.created_at",
+	"updated_at": "technicians.updated_at",
+}
+
+// TechnicianRepository implements repositories.TechnicianRepository with GORM
+type TechnicianRepository struct {
+	db *gorm.DB
+}
+
+// NewTechnicianRepository creates a technician repository backed by db
+func NewTechnicianRepository(db *gorm.DB) *TechnicianRepository {
+	return &TechnicianRepository{
+		db: db,
+	}
+}
+
+// List implements repositories.TechnicianRepository
+func (r *TechnicianRepository) List(ctx context.Context, filter repositories.TechnicianFilter, page repositories.Page) ([]models.Technician, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Technician{}).
+		Joins("JOIN users ON users.id = technicians.user_id AND users.deleted_at IS NULL")
+	if len(filter.Statuses) > 0 {
+		query = query.Where("technicians.status IN ?", filter.Statuses)
+	}
+	if filter.Active != nil {
+		query = query.Where("users.active = ?", *filter.Active)
+	}
+	if filter.Search != "" {
+		search := "%" + strings.ToLower(filter.Search) + "%"
+		query = query.Where(
+			"LOWER(users.first_name) LIKE ? OR LOWER(users.last_name) LIKE ? OR LOWER(users.email) LIKE ? OR technicians.phone_number LIKE ?",
+			search, search, search, search,
+		)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	orderColumn := "technicians.created_at"
+	if column, exists := technicianSortColumns[filter.SortBy]; exists {
+		orderColumn = column
+	}
+	orderDirection := "ASC"
+	if filter.SortDesc || filter.SortBy == "" {
+		orderDirection = "DESC"
+	}
+
+	var technicians []models.Technician
+	if err := query.
+		Preload("User.Role").
+		Order(fmt.Sprintf("%s %s", orderColumn, orderDirection)).
+		Offset(page.Offset()).
+		Limit(page.PageSize).
+		Find(&technicians).Error; err != nil {
+		return nil, 0, err
+	}
+	return technicians, total, nil
+}
+
+// FindByID implements repositories.TechnicianRepository
+func (r *TechnicianRepository) FindByID(ctx context.Context, id uint) (*models.Technician, error) {
+	return r.find(ctx, "id = ?", id)
+}
+
+// FindByUserID implements repositories.TechnicianRepository
+func (r *TechnicianRepository) FindByUserID(ctx context.Context, userID uint) (*models.Technician, error) {
+	return r.find(ctx, "user_id = ?", userID)
+}
+
+// ExistsForUser implements repositories.TechnicianRepository
+func (r *TechnicianRepository) ExistsForUser(ctx context.Context, userID uint) (bool, error) {
+	// user_id is unique across all rows, including soft-deleted ones
+	var count int64
+	if err := r.db.WithContext(ctx).Unscoped().Model(&models.Technician{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Create implements repositories.TechnicianRepository
+func (r *TechnicianRepository) Create(ctx context.Context, technician *models.Technician) error {
+	return r.db.WithContext(ctx).Omit("User").Create(technician).Error
+}
+
+// Update implements repositories.TechnicianRepository
+func (r *TechnicianRepository) Update(ctx context.Context, id uint, update repositories.TechnicianUpdate) error {
+	updates := make(map[string]interface{})
+	if update.Status != nil {
+		updates["status"] = *update.Status
+	}
+	if update.PhoneNumber != nil {
+		updates["phone_number"] = *update.PhoneNumber
+	}
+	if update.Notes != nil {
+		updates["notes"] = *update.Notes
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return requireAffected(r.db.WithContext(ctx).Model(&models.Technician{}).Where("id = ?", id).Updates(updates))
+}
+
+// find returns the first technician matching the condition with its user and role
+func (r *TechnicianRepository) find(ctx context.Context, condition string, value uint) (*models.Technician, error) {
+	var technician models.Technician
+	if err := r.db.WithContext(ctx).Preload("User.Role").Where(condition, value).First(&technician).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &technician, nil
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+)
+
+// UserRepository implements repositories.UserRepository with GORM
+type UserRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository creates a user repository backed by db
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{
+		db: db,
+	}
+}
+
+// FindByID implements repositories.UserRepository
+func (r *UserRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Preload("Role").Where("id = ?", id).First(&user).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+// FindByEmail implements repositories.UserRepository
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Preload("Role").Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+// Create implements repositories.UserRepository
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	return translateError(r.db.WithContext(ctx).Omit("Role", "Technician").Create(user).Error)
+}
+
+// Update implements repositories.UserRepository
+func (r *UserRepository) Update(ctx context.Context, id uint, update repositories.UserUpdate) error {
+	updates := make(map[string]interface{})
+	if update.FirstName != nil {
+		updates["first_name"] = *update.FirstName
+	}
+	if update.LastName != nil {
+		updates["last_name"] = *update.LastName
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return requireAffected(r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Updates(updates))
+}
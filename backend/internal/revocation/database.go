@@ -0,0 +1,54 @@
+package revocation
+
+import (
+	"context"
+	"time"
+
+	"routrapp-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DatabaseStore keeps the revocations in the revoked_tokens table, so a token revoked on one
+// replica is rejected by every other
+type DatabaseStore struct {
+	db *gorm.DB
+}
+
+// NewDatabaseStore creates a store on the database
+func NewDatabaseStore(db *gorm.DB) *DatabaseStore {
+	return &DatabaseStore{db: db}
+}
+
+// Revoke implements Store. The times only move forward in a single upsert, so concurrent
+// revocations by several replicas don't undo each other.
+func (s *DatabaseStore) Revoke(ctx context.Context, key string, notBefore, expiresAt time.Time) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"not_before": gorm.Expr("CASE WHEN revoked_tokens.not_before < ? THEN ? ELSE revoked_tokens.not_before END", notBefore, notBefore),
+			"expires_at": gorm.Expr("CASE WHEN revoked_tokens.expires_at < ? THEN ? ELSE revoked_tokens.expires_at END", expiresAt, expiresAt),
+		}),
+	}).Create(&models.RevokedToken{Key: key, NotBefore: notBefore, ExpiresAt: expiresAt}).Error
+}
+
+// NotBefore implements Store
+func (s *DatabaseStore) NotBefore(ctx context.Context, now time.Time, keys ...string) (time.Time, error) {
+	var revoked []models.RevokedToken
+	if err := s.db.WithContext(ctx).Where("key IN ? AND expires_at > ?", keys, now).Find(&revoked).Error; err != nil {
+		return time.Time{}, err
+	}
+	var notBefore time.Time
+	for _, r := range revoked {
+		if r.NotBefore.After(notBefore) {
+			notBefore = r.NotBefore
+		}
+	}
+	return notBefore, nil
+}
+
+// Prune implements Store
+func (s *DatabaseStore) Prune(ctx context.Context, before time.Time) error {
+	return s.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.RevokedToken{}).Error
+}
@@ -0,0 +1,65 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// entry is a revocation kept by the MemoryStore
+type entry struct {
+	notBefore time.Time
+	expiresAt time.Time
+}
+
+// MemoryStore keeps the revocations in the process. A token revoked on one replica stays valid on
+// the others, so deployments with several replicas should use the DatabaseStore.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]entry)}
+}
+
+// Revoke implements Store
+func (s *MemoryStore) Revoke(ctx context.Context, key string, notBefore, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.entries[key]
+	if notBefore.After(e.notBefore) {
+		e.notBefore = notBefore
+	}
+	if expiresAt.After(e.expiresAt) {
+		e.expiresAt = expiresAt
+	}
+	s.entries[key] = e
+	return nil
+}
+
+// NotBefore implements Store
+func (s *MemoryStore) NotBefore(ctx context.Context, now time.Time, keys ...string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var notBefore time.Time
+	for _, key := range keys {
+		if e, ok := s.entries[key]; ok && e.expiresAt.After(now) && e.notBefore.After(notBefore) {
+			notBefore = e.notBefore
+		}
+	}
+	return notBefore, nil
+}
+
+// Prune implements Store
+func (s *MemoryStore) Prune(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, e := range s.entries {
+		if e.expiresAt.Before(before) {
+			delete(s.entries, key)
+		}
+	}
+	return nil
+}
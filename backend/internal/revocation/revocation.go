@@ -0,0 +1,151 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"routrapp-api/internal/config"
+	"routrapp-api/internal/utils/auth"
+	"routrapp-api/internal/utils/constants"
+
+	"gorm.io/gorm"
+)
+
+// Store keeps the revocations. Keys are built with TokenKey, SessionKey and UserKey.
+type Store interface {
+	// Revoke revokes the tokens of key issued up to notBefore and keeps the revocation until
+	// expiresAt. Revoking a key again keeps the later of both times.
+	Revoke(ctx context.Context, key string, notBefore, expiresAt time.Time) error
+	// NotBefore returns the latest time up to which tokens of the keys are revoked, ignoring the
+	// revocations that expired at now. It returns the zero time when none of the keys is revoked.
+	NotBefore(ctx context.Context, now time.Time, keys ...string) (time.Time, error)
+	// Prune forgets the revocations that expired before the given time
+	Prune(ctx context.Context, before time.Time) error
+}
+
+// TokenKey returns the key revoking a single token by its ID (jti)
+func TokenKey(tokenID string) string {
+	return "jti:" + tokenID
+}
+
+// SessionKey returns the key revoking the tokens of a user session
+func SessionKey(familyID string) string {
+	return "sid:" + familyID
+}
+
+// UserKey returns the key revoking the tokens of a user
+func UserKey(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// List is the deny list of access tokens. Access tokens are stateless, so signing out, changing a
+// password or role or deactivating a user revokes the tokens issued before that on the list until
+// they expire. Revocations outlive the tokens they cover by no more than the access token lifetime.
+// A nil *List is valid and revokes nothing.
+type List struct {
+	store    Store
+	now      func() time.Time
+	lifetime time.Duration // lifetime of access tokens
+
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+// New creates a list with the store selected by the configuration
+func New(cfg config.RevocationConfig, db *gorm.DB) (*List, error) {
+	switch cfg.Store {
+	case "", "memory":
+		return NewList(NewMemoryStore()), nil
+	case "database":
+		return NewList(NewDatabaseStore(db)), nil
+	default:
+		return nil, fmt.Errorf("unsupported revocation store %q", cfg.Store)
+	}
+}
+
+// NewList creates a list keeping its revocations in store
+func NewList(store Store) *List {
+	return NewListWithClock(store, time.Now)
+}
+
+// NewListWithClock creates a list reading the current time from now
+func NewListWithClock(store Store, now func() time.Time) *List {
+	return &List{
+		store:    store,
+		now:      now,
+		lifetime: time.Duration(constants.JWT_ACCESS_TOKEN_EXPIRY) * time.Second,
+	}
+}
+
+// RevokeToken revokes a single token until it expires
+func (l *List) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	if l == nil || tokenID == "" {
+		return nil
+	}
+	l.prune(ctx, l.now())
+	return l.store.Revoke(ctx, TokenKey(tokenID), expiresAt, expiresAt)
+}
+
+// RevokeSession revokes every access token of a user session. The session itself is revoked in
+// the database, so no new token is issued for it and the revocation covers all of its tokens.
+func (l *List) RevokeSession(ctx context.Context, familyID string) error {
+	if l == nil || familyID == "" {
+		return nil
+	}
+	now := l.now()
+	l.prune(ctx, now)
+	expiresAt := now.Add(l.lifetime)
+	return l.store.Revoke(ctx, SessionKey(familyID), expiresAt, expiresAt)
+}
+
+// RevokeUser revokes the access tokens issued to a user until now, while the tokens of later
+// logins stay valid. Tokens carry their issue time in seconds, so the revocation covers the whole
+// current second: tokens issued within that second, even just after the revocation, are refused.
+func (l *List) RevokeUser(ctx context.Context, userID uint) error {
+	if l == nil {
+		return nil
+	}
+	now := l.now()
+	l.prune(ctx, now)
+	return l.store.Revoke(ctx, UserKey(userID), now.Truncate(time.Second), now.Add(l.lifetime))
+}
+
+// IsRevoked reports whether the token was revoked by its ID, its session or its user
+func (l *List) IsRevoked(ctx context.Context, claims *auth.JWTClaims) (bool, error) {
+	if l == nil {
+		return false, nil
+	}
+	keys := []string{UserKey(claims.UserID)}
+	if claims.ID != "" {
+		keys = append(keys, TokenKey(claims.ID))
+	}
+	if claims.FamilyID != "" {
+		keys = append(keys, SessionKey(claims.FamilyID))
+	}
+
+	notBefore, err := l.store.NotBefore(ctx, l.now(), keys...)
+	if err != nil || notBefore.IsZero() {
+		return false, err
+	}
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	return !issuedAt.After(notBefore), nil
+}
+
+// prune forgets expired revocations at most once per access token lifetime
+func (l *List) prune(ctx context.Context, now time.Time) {
+	l.mu.Lock()
+	if now.Sub(l.lastPruned) < l.lifetime {
+		l.mu.Unlock()
+		return
+	}
+	l.lastPruned = now
+	l.mu.Unlock()
+
+	// Pruning is housekeeping; a failure leaves the expired revocations for the next run
+	_ = l.store.Prune(ctx, now)
+}
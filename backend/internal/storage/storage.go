@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"routrapp-api/internal/config"
+)
+
+// Storage errors
+var (
+	ErrNotFound   = errors.New("storage: object not found")
+	ErrInvalidKey = errors.New("storage: invalid object key")
+)
+
+// Storage stores and retrieves blobs by key. Keys are slash separated relative paths,
+// e.g. "org/1/stops/2/abc.jpg".
+type Storage interface {
+	// Put stores the blob read from r under key, replacing any existing blob, and returns the bytes written
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Open returns a reader for the blob stored under key, or ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob stored under key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// New creates the storage backend selected by the configuration
+func New(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalStorage(cfg.LocalPath)
+	default:
+		return nil, fmt.Errorf("unsupported storage driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,43 @@
+package tenant
+
+import "context"
+
+// contextKey is the type of the context keys owned by this package
+type contextKey int
+
+const (
+	organizationKey contextKey = iota
+	systemKey
+)
+
+// WithOrganization returns a context whose database queries are scoped to the organization
+func WithOrganization(ctx context.Context, organizationID uint) context.Context {
+	return context.WithValue(ctx, organizationKey, organizationID)
+}
+
+// OrganizationFromContext returns the organization the context is scoped to
+func OrganizationFromContext(ctx context.Context) (uint, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	organizationID, ok := ctx.Value(organizationKey).(uint)
+	return organizationID, ok && organizationID != 0
+}
+
+// WithoutScope returns a context whose database queries are not scoped to any organization.
+// It is the escape hatch for system jobs that legitimately work across tenants (migrations,
+// scheduled maintenance, resolving a login before the tenant is known); request handlers
+// should use WithOrganization instead.
+func WithoutScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemKey, true)
+}
+
+// IsUnscoped reports whether the context was created by WithoutScope.
+// An organization set on the context takes precedence over the escape hatch.
+func IsUnscoped(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	unscoped, _ := ctx.Value(systemKey).(bool)
+	return unscoped
+}
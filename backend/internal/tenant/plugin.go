@@ -0,0 +1,140 @@
+package tenant
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"routrapp-api/internal/models"
+)
+
+var (
+	// ErrMissingTenant is returned for statements on tenant-scoped models whose context carries no organization
+	ErrMissingTenant = errors.New("tenant: no organization in context for tenant-scoped statement")
+
+	// ErrTenantMismatch is returned when creating a record that belongs to another organization than the context
+	ErrTenantMismatch = errors.New("tenant: record belongs to another organization than the context")
+)
+
+// Plugin scopes every statement on models embedding models.Base to the organization carried by the
+// statement's context. Queries, updates and deletes get an organization_id predicate, creates get their
+// organization set, and statements without an organization fail with ErrMissingTenant unless the
+// context was created by WithoutScope. Raw SQL and statements without a model are not inspected.
+type Plugin struct {
+	tenantModels sync.Map // reflect.Type -> bool
+}
+
+// NewPlugin creates the tenant scoping plugin; register it with db.Use
+func NewPlugin() *Plugin {
+	return &Plugin{}
+}
+
+// Name implements gorm.Plugin
+func (p *Plugin) Name() string {
+	return "tenant"
+}
+
+// Initialize implements gorm.Plugin by registering the scoping callbacks
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("tenant:assign", p.assign); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:scope", p.scope); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenant:scope", p.scope); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:scope", p.scope); err != nil {
+		return err
+	}
+	return callbacks.Delete().Before("gorm:delete").Register("tenant:scope", p.scope)
+}
+
+// scope adds the organization predicate to queries, updates and deletes
+func (p *Plugin) scope(db *gorm.DB) {
+	field, ok := p.tenantField(db)
+	if !ok {
+		return
+	}
+
+	organizationID, ok := OrganizationFromContext(db.Statement.Context)
+	if !ok {
+		if !IsUnscoped(db.Statement.Context) {
+			db.AddError(ErrMissingTenant)
+		}
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: organizationID},
+	}})
+}
+
+// assign sets the organization on records being created, rejecting records of other organizations
+func (p *Plugin) assign(db *gorm.DB) {
+	field, ok := p.tenantField(db)
+	if !ok {
+		return
+	}
+
+	organizationID, ok := OrganizationFromContext(db.Statement.Context)
+	if !ok {
+		if !IsUnscoped(db.Statement.Context) {
+			db.AddError(ErrMissingTenant)
+		}
+		return
+	}
+
+	ctx := db.Statement.Context
+	assignRecord := func(record reflect.Value) {
+		value, zero := field.ValueOf(ctx, record)
+		if zero {
+			if err := field.Set(ctx, record, organizationID); err != nil {
+				db.AddError(err)
+			}
+			return
+		}
+		if id, ok := value.(uint); !ok || id != organizationID {
+			db.AddError(ErrTenantMismatch)
+		}
+	}
+
+	switch db.Statement.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < db.Statement.ReflectValue.Len(); i++ {
+			record := reflect.Indirect(db.Statement.ReflectValue.Index(i))
+			if record.Kind() == reflect.Struct {
+				assignRecord(record)
+			}
+		}
+	case reflect.Struct:
+		assignRecord(db.Statement.ReflectValue)
+	}
+}
+
+// tenantField returns the organization field of the statement's model when it embeds models.Base
+func (p *Plugin) tenantField(db *gorm.DB) (*schema.Field, bool) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil, false
+	}
+
+	modelType := db.Statement.Schema.ModelType
+	scoped, cached := p.tenantModels.Load(modelType)
+	if !cached {
+		base, ok := modelType.FieldByName("Base")
+		scoped = ok && base.Anonymous && base.Type == reflect.TypeOf(models.Base{})
+		p.tenantModels.Store(modelType, scoped)
+	}
+	if !scoped.(bool) {
+		return nil, false
+	}
+
+	field := db.Statement.Schema.LookUpField("OrganizationID")
+	return field, field != nil
+}
@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	"routrapp-api/internal/api"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AttachmentTestContext holds dependencies for attachment endpoint tests
+type AttachmentTestContext struct {
+	*TestContext
+	StorageDir        string
+	Storage           *storage.LocalStorage
+	Signer            *storage.URLSigner
+	AttachmentHandler *api.AttachmentHandler
+}
+
+// SetupAttachmentTestContext creates a test context with the route, attachment and proof-of-service
+// endpoints registered, storing blobs in a temporary directory
+func SetupAttachmentTestContext(options api.AttachmentOptions) (*AttachmentTestContext, error) {
+	ctx, err := SetupTestContext()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "routrapp-attachments-*")
+	if err != nil {
+		return nil, err
+	}
+	store, err := storage.NewLocalStorage(dir)
+	if err != nil {
+		return nil, err
+	}
+	signer := storage.NewURLSigner("test-signing-secret", 15*time.Minute)
+	attachmentHandler := api.NewAttachmentHandler(ctx.DB, store, signer, options)
+	proofHandler := api.NewProofHandler(ctx.DB, attachmentHandler, nil)
+	stopChecklistHandler := api.NewStopChecklistHandler(ctx.DB)
+
+	registerRouteEndpoints(ctx, api.NewRouteHandler(ctx.DB))
+	attachments := ctx.Router.Group("/api/v1/routes")
+	attachments.Use(middleware.AuthMiddlewareWithJWT(ctx.JWTService))
+	{
+		attachments.GET("/:id/stops/:stop_id/attachments", middleware.RequirePermission("routes.read"), attachmentHandler.ListStopAttachments)
+		attachments.POST("/:id/stops/:stop_id/attachments", middleware.RequirePermission("routes.update_status"), attachmentHandler.UploadStopAttachment)
+		attachments.DELETE("/:id/stops/:stop_id/attachments/:attachment_id", middleware.RequirePermission("routes.update"), attachmentHandler.DeleteStopAttachment)
+		attachments.POST("/:id/stops/:stop_id/complete", middleware.RequirePermission("routes.update_status"), proofHandler.CompleteStop)
+		attachments.GET("/:id/stops/:stop_id/proof", middleware.RequirePermission("routes.read"), proofHandler.GetStopProof)
+	}
+	stopChecklists := ctx.Router.Group("/api/v1/stop-checklists")
+	stopChecklists.Use(middleware.AuthMiddlewareWithJWT(ctx.JWTService))
+	{
+		stopChecklists.GET("", middleware.RequirePermission("routes.read"), stopChecklistHandler.ListStopChecklists)
+		stopChecklists.GET("/:stop_type", middleware.RequirePermission("routes.read"), stopChecklistHandler.GetStopChecklist)
+		stopChecklists.PUT("/:stop_type", middleware.RequirePermission("organizations.update"), stopChecklistHandler.PutStopChecklist)
+		stopChecklists.DELETE("/:stop_type", middleware.RequirePermission("organizations.update"), stopChecklistHandler.DeleteStopChecklist)
+	}
+	ctx.Router.GET("/api/v1/attachments/:id/content", attachmentHandler.DownloadAttachment)
+
+	return &AttachmentTestContext{
+		TestContext:       ctx,
+		StorageDir:        dir,
+		Storage:           store,
+		Signer:            signer,
+		AttachmentHandler: attachmentHandler,
+	}, nil
+}
+
+// CleanupAttachmentTestContext closes the database and removes the temporary storage directory
+func CleanupAttachmentTestContext(ctx *AttachmentTestContext) error {
+	if err := os.RemoveAll(ctx.StorageDir); err != nil {
+		return err
+	}
+	return CleanupTestContext(ctx.TestContext)
+}
+
+// MakeMultipartRequest uploads content as the "file" form field with an optional Bearer token
+func MakeMultipartRequest(router *gin.Engine, path, token, fileName string, content []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if content != nil {
+		part, _ := writer.CreateFormFile("file", fileName)
+		part.Write(content)
+	}
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// BuildTestJPEG returns a minimal JPEG whose EXIF block carries the capture time and GPS position
+func BuildTestJPEG(capturedAt time.Time, lat, lng float64) []byte {
+	order := binary.LittleEndian
+	tiff := &bytes.Buffer{}
+	write := func(values ...interface{}) {
+		for _, value := range values {
+			binary.Write(tiff, order, value)
+		}
+	}
+	entry := func(tag, typ uint16, count, value uint32) {
+		write(tag, typ, count, value)
+	}
+	rationals := func(value float64) []uint32 {
+		value = math.Abs(value)
+		degrees := math.Floor(value)
+		minutes := math.Floor((value - degrees) * 60)
+		seconds := ((value-degrees)*60 - minutes) * 60
+		return []uint32{uint32(degrees), 1, uint32(minutes), 1, uint32(math.Round(seconds * 10000)), 10000}
+	}
+	ref := func(value float64, positive, negative byte) uint32 {
+		if value < 0 {
+			return uint32(negative)
+		}
+		return uint32(positive)
+	}
+
+	const (
+		ifd0Offset    = 8
+		exifIFDOffset = ifd0Offset + 2 + 2*12 + 4
+		dateOffset    = exifIFDOffset + 2 + 12 + 4
+		gpsIFDOffset  = dateOffset + 20
+		latOffset     = gpsIFDOffset + 2 + 4*12 + 4
+		lngOffset     = latOffset + 24
+	)
+
+	tiff.WriteString("II")
+	write(uint16(42), uint32(ifd0Offset))
+
+	write(uint16(2))
+	entry(0x8769, 4, 1, exifIFDOffset)
+	entry(0x8825, 4, 1, gpsIFDOffset)
+	write(uint32(0))
+
+	write(uint16(1))
+	entry(0x9003, 2, 20, dateOffset)
+	write(uint32(0))
+	tiff.WriteString(capturedAt.UTC().Format("2006:01:02 15:04:05") + "\x00")
+
+	write(uint16(4))
+	entry(0x0001, 2, 2, ref(lat, 'N', 'S'))
+	entry(0x0002, 5, 3, latOffset)
+	entry(0x0003, 2, 2, ref(lng, 'E', 'W'))
+	entry(0x0004, 5, 3, lngOffset)
+	write(uint32(0))
+	write(rationals(lat), rationals(lng))
+
+	app1 := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	jpeg := &bytes.Buffer{}
+	jpeg.Write([]byte{0xFF, 0xD8, 0xFF, 0xE1})
+	binary.Write(jpeg, binary.BigEndian, uint16(len(app1)+2))
+	jpeg.Write(app1)
+	jpeg.Write([]byte{0xFF, 0xDA, 0x00, 0x02, 0x00, 0xFF, 0xD9})
+	return jpeg.Bytes()
+}
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -9,8 +10,14 @@ import (
 	"time"
 
 	"routrapp-api/internal/api"
+	"routrapp-api/internal/lockout"
+	"routrapp-api/internal/mailer"
+	"routrapp-api/internal/middleware"
 	"routrapp-api/internal/models"
+	"routrapp-api/internal/revocation"
+	"routrapp-api/internal/tenant"
 	"routrapp-api/internal/utils/auth"
+	"routrapp-api/internal/utils/constants"
 	"routrapp-api/internal/validation"
 
 	"github.com/gin-gonic/gin"
@@ -26,9 +33,14 @@ type TestContext struct {
 	Router      *gin.Engine
 	AuthHandler *api.AuthHandler
 	JWTService  *auth.JWTService
+	Outbox      *mailer.Outbox   // emails sent by the auth handler
+	MailQueue   *mailer.Queue    // emails the auth handler sends in the background; flush it before reading the outbox
+	Revocations *revocation.List // access tokens revoked by the handlers
 }
 
-// SetupTestDB creates an in-memory SQLite database for testing
+// SetupTestDB creates an in-memory SQLite database for testing with the tenant scoping plugin installed.
+// The returned handle is unscoped so tests can seed fixtures in any organization; handlers rebind it to
+// the request context, which scopes their queries to the caller's organization.
 func SetupTestDB() (*gorm.DB, error) {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
 		Logger: logger.Discard,
@@ -36,6 +48,9 @@ func SetupTestDB() (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := db.Use(tenant.NewPlugin()); err != nil {
+		return nil, err
+	}
 
 	// Auto-migrate all models
 	err = db.AutoMigrate(
@@ -45,16 +60,32 @@ func SetupTestDB() (*gorm.DB, error) {
 		&models.Technician{},
 		&models.Route{},
 		&models.RouteStop{},
+		&models.RouteActivity{},
+		&models.TechnicianLocation{},
+		&models.StopAttachment{},
+		&models.StopChecklist{},
+		&models.StopProof{},
+		&models.UserSession{},
+		&models.UserToken{},
+		&models.UserRecoveryCode{},
+		&models.LoginAttempt{},
+		&models.AccountLockout{},
+		&models.RevokedToken{},
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	return db, nil
+	return db.WithContext(tenant.WithoutScope(context.Background())), nil
 }
 
 // SetupTestContext creates a complete test context with database, router, and handlers
 func SetupTestContext() (*TestContext, error) {
+	return SetupTestContextWithLockout(nil)
+}
+
+// SetupTestContextWithLockout creates a test context whose failed logins are throttled by the guard
+func SetupTestContextWithLockout(guard *lockout.Guard) (*TestContext, error) {
 	db, err := SetupTestDB()
 	if err != nil {
 		return nil, err
@@ -69,10 +100,21 @@ func SetupTestContext() (*TestContext, error) {
 	// Create JWT service with test secret
 	jwtService := auth.NewJWTService("test-secret-key")
 	
-	// Create auth handler using the constructor
-	authHandler := api.NewAuthHandler(db)
+	// Create auth handler keeping its emails in memory
+	outbox, err := mailer.NewOutbox("")
+	if err != nil {
+		return nil, err
+	}
+	mailQueue := mailer.NewQueue(outbox, constants.MailQueueSize, constants.MailSendTimeout)
+	authHandler := api.NewAuthHandlerWithMailer(db, jwtService, outbox, api.AuthOptions{
+		LinkBaseURL: "http://localhost:3000",
+		Lockout:     guard,
+		MailQueue:   mailQueue,
+	})
 
+	revocations := revocation.NewList(revocation.NewMemoryStore())
 	router := gin.New()
+	router.Use(middleware.RevocationMiddleware(revocations))
 
 	// Setup auth routes
 	authGroup := router.Group("/api/v1/auth")
@@ -81,91 +123,46 @@ func SetupTestContext() (*TestContext, error) {
 		authGroup.POST("/register-user", authHandler.Register)                                      // POST /api/v1/auth/register-user (user registration to existing org)
 		authGroup.POST("/login", authHandler.Login)                                                    // POST /api/v1/auth/login
 		authGroup.POST("/refresh", authHandler.RefreshToken)                                           // POST /api/v1/auth/refresh
-		authGroup.GET("/me", CreateTestAuthMiddleware(jwtService), authHandler.GetCurrentUser)         // GET /api/v1/auth/me (requires auth)
-		authGroup.POST("/logout", CreateTestAuthMiddleware(jwtService), authHandler.Logout)            // POST /api/v1/auth/logout (requires auth)
-		authGroup.POST("/change-password", CreateTestAuthMiddleware(jwtService), authHandler.ChangePassword) // POST /api/v1/auth/change-password (requires auth)
-	}
+		authGroup.POST("/forgot-password", authHandler.ForgotPassword)                                 // POST /api/v1/auth/forgot-password
+		authGroup.POST("/reset-password", authHandler.ResetPassword)                                   // POST /api/v1/auth/reset-password
+		authGroup.POST("/verify-email", authHandler.VerifyEmail)                                       // POST /api/v1/auth/verify-email
+		authGroup.POST("/resend-verification", middleware.AuthMiddlewareWithJWT(jwtService), authHandler.ResendVerificationEmail) // POST /api/v1/auth/resend-verification (requires auth)
+		authGroup.GET("/me", middleware.AuthMiddlewareWithJWT(jwtService), authHandler.GetCurrentUser)         // GET /api/v1/auth/me (requires auth)
+		authGroup.POST("/logout", middleware.AuthMiddlewareWithJWT(jwtService), authHandler.Logout)            // POST /api/v1/auth/logout (requires auth)
+		authGroup.POST("/change-password", middleware.AuthMiddlewareWithJWT(jwtService), authHandler.ChangePassword) // POST /api/v1/auth/change-password (requires auth)
+		authGroup.GET("/sessions", middleware.AuthMiddlewareWithJWT(jwtService), authHandler.ListSessions)           // GET /api/v1/auth/sessions (requires auth)
+		authGroup.DELETE("/sessions", middleware.AuthMiddlewareWithJWT(jwtService), authHandler.RevokeAllSessions)   // DELETE /api/v1/auth/sessions (requires auth)
+		authGroup.DELETE("/sessions/:id", middleware.AuthMiddlewareWithJWT(jwtService), authHandler.RevokeSession)   // DELETE /api/v1/auth/sessions/:id (requires auth)
+		authGroup.POST("/mfa/verify", authHandler.VerifyMFA)                                                 // POST /api/v1/auth/mfa/verify
+		authGroup.POST("/mfa/enroll", authHandler.EnrollMFA)                                                 // POST /api/v1/auth/mfa/enroll
+		authGroup.POST("/mfa/setup", middleware.AuthMiddlewareWithJWT(jwtService), authHandler.SetupMFA)             // POST /api/v1/auth/mfa/setup (requires auth)
+		authGroup.POST("/mfa/enable", middleware.AuthMiddlewareWithJWT(jwtService), authHandler.EnableMFA)           // POST /api/v1/auth/mfa/enable (requires auth)
+		authGroup.POST("/mfa/disable", middleware.AuthMiddlewareWithJWT(jwtService), authHandler.DisableMFA)         // POST /api/v1/auth/mfa/disable (requires auth)
+		authGroup.POST("/mfa/recovery-codes", middleware.AuthMiddlewareWithJWT(jwtService), authHandler.RegenerateRecoveryCodes) // POST /api/v1/auth/mfa/recovery-codes (requires auth)
+		authGroup.GET("/mfa/policy", middleware.AuthMiddlewareWithJWT(jwtService), middleware.RequirePermission("organizations.read"), authHandler.GetMFAPolicy)   // GET /api/v1/auth/mfa/policy
+		authGroup.PUT("/mfa/policy", middleware.AuthMiddlewareWithJWT(jwtService), middleware.RequirePermission("organizations.update"), authHandler.UpdateMFAPolicy) // PUT /api/v1/auth/mfa/policy
+	}
+
+	// User administration checks the stored permissions, as in production, so that custom roles can be tested
+	userAdmin := middleware.PermissionCheckerMiddleware(middleware.NewDBPermissionChecker(db, time.Minute))
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)                                                                                                  // GET /.well-known/jwks.json
+	router.DELETE("/api/v1/users/:id/mfa", middleware.AuthMiddlewareWithJWT(jwtService), userAdmin, middleware.RequirePermission("users.update"), authHandler.ResetUserMFA) // DELETE /api/v1/users/:id/mfa
+	router.POST("/api/v1/users/:id/unlock", middleware.AuthMiddlewareWithJWT(jwtService), userAdmin, middleware.RequirePermission("users.update"), authHandler.UnlockUser)     // POST /api/v1/users/:id/unlock
+	router.GET("/api/v1/users/:id/lockouts", middleware.AuthMiddlewareWithJWT(jwtService), userAdmin, middleware.RequirePermission("users.read"), authHandler.ListUserLockouts) // GET /api/v1/users/:id/lockouts
+	router.POST("/api/v1/users/:id/deactivate", middleware.AuthMiddlewareWithJWT(jwtService), userAdmin, middleware.RequirePermission("users.update"), authHandler.DeactivateUser) // POST /api/v1/users/:id/deactivate
+	router.POST("/api/v1/users/:id/activate", middleware.AuthMiddlewareWithJWT(jwtService), userAdmin, middleware.RequirePermission("users.update"), authHandler.ActivateUser) // POST /api/v1/users/:id/activate
 
 	return &TestContext{
 		DB:          db,
 		Router:      router,
 		AuthHandler: authHandler,
 		JWTService:  jwtService,
+		Outbox:      outbox,
+		MailQueue:   mailQueue,
+		Revocations: revocations,
 	}, nil
 }
 
-// CreateTestAuthMiddleware creates an auth middleware that uses the test JWT service
-func CreateTestAuthMiddleware(jwtService *auth.JWTService) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Extract token from Authorization header
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": map[string]interface{}{
-					"code":    401,
-					"message": "Authorization header is required",
-					"details": map[string]interface{}{
-						"code": "MISSING_AUTH_HEADER",
-					},
-				},
-			})
-			return
-		}
-
-		// Extract the token from Bearer prefix
-		tokenString, err := auth.ExtractTokenFromHeader(authHeader)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": map[string]interface{}{
-					"code":    401,
-					"message": "Invalid authorization header format: " + err.Error(),
-					"details": map[string]interface{}{
-						"code": "INVALID_TOKEN",
-					},
-				},
-			})
-			return
-		}
-
-		// Validate the token
-		claims, err := jwtService.ValidateToken(tokenString)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": map[string]interface{}{
-					"code":    401,
-					"message": "Invalid or expired token: " + err.Error(),
-					"details": map[string]interface{}{
-						"code": "INVALID_TOKEN",
-					},
-				},
-			})
-			return
-		}
-
-		// Ensure this is an access token (not a refresh token)
-		if !claims.IsAccessToken() {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": map[string]interface{}{
-					"code":    401,
-					"message": "Access token required",
-					"details": map[string]interface{}{
-						"code": "INVALID_TOKEN_TYPE",
-					},
-				},
-			})
-			return
-		}
-
-		// Set user context in Gin context
-		c.Set("user_id", claims.UserID)
-		c.Set("organization_id", claims.OrganizationID)
-		c.Set("user_email", claims.Email)
-		c.Set("user_role", claims.Role)
-
-		c.Next()
-	}
-}
-
 // CreateTestOrganization creates a test organization
 func CreateTestOrganization(db *gorm.DB) (*models.Organization, error) {
 	org := &models.Organization{
@@ -196,6 +193,25 @@ func CreateTestRole(db *gorm.DB, orgID uint, roleType models.RoleType) (*models.
 	return role, err
 }
 
+// CreateTestRoleWithPermissions creates a custom test role granting the given permissions
+func CreateTestRoleWithPermissions(db *gorm.DB, orgID uint, name string, permissions []string) (*models.Role, error) {
+	role := &models.Role{
+		Base: models.Base{
+			OrganizationID: orgID,
+		},
+		Name:        models.RoleType(name),
+		DisplayName: name,
+		Description: "Test " + name + " role",
+		Active:      true,
+	}
+	if err := role.SetPermissions(permissions); err != nil {
+		return nil, err
+	}
+
+	err := db.Create(role).Error
+	return role, err
+}
+
 // CreateTestUser creates a test user with hashed password
 func CreateTestUser(db *gorm.DB, orgID, roleID uint, email, password string, active bool) (*models.User, error) {
 	hashedPassword, err := auth.HashPassword(password)
@@ -414,6 +430,12 @@ func GenerateExpiredToken(jwtService *auth.JWTService, userID, orgID uint, email
 	return jwtService.GenerateRefreshToken(userID, orgID, email, role)
 }
 
+// WaitForNextSecond waits until the second in which a user's tokens were revoked passed. Tokens
+// carry their issue time in seconds, so those issued within that second are revoked too.
+func WaitForNextSecond() {
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+}
+
 // AssertResponseSuccess checks if a response indicates success
 func AssertResponseSuccess(w *httptest.ResponseRecorder, expectedStatus int) bool {
 	if w.Code != expectedStatus {
@@ -456,6 +478,11 @@ func AssertResponseError(w *httptest.ResponseRecorder, expectedStatus int, expec
 
 // CleanupTestContext cleans up test database
 func CleanupTestContext(ctx *TestContext) error {
+	if ctx.MailQueue != nil {
+		if err := ctx.MailQueue.Close(context.Background()); err != nil {
+			return err
+		}
+	}
 	if ctx.DB != nil {
 		sqlDB, err := ctx.DB.DB()
 		if err != nil {
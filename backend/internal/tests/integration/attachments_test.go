@@ -0,0 +1,247 @@
+package integration
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/api"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/validation"
+)
+
+func TestAttachmentHandler_StopUploads(t *testing.T) {
+	ctx, err := tests.SetupAttachmentTestContext(api.AttachmentOptions{
+		MaxUploadSize: 64 << 10,
+		DefaultQuota:  1 << 20,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupAttachmentTestContext(ctx)
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "Password123!", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	ownerToken, err := tests.GenerateTestAccessToken(ctx.TestContext, owner)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	orgID := owner.Organization.ID
+	techRole, err := tests.CreateTestRole(ctx.DB, orgID, models.RoleTypeTechnician)
+	if err != nil {
+		t.Fatalf("Failed to create technician role: %v", err)
+	}
+	technician := createLocatedTechnician(t, ctx.DB, orgID, techRole.ID, "tech@example.com", models.TechnicianStatusActive, nil, nil)
+	techToken, err := ctx.JWTService.GenerateAccessToken(technician.UserID, orgID, "tech@example.com", models.RoleTypeTechnician.String())
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	other := createLocatedTechnician(t, ctx.DB, orgID, techRole.ID, "other@example.com", models.TechnicianStatusActive, nil, nil)
+	otherToken, err := ctx.JWTService.GenerateAccessToken(other.UserID, orgID, "other@example.com", models.RoleTypeTechnician.String())
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	route := createAssignedRoute(t, ctx.DB, orgID, &technician.ID, "Morning run")
+	stop := models.RouteStop{Base: models.Base{OrganizationID: orgID}, RouteID: route.ID, Name: "First", SequenceNum: 1}
+	if err := ctx.DB.Create(&stop).Error; err != nil {
+		t.Fatalf("Failed to create stop: %v", err)
+	}
+	attachmentsPath := fmt.Sprintf("/api/v1/routes/%d/stops/%d/attachments", route.ID, stop.ID)
+
+	capturedAt := time.Date(2025, 8, 11, 14, 30, 5, 0, time.UTC)
+	photo := tests.BuildTestJPEG(capturedAt, 40.748817, -73.985428)
+	var uploaded validation.StopAttachmentResponse
+
+	download := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ctx.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("Technician uploads a photo with EXIF metadata", func(t *testing.T) {
+		w := tests.MakeMultipartRequest(ctx.Router, attachmentsPath, techToken, "front-door.jpg", photo)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		if err := tests.ParseDataResponse(w, &uploaded); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		checksum := sha256.Sum256(photo)
+		if uploaded.Kind != models.AttachmentKindPhoto || uploaded.ContentType != "image/jpeg" || uploaded.Size != int64(len(photo)) {
+			t.Errorf("Unexpected attachment %+v", uploaded)
+		}
+		if uploaded.Checksum != hex.EncodeToString(checksum[:]) || uploaded.FileName != "front-door.jpg" || uploaded.UploadedByID != technician.UserID {
+			t.Errorf("Unexpected attachment metadata %+v", uploaded)
+		}
+		if uploaded.CapturedAt == nil || !uploaded.CapturedAt.Equal(capturedAt) {
+			t.Errorf("Expected capture time %s, got %v", capturedAt, uploaded.CapturedAt)
+		}
+		if uploaded.Lat == nil || uploaded.Lng == nil || math.Abs(*uploaded.Lat-40.748817) > 1e-6 || math.Abs(*uploaded.Lng+73.985428) > 1e-6 {
+			t.Errorf("Expected EXIF GPS position, got %v, %v", uploaded.Lat, uploaded.Lng)
+		}
+		if !strings.Contains(uploaded.DownloadURL, "signature=") || !uploaded.URLExpiresAt.After(time.Now()) {
+			t.Errorf("Expected a signed download URL, got %s", uploaded.DownloadURL)
+		}
+
+		var current models.RouteStop
+		ctx.DB.First(&current, stop.ID)
+		if current.PhotosCount != 1 {
+			t.Errorf("Expected the stop's photos count to be 1, got %d", current.PhotosCount)
+		}
+	})
+
+	t.Run("Signed URLs serve the blob", func(t *testing.T) {
+		w := download(uploaded.DownloadURL)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if !bytes.Equal(w.Body.Bytes(), photo) || w.Header().Get("Content-Type") != "image/jpeg" {
+			t.Errorf("Unexpected download (%s, %d bytes)", w.Header().Get("Content-Type"), w.Body.Len())
+		}
+		if !strings.Contains(w.Header().Get("Content-Disposition"), "front-door.jpg") {
+			t.Errorf("Expected the file name in Content-Disposition, got %s", w.Header().Get("Content-Disposition"))
+		}
+
+		tampered := strings.Replace(uploaded.DownloadURL, "signature=", "signature=x", 1)
+		if w := download(tampered); !tests.AssertResponseError(w, http.StatusForbidden, "INVALID_SIGNATURE") {
+			t.Errorf("Expected INVALID_SIGNATURE, got %d: %s", w.Code, w.Body.String())
+		}
+		if w := download(fmt.Sprintf("/api/v1/attachments/%d/content", uploaded.ID)); w.Code != http.StatusForbidden {
+			t.Errorf("Expected unsigned downloads to be rejected, got %d", w.Code)
+		}
+
+		expired, _ := ctx.Signer.SignedURL(fmt.Sprintf("/api/v1/attachments/%d/content", uploaded.ID), uploaded.ID, time.Now().Add(-time.Hour))
+		if w := download(expired); !tests.AssertResponseError(w, http.StatusForbidden, "INVALID_SIGNATURE") {
+			t.Errorf("Expected expired URLs to be rejected, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Owner uploads a document and lists attachments", func(t *testing.T) {
+		w := tests.MakeMultipartRequest(ctx.Router, attachmentsPath, ownerToken, "work-order.pdf", []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\n"))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var document validation.StopAttachmentResponse
+		if err := tests.ParseDataResponse(w, &document); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if document.Kind != models.AttachmentKindDocument || document.ContentType != "application/pdf" || document.CapturedAt != nil {
+			t.Errorf("Unexpected document %+v", document)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", attachmentsPath, techToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var attachments []validation.StopAttachmentResponse
+		if err := tests.ParseDataResponse(w, &attachments); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(attachments) != 2 || attachments[0].ID != uploaded.ID || attachments[1].ID != document.ID {
+			t.Errorf("Expected the photo then the document, got %+v", attachments)
+		}
+
+		var current models.RouteStop
+		ctx.DB.First(&current, stop.ID)
+		if current.PhotosCount != 1 {
+			t.Errorf("Expected documents not to count as photos, got %d", current.PhotosCount)
+		}
+	})
+
+	t.Run("Invalid uploads are rejected", func(t *testing.T) {
+		w := tests.MakeMultipartRequest(ctx.Router, attachmentsPath, techToken, "", nil)
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "FILE_REQUIRED") {
+			t.Errorf("Expected FILE_REQUIRED, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeMultipartRequest(ctx.Router, attachmentsPath, techToken, "photo.jpg", []byte("#!/bin/sh\necho not a photo\n"))
+		if !tests.AssertResponseError(w, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE") {
+			t.Errorf("Expected UNSUPPORTED_MEDIA_TYPE, got %d: %s", w.Code, w.Body.String())
+		}
+
+		large := append(append([]byte{}, photo...), make([]byte, 64<<10)...)
+		w = tests.MakeMultipartRequest(ctx.Router, attachmentsPath, techToken, "large.jpg", large)
+		if !tests.AssertResponseError(w, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE") {
+			t.Errorf("Expected FILE_TOO_LARGE, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeMultipartRequest(ctx.Router, fmt.Sprintf("/api/v1/routes/%d/stops/99999/attachments", route.ID), techToken, "photo.jpg", photo)
+		if !tests.AssertResponseError(w, http.StatusNotFound, "STOP_NOT_FOUND") {
+			t.Errorf("Expected STOP_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Only the assigned technician uploads", func(t *testing.T) {
+		w := tests.MakeMultipartRequest(ctx.Router, attachmentsPath, otherToken, "photo.jpg", photo)
+		if !tests.AssertResponseError(w, http.StatusForbidden, "RESOURCE_ACCESS_DENIED") {
+			t.Errorf("Expected RESOURCE_ACCESS_DENIED, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Uploads are limited by the organization quota", func(t *testing.T) {
+		var used int64
+		ctx.DB.Model(&models.StopAttachment{}).Where("organization_id = ?", orgID).Select("SUM(size)").Scan(&used)
+		if err := ctx.DB.Model(&models.Organization{}).Where("id = ?", orgID).Update("storage_quota", used+int64(len(photo))-1).Error; err != nil {
+			t.Fatalf("Failed to set quota: %v", err)
+		}
+
+		w := tests.MakeMultipartRequest(ctx.Router, attachmentsPath, techToken, "photo.jpg", photo)
+		if !tests.AssertResponseError(w, http.StatusRequestEntityTooLarge, "STORAGE_QUOTA_EXCEEDED") {
+			t.Fatalf("Expected STORAGE_QUOTA_EXCEEDED, got %d: %s", w.Code, w.Body.String())
+		}
+		details := errorDetails(t, w.Body.Bytes())
+		if details["used"] != float64(used) {
+			t.Errorf("Expected used %d in details, got %+v", used, details)
+		}
+	})
+
+	t.Run("Owner deletes an attachment", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", fmt.Sprintf("%s/%d", attachmentsPath, uploaded.ID), techToken, nil)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected technicians not to delete attachments, got %d", w.Code)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", fmt.Sprintf("%s/%d", attachmentsPath, uploaded.ID), ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var current models.RouteStop
+		ctx.DB.First(&current, stop.ID)
+		if current.PhotosCount != 0 {
+			t.Errorf("Expected the photos count to drop to 0, got %d", current.PhotosCount)
+		}
+		if w := download(uploaded.DownloadURL); !tests.AssertResponseError(w, http.StatusNotFound, "ATTACHMENT_NOT_FOUND") {
+			t.Errorf("Expected deleted attachments not to download, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", fmt.Sprintf("%s/%d", attachmentsPath, uploaded.ID), ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusNotFound, "ATTACHMENT_NOT_FOUND") {
+			t.Errorf("Expected ATTACHMENT_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Attachments are scoped to the organization", func(t *testing.T) {
+		otherOrgToken, err := ctx.JWTService.GenerateAccessToken(owner.User.ID, orgID+100, owner.User.Email, models.RoleTypeOwner.String())
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", attachmentsPath, otherOrgToken, nil)
+		if !tests.AssertResponseError(w, http.StatusNotFound, "ROUTE_NOT_FOUND") {
+			t.Errorf("Expected ROUTE_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,245 @@
+package integration_test
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/validation"
+)
+
+var emailTokenPattern = regexp.MustCompile(`\?token=([0-9a-f]+)`)
+
+// lastEmailToken returns the token in the link of the latest email sent to the address
+func lastEmailToken(t *testing.T, ctx *tests.TestContext, to string) string {
+	t.Helper()
+	flushEmails(t, ctx)
+	message, ok := ctx.Outbox.Last(to)
+	if !ok {
+		t.Fatalf("Expected an email to %s", to)
+	}
+	match := emailTokenPattern.FindStringSubmatch(message.Body)
+	if match == nil {
+		t.Fatalf("Expected a link with a token in the email, got:\n%s", message.Body)
+	}
+	return match[1]
+}
+
+// flushEmails waits for the emails the auth handler sends in the background
+func flushEmails(t *testing.T, ctx *tests.TestContext) {
+	t.Helper()
+	if err := ctx.MailQueue.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush the mail queue: %v", err)
+	}
+}
+
+func TestAuthHandler_PasswordReset(t *testing.T) {
+	ctx, err := tests.SetupTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx)
+
+	if _, err := tests.CreateCompleteTestUser(ctx.DB, "test@example.com", "password123", models.RoleTypeOwner, true); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	forgotPassword := func(t *testing.T, email string) {
+		t.Helper()
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/forgot-password", "", validation.ForgotPasswordRequest{Email: email})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Forgot password failed: %s", w.Body.String())
+		}
+	}
+	resetPassword := func(token, password string) int {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/reset-password", "", validation.ResetPasswordRequest{Token: token, NewPassword: password})
+		if w.Code != http.StatusOK && !tests.AssertResponseError(w, w.Code, "INVALID_RESET_TOKEN") {
+			t.Errorf("Unexpected reset password response %d: %s", w.Code, w.Body.String())
+		}
+		return w.Code
+	}
+
+	t.Run("Unknown addresses get the same response without an email", func(t *testing.T) {
+		forgotPassword(t, "nobody@example.com")
+		flushEmails(t, ctx)
+		if len(ctx.Outbox.Messages()) != 0 {
+			t.Errorf("Expected no email to be sent, got %+v", ctx.Outbox.Messages())
+		}
+	})
+
+	t.Run("Resetting the password signs out every session", func(t *testing.T) {
+		session, err := tests.ParseLoginResponse(tests.MakeLoginRequest(ctx.Router, "test@example.com", "password123"))
+		if err != nil {
+			t.Fatalf("Login failed: %v", err)
+		}
+
+		forgotPassword(t, "test@example.com")
+		token := lastEmailToken(t, ctx, "test@example.com")
+		if status := resetPassword(token, "NewPassword123!"); status != http.StatusOK {
+			t.Fatalf("Expected the password to be reset, got %d", status)
+		}
+
+		if w := tests.MakeLoginRequest(ctx.Router, "test@example.com", "password123"); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected the old password to be rejected, got %d", w.Code)
+		}
+		if w := tests.MakeLoginRequest(ctx.Router, "test@example.com", "NewPassword123!"); w.Code != http.StatusOK {
+			t.Errorf("Expected the new password to be accepted, got %d: %s", w.Code, w.Body.String())
+		}
+		w := tests.MakeRefreshRequest(ctx.Router, session.RefreshToken)
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_REFRESH_TOKEN") {
+			t.Errorf("Expected the earlier session to be revoked, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var user models.User
+		ctx.DB.Where("email = ?", "test@example.com").First(&user)
+		if !user.IsEmailVerified() {
+			t.Errorf("Expected following the reset link to verify the address")
+		}
+	})
+
+	t.Run("Reset tokens can only be used once", func(t *testing.T) {
+		forgotPassword(t, "test@example.com")
+		token := lastEmailToken(t, ctx, "test@example.com")
+		if status := resetPassword(token, "AnotherPassword123!"); status != http.StatusOK {
+			t.Fatalf("Expected the password to be reset, got %d", status)
+		}
+		if status := resetPassword(token, "ThirdPassword123!"); status != http.StatusBadRequest {
+			t.Errorf("Expected a used token to be rejected, got %d", status)
+		}
+	})
+
+	t.Run("Requesting a new link retires the previous one", func(t *testing.T) {
+		forgotPassword(t, "test@example.com")
+		first := lastEmailToken(t, ctx, "test@example.com")
+		forgotPassword(t, "test@example.com")
+		second := lastEmailToken(t, ctx, "test@example.com")
+
+		if status := resetPassword(first, "AnotherPassword456!"); status != http.StatusBadRequest {
+			t.Errorf("Expected the earlier token to be rejected, got %d", status)
+		}
+		if status := resetPassword(second, "AnotherPassword456!"); status != http.StatusOK {
+			t.Errorf("Expected the latest token to be accepted, got %d", status)
+		}
+	})
+
+	t.Run("Expired and unknown tokens are rejected", func(t *testing.T) {
+		forgotPassword(t, "test@example.com")
+		token := lastEmailToken(t, ctx, "test@example.com")
+		ctx.DB.Model(&models.UserToken{}).Where("used_at IS NULL").Update("expires_at", time.Now().Add(-time.Minute))
+
+		if status := resetPassword(token, "AnotherPassword789!"); status != http.StatusBadRequest {
+			t.Errorf("Expected an expired token to be rejected, got %d", status)
+		}
+		if status := resetPassword("0123456789abcdef0123456789abcdef", "AnotherPassword789!"); status != http.StatusBadRequest {
+			t.Errorf("Expected an unknown token to be rejected, got %d", status)
+		}
+	})
+
+	t.Run("Only token hashes are stored", func(t *testing.T) {
+		forgotPassword(t, "test@example.com")
+		token := lastEmailToken(t, ctx, "test@example.com")
+		var count int64
+		ctx.DB.Model(&models.UserToken{}).Where("token_hash = ?", token).Count(&count)
+		if count != 0 {
+			t.Errorf("Expected the token not to be stored in plain text")
+		}
+	})
+}
+
+func TestAuthHandler_EmailVerification(t *testing.T) {
+	ctx, err := tests.SetupTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx)
+
+	w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/register", "", validation.RegistrationRequest{
+		FirstName:         "Jane",
+		LastName:          "Doe",
+		Email:             "jane@example.com",
+		Password:          "SecurePass123!",
+		OrganizationName:  "Acme",
+		OrganizationEmail: "contact@acme.example.com",
+		SubDomain:         "acme",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Registration failed: %s", w.Body.String())
+	}
+	var registration validation.RegistrationResponse
+	if err := tests.ParseDataResponse(w, &registration); err != nil {
+		t.Fatalf("Failed to parse registration response: %v", err)
+	}
+	if registration.User.EmailVerified {
+		t.Errorf("Expected a new user's address not to be verified")
+	}
+
+	verifyEmail := func(token string) *validation.UserResponse {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/verify-email", "", validation.VerifyEmailRequest{Token: token})
+		if w.Code != http.StatusOK {
+			if !tests.AssertResponseError(w, http.StatusBadRequest, "INVALID_VERIFICATION_TOKEN") {
+				t.Errorf("Unexpected verify email response %d: %s", w.Code, w.Body.String())
+			}
+			return nil
+		}
+		var user validation.UserResponse
+		if err := tests.ParseDataResponse(w, &user); err != nil {
+			t.Fatalf("Failed to parse user: %v", err)
+		}
+		return &user
+	}
+
+	t.Run("The emailed link verifies the address once", func(t *testing.T) {
+		token := lastEmailToken(t, ctx, "jane@example.com")
+		if user := verifyEmail(token); user == nil || !user.EmailVerified {
+			t.Fatalf("Expected the address to be verified, got %+v", user)
+		}
+		if user := verifyEmail(token); user != nil {
+			t.Errorf("Expected a used token to be rejected")
+		}
+	})
+
+	t.Run("A link doesn't verify an address changed since it was sent", func(t *testing.T) {
+		var user models.User
+		ctx.DB.Where("email = ?", "jane@example.com").First(&user)
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/register-user", "", validation.UserRegistrationRequest{
+			Email:     "tech@example.com",
+			Password:  "SecurePass123!",
+			FirstName: "Tom",
+			LastName:  "Tech",
+			Role:      models.RoleTypeTechnician,
+			TenantID:  user.OrganizationID,
+		})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("User registration failed: %s", w.Body.String())
+		}
+		token := lastEmailToken(t, ctx, "tech@example.com")
+
+		ctx.DB.Model(&models.User{}).Where("email = ?", "tech@example.com").Update("email", "tom@example.com")
+		if user := verifyEmail(token); user != nil {
+			t.Errorf("Expected the token for the previous address to be rejected")
+		}
+	})
+
+	t.Run("Signed in users can ask for a new link", func(t *testing.T) {
+		login, err := tests.ParseLoginResponse(tests.MakeLoginRequest(ctx.Router, "tom@example.com", "SecurePass123!"))
+		if err != nil {
+			t.Fatalf("Login failed: %v", err)
+		}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/resend-verification", login.AccessToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Resending the verification email failed: %s", w.Body.String())
+		}
+		if user := verifyEmail(lastEmailToken(t, ctx, "tom@example.com")); user == nil || !user.EmailVerified {
+			t.Fatalf("Expected the new address to be verified, got %+v", user)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/resend-verification", login.AccessToken, nil)
+		if !tests.AssertResponseError(w, http.StatusConflict, "EMAIL_ALREADY_VERIFIED") {
+			t.Errorf("Expected EMAIL_ALREADY_VERIFIED, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
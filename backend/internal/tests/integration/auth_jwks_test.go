@@ -0,0 +1,117 @@
+package integration_test
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"routrapp-api/internal/api"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/utils/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func fetchJWKS(t *testing.T, router *gin.Engine) auth.JWKS {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Fetching JWKS failed: %d %s", w.Code, w.Body.String())
+	}
+	var jwks auth.JWKS
+	if err := json.Unmarshal(w.Body.Bytes(), &jwks); err != nil {
+		t.Fatalf("Failed to parse JWKS: %v", err)
+	}
+	return jwks
+}
+
+// rsaPublicKey decodes the RSA public key of a JWK the way a service verifying tokens would
+func rsaPublicKey(t *testing.T, jwk auth.JWK) *rsa.PublicKey {
+	t.Helper()
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		t.Fatalf("Failed to decode modulus: %v", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		t.Fatalf("Failed to decode exponent: %v", err)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+}
+
+func TestAuthHandler_JWKS(t *testing.T) {
+	t.Run("The shared secret is never published", func(t *testing.T) {
+		ctx, err := tests.SetupTestContext()
+		if err != nil {
+			t.Fatalf("Failed to setup test context: %v", err)
+		}
+		defer tests.CleanupTestContext(ctx)
+
+		if jwks := fetchJWKS(t, ctx.Router); jwks.Keys == nil || len(jwks.Keys) != 0 {
+			t.Errorf("Expected an empty key set, got %+v", jwks)
+		}
+	})
+
+	t.Run("Tokens signed with key pairs verify with the published keys", func(t *testing.T) {
+		ctx, err := tests.SetupTestContext()
+		if err != nil {
+			t.Fatalf("Failed to setup test context: %v", err)
+		}
+		defer tests.CleanupTestContext(ctx)
+
+		keys, err := auth.NewKeyRing(auth.AlgorithmRS256, t.TempDir(), 0)
+		if err != nil {
+			t.Fatalf("Failed to create key ring: %v", err)
+		}
+		jwtService := auth.NewJWTServiceWithKeys(keys)
+		authHandler := api.NewAuthHandlerWithJWT(ctx.DB, jwtService)
+		router := gin.New()
+		router.POST("/api/v1/auth/login", authHandler.Login)
+		router.GET("/api/v1/auth/me", middleware.AuthMiddlewareWithJWT(jwtService), authHandler.GetCurrentUser)
+		router.GET("/.well-known/jwks.json", authHandler.JWKS)
+
+		if _, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "password123", models.RoleTypeOwner, true); err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		login, err := tests.ParseLoginResponse(tests.MakeLoginRequest(router, "owner@example.com", "password123"))
+		if err != nil || login.AccessToken == "" {
+			t.Fatalf("Login failed: %v", err)
+		}
+
+		w := tests.MakeAuthenticatedRequest(router, "GET", "/api/v1/auth/me", login.AccessToken, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected the access token to authenticate, got %d: %s", w.Code, w.Body.String())
+		}
+
+		jwks := fetchJWKS(t, router)
+		if len(jwks.Keys) != 1 || jwks.Keys[0].KeyType != "RSA" || jwks.Keys[0].KeyID != keys.Current().ID {
+			t.Fatalf("Expected the RSA key to be published, got %+v", jwks)
+		}
+
+		// Another service verifies the token with nothing but the published key
+		claims := &auth.JWTClaims{}
+		token, err := jwt.ParseWithClaims(login.AccessToken, claims, func(token *jwt.Token) (interface{}, error) {
+			for _, jwk := range jwks.Keys {
+				if jwk.KeyID == token.Header["kid"] {
+					return rsaPublicKey(t, jwk), nil
+				}
+			}
+			return nil, fmt.Errorf("unknown key %v", token.Header["kid"])
+		}, jwt.WithValidMethods([]string{"RS256"}))
+		if err != nil || !token.Valid {
+			t.Fatalf("Expected the token to verify with the published key: %v", err)
+		}
+		if claims.Email != "owner@example.com" || !claims.IsAccessToken() {
+			t.Errorf("Unexpected claims %+v", claims)
+		}
+	})
+}
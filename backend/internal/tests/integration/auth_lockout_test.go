@@ -0,0 +1,281 @@
+package integration_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/config"
+	"routrapp-api/internal/lockout"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/validation"
+)
+
+func TestAuthHandler_AccountLockout(t *testing.T) {
+	guard := lockout.NewGuard(lockout.NewMemoryStore(), config.LockoutConfig{
+		MaxAttempts:   3,
+		IPMaxAttempts: 100,
+		Duration:      15 * time.Minute,
+		Window:        time.Hour,
+	})
+	ctx, err := tests.SetupTestContextWithLockout(guard)
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx)
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "password123", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	technicianRole, _ := tests.CreateTestRole(ctx.DB, owner.Organization.ID, models.RoleTypeTechnician)
+	technician, err := tests.CreateTestUser(ctx.DB, owner.Organization.ID, technicianRole.ID, "tech@example.com", "password123", true)
+	if err != nil {
+		t.Fatalf("Failed to create technician: %v", err)
+	}
+	ownerToken, _ := tests.GenerateTestAccessToken(ctx, owner)
+	technicianToken, _ := tests.GenerateTestAccessToken(ctx, &tests.TestUser{Organization: owner.Organization, Role: technicianRole, User: technician})
+
+	t.Run("Repeated failures lock the account", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			w := tests.MakeLoginRequest(ctx.Router, "tech@example.com", "wrongpassword")
+			if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS") {
+				t.Fatalf("Expected failed login %d to be rejected as invalid, got %d: %s", i+1, w.Code, w.Body.String())
+			}
+		}
+
+		w := tests.MakeLoginRequest(ctx.Router, "tech@example.com", "password123")
+		if !tests.AssertResponseError(w, http.StatusLocked, "ACCOUNT_LOCKED") {
+			t.Fatalf("Expected the correct password to be refused while locked, got %d: %s", w.Code, w.Body.String())
+		}
+		if seconds, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || seconds <= 0 || seconds > 900 {
+			t.Errorf("Expected Retry-After within the lockout duration, got %q", w.Header().Get("Retry-After"))
+		}
+
+		var record models.AccountLockout
+		if err := ctx.DB.Where("user_id = ?", technician.ID).First(&record).Error; err != nil {
+			t.Fatalf("Expected the lockout to be recorded: %v", err)
+		}
+		if record.FailedAttempts != 3 || record.OrganizationID != owner.Organization.ID || !record.IsActive(time.Now()) {
+			t.Errorf("Unexpected lockout record %+v", record)
+		}
+
+		w = tests.MakeLoginRequest(ctx.Router, "owner@example.com", "password123")
+		if login, err := tests.ParseLoginResponse(w); err != nil || login.AccessToken == "" {
+			t.Errorf("Expected other accounts to sign in, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Owners see the lockouts of an account", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", fmt.Sprintf("/api/v1/users/%d/lockouts", technician.ID), ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Listing lockouts failed: %s", w.Body.String())
+		}
+		var lockouts []validation.AccountLockoutResponse
+		if err := tests.ParseDataResponse(w, &lockouts); err != nil {
+			t.Fatalf("Failed to parse lockouts: %v", err)
+		}
+		if len(lockouts) != 1 || !lockouts[0].Active || lockouts[0].FailedAttempts != 3 || lockouts[0].UnlockedAt != nil {
+			t.Errorf("Expected one active lockout, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Users can't unlock accounts without permission", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/users/%d/unlock", technician.ID), technicianToken, nil)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected a technician to be forbidden, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Users can't unlock more privileged accounts", func(t *testing.T) {
+		adminToken := userAdminToken(t, ctx, owner.Organization.ID)
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/users/%d/unlock", owner.User.ID), adminToken, nil)
+		if !tests.AssertResponseError(w, http.StatusForbidden, "PERMISSION_ESCALATION") {
+			t.Errorf("Expected unlocking an owner to be refused, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Owners unlock an account before the lockout ends", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/users/%d/unlock", technician.ID), ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Unlocking failed: %s", w.Body.String())
+		}
+
+		var record models.AccountLockout
+		ctx.DB.Where("user_id = ?", technician.ID).First(&record)
+		if record.UnlockedAt == nil || record.UnlockedByID == nil || *record.UnlockedByID != owner.User.ID || record.IsActive(time.Now()) {
+			t.Errorf("Expected the unlock to be recorded, got %+v", record)
+		}
+
+		w = tests.MakeLoginRequest(ctx.Router, "tech@example.com", "password123")
+		if login, err := tests.ParseLoginResponse(w); err != nil || login.AccessToken == "" {
+			t.Errorf("Expected the technician to sign in after the unlock, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("A successful login restarts the count", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			tests.MakeLoginRequest(ctx.Router, "tech@example.com", "wrongpassword")
+		}
+		tests.MakeLoginRequest(ctx.Router, "tech@example.com", "password123")
+		w := tests.MakeLoginRequest(ctx.Router, "tech@example.com", "wrongpassword")
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS") {
+			t.Errorf("Expected the failure not to lock the account, got %d: %s", w.Code, w.Body.String())
+		}
+		tests.MakeLoginRequest(ctx.Router, "tech@example.com", "password123")
+	})
+
+	t.Run("Wrong MFA codes count as failed logins", func(t *testing.T) {
+		setupMFA(t, ctx, ownerToken, "owner@example.com")
+		challenge := loginChallenge(t, ctx, "owner@example.com", "password123")
+		for i := 0; i < 3; i++ {
+			w := verifyMFA(ctx, validation.MFAVerifyRequest{MFAToken: challenge.MFAToken, Code: "000000"})
+			if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_MFA_CODE") {
+				t.Fatalf("Expected wrong code %d to be rejected, got %d: %s", i+1, w.Code, w.Body.String())
+			}
+		}
+
+		w := verifyMFA(ctx, validation.MFAVerifyRequest{MFAToken: challenge.MFAToken, Code: "000000"})
+		if !tests.AssertResponseError(w, http.StatusLocked, "ACCOUNT_LOCKED") {
+			t.Errorf("Expected codes to be refused while locked, got %d: %s", w.Code, w.Body.String())
+		}
+		w = tests.MakeLoginRequest(ctx.Router, "owner@example.com", "password123")
+		if !tests.AssertResponseError(w, http.StatusLocked, "ACCOUNT_LOCKED") {
+			t.Errorf("Expected password logins to be refused while locked, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestAuthHandler_LoginBackoff(t *testing.T) {
+	guard := lockout.NewGuard(lockout.NewMemoryStore(), config.LockoutConfig{
+		MaxAttempts:    10,
+		BackoffAfter:   2,
+		IPMaxAttempts:  100,
+		IPBackoffAfter: 20,
+		BaseDelay:      time.Minute,
+		MaxDelay:       time.Hour,
+		Duration:       15 * time.Minute,
+		Window:         time.Hour,
+	})
+	ctx, err := tests.SetupTestContextWithLockout(guard)
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx)
+
+	if _, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "password123", models.RoleTypeOwner, true); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	tests.MakeLoginRequest(ctx.Router, "owner@example.com", "wrongpassword")
+	w := tests.MakeLoginRequest(ctx.Router, "owner@example.com", "wrongpassword")
+	if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS") {
+		t.Fatalf("Expected a retry below the backoff threshold, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = tests.MakeLoginRequest(ctx.Router, "owner@example.com", "password123")
+	if !tests.AssertResponseError(w, http.StatusTooManyRequests, "TOO_MANY_ATTEMPTS") {
+		t.Fatalf("Expected the login to wait after reaching the threshold, got %d: %s", w.Code, w.Body.String())
+	}
+	if seconds, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || seconds <= 0 || seconds > 60 {
+		t.Errorf("Expected Retry-After within the base delay, got %q", w.Header().Get("Retry-After"))
+	}
+
+	var count int64
+	ctx.DB.Model(&models.AccountLockout{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected backing off not to record a lockout, got %d", count)
+	}
+}
+
+func TestAuthHandler_LockoutStoreOutage(t *testing.T) {
+	counters, err := tests.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup counters database: %v", err)
+	}
+	guard := lockout.NewGuard(lockout.NewDatabaseStore(counters), config.LockoutConfig{
+		MaxAttempts:   3,
+		IPMaxAttempts: 100,
+		Duration:      15 * time.Minute,
+		Window:        time.Hour,
+	})
+	ctx, err := tests.SetupTestContextWithLockout(guard)
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx)
+
+	if _, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "password123", models.RoleTypeOwner, true); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	if w := tests.MakeLoginRequest(ctx.Router, "owner@example.com", "password123"); w.Code != http.StatusOK {
+		t.Fatalf("Expected the login to succeed while the counters are available, got %d: %s", w.Code, w.Body.String())
+	}
+
+	sqlDB, err := counters.DB()
+	if err != nil {
+		t.Fatalf("Failed to get counters database handle: %v", err)
+	}
+	sqlDB.Close()
+
+	// Without the counters failed logins can't be throttled, so passwords aren't checked at all
+	w := tests.MakeLoginRequest(ctx.Router, "owner@example.com", "password123")
+	if !tests.AssertResponseError(w, http.StatusServiceUnavailable, "LOCKOUT_UNAVAILABLE") {
+		t.Errorf("Expected LOCKOUT_UNAVAILABLE, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAuthHandler_PasswordResetThrottling(t *testing.T) {
+	guard := lockout.NewGuard(lockout.NewMemoryStore(), config.LockoutConfig{
+		MaxAttempts:   2,
+		IPMaxAttempts: 3,
+		Duration:      15 * time.Minute,
+		Window:        time.Hour,
+	})
+	ctx, err := tests.SetupTestContextWithLockout(guard)
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx)
+
+	if _, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "password123", models.RoleTypeOwner, true); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	forgotPassword := func(email string) *httptest.ResponseRecorder {
+		return tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/forgot-password", "", validation.ForgotPasswordRequest{Email: email})
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := forgotPassword("owner@example.com"); w.Code != http.StatusOK {
+			t.Fatalf("Expected request %d to be accepted, got %d: %s", i+1, w.Code, w.Body.String())
+		}
+	}
+	w := forgotPassword("Owner@example.com")
+	if !tests.AssertResponseError(w, http.StatusTooManyRequests, "TOO_MANY_REQUESTS") {
+		t.Fatalf("Expected further requests for the address to be refused, got %d: %s", w.Code, w.Body.String())
+	}
+	if seconds, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || seconds <= 0 || seconds > 900 {
+		t.Errorf("Expected Retry-After within the lockout duration, got %q", w.Header().Get("Retry-After"))
+	}
+	if err := ctx.MailQueue.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush the mail queue: %v", err)
+	}
+	if got := len(ctx.Outbox.Messages()); got != 2 {
+		t.Errorf("Expected only the accepted requests to send an email, got %d", got)
+	}
+
+	// The refused request counted against the IP address too, leaving it no request for other addresses
+	if w := forgotPassword("nobody@example.com"); !tests.AssertResponseError(w, http.StatusTooManyRequests, "TOO_MANY_REQUESTS") {
+		t.Errorf("Expected the IP address to reach its limit, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Reset requests are counted apart from failed logins
+	if w := tests.MakeLoginRequest(ctx.Router, "owner@example.com", "password123"); w.Code != http.StatusOK {
+		t.Errorf("Expected logins to the account to be unaffected, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,430 @@
+package integration_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/utils/auth"
+	"routrapp-api/internal/validation"
+)
+
+// totpCode returns the current code for the secret. The last used step of the user is cleared first,
+// so a test can present several codes within one 30 second step.
+func totpCode(t *testing.T, ctx *tests.TestContext, email, secret string) string {
+	t.Helper()
+	ctx.DB.Model(&models.User{}).Where("email = ?", email).Update("totp_last_step", 0)
+	code, err := auth.TOTPCode(secret, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to compute TOTP code: %v", err)
+	}
+	return code
+}
+
+// loginChallenge signs in with a password and returns the MFA challenge the login responded with
+func loginChallenge(t *testing.T, ctx *tests.TestContext, email, password string) validation.MFAChallengeResponse {
+	t.Helper()
+	w := tests.MakeLoginRequest(ctx.Router, email, password)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Login failed: %s", w.Body.String())
+	}
+	var challenge validation.MFAChallengeResponse
+	if err := tests.ParseDataResponse(w, &challenge); err != nil {
+		t.Fatalf("Failed to parse login response: %v", err)
+	}
+	if !challenge.MFARequired || challenge.MFAToken == "" {
+		t.Fatalf("Expected an MFA challenge, got %s", w.Body.String())
+	}
+	return challenge
+}
+
+// setupMFA turns on two-factor authentication for a signed in user and returns the secret and recovery codes
+func setupMFA(t *testing.T, ctx *tests.TestContext, accessToken, email string) (string, []string) {
+	t.Helper()
+	w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/mfa/setup", accessToken, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("MFA setup failed: %s", w.Body.String())
+	}
+	var setup validation.MFASetupResponse
+	if err := tests.ParseDataResponse(w, &setup); err != nil {
+		t.Fatalf("Failed to parse setup response: %v", err)
+	}
+
+	w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/mfa/enable", accessToken, validation.MFACodeRequest{Code: totpCode(t, ctx, email, setup.Secret)})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Enabling MFA failed: %s", w.Body.String())
+	}
+	var enabled validation.MFARecoveryCodesResponse
+	if err := tests.ParseDataResponse(w, &enabled); err != nil {
+		t.Fatalf("Failed to parse recovery codes: %v", err)
+	}
+	return setup.Secret, enabled.RecoveryCodes
+}
+
+func verifyMFA(ctx *tests.TestContext, req validation.MFAVerifyRequest) *httptest.ResponseRecorder {
+	return tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/mfa/verify", "", req)
+}
+
+func TestAuthHandler_TwoFactorLogin(t *testing.T) {
+	ctx, err := tests.SetupTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx)
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "password123", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	accessToken, err := tests.GenerateTestAccessToken(ctx, owner)
+	if err != nil {
+		t.Fatalf("Failed to generate access token: %v", err)
+	}
+
+	var secret string
+	var recoveryCodes []string
+
+	t.Run("Setup returns a provisioning URI and stores the secret encrypted", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/mfa/setup", accessToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("MFA setup failed: %s", w.Body.String())
+		}
+		var setup validation.MFASetupResponse
+		if err := tests.ParseDataResponse(w, &setup); err != nil {
+			t.Fatalf("Failed to parse setup response: %v", err)
+		}
+		if !strings.HasPrefix(setup.ProvisioningURI, "otpauth://totp/RoutrApp:owner@example.com?") || !strings.Contains(setup.ProvisioningURI, "secret="+setup.Secret) {
+			t.Errorf("Unexpected provisioning URI %q", setup.ProvisioningURI)
+		}
+
+		var user models.User
+		ctx.DB.First(&user, owner.User.ID)
+		if user.TOTPSecret == "" || strings.Contains(user.TOTPSecret, setup.Secret) {
+			t.Errorf("Expected the secret to be stored encrypted, got %q", user.TOTPSecret)
+		}
+		if user.IsMFAEnabled() {
+			t.Errorf("Expected MFA to stay off until a code is confirmed")
+		}
+		if w := tests.MakeLoginRequest(ctx.Router, "owner@example.com", "password123"); !strings.Contains(w.Body.String(), `"access_token"`) {
+			t.Errorf("Expected login not to ask for a code before MFA is enabled, got %s", w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/mfa/enable", accessToken, validation.MFACodeRequest{Code: "000000"})
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_MFA_CODE") {
+			t.Errorf("Expected a wrong code to be rejected, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/mfa/enable", accessToken, validation.MFACodeRequest{Code: totpCode(t, ctx, "owner@example.com", setup.Secret)})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Enabling MFA failed: %s", w.Body.String())
+		}
+		var enabled validation.MFARecoveryCodesResponse
+		if err := tests.ParseDataResponse(w, &enabled); err != nil {
+			t.Fatalf("Failed to parse recovery codes: %v", err)
+		}
+		if len(enabled.RecoveryCodes) != 10 {
+			t.Errorf("Expected 10 recovery codes, got %d", len(enabled.RecoveryCodes))
+		}
+		secret, recoveryCodes = setup.Secret, enabled.RecoveryCodes
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/mfa/setup", accessToken, nil)
+		if !tests.AssertResponseError(w, http.StatusConflict, "MFA_ALREADY_ENABLED") {
+			t.Errorf("Expected setting up a second secret to be refused, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Login asks for a code and the MFA token is not an access token", func(t *testing.T) {
+		challenge := loginChallenge(t, ctx, "owner@example.com", "password123")
+		if challenge.EnrollmentRequired {
+			t.Errorf("Expected no enrollment for a user with MFA enabled")
+		}
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/auth/me", challenge.MFAToken, nil)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected the MFA token to be refused as an access token, got %d", w.Code)
+		}
+		w = verifyMFA(ctx, validation.MFAVerifyRequest{MFAToken: accessToken, Code: totpCode(t, ctx, "owner@example.com", secret)})
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_MFA_TOKEN") {
+			t.Errorf("Expected an access token to be refused as an MFA token, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = verifyMFA(ctx, validation.MFAVerifyRequest{MFAToken: challenge.MFAToken, Code: "000000"})
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_MFA_CODE") {
+			t.Errorf("Expected a wrong code to be rejected, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("A code completes the login once", func(t *testing.T) {
+		code := totpCode(t, ctx, "owner@example.com", secret)
+		w := verifyMFA(ctx, validation.MFAVerifyRequest{MFAToken: loginChallenge(t, ctx, "owner@example.com", "password123").MFAToken, Code: code})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Verifying the code failed: %s", w.Body.String())
+		}
+		login, err := tests.ParseLoginResponse(w)
+		if err != nil || login.AccessToken == "" || login.RefreshToken == "" || !login.User.MFAEnabled {
+			t.Fatalf("Expected session tokens for a user with MFA enabled, got %s", w.Body.String())
+		}
+		if len(login.RecoveryCodes) != 0 {
+			t.Errorf("Expected no recovery codes outside of enrollment")
+		}
+
+		w = verifyMFA(ctx, validation.MFAVerifyRequest{MFAToken: loginChallenge(t, ctx, "owner@example.com", "password123").MFAToken, Code: code})
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_MFA_CODE") {
+			t.Errorf("Expected a replayed code to be rejected, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Recovery codes can be used once", func(t *testing.T) {
+		typed := strings.ToUpper(strings.ReplaceAll(recoveryCodes[0], "-", ""))
+		w := verifyMFA(ctx, validation.MFAVerifyRequest{MFAToken: loginChallenge(t, ctx, "owner@example.com", "password123").MFAToken, RecoveryCode: typed})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Signing in with a recovery code failed: %s", w.Body.String())
+		}
+		w = verifyMFA(ctx, validation.MFAVerifyRequest{MFAToken: loginChallenge(t, ctx, "owner@example.com", "password123").MFAToken, RecoveryCode: recoveryCodes[0]})
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_MFA_CODE") {
+			t.Errorf("Expected a used recovery code to be rejected, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Regenerating recovery codes retires the previous ones", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/mfa/recovery-codes", accessToken, validation.MFACodeRequest{Code: totpCode(t, ctx, "owner@example.com", secret)})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Regenerating recovery codes failed: %s", w.Body.String())
+		}
+		var regenerated validation.MFARecoveryCodesResponse
+		if err := tests.ParseDataResponse(w, &regenerated); err != nil {
+			t.Fatalf("Failed to parse recovery codes: %v", err)
+		}
+
+		w = verifyMFA(ctx, validation.MFAVerifyRequest{MFAToken: loginChallenge(t, ctx, "owner@example.com", "password123").MFAToken, RecoveryCode: recoveryCodes[1]})
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_MFA_CODE") {
+			t.Errorf("Expected a previous recovery code to be rejected, got %d: %s", w.Code, w.Body.String())
+		}
+		w = verifyMFA(ctx, validation.MFAVerifyRequest{MFAToken: loginChallenge(t, ctx, "owner@example.com", "password123").MFAToken, RecoveryCode: regenerated.RecoveryCodes[0]})
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected a new recovery code to be accepted, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Disabling requires the password and a code", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/mfa/disable", accessToken, validation.MFADisableRequest{Password: "wrong-password", Code: totpCode(t, ctx, "owner@example.com", secret)})
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_CURRENT_PASSWORD") {
+			t.Errorf("Expected a wrong password to be rejected, got %d: %s", w.Code, w.Body.String())
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/mfa/disable", accessToken, validation.MFADisableRequest{Password: "password123", Code: totpCode(t, ctx, "owner@example.com", secret)})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Disabling MFA failed: %s", w.Body.String())
+		}
+
+		var count int64
+		ctx.DB.Model(&models.UserRecoveryCode{}).Where("user_id = ?", owner.User.ID).Count(&count)
+		if count != 0 {
+			t.Errorf("Expected the recovery codes to be deleted, %d left", count)
+		}
+		if w := tests.MakeLoginRequest(ctx.Router, "owner@example.com", "password123"); !strings.Contains(w.Body.String(), `"access_token"`) {
+			t.Errorf("Expected login to issue tokens directly again, got %s", w.Body.String())
+		}
+	})
+}
+
+func TestAuthHandler_OrganizationRequiresMFA(t *testing.T) {
+	ctx, err := tests.SetupTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx)
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "password123", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	technicianRole, err := tests.CreateTestRole(ctx.DB, owner.Organization.ID, models.RoleTypeTechnician)
+	if err != nil {
+		t.Fatalf("Failed to create technician role: %v", err)
+	}
+	technician, err := tests.CreateTestUser(ctx.DB, owner.Organization.ID, technicianRole.ID, "tech@example.com", "password123", true)
+	if err != nil {
+		t.Fatalf("Failed to create technician: %v", err)
+	}
+	ownerToken, _ := tests.GenerateTestAccessToken(ctx, owner)
+	technicianToken, _ := tests.GenerateTestAccessToken(ctx, &tests.TestUser{Organization: owner.Organization, Role: technicianRole, User: technician})
+
+	t.Run("Only users allowed to update the organization change the policy", func(t *testing.T) {
+		requireMFA := true
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "PUT", "/api/v1/auth/mfa/policy", technicianToken, validation.MFAPolicyRequest{RequireMFA: &requireMFA})
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected a technician to be forbidden, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PUT", "/api/v1/auth/mfa/policy", ownerToken, validation.MFAPolicyRequest{RequireMFA: &requireMFA})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Updating the policy failed: %s", w.Body.String())
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/auth/mfa/policy", ownerToken, nil)
+		var policy validation.MFAPolicyResponse
+		if err := tests.ParseDataResponse(w, &policy); err != nil || !policy.RequireMFA {
+			t.Errorf("Expected the policy to require MFA, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Owners without a second factor enroll while signing in", func(t *testing.T) {
+		challenge := loginChallenge(t, ctx, "owner@example.com", "password123")
+		if !challenge.EnrollmentRequired {
+			t.Fatalf("Expected enrollment to be required")
+		}
+
+		w := verifyMFA(ctx, validation.MFAVerifyRequest{MFAToken: challenge.MFAToken, Code: "123456"})
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "MFA_SETUP_REQUIRED") {
+			t.Errorf("Expected a code before enrollment to be refused, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/mfa/enroll", "", validation.MFAEnrollRequest{MFAToken: challenge.MFAToken})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Enrollment failed: %s", w.Body.String())
+		}
+		var setup validation.MFASetupResponse
+		if err := tests.ParseDataResponse(w, &setup); err != nil {
+			t.Fatalf("Failed to parse setup response: %v", err)
+		}
+
+		w = verifyMFA(ctx, validation.MFAVerifyRequest{MFAToken: challenge.MFAToken, Code: totpCode(t, ctx, "owner@example.com", setup.Secret)})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Confirming enrollment failed: %s", w.Body.String())
+		}
+		login, err := tests.ParseLoginResponse(w)
+		if err != nil || login.AccessToken == "" || !login.User.MFAEnabled || len(login.RecoveryCodes) != 10 {
+			t.Fatalf("Expected session tokens and recovery codes, got %s", w.Body.String())
+		}
+		ownerToken = login.AccessToken
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/mfa/disable", ownerToken, validation.MFADisableRequest{Password: "password123", Code: totpCode(t, ctx, "owner@example.com", setup.Secret)})
+		if !tests.AssertResponseError(w, http.StatusForbidden, "MFA_REQUIRED_BY_ORGANIZATION") {
+			t.Errorf("Expected disabling to be refused while the organization requires MFA, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("The policy applies to owners only", func(t *testing.T) {
+		w := tests.MakeLoginRequest(ctx.Router, "tech@example.com", "password123")
+		if login, err := tests.ParseLoginResponse(w); err != nil || login.AccessToken == "" {
+			t.Errorf("Expected a technician to sign in with a password, got %s", w.Body.String())
+		}
+	})
+}
+
+func TestAuthHandler_ResetUserMFA(t *testing.T) {
+	ctx, err := tests.SetupTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx)
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "password123", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	technicianRole, _ := tests.CreateTestRole(ctx.DB, owner.Organization.ID, models.RoleTypeTechnician)
+	technician, err := tests.CreateTestUser(ctx.DB, owner.Organization.ID, technicianRole.ID, "tech@example.com", "password123", true)
+	if err != nil {
+		t.Fatalf("Failed to create technician: %v", err)
+	}
+	ownerToken, _ := tests.GenerateTestAccessToken(ctx, owner)
+	technicianToken, _ := tests.GenerateTestAccessToken(ctx, &tests.TestUser{Organization: owner.Organization, Role: technicianRole, User: technician})
+
+	setupMFA(t, ctx, ownerToken, "owner@example.com")
+	_, recoveryCodes := setupMFA(t, ctx, technicianToken, "tech@example.com")
+	session, err := tests.ParseLoginResponse(verifyMFA(ctx, validation.MFAVerifyRequest{
+		MFAToken:     loginChallenge(t, ctx, "tech@example.com", "password123").MFAToken,
+		RecoveryCode: recoveryCodes[0],
+	}))
+	if err != nil || session.RefreshToken == "" {
+		t.Fatalf("Technician login failed: %v", err)
+	}
+
+	t.Run("Users can't reset second factors without permission", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", fmt.Sprintf("/api/v1/users/%d/mfa", owner.User.ID), technicianToken, nil)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected a technician to be forbidden, got %d: %s", w.Code, w.Body.String())
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", fmt.Sprintf("/api/v1/users/%d/mfa", owner.User.ID), ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "CANNOT_RESET_OWN_MFA") {
+			t.Errorf("Expected resetting one's own second factor to be refused, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Users can't reset the second factor of more privileged users", func(t *testing.T) {
+		adminToken := userAdminToken(t, ctx, owner.Organization.ID)
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", fmt.Sprintf("/api/v1/users/%d/mfa", owner.User.ID), adminToken, nil)
+		if !tests.AssertResponseError(w, http.StatusForbidden, "PERMISSION_ESCALATION") {
+			t.Errorf("Expected resetting an owner's second factor to be refused, got %d: %s", w.Code, w.Body.String())
+		}
+		var stored models.User
+		ctx.DB.First(&stored, owner.User.ID)
+		if !stored.IsMFAEnabled() {
+			t.Errorf("Expected the owner to keep their second factor")
+		}
+	})
+
+	t.Run("Resetting removes the second factor and signs the user out", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", fmt.Sprintf("/api/v1/users/%d/mfa", technician.ID), ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Resetting MFA failed: %s", w.Body.String())
+		}
+		var user validation.UserResponse
+		if err := tests.ParseDataResponse(w, &user); err != nil || user.MFAEnabled {
+			t.Errorf("Expected MFA to be off, got %s", w.Body.String())
+		}
+
+		w = tests.MakeRefreshRequest(ctx.Router, session.RefreshToken)
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_REFRESH_TOKEN") {
+			t.Errorf("Expected the technician's sessions to be revoked, got %d: %s", w.Code, w.Body.String())
+		}
+		var revoked models.UserSession
+		ctx.DB.Where("user_id = ?", technician.ID).First(&revoked)
+		if revoked.RevokedReason != models.SessionRevokedMFAReset {
+			t.Errorf("Expected the session to be revoked for an MFA reset, got %q", revoked.RevokedReason)
+		}
+
+		w = tests.MakeLoginRequest(ctx.Router, "tech@example.com", "password123")
+		if login, err := tests.ParseLoginResponse(w); err != nil || login.AccessToken == "" {
+			t.Errorf("Expected the technician to sign in with a password, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Users of other organizations are not found", func(t *testing.T) {
+		other := &models.Organization{Name: "Other", SubDomain: "other", ContactEmail: "admin@other.com", Active: true, PlanType: "basic"}
+		ctx.DB.Create(other)
+		otherRole, _ := tests.CreateTestRole(ctx.DB, other.ID, models.RoleTypeOwner)
+		otherUser, err := tests.CreateTestUser(ctx.DB, other.ID, otherRole.ID, "other@example.com", "password123", true)
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", fmt.Sprintf("/api/v1/users/%d/mfa", otherUser.ID), ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusNotFound, "USER_NOT_FOUND") {
+			t.Errorf("Expected a user of another organization not to be found, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+// userAdminToken creates a user whose custom role may only administer users and returns their access token
+func userAdminToken(t *testing.T, ctx *tests.TestContext, orgID uint) string {
+	t.Helper()
+
+	role, err := tests.CreateTestRoleWithPermissions(ctx.DB, orgID, "user_admin", []string{"users.read", "users.update"})
+	if err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	admin, err := tests.CreateTestUser(ctx.DB, orgID, role.ID, "user-admin@example.com", "password123", true)
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	token, err := ctx.JWTService.GenerateAccessToken(admin.ID, orgID, admin.Email, string(role.Name))
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	return token
+}
@@ -125,8 +125,10 @@ func TestAuthHandler_Register_Success(t *testing.T) {
 		if user.RoleID != ownerRole.ID {
 			t.Errorf("User was not assigned owner role. Expected role ID %d, got %d", ownerRole.ID, user.RoleID)
 		}
-		if user.RefreshToken == "" {
-			t.Error("User refresh token was not set")
+		var sessions int64
+		ctx.DB.Model(&models.UserSession{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Count(&sessions)
+		if sessions != 1 {
+			t.Errorf("Expected a session for the new user, got %d", sessions)
 		}
 		if user.LastLoginAt == nil {
 			t.Error("User last login time was not set")
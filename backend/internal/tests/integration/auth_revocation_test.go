@@ -0,0 +1,177 @@
+package integration_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/validation"
+)
+
+// login signs the user in on a new device and returns the access token
+func login(t *testing.T, ctx *tests.RoleTestContext, email, password string) string {
+	t.Helper()
+	login, err := tests.ParseLoginResponse(tests.MakeLoginRequest(ctx.Router, email, password))
+	if err != nil || login.AccessToken == "" {
+		t.Fatalf("Login of %s failed: %v", email, err)
+	}
+	return login.AccessToken
+}
+
+// assertRevoked checks that the access token no longer authenticates
+func assertRevoked(t *testing.T, ctx *tests.RoleTestContext, accessToken, reason string) {
+	t.Helper()
+	w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/auth/me", accessToken, nil)
+	if !tests.AssertResponseError(w, http.StatusUnauthorized, "TOKEN_REVOKED") {
+		t.Errorf("Expected the access token to be revoked %s, got %d: %s", reason, w.Code, w.Body.String())
+	}
+}
+
+// assertValid checks that the access token still authenticates
+func assertValid(t *testing.T, ctx *tests.RoleTestContext, accessToken, reason string) {
+	t.Helper()
+	w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/auth/me", accessToken, nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the access token to stay valid %s, got %d: %s", reason, w.Code, w.Body.String())
+	}
+}
+
+func TestAuthHandler_TokenRevocation(t *testing.T) {
+	ctx, err := tests.SetupRoleTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx.TestContext)
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "CurrentPass123!", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	technicianRole, _ := tests.CreateTestRole(ctx.DB, owner.Organization.ID, models.RoleTypeTechnician)
+	technician, err := tests.CreateTestUser(ctx.DB, owner.Organization.ID, technicianRole.ID, "tech@example.com", "password123", true)
+	if err != nil {
+		t.Fatalf("Failed to create technician: %v", err)
+	}
+
+	t.Run("Logging out revokes the access token of the session only", func(t *testing.T) {
+		phone := login(t, ctx, "tech@example.com", "password123")
+		laptop := login(t, ctx, "tech@example.com", "password123")
+
+		if w := tests.MakeLogoutRequest(ctx.Router, phone); w.Code != http.StatusOK {
+			t.Fatalf("Logout failed: %d %s", w.Code, w.Body.String())
+		}
+		assertRevoked(t, ctx, phone, "after logging out")
+		assertValid(t, ctx, laptop, "on the other device")
+	})
+
+	t.Run("Revoking a session revokes its access tokens", func(t *testing.T) {
+		phone := login(t, ctx, "tech@example.com", "password123")
+		laptop := login(t, ctx, "tech@example.com", "password123")
+		var session models.UserSession
+		if err := ctx.DB.Where("user_id = ? AND revoked_at IS NULL", technician.ID).Order("id DESC").First(&session).Error; err != nil {
+			t.Fatalf("Failed to load session: %v", err)
+		}
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", fmt.Sprintf("/api/v1/auth/sessions/%d", session.ID), phone, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Revoking the session failed: %d %s", w.Code, w.Body.String())
+		}
+		assertRevoked(t, ctx, laptop, "after its session was revoked")
+		assertValid(t, ctx, phone, "in the session revoking the other")
+	})
+
+	t.Run("Signing out everywhere revokes every access token", func(t *testing.T) {
+		phone := login(t, ctx, "tech@example.com", "password123")
+		laptop := login(t, ctx, "tech@example.com", "password123")
+
+		if w := tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", "/api/v1/auth/sessions", phone, nil); w.Code != http.StatusOK {
+			t.Fatalf("Signing out everywhere failed: %d %s", w.Code, w.Body.String())
+		}
+		assertRevoked(t, ctx, phone, "after signing out everywhere")
+		assertRevoked(t, ctx, laptop, "after signing out everywhere")
+		tests.WaitForNextSecond()
+		assertValid(t, ctx, login(t, ctx, "tech@example.com", "password123"), "after signing in again")
+	})
+
+	t.Run("Changing the password revokes the user's access tokens", func(t *testing.T) {
+		accessToken := login(t, ctx, "owner@example.com", "CurrentPass123!")
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/change-password", accessToken, validation.ChangePasswordRequest{
+			CurrentPassword: "CurrentPass123!",
+			NewPassword:     "NewStrongPass456@",
+		})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Changing the password failed: %d %s", w.Code, w.Body.String())
+		}
+		assertRevoked(t, ctx, accessToken, "after the password change")
+		tests.WaitForNextSecond()
+		assertValid(t, ctx, login(t, ctx, "owner@example.com", "NewStrongPass456@"), "after signing in with the new password")
+	})
+
+	ownerToken := login(t, ctx, "owner@example.com", "NewStrongPass456@")
+
+	t.Run("Changing the role revokes the user's access tokens", func(t *testing.T) {
+		accessToken := login(t, ctx, "tech@example.com", "password123")
+		dispatcherRole, err := tests.CreateTestRole(ctx.DB, owner.Organization.ID, models.RoleType("dispatcher"))
+		if err != nil {
+			t.Fatalf("Failed to create role: %v", err)
+		}
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "PUT", fmt.Sprintf("/api/v1/users/%d/role", technician.ID), ownerToken, map[string]interface{}{"role_id": dispatcherRole.ID})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Assigning the role failed: %d %s", w.Code, w.Body.String())
+		}
+		assertRevoked(t, ctx, accessToken, "naming the previous role")
+		assertValid(t, ctx, ownerToken, "of the owner assigning the role")
+	})
+
+	t.Run("Deactivating a user cuts them off", func(t *testing.T) {
+		tests.WaitForNextSecond()
+		accessToken := login(t, ctx, "tech@example.com", "password123")
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/users/%d/deactivate", technician.ID), accessToken, nil)
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("Expected users without permission to be forbidden, got %d: %s", w.Code, w.Body.String())
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/users/%d/deactivate", technician.ID), ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Deactivating the user failed: %d %s", w.Code, w.Body.String())
+		}
+		assertRevoked(t, ctx, accessToken, "after deactivation")
+		w = tests.MakeLoginRequest(ctx.Router, "tech@example.com", "password123")
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "ACCOUNT_DISABLED") {
+			t.Errorf("Expected a deactivated user not to sign in, got %d: %s", w.Code, w.Body.String())
+		}
+		var active int64
+		ctx.DB.Model(&models.UserSession{}).Where("user_id = ? AND revoked_at IS NULL", technician.ID).Count(&active)
+		if active != 0 {
+			t.Errorf("Expected the sessions of a deactivated user to be revoked, got %d", active)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/users/%d/activate", technician.ID), ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Activating the user failed: %d %s", w.Code, w.Body.String())
+		}
+		tests.WaitForNextSecond()
+		assertValid(t, ctx, login(t, ctx, "tech@example.com", "password123"), "after reactivation")
+	})
+
+	t.Run("Users cannot deactivate more privileged users", func(t *testing.T) {
+		adminToken := userAdminToken(t, ctx.TestContext, owner.Organization.ID)
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/users/%d/deactivate", owner.User.ID), adminToken, nil)
+		if !tests.AssertResponseError(w, http.StatusForbidden, "PERMISSION_ESCALATION") {
+			t.Errorf("Expected deactivating an owner to be refused, got %d: %s", w.Code, w.Body.String())
+		}
+		assertValid(t, ctx, ownerToken, "of the owner")
+	})
+
+	t.Run("The last active owner cannot be deactivated", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/users/%d/deactivate", owner.User.ID), ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusConflict, "LAST_OWNER") {
+			t.Errorf("Expected LAST_OWNER, got %d: %s", w.Code, w.Body.String())
+		}
+		assertValid(t, ctx, ownerToken, "of the last owner")
+	})
+}
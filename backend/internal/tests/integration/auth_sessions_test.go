@@ -0,0 +1,161 @@
+package integration_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/validation"
+)
+
+func TestAuthHandler_Sessions(t *testing.T) {
+	ctx, err := tests.SetupTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx)
+
+	if _, err := tests.CreateCompleteTestUser(ctx.DB, "test@example.com", "password123", models.RoleTypeOwner, true); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	login := func(t *testing.T, deviceName string) *validation.LoginResponse {
+		t.Helper()
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/login", "", validation.UserLoginRequest{
+			Email:      "test@example.com",
+			Password:   "password123",
+			DeviceName: deviceName,
+		})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Login failed: %s", w.Body.String())
+		}
+		resp, err := tests.ParseLoginResponse(w)
+		if err != nil {
+			t.Fatalf("Failed to parse login response: %v", err)
+		}
+		return resp
+	}
+	refresh := func(t *testing.T, refreshToken string) *validation.TokenResponse {
+		t.Helper()
+		w := tests.MakeRefreshRequest(ctx.Router, refreshToken)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Token refresh failed: %s", w.Body.String())
+		}
+		resp, err := tests.ParseTokenResponse(w)
+		if err != nil {
+			t.Fatalf("Failed to parse token response: %v", err)
+		}
+		return resp
+	}
+	listSessions := func(t *testing.T, accessToken string) []validation.SessionResponse {
+		t.Helper()
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/auth/sessions", accessToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Listing sessions failed: %s", w.Body.String())
+		}
+		var sessions []validation.SessionResponse
+		if err := tests.ParseDataResponse(w, &sessions); err != nil {
+			t.Fatalf("Failed to parse sessions: %v", err)
+		}
+		return sessions
+	}
+
+	t.Run("Refresh tokens are rotated on every use", func(t *testing.T) {
+		first := login(t, "Phone")
+		second := refresh(t, first.RefreshToken)
+		if second.RefreshToken == "" || second.RefreshToken == first.RefreshToken {
+			t.Fatalf("Expected a new refresh token")
+		}
+		third := refresh(t, second.RefreshToken)
+		if third.AccessToken == "" || third.RefreshToken == second.RefreshToken {
+			t.Errorf("Expected the rotated token to be refreshed again")
+		}
+	})
+
+	t.Run("Reusing a rotated refresh token revokes the session", func(t *testing.T) {
+		stolen := login(t, "Phone")
+		current := refresh(t, stolen.RefreshToken)
+
+		w := tests.MakeRefreshRequest(ctx.Router, stolen.RefreshToken)
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "REFRESH_TOKEN_REUSED") {
+			t.Errorf("Expected REFRESH_TOKEN_REUSED, got %d: %s", w.Code, w.Body.String())
+		}
+
+		// The legitimate device is signed out too, as it can't be told apart from the copy
+		w = tests.MakeRefreshRequest(ctx.Router, current.RefreshToken)
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_REFRESH_TOKEN") {
+			t.Errorf("Expected INVALID_REFRESH_TOKEN, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var session models.UserSession
+		ctx.DB.Order("id DESC").First(&session)
+		if session.RevokedReason != models.SessionRevokedTokenReuse {
+			t.Errorf("Expected the session to be revoked for token reuse, got %q", session.RevokedReason)
+		}
+	})
+
+	t.Run("Devices keep separate sessions", func(t *testing.T) {
+		// Start from a clean slate
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", "/api/v1/auth/sessions", login(t, "").AccessToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Revoking all sessions failed: %s", w.Body.String())
+		}
+		tests.WaitForNextSecond()
+
+		phone := login(t, "Phone")
+		tablet := login(t, "Tablet")
+		refresh(t, phone.RefreshToken)
+		tablet2 := refresh(t, tablet.RefreshToken)
+
+		sessions := listSessions(t, tablet2.AccessToken)
+		if len(sessions) != 2 {
+			t.Fatalf("Expected two sessions, got %+v", sessions)
+		}
+		var phoneSession validation.SessionResponse
+		for _, session := range sessions {
+			if session.Current != (session.DeviceName == "Tablet") {
+				t.Errorf("Expected only the tablet's session to be current, got %+v", session)
+			}
+			if session.DeviceName == "Phone" {
+				phoneSession = session
+			}
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", fmt.Sprintf("/api/v1/auth/sessions/%d", phoneSession.ID), tablet2.AccessToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Revoking the phone's session failed: %s", w.Body.String())
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", fmt.Sprintf("/api/v1/auth/sessions/%d", phoneSession.ID), tablet2.AccessToken, nil)
+		if !tests.AssertResponseError(w, http.StatusNotFound, "SESSION_NOT_FOUND") {
+			t.Errorf("Expected SESSION_NOT_FOUND for a revoked session, got %d", w.Code)
+		}
+
+		if sessions := listSessions(t, tablet2.AccessToken); len(sessions) != 1 || !sessions[0].Current {
+			t.Errorf("Expected only the tablet's session to be left, got %+v", sessions)
+		}
+		refresh(t, tablet2.RefreshToken)
+	})
+
+	t.Run("Changing the password revokes every session", func(t *testing.T) {
+		session := login(t, "Laptop")
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/change-password", session.AccessToken, map[string]string{
+			"current_password": "password123",
+			"new_password":     "NewPassword123!",
+		})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Password change failed: %s", w.Body.String())
+		}
+
+		var active int64
+		ctx.DB.Model(&models.UserSession{}).Where("revoked_at IS NULL").Count(&active)
+		if active != 0 {
+			t.Errorf("Expected every session to be revoked, %d are active", active)
+		}
+		w = tests.MakeRefreshRequest(ctx.Router, session.RefreshToken)
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_REFRESH_TOKEN") {
+			t.Errorf("Expected INVALID_REFRESH_TOKEN, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
@@ -10,6 +10,7 @@ import (
 
 	"routrapp-api/internal/models"
 	"routrapp-api/internal/tests"
+	"routrapp-api/internal/utils/auth"
 	"routrapp-api/internal/validation"
 )
 
@@ -207,16 +208,6 @@ func TestAuthHandler_ChangePassword(t *testing.T) {
 		expectedCode   string
 		checkSuccess   bool
 	}{
-		{
-			name: "Valid password change",
-			request: validation.ChangePasswordRequest{
-				CurrentPassword: "CurrentPass123!",
-				NewPassword:     "NewStrongPass456@",
-			},
-			accessToken:    accessToken,
-			expectedStatus: http.StatusOK,
-			checkSuccess:   true,
-		},
 		{
 			name: "Password change with weak new password",
 			request: validation.ChangePasswordRequest{
@@ -257,6 +248,17 @@ func TestAuthHandler_ChangePassword(t *testing.T) {
 			expectedStatus: http.StatusUnauthorized,
 			expectedCode:   "INVALID_CURRENT_PASSWORD",
 		},
+		{
+			// Runs after the other cases: changing the password revokes the access token
+			name: "Valid password change",
+			request: validation.ChangePasswordRequest{
+				CurrentPassword: "CurrentPass123!",
+				NewPassword:     "NewStrongPass456@",
+			},
+			accessToken:    accessToken,
+			expectedStatus: http.StatusOK,
+			checkSuccess:   true,
+		},
 		{
 			name: "Password change without authentication",
 			request: validation.ChangePasswordRequest{
@@ -486,6 +488,85 @@ func TestAuthHandler_Login(t *testing.T) {
 	})
 }
 
+func TestAuthHandler_LoginIsScopedToOrganization(t *testing.T) {
+	ctx, err := tests.SetupTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx)
+
+	// The same email is registered with two organizations, each with its own password
+	first, err := tests.CreateCompleteTestUser(ctx.DB, "shared@example.com", "FirstPass123!", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create first user: %v", err)
+	}
+	second := models.Organization{Name: "Second Org", SubDomain: "second", ContactEmail: "second@example.com", Active: true}
+	if err := ctx.DB.Create(&second).Error; err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	secondRole, err := tests.CreateTestRole(ctx.DB, second.ID, models.RoleTypeTechnician)
+	if err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	if _, err := tests.CreateTestUser(ctx.DB, second.ID, secondRole.ID, "shared@example.com", "SecondPass123!", true); err != nil {
+		t.Fatalf("Failed to create second user: %v", err)
+	}
+
+	login := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		return tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/auth/login", "", body)
+	}
+
+	t.Run("Ambiguous emails require the organization", func(t *testing.T) {
+		// Refused like an unknown email, so the response doesn't reveal the address is registered
+		w := login(map[string]interface{}{"email": "shared@example.com", "password": "FirstPass123!"})
+		unknown := login(map[string]interface{}{"email": "nobody@example.com", "password": "FirstPass123!"})
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS") {
+			t.Errorf("Expected INVALID_CREDENTIALS, got %d: %s", w.Code, w.Body.String())
+		}
+		if w.Body.String() != unknown.Body.String() {
+			t.Errorf("Expected the same response as for an unknown email, got %s and %s", w.Body.String(), unknown.Body.String())
+		}
+	})
+
+	t.Run("The subdomain selects the organization", func(t *testing.T) {
+		w := login(map[string]interface{}{"email": "shared@example.com", "password": "SecondPass123!", "sub_domain": "second"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		response, err := tests.ParseLoginResponse(w)
+		if err != nil {
+			t.Fatalf("Failed to parse login response: %v", err)
+		}
+		if response.User.Role != models.RoleTypeTechnician.String() {
+			t.Errorf("Expected the second organization's account, got role %s", response.User.Role)
+		}
+
+		// The other organization's password does not unlock this account
+		w = login(map[string]interface{}{"email": "shared@example.com", "password": "FirstPass123!", "sub_domain": "second"})
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS") {
+			t.Errorf("Expected INVALID_CREDENTIALS, got %d", w.Code)
+		}
+	})
+
+	t.Run("Unknown subdomains are rejected", func(t *testing.T) {
+		w := login(map[string]interface{}{"email": "shared@example.com", "password": "FirstPass123!", "sub_domain": "missing"})
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS") {
+			t.Errorf("Expected INVALID_CREDENTIALS, got %d", w.Code)
+		}
+	})
+
+	t.Run("Refresh tokens are bound to their organization", func(t *testing.T) {
+		refreshToken, err := ctx.JWTService.GenerateRefreshToken(first.User.ID, second.ID, first.User.Email, first.Role.Name.String())
+		if err != nil {
+			t.Fatalf("Failed to generate refresh token: %v", err)
+		}
+		w := tests.MakeRefreshRequest(ctx.Router, refreshToken)
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "INVALID_REFRESH_TOKEN") {
+			t.Errorf("Expected INVALID_REFRESH_TOKEN, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
 func TestAuthHandler_RefreshToken(t *testing.T) {
 	ctx, err := tests.SetupTestContext()
 	if err != nil {
@@ -736,11 +817,11 @@ func TestAuthHandler_Logout(t *testing.T) {
 					t.Logf("Response body: %s", w.Body.String())
 				}
 
-				// Verify refresh token was cleared from database
-				var user models.User
-				ctx.DB.First(&user, "email = ?", "test@example.com")
-				if user.RefreshToken != "" {
-					t.Error("Refresh token should be cleared from database")
+				// Verify the session was revoked
+				var session models.UserSession
+				ctx.DB.First(&session, "refresh_token_hash = ?", auth.HashToken(loginResp.RefreshToken))
+				if session.RevokedAt == nil || session.RevokedReason != models.SessionRevokedLogout {
+					t.Error("Session should be revoked on logout")
 				}
 			} else if tt.expectedCode != "" {
 				if !tests.AssertResponseError(w, tt.expectedStatus, tt.expectedCode) {
@@ -0,0 +1,257 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tenant"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/validation"
+)
+
+func TestHandlers_InMemoryRepositories(t *testing.T) {
+	ctx := tests.SetupRepositoryTestContext()
+
+	org, err := tests.CreateRepositoryTestOrganization(ctx, "acme")
+	if err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	other, err := tests.CreateRepositoryTestOrganization(ctx, "other")
+	if err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+
+	owner, err := tests.CreateRepositoryTestUser(ctx, org.ID, models.RoleTypeOwner, "owner@acme.example.com")
+	if err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	techUser, err := tests.CreateRepositoryTestUser(ctx, org.ID, models.RoleTypeTechnician, "tech@acme.example.com")
+	if err != nil {
+		t.Fatalf("Failed to create technician user: %v", err)
+	}
+	otherOwner, err := tests.CreateRepositoryTestUser(ctx, other.ID, models.RoleTypeOwner, "owner@other.example.com")
+	if err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+
+	token := func(user *models.User) string {
+		accessToken, err := tests.GenerateRepositoryTestAccessToken(ctx, user)
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+		return accessToken
+	}
+	ownerToken, techToken, otherToken := token(owner), token(techUser), token(otherOwner)
+
+	var created validation.TechnicianResponse
+	t.Run("Technician profiles are created and listed", func(t *testing.T) {
+		body := validation.TechnicianCreateRequest{UserID: techUser.ID, PhoneNumber: "5551234567"}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/technicians", ownerToken, body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		if err := tests.ParseDataResponse(w, &created); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if created.User.Email != techUser.Email || created.User.Role != models.RoleTypeTechnician.String() {
+			t.Errorf("Expected the technician with its user and role, got %+v", created)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/technicians", ownerToken, body)
+		if !tests.AssertResponseError(w, http.StatusConflict, "TECHNICIAN_EXISTS") {
+			t.Errorf("Expected TECHNICIAN_EXISTS, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/technicians?search=TECH@&status=active", ownerToken, nil)
+		var technicians []validation.TechnicianResponse
+		if err := tests.ParseDataResponse(w, &technicians); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("Expected a technician list, got %d: %s", w.Code, w.Body.String())
+		}
+		if len(technicians) != 1 || technicians[0].ID != created.ID {
+			t.Errorf("Expected the created technician, got %+v", technicians)
+		}
+	})
+
+	t.Run("Other organizations cannot see the technician", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", fmt.Sprintf("/api/v1/technicians/%d", created.ID), otherToken, nil)
+		if !tests.AssertResponseError(w, http.StatusNotFound, "TECHNICIAN_NOT_FOUND") {
+			t.Errorf("Expected TECHNICIAN_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/technicians", otherToken, validation.TechnicianCreateRequest{UserID: techUser.ID, PhoneNumber: "5550000000"})
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "INVALID_USER") {
+			t.Errorf("Expected INVALID_USER, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Technicians update their own profile", func(t *testing.T) {
+		onBreak := models.TechnicianStatusOnBreak
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", "/api/v1/technicians/me", techToken, validation.TechnicianSelfUpdateRequest{Status: &onBreak})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/technicians/me", techToken, nil)
+		var profile validation.TechnicianResponse
+		if err := tests.ParseDataResponse(w, &profile); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if profile.Status != models.TechnicianStatusOnBreak {
+			t.Errorf("Expected status %s, got %s", models.TechnicianStatusOnBreak, profile.Status)
+		}
+	})
+
+	scoped := tenant.WithOrganization(context.Background(), org.ID)
+	route := models.Route{
+		Name:         "Morning run",
+		TechnicianID: &created.ID,
+		Status:       models.RouteStatusStarted,
+		Stops: []models.RouteStop{
+			{Name: "Second", SequenceNum: 2},
+			{Name: "First", SequenceNum: 1},
+		},
+	}
+	if err := ctx.Repositories.Routes.Create(scoped, &route); err != nil {
+		t.Fatalf("Failed to create route: %v", err)
+	}
+	start := time.Now().Add(-time.Hour).UTC()
+	for i, activityType := range []string{"arrive", "note", "complete"} {
+		activity := models.RouteActivity{
+			RouteID:      route.ID,
+			RouteStopID:  &route.Stops[1].ID,
+			TechnicianID: created.ID,
+			ActivityType: activityType,
+			Timestamp:    start.Add(time.Duration(i) * time.Minute),
+		}
+		if err := ctx.Repositories.Activities.Create(scoped, &activity); err != nil {
+			t.Fatalf("Failed to create activity: %v", err)
+		}
+	}
+
+	t.Run("Routes and timelines are read through the repositories", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", fmt.Sprintf("/api/v1/routes/%d", route.ID), ownerToken, nil)
+		var loaded validation.RouteResponse
+		if err := tests.ParseDataResponse(w, &loaded); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("Expected the route, got %d: %s", w.Code, w.Body.String())
+		}
+		if len(loaded.Stops) != 2 || loaded.Stops[0].Name != "First" {
+			t.Errorf("Expected the stops in sequence order, got %+v", loaded.Stops)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", fmt.Sprintf("/api/v1/routes/%d/activities?activity_type=arrive&activity_type=complete", route.ID), ownerToken, nil)
+		var activities []validation.RouteActivityResponse
+		if err := tests.ParseDataResponse(w, &activities); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("Expected the timeline, got %d: %s", w.Code, w.Body.String())
+		}
+		if len(activities) != 2 || activities[0].ActivityType != "arrive" || activities[1].ActivityType != "complete" {
+			t.Errorf("Expected the filtered timeline in order, got %+v", activities)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", fmt.Sprintf("/api/v1/routes/%d/activities?route_id=%d", route.ID, route.ID+100), ownerToken, nil)
+		activities = nil
+		if err := tests.ParseDataResponse(w, &activities); err != nil || len(activities) != 0 {
+			t.Errorf("Expected a route_id filter for another route to match nothing, got %+v (%v)", activities, err)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", fmt.Sprintf("/api/v1/technicians/%d/activities?page=2&page_size=2", created.ID), techToken, nil)
+		activities = nil
+		if err := tests.ParseDataResponse(w, &activities); err != nil || len(activities) != 1 || activities[0].ActivityType != "complete" {
+			t.Errorf("Expected the last activity on the second page, got %+v (%v)", activities, err)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", fmt.Sprintf("/api/v1/routes/%d/activities", route.ID), otherToken, nil)
+		if !tests.AssertResponseError(w, http.StatusNotFound, "ROUTE_NOT_FOUND") {
+			t.Errorf("Expected ROUTE_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Routes are managed through the repositories", func(t *testing.T) {
+		body := validation.RouteCreateRequest{
+			Name:         "Evening run",
+			TechnicianID: &created.ID,
+			Stops: []validation.RouteStopCreateRequest{
+				{Name: "Depot", Address: "1 Main St", Lat: 40.7, Lng: -74.0, SequenceNum: 1, StopType: "pickup", Duration: 10},
+				{Name: "Customer", Address: "2 Main St", Lat: 40.8, Lng: -74.1, SequenceNum: 2, StopType: "delivery", Duration: 15},
+			},
+		}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/routes", ownerToken, body)
+		var evening validation.RouteResponse
+		if err := tests.ParseDataResponse(w, &evening); err != nil || w.Code != http.StatusCreated {
+			t.Fatalf("Expected the created route, got %d: %s", w.Code, w.Body.String())
+		}
+		if evening.Status != models.RouteStatusAssigned || evening.Technician == nil || evening.Technician.User.Email != techUser.Email {
+			t.Errorf("Expected the route assigned to the technician, got %+v", evening)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/routes?search=evening", ownerToken, nil)
+		var listed []validation.RouteResponse
+		if err := tests.ParseDataResponse(w, &listed); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("Expected a route list, got %d: %s", w.Code, w.Body.String())
+		}
+		if len(listed) != 1 || listed[0].ID != evening.ID || listed[0].StopsCount != 2 {
+			t.Errorf("Expected the evening run with its two stops, got %+v", listed)
+		}
+
+		name, first, second := "Late run", 1, 2
+		update := validation.RouteUpdateRequest{
+			Name: &name,
+			Stops: []validation.RouteStopUpdateRequest{
+				{ID: evening.Stops[0].ID, SequenceNum: &second},
+				{ID: evening.Stops[1].ID, SequenceNum: &first},
+			},
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", fmt.Sprintf("/api/v1/routes/%d", evening.ID), ownerToken, update)
+		var updated validation.RouteResponse
+		if err := tests.ParseDataResponse(w, &updated); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("Expected the updated route, got %d: %s", w.Code, w.Body.String())
+		}
+		if updated.Name != name || updated.Stops[0].Name != "Customer" {
+			t.Errorf("Expected the renamed route with its stops swapped, got %+v", updated)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/routes/%d/optimize", evening.ID), ownerToken, validation.RouteOptimizeRequest{PinFirst: true})
+		var optimized validation.RouteOptimizationResponse
+		if err := tests.ParseDataResponse(w, &optimized); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("Expected the optimized route, got %d: %s", w.Code, w.Body.String())
+		}
+		if !optimized.Route.IsOptimized || optimized.Route.TotalDistance <= 0 || optimized.Route.Stops[0].Name != "Customer" {
+			t.Errorf("Expected the optimization to be stored, got %+v", optimized.Route)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", fmt.Sprintf("/api/v1/routes/%d", evening.ID), otherToken, nil)
+		if !tests.AssertResponseError(w, http.StatusNotFound, "ROUTE_NOT_FOUND") {
+			t.Errorf("Expected ROUTE_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", fmt.Sprintf("/api/v1/routes/%d", evening.ID), ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", fmt.Sprintf("/api/v1/routes/%d", evening.ID), ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusNotFound, "ROUTE_NOT_FOUND") {
+			t.Errorf("Expected the deleted route to be gone, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Technicians on a route cannot be deactivated", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/technicians/%d/deactivate", created.ID), ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusConflict, "TECHNICIAN_ON_ROUTE") {
+			t.Errorf("Expected TECHNICIAN_ON_ROUTE, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Users update their profile", func(t *testing.T) {
+		firstName := "Ada"
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "PUT", "/api/v1/users/profile", ownerToken, validation.UserUpdateRequest{FirstName: &firstName})
+		var profile validation.UserResponse
+		if err := tests.ParseDataResponse(w, &profile); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("Expected the updated profile, got %d: %s", w.Code, w.Body.String())
+		}
+		if profile.FirstName != firstName || profile.Role != models.RoleTypeOwner.String() {
+			t.Errorf("Expected the renamed owner, got %+v", profile)
+		}
+	})
+}
@@ -0,0 +1,317 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/validation"
+)
+
+func TestRoleHandler_CustomRoles(t *testing.T) {
+	ctx, err := tests.SetupRoleTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx.TestContext)
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "Password123!", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	ownerToken, err := tests.GenerateTestAccessToken(ctx.TestContext, owner)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	orgID := owner.Organization.ID
+
+	techRole, err := tests.CreateTestRole(ctx.DB, orgID, models.RoleTypeTechnician)
+	if err != nil {
+		t.Fatalf("Failed to create technician role: %v", err)
+	}
+	technician, err := tests.CreateTestUser(ctx.DB, orgID, techRole.ID, "tech@example.com", "Password123!", true)
+	if err != nil {
+		t.Fatalf("Failed to create technician: %v", err)
+	}
+
+	var auditor validation.RoleResponse
+
+	t.Run("Permission catalogue is published", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/permissions", ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var catalogue []models.PermissionDefinition
+		if err := tests.ParseDataResponse(w, &catalogue); err != nil {
+			t.Fatalf("Failed to parse catalogue: %v", err)
+		}
+		if len(catalogue) != len(models.PermissionCatalogue) {
+			t.Errorf("Expected %d permissions, got %d", len(models.PermissionCatalogue), len(catalogue))
+		}
+	})
+
+	t.Run("Owner creates a custom role", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/roles", ownerToken, map[string]interface{}{
+			"name":         "auditor",
+			"display_name": "Read-only auditor",
+			"permissions":  []string{"routes.read", "roles.read", "routes.read", "technicians.read"},
+		})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+		if err := tests.ParseDataResponse(w, &auditor); err != nil {
+			t.Fatalf("Failed to parse role: %v", err)
+		}
+		expected := []string{"roles.read", "routes.read", "technicians.read"}
+		if fmt.Sprint(auditor.Permissions) != fmt.Sprint(expected) {
+			t.Errorf("Expected de-duplicated sorted permissions %v, got %v", expected, auditor.Permissions)
+		}
+		if auditor.BuiltIn || !auditor.Active {
+			t.Errorf("Expected an active custom role, got %+v", auditor)
+		}
+	})
+
+	t.Run("Role creation is validated", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			body   map[string]interface{}
+			status int
+			code   string
+		}{
+			{"reserved name", map[string]interface{}{"name": "owner", "display_name": "Owner", "permissions": []string{"routes.read"}}, http.StatusBadRequest, "INVALID_ROLE_NAME"},
+			{"malformed name", map[string]interface{}{"name": "Dispatch Team", "display_name": "Dispatch", "permissions": []string{"routes.read"}}, http.StatusBadRequest, "INVALID_ROLE_NAME"},
+			{"unknown permission", map[string]interface{}{"name": "dispatcher", "display_name": "Dispatcher", "permissions": []string{"routes.read", "billing.read"}}, http.StatusBadRequest, "INVALID_PERMISSIONS"},
+			{"duplicate name", map[string]interface{}{"name": "auditor", "display_name": "Auditor", "permissions": []string{"routes.read"}}, http.StatusConflict, "ROLE_NAME_TAKEN"},
+		}
+		for _, tc := range cases {
+			w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/roles", ownerToken, tc.body)
+			if !tests.AssertResponseError(w, tc.status, tc.code) {
+				t.Errorf("%s: expected %d %s, got %d: %s", tc.name, tc.status, tc.code, w.Code, w.Body.String())
+			}
+		}
+	})
+
+	t.Run("Roles are listed with their user counts", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/roles", ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var roles []validation.RoleResponse
+		if err := tests.ParseDataResponse(w, &roles); err != nil {
+			t.Fatalf("Failed to parse roles: %v", err)
+		}
+		if len(roles) != 3 {
+			t.Fatalf("Expected 3 roles, got %d", len(roles))
+		}
+		counts := map[string]int64{}
+		for _, role := range roles {
+			counts[role.Name] = role.UserCount
+		}
+		if counts["owner"] != 1 || counts["technician"] != 1 || counts["auditor"] != 0 {
+			t.Errorf("Unexpected user counts %v", counts)
+		}
+	})
+
+	t.Run("Users are reassigned to a custom role and gain its permissions", func(t *testing.T) {
+		path := fmt.Sprintf("/api/v1/users/%d/role", technician.ID)
+		techToken, err := ctx.JWTService.GenerateAccessToken(technician.ID, orgID, technician.Email, models.RoleTypeTechnician.String())
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/roles", techToken, nil)
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("Expected technicians not to read roles, got %d", w.Code)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PUT", path, ownerToken, map[string]interface{}{"role_id": auditor.ID})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var user validation.UserResponse
+		if err := tests.ParseDataResponse(w, &user); err != nil {
+			t.Fatalf("Failed to parse user: %v", err)
+		}
+		if user.Role != "auditor" {
+			t.Errorf("Expected the auditor role, got %s", user.Role)
+		}
+
+		// The reassignment revoked the token; the stored role applies to tokens still naming the
+		// previous role
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/roles", techToken, nil)
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "TOKEN_REVOKED") {
+			t.Errorf("Expected the token naming the previous role to be revoked, got %d", w.Code)
+		}
+		tests.WaitForNextSecond()
+		techToken, err = ctx.JWTService.GenerateAccessToken(technician.ID, orgID, technician.Email, models.RoleTypeTechnician.String())
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/roles", techToken, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected the auditor role to read roles, got %d", w.Code)
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/roles", techToken, map[string]interface{}{
+			"name": "dispatcher", "display_name": "Dispatcher", "permissions": []string{"routes.read"},
+		})
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected the auditor role not to create roles, got %d", w.Code)
+		}
+	})
+
+	t.Run("Callers cannot grant permissions they lack", func(t *testing.T) {
+		// A supervisor may manage roles but only holds route permissions
+		supervisorRole := models.Role{Base: models.Base{OrganizationID: orgID}, Name: "supervisor", DisplayName: "Supervisor", Active: true}
+		if err := supervisorRole.SetPermissions([]string{"roles.*", "routes.*"}); err != nil {
+			t.Fatalf("Failed to set permissions: %v", err)
+		}
+		if err := ctx.DB.Create(&supervisorRole).Error; err != nil {
+			t.Fatalf("Failed to create supervisor role: %v", err)
+		}
+		supervisor, err := tests.CreateTestUser(ctx.DB, orgID, supervisorRole.ID, "supervisor@example.com", "Password123!", true)
+		if err != nil {
+			t.Fatalf("Failed to create supervisor: %v", err)
+		}
+		supervisorToken, err := ctx.JWTService.GenerateAccessToken(supervisor.ID, orgID, supervisor.Email, "supervisor")
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/roles", supervisorToken, map[string]interface{}{
+			"name": "dispatcher", "display_name": "Dispatcher", "permissions": []string{"routes.read", "users.delete"},
+		})
+		if !tests.AssertResponseError(w, http.StatusForbidden, "PERMISSION_ESCALATION") {
+			t.Fatalf("Expected PERMISSION_ESCALATION, got %d: %s", w.Code, w.Body.String())
+		}
+		if details := errorDetails(t, w.Body.Bytes()); fmt.Sprint(details["permissions"]) != "[users.delete]" {
+			t.Errorf("Expected users.delete to be reported, got %+v", details)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PUT", fmt.Sprintf("/api/v1/users/%d/role", supervisor.ID), supervisorToken, map[string]interface{}{"role_id": owner.Role.ID})
+		if !tests.AssertResponseError(w, http.StatusForbidden, "PERMISSION_ESCALATION") {
+			t.Errorf("Expected supervisors not to assign the owner role, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/roles", supervisorToken, map[string]interface{}{
+			"name": "dispatcher", "display_name": "Dispatcher", "permissions": []string{"routes.read", "routes.update_status"},
+		})
+		if w.Code != http.StatusCreated {
+			t.Errorf("Expected 201 for held permissions, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Role permission changes apply immediately", func(t *testing.T) {
+		techToken, err := ctx.JWTService.GenerateAccessToken(technician.ID, orgID, technician.Email, models.RoleTypeTechnician.String())
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", fmt.Sprintf("/api/v1/roles/%d", auditor.ID), ownerToken, map[string]interface{}{
+			"permissions": []string{"routes.read"},
+		})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var updated validation.RoleResponse
+		if err := tests.ParseDataResponse(w, &updated); err != nil {
+			t.Fatalf("Failed to parse role: %v", err)
+		}
+		if fmt.Sprint(updated.Permissions) != "[routes.read]" || updated.UserCount != 1 {
+			t.Errorf("Unexpected updated role %+v", updated)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/roles", techToken, nil)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected roles.read to be revoked, got %d", w.Code)
+		}
+	})
+
+	t.Run("Built-in roles are protected", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", fmt.Sprintf("/api/v1/roles/%d", owner.Role.ID), ownerToken, map[string]interface{}{
+			"permissions": []string{"routes.read"},
+		})
+		if !tests.AssertResponseError(w, http.StatusConflict, "ROLE_IMMUTABLE") {
+			t.Errorf("Expected ROLE_IMMUTABLE, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", fmt.Sprintf("/api/v1/roles/%d", owner.Role.ID), ownerToken, map[string]interface{}{
+			"display_name": "Administrator",
+		})
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected the owner role to be renamed, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", fmt.Sprintf("/api/v1/roles/%d", techRole.ID), ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusConflict, "ROLE_BUILT_IN") {
+			t.Errorf("Expected ROLE_BUILT_IN, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Roles with users cannot be deleted", func(t *testing.T) {
+		path := fmt.Sprintf("/api/v1/roles/%d", auditor.ID)
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", path, ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusConflict, "ROLE_IN_USE") {
+			t.Fatalf("Expected ROLE_IN_USE, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PUT", fmt.Sprintf("/api/v1/users/%d/role", technician.ID), ownerToken, map[string]interface{}{"role_id": techRole.ID})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", path, ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", path, ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusNotFound, "ROLE_NOT_FOUND") {
+			t.Errorf("Expected ROLE_NOT_FOUND after deletion, got %d", w.Code)
+		}
+	})
+
+	t.Run("The last active owner cannot be reassigned", func(t *testing.T) {
+		path := fmt.Sprintf("/api/v1/users/%d/role", owner.User.ID)
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "PUT", path, ownerToken, map[string]interface{}{"role_id": techRole.ID})
+		if !tests.AssertResponseError(w, http.StatusConflict, "LAST_OWNER") {
+			t.Fatalf("Expected LAST_OWNER, got %d: %s", w.Code, w.Body.String())
+		}
+
+		// Inactive owners do not count towards the organization's owners
+		if _, err := tests.CreateTestUser(ctx.DB, orgID, owner.Role.ID, "former@example.com", "Password123!", false); err != nil {
+			t.Fatalf("Failed to create inactive owner: %v", err)
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PUT", path, ownerToken, map[string]interface{}{"role_id": techRole.ID})
+		if !tests.AssertResponseError(w, http.StatusConflict, "LAST_OWNER") {
+			t.Fatalf("Expected LAST_OWNER with only an inactive second owner, got %d", w.Code)
+		}
+
+		coOwner, err := tests.CreateTestUser(ctx.DB, orgID, owner.Role.ID, "coowner@example.com", "Password123!", true)
+		if err != nil {
+			t.Fatalf("Failed to create co-owner: %v", err)
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PUT", path, ownerToken, map[string]interface{}{"role_id": techRole.ID})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 once another owner exists, got %d: %s", w.Code, w.Body.String())
+		}
+
+		// The reassigned owner immediately loses role management; their token naming the previous
+		// role is revoked
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PUT", fmt.Sprintf("/api/v1/users/%d/role", coOwner.ID), ownerToken, map[string]interface{}{"role_id": techRole.ID})
+		if !tests.AssertResponseError(w, http.StatusUnauthorized, "TOKEN_REVOKED") {
+			t.Errorf("Expected the token of a demoted owner to be revoked, got %d", w.Code)
+		}
+	})
+
+	t.Run("Roles are scoped to the organization", func(t *testing.T) {
+		tests.WaitForNextSecond()
+		otherToken, err := ctx.JWTService.GenerateAccessToken(owner.User.ID, orgID+100, owner.User.Email, models.RoleTypeOwner.String())
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", fmt.Sprintf("/api/v1/roles/%d", techRole.ID), otherToken, nil)
+		if w.Code != http.StatusForbidden && w.Code != http.StatusNotFound {
+			t.Errorf("Expected another organization not to see the role, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/validation"
+)
+
+func TestRouteHandler_Activities(t *testing.T) {
+	ctx, err := tests.SetupStreamTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx.TestContext)
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "Password123!", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	ownerToken, err := tests.GenerateTestAccessToken(ctx.TestContext, owner)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	orgID := owner.Organization.ID
+	techRole, err := tests.CreateTestRole(ctx.DB, orgID, models.RoleTypeTechnician)
+	if err != nil {
+		t.Fatalf("Failed to create technician role: %v", err)
+	}
+	technician := createLocatedTechnician(t, ctx.DB, orgID, techRole.ID, "tech@example.com", models.TechnicianStatusActive, nil, nil)
+	techToken, err := ctx.JWTService.GenerateAccessToken(technician.UserID, orgID, "tech@example.com", models.RoleTypeTechnician.String())
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	other := createLocatedTechnician(t, ctx.DB, orgID, techRole.ID, "other@example.com", models.TechnicianStatusActive, nil, nil)
+	otherToken, err := ctx.JWTService.GenerateAccessToken(other.UserID, orgID, "other@example.com", models.RoleTypeTechnician.String())
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	route := createAssignedRoute(t, ctx.DB, orgID, &technician.ID, "Morning run")
+	stops := []models.RouteStop{
+		{Base: models.Base{OrganizationID: orgID}, RouteID: route.ID, Name: "First", SequenceNum: 1},
+		{Base: models.Base{OrganizationID: orgID}, RouteID: route.ID, Name: "Second", SequenceNum: 2},
+	}
+	if err := ctx.DB.Create(&stops).Error; err != nil {
+		t.Fatalf("Failed to create stops: %v", err)
+	}
+	activitiesPath := fmt.Sprintf("/api/v1/routes/%d/activities", route.ID)
+
+	post := func(token string, req validation.RouteActivityCreateRequest) *validation.RouteActivityResultResponse {
+		t.Helper()
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", activitiesPath, token, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var result validation.RouteActivityResultResponse
+		if err := tests.ParseDataResponse(w, &result); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return &result
+	}
+
+	t.Run("Activities require a route in progress", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", activitiesPath, techToken, validation.RouteActivityCreateRequest{ActivityType: models.ActivityNote, Notes: "Too early"})
+		if !tests.AssertResponseError(w, http.StatusConflict, "ROUTE_NOT_IN_PROGRESS") {
+			t.Errorf("Expected ROUTE_NOT_IN_PROGRESS, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/routes/%d/start", route.ID), techToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected route to start, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Technician records notes and photos against a stop", func(t *testing.T) {
+		lat, lng := 40.7, -74.0
+		result := post(techToken, validation.RouteActivityCreateRequest{
+			RouteStopID:  &stops[0].ID,
+			ActivityType: models.ActivityNote,
+			Notes:        "Gate code 1234",
+			Lat:          &lat,
+			Lng:          &lng,
+		})
+		if result.Activity.TechnicianID != technician.ID || result.Activity.Lat == nil || *result.Activity.Lat != lat {
+			t.Errorf("Unexpected activity %+v", result.Activity)
+		}
+		if result.Stop == nil || result.Stop.NotesCount != 1 || result.Stop.IsCompleted {
+			t.Errorf("Expected the stop's notes count to be bumped, got %+v", result.Stop)
+		}
+
+		result = post(techToken, validation.RouteActivityCreateRequest{
+			RouteStopID:  &stops[0].ID,
+			ActivityType: models.ActivityPhoto,
+			PhotoURL:     "https://cdn.example.com/photo.jpg",
+		})
+		if result.Stop == nil || result.Stop.PhotosCount != 1 || result.Stop.NotesCount != 1 {
+			t.Errorf("Expected the stop's photos count to be bumped, got %+v", result.Stop)
+		}
+	})
+
+	t.Run("Completing a stop flips it once", func(t *testing.T) {
+		completedAt := time.Now().UTC()
+		result := post(techToken, validation.RouteActivityCreateRequest{
+			RouteStopID:  &stops[0].ID,
+			ActivityType: models.ActivityComplete,
+			Notes:        "Left with reception",
+			Timestamp:    &completedAt,
+		})
+		if result.Stop == nil || !result.Stop.IsCompleted || result.Stop.CompletedAt == nil || result.Stop.CompletedAt.Sub(completedAt).Abs() > time.Second {
+			t.Errorf("Expected a completed stop at %s, got %+v", completedAt, result.Stop)
+		}
+		if result.Stop != nil && result.Stop.NotesCount != 2 {
+			t.Errorf("Expected completion notes to be counted, got %d", result.Stop.NotesCount)
+		}
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", activitiesPath, techToken, validation.RouteActivityCreateRequest{RouteStopID: &stops[0].ID, ActivityType: models.ActivityComplete})
+		if !tests.AssertResponseError(w, http.StatusConflict, "STOP_ALREADY_COMPLETED") {
+			t.Errorf("Expected STOP_ALREADY_COMPLETED, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Invalid activities are rejected", func(t *testing.T) {
+		future := time.Now().Add(time.Hour)
+		foreignStop := uint(99999)
+		cases := []struct {
+			name string
+			req  validation.RouteActivityCreateRequest
+			code string
+		}{
+			{"unknown type", validation.RouteActivityCreateRequest{ActivityType: "dance"}, "VALIDATION_ERROR"},
+			{"complete without stop", validation.RouteActivityCreateRequest{ActivityType: models.ActivityComplete}, "STOP_REQUIRED"},
+			{"empty note", validation.RouteActivityCreateRequest{ActivityType: models.ActivityNote}, "NOTES_REQUIRED"},
+			{"photo without url", validation.RouteActivityCreateRequest{ActivityType: models.ActivityPhoto}, "PHOTO_URL_REQUIRED"},
+			{"future timestamp", validation.RouteActivityCreateRequest{ActivityType: models.ActivityStop, Timestamp: &future}, "INVALID_ACTIVITY_TIMESTAMP"},
+			{"stop on another route", validation.RouteActivityCreateRequest{ActivityType: models.ActivityStop, RouteStopID: &foreignStop}, "STOP_NOT_FOUND"},
+		}
+		for _, tc := range cases {
+			w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", activitiesPath, techToken, tc.req)
+			if !tests.AssertResponseError(w, http.StatusBadRequest, tc.code) {
+				t.Errorf("%s: expected %s, got %d: %s", tc.name, tc.code, w.Code, w.Body.String())
+			}
+		}
+	})
+
+	t.Run("Only the assigned technician records activities", func(t *testing.T) {
+		for _, token := range []string{otherToken, ownerToken} {
+			w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", activitiesPath, token, validation.RouteActivityCreateRequest{ActivityType: models.ActivityNote, Notes: "Not mine"})
+			if w.Code != http.StatusForbidden {
+				t.Errorf("Expected status %d, got %d. Body: %s", http.StatusForbidden, w.Code, w.Body.String())
+			}
+		}
+	})
+
+	t.Run("Owner reads the route timeline in order", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", activitiesPath, ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var timeline []validation.RouteActivityResponse
+		if err := tests.ParseDataResponse(w, &timeline); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(timeline) != 4 {
+			t.Fatalf("Expected 4 activities, got %d", len(timeline))
+		}
+		if timeline[0].ActivityType != models.ActivityRouteStarted {
+			t.Errorf("Expected the route_started activity first, got %s", timeline[0].ActivityType)
+		}
+		for i := 1; i < len(timeline); i++ {
+			if timeline[i].Timestamp.Before(timeline[i-1].Timestamp) {
+				t.Errorf("Expected chronological order, got %s before %s", timeline[i-1].Timestamp, timeline[i].Timestamp)
+			}
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", activitiesPath+"?activity_type=note&activity_type=photo", ownerToken, nil)
+		if err := tests.ParseDataResponse(w, &timeline); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(timeline) != 2 {
+			t.Errorf("Expected 2 note/photo activities, got %d", len(timeline))
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", activitiesPath+"?date_from=2030-01-02T00:00:00Z&date_to=2030-01-01T00:00:00Z", ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "INVALID_DATE_RANGE") {
+			t.Errorf("Expected INVALID_DATE_RANGE, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Technician timelines", func(t *testing.T) {
+		techPath := fmt.Sprintf("/api/v1/technicians/%d/activities", technician.ID)
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", techPath+fmt.Sprintf("?route_id=%d", route.ID), techToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var timeline []validation.RouteActivityResponse
+		if err := tests.ParseDataResponse(w, &timeline); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(timeline) != 4 {
+			t.Errorf("Expected 4 activities for the technician, got %d", len(timeline))
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", techPath, otherToken, nil)
+		if !tests.AssertResponseError(w, http.StatusForbidden, "RESOURCE_ACCESS_DENIED") {
+			t.Errorf("Expected RESOURCE_ACCESS_DENIED, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", techPath, ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected owners to read any technician timeline, got %d", w.Code)
+		}
+	})
+
+	t.Run("Timelines are scoped to the organization", func(t *testing.T) {
+		outsiderToken, err := ctx.JWTService.GenerateAccessToken(owner.User.ID, orgID+100, owner.User.Email, models.RoleTypeOwner.String())
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", activitiesPath, outsiderToken, nil)
+		if !tests.AssertResponseError(w, http.StatusNotFound, "ROUTE_NOT_FOUND") {
+			t.Errorf("Expected ROUTE_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", fmt.Sprintf("/api/v1/technicians/%d/activities", technician.ID), outsiderToken, nil)
+		if !tests.AssertResponseError(w, http.StatusNotFound, "TECHNICIAN_NOT_FOUND") {
+			t.Errorf("Expected TECHNICIAN_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,239 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/validation"
+
+	"gorm.io/gorm"
+)
+
+// createAssignedRoute creates a route assigned to the technician
+func createAssignedRoute(t *testing.T, db *gorm.DB, orgID uint, technicianID *uint, name string) models.Route {
+	t.Helper()
+
+	route := models.Route{
+		Base:         models.Base{OrganizationID: orgID},
+		Name:         name,
+		TechnicianID: technicianID,
+		Status:       models.RouteStatusPending,
+	}
+	if technicianID != nil {
+		route.Status = models.RouteStatusAssigned
+	}
+	if err := db.Omit("Technician").Create(&route).Error; err != nil {
+		t.Fatalf("Failed to create route: %v", err)
+	}
+	return route
+}
+
+// errorDetails extracts the details of an error response
+func errorDetails(t *testing.T, body []byte) map[string]interface{} {
+	t.Helper()
+
+	var response struct {
+		Error struct {
+			Details map[string]interface{} `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("Failed to parse error response: %v", err)
+	}
+	return response.Error.Details
+}
+
+func TestRouteHandler_Lifecycle(t *testing.T) {
+	ctx, err := tests.SetupRouteTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx.TestContext)
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "Password123!", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	ownerToken, err := tests.GenerateTestAccessToken(ctx.TestContext, owner)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	orgID := owner.Organization.ID
+	techRole, err := tests.CreateTestRole(ctx.DB, orgID, models.RoleTypeTechnician)
+	if err != nil {
+		t.Fatalf("Failed to create technician role: %v", err)
+	}
+	technician := createLocatedTechnician(t, ctx.DB, orgID, techRole.ID, "tech@example.com", models.TechnicianStatusActive, nil, nil)
+	techToken, err := ctx.JWTService.GenerateAccessToken(technician.UserID, orgID, "tech@example.com", models.RoleTypeTechnician.String())
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	route := createAssignedRoute(t, ctx.DB, orgID, &technician.ID, "Morning run")
+	routePath := fmt.Sprintf("/api/v1/routes/%d", route.ID)
+
+	technicianStatus := func() models.TechnicianStatus {
+		var current models.Technician
+		ctx.DB.First(&current, technician.ID)
+		return current.Status
+	}
+
+	t.Run("Technician starts their route", func(t *testing.T) {
+		lat, lng := 40.0, -74.0
+		body := validation.RouteTransitionRequest{Notes: "Leaving depot", Lat: &lat, Lng: &lng}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", routePath+"/start", techToken, body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var started validation.RouteResponse
+		if err := tests.ParseDataResponse(w, &started); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if started.Status != models.RouteStatusStarted || started.StartedAt == nil {
+			t.Errorf("Expected a started route with started_at, got %+v", started)
+		}
+		if technicianStatus() != models.TechnicianStatusOnRoute {
+			t.Errorf("Expected technician to be on_route, got %s", technicianStatus())
+		}
+
+		var activity models.RouteActivity
+		if err := ctx.DB.Where("route_id = ? AND activity_type = ?", route.ID, models.ActivityRouteStarted).First(&activity).Error; err != nil {
+			t.Fatalf("Expected a route_started activity: %v", err)
+		}
+		if activity.TechnicianID != technician.ID || activity.Notes != "Leaving depot" || activity.Lat == nil || *activity.Lat != lat {
+			t.Errorf("Unexpected activity %+v", activity)
+		}
+	})
+
+	t.Run("Illegal transitions are rejected", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", routePath+"/start", techToken, nil)
+		if !tests.AssertResponseError(w, http.StatusConflict, "INVALID_STATUS_TRANSITION") {
+			t.Fatalf("Expected INVALID_STATUS_TRANSITION, got %d: %s", w.Code, w.Body.String())
+		}
+		details := errorDetails(t, w.Body.Bytes())
+		if details["current_status"] != "started" || fmt.Sprint(details["allowed_actions"]) != "[pause complete cancel]" {
+			t.Errorf("Unexpected error details %+v", details)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", routePath+"/resume", techToken, nil)
+		if !tests.AssertResponseError(w, http.StatusConflict, "INVALID_STATUS_TRANSITION") {
+			t.Errorf("Expected INVALID_STATUS_TRANSITION for resume, got %d: %s", w.Code, w.Body.String())
+		}
+
+		status := models.RouteStatusCompleted
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", routePath, ownerToken, validation.RouteUpdateRequest{Status: &status})
+		if !tests.AssertResponseError(w, http.StatusConflict, "INVALID_STATUS_TRANSITION") {
+			t.Errorf("Expected PATCH to reject lifecycle statuses, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Technician pauses, resumes and completes the route", func(t *testing.T) {
+		for _, action := range []string{"pause", "resume", "complete"} {
+			w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", routePath+"/"+action, techToken, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d for %s, got %d. Body: %s", http.StatusOK, action, w.Code, w.Body.String())
+			}
+		}
+
+		var completed models.Route
+		ctx.DB.First(&completed, route.ID)
+		if completed.Status != models.RouteStatusCompleted || completed.CompletedAt == nil || completed.StartedAt == nil {
+			t.Errorf("Expected a completed route with timestamps, got %+v", completed)
+		}
+		if technicianStatus() != models.TechnicianStatusActive {
+			t.Errorf("Expected technician to be active again, got %s", technicianStatus())
+		}
+
+		var activities int64
+		ctx.DB.Model(&models.RouteActivity{}).Where("route_id = ?", route.ID).Count(&activities)
+		if activities != 4 {
+			t.Errorf("Expected 4 lifecycle activities, got %d", activities)
+		}
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", routePath+"/cancel", ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusConflict, "INVALID_STATUS_TRANSITION") {
+			t.Errorf("Expected completed routes not to be cancellable, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Technicians only drive their own routes", func(t *testing.T) {
+		other := createLocatedTechnician(t, ctx.DB, orgID, techRole.ID, "other@example.com", models.TechnicianStatusActive, nil, nil)
+		otherRoute := createAssignedRoute(t, ctx.DB, orgID, &other.ID, "Other run")
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/routes/%d/start", otherRoute.ID), techToken, nil)
+		if !tests.AssertResponseError(w, http.StatusForbidden, "RESOURCE_ACCESS_DENIED") {
+			t.Errorf("Expected RESOURCE_ACCESS_DENIED, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/routes/%d/cancel", otherRoute.ID), techToken, nil)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected technicians not to cancel routes, got %d", w.Code)
+		}
+	})
+
+	t.Run("A technician drives one route at a time", func(t *testing.T) {
+		first := createAssignedRoute(t, ctx.DB, orgID, &technician.ID, "First")
+		second := createAssignedRoute(t, ctx.DB, orgID, &technician.ID, "Second")
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/routes/%d/start", first.ID), techToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/routes/%d/start", second.ID), techToken, nil)
+		if !tests.AssertResponseError(w, http.StatusConflict, "TECHNICIAN_ON_ROUTE") {
+			t.Errorf("Expected TECHNICIAN_ON_ROUTE, got %d: %s", w.Code, w.Body.String())
+		}
+
+		techID := technician.ID
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", fmt.Sprintf("/api/v1/routes/%d", first.ID), ownerToken, validation.RouteUpdateRequest{TechnicianID: &techID})
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected keeping the same technician to be allowed, got %d", w.Code)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/routes/%d/cancel", first.ID), ownerToken, validation.RouteTransitionRequest{Notes: "Vehicle breakdown"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var cancelled validation.RouteResponse
+		if err := tests.ParseDataResponse(w, &cancelled); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if cancelled.Status != models.RouteStatusCancelled || cancelled.CancelledAt == nil {
+			t.Errorf("Expected a cancelled route with cancelled_at, got %+v", cancelled)
+		}
+		if technicianStatus() != models.TechnicianStatusActive {
+			t.Errorf("Expected technician to be active after cancel, got %s", technicianStatus())
+		}
+	})
+
+	t.Run("Owner unassigns and cancels an unassigned route", func(t *testing.T) {
+		assigned := createAssignedRoute(t, ctx.DB, orgID, &technician.ID, "Unassign me")
+		status := models.RouteStatusPending
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", fmt.Sprintf("/api/v1/routes/%d", assigned.ID), ownerToken, validation.RouteUpdateRequest{Status: &status})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var pending validation.RouteResponse
+		if err := tests.ParseDataResponse(w, &pending); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if pending.Status != models.RouteStatusPending || pending.TechnicianID != nil {
+			t.Errorf("Expected an unassigned pending route, got %+v", pending)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/routes/%d/start", assigned.ID), ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusConflict, "INVALID_STATUS_TRANSITION") {
+			t.Errorf("Expected pending routes not to start, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/routes/%d/cancel", assigned.ID), ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected pending routes to be cancellable, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,197 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/validation"
+
+	"gorm.io/gorm"
+)
+
+// createLocatedTechnician creates a technician user in the organization positioned at lat/lng
+func createLocatedTechnician(t *testing.T, db *gorm.DB, orgID, roleID uint, email string, status models.TechnicianStatus, lat, lng *float64) *models.Technician {
+	t.Helper()
+
+	user, err := tests.CreateTestUser(db, orgID, roleID, email, "Password123!", true)
+	if err != nil {
+		t.Fatalf("Failed to create technician user: %v", err)
+	}
+	technician, err := tests.CreateTestTechnician(db, orgID, user.ID, status)
+	if err != nil {
+		t.Fatalf("Failed to create technician: %v", err)
+	}
+	if err := db.Model(technician).Updates(map[string]interface{}{"current_lat": lat, "current_lng": lng}).Error; err != nil {
+		t.Fatalf("Failed to set technician location: %v", err)
+	}
+	return technician
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestRouteHandler_PlanRoutes(t *testing.T) {
+	ctx, err := tests.SetupRouteTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx.TestContext)
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "Password123!", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	token, err := tests.GenerateTestAccessToken(ctx.TestContext, owner)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	orgID := owner.Organization.ID
+	techRole, err := tests.CreateTestRole(ctx.DB, orgID, models.RoleTypeTechnician)
+	if err != nil {
+		t.Fatalf("Failed to create technician role: %v", err)
+	}
+
+	west := createLocatedTechnician(t, ctx.DB, orgID, techRole.ID, "west@example.com", models.TechnicianStatusActive, floatPtr(40.70), floatPtr(-74.00))
+	east := createLocatedTechnician(t, ctx.DB, orgID, techRole.ID, "east@example.com", models.TechnicianStatusActive, floatPtr(40.70), floatPtr(-73.70))
+	lost := createLocatedTechnician(t, ctx.DB, orgID, techRole.ID, "lost@example.com", models.TechnicianStatusActive, nil, nil)
+	createLocatedTechnician(t, ctx.DB, orgID, techRole.ID, "off@example.com", models.TechnicianStatusOffDuty, floatPtr(40.70), floatPtr(-73.85))
+
+	req := validation.RoutePlanRequest{Name: "Monday"}
+	for i := 0; i < 3; i++ {
+		offset := float64(i) * 0.005
+		req.Stops = append(req.Stops,
+			validation.RoutePlanStopRequest{Name: fmt.Sprintf("West %d", i), Address: "W St", Lat: 40.70 + offset, Lng: -74.00 + offset, StopType: "delivery", Duration: 15},
+			validation.RoutePlanStopRequest{Name: fmt.Sprintf("East %d", i), Address: "E St", Lat: 40.70 + offset, Lng: -73.70 + offset, StopType: "delivery", Duration: 15},
+		)
+	}
+
+	t.Run("Dry run previews without creating routes", func(t *testing.T) {
+		body := req
+		body.DryRun = true
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/routes/plan", token, body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var count int64
+		ctx.DB.Model(&models.Route{}).Count(&count)
+		if count != 0 {
+			t.Errorf("Expected no routes after dry run, found %d", count)
+		}
+	})
+
+	t.Run("Plan creates one route per technician", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/routes/plan", token, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var plan validation.RoutePlanResponse
+		if err := tests.ParseDataResponse(w, &plan); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		if len(plan.Routes) != 2 || len(plan.UnassignedStops) != 0 {
+			t.Fatalf("Expected 2 routes and no unassigned stops, got %+v", plan)
+		}
+		if len(plan.SkippedTechnicians) != 1 || plan.SkippedTechnicians[0] != lost.ID {
+			t.Errorf("Expected technician %d without a location to be skipped, got %v", lost.ID, plan.SkippedTechnicians)
+		}
+
+		for _, route := range plan.Routes {
+			if route.Status != models.RouteStatusAssigned || !route.IsOptimized || len(route.Stops) != 3 {
+				t.Errorf("Unexpected planned route %+v", route)
+			}
+			prefix := "West"
+			if *route.TechnicianID == east.ID {
+				prefix = "East"
+			} else if *route.TechnicianID != west.ID {
+				t.Errorf("Route assigned to unexpected technician %d", *route.TechnicianID)
+			}
+			for i, stop := range route.Stops {
+				if stop.Name[:4] != prefix || stop.SequenceNum != i+1 {
+					t.Errorf("Technician %d got stop %s at sequence %d", *route.TechnicianID, stop.Name, stop.SequenceNum)
+				}
+			}
+		}
+
+		var count int64
+		ctx.DB.Model(&models.RouteStop{}).Count(&count)
+		if count != int64(len(req.Stops)) {
+			t.Errorf("Expected %d stops saved, found %d", len(req.Stops), count)
+		}
+	})
+
+	t.Run("Unknown technicians are rejected", func(t *testing.T) {
+		body := req
+		body.TechnicianIDs = []uint{west.ID, 9999}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/routes/plan", token, body)
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "INVALID_TECHNICIAN") {
+			t.Errorf("Expected INVALID_TECHNICIAN, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Time windows constrain the plan", func(t *testing.T) {
+		start := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+		windowed := func(deadline time.Duration) validation.RoutePlanRequest {
+			end := start.Add(deadline)
+			body := validation.RoutePlanRequest{Name: "Tuesday", TechnicianIDs: []uint{west.ID}, DryRun: true}
+			for i := 0; i < 3; i++ {
+				body.Stops = append(body.Stops, req.Stops[2*i])
+			}
+			// The farthest stop is visited last unless its deadline forces it first
+			body.Stops[2].TimeWindow = &validation.TimeWindowRequest{EndTime: &end}
+			return body
+		}
+		plan := func(body validation.RoutePlanRequest) validation.RoutePlanResponse {
+			t.Helper()
+			w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/routes/plan", token, body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			var response validation.RoutePlanResponse
+			if err := tests.ParseDataResponse(w, &response); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			return response
+		}
+
+		body := windowed(10 * time.Minute)
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/routes/plan", token, body)
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "START_TIME_REQUIRED") {
+			t.Errorf("Expected START_TIME_REQUIRED without a start time, got %d: %s", w.Code, w.Body.String())
+		}
+
+		body.ScheduledDate = &start
+		planned := plan(body)
+		if len(planned.Routes) != 1 || planned.Routes[0].Stops[0].Name != "West 2" || len(planned.LateStops) != 0 {
+			t.Errorf("Expected the stop with a deadline to be visited first on time, got %+v", planned)
+		}
+
+		body.TimeWindowMode = "ignore"
+		if planned := plan(body); len(planned.LateStops) != 1 || planned.LateStops[0] != 2 {
+			t.Errorf("Expected stop 2 to be reported late when windows are ignored, got %v", planned.LateStops)
+		}
+
+		body = windowed(time.Minute)
+		body.StartTime = &start
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/routes/plan", token, body)
+		if !tests.AssertResponseError(w, http.StatusUnprocessableEntity, "TIME_WINDOWS_INFEASIBLE") {
+			t.Errorf("Expected TIME_WINDOWS_INFEASIBLE for an unreachable deadline, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Plans need an available technician", func(t *testing.T) {
+		body := req
+		body.TechnicianIDs = []uint{lost.ID}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/routes/plan", token, body)
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "NO_AVAILABLE_TECHNICIANS") {
+			t.Errorf("Expected NO_AVAILABLE_TECHNICIANS, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,377 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/validation"
+)
+
+func sampleRouteRequest(name string) validation.RouteCreateRequest {
+	return validation.RouteCreateRequest{
+		Name: name,
+		Stops: []validation.RouteStopCreateRequest{
+			{Name: "Depot", Address: "1 Main St", Lat: 40.7128, Lng: -74.0060, SequenceNum: 1, StopType: "pickup", Duration: 10},
+			{Name: "Customer", Address: "2 Side St", Lat: 40.7306, Lng: -73.9352, SequenceNum: 2, StopType: "delivery", Duration: 15},
+		},
+	}
+}
+
+func TestRouteHandler_CRUD(t *testing.T) {
+	ctx, err := tests.SetupRouteTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx.TestContext)
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "Password123!", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	token, err := tests.GenerateTestAccessToken(ctx.TestContext, owner)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	var created validation.RouteResponse
+	t.Run("Create route with stops", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/routes", token, sampleRouteRequest("Morning Run"))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		if err := tests.ParseDataResponse(w, &created); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if created.Status != models.RouteStatusPending {
+			t.Errorf("Expected status pending, got %s", created.Status)
+		}
+		if len(created.Stops) != 2 || created.Stops[0].Name != "Depot" {
+			t.Errorf("Expected 2 ordered stops, got %+v", created.Stops)
+		}
+	})
+
+	t.Run("Reject duplicate stop sequence", func(t *testing.T) {
+		req := sampleRouteRequest("Broken")
+		req.Stops[1].SequenceNum = 1
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/routes", token, req)
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "INVALID_ROUTE_STOPS") {
+			t.Errorf("Expected INVALID_ROUTE_STOPS, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Reject inactive technicians", func(t *testing.T) {
+		techRole, err := tests.CreateTestRole(ctx.DB, owner.Organization.ID, models.RoleTypeTechnician)
+		if err != nil {
+			t.Fatalf("Failed to create technician role: %v", err)
+		}
+		inactive := createLocatedTechnician(t, ctx.DB, owner.Organization.ID, techRole.ID, "inactive@example.com", models.TechnicianStatusInactive, nil, nil)
+
+		req := sampleRouteRequest("Unstaffed")
+		req.TechnicianID = &inactive.ID
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/routes", token, req)
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "TECHNICIAN_INACTIVE") {
+			t.Errorf("Expected TECHNICIAN_INACTIVE, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("List routes with pagination and filters", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/routes?page=1&page_size=10&status=pending", token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var routes []validation.RouteResponse
+		if err := tests.ParseDataResponse(w, &routes); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(routes) != 1 || routes[0].StopsCount != 2 {
+			t.Errorf("Expected one route with 2 stops, got %+v", routes)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/routes?status=completed", token, nil)
+		routes = nil
+		if err := tests.ParseDataResponse(w, &routes); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(routes) != 0 {
+			t.Errorf("Expected no completed routes, got %d", len(routes))
+		}
+	})
+
+	t.Run("Partial update swaps stop order", func(t *testing.T) {
+		first, second := 2, 1
+		newName := "Renamed Run"
+		update := validation.RouteUpdateRequest{
+			Name: &newName,
+			Stops: []validation.RouteStopUpdateRequest{
+				{ID: created.Stops[0].ID, SequenceNum: &first},
+				{ID: created.Stops[1].ID, SequenceNum: &second},
+			},
+		}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", fmt.Sprintf("/api/v1/routes/%d", created.ID), token, update)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var updated validation.RouteResponse
+		if err := tests.ParseDataResponse(w, &updated); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if updated.Name != newName {
+			t.Errorf("Expected name %q, got %q", newName, updated.Name)
+		}
+		if updated.Stops[0].Name != "Customer" {
+			t.Errorf("Expected Customer first after reorder, got %s", updated.Stops[0].Name)
+		}
+	})
+
+	t.Run("Routes from other organizations are not visible", func(t *testing.T) {
+		other := models.Organization{Name: "Other", SubDomain: "other", ContactEmail: "a@other.com", Active: true}
+		if err := ctx.DB.Create(&other).Error; err != nil {
+			t.Fatalf("Failed to create organization: %v", err)
+		}
+		foreign := models.Route{Base: models.Base{OrganizationID: other.ID}, Name: "Foreign"}
+		if err := ctx.DB.Create(&foreign).Error; err != nil {
+			t.Fatalf("Failed to create route: %v", err)
+		}
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", fmt.Sprintf("/api/v1/routes/%d", foreign.ID), token, nil)
+		if !tests.AssertResponseError(w, http.StatusNotFound, "ROUTE_NOT_FOUND") {
+			t.Errorf("Expected ROUTE_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Soft delete removes route and stops", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "DELETE", fmt.Sprintf("/api/v1/routes/%d", created.ID), token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var stops int64
+		ctx.DB.Model(&models.RouteStop{}).Where("route_id = ?", created.ID).Count(&stops)
+		if stops != 0 {
+			t.Errorf("Expected stops to be soft deleted, found %d", stops)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", fmt.Sprintf("/api/v1/routes/%d", created.ID), token, nil)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d after delete, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestRouteHandler_TechnicianCannotCreate(t *testing.T) {
+	ctx, err := tests.SetupRouteTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx.TestContext)
+
+	tech, err := tests.CreateCompleteTestUser(ctx.DB, "tech@example.com", "Password123!", models.RoleTypeTechnician, true)
+	if err != nil {
+		t.Fatalf("Failed to create technician: %v", err)
+	}
+	token, err := tests.GenerateTestAccessToken(ctx.TestContext, tech)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/routes", token, sampleRouteRequest("Nope"))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+
+	w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/routes", token, nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d for technician listing, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestRouteHandler_Optimize(t *testing.T) {
+	ctx, err := tests.SetupRouteTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx.TestContext)
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "Password123!", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	token, err := tests.GenerateTestAccessToken(ctx.TestContext, owner)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	req := validation.RouteCreateRequest{
+		Name: "Zig Zag",
+		Stops: []validation.RouteStopCreateRequest{
+			{Name: "Depot", Address: "1 Main St", Lat: 40.0, Lng: -74.00, SequenceNum: 1, StopType: "pickup", Duration: 10},
+			{Name: "Far", Address: "4 Far St", Lat: 40.0, Lng: -73.70, SequenceNum: 2, StopType: "delivery", Duration: 10},
+			{Name: "Near", Address: "2 Near St", Lat: 40.0, Lng: -73.90, SequenceNum: 3, StopType: "delivery", Duration: 10},
+			{Name: "Middle", Address: "3 Mid St", Lat: 40.0, Lng: -73.80, SequenceNum: 4, StopType: "delivery", Duration: 10},
+		},
+	}
+	w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/routes", token, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created validation.RouteResponse
+	if err := tests.ParseDataResponse(w, &created); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	t.Run("Optimize resequences stops and records totals", func(t *testing.T) {
+		path := fmt.Sprintf("/api/v1/routes/%d/optimize", created.ID)
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", path, token, validation.RouteOptimizeRequest{PinFirst: true})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var optimized validation.RouteOptimizationResponse
+		if err := tests.ParseDataResponse(w, &optimized); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		want := []string{"Depot", "Near", "Middle", "Far"}
+		for i, stop := range optimized.Route.Stops {
+			if stop.Name != want[i] || stop.SequenceNum != i+1 {
+				t.Errorf("Stop %d: expected %s at sequence %d, got %s at %d", i, want[i], i+1, stop.Name, stop.SequenceNum)
+			}
+		}
+		if !optimized.Route.IsOptimized {
+			t.Error("Expected route to be marked optimized")
+		}
+		if optimized.DistanceSaved <= 0 || optimized.Route.TotalDistance >= optimized.PreviousDistance {
+			t.Errorf("Expected a shorter route, got %+v", optimized)
+		}
+		if optimized.Route.TotalDuration <= 40*60 {
+			t.Errorf("Expected duration to include service time, got %d", optimized.Route.TotalDuration)
+		}
+	})
+
+	t.Run("Completed stops of a route in progress stay in place", func(t *testing.T) {
+		if err := ctx.DB.Model(&models.Route{}).Where("id = ?", created.ID).Update("status", models.RouteStatusStarted).Error; err != nil {
+			t.Fatalf("Failed to start route: %v", err)
+		}
+		if err := ctx.DB.Model(&models.RouteStop{}).Where("route_id = ? AND name IN ?", created.ID, []string{"Depot", "Far"}).Update("is_completed", true).Error; err != nil {
+			t.Fatalf("Failed to complete stops: %v", err)
+		}
+
+		path := fmt.Sprintf("/api/v1/routes/%d/optimize", created.ID)
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", path, token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var optimized validation.RouteOptimizationResponse
+		if err := tests.ParseDataResponse(w, &optimized); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		want := []string{"Depot", "Far", "Middle", "Near"}
+		for i, stop := range optimized.Route.Stops {
+			if stop.Name != want[i] || stop.SequenceNum != i+1 {
+				t.Errorf("Stop %d: expected %s at sequence %d, got %s at %d", i, want[i], i+1, stop.Name, stop.SequenceNum)
+			}
+		}
+	})
+
+	t.Run("Cancelled routes cannot be optimized", func(t *testing.T) {
+		if err := ctx.DB.Model(&models.Route{}).Where("id = ?", created.ID).Update("status", models.RouteStatusCancelled).Error; err != nil {
+			t.Fatalf("Failed to cancel route: %v", err)
+		}
+		path := fmt.Sprintf("/api/v1/routes/%d/optimize", created.ID)
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", path, token, nil)
+		if !tests.AssertResponseError(w, http.StatusConflict, "ROUTE_NOT_OPTIMIZABLE") {
+			t.Errorf("Expected ROUTE_NOT_OPTIMIZABLE, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestRouteHandler_TimeWindows(t *testing.T) {
+	ctx, err := tests.SetupRouteTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx.TestContext)
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "Password123!", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	token, err := tests.GenerateTestAccessToken(ctx.TestContext, owner)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	closesAt := start.Add(20 * time.Minute)
+	req := validation.RouteCreateRequest{
+		Name:          "Windowed",
+		ScheduledDate: &start,
+		Stops: []validation.RouteStopCreateRequest{
+			{Name: "Depot", Address: "1 Main St", Lat: 40.0, Lng: -74.00, SequenceNum: 1, StopType: "pickup", Duration: 10},
+			{Name: "Far", Address: "4 Far St", Lat: 40.0, Lng: -73.60, SequenceNum: 2, StopType: "delivery", Duration: 10,
+				TimeWindow: &validation.TimeWindowRequest{EndTime: &closesAt}},
+			{Name: "Near", Address: "2 Near St", Lat: 40.0, Lng: -73.90, SequenceNum: 3, StopType: "delivery", Duration: 10},
+		},
+	}
+	w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/routes", token, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created validation.RouteResponse
+	if err := tests.ParseDataResponse(w, &created); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	t.Run("Schedule reports late stops in the current order", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", fmt.Sprintf("/api/v1/routes/%d/schedule", created.ID), token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var schedule validation.RouteScheduleResponse
+		if err := tests.ParseDataResponse(w, &schedule); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if schedule.Feasible || len(schedule.LateStops) != 1 || !schedule.Stops[1].IsLate {
+			t.Errorf("Expected the far stop to be late, got %+v", schedule)
+		}
+		if !schedule.Stops[0].ArrivalTime.Equal(start) {
+			t.Errorf("Expected schedule to start at %v, got %v", start, schedule.Stops[0].ArrivalTime)
+		}
+	})
+
+	t.Run("Hard windows reject infeasible routes", func(t *testing.T) {
+		path := fmt.Sprintf("/api/v1/routes/%d/optimize", created.ID)
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", path, token, validation.RouteOptimizeRequest{PinFirst: true})
+		if !tests.AssertResponseError(w, http.StatusUnprocessableEntity, "TIME_WINDOWS_INFEASIBLE") {
+			t.Errorf("Expected TIME_WINDOWS_INFEASIBLE, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Soft windows dry run leaves the route unchanged", func(t *testing.T) {
+		path := fmt.Sprintf("/api/v1/routes/%d/optimize", created.ID)
+		body := validation.RouteOptimizeRequest{PinFirst: true, TimeWindowMode: "soft", DryRun: true}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", path, token, body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var preview validation.RouteOptimizationResponse
+		if err := tests.ParseDataResponse(w, &preview); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if preview.Schedule == nil || preview.Schedule.Feasible {
+			t.Errorf("Expected an infeasible schedule in the preview, got %+v", preview.Schedule)
+		}
+
+		var route models.Route
+		if err := ctx.DB.First(&route, created.ID).Error; err != nil {
+			t.Fatalf("Failed to reload route: %v", err)
+		}
+		if route.IsOptimized {
+			t.Error("Expected dry run not to persist the optimization")
+		}
+	})
+}
@@ -0,0 +1,285 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"testing"
+
+	"routrapp-api/internal/api"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/validation"
+)
+
+func TestProofHandler_CompleteStop(t *testing.T) {
+	ctx, err := tests.SetupAttachmentTestContext(api.AttachmentOptions{
+		MaxUploadSize: 64 << 10,
+		DefaultQuota:  1 << 20,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupAttachmentTestContext(ctx)
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "Password123!", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	ownerToken, err := tests.GenerateTestAccessToken(ctx.TestContext, owner)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	orgID := owner.Organization.ID
+	techRole, err := tests.CreateTestRole(ctx.DB, orgID, models.RoleTypeTechnician)
+	if err != nil {
+		t.Fatalf("Failed to create technician role: %v", err)
+	}
+	technician := createLocatedTechnician(t, ctx.DB, orgID, techRole.ID, "tech@example.com", models.TechnicianStatusActive, nil, nil)
+	techToken, err := ctx.JWTService.GenerateAccessToken(technician.UserID, orgID, "tech@example.com", models.RoleTypeTechnician.String())
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	route := createAssignedRoute(t, ctx.DB, orgID, &technician.ID, "Delivery run")
+	if err := ctx.DB.Model(&models.Route{}).Where("id = ?", route.ID).Update("status", models.RouteStatusStarted).Error; err != nil {
+		t.Fatalf("Failed to start route: %v", err)
+	}
+	delivery := models.RouteStop{Base: models.Base{OrganizationID: orgID}, RouteID: route.ID, Name: "Customer", SequenceNum: 1, StopType: models.StopTypeDelivery}
+	pickup := models.RouteStop{Base: models.Base{OrganizationID: orgID}, RouteID: route.ID, Name: "Depot", SequenceNum: 2, StopType: models.StopTypePickup}
+	for _, stop := range []*models.RouteStop{&delivery, &pickup} {
+		if err := ctx.DB.Create(stop).Error; err != nil {
+			t.Fatalf("Failed to create stop: %v", err)
+		}
+	}
+	completePath := func(stopID uint) string {
+		return fmt.Sprintf("/api/v1/routes/%d/stops/%d/complete", route.ID, stopID)
+	}
+
+	var signature bytes.Buffer
+	if err := png.Encode(&signature, image.NewGray(image.Rect(0, 0, 4, 2))); err != nil {
+		t.Fatalf("Failed to encode signature: %v", err)
+	}
+	signatureDataURL := "data:image/png;base64," + base64.StdEncoding.EncodeToString(signature.Bytes())
+
+	t.Run("Owner defines the delivery checklist", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, http.MethodPut, "/api/v1/stop-checklists/delivery", ownerToken, validation.StopChecklistRequest{
+			RequireSignature:     true,
+			RequireRecipientName: true,
+			Items: []validation.StopChecklistItemRequest{
+				{Key: "left_at_door", Label: "Left at door?", Type: "boolean", Required: true},
+				{Key: "packages", Label: "Packages delivered", Type: "number"},
+			},
+		})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+
+		var checklist validation.StopChecklistResponse
+		if err := tests.ParseDataResponse(w, &checklist); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if checklist.StopType != models.StopTypeDelivery || len(checklist.Items) != 2 || !checklist.RequireSignature {
+			t.Errorf("Unexpected checklist: %+v", checklist)
+		}
+	})
+
+	t.Run("Checklist validation", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, http.MethodPut, "/api/v1/stop-checklists/delivery", ownerToken, validation.StopChecklistRequest{
+			Items: []validation.StopChecklistItemRequest{
+				{Key: "photo", Label: "Photo", Type: "boolean"},
+				{Key: "photo", Label: "Photo again", Type: "text"},
+			},
+		})
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "INVALID_CHECKLIST") {
+			t.Errorf("Expected INVALID_CHECKLIST for duplicate keys, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, http.MethodGet, "/api/v1/stop-checklists/parcel", ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "INVALID_STOP_TYPE") {
+			t.Errorf("Expected INVALID_STOP_TYPE, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, http.MethodGet, "/api/v1/stop-checklists/service", ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusNotFound, "CHECKLIST_NOT_FOUND") {
+			t.Errorf("Expected CHECKLIST_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, http.MethodPut, "/api/v1/stop-checklists/service", techToken, validation.StopChecklistRequest{})
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected technicians to be forbidden from editing checklists, got %d", w.Code)
+		}
+	})
+
+	t.Run("Completion enforces the checklist", func(t *testing.T) {
+		cases := []struct {
+			name string
+			body map[string]interface{}
+			code string
+		}{
+			{"missing signature", map[string]interface{}{"outcome": "completed", "recipient_name": "Ann"}, "SIGNATURE_REQUIRED"},
+			{"missing recipient", map[string]interface{}{"outcome": "completed", "signature": signatureDataURL}, "RECIPIENT_NAME_REQUIRED"},
+			{"missing answer", map[string]interface{}{"outcome": "completed", "signature": signatureDataURL, "recipient_name": "Ann"}, "INVALID_CHECKLIST"},
+			{"wrong answer type", map[string]interface{}{"outcome": "completed", "signature": signatureDataURL, "recipient_name": "Ann", "checklist": map[string]interface{}{"left_at_door": "yes"}}, "INVALID_CHECKLIST"},
+			{"bad signature", map[string]interface{}{"outcome": "completed", "signature": "not-base64!", "recipient_name": "Ann", "checklist": map[string]interface{}{"left_at_door": true}}, "INVALID_SIGNATURE_IMAGE"},
+			{"failed without reason", map[string]interface{}{"outcome": "failed"}, "FAILURE_REASON_REQUIRED"},
+			{"other without notes", map[string]interface{}{"outcome": "failed", "failure_reason": "other"}, "NOTES_REQUIRED"},
+			{"reason on success", map[string]interface{}{"outcome": "completed", "failure_reason": "refused"}, "INVALID_FAILURE_REASON"},
+		}
+		for _, tc := range cases {
+			w := tests.MakeAuthenticatedRequest(ctx.Router, http.MethodPost, completePath(delivery.ID), techToken, tc.body)
+			if !tests.AssertResponseError(w, http.StatusBadRequest, tc.code) {
+				t.Errorf("%s: expected %s, got %d: %s", tc.name, tc.code, w.Code, w.Body.String())
+			}
+		}
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, http.MethodPost, completePath(delivery.ID), techToken, map[string]interface{}{
+			"outcome":   "completed",
+			"signature": signatureDataURL, "recipient_name": "Ann",
+			"checklist": map[string]interface{}{"left_at_door": true, "mood": "happy"},
+		})
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "INVALID_CHECKLIST") {
+			t.Fatalf("Expected INVALID_CHECKLIST for an unknown answer, got %d: %s", w.Code, w.Body.String())
+		}
+		details := errorDetails(t, w.Body.Bytes())
+		if unknown, _ := details["unknown"].([]interface{}); len(unknown) != 1 || unknown[0] != "mood" {
+			t.Errorf("Expected mood to be reported as unknown, got %v", details)
+		}
+	})
+
+	t.Run("Only the assigned technician can complete stops", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, http.MethodPost, completePath(pickup.ID), ownerToken, map[string]interface{}{"outcome": "completed"})
+		if !tests.AssertResponseError(w, http.StatusForbidden, "RESOURCE_ACCESS_DENIED") {
+			t.Errorf("Expected RESOURCE_ACCESS_DENIED, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Stops requiring proof cannot be completed elsewhere", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, http.MethodPost, fmt.Sprintf("/api/v1/routes/%d/activities", route.ID), techToken, map[string]interface{}{
+			"activity_type": models.ActivityComplete,
+			"route_stop_id": delivery.ID,
+		})
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "PROOF_REQUIRED") {
+			t.Fatalf("Expected PROOF_REQUIRED for a complete activity, got %d: %s", w.Code, w.Body.String())
+		}
+		if details := errorDetails(t, w.Body.Bytes()); details["complete_path"] != completePath(delivery.ID) {
+			t.Errorf("Expected the caller to be sent to %s, got %v", completePath(delivery.ID), details)
+		}
+
+		completed := true
+		w = tests.MakeAuthenticatedRequest(ctx.Router, http.MethodPatch, fmt.Sprintf("/api/v1/routes/%d", route.ID), ownerToken, validation.RouteUpdateRequest{
+			Stops: []validation.RouteStopUpdateRequest{{ID: delivery.ID, IsCompleted: &completed}},
+		})
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "PROOF_REQUIRED") {
+			t.Errorf("Expected PROOF_REQUIRED for a route update, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var stop models.RouteStop
+		ctx.DB.First(&stop, delivery.ID)
+		if stop.IsCompleted {
+			t.Error("Expected the delivery stop to remain open")
+		}
+	})
+
+	t.Run("Failed attempt is recorded without completing the stop", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, http.MethodPost, completePath(delivery.ID), techToken, map[string]interface{}{
+			"outcome":        "failed",
+			"failure_reason": "recipient_unavailable",
+			"notes":          "Nobody home",
+		})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+
+		var stop models.RouteStop
+		ctx.DB.First(&stop, delivery.ID)
+		if stop.IsCompleted || stop.NotesCount != 1 {
+			t.Errorf("Expected an incomplete stop with one note, got completed=%v notes=%d", stop.IsCompleted, stop.NotesCount)
+		}
+
+		var activity models.RouteActivity
+		ctx.DB.Where("route_stop_id = ?", delivery.ID).Last(&activity)
+		if activity.ActivityType != models.ActivityStopFailed {
+			t.Errorf("Expected a %s activity, got %q", models.ActivityStopFailed, activity.ActivityType)
+		}
+	})
+
+	t.Run("Successful attempt captures signature and checklist", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, http.MethodPost, completePath(delivery.ID), techToken, map[string]interface{}{
+			"outcome":        "completed",
+			"signature":      signatureDataURL,
+			"recipient_name": "Ann Smith",
+			"checklist":      map[string]interface{}{"left_at_door": false, "packages": 3},
+			"lat":            40.7,
+			"lng":            -73.9,
+		})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+
+		var proof validation.StopProofResponse
+		if err := tests.ParseDataResponse(w, &proof); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if proof.Outcome != models.StopOutcomeCompleted || proof.RecipientName != "Ann Smith" || proof.TechnicianID != technician.ID {
+			t.Errorf("Unexpected proof: %+v", proof)
+		}
+		if proof.Signature == nil || proof.Signature.Kind != models.AttachmentKindSignature || proof.Signature.ContentType != "image/png" {
+			t.Fatalf("Expected a PNG signature attachment, got %+v", proof.Signature)
+		}
+		if proof.Signature.Size != int64(signature.Len()) || proof.Signature.DownloadURL == "" {
+			t.Errorf("Unexpected signature attachment: %+v", proof.Signature)
+		}
+		if len(proof.Checklist) != 2 || proof.Checklist[0].Label != "Left at door?" || proof.Checklist[1].Value != float64(3) {
+			t.Errorf("Expected checklist answers with labels, got %+v", proof.Checklist)
+		}
+
+		var stop models.RouteStop
+		ctx.DB.First(&stop, delivery.ID)
+		if !stop.IsCompleted || stop.CompletedAt == nil {
+			t.Errorf("Expected the stop to be completed, got %+v", stop)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, http.MethodPost, completePath(delivery.ID), techToken, map[string]interface{}{"outcome": "completed"})
+		if !tests.AssertResponseError(w, http.StatusConflict, "STOP_ALREADY_COMPLETED") {
+			t.Errorf("Expected STOP_ALREADY_COMPLETED, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, http.MethodDelete, fmt.Sprintf("/api/v1/routes/%d/stops/%d/attachments/%d", route.ID, delivery.ID, proof.Signature.ID), ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusConflict, "ATTACHMENT_IN_USE") {
+			t.Errorf("Expected ATTACHMENT_IN_USE when deleting a signature, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Stops without a checklist complete freely", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, http.MethodPost, completePath(pickup.ID), techToken, map[string]interface{}{"outcome": "completed"})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Proof record lists every attempt", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, http.MethodGet, fmt.Sprintf("/api/v1/routes/%d/stops/%d/proof", route.ID, delivery.ID), ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var record validation.StopProofRecordResponse
+		if err := tests.ParseDataResponse(w, &record); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if !record.Stop.IsCompleted || len(record.Attempts) != 2 {
+			t.Fatalf("Expected a completed stop with 2 attempts, got %+v", record)
+		}
+		if record.Attempts[0].Outcome != models.StopOutcomeFailed || record.Attempts[0].FailureReason != models.FailureRecipientUnavailable {
+			t.Errorf("Expected the failed attempt first, got %+v", record.Attempts[0])
+		}
+		if record.Attempts[1].Signature == nil {
+			t.Errorf("Expected the signature to be loaded with the successful attempt")
+		}
+	})
+}
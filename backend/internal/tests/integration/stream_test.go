@@ -0,0 +1,299 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/events"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/validation"
+)
+
+// streamEvent is a single Server-Sent Event read from the stream
+type streamEvent struct {
+	Name  string
+	Event events.Event
+}
+
+// openStream connects to the event stream and returns a channel of the events it receives
+func openStream(t *testing.T, server *httptest.Server, token, query string) <-chan streamEvent {
+	t.Helper()
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(reqCtx, "GET", server.URL+"/api/v1/stream"+query, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("Expected an event stream, got %s", resp.Header.Get("Content-Type"))
+	}
+
+	received := make(chan streamEvent, 16)
+	go func() {
+		defer resp.Body.Close()
+		defer close(received)
+
+		scanner := bufio.NewScanner(resp.Body)
+		var current streamEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				current.Name = strings.TrimPrefix(line, "event:")
+			case strings.HasPrefix(line, "data:"):
+				_ = json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &current.Event)
+			case line == "" && current.Name != "":
+				received <- current
+				current = streamEvent{}
+			}
+		}
+	}()
+
+	ready := nextStreamEvent(t, received)
+	if ready.Name != "ready" {
+		t.Fatalf("Expected a ready event first, got %s", ready.Name)
+	}
+	return received
+}
+
+// nextStreamEvent waits for the next event on the stream
+func nextStreamEvent(t *testing.T, received <-chan streamEvent) streamEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-received:
+		if !ok {
+			t.Fatalf("Stream closed unexpectedly")
+		}
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Timed out waiting for a stream event")
+	}
+	return streamEvent{}
+}
+
+func TestStreamHandler_Events(t *testing.T) {
+	ctx, err := tests.SetupStreamTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx.TestContext)
+
+	server := httptest.NewServer(ctx.Router)
+	defer server.Close()
+	defer ctx.Events.Close()
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "Password123!", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	ownerToken, err := tests.GenerateTestAccessToken(ctx.TestContext, owner)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	orgID := owner.Organization.ID
+	techRole, err := tests.CreateTestRole(ctx.DB, orgID, models.RoleTypeTechnician)
+	if err != nil {
+		t.Fatalf("Failed to create technician role: %v", err)
+	}
+	technician := createLocatedTechnician(t, ctx.DB, orgID, techRole.ID, "tech@example.com", models.TechnicianStatusActive, nil, nil)
+	techToken, err := ctx.JWTService.GenerateAccessToken(technician.UserID, orgID, "tech@example.com", models.RoleTypeTechnician.String())
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	route := models.Route{
+		Base:         models.Base{OrganizationID: orgID},
+		Name:         "Morning run",
+		TechnicianID: &technician.ID,
+		Status:       models.RouteStatusAssigned,
+		Stops: []models.RouteStop{
+			{Base: models.Base{OrganizationID: orgID}, Name: "First", Lat: 40.0, Lng: -74.0, SequenceNum: 1},
+		},
+	}
+	if err := ctx.DB.Omit("Technician").Create(&route).Error; err != nil {
+		t.Fatalf("Failed to create route: %v", err)
+	}
+
+	// A subscriber in another organization must never see these events
+	otherOrg := ctx.Events.Subscribe(orgID + 100)
+	defer otherOrg.Close()
+
+	stream := openStream(t, server, ownerToken, "")
+
+	t.Run("Location updates are streamed", func(t *testing.T) {
+		body := validation.LocationUpdateRequest{Lat: 40.5, Lng: -74.5}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/technicians/me/location", techToken, body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+
+		event := nextStreamEvent(t, stream)
+		if event.Name != string(events.EventTechnicianLocation) || event.Event.OrganizationID != orgID {
+			t.Fatalf("Expected a technician.location event, got %+v", event)
+		}
+		data := event.Event.Data.(map[string]interface{})
+		if data["technician_id"] != float64(technician.ID) || data["lat"] != 40.5 {
+			t.Errorf("Unexpected location payload %+v", data)
+		}
+	})
+
+	t.Run("Route status and stop completion are streamed", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", fmt.Sprintf("/api/v1/routes/%d/start", route.ID), techToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		event := nextStreamEvent(t, stream)
+		data := event.Event.Data.(map[string]interface{})
+		if event.Name != string(events.EventRouteStatus) || data["status"] != "started" || data["previous_status"] != "assigned" {
+			t.Fatalf("Expected a route.status event, got %+v", event)
+		}
+
+		event = nextStreamEvent(t, stream)
+		data = event.Event.Data.(map[string]interface{})
+		if event.Name != string(events.EventTechnicianStatus) || data["status"] != "on_route" {
+			t.Fatalf("Expected a technician.status event, got %+v", event)
+		}
+
+		completed := true
+		body := validation.RouteUpdateRequest{
+			Stops: []validation.RouteStopUpdateRequest{{ID: route.Stops[0].ID, IsCompleted: &completed}},
+		}
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", fmt.Sprintf("/api/v1/routes/%d", route.ID), ownerToken, body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		event = nextStreamEvent(t, stream)
+		data = event.Event.Data.(map[string]interface{})
+		if event.Name != string(events.EventStopCompleted) || data["stop_id"] != float64(route.Stops[0].ID) || data["is_completed"] != true {
+			t.Fatalf("Expected a route_stop.completed event, got %+v", event)
+		}
+	})
+
+	t.Run("Technician status changes are streamed", func(t *testing.T) {
+		status := models.TechnicianStatusOnBreak
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", "/api/v1/technicians/me", techToken, validation.TechnicianSelfUpdateRequest{Status: &status})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		event := nextStreamEvent(t, stream)
+		data := event.Event.Data.(map[string]interface{})
+		if event.Name != string(events.EventTechnicianStatus) || data["status"] != "on_break" || data["previous_status"] != "on_route" {
+			t.Fatalf("Expected a technician.status event, got %+v", event)
+		}
+	})
+
+	t.Run("Planned routes are streamed", func(t *testing.T) {
+		planned := createLocatedTechnician(t, ctx.DB, orgID, techRole.ID, "planned@example.com", models.TechnicianStatusActive, floatPtr(40.70), floatPtr(-74.00))
+		body := validation.RoutePlanRequest{
+			Name:          "Afternoon",
+			TechnicianIDs: []uint{planned.ID},
+			Stops: []validation.RoutePlanStopRequest{
+				{Name: "Depot", Address: "Main St", Lat: 40.71, Lng: -74.01, StopType: "delivery", Duration: 15},
+			},
+		}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/routes/plan", ownerToken, body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var plan validation.RoutePlanResponse
+		if err := tests.ParseDataResponse(w, &plan); err != nil || len(plan.Routes) != 1 {
+			t.Fatalf("Expected one planned route, got %+v, %v", plan, err)
+		}
+
+		event := nextStreamEvent(t, stream)
+		data := event.Event.Data.(map[string]interface{})
+		if event.Name != string(events.EventRouteStatus) || data["route_id"] != float64(plan.Routes[0].ID) || data["status"] != "assigned" {
+			t.Fatalf("Expected a route.status event for the planned route, got %+v", event)
+		}
+		if data["technician_id"] != float64(planned.ID) || data["previous_status"] != "" {
+			t.Errorf("Unexpected planned route payload %+v", data)
+		}
+	})
+
+	t.Run("Streams can be filtered by event type", func(t *testing.T) {
+		filtered := openStream(t, server, ownerToken, "?types=technician.status")
+
+		body := validation.LocationUpdateRequest{Lat: 40.6, Lng: -74.6}
+		tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/technicians/me/location", techToken, body)
+		status := models.TechnicianStatusActive
+		tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", "/api/v1/technicians/me", techToken, validation.TechnicianSelfUpdateRequest{Status: &status})
+
+		event := nextStreamEvent(t, filtered)
+		if event.Name != string(events.EventTechnicianStatus) {
+			t.Errorf("Expected only technician.status events, got %s", event.Name)
+		}
+	})
+
+	t.Run("Other organizations receive nothing", func(t *testing.T) {
+		select {
+		case event := <-otherOrg.Events():
+			t.Errorf("Expected no events for another organization, got %+v", event)
+		default:
+		}
+	})
+
+	t.Run("Stream closes once the token is revoked", func(t *testing.T) {
+		token, err := tests.GenerateTestAccessToken(ctx.TestContext, owner)
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+		claims, err := ctx.JWTService.ValidateToken(token)
+		if err != nil {
+			t.Fatalf("Failed to parse token: %v", err)
+		}
+		received := openStream(t, server, token, "")
+
+		if err := ctx.Revocations.RevokeToken(context.Background(), claims.ID, claims.ExpiresAt.Time); err != nil {
+			t.Fatalf("Failed to revoke token: %v", err)
+		}
+
+		event := nextStreamEvent(t, received)
+		if event.Name != "close" {
+			t.Fatalf("Expected a close event, got %s", event.Name)
+		}
+		select {
+		case _, ok := <-received:
+			if ok {
+				t.Errorf("Expected the stream to end after the close event")
+			}
+		case <-time.After(2 * time.Second):
+			t.Errorf("Timed out waiting for the stream to end")
+		}
+	})
+
+	t.Run("Stream requires dispatcher access", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/stream", techToken, nil)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d for technicians, got %d", http.StatusForbidden, w.Code)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/stream", "", nil)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d without a token, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+}
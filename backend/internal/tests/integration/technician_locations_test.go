@@ -0,0 +1,197 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/validation"
+)
+
+func TestTechnicianHandler_Locations(t *testing.T) {
+	ctx, err := tests.SetupTechnicianTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx.TestContext)
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "Password123!", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	ownerToken, err := tests.GenerateTestAccessToken(ctx.TestContext, owner)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	orgID := owner.Organization.ID
+	techRole, err := tests.CreateTestRole(ctx.DB, orgID, models.RoleTypeTechnician)
+	if err != nil {
+		t.Fatalf("Failed to create technician role: %v", err)
+	}
+	technician := createLocatedTechnician(t, ctx.DB, orgID, techRole.ID, "tech@example.com", models.TechnicianStatusActive, nil, nil)
+	techToken, err := ctx.JWTService.GenerateAccessToken(technician.UserID, orgID, "tech@example.com", models.RoleTypeTechnician.String())
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	route := models.Route{
+		Base:         models.Base{OrganizationID: orgID},
+		Name:         "Morning run",
+		TechnicianID: &technician.ID,
+		Status:       models.RouteStatusStarted,
+	}
+	if err := ctx.DB.Omit("Technician").Create(&route).Error; err != nil {
+		t.Fatalf("Failed to create route: %v", err)
+	}
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	trailPath := fmt.Sprintf("/api/v1/technicians/%d/locations", technician.ID)
+
+	t.Run("Technician records a single point", func(t *testing.T) {
+		body := validation.LocationUpdateRequest{Lat: 40.0, Lng: -74.0, RecordedAt: &start, Accuracy: floatPtr(5)}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/technicians/me/location", techToken, body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var response validation.LocationIngestResponse
+		if err := tests.ParseDataResponse(w, &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if response.Accepted != 1 || response.LastLat == nil || *response.LastLat != 40.0 {
+			t.Errorf("Unexpected ingest response %+v", response)
+		}
+	})
+
+	t.Run("Technician uploads a buffered batch out of order", func(t *testing.T) {
+		points := make([]validation.LocationUpdateRequest, 0, 20)
+		for i := 20; i >= 1; i-- {
+			recordedAt := start.Add(time.Duration(i) * time.Minute)
+			points = append(points, validation.LocationUpdateRequest{Lat: 40.0 + float64(i)*0.01, Lng: -74.0, RecordedAt: &recordedAt})
+		}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/technicians/me/location", techToken, validation.LocationBatchRequest{Points: points})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+
+		var updated models.Technician
+		ctx.DB.First(&updated, technician.ID)
+		if updated.CurrentLat == nil || *updated.CurrentLat != 40.2 {
+			t.Errorf("Expected the newest point to become the current position, got %v", updated.CurrentLat)
+		}
+	})
+
+	t.Run("Late points do not move the current position backwards", func(t *testing.T) {
+		recordedAt := start.Add(30 * time.Second)
+		body := validation.LocationUpdateRequest{Lat: 41.0, Lng: -75.0, RecordedAt: &recordedAt}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/technicians/me/location", techToken, body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+
+		var updated models.Technician
+		ctx.DB.First(&updated, technician.ID)
+		if *updated.CurrentLat != 40.2 || *updated.LastLocationAt != start.Add(20*time.Minute).Unix() {
+			t.Errorf("Expected current position to stay at the newest point, got %v at %v", *updated.CurrentLat, *updated.LastLocationAt)
+		}
+	})
+
+	t.Run("Invalid points are rejected", func(t *testing.T) {
+		future := time.Now().Add(time.Hour)
+		body := validation.LocationUpdateRequest{Lat: 40.0, Lng: -74.0, RecordedAt: &future}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/technicians/me/location", techToken, body)
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "INVALID_LOCATION_TIMESTAMP") {
+			t.Errorf("Expected INVALID_LOCATION_TIMESTAMP, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/technicians/me/location", techToken, map[string]interface{}{"lat": 120.0, "lng": -74.0})
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "VALIDATION_ERROR") {
+			t.Errorf("Expected VALIDATION_ERROR, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Owner reads the trail", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", trailPath, ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var trail validation.LocationTrailResponse
+		if err := tests.ParseDataResponse(w, &trail); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if trail.TotalPoints != 22 || len(trail.Points) != 22 || trail.Downsampled {
+			t.Fatalf("Expected all 22 points, got %d of %d", len(trail.Points), trail.TotalPoints)
+		}
+		for i := 1; i < len(trail.Points); i++ {
+			if trail.Points[i].RecordedAt.Before(trail.Points[i-1].RecordedAt) {
+				t.Fatalf("Expected points in chronological order")
+			}
+		}
+		if trail.Points[0].RouteID == nil || *trail.Points[0].RouteID != route.ID {
+			t.Errorf("Expected points to be tagged with the active route")
+		}
+		if trail.Distance <= 0 {
+			t.Errorf("Expected a travelled distance, got %v", trail.Distance)
+		}
+	})
+
+	t.Run("Long trails are downsampled", func(t *testing.T) {
+		from := start.Add(-time.Minute).Format(time.RFC3339)
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", trailPath+"?max_points=5&from="+from, ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var trail validation.LocationTrailResponse
+		if err := tests.ParseDataResponse(w, &trail); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if !trail.Downsampled || len(trail.Points) > 5 || len(trail.Points) < 2 {
+			t.Fatalf("Expected at most 5 downsampled points, got %d", len(trail.Points))
+		}
+		last := trail.Points[len(trail.Points)-1]
+		if !last.RecordedAt.Equal(start.Add(20 * time.Minute)) {
+			t.Errorf("Expected the newest point to be kept, got %v", last.RecordedAt)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", trailPath+"?from=2024-01-01T00:00:00Z&to=2024-03-01T00:00:00Z", ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusBadRequest, "INVALID_DATE_RANGE") {
+			t.Errorf("Expected INVALID_DATE_RANGE, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Technicians only read their own trail", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", trailPath, techToken, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected technician to read own trail, got %d", w.Code)
+		}
+
+		other := createLocatedTechnician(t, ctx.DB, orgID, techRole.ID, "other@example.com", models.TechnicianStatusActive, nil, nil)
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", fmt.Sprintf("/api/v1/technicians/%d/locations", other.ID), techToken, nil)
+		if !tests.AssertResponseError(w, http.StatusForbidden, "RESOURCE_ACCESS_DENIED") {
+			t.Errorf("Expected RESOURCE_ACCESS_DENIED, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Trails are scoped to the organization", func(t *testing.T) {
+		otherOrgToken, err := ctx.JWTService.GenerateAccessToken(owner.User.ID, orgID+100, owner.User.Email, models.RoleTypeOwner.String())
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", trailPath, otherOrgToken, nil)
+		if !tests.AssertResponseError(w, http.StatusNotFound, "TECHNICIAN_NOT_FOUND") {
+			t.Errorf("Expected TECHNICIAN_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Inactive technicians cannot report locations", func(t *testing.T) {
+		ctx.DB.Model(technician).Update("status", models.TechnicianStatusInactive)
+		body := validation.LocationUpdateRequest{Lat: 40.0, Lng: -74.0}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/technicians/me/location", techToken, body)
+		if !tests.AssertResponseError(w, http.StatusForbidden, "TECHNICIAN_INACTIVE") {
+			t.Errorf("Expected TECHNICIAN_INACTIVE, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
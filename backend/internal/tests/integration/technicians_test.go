@@ -0,0 +1,201 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/validation"
+)
+
+func TestTechnicianHandler_Management(t *testing.T) {
+	ctx, err := tests.SetupTechnicianTestContext()
+	if err != nil {
+		t.Fatalf("Failed to setup test context: %v", err)
+	}
+	defer tests.CleanupTestContext(ctx.TestContext)
+
+	owner, err := tests.CreateCompleteTestUser(ctx.DB, "owner@example.com", "Password123!", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	ownerToken, err := tests.GenerateTestAccessToken(ctx.TestContext, owner)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	orgID := owner.Organization.ID
+	techRole, err := tests.CreateTestRole(ctx.DB, orgID, models.RoleTypeTechnician)
+	if err != nil {
+		t.Fatalf("Failed to create technician role: %v", err)
+	}
+	techUser, err := tests.CreateTestUser(ctx.DB, orgID, techRole.ID, "tech@example.com", "Password123!", true)
+	if err != nil {
+		t.Fatalf("Failed to create technician user: %v", err)
+	}
+	techToken, err := ctx.JWTService.GenerateAccessToken(techUser.ID, orgID, techUser.Email, models.RoleTypeTechnician.String())
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	var created validation.TechnicianResponse
+	t.Run("Owner creates a technician profile", func(t *testing.T) {
+		body := validation.TechnicianCreateRequest{UserID: techUser.ID, PhoneNumber: "5551234567", Notes: "Night shift"}
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/technicians", ownerToken, body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		if err := tests.ParseDataResponse(w, &created); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if created.Status != models.TechnicianStatusActive || created.User.Email != techUser.Email {
+			t.Errorf("Unexpected technician %+v", created)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", "/api/v1/technicians", ownerToken, body)
+		if !tests.AssertResponseError(w, http.StatusConflict, "TECHNICIAN_EXISTS") {
+			t.Errorf("Expected TECHNICIAN_EXISTS, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Owner lists technicians with filters", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/technicians?status=active&search=tech&sort_by=name", ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var technicians []validation.TechnicianResponse
+		if err := tests.ParseDataResponse(w, &technicians); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(technicians) != 1 || technicians[0].ID != created.ID {
+			t.Errorf("Expected the created technician, got %+v", technicians)
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/technicians?status=off_duty", ownerToken, nil)
+		technicians = nil
+		if err := tests.ParseDataResponse(w, &technicians); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(technicians) != 0 {
+			t.Errorf("Expected no off duty technicians, got %d", len(technicians))
+		}
+	})
+
+	t.Run("Technician reads and updates their own profile", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/technicians/me", techToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", fmt.Sprintf("/api/v1/technicians/%d", created.ID), techToken, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected technician to read own profile by ID, got %d", w.Code)
+		}
+
+		status := models.TechnicianStatusOnBreak
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", "/api/v1/technicians/me", techToken, validation.TechnicianSelfUpdateRequest{Status: &status})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var updated validation.TechnicianResponse
+		if err := tests.ParseDataResponse(w, &updated); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if updated.Status != models.TechnicianStatusOnBreak {
+			t.Errorf("Expected status on_break, got %s", updated.Status)
+		}
+
+		inactive := models.TechnicianStatusInactive
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", "/api/v1/technicians/me", techToken, validation.TechnicianSelfUpdateRequest{Status: &inactive})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected technicians not to deactivate themselves, got %d", w.Code)
+		}
+	})
+
+	t.Run("Technician cannot manage other technicians", func(t *testing.T) {
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", "/api/v1/technicians", techToken, nil)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d for listing, got %d", http.StatusForbidden, w.Code)
+		}
+
+		otherUser, err := tests.CreateTestUser(ctx.DB, orgID, techRole.ID, "other@example.com", "Password123!", true)
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		other, err := tests.CreateTestTechnician(ctx.DB, orgID, otherUser.ID, models.TechnicianStatusActive)
+		if err != nil {
+			t.Fatalf("Failed to create technician: %v", err)
+		}
+
+		path := fmt.Sprintf("/api/v1/technicians/%d", other.ID)
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "GET", path, techToken, nil)
+		if !tests.AssertResponseError(w, http.StatusForbidden, "RESOURCE_ACCESS_DENIED") {
+			t.Errorf("Expected RESOURCE_ACCESS_DENIED, got %d: %s", w.Code, w.Body.String())
+		}
+
+		notes := "hijacked"
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", path, techToken, validation.TechnicianUpdateRequest{Notes: &notes})
+		if !tests.AssertResponseError(w, http.StatusForbidden, "RESOURCE_ACCESS_DENIED") {
+			t.Errorf("Expected RESOURCE_ACCESS_DENIED, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", path+"/deactivate", techToken, nil)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d for deactivate, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("Owner deactivates a technician", func(t *testing.T) {
+		route := models.Route{Base: models.Base{OrganizationID: orgID}, Name: "Busy", TechnicianID: &created.ID, Status: models.RouteStatusStarted}
+		if err := ctx.DB.Create(&route).Error; err != nil {
+			t.Fatalf("Failed to create route: %v", err)
+		}
+
+		path := fmt.Sprintf("/api/v1/technicians/%d/deactivate", created.ID)
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "POST", path, ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusConflict, "TECHNICIAN_ON_ROUTE") {
+			t.Fatalf("Expected TECHNICIAN_ON_ROUTE, got %d: %s", w.Code, w.Body.String())
+		}
+
+		ctx.DB.Model(&route).Update("status", models.RouteStatusAssigned)
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", path, ownerToken, nil)
+		if !tests.AssertResponseError(w, http.StatusConflict, "TECHNICIAN_HAS_ASSIGNED_ROUTES") {
+			t.Fatalf("Expected TECHNICIAN_HAS_ASSIGNED_ROUTES, got %d: %s", w.Code, w.Body.String())
+		}
+		inactive := models.TechnicianStatusInactive
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", fmt.Sprintf("/api/v1/technicians/%d", created.ID), ownerToken, validation.TechnicianUpdateRequest{Status: &inactive})
+		if !tests.AssertResponseError(w, http.StatusConflict, "TECHNICIAN_HAS_ASSIGNED_ROUTES") {
+			t.Fatalf("Expected updates to the inactive status to be checked too, got %d: %s", w.Code, w.Body.String())
+		}
+
+		ctx.DB.Model(&route).Update("status", models.RouteStatusCompleted)
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "POST", path, ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		status := models.TechnicianStatusActive
+		w = tests.MakeAuthenticatedRequest(ctx.Router, "PATCH", "/api/v1/technicians/me", techToken, validation.TechnicianSelfUpdateRequest{Status: &status})
+		if !tests.AssertResponseError(w, http.StatusForbidden, "TECHNICIAN_INACTIVE") {
+			t.Errorf("Expected TECHNICIAN_INACTIVE, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Technicians from other organizations are not visible", func(t *testing.T) {
+		otherOrg := &models.Organization{Name: "Other", SubDomain: "other", ContactEmail: "other@example.com", Active: true}
+		if err := ctx.DB.Create(otherOrg).Error; err != nil {
+			t.Fatalf("Failed to create organization: %v", err)
+		}
+		token, err := ctx.JWTService.GenerateAccessToken(owner.User.ID, otherOrg.ID, owner.User.Email, models.RoleTypeOwner.String())
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+
+		w := tests.MakeAuthenticatedRequest(ctx.Router, "GET", fmt.Sprintf("/api/v1/technicians/%d", created.ID), token, nil)
+		if !tests.AssertResponseError(w, http.StatusNotFound, "TECHNICIAN_NOT_FOUND") {
+			t.Errorf("Expected TECHNICIAN_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
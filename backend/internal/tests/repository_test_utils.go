@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"routrapp-api/internal/api"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+	"routrapp-api/internal/repositories/memory"
+	"routrapp-api/internal/tenant"
+	"routrapp-api/internal/utils/auth"
+)
+
+// RepositoryTestContext holds dependencies for endpoint tests backed by the in-memory
+// repositories; it needs no database
+type RepositoryTestContext struct {
+	Router       *gin.Engine
+	JWTService   *auth.JWTService
+	Repositories repositories.Repositories
+}
+
+// SetupRepositoryTestContext creates a test context with the repository-backed user, technician
+// and route activity endpoints registered over an empty in-memory store
+func SetupRepositoryTestContext() *RepositoryTestContext {
+	gin.SetMode(gin.TestMode)
+
+	jwtService := auth.NewJWTService("test-secret-key")
+	repos := memory.NewRepositories()
+	userHandler := api.NewUserHandlerWithRepository(repos.Users)
+	technicianHandler := api.NewTechnicianHandlerWithRepositories(nil, repos, nil)
+	routeHandler := api.NewRouteHandlerWithRepositories(nil, repos, nil)
+
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.Use(middleware.AuthMiddlewareWithJWT(jwtService))
+	{
+		v1.PUT("/users/profile", userHandler.UpdateProfile)
+
+		v1.GET("/technicians", middleware.RequirePermission("technicians.read"), technicianHandler.ListTechnicians)
+		v1.POST("/technicians", middleware.RequirePermission("technicians.create"), technicianHandler.CreateTechnician)
+		v1.GET("/technicians/me", middleware.RequirePermission("technicians.read_own"), technicianHandler.GetMyProfile)
+		v1.PATCH("/technicians/me", middleware.RequirePermission("technicians.update_own"), technicianHandler.UpdateMyProfile)
+		v1.GET("/technicians/:id", middleware.RequireAnyPermission("technicians.read", "technicians.read_own"), technicianHandler.GetTechnician)
+		v1.PATCH("/technicians/:id", middleware.RequireAnyPermission("technicians.update", "technicians.update_own"), technicianHandler.UpdateTechnician)
+		v1.POST("/technicians/:id/deactivate", middleware.RequirePermission("technicians.deactivate"), technicianHandler.DeactivateTechnician)
+		v1.GET("/technicians/:id/activities", middleware.RequireAnyPermission("technicians.read", "technicians.read_own"), technicianHandler.ListTechnicianActivities)
+
+		v1.GET("/routes", middleware.RequirePermission("routes.read"), routeHandler.ListRoutes)
+		v1.POST("/routes", middleware.RequirePermission("routes.create"), routeHandler.CreateRoute)
+		v1.GET("/routes/:id", middleware.RequirePermission("routes.read"), routeHandler.GetRoute)
+		v1.PATCH("/routes/:id", middleware.RequirePermission("routes.update"), routeHandler.UpdateRoute)
+		v1.DELETE("/routes/:id", middleware.RequirePermission("routes.delete"), routeHandler.DeleteRoute)
+		v1.POST("/routes/:id/optimize", middleware.RequirePermission("routes.update"), routeHandler.OptimizeRoute)
+		v1.GET("/routes/:id/activities", middleware.RequirePermission("routes.read"), routeHandler.ListRouteActivities)
+	}
+
+	return &RepositoryTestContext{
+		Router:       router,
+		JWTService:   jwtService,
+		Repositories: repos,
+	}
+}
+
+// CreateRepositoryTestOrganization stores an active organization with the subdomain
+func CreateRepositoryTestOrganization(ctx *RepositoryTestContext, subDomain string) (*models.Organization, error) {
+	organization := &models.Organization{
+		Name:         "Org " + subDomain,
+		SubDomain:    subDomain,
+		ContactEmail: "contact@" + subDomain + ".example.com",
+		Active:       true,
+	}
+	err := ctx.Repositories.Organizations.Create(context.Background(), organization)
+	return organization, err
+}
+
+// CreateRepositoryTestUser stores an active user holding a role of the type in the organization
+func CreateRepositoryTestUser(ctx *RepositoryTestContext, organizationID uint, roleType models.RoleType, email string) (*models.User, error) {
+	user := &models.User{
+		Email:     email,
+		FirstName: "Test",
+		LastName:  string(roleType),
+		Active:    true,
+		Role: models.Role{
+			Name:        roleType,
+			DisplayName: string(roleType),
+			Active:      true,
+		},
+	}
+	err := ctx.Repositories.Users.Create(tenant.WithOrganization(context.Background(), organizationID), user)
+	return user, err
+}
+
+// GenerateRepositoryTestAccessToken issues an access token for the user
+func GenerateRepositoryTestAccessToken(ctx *RepositoryTestContext, user *models.User) (string, error) {
+	return ctx.JWTService.GenerateAccessToken(user.ID, user.OrganizationID, user.Email, user.Role.Name.String())
+}
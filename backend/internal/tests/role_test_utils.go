@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"time"
+
+	"routrapp-api/internal/api"
+	"routrapp-api/internal/middleware"
+)
+
+// RoleTestContext holds dependencies for role management tests
+type RoleTestContext struct {
+	*TestContext
+	RoleHandler *api.RoleHandler
+	Permissions *middleware.DBPermissionChecker
+}
+
+// SetupRoleTestContext creates a test context with the role endpoints registered behind the
+// database permission checker, so users holding custom roles are authorized by their stored permissions
+func SetupRoleTestContext() (*RoleTestContext, error) {
+	ctx, err := SetupTestContext()
+	if err != nil {
+		return nil, err
+	}
+
+	roleHandler := api.NewRoleHandler(ctx.DB)
+	checker := middleware.NewDBPermissionChecker(ctx.DB, time.Minute)
+
+	v1 := ctx.Router.Group("/api/v1")
+	v1.Use(middleware.PermissionCheckerMiddleware(checker), middleware.AuthMiddlewareWithJWT(ctx.JWTService))
+	{
+		v1.GET("/permissions", middleware.RequirePermission("roles.read"), roleHandler.ListPermissions)
+		v1.GET("/roles", middleware.RequirePermission("roles.read"), roleHandler.ListRoles)
+		v1.POST("/roles", middleware.RequirePermission("roles.create"), roleHandler.CreateRole)
+		v1.GET("/roles/:id", middleware.RequirePermission("roles.read"), roleHandler.GetRole)
+		v1.PATCH("/roles/:id", middleware.RequirePermission("roles.update"), roleHandler.UpdateRole)
+		v1.DELETE("/roles/:id", middleware.RequirePermission("roles.delete"), roleHandler.DeleteRole)
+		v1.PUT("/users/:id/role", middleware.RequirePermission("roles.assign"), roleHandler.AssignUserRole)
+	}
+
+	return &RoleTestContext{
+		TestContext: ctx,
+		RoleHandler: roleHandler,
+		Permissions: checker,
+	}, nil
+}
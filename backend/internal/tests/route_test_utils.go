@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+
+	"routrapp-api/internal/api"
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RouteTestContext holds dependencies for route endpoint tests
+type RouteTestContext struct {
+	*TestContext
+	RouteHandler *api.RouteHandler
+}
+
+// SetupRouteTestContext creates a test context with the route endpoints registered
+func SetupRouteTestContext() (*RouteTestContext, error) {
+	ctx, err := SetupTestContext()
+	if err != nil {
+		return nil, err
+	}
+
+	routeHandler := api.NewRouteHandler(ctx.DB)
+	registerRouteEndpoints(ctx, routeHandler)
+
+	return &RouteTestContext{
+		TestContext:  ctx,
+		RouteHandler: routeHandler,
+	}, nil
+}
+
+// registerRouteEndpoints registers the route endpoints on the test router
+func registerRouteEndpoints(ctx *TestContext, routeHandler *api.RouteHandler) {
+	routes := ctx.Router.Group("/api/v1/routes")
+	routes.Use(middleware.AuthMiddlewareWithJWT(ctx.JWTService))
+	{
+		routes.GET("", middleware.RequirePermission("routes.read"), routeHandler.ListRoutes)
+		routes.POST("", middleware.RequirePermission("routes.create"), routeHandler.CreateRoute)
+		routes.POST("/plan", middleware.RequirePermission("routes.create"), routeHandler.PlanRoutes)
+		routes.GET("/:id", middleware.RequirePermission("routes.read"), routeHandler.GetRoute)
+		routes.PATCH("/:id", middleware.RequirePermission("routes.update"), routeHandler.UpdateRoute)
+		routes.DELETE("/:id", middleware.RequirePermission("routes.delete"), routeHandler.DeleteRoute)
+		routes.POST("/:id/optimize", middleware.RequirePermission("routes.optimize"), routeHandler.OptimizeRoute)
+		routes.GET("/:id/schedule", middleware.RequirePermission("routes.read"), routeHandler.GetRouteSchedule)
+		routes.POST("/:id/start", middleware.RequirePermission("routes.update_status"), routeHandler.StartRoute)
+		routes.POST("/:id/pause", middleware.RequirePermission("routes.update_status"), routeHandler.PauseRoute)
+		routes.POST("/:id/resume", middleware.RequirePermission("routes.update_status"), routeHandler.ResumeRoute)
+		routes.POST("/:id/complete", middleware.RequirePermission("routes.update_status"), routeHandler.CompleteRoute)
+		routes.POST("/:id/cancel", middleware.RequirePermission("routes.update"), routeHandler.CancelRoute)
+		routes.GET("/:id/activities", middleware.RequirePermission("routes.read"), routeHandler.ListRouteActivities)
+		routes.POST("/:id/activities", middleware.RequirePermission("routes.update_status"), routeHandler.CreateRouteActivity)
+	}
+}
+
+// CreateTestTechnician creates a technician profile for an existing user
+func CreateTestTechnician(db *gorm.DB, orgID, userID uint, status models.TechnicianStatus) (*models.Technician, error) {
+	technician := &models.Technician{
+		Base: models.Base{
+			OrganizationID: orgID,
+		},
+		UserID:      userID,
+		Status:      status,
+		PhoneNumber: "5551234567",
+	}
+
+	err := db.Create(technician).Error
+	return technician, err
+}
+
+// GenerateTestAccessToken issues an access token for a test user
+func GenerateTestAccessToken(ctx *TestContext, user *TestUser) (string, error) {
+	return ctx.JWTService.GenerateAccessToken(user.User.ID, user.Organization.ID, user.User.Email, user.Role.Name.String())
+}
+
+// MakeAuthenticatedRequest sends a JSON request with a bearer token
+func MakeAuthenticatedRequest(router *gin.Engine, method, path, accessToken string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		jsonBody, _ := json.Marshal(body)
+		buf.Write(jsonBody)
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// ParseDataResponse decodes the "data" field of a successful response into out
+func ParseDataResponse(w *httptest.ResponseRecorder, out interface{}) error {
+	var response struct {
+		Success bool            `json:"success"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		return err
+	}
+	return json.Unmarshal(response.Data, out)
+}
@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"time"
+
+	"routrapp-api/internal/api"
+	"routrapp-api/internal/events"
+	"routrapp-api/internal/middleware"
+)
+
+// StreamTestContext holds dependencies for event stream tests
+type StreamTestContext struct {
+	*TestContext
+	Events            *events.Hub
+	RouteHandler      *api.RouteHandler
+	TechnicianHandler *api.TechnicianHandler
+}
+
+// StreamTestHeartbeat is how often test streams ping and check that their token wasn't revoked
+const StreamTestHeartbeat = 50 * time.Millisecond
+
+// SetupStreamTestContext creates a test context with the event stream, route and technician
+// endpoints registered, all sharing one event hub
+func SetupStreamTestContext() (*StreamTestContext, error) {
+	ctx, err := SetupTestContext()
+	if err != nil {
+		return nil, err
+	}
+
+	hub := events.NewHub()
+	routeHandler := api.NewRouteHandlerWithEvents(ctx.DB, hub)
+	technicianHandler := api.NewTechnicianHandlerWithEvents(ctx.DB, hub)
+	streamHandler := api.NewStreamHandlerWithHeartbeat(hub, StreamTestHeartbeat)
+
+	registerRouteEndpoints(ctx, routeHandler)
+	registerTechnicianEndpoints(ctx, technicianHandler)
+	ctx.Router.GET("/api/v1/stream", middleware.AuthMiddlewareWithJWT(ctx.JWTService), middleware.RequirePermission("technicians.read"), streamHandler.Stream)
+
+	return &StreamTestContext{
+		TestContext:       ctx,
+		Events:            hub,
+		RouteHandler:      routeHandler,
+		TechnicianHandler: technicianHandler,
+	}, nil
+}
@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"routrapp-api/internal/api"
+	"routrapp-api/internal/middleware"
+)
+
+// TechnicianTestContext holds dependencies for technician endpoint tests
+type TechnicianTestContext struct {
+	*TestContext
+	TechnicianHandler *api.TechnicianHandler
+}
+
+// SetupTechnicianTestContext creates a test context with the technician endpoints registered
+func SetupTechnicianTestContext() (*TechnicianTestContext, error) {
+	ctx, err := SetupTestContext()
+	if err != nil {
+		return nil, err
+	}
+
+	technicianHandler := api.NewTechnicianHandler(ctx.DB)
+	registerTechnicianEndpoints(ctx, technicianHandler)
+
+	return &TechnicianTestContext{
+		TestContext:       ctx,
+		TechnicianHandler: technicianHandler,
+	}, nil
+}
+
+// registerTechnicianEndpoints registers the technician endpoints on the test router
+func registerTechnicianEndpoints(ctx *TestContext, technicianHandler *api.TechnicianHandler) {
+	technicians := ctx.Router.Group("/api/v1/technicians")
+	technicians.Use(middleware.AuthMiddlewareWithJWT(ctx.JWTService))
+	{
+		technicians.GET("", middleware.RequirePermission("technicians.read"), technicianHandler.ListTechnicians)
+		technicians.POST("", middleware.RequirePermission("technicians.create"), technicianHandler.CreateTechnician)
+		technicians.GET("/me", middleware.RequirePermission("technicians.read_own"), technicianHandler.GetMyProfile)
+		technicians.PATCH("/me", middleware.RequirePermission("technicians.update_own"), technicianHandler.UpdateMyProfile)
+		technicians.POST("/me/location", middleware.RequirePermission("technicians.update_own"), technicianHandler.RecordMyLocation)
+		technicians.GET("/:id", middleware.RequireAnyPermission("technicians.read", "technicians.read_own"), technicianHandler.GetTechnician)
+		technicians.PATCH("/:id", middleware.RequireAnyPermission("technicians.update", "technicians.update_own"), technicianHandler.UpdateTechnician)
+		technicians.POST("/:id/deactivate", middleware.RequirePermission("technicians.deactivate"), technicianHandler.DeactivateTechnician)
+		technicians.GET("/:id/locations", middleware.RequireAnyPermission("technicians.read", "technicians.read_own"), technicianHandler.GetLocationTrail)
+		technicians.GET("/:id/activities", middleware.RequireAnyPermission("technicians.read", "technicians.read_own"), technicianHandler.ListTechnicianActivities)
+	}
+}
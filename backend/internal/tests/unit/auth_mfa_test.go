@@ -0,0 +1,190 @@
+package unit_test
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/utils/auth"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors, "12345678901234567890", base32 encoded
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	// The RFC lists 8 digit codes; 6 digit codes are their last 6 digits
+	testCases := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for _, tc := range testCases {
+		got, err := auth.TOTPCode(rfc6238Secret, time.Unix(tc.unix, 0))
+		if err != nil {
+			t.Fatalf("TOTPCode failed: %v", err)
+		}
+		if got != tc.want {
+			t.Errorf("TOTPCode at %d = %s, want %s", tc.unix, got, tc.want)
+		}
+	}
+
+	if _, err := auth.TOTPCode("not base32!", time.Now()); err == nil {
+		t.Errorf("Expected an invalid secret to be rejected")
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	step := now.Unix() / 30
+
+	t.Run("Accepts the current code and returns its step", func(t *testing.T) {
+		got, ok := auth.ValidateTOTP(rfc6238Secret, "081804", now, 0)
+		if !ok || got != step {
+			t.Errorf("Expected step %d to be accepted, got %d, %t", step, got, ok)
+		}
+	})
+
+	t.Run("Tolerates one step of clock drift", func(t *testing.T) {
+		previous, _ := auth.TOTPCode(rfc6238Secret, now.Add(-30*time.Second))
+		next, _ := auth.TOTPCode(rfc6238Secret, now.Add(30*time.Second))
+		if _, ok := auth.ValidateTOTP(rfc6238Secret, previous, now, 0); !ok {
+			t.Errorf("Expected the previous code to be accepted")
+		}
+		if _, ok := auth.ValidateTOTP(rfc6238Secret, next, now, 0); !ok {
+			t.Errorf("Expected the next code to be accepted")
+		}
+		stale, _ := auth.TOTPCode(rfc6238Secret, now.Add(-90*time.Second))
+		if _, ok := auth.ValidateTOTP(rfc6238Secret, stale, now, 0); ok {
+			t.Errorf("Expected a code three steps old to be rejected")
+		}
+	})
+
+	t.Run("Rejects codes at or before the last used step", func(t *testing.T) {
+		if _, ok := auth.ValidateTOTP(rfc6238Secret, "081804", now, step); ok {
+			t.Errorf("Expected a replayed code to be rejected")
+		}
+		previous, _ := auth.TOTPCode(rfc6238Secret, now.Add(-30*time.Second))
+		if _, ok := auth.ValidateTOTP(rfc6238Secret, previous, now, step); ok {
+			t.Errorf("Expected a code older than the last used one to be rejected")
+		}
+	})
+
+	t.Run("Rejects malformed codes", func(t *testing.T) {
+		for _, code := range []string{"", "08180", "0818045", "abcdef"} {
+			if _, ok := auth.ValidateTOTP(rfc6238Secret, code, now, 0); ok {
+				t.Errorf("Expected %q to be rejected", code)
+			}
+		}
+	})
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret failed: %v", err)
+	}
+	if !regexp.MustCompile(`^[A-Z2-7]{32}$`).MatchString(secret) {
+		t.Errorf("Expected 32 base32 characters without padding, got %q", secret)
+	}
+	if other, _ := auth.GenerateTOTPSecret(); other == secret {
+		t.Errorf("Expected secrets to be random")
+	}
+	if _, err := auth.TOTPCode(secret, time.Now()); err != nil {
+		t.Errorf("Expected a generated secret to produce codes: %v", err)
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := auth.TOTPProvisioningURI("RoutrApp", "jane+owner@example.com", rfc6238Secret)
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("Failed to parse %q: %v", uri, err)
+	}
+	if parsed.Scheme != "otpauth" || parsed.Host != "totp" {
+		t.Errorf("Expected an otpauth://totp URI, got %q", uri)
+	}
+	if parsed.Path != "/RoutrApp:jane+owner@example.com" {
+		t.Errorf("Unexpected label %q", parsed.Path)
+	}
+	query := parsed.Query()
+	for key, want := range map[string]string{"secret": rfc6238Secret, "issuer": "RoutrApp", "algorithm": "SHA1", "digits": "6", "period": "30"} {
+		if got := query.Get(key); got != want {
+			t.Errorf("Expected %s=%s, got %q", key, want, got)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := auth.GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes failed: %v", err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("Expected 10 codes, got %d", len(codes))
+	}
+	format := regexp.MustCompile(`^[a-z1-9]{5}-[a-z1-9]{5}$`)
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("Unexpected recovery code format %q", code)
+		}
+		if strings.ContainsAny(code, "ilo0") {
+			t.Errorf("Expected %q not to contain easily confused characters", code)
+		}
+		if seen[code] {
+			t.Errorf("Duplicate recovery code %q", code)
+		}
+		seen[code] = true
+	}
+
+	for _, typed := range []string{"ABCDE-FGHJK", " abcdefghjk ", "abcde fghjk", "abcde-fghjk"} {
+		if got := auth.NormalizeRecoveryCode(typed); got != "abcde-fghjk" {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want abcde-fghjk", typed, got)
+		}
+	}
+}
+
+func TestSecretBox(t *testing.T) {
+	box := auth.NewSecretBox("test-secret-key")
+
+	sealed, err := box.Seal(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if strings.Contains(sealed, rfc6238Secret) {
+		t.Errorf("Expected the sealed value not to contain the plaintext")
+	}
+	if again, _ := box.Seal(rfc6238Secret); again == sealed {
+		t.Errorf("Expected every seal to use a new nonce")
+	}
+
+	opened, err := box.Open(sealed)
+	if err != nil || opened != rfc6238Secret {
+		t.Errorf("Expected the secret back, got %q, %v", opened, err)
+	}
+
+	if _, err := auth.NewSecretBox("another-key").Open(sealed); err == nil {
+		t.Errorf("Expected a different key to fail to open the secret")
+	}
+	tampered := []byte(sealed)
+	middle := len(tampered) / 2
+	if tampered[middle] == 'A' {
+		tampered[middle] = 'B'
+	} else {
+		tampered[middle] = 'A'
+	}
+	if _, err := box.Open(string(tampered)); err == nil {
+		t.Errorf("Expected a tampered secret to be rejected")
+	}
+	if _, err := box.Open("short"); err == nil {
+		t.Errorf("Expected a truncated secret to be rejected")
+	}
+}
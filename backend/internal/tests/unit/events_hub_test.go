@@ -0,0 +1,83 @@
+package unit_test
+
+import (
+	"testing"
+
+	"routrapp-api/internal/events"
+)
+
+func TestHub_PublishIsScopedToOrganization(t *testing.T) {
+	hub := events.NewHub()
+	defer hub.Close()
+
+	first := hub.Subscribe(1)
+	second := hub.Subscribe(2)
+
+	hub.Publish(1, events.EventRouteStatus, events.RouteStatusEvent{RouteID: 7})
+
+	select {
+	case event := <-first.Events():
+		if event.OrganizationID != 1 || event.Type != events.EventRouteStatus || event.ID == 0 {
+			t.Errorf("Unexpected event %+v", event)
+		}
+	default:
+		t.Fatal("Expected the subscriber of organization 1 to receive the event")
+	}
+
+	select {
+	case event := <-second.Events():
+		t.Errorf("Expected organization 2 to receive nothing, got %+v", event)
+	default:
+	}
+}
+
+func TestHub_SlowSubscriberIsDisconnected(t *testing.T) {
+	hub := events.NewHubWithBufferSize(2)
+	defer hub.Close()
+
+	slow := hub.Subscribe(1)
+	for i := 0; i < 3; i++ {
+		hub.Publish(1, events.EventTechnicianLocation, nil)
+	}
+
+	if hub.SubscriberCount(1) != 0 {
+		t.Errorf("Expected the slow subscriber to be removed, got %d subscribers", hub.SubscriberCount(1))
+	}
+
+	received := 0
+	for range slow.Events() {
+		received++
+	}
+	if received != 2 {
+		t.Errorf("Expected the buffered events before disconnect, got %d", received)
+	}
+}
+
+func TestHub_CloseEndsSubscriptions(t *testing.T) {
+	hub := events.NewHub()
+	sub := hub.Subscribe(1)
+	sub.Close()
+	sub.Close()
+
+	if _, open := <-sub.Events(); open {
+		t.Error("Expected a closed subscription channel")
+	}
+
+	other := hub.Subscribe(1)
+	hub.Close()
+	if _, open := <-other.Events(); open {
+		t.Error("Expected closing the hub to close subscriptions")
+	}
+
+	late := hub.Subscribe(1)
+	if _, open := <-late.Events(); open {
+		t.Error("Expected subscriptions to a closed hub to be closed")
+	}
+	hub.Publish(1, events.EventRouteStatus, nil)
+}
+
+func TestHub_NilHubDiscardsEvents(t *testing.T) {
+	var hub *events.Hub
+	hub.Publish(1, events.EventRouteStatus, nil)
+	hub.Close()
+}
@@ -0,0 +1,277 @@
+package unit_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/config"
+	"routrapp-api/internal/utils/auth"
+	"routrapp-api/internal/utils/constants"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// tokenHeader returns the header of a token without verifying it
+func tokenHeader(t *testing.T, tokenString string) map[string]interface{} {
+	t.Helper()
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &auth.JWTClaims{})
+	if err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+	return token.Header
+}
+
+func TestJWTService_SigningKeys(t *testing.T) {
+	for _, algorithm := range []string{auth.AlgorithmRS256, auth.AlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			keys, err := auth.NewKeyRing(algorithm, "", 0)
+			if err != nil {
+				t.Fatalf("NewKeyRing failed: %v", err)
+			}
+			service := auth.NewJWTServiceWithKeys(keys)
+
+			token, err := service.GenerateAccessToken(1, 2, "jane@example.com", "owner")
+			if err != nil {
+				t.Fatalf("GenerateAccessToken failed: %v", err)
+			}
+			header := tokenHeader(t, token)
+			if header["alg"] != algorithm || header["kid"] != keys.Current().ID {
+				t.Errorf("Expected alg %s and kid %s, got %v", algorithm, keys.Current().ID, header)
+			}
+
+			claims, err := service.ValidateToken(token)
+			if err != nil {
+				t.Fatalf("ValidateToken failed: %v", err)
+			}
+			if claims.UserID != 1 || claims.OrganizationID != 2 || !claims.IsAccessToken() {
+				t.Errorf("Unexpected claims %+v", claims)
+			}
+
+			jwks := service.JWKS()
+			if len(jwks.Keys) != 1 || jwks.Keys[0].KeyID != keys.Current().ID || jwks.Keys[0].Algorithm != algorithm || jwks.Keys[0].Use != "sig" {
+				t.Errorf("Unexpected JWKS %+v", jwks)
+			}
+
+			// Another key ring doesn't know the key
+			other, _ := auth.NewKeyRing(algorithm, "", 0)
+			if _, err := auth.NewJWTServiceWithKeys(other).ValidateToken(token); err == nil {
+				t.Errorf("Expected a token of an unknown key to be rejected")
+			}
+		})
+	}
+}
+
+func TestJWTService_RejectsOtherAlgorithms(t *testing.T) {
+	keys, err := auth.NewKeyRing(auth.AlgorithmRS256, "", 0)
+	if err != nil {
+		t.Fatalf("NewKeyRing failed: %v", err)
+	}
+	service := auth.NewJWTServiceWithKeys(keys)
+	secretService := auth.NewJWTService("test-secret-key")
+
+	hmacToken, _ := secretService.GenerateAccessToken(1, 2, "jane@example.com", "owner")
+	if _, err := service.ValidateToken(hmacToken); err == nil {
+		t.Errorf("Expected an HS256 token to be rejected by a key ring")
+	}
+	rsaToken, _ := service.GenerateAccessToken(1, 2, "jane@example.com", "owner")
+	if _, err := secretService.ValidateToken(rsaToken); err == nil {
+		t.Errorf("Expected an RS256 token to be rejected by the secret")
+	}
+
+	// A token signed with HS256 and the published public key as the secret names a known key
+	publicDER, err := x509.MarshalPKIXPublicKey(keys.Current().PublicKey())
+	if err != nil {
+		t.Fatalf("Failed to encode public key: %v", err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.JWTClaims{
+		UserID:    1,
+		TokenType: "access",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+	forged.Header["kid"] = keys.Current().ID
+	forgedToken, _ := forged.SignedString(publicPEM)
+	if _, err := service.ValidateToken(forgedToken); err == nil {
+		t.Errorf("Expected an HS256 token signed with the public key to be rejected")
+	}
+
+	if len(secretService.JWKS().Keys) != 0 {
+		t.Errorf("Expected no keys to be published for the secret")
+	}
+}
+
+func TestKeyRing_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	clock := newTestClock()
+	keys, err := auth.NewKeyRingWithClock(auth.AlgorithmEdDSA, dir, 24*time.Hour, clock.Now)
+	if err != nil {
+		t.Fatalf("NewKeyRing failed: %v", err)
+	}
+	// Another replica sharing the key directory
+	replica, err := auth.NewKeyRingWithClock(auth.AlgorithmEdDSA, dir, 24*time.Hour, clock.Now)
+	if err != nil {
+		t.Fatalf("NewKeyRing failed: %v", err)
+	}
+	first := keys.Current()
+	if replica.Current().ID != first.ID {
+		t.Fatalf("Expected replicas to share the first key")
+	}
+	service := auth.NewJWTServiceWithKeys(keys)
+	oldToken, _ := service.GenerateAccessToken(1, 2, "jane@example.com", "owner")
+
+	if rotated, err := keys.Rotate(clock.Now().Add(time.Hour)); err != nil || rotated {
+		t.Fatalf("Expected no rotation before the interval, got %t, %v", rotated, err)
+	}
+
+	// The next key is published a JWKS cache period before the interval ends, but doesn't sign yet
+	clock.Advance(24*time.Hour - constants.JWKSCacheMaxAge)
+	if rotated, err := keys.Rotate(clock.Now()); err != nil || !rotated {
+		t.Fatalf("Expected the next key to be published, got %t, %v", rotated, err)
+	}
+	second := keys.Next()
+	if second == nil || second.ID == first.ID || keys.Current().ID != first.ID {
+		t.Fatalf("Expected a published next key while the first one still signs")
+	}
+	if !second.CreatedAt.Equal(first.CreatedAt.Add(24 * time.Hour)) {
+		t.Errorf("Expected the next key to sign once the interval ended, got %s", second.CreatedAt)
+	}
+	if len(service.JWKS().Keys) != 2 {
+		t.Errorf("Expected both keys to be published, got %+v", service.JWKS())
+	}
+	if rotated, err := keys.Rotate(clock.Now().Add(time.Minute)); err != nil || rotated {
+		t.Fatalf("Expected no other key while the next one is pending, got %t, %v", rotated, err)
+	}
+	if token, _ := service.GenerateAccessToken(1, 2, "jane@example.com", "owner"); tokenHeader(t, token)["kid"] != first.ID {
+		t.Errorf("Expected tokens to be signed with the first key until the next one starts")
+	}
+
+	clock.Advance(constants.JWKSCacheMaxAge)
+	rotatedAt := clock.Now()
+	if rotated, err := keys.Rotate(rotatedAt); err != nil || rotated {
+		t.Fatalf("Expected the published key to start signing without another key, got %t, %v", rotated, err)
+	}
+	if keys.Current().ID != second.ID || keys.Next() != nil {
+		t.Fatalf("Expected the next key to sign once the interval ended")
+	}
+	newToken, _ := service.GenerateAccessToken(1, 2, "jane@example.com", "owner")
+	if tokenHeader(t, newToken)["kid"] != second.ID {
+		t.Errorf("Expected new tokens to be signed with the new key")
+	}
+	if _, err := service.ValidateToken(oldToken); err != nil {
+		t.Errorf("Expected tokens of the replaced key to stay valid: %v", err)
+	}
+
+	// The replica loads the new key when a token names it
+	if _, err := auth.NewJWTServiceWithKeys(replica).ValidateToken(newToken); err != nil {
+		t.Errorf("Expected the replica to verify tokens of the new key: %v", err)
+	}
+
+	// Once every token of the replaced key expired the key is removed
+	lifetime := time.Duration(constants.JWT_REFRESH_TOKEN_EXPIRY) * time.Second
+	if _, err := keys.Rotate(rotatedAt.Add(lifetime - time.Minute)); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if _, ok := keys.Lookup(first.ID); !ok {
+		t.Errorf("Expected the replaced key to verify until its tokens expired")
+	}
+	if _, err := keys.Rotate(rotatedAt.Add(lifetime + time.Minute)); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if _, ok := keys.Lookup(first.ID); ok {
+		t.Errorf("Expected the replaced key to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, first.ID+".pem")); !os.IsNotExist(err) {
+		t.Errorf("Expected the file of the replaced key to be removed, got %v", err)
+	}
+	if _, err := service.ValidateToken(oldToken); err == nil {
+		t.Errorf("Expected tokens of a removed key to be rejected")
+	}
+}
+
+func TestKeyRing_LoadsProvidedKeys(t *testing.T) {
+	dir := t.TempDir()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+	if err := os.WriteFile(filepath.Join(dir, "2025-09.pem"), data, 0o600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+
+	keys, err := auth.NewKeyRing(auth.AlgorithmRS256, dir, 0)
+	if err != nil {
+		t.Fatalf("NewKeyRing failed: %v", err)
+	}
+	if keys.Current().ID != "2025-09" || len(keys.Keys()) != 1 {
+		t.Errorf("Expected the provided key to sign, got %s of %d keys", keys.Current().ID, len(keys.Keys()))
+	}
+
+	// Generated keys are saved with their creation time and load again
+	generated, err := auth.GenerateSigningKey(auth.AlgorithmEdDSA, time.Date(2025, 9, 15, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GenerateSigningKey failed: %v", err)
+	}
+	encoded, err := generated.MarshalPEM()
+	if err != nil {
+		t.Fatalf("MarshalPEM failed: %v", err)
+	}
+	parsed, err := auth.ParseSigningKey(generated.ID, encoded, time.Now())
+	if err != nil {
+		t.Fatalf("ParseSigningKey failed: %v", err)
+	}
+	if parsed.Algorithm != auth.AlgorithmEdDSA || !parsed.CreatedAt.Equal(generated.CreatedAt) {
+		t.Errorf("Expected the key back, got %s created %s", parsed.Algorithm, parsed.CreatedAt)
+	}
+
+	if _, err := auth.NewKeyRing(auth.AlgorithmHS256, dir, 0); err == nil {
+		t.Errorf("Expected HS256 to be rejected for a key ring")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.pem"), []byte("not a key"), 0o600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	if _, err := auth.NewKeyRing(auth.AlgorithmRS256, dir, 0); err == nil || !strings.Contains(err.Error(), "broken.pem") {
+		t.Errorf("Expected an unreadable key to be reported, got %v", err)
+	}
+}
+
+func TestConfig_ValidateJWTSecret(t *testing.T) {
+	testCases := []struct {
+		environment   string
+		secret        string
+		mfaKey        string
+		signingSecret string
+		valid         bool
+	}{
+		{environment: "development", secret: constants.DEFAULT_JWT_SECRET, valid: true},
+		{environment: "test", secret: "", valid: true},
+		{environment: "production", secret: constants.DEFAULT_JWT_SECRET, mfaKey: "mfa-key", signingSecret: "url-secret", valid: false},
+		{environment: "production", secret: "", mfaKey: "mfa-key", signingSecret: "url-secret", valid: false},
+		{environment: "staging", secret: constants.DEFAULT_JWT_SECRET, mfaKey: "mfa-key", signingSecret: "url-secret", valid: false},
+		{environment: "qa", secret: "", mfaKey: "mfa-key", signingSecret: "url-secret", valid: false},
+		{environment: "production", secret: "a-secret-of-its-own", signingSecret: "url-secret", valid: false},
+		{environment: "staging", secret: "a-secret-of-its-own", mfaKey: "mfa-key", valid: false},
+		{environment: "production", secret: "a-secret-of-its-own", mfaKey: "mfa-key", signingSecret: "url-secret", valid: true},
+	}
+
+	for _, tc := range testCases {
+		cfg := &config.Config{
+			Environment: tc.environment,
+			JWT:         config.JWTConfig{Secret: tc.secret},
+			MFA:         config.MFAConfig{SecretKey: tc.mfaKey},
+			Storage:     config.StorageConfig{SigningSecret: tc.signingSecret},
+		}
+		if err := cfg.Validate(); (err == nil) != tc.valid {
+			t.Errorf("Validate in %s with secret %q, MFA key %q and signing secret %q returned %v", tc.environment, tc.secret, tc.mfaKey, tc.signingSecret, err)
+		}
+	}
+}
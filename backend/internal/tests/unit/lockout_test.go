@@ -0,0 +1,323 @@
+package unit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/config"
+	"routrapp-api/internal/lockout"
+	"routrapp-api/internal/tests"
+)
+
+var testLockoutConfig = config.LockoutConfig{
+	MaxAttempts:    5,
+	BackoffAfter:   2,
+	IPMaxAttempts:  8,
+	IPBackoffAfter: 6,
+	BaseDelay:      time.Second,
+	MaxDelay:       4 * time.Second,
+	Duration:       15 * time.Minute,
+	Window:         time.Hour,
+}
+
+// lockoutStores returns the stores the guard is tested with
+func lockoutStores(t *testing.T) map[string]func() lockout.Store {
+	return map[string]func() lockout.Store{
+		"memory": func() lockout.Store { return lockout.NewMemoryStore() },
+		"database": func() lockout.Store {
+			db, err := tests.SetupTestDB()
+			if err != nil {
+				t.Fatalf("Failed to setup test database: %v", err)
+			}
+			return lockout.NewDatabaseStore(db)
+		},
+	}
+}
+
+// testClock is a clock the test moves forward
+type testClock struct {
+	now time.Time
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Date(2025, 9, 15, 9, 0, 0, 0, time.UTC)}
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func failLogin(t *testing.T, g *lockout.Guard, account, ip string) lockout.Failure {
+	t.Helper()
+	failure, err := g.Fail(context.Background(), account, ip)
+	if err != nil {
+		t.Fatalf("Fail returned an error: %v", err)
+	}
+	return failure
+}
+
+func checkLogin(t *testing.T, g *lockout.Guard, account, ip string) lockout.Status {
+	t.Helper()
+	status, err := g.Check(context.Background(), account, ip)
+	if err != nil {
+		t.Fatalf("Check returned an error: %v", err)
+	}
+	return status
+}
+
+func reserveLogin(t *testing.T, g *lockout.Guard, account, ip string) lockout.Status {
+	t.Helper()
+	status, err := g.Reserve(context.Background(), account, ip)
+	if err != nil {
+		t.Fatalf("Reserve returned an error: %v", err)
+	}
+	return status
+}
+
+func TestGuard_AccountBackoffAndLockout(t *testing.T) {
+	for name, newStore := range lockoutStores(t) {
+		t.Run(name, func(t *testing.T) {
+			clock := newTestClock()
+			guard := lockout.NewGuardWithClock(newStore(), testLockoutConfig, clock.Now)
+			account := lockout.AccountKey(1, "Jane@Example.com")
+
+			failLogin(t, guard, account, "")
+			if status := checkLogin(t, guard, account, ""); !status.Allowed() {
+				t.Fatalf("Expected a retry without delay below the backoff threshold, got %+v", status)
+			}
+
+			// Delays double from the base delay once the threshold is reached, up to the maximum
+			for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+				failLogin(t, guard, account, "")
+				status := checkLogin(t, guard, account, "")
+				if status.Allowed() || status.Locked || status.RetryAfter != want {
+					t.Fatalf("Expected to wait %s, got %+v", want, status)
+				}
+				clock.Advance(want)
+				if status := checkLogin(t, guard, account, ""); !status.Allowed() {
+					t.Fatalf("Expected a retry after waiting %s, got %+v", want, status)
+				}
+			}
+
+			failure := failLogin(t, guard, account, "")
+			if !failure.AccountLocked || failure.Failures != 5 {
+				t.Fatalf("Expected the fifth failure to lock the account, got %+v", failure)
+			}
+			status := checkLogin(t, guard, lockout.AccountKey(1, "jane@example.com"), "")
+			if !status.Locked || status.RetryAfter != 15*time.Minute {
+				t.Fatalf("Expected the account to be locked for 15 minutes, got %+v", status)
+			}
+
+			clock.Advance(15 * time.Minute)
+			if status := checkLogin(t, guard, account, ""); !status.Allowed() {
+				t.Fatalf("Expected the lockout to end, got %+v", status)
+			}
+			if failure := failLogin(t, guard, account, ""); failure.Failures != 1 || failure.AccountLocked {
+				t.Errorf("Expected the count to restart after a lockout, got %+v", failure)
+			}
+		})
+	}
+}
+
+func TestGuard_ResetAndWindow(t *testing.T) {
+	for name, newStore := range lockoutStores(t) {
+		t.Run(name, func(t *testing.T) {
+			clock := newTestClock()
+			guard := lockout.NewGuardWithClock(newStore(), testLockoutConfig, clock.Now)
+			account := lockout.AccountKey(1, "jane@example.com")
+
+			for i := 0; i < 4; i++ {
+				failLogin(t, guard, account, "")
+			}
+			if err := guard.Succeed(context.Background(), account); err != nil {
+				t.Fatalf("Succeed failed: %v", err)
+			}
+			if failure := failLogin(t, guard, account, ""); failure.Failures != 1 {
+				t.Errorf("Expected a successful login to reset the count, got %+v", failure)
+			}
+
+			clock.Advance(time.Hour + time.Second)
+			if failure := failLogin(t, guard, account, ""); failure.Failures != 1 {
+				t.Errorf("Expected failures older than the window to be forgotten, got %+v", failure)
+			}
+
+			for i := 0; i < 4; i++ {
+				failLogin(t, guard, account, "")
+			}
+			if status := checkLogin(t, guard, account, ""); !status.Locked {
+				t.Fatalf("Expected the account to be locked, got %+v", status)
+			}
+			if err := guard.Unlock(context.Background(), account); err != nil {
+				t.Fatalf("Unlock failed: %v", err)
+			}
+			if status := checkLogin(t, guard, account, ""); !status.Allowed() {
+				t.Errorf("Expected an unlocked account to be allowed, got %+v", status)
+			}
+		})
+	}
+}
+
+func TestGuard_IPAddresses(t *testing.T) {
+	for name, newStore := range lockoutStores(t) {
+		t.Run(name, func(t *testing.T) {
+			clock := newTestClock()
+			guard := lockout.NewGuardWithClock(newStore(), testLockoutConfig, clock.Now)
+
+			// Failures spread over many accounts are still counted for the address
+			for i := 0; i < 7; i++ {
+				account := lockout.AccountKey(1, string(rune('a'+i))+"@example.com")
+				if failure := failLogin(t, guard, account, "203.0.113.7"); failure.IPBlocked {
+					t.Fatalf("Expected no block after %d failures", i+1)
+				}
+				clock.Advance(time.Minute)
+			}
+			if status := checkLogin(t, guard, "", "198.51.100.1"); !status.Allowed() {
+				t.Errorf("Expected other addresses to be allowed, got %+v", status)
+			}
+
+			if failure := failLogin(t, guard, lockout.AccountKey(1, "h@example.com"), "203.0.113.7"); !failure.IPBlocked || failure.AccountLocked {
+				t.Fatalf("Expected the eighth failure to block the address only, got %+v", failure)
+			}
+			status := checkLogin(t, guard, lockout.AccountKey(1, "new@example.com"), "203.0.113.7")
+			if status.Allowed() || status.Locked {
+				t.Errorf("Expected logins from the address to be refused without locking accounts, got %+v", status)
+			}
+			if err := guard.Succeed(context.Background(), lockout.AccountKey(1, "h@example.com")); err != nil {
+				t.Fatalf("Succeed failed: %v", err)
+			}
+			if status := checkLogin(t, guard, "", "203.0.113.7"); status.Allowed() {
+				t.Errorf("Expected a successful login not to lift the block of the address")
+			}
+		})
+	}
+}
+
+func TestGuard_Throttle(t *testing.T) {
+	for name, newStore := range lockoutStores(t) {
+		t.Run(name, func(t *testing.T) {
+			clock := newTestClock()
+			guard := lockout.NewGuardWithClock(newStore(), testLockoutConfig, clock.Now)
+			throttle := func(email, ip string) lockout.Status {
+				t.Helper()
+				status, err := guard.Throttle(context.Background(), lockout.EmailKey(email), ip)
+				if err != nil {
+					t.Fatalf("Throttle returned an error: %v", err)
+				}
+				return status
+			}
+
+			for i := 0; i < 5; i++ {
+				if status := throttle("user@example.com", "203.0.113.7"); !status.Allowed() {
+					t.Fatalf("Expected request %d to be allowed, got %+v", i+1, status)
+				}
+			}
+			if status := throttle(" User@example.com", "198.51.100.1"); status.Allowed() || status.Locked || status.RetryAfter != 15*time.Minute {
+				t.Fatalf("Expected requests beyond the maximum to be refused for the lockout duration, got %+v", status)
+			}
+			if status := throttle("other@example.com", "198.51.100.1"); !status.Allowed() {
+				t.Errorf("Expected other addresses to be allowed, got %+v", status)
+			}
+
+			// Requests from one IP address are limited across addresses
+			for i := 0; i < 3; i++ {
+				if status := throttle(string(rune('a'+i))+"@example.com", "203.0.113.7"); !status.Allowed() {
+					t.Fatalf("Expected request %d from the address to be allowed, got %+v", 6+i, status)
+				}
+			}
+			if status := throttle("d@example.com", "203.0.113.7"); status.Allowed() {
+				t.Errorf("Expected the ninth request from the address to be refused")
+			}
+
+			// The requests don't count as failed logins
+			if status := checkLogin(t, guard, lockout.AccountKey(1, "user@example.com"), "203.0.113.7"); !status.Allowed() {
+				t.Errorf("Expected logins to be unaffected, got %+v", status)
+			}
+
+			clock.Advance(16 * time.Minute)
+			if status := throttle("user@example.com", "192.0.2.1"); !status.Allowed() {
+				t.Errorf("Expected requests to be allowed once the lockout ended, got %+v", status)
+			}
+		})
+	}
+}
+
+func TestGuard_ReservedAttempts(t *testing.T) {
+	for name, newStore := range lockoutStores(t) {
+		t.Run(name, func(t *testing.T) {
+			clock := newTestClock()
+			guard := lockout.NewGuardWithClock(newStore(), testLockoutConfig, clock.Now)
+			ctx := context.Background()
+			account := lockout.AccountKey(1, "jane@example.com")
+
+			// An attempt in progress counts as a failure for parallel attempts
+			failLogin(t, guard, account, "")
+			if status := reserveLogin(t, guard, account, "203.0.113.7"); !status.Allowed() {
+				t.Fatalf("Expected the first attempt to be reserved, got %+v", status)
+			}
+			if status := reserveLogin(t, guard, account, "203.0.113.7"); status.Allowed() || status.RetryAfter != time.Second {
+				t.Fatalf("Expected a parallel attempt to back off, got %+v", status)
+			}
+			if err := guard.Release(ctx, account, "203.0.113.7"); err != nil {
+				t.Fatalf("Release failed: %v", err)
+			}
+			if status := reserveLogin(t, guard, account, "203.0.113.7"); !status.Allowed() {
+				t.Fatalf("Expected an attempt after the release to be reserved, got %+v", status)
+			}
+
+			// Reservations that were never released are forgotten
+			clock.Advance(2 * time.Minute)
+			if status := reserveLogin(t, guard, account, "203.0.113.7"); !status.Allowed() {
+				t.Fatalf("Expected a stale reservation to be forgotten, got %+v", status)
+			}
+			if status := checkLogin(t, guard, account, ""); status.Allowed() {
+				t.Errorf("Expected Check to count the attempt in progress, got %+v", status)
+			}
+		})
+	}
+
+	t.Run("Attempts in progress can't exceed the maximum", func(t *testing.T) {
+		cfg := testLockoutConfig
+		cfg.BaseDelay = 0
+		guard := lockout.NewGuard(lockout.NewMemoryStore(), cfg)
+		account := lockout.AccountKey(1, "jane@example.com")
+		for i := 0; i < 4; i++ {
+			failLogin(t, guard, account, "")
+		}
+		if status := reserveLogin(t, guard, account, ""); !status.Allowed() {
+			t.Fatalf("Expected the fifth attempt to be reserved, got %+v", status)
+		}
+		if status := reserveLogin(t, guard, account, ""); status.Allowed() || status.Locked {
+			t.Errorf("Expected a sixth attempt to wait for the fifth, got %+v", status)
+		}
+	})
+}
+
+func TestGuard_Disabled(t *testing.T) {
+	var guard *lockout.Guard
+	for i := 0; i < 20; i++ {
+		if failure := failLogin(t, guard, "account:1:jane@example.com", "203.0.113.7"); failure.AccountLocked || failure.IPBlocked {
+			t.Fatalf("Expected a nil guard never to lock, got %+v", failure)
+		}
+	}
+	if status := checkLogin(t, guard, "account:1:jane@example.com", "203.0.113.7"); !status.Allowed() {
+		t.Errorf("Expected a nil guard to allow every login, got %+v", status)
+	}
+
+	cfg := testLockoutConfig
+	cfg.MaxAttempts, cfg.IPMaxAttempts = 0, 0
+	guard = lockout.NewGuard(lockout.NewMemoryStore(), cfg)
+	for i := 0; i < 20; i++ {
+		if failure := failLogin(t, guard, "account:1:jane@example.com", "203.0.113.7"); failure.AccountLocked || failure.IPBlocked {
+			t.Fatalf("Expected no lockouts with max_attempts 0, got %+v", failure)
+		}
+	}
+
+	if _, err := lockout.New(config.LockoutConfig{Store: "redis"}, nil); err == nil {
+		t.Errorf("Expected an unsupported store to be rejected")
+	}
+}
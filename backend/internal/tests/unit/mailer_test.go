@@ -0,0 +1,228 @@
+package unit_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/mailer"
+)
+
+func TestOutbox_KeepsMessages(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	outbox, err := mailer.NewOutbox(dir)
+	if err != nil {
+		t.Fatalf("Failed to create outbox: %v", err)
+	}
+
+	for _, to := range []string{"first@example.com", "second@example.com", "first@example.com"} {
+		if err := outbox.Send(context.Background(), mailer.Message{To: to, Subject: "Héllo " + to, Body: "line one\nline two"}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	if got := len(outbox.Messages()); got != 3 {
+		t.Errorf("Expected 3 messages, got %d", got)
+	}
+	last, ok := outbox.Last("first@example.com")
+	if !ok || last.Subject != "Héllo first@example.com" {
+		t.Errorf("Expected the latest message to first@example.com, got %+v", last)
+	}
+	if _, ok := outbox.Last("nobody@example.com"); ok {
+		t.Errorf("Expected no message to an unknown address")
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil || len(files) != 3 {
+		t.Fatalf("Expected 3 message files, got %d (%v)", len(files), err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	if err != nil {
+		t.Fatalf("Failed to read message file: %v", err)
+	}
+	for _, want := range []string{"To: first@example.com\r\n", "Subject: =?utf-8?q?H=C3=A9llo_first@example.com?=\r\n", "\r\n\r\nline one\r\nline two"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected the message file to contain %q, got:\n%s", want, data)
+		}
+	}
+}
+
+func TestMailer_RejectsHeaderInjection(t *testing.T) {
+	outbox, _ := mailer.NewOutbox("")
+	err := outbox.Send(context.Background(), mailer.Message{To: "victim@example.com\r\nBcc: everyone@example.com", Subject: "Hi"})
+	if !errors.Is(err, mailer.ErrInvalidHeader) {
+		t.Errorf("Expected ErrInvalidHeader for a recipient with a line break, got %v", err)
+	}
+	err = outbox.Send(context.Background(), mailer.Message{To: "victim@example.com", Subject: "Hi\nBcc: everyone@example.com"})
+	if !errors.Is(err, mailer.ErrInvalidHeader) {
+		t.Errorf("Expected ErrInvalidHeader for a subject with a line break, got %v", err)
+	}
+	if len(outbox.Messages()) != 0 {
+		t.Errorf("Expected rejected messages not to be kept")
+	}
+
+	if _, err := mailer.NewSMTPMailer("localhost", 25, "", "", "App <app@example.com>\nBcc: x@example.com"); !errors.Is(err, mailer.ErrInvalidHeader) {
+		t.Errorf("Expected ErrInvalidHeader for a sender with a line break, got %v", err)
+	}
+}
+
+// blockingMailer holds every send until it is released
+type blockingMailer struct {
+	started chan struct{}
+	release chan struct{}
+	outbox  *mailer.Outbox
+}
+
+func (m *blockingMailer) Send(ctx context.Context, message mailer.Message) error {
+	m.started <- struct{}{}
+	<-m.release
+	return m.outbox.Send(ctx, message)
+}
+
+func TestQueue_SendsInTheBackground(t *testing.T) {
+	outbox, _ := mailer.NewOutbox("")
+	next := &blockingMailer{started: make(chan struct{}, 2), release: make(chan struct{}), outbox: outbox}
+	queue := mailer.NewQueue(next, 1, time.Second)
+	ctx := context.Background()
+
+	// The first message is taken by the sender, the second waits in the queue
+	for _, to := range []string{"first@example.com", "second@example.com"} {
+		if err := queue.Send(ctx, mailer.Message{To: to, Subject: "Hi"}); err != nil {
+			t.Fatalf("Send to %s failed: %v", to, err)
+		}
+		if to == "first@example.com" {
+			<-next.started
+		}
+	}
+	if err := queue.Send(ctx, mailer.Message{To: "third@example.com", Subject: "Hi"}); !errors.Is(err, mailer.ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull while the sender is busy, got %v", err)
+	}
+	if err := queue.Send(ctx, mailer.Message{To: "x@example.com", Subject: "Hi\nBcc: y@example.com"}); !errors.Is(err, mailer.ErrInvalidHeader) {
+		t.Errorf("Expected ErrInvalidHeader to be reported right away, got %v", err)
+	}
+	if len(outbox.Messages()) != 0 {
+		t.Fatalf("Expected no message to be delivered while the mailer is blocked")
+	}
+
+	close(next.release)
+	if err := queue.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if got := len(outbox.Messages()); got != 2 {
+		t.Errorf("Expected the queued messages to be delivered, got %d", got)
+	}
+
+	if err := queue.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := queue.Send(ctx, mailer.Message{To: "late@example.com", Subject: "Hi"}); !errors.Is(err, mailer.ErrQueueClosed) {
+		t.Errorf("Expected ErrQueueClosed after closing, got %v", err)
+	}
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	server := startFakeSMTPServer(t)
+
+	host, portString, _ := net.SplitHostPort(server.addr)
+	port, _ := strconv.Atoi(portString)
+	m, err := mailer.NewSMTPMailer(host, port, "", "", "RoutrApp <no-reply@example.com>")
+	if err != nil {
+		t.Fatalf("Failed to create SMTP mailer: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.Send(ctx, mailer.Message{To: "user@example.com", Subject: "Reset your password", Body: "Open the link.\n.\nThanks"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	received := <-server.received
+	if received.from != "<no-reply@example.com>" || received.to != "<user@example.com>" {
+		t.Errorf("Unexpected envelope: from %q to %q", received.from, received.to)
+	}
+	for _, want := range []string{"From: RoutrApp <no-reply@example.com>", "To: user@example.com", "Subject: Reset your password", "Open the link.\r\n..\r\nThanks"} {
+		if !strings.Contains(received.data, want) {
+			t.Errorf("Expected the message to contain %q, got:\n%s", want, received.data)
+		}
+	}
+}
+
+type fakeSMTPMessage struct {
+	from, to, data string
+}
+
+type fakeSMTPServer struct {
+	addr     string
+	received chan fakeSMTPMessage
+}
+
+// startFakeSMTPServer accepts a single SMTP session without STARTTLS or authentication
+func startFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeSMTPServer{addr: listener.Addr().String(), received: make(chan fakeSMTPMessage, 1)}
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		var message fakeSMTPMessage
+		reply("220 fake ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch command := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); {
+			case command == "EHLO" || command == "HELO":
+				reply("250 fake")
+			case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+				message.from = line[len("MAIL FROM:"):]
+				reply("250 OK")
+			case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+				message.to = line[len("RCPT TO:"):]
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					dataLine, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				message.data = data.String()
+				server.received <- message
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+	return server
+}
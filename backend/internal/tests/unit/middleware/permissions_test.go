@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tests"
+)
+
+func TestDBPermissionChecker(t *testing.T) {
+	db, err := tests.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	owner, err := tests.CreateCompleteTestUser(db, "owner@example.com", "Password123!", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	orgID := owner.Organization.ID
+
+	// A technician role whose stored permissions differ from the technician defaults
+	role, err := tests.CreateTestRole(db, orgID, models.RoleTypeTechnician)
+	if err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	if err := db.Model(role).Update("permissions", `["routes.read", "reports.*"]`).Error; err != nil {
+		t.Fatalf("Failed to set role permissions: %v", err)
+	}
+	user, err := tests.CreateTestUser(db, orgID, role.ID, "dispatcher@example.com", "Password123!", true)
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	checker := middleware.NewDBPermissionChecker(db, time.Minute)
+
+	t.Run("Stored permissions are evaluated with wildcards", func(t *testing.T) {
+		cases := []struct {
+			permission string
+			expected   bool
+		}{
+			{"routes.read", true},
+			{"reports.export", true},
+			{"routes.update_status", false}, // technician default, but not stored on the role
+			{"users.create", false},
+		}
+		for _, tc := range cases {
+			if got := checker.HasPermission("", user.ID, orgID, tc.permission); got != tc.expected {
+				t.Errorf("HasPermission(%s) = %v, want %v", tc.permission, got, tc.expected)
+			}
+		}
+	})
+
+	t.Run("Roles without stored permissions use the role defaults", func(t *testing.T) {
+		if !checker.HasPermission("", owner.User.ID, orgID, "organizations.update") {
+			t.Errorf("Expected owner defaults to grant organizations.update")
+		}
+	})
+
+	t.Run("The role name passed by the caller is ignored", func(t *testing.T) {
+		if checker.HasPermission(models.RoleTypeOwner.String(), user.ID, orgID, "users.create") {
+			t.Errorf("Expected the user's own role to be used rather than the role name argument")
+		}
+	})
+
+	t.Run("Users are resolved within the organization", func(t *testing.T) {
+		if checker.HasPermission("", user.ID, orgID+100, "routes.read") {
+			t.Errorf("Expected no permissions in another organization")
+		}
+		if checker.HasPermission("", 9999, orgID, "routes.read") {
+			t.Errorf("Expected no permissions for an unknown user")
+		}
+	})
+
+	t.Run("Role changes apply after invalidation", func(t *testing.T) {
+		if err := db.Model(role).Update("permissions", `["routes.*"]`).Error; err != nil {
+			t.Fatalf("Failed to update role permissions: %v", err)
+		}
+		if checker.HasPermission("", user.ID, orgID, "routes.create") {
+			t.Errorf("Expected the cached permissions to be used until the role is invalidated")
+		}
+
+		checker.InvalidateRole(role.ID)
+		if !checker.HasPermission("", user.ID, orgID, "routes.create") {
+			t.Errorf("Expected the updated permissions after invalidation")
+		}
+	})
+
+	t.Run("Role reassignment applies after invalidation", func(t *testing.T) {
+		if err := db.Model(&models.User{}).Where("id = ?", user.ID).Update("role_id", owner.Role.ID).Error; err != nil {
+			t.Fatalf("Failed to reassign role: %v", err)
+		}
+		if checker.HasPermission("", user.ID, orgID, "users.create") {
+			t.Errorf("Expected the cached role assignment to be used until the user is invalidated")
+		}
+
+		checker.InvalidateUser(user.ID)
+		if !checker.HasPermission("", user.ID, orgID, "users.create") {
+			t.Errorf("Expected the owner role after invalidation")
+		}
+	})
+
+	t.Run("Inactive roles grant nothing", func(t *testing.T) {
+		if err := db.Model(owner.Role).Update("active", false).Error; err != nil {
+			t.Fatalf("Failed to deactivate role: %v", err)
+		}
+		checker.InvalidateRole(owner.Role.ID)
+		if checker.HasPermission("", owner.User.ID, orgID, "organizations.read") {
+			t.Errorf("Expected an inactive role to grant no permissions")
+		}
+	})
+
+	t.Run("Lookups expire after the TTL", func(t *testing.T) {
+		if err := db.Model(&models.User{}).Where("id = ?", user.ID).Update("role_id", role.ID).Error; err != nil {
+			t.Fatalf("Failed to reassign role: %v", err)
+		}
+		shortLived := middleware.NewDBPermissionChecker(db, 10*time.Millisecond)
+		if !shortLived.HasPermission("", user.ID, orgID, "routes.create") {
+			t.Fatalf("Expected routes.create before the change")
+		}
+		if err := db.Model(role).Update("permissions", `["reports.*"]`).Error; err != nil {
+			t.Fatalf("Failed to update role permissions: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if shortLived.HasPermission("", user.ID, orgID, "routes.create") {
+			t.Errorf("Expected the change to apply once the cache expired")
+		}
+	})
+}
+
+func TestPermissionCheckerMiddleware(t *testing.T) {
+	db, err := tests.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	owner, err := tests.CreateCompleteTestUser(db, "owner@example.com", "Password123!", models.RoleTypeOwner, true)
+	if err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	role, err := tests.CreateTestRole(db, owner.Organization.ID, models.RoleTypeTechnician)
+	if err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	if err := db.Model(role).Update("permissions", `["users.read"]`).Error; err != nil {
+		t.Fatalf("Failed to set role permissions: %v", err)
+	}
+	user, err := tests.CreateTestUser(db, owner.Organization.ID, role.ID, "auditor@example.com", "Password123!", true)
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	newRouter := func(checker middleware.PermissionChecker) *gin.Engine {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		if checker != nil {
+			router.Use(middleware.PermissionCheckerMiddleware(checker))
+		}
+		router.Use(func(c *gin.Context) {
+			c.Set("user_id", user.ID)
+			c.Set("organization_id", owner.Organization.ID)
+			c.Set("user_role", "auditor") // not one of the built-in role names
+			c.Next()
+		})
+		ok := func(c *gin.Context) { c.JSON(200, gin.H{"message": "success"}) }
+		router.GET("/users", middleware.RequirePermission("users.read"), ok)
+		router.GET("/routes", middleware.RequireAnyPermission("routes.read", "routes.update_status"), ok)
+		router.GET("/helper", func(c *gin.Context) {
+			c.JSON(200, gin.H{"has_permission": middleware.HasPermission(c, "users.read")})
+		})
+		return router
+	}
+
+	serve := func(router *gin.Engine, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	router := newRouter(middleware.NewDBPermissionChecker(db, time.Minute))
+	if w := serve(router, "/users"); w.Code != 200 {
+		t.Errorf("Expected the stored users.read permission to allow access, got %d", w.Code)
+	}
+	if w := serve(router, "/routes"); w.Code != 403 {
+		t.Errorf("Expected 403 without any route permission, got %d", w.Code)
+	}
+	if w := serve(router, "/helper"); w.Body.String() != `{"has_permission":true}` {
+		t.Errorf("Expected HasPermission to use the installed checker, got %s", w.Body.String())
+	}
+
+	// Without an installed checker only the built-in role names are understood
+	router = newRouter(nil)
+	if w := serve(router, "/users"); w.Code != 403 {
+		t.Errorf("Expected 403 for an unknown role name without a database checker, got %d", w.Code)
+	}
+}
@@ -14,7 +14,7 @@ type MockPermissionChecker struct {
 	permissions map[string]bool
 }
 
-func (mpc *MockPermissionChecker) HasPermission(userRole, userID, organizationID uint, permission string) bool {
+func (mpc *MockPermissionChecker) HasPermission(userRole string, userID, organizationID uint, permission string) bool {
 	return mpc.permissions[permission]
 }
 
@@ -78,34 +78,34 @@ func TestDefaultPermissionChecker(t *testing.T) {
 	
 	tests := []struct {
 		name       string
-		roleID     uint
+		role       string
 		permission string
 		expected   bool
 	}{
 		// Owner permissions
-		{"owner org read", 1, "organizations.read", true},
-		{"owner org create", 1, "organizations.create", true},
-		{"owner users manage", 1, "users.manage", true},
-		{"owner routes read", 1, "routes.read", true},
-		{"owner routes create", 1, "routes.create", true},
+		{"owner org read", "owner", "organizations.read", true},
+		{"owner org create", "owner", "organizations.create", true},
+		{"owner users manage", "owner", "users.manage", true},
+		{"owner routes read", "owner", "routes.read", true},
+		{"owner routes create", "owner", "routes.create", true},
 		
 		// Technician permissions
-		{"tech routes read", 2, "routes.read", true},
-		{"tech routes update status", 2, "routes.update_status", true},
-		{"tech read own", 2, "technicians.read_own", true},
-		{"tech update own", 2, "technicians.update_own", true},
-		{"tech no users create", 2, "users.create", false},
-		{"tech no org read", 2, "organizations.read", false},
+		{"tech routes read", "technician", "routes.read", true},
+		{"tech routes update status", "technician", "routes.update_status", true},
+		{"tech read own", "technician", "technicians.read_own", true},
+		{"tech update own", "technician", "technicians.update_own", true},
+		{"tech no users create", "technician", "users.create", false},
+		{"tech no org read", "technician", "organizations.read", false},
 		
 		// Invalid role
-		{"invalid role", 999, "any.permission", false},
+		{"invalid role", "dispatcher", "routes.read", false},
 	}
 	
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := checker.HasPermission(tt.roleID, 123, 456, tt.permission)
+			result := checker.HasPermission(tt.role, 123, 456, tt.permission)
 			if result != tt.expected {
-				t.Errorf("HasPermission(%d, 123, 456, %s) = %v, want %v", tt.roleID, tt.permission, result, tt.expected)
+				t.Errorf("HasPermission(%s, 123, 456, %s) = %v, want %v", tt.role, tt.permission, result, tt.expected)
 			}
 		})
 	}
@@ -335,7 +335,7 @@ func TestNoAuthContext(t *testing.T) {
 func BenchmarkPermissionChecker(b *testing.B) {
 	checker := &middleware.DefaultPermissionChecker{}
 	for i := 0; i < b.N; i++ {
-		checker.HasPermission(1, 123, 456, "routes.read")
+		checker.HasPermission("owner", 123, 456, "routes.read")
 	}
 }
 
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/revocation"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/utils/auth"
+)
+
+func TestAuthMiddleware_RevocationStoreOutage(t *testing.T) {
+	db, err := tests.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	list := revocation.NewList(revocation.NewDatabaseStore(db))
+
+	t.Setenv("JWT_SECRET", "test-secret-key")
+	jwtService := auth.NewJWTService("test-secret-key")
+	accessToken, err := jwtService.GenerateAccessToken(1, 1, "owner@example.com", "owner")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RevocationMiddleware(list))
+	router.GET("/required", middleware.AuthMiddlewareWithJWT(jwtService), func(c *gin.Context) {
+		c.JSON(200, gin.H{"user_id": c.GetUint("user_id")})
+	})
+	router.GET("/optional", middleware.OptionalAuthMiddleware(), func(c *gin.Context) {
+		_, authenticated := middleware.GetUserContext(c)
+		c.JSON(200, gin.H{"authenticated": authenticated})
+	})
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve("/required"); w.Code != 200 {
+		t.Fatalf("Expected 200 while the store is available, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve("/optional"); w.Body.String() != `{"authenticated":true}` {
+		t.Fatalf("Expected the optional middleware to authenticate the token, got %d: %s", w.Code, w.Body.String())
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to get database handle: %v", err)
+	}
+	sqlDB.Close()
+
+	if w := serve("/required"); !tests.AssertResponseError(w, 503, "REVOCATION_UNAVAILABLE") {
+		t.Errorf("Expected REVOCATION_UNAVAILABLE during an outage, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve("/optional"); w.Code != 200 || w.Body.String() != `{"authenticated":false}` {
+		t.Errorf("Expected the optional middleware to ignore the token during an outage, got %d: %s", w.Code, w.Body.String())
+	}
+}
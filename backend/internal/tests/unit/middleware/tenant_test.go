@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"routrapp-api/internal/middleware"
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories/postgres"
+	"routrapp-api/internal/tenant"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/utils/auth"
+)
+
+func TestTenantResolver_Subdomain(t *testing.T) {
+	cases := []struct {
+		baseDomain string
+		host       string
+		expected   string
+	}{
+		{"routrapp.com", "acme.routrapp.com", "acme"},
+		{"routrapp.com", "ACME.Routrapp.com:8443", "acme"},
+		{"routrapp.com", "routrapp.com", ""},
+		{"routrapp.com", "www.acme.routrapp.com", ""},
+		{"routrapp.com", "acme.example.com", ""},
+		{"routrapp.com", "acmeroutrapp.com", ""},
+		{"app.routrapp.co.uk", "acme.app.routrapp.co.uk", "acme"},
+		{"app.routrapp.co.uk", "app.routrapp.co.uk", ""},
+		{"localhost", "acme.localhost:8080", "acme"},
+		{"localhost", "localhost:8080", ""},
+		{"", "acme.example.com", "acme"},
+		{"", "localhost", ""},
+	}
+	for _, tc := range cases {
+		resolver := middleware.NewTenantResolver(nil, tc.baseDomain, time.Minute)
+		if got := resolver.Subdomain(tc.host); got != tc.expected {
+			t.Errorf("Subdomain(%q) with base domain %q = %q, want %q", tc.host, tc.baseDomain, got, tc.expected)
+		}
+	}
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	db, err := tests.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	acme, err := tests.CreateTestOrganization(db)
+	if err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	dormant := models.Organization{Name: "Dormant", SubDomain: "dormant", ContactEmail: "dormant@example.com", Active: true}
+	if err := db.Create(&dormant).Error; err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	if err := db.Model(&dormant).Update("active", false).Error; err != nil {
+		t.Fatalf("Failed to deactivate organization: %v", err)
+	}
+
+	jwtService := auth.NewJWTService("test-secret-key")
+	token := func(organizationID uint) string {
+		accessToken, err := jwtService.GenerateAccessToken(1, organizationID, "owner@example.com", "owner")
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+		return accessToken
+	}
+
+	newRouter := func(resolver *middleware.TenantResolver) *gin.Engine {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(middleware.TenantMiddleware(resolver, jwtService))
+		handler := func(c *gin.Context) {
+			tenantCtx, _ := middleware.GetTenantContext(c)
+			scopedID, _ := tenant.OrganizationFromContext(c.Request.Context())
+			c.JSON(200, gin.H{"organization_id": tenantCtx.OrganizationID, "scoped_id": scopedID})
+		}
+		router.GET("/api/v1/routes", handler)
+		router.POST("/api/v1/auth/login", handler)
+		return router
+	}
+
+	serve := func(router *gin.Engine, method, host, accessToken string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/routes", nil)
+		if method == "POST" {
+			req = httptest.NewRequest(method, "/api/v1/auth/login", nil)
+		}
+		req.Host = host
+		if accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	router := newRouter(middleware.NewTenantResolver(postgres.NewOrganizationRepository(db), "routrapp.com", time.Minute))
+
+	t.Run("Subdomains resolve to their organization", func(t *testing.T) {
+		w := serve(router, "GET", "test.routrapp.com", "")
+		var body struct {
+			OrganizationID uint `json:"organization_id"`
+			ScopedID       uint `json:"scoped_id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != 200 {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if body.OrganizationID != acme.ID || body.ScopedID != acme.ID {
+			t.Errorf("Expected organization %d in both contexts, got %+v", acme.ID, body)
+		}
+	})
+
+	t.Run("Unknown and inactive organizations are rejected", func(t *testing.T) {
+		if w := serve(router, "GET", "missing.routrapp.com", ""); !tests.AssertResponseError(w, 404, "TENANT_NOT_FOUND") {
+			t.Errorf("Expected TENANT_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+		}
+		if w := serve(router, "GET", "dormant.routrapp.com", ""); !tests.AssertResponseError(w, 403, "TENANT_INACTIVE") {
+			t.Errorf("Expected TENANT_INACTIVE, got %d: %s", w.Code, w.Body.String())
+		}
+		if w := serve(router, "POST", "dormant.routrapp.com", ""); !tests.AssertResponseError(w, 403, "TENANT_INACTIVE") {
+			t.Errorf("Expected public endpoints to be rejected too, got %d", w.Code)
+		}
+	})
+
+	t.Run("Tokens must match the subdomain's organization", func(t *testing.T) {
+		if w := serve(router, "GET", "test.routrapp.com", token(acme.ID)); w.Code != 200 {
+			t.Errorf("Expected 200 for a matching token, got %d: %s", w.Code, w.Body.String())
+		}
+		if w := serve(router, "GET", "test.routrapp.com", token(dormant.ID)); !tests.AssertResponseError(w, 403, "TENANT_MISMATCH") {
+			t.Errorf("Expected TENANT_MISMATCH, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Without a subdomain the token's organization is used", func(t *testing.T) {
+		if w := serve(router, "GET", "routrapp.com", token(acme.ID)); w.Code != 200 {
+			t.Errorf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if w := serve(router, "GET", "routrapp.com", ""); !tests.AssertResponseError(w, 400, "TENANT_REQUIRED") {
+			t.Errorf("Expected TENANT_REQUIRED, got %d: %s", w.Code, w.Body.String())
+		}
+		if w := serve(router, "POST", "routrapp.com", ""); w.Code != 200 {
+			t.Errorf("Expected public endpoints without a tenant, got %d", w.Code)
+		}
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/routes?organization_id=%d", acme.ID), nil)
+		req.Host = "routrapp.com"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if !tests.AssertResponseError(w, 400, "TENANT_REQUIRED") {
+			t.Errorf("Expected the organization_id query parameter to be ignored, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Lookups are cached until invalidated or expired", func(t *testing.T) {
+		resolver := middleware.NewTenantResolver(postgres.NewOrganizationRepository(db), "routrapp.com", 50*time.Millisecond)
+		cached := newRouter(resolver)
+		if w := serve(cached, "GET", "test.routrapp.com", ""); w.Code != 200 {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+
+		if err := db.Model(acme).Update("active", false).Error; err != nil {
+			t.Fatalf("Failed to deactivate organization: %v", err)
+		}
+		if w := serve(cached, "GET", "test.routrapp.com", ""); w.Code != 200 {
+			t.Errorf("Expected the cached lookup to be used, got %d", w.Code)
+		}
+
+		resolver.Invalidate("TEST")
+		if w := serve(cached, "GET", "test.routrapp.com", ""); !tests.AssertResponseError(w, 403, "TENANT_INACTIVE") {
+			t.Errorf("Expected TENANT_INACTIVE after invalidation, got %d", w.Code)
+		}
+
+		if err := db.Model(acme).Update("active", true).Error; err != nil {
+			t.Fatalf("Failed to reactivate organization: %v", err)
+		}
+		time.Sleep(60 * time.Millisecond)
+		if w := serve(cached, "GET", "test.routrapp.com", ""); w.Code != 200 {
+			t.Errorf("Expected the reactivation to apply once the cache expired, got %d", w.Code)
+		}
+	})
+
+	t.Run("Handlers invalidate the lookups of organizations they change", func(t *testing.T) {
+		resolver := middleware.NewTenantResolver(postgres.NewOrganizationRepository(db), "routrapp.com", time.Hour)
+		cached := newRouter(resolver)
+		cached.POST("/api/v1/auth/register", func(c *gin.Context) {
+			organization := models.Organization{Name: "Newco", SubDomain: "newco", ContactEmail: "newco@example.com", Active: true}
+			if err := db.Create(&organization).Error; err != nil {
+				t.Fatalf("Failed to create organization: %v", err)
+			}
+			middleware.InvalidateTenant(c, organization.SubDomain)
+		})
+
+		if w := serve(cached, "GET", "newco.routrapp.com", ""); !tests.AssertResponseError(w, 404, "TENANT_NOT_FOUND") {
+			t.Fatalf("Expected TENANT_NOT_FOUND before registration, got %d", w.Code)
+		}
+		req := httptest.NewRequest("POST", "/api/v1/auth/register", nil)
+		req.Host = "routrapp.com"
+		cached.ServeHTTP(httptest.NewRecorder(), req)
+		if w := serve(cached, "GET", "newco.routrapp.com", ""); w.Code != 200 {
+			t.Errorf("Expected the registered organization to be served at once, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package unit_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"routrapp-api/internal/repositories/postgres"
+)
+
+// writeMigration writes the up and down files of a migration
+func writeMigration(t *testing.T, dir, name, up, down string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name+".up.sql"), []byte(up), 0644); err != nil {
+		t.Fatalf("Failed to write migration: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".down.sql"), []byte(down), 0644); err != nil {
+		t.Fatalf("Failed to write migration: %v", err)
+	}
+}
+
+// openMigrationTestDB opens an empty in-memory database
+func openMigrationTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	// Every connection to an in-memory database is a database of its own
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to get database connection: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	return db
+}
+
+func TestMigrationRunner(t *testing.T) {
+	db := openMigrationTestDB(t)
+	dir := t.TempDir()
+	writeMigration(t, dir, "001_initial",
+		`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP, description TEXT);
+CREATE TABLE organizations (id INTEGER PRIMARY KEY, name TEXT);
+INSERT INTO schema_migrations (version, description) VALUES (1, 'Initial schema') ON CONFLICT (version) DO NOTHING;`,
+		`DELETE FROM schema_migrations WHERE version = 1;
+DROP TABLE IF EXISTS organizations;
+DROP TABLE IF EXISTS schema_migrations;`)
+	writeMigration(t, dir, "002_add_users",
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);`,
+		`DROP TABLE IF EXISTS users;`)
+	writeMigration(t, dir, "003_add_routes",
+		`CREATE TABLE routes (id INTEGER PRIMARY KEY, name TEXT);`,
+		`DROP TABLE IF EXISTS routes;`)
+
+	ctx := context.Background()
+	runner := postgres.NewMigrationRunner(db, dir)
+
+	versions := func(steps []postgres.MigrationStep) []int {
+		var result []int
+		for _, step := range steps {
+			result = append(result, step.Version)
+		}
+		return result
+	}
+	equal := func(a, b []int) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	t.Run("Dry runs plan without writing", func(t *testing.T) {
+		runner.SetDryRun(true)
+		defer runner.SetDryRun(false)
+
+		steps, err := runner.Up(ctx)
+		if err != nil {
+			t.Fatalf("Failed to plan migrations: %v", err)
+		}
+		if !equal(versions(steps), []int{1, 2, 3}) {
+			t.Errorf("Expected all migrations to be planned, got %v", versions(steps))
+		}
+		if db.Migrator().HasTable("schema_migrations") || db.Migrator().HasTable("organizations") {
+			t.Errorf("Expected a dry run not to touch the database")
+		}
+	})
+
+	t.Run("Up applies pending migrations and records them", func(t *testing.T) {
+		steps, err := runner.Up(ctx)
+		if err != nil {
+			t.Fatalf("Failed to migrate: %v", err)
+		}
+		if !equal(versions(steps), []int{1, 2, 3}) {
+			t.Errorf("Expected all migrations to be applied, got %v", versions(steps))
+		}
+		if !db.Migrator().HasTable("routes") {
+			t.Errorf("Expected the routes table to exist")
+		}
+
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get status: %v", err)
+		}
+		for _, status := range statuses {
+			if !status.Applied || status.AppliedAt == nil || status.Modified {
+				t.Errorf("Expected migration %d to be applied and unmodified, got %+v", status.Version, status)
+			}
+		}
+
+		records, err := postgres.NewPostgresMigrationTracker(db).GetAppliedMigrations()
+		if err != nil || len(records) != 3 {
+			t.Fatalf("Expected three records, got %+v (%v)", records, err)
+		}
+		if records[0].Description != "Initial schema" || records[0].Checksum != statuses[0].Checksum() {
+			t.Errorf("Expected the migration's own description and the checksum, got %+v", records[0])
+		}
+
+		if steps, err := runner.Up(ctx); err != nil || len(steps) != 0 {
+			t.Errorf("Expected nothing left to apply, got %v (%v)", versions(steps), err)
+		}
+	})
+
+	t.Run("Down rolls back the most recent migrations", func(t *testing.T) {
+		steps, err := runner.Down(ctx, 2)
+		if err != nil {
+			t.Fatalf("Failed to roll back: %v", err)
+		}
+		if !equal(versions(steps), []int{3, 2}) {
+			t.Errorf("Expected migrations 3 and 2 to be rolled back, got %v", versions(steps))
+		}
+		if db.Migrator().HasTable("users") {
+			t.Errorf("Expected the users table to be dropped")
+		}
+		version, err := postgres.NewPostgresMigrationTracker(db).GetCurrentSchemaVersion()
+		if err != nil || version != 1 {
+			t.Errorf("Expected schema version 1, got %d (%v)", version, err)
+		}
+	})
+
+	t.Run("To migrates in either direction", func(t *testing.T) {
+		steps, err := runner.To(ctx, 2)
+		if err != nil || !equal(versions(steps), []int{2}) {
+			t.Fatalf("Expected migration 2 to be applied, got %v (%v)", versions(steps), err)
+		}
+
+		steps, err = runner.To(ctx, 0)
+		if err != nil || !equal(versions(steps), []int{2, 1}) {
+			t.Fatalf("Expected everything to be rolled back, got %v (%v)", versions(steps), err)
+		}
+		if db.Migrator().HasTable("schema_migrations") {
+			t.Errorf("Expected the initial migration's rollback to drop the tracking table")
+		}
+
+		if _, err := runner.To(ctx, 9); !errors.Is(err, postgres.ErrMigrationNotFound) {
+			t.Errorf("Expected ErrMigrationNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Edited migrations are detected", func(t *testing.T) {
+		if _, err := runner.Up(ctx); err != nil {
+			t.Fatalf("Failed to migrate: %v", err)
+		}
+		writeMigration(t, dir, "002_add_users",
+			`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT, name TEXT);`,
+			`DROP TABLE IF EXISTS users;`)
+		writeMigration(t, dir, "004_add_stops",
+			`CREATE TABLE route_stops (id INTEGER PRIMARY KEY);`,
+			`DROP TABLE IF EXISTS route_stops;`)
+
+		if err := runner.Verify(ctx); !errors.Is(err, postgres.ErrChecksumMismatch) {
+			t.Errorf("Expected ErrChecksumMismatch, got %v", err)
+		}
+		if _, err := runner.Up(ctx); !errors.Is(err, postgres.ErrChecksumMismatch) {
+			t.Errorf("Expected migrating to refuse, got %v", err)
+		}
+		if db.Migrator().HasTable("route_stops") {
+			t.Errorf("Expected no migration to run")
+		}
+	})
+
+	t.Run("Baselines record existing schemas without running migrations", func(t *testing.T) {
+		db := openMigrationTestDB(t)
+		existing := t.TempDir()
+		writeMigration(t, existing, "001_initial", `CREATE TABLE organizations (id INTEGER PRIMARY KEY);`, `DROP TABLE organizations;`)
+		writeMigration(t, existing, "002_add_users", `CREATE TABLE users (id INTEGER PRIMARY KEY);`, `DROP TABLE users;`)
+		if err := db.Exec(`CREATE TABLE organizations (id INTEGER PRIMARY KEY)`).Error; err != nil {
+			t.Fatalf("Failed to create table: %v", err)
+		}
+
+		runner := postgres.NewMigrationRunner(db, existing)
+		steps, err := runner.Baseline(ctx, 1)
+		if err != nil || !equal(versions(steps), []int{1}) {
+			t.Fatalf("Expected migration 1 to be baselined, got %v (%v)", versions(steps), err)
+		}
+		steps, err = runner.Up(ctx)
+		if err != nil || !equal(versions(steps), []int{2}) {
+			t.Errorf("Expected only migration 2 to run, got %v (%v)", versions(steps), err)
+		}
+	})
+
+	t.Run("Failed migrations are rolled back", func(t *testing.T) {
+		db := openMigrationTestDB(t)
+		failing := t.TempDir()
+		writeMigration(t, failing, "001_broken",
+			`CREATE TABLE broken (id INTEGER PRIMARY KEY); INSERT INTO missing_table VALUES (1);`,
+			`DROP TABLE IF EXISTS broken;`)
+		if _, err := postgres.NewMigrationRunner(db, failing).Up(ctx); err == nil {
+			t.Fatalf("Expected the migration to fail")
+		}
+		if db.Migrator().HasTable("broken") {
+			t.Errorf("Expected the failed migration's changes to be rolled back")
+		}
+	})
+}
@@ -0,0 +1,330 @@
+package unit_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/optimizer"
+)
+
+func TestHaversineDistance(t *testing.T) {
+	// New York to Los Angeles is roughly 3936 km
+	distance := optimizer.HaversineDistance(40.7128, -74.0060, 34.0522, -118.2437)
+	if math.Abs(distance-3936) > 10 {
+		t.Errorf("HaversineDistance() = %.1f, want ~3936", distance)
+	}
+
+	if d := optimizer.HaversineDistance(10, 10, 10, 10); d != 0 {
+		t.Errorf("HaversineDistance() for identical points = %v, want 0", d)
+	}
+}
+
+// lineStops returns stops along a line of longitude in a shuffled order
+func lineStops() []optimizer.Stop {
+	return []optimizer.Stop{
+		{ID: 1, Lat: 40.0, Lng: -74.00},
+		{ID: 2, Lat: 40.0, Lng: -73.60},
+		{ID: 3, Lat: 40.0, Lng: -73.90},
+		{ID: 4, Lat: 40.0, Lng: -73.70},
+		{ID: 5, Lat: 40.0, Lng: -73.80},
+	}
+}
+
+func stopIDs(stops []optimizer.Stop) []uint {
+	ids := make([]uint, 0, len(stops))
+	for _, stop := range stops {
+		ids = append(ids, stop.ID)
+	}
+	return ids
+}
+
+func TestOptimize_OrdersCollinearStops(t *testing.T) {
+	opts := optimizer.DefaultOptions()
+	opts.PinFirst = true
+
+	result, err := optimizer.Optimize(lineStops(), opts)
+	if err != nil {
+		t.Fatalf("Optimize() error = %v", err)
+	}
+
+	want := []uint{1, 3, 5, 4, 2}
+	got := stopIDs(result.Stops)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Optimize() order = %v, want %v", got, want)
+		}
+	}
+
+	if result.TotalDistanceKm >= optimizer.RouteDistance(lineStops()) {
+		t.Errorf("Expected optimized distance %.2f to beat original %.2f", result.TotalDistanceKm, optimizer.RouteDistance(lineStops()))
+	}
+}
+
+func TestOptimize_PinsDepots(t *testing.T) {
+	stops := lineStops()
+	opts := optimizer.DefaultOptions()
+	opts.PinFirst = true
+	opts.PinLast = true
+
+	result, err := optimizer.Optimize(stops, opts)
+	if err != nil {
+		t.Fatalf("Optimize() error = %v", err)
+	}
+
+	ids := stopIDs(result.Stops)
+	if ids[0] != 1 || ids[len(ids)-1] != 5 {
+		t.Errorf("Expected first and last stops pinned, got %v", ids)
+	}
+	if len(ids) != len(stops) {
+		t.Errorf("Expected %d stops, got %d", len(stops), len(ids))
+	}
+}
+
+func TestOptimize_KeepsFixedStops(t *testing.T) {
+	opts := optimizer.DefaultOptions()
+	opts.FixedStops = 2
+
+	result, err := optimizer.Optimize(lineStops(), opts)
+	if err != nil {
+		t.Fatalf("Optimize() error = %v", err)
+	}
+
+	// The remaining stops continue from the last fixed one
+	want := []uint{1, 2, 4, 5, 3}
+	got := stopIDs(result.Stops)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Optimize() order = %v, want %v", got, want)
+		}
+	}
+
+	opts.FixedStops = len(lineStops())
+	opts.PinLast = true
+	result, err = optimizer.Optimize(lineStops(), opts)
+	if err != nil {
+		t.Fatalf("Optimize() error = %v", err)
+	}
+	got = stopIDs(result.Stops)
+	for i, stop := range lineStops() {
+		if got[i] != stop.ID {
+			t.Fatalf("Expected fully fixed stops to keep their order, got %v", got)
+		}
+	}
+}
+
+func TestOptimize_ComputesDuration(t *testing.T) {
+	stops := []optimizer.Stop{
+		{ID: 1, Lat: 0, Lng: 0, ServiceMinutes: 10},
+		{ID: 2, Lat: 0, Lng: 0.36, ServiceMinutes: 20},
+	}
+	opts := optimizer.DefaultOptions()
+	opts.AverageSpeedKmh = 40
+
+	result, err := optimizer.Optimize(stops, opts)
+	if err != nil {
+		t.Fatalf("Optimize() error = %v", err)
+	}
+
+	// ~40 km at 40 km/h is one hour of driving plus 30 minutes of service time
+	wantSeconds := 3600 + 30*60
+	if diff := result.TotalDurationSeconds - wantSeconds; diff < -60 || diff > 60 {
+		t.Errorf("TotalDurationSeconds = %d, want ~%d", result.TotalDurationSeconds, wantSeconds)
+	}
+}
+
+func TestOptimize_EdgeCases(t *testing.T) {
+	result, err := optimizer.Optimize(nil, optimizer.DefaultOptions())
+	if err != nil || len(result.Stops) != 0 {
+		t.Errorf("Optimize(nil) = %+v, %v; want empty result", result, err)
+	}
+
+	result, err = optimizer.Optimize([]optimizer.Stop{{ID: 7, Lat: 1, Lng: 1}}, optimizer.DefaultOptions())
+	if err != nil || len(result.Stops) != 1 || result.TotalDistanceKm != 0 {
+		t.Errorf("Optimize(single) = %+v, %v; want single stop with zero distance", result, err)
+	}
+
+	opts := optimizer.DefaultOptions()
+	opts.AverageSpeedKmh = -1
+	if _, err := optimizer.Optimize(lineStops(), opts); err != optimizer.ErrInvalidSpeed {
+		t.Errorf("Optimize() with negative speed error = %v, want ErrInvalidSpeed", err)
+	}
+}
+
+func TestOptimize_LargeRouteImproves(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	stops := make([]optimizer.Stop, 80)
+	for i := range stops {
+		stops[i] = optimizer.Stop{
+			ID:  uint(i + 1),
+			Lat: 40.6 + rng.Float64()*0.3,
+			Lng: -74.1 + rng.Float64()*0.3,
+		}
+	}
+
+	start := time.Now()
+	result, err := optimizer.Optimize(stops, optimizer.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Optimize() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Optimize() took %v for 80 stops", elapsed)
+	}
+
+	seen := make(map[uint]bool)
+	for _, stop := range result.Stops {
+		if seen[stop.ID] {
+			t.Fatalf("Stop %d visited twice", stop.ID)
+		}
+		seen[stop.ID] = true
+	}
+	if len(seen) != len(stops) {
+		t.Fatalf("Expected %d stops, got %d", len(stops), len(seen))
+	}
+
+	if result.TotalDistanceKm >= optimizer.RouteDistance(stops)/2 {
+		t.Errorf("Expected optimized distance %.2f to be well under random order %.2f", result.TotalDistanceKm, optimizer.RouteDistance(stops))
+	}
+}
+
+func timeAt(base time.Time, minutes int) *time.Time {
+	t := base.Add(time.Duration(minutes) * time.Minute)
+	return &t
+}
+
+// serviceStops returns lineStops with ten minutes of work at every stop, so the
+// visiting order changes when the far end of the line is reached
+func serviceStops() []optimizer.Stop {
+	stops := lineStops()
+	for i := range stops {
+		stops[i].ServiceMinutes = 10
+	}
+	return stops
+}
+
+func TestOptimize_HardTimeWindowsOverrideDistance(t *testing.T) {
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	stops := serviceStops()
+	// The far end of the line (~51 minutes away) must be served before the stops in between
+	stops[1].WindowEnd = timeAt(start, 65)
+
+	opts := optimizer.DefaultOptions()
+	opts.PinFirst = true
+	opts.StartTime = start
+	opts.TimeWindows = optimizer.TimeWindowsHard
+
+	result, err := optimizer.Optimize(stops, opts)
+	if err != nil {
+		t.Fatalf("Optimize() error = %v", err)
+	}
+	if !result.Feasible() {
+		t.Fatalf("Expected a feasible sequence, late stops: %v", result.LateStops)
+	}
+	if ids := stopIDs(result.Stops); ids[1] != 2 {
+		t.Errorf("Expected stop 2 to be visited right after the depot, got %v", ids)
+	}
+	if len(result.Schedule) != len(stops) || !result.Schedule[0].Arrival.Equal(start) {
+		t.Errorf("Expected schedule starting at %v, got %+v", start, result.Schedule)
+	}
+}
+
+func TestOptimize_WaitsForWindowToOpen(t *testing.T) {
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	stops := []optimizer.Stop{
+		{ID: 1, Lat: 40.0, Lng: -74.00, ServiceMinutes: 5},
+		{ID: 2, Lat: 40.0, Lng: -73.99, ServiceMinutes: 10, WindowStart: timeAt(start, 60)},
+	}
+	opts := optimizer.DefaultOptions()
+	opts.StartTime = start
+
+	result, err := optimizer.Evaluate(stops, opts)
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+
+	second := result.Schedule[1]
+	if !second.ServiceStart.Equal(*stops[1].WindowStart) || second.WaitSeconds <= 0 {
+		t.Errorf("Expected service to wait for the window, got %+v", second)
+	}
+	if want := 70 * 60; result.TotalDurationSeconds != want {
+		t.Errorf("TotalDurationSeconds = %d, want %d", result.TotalDurationSeconds, want)
+	}
+}
+
+func TestOptimize_ReportsLateStops(t *testing.T) {
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	stops := lineStops()
+	// Both ends of the line close within minutes of each other; one of them must be late
+	stops[1].WindowEnd = timeAt(start, 10)
+	stops[0].WindowEnd = timeAt(start, 10)
+	stops[0].ServiceMinutes = 30
+
+	for _, mode := range []optimizer.TimeWindowMode{optimizer.TimeWindowsHard, optimizer.TimeWindowsSoft} {
+		opts := optimizer.DefaultOptions()
+		opts.StartTime = start
+		opts.TimeWindows = mode
+
+		result, err := optimizer.Optimize(stops, opts)
+		if err != nil {
+			t.Fatalf("Optimize(%s) error = %v", mode, err)
+		}
+		if result.Feasible() || len(result.LateStops) != 1 {
+			t.Errorf("Optimize(%s) expected exactly one late stop, got %v", mode, result.LateStops)
+		}
+	}
+
+	opts := optimizer.DefaultOptions()
+	opts.StartTime = start
+	result, err := optimizer.Evaluate(stops, opts)
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if len(result.LateStops) != 1 || result.LateStops[0] != 2 {
+		t.Errorf("Expected stop 2 to be late in the given order, got %v", result.LateStops)
+	}
+}
+
+func TestOptimize_SoftWindowsTradeLatenessForDistance(t *testing.T) {
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	stops := serviceStops()
+	// Serving the far stop first avoids lateness at a large distance cost
+	stops[1].WindowEnd = timeAt(start, 65)
+
+	opts := optimizer.DefaultOptions()
+	opts.PinFirst = true
+	opts.StartTime = start
+	opts.TimeWindows = optimizer.TimeWindowsSoft
+	opts.LatePenaltyPerMinute = 0.01
+
+	result, err := optimizer.Optimize(stops, opts)
+	if err != nil {
+		t.Fatalf("Optimize() error = %v", err)
+	}
+	if ids := stopIDs(result.Stops); ids[len(ids)-1] != 2 {
+		t.Errorf("Expected a low penalty to keep the distance-optimal order, got %v", ids)
+	}
+
+	opts.LatePenaltyPerMinute = 100
+	result, err = optimizer.Optimize(stops, opts)
+	if err != nil {
+		t.Fatalf("Optimize() error = %v", err)
+	}
+	if !result.Feasible() {
+		t.Errorf("Expected a high penalty to avoid lateness, late stops: %v", result.LateStops)
+	}
+}
+
+func TestOptimize_TimeWindowsRequireStartTime(t *testing.T) {
+	opts := optimizer.DefaultOptions()
+	opts.TimeWindows = optimizer.TimeWindowsHard
+	if _, err := optimizer.Optimize(lineStops(), opts); err != optimizer.ErrStartTimeRequired {
+		t.Errorf("Optimize() error = %v, want ErrStartTimeRequired", err)
+	}
+
+	opts.TimeWindows = "strict"
+	opts.StartTime = time.Now()
+	if _, err := optimizer.Optimize(lineStops(), opts); err != optimizer.ErrInvalidTimeWindowMode {
+		t.Errorf("Optimize() error = %v, want ErrInvalidTimeWindowMode", err)
+	}
+}
@@ -0,0 +1,204 @@
+package unit_test
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/optimizer"
+)
+
+// clusteredStops returns stops grouped tightly around each of the given centres
+func clusteredStops(centres [][2]float64, perCluster int) []optimizer.Stop {
+	rng := rand.New(rand.NewSource(7))
+	stops := make([]optimizer.Stop, 0, len(centres)*perCluster)
+	for _, centre := range centres {
+		for i := 0; i < perCluster; i++ {
+			stops = append(stops, optimizer.Stop{
+				ID:             uint(len(stops) + 1),
+				Lat:            centre[0] + rng.Float64()*0.02,
+				Lng:            centre[1] + rng.Float64()*0.02,
+				ServiceMinutes: 15,
+			})
+		}
+	}
+	return stops
+}
+
+func TestPlanRoutes_AssignsClustersToNearestVehicles(t *testing.T) {
+	centres := [][2]float64{{40.70, -74.00}, {40.90, -73.80}}
+	stops := clusteredStops(centres, 5)
+	vehicles := []optimizer.Vehicle{
+		{ID: 10, Lat: 40.91, Lng: -73.79},
+		{ID: 20, Lat: 40.69, Lng: -74.01},
+	}
+
+	plan, err := optimizer.PlanRoutes(stops, vehicles, optimizer.DefaultPlanOptions())
+	if err != nil {
+		t.Fatalf("PlanRoutes() error = %v", err)
+	}
+
+	if len(plan.Routes) != 2 || len(plan.Unassigned) != 0 {
+		t.Fatalf("Expected 2 routes and no unassigned stops, got %+v", plan)
+	}
+	for _, stop := range plan.Routes[0].Stops {
+		if stop.ID <= 5 {
+			t.Errorf("Vehicle 10 was given stop %d from the far cluster", stop.ID)
+		}
+	}
+	for _, stop := range plan.Routes[1].Stops {
+		if stop.ID > 5 {
+			t.Errorf("Vehicle 20 was given stop %d from the far cluster", stop.ID)
+		}
+	}
+}
+
+func TestPlanRoutes_BalancesWorkload(t *testing.T) {
+	// All stops sit near one vehicle; balancing should still put the other vehicle to work
+	stops := clusteredStops([][2]float64{{40.70, -74.00}}, 12)
+	vehicles := []optimizer.Vehicle{
+		{ID: 1, Lat: 40.70, Lng: -74.00},
+		{ID: 2, Lat: 40.75, Lng: -74.05},
+	}
+
+	opts := optimizer.DefaultPlanOptions()
+	plan, err := optimizer.PlanRoutes(stops, vehicles, opts)
+	if err != nil {
+		t.Fatalf("PlanRoutes() error = %v", err)
+	}
+	first, second := len(plan.Routes[0].Stops), len(plan.Routes[1].Stops)
+	if first == 0 || second == 0 || first-second > 2 || second-first > 2 {
+		t.Errorf("Expected a balanced split, got %d and %d stops", first, second)
+	}
+
+	opts.BalanceWeight = 0
+	plan, err = optimizer.PlanRoutes(stops, vehicles, opts)
+	if err != nil {
+		t.Fatalf("PlanRoutes() error = %v", err)
+	}
+	if len(plan.Routes[0].Stops) != len(stops) {
+		t.Errorf("Expected distance-only planning to give every stop to the nearest vehicle, got %d", len(plan.Routes[0].Stops))
+	}
+}
+
+func TestPlanRoutes_RespectsStopLimit(t *testing.T) {
+	stops := clusteredStops([][2]float64{{40.70, -74.00}}, 7)
+	vehicles := []optimizer.Vehicle{{ID: 1, Lat: 40.70, Lng: -74.00}, {ID: 2, Lat: 40.71, Lng: -74.01}}
+
+	opts := optimizer.DefaultPlanOptions()
+	opts.MaxStopsPerRoute = 3
+
+	plan, err := optimizer.PlanRoutes(stops, vehicles, opts)
+	if err != nil {
+		t.Fatalf("PlanRoutes() error = %v", err)
+	}
+	for _, route := range plan.Routes {
+		if len(route.Stops) > 3 {
+			t.Errorf("Vehicle %d got %d stops, limit is 3", route.VehicleID, len(route.Stops))
+		}
+	}
+	if len(plan.Unassigned) != 1 {
+		t.Errorf("Expected 1 unassigned stop, got %v", plan.Unassigned)
+	}
+}
+
+func TestPlanRoutes_TimeWindows(t *testing.T) {
+	start := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	deadline := start.Add(70 * time.Minute)
+	stops := lineStops()
+	for i := range stops {
+		stops[i].ServiceMinutes = 15
+	}
+	stops[1].WindowEnd = &deadline // stop 2 is the farthest from the vehicle
+	vehicles := []optimizer.Vehicle{{ID: 1, Lat: 40.0, Lng: -74.05}}
+
+	opts := optimizer.DefaultPlanOptions()
+	opts.StartTime = start
+	plan, err := optimizer.PlanRoutes(stops, vehicles, opts)
+	if err != nil {
+		t.Fatalf("PlanRoutes() error = %v", err)
+	}
+	route := plan.Routes[0]
+	if len(route.Schedule) != len(stops) || len(route.LateStops) != 1 || route.LateStops[0] != 2 {
+		t.Errorf("Expected distance-only planning to reach stop 2 late, got late stops %v", route.LateStops)
+	}
+
+	opts.TimeWindows = optimizer.TimeWindowsHard
+	plan, err = optimizer.PlanRoutes(stops, vehicles, opts)
+	if err != nil {
+		t.Fatalf("PlanRoutes() error = %v", err)
+	}
+	route = plan.Routes[0]
+	if len(route.LateStops) != 0 || route.Stops[0].ID != 2 {
+		t.Errorf("Expected stop 2 first and on time, got %v late %v", stopIDs(route.Stops), route.LateStops)
+	}
+	if finish := route.Schedule[len(route.Schedule)-1].Departure; route.DurationSeconds != int(finish.Sub(start).Seconds()) {
+		t.Errorf("Expected the duration to run until the last departure, got %d", route.DurationSeconds)
+	}
+
+	opts.StartTime = time.Time{}
+	if _, err := optimizer.PlanRoutes(stops, vehicles, opts); err != optimizer.ErrStartTimeRequired {
+		t.Errorf("PlanRoutes() error = %v, want ErrStartTimeRequired", err)
+	}
+}
+
+func TestPlanRoutes_Errors(t *testing.T) {
+	if _, err := optimizer.PlanRoutes(lineStops(), nil, optimizer.DefaultPlanOptions()); err != optimizer.ErrNoVehicles {
+		t.Errorf("PlanRoutes() error = %v, want ErrNoVehicles", err)
+	}
+
+	opts := optimizer.DefaultPlanOptions()
+	opts.BalanceWeight = -1
+	vehicles := []optimizer.Vehicle{{ID: 1}}
+	if _, err := optimizer.PlanRoutes(lineStops(), vehicles, opts); err != optimizer.ErrInvalidBalanceWeight {
+		t.Errorf("PlanRoutes() error = %v, want ErrInvalidBalanceWeight", err)
+	}
+}
+
+func TestPlanRoutes_DailyVolume(t *testing.T) {
+	rng := rand.New(rand.NewSource(99))
+	stops := make([]optimizer.Stop, 80)
+	for i := range stops {
+		stops[i] = optimizer.Stop{
+			ID:             uint(i + 1),
+			Lat:            40.5 + rng.Float64()*0.4,
+			Lng:            -74.2 + rng.Float64()*0.4,
+			ServiceMinutes: 10 + rng.Intn(20),
+		}
+	}
+	vehicles := make([]optimizer.Vehicle, 10)
+	for i := range vehicles {
+		vehicles[i] = optimizer.Vehicle{ID: uint(i + 1), Lat: 40.5 + rng.Float64()*0.4, Lng: -74.2 + rng.Float64()*0.4}
+	}
+
+	start := time.Now()
+	plan, err := optimizer.PlanRoutes(stops, vehicles, optimizer.DefaultPlanOptions())
+	if err != nil {
+		t.Fatalf("PlanRoutes() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("PlanRoutes() took %v for 80 stops and 10 vehicles", elapsed)
+	}
+
+	seen := make(map[uint]bool)
+	shortest := plan.MaxDurationSeconds
+	for _, route := range plan.Routes {
+		for _, stop := range route.Stops {
+			if seen[stop.ID] {
+				t.Fatalf("Stop %d assigned twice", stop.ID)
+			}
+			seen[stop.ID] = true
+		}
+		if route.DurationSeconds < shortest {
+			shortest = route.DurationSeconds
+		}
+	}
+	if len(seen) != len(stops) {
+		t.Fatalf("Expected all %d stops assigned, got %d", len(stops), len(seen))
+	}
+
+	// The longest route should not be more than twice the shortest one
+	if plan.MaxDurationSeconds > 2*shortest {
+		t.Errorf("Unbalanced plan: longest %ds, shortest %ds", plan.MaxDurationSeconds, shortest)
+	}
+}
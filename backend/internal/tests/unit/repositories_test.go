@@ -0,0 +1,316 @@
+package unit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories"
+	"routrapp-api/internal/repositories/memory"
+	"routrapp-api/internal/repositories/postgres"
+	"routrapp-api/internal/tenant"
+	"routrapp-api/internal/tests"
+)
+
+// repositoryImplementation creates a set of repositories and the roles their users refer to
+type repositoryImplementation struct {
+	name  string
+	setup func(t *testing.T) (repositories.Repositories, func(organizationID uint, roleType models.RoleType) models.Role)
+}
+
+func TestRepositories_Contract(t *testing.T) {
+	implementations := []repositoryImplementation{
+		{
+			name: "postgres",
+			setup: func(t *testing.T) (repositories.Repositories, func(uint, models.RoleType) models.Role) {
+				db, err := tests.SetupTestDB()
+				if err != nil {
+					t.Fatalf("Failed to setup test database: %v", err)
+				}
+				for _, statement := range (models.User{}).Indexes() {
+					if err := db.Exec(statement).Error; err != nil {
+						t.Fatalf("Failed to create user index: %v", err)
+					}
+				}
+				return postgres.NewRepositories(db), func(organizationID uint, roleType models.RoleType) models.Role {
+					role, err := tests.CreateTestRole(db, organizationID, roleType)
+					if err != nil {
+						t.Fatalf("Failed to create role: %v", err)
+					}
+					return *role
+				}
+			},
+		},
+		{
+			name: "memory",
+			setup: func(t *testing.T) (repositories.Repositories, func(uint, models.RoleType) models.Role) {
+				return memory.NewRepositories(), func(organizationID uint, roleType models.RoleType) models.Role {
+					return models.Role{Name: roleType, DisplayName: string(roleType), Active: true}
+				}
+			},
+		},
+	}
+
+	for _, implementation := range implementations {
+		t.Run(implementation.name, func(t *testing.T) {
+			repos, newRole := implementation.setup(t)
+			testRepositoryContract(t, repos, newRole)
+		})
+	}
+}
+
+// testRepositoryContract checks the behaviour every repositories implementation must share
+func testRepositoryContract(t *testing.T, repos repositories.Repositories, newRole func(uint, models.RoleType) models.Role) {
+	background := context.Background()
+
+	var organizations [2]models.Organization
+	for i, subDomain := range []string{"first", "second"} {
+		organizations[i] = models.Organization{Name: subDomain, SubDomain: subDomain, ContactEmail: subDomain + "@example.com", Active: true}
+		if err := repos.Organizations.Create(background, &organizations[i]); err != nil {
+			t.Fatalf("Failed to create organization: %v", err)
+		}
+	}
+	first := tenant.WithOrganization(background, organizations[0].ID)
+	second := tenant.WithOrganization(background, organizations[1].ID)
+
+	createTechnician := func(ctx context.Context, organizationID uint, email, firstName string, status models.TechnicianStatus) models.Technician {
+		role := newRole(organizationID, models.RoleTypeTechnician)
+		user := models.User{Email: email, FirstName: firstName, LastName: "Tech", RoleID: role.ID, Role: role, Active: true}
+		if err := repos.Users.Create(ctx, &user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		technician := models.Technician{UserID: user.ID, Status: status, PhoneNumber: "555"}
+		if err := repos.Technicians.Create(ctx, &technician); err != nil {
+			t.Fatalf("Failed to create technician: %v", err)
+		}
+		return technician
+	}
+	alice := createTechnician(first, organizations[0].ID, "alice@example.com", "Alice", models.TechnicianStatusActive)
+	bob := createTechnician(first, organizations[0].ID, "bob@example.com", "Bob", models.TechnicianStatusOnBreak)
+	carol := createTechnician(second, organizations[1].ID, "carol@example.com", "Carol", models.TechnicianStatusActive)
+
+	t.Run("Contexts without a tenant fail closed", func(t *testing.T) {
+		if _, err := repos.Users.FindByID(background, alice.UserID); !errors.Is(err, tenant.ErrMissingTenant) {
+			t.Errorf("Expected ErrMissingTenant, got %v", err)
+		}
+		if _, _, err := repos.Technicians.List(background, repositories.TechnicianFilter{}, repositories.Page{Page: 1, PageSize: 10}); !errors.Is(err, tenant.ErrMissingTenant) {
+			t.Errorf("Expected ErrMissingTenant, got %v", err)
+		}
+		if _, err := repos.Organizations.FindBySubDomain(background, "first"); err != nil {
+			t.Errorf("Expected organizations not to be tenant-scoped, got %v", err)
+		}
+	})
+
+	t.Run("Records of other organizations are not found", func(t *testing.T) {
+		if _, err := repos.Technicians.FindByID(second, alice.ID); err != repositories.ErrNotFound {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		name := "Mallory"
+		if err := repos.Users.Update(second, alice.UserID, repositories.UserUpdate{FirstName: &name}); err != repositories.ErrNotFound {
+			t.Errorf("Expected ErrNotFound for an update, got %v", err)
+		}
+		if _, err := repos.Organizations.FindBySubDomain(background, "missing"); err != repositories.ErrNotFound {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if exists, err := repos.Technicians.ExistsForUser(first, carol.UserID); err != nil || exists {
+			t.Errorf("Expected no technician for another organization's user, got %v (%v)", exists, err)
+		}
+	})
+
+	t.Run("Emails are unique within an organization", func(t *testing.T) {
+		role := newRole(organizations[0].ID, models.RoleTypeTechnician)
+		duplicate := models.User{Email: "alice@example.com", FirstName: "Alice", RoleID: role.ID, Role: role, Active: true}
+		if err := repos.Users.Create(first, &duplicate); !errors.Is(err, repositories.ErrDuplicate) {
+			t.Errorf("Expected ErrDuplicate, got %v", err)
+		}
+
+		role = newRole(organizations[1].ID, models.RoleTypeTechnician)
+		elsewhere := models.User{Email: "alice@example.com", FirstName: "Alice", RoleID: role.ID, Role: role, Active: true}
+		if err := repos.Users.Create(second, &elsewhere); err != nil {
+			t.Errorf("Expected the email to be free in another organization, got %v", err)
+		}
+	})
+
+	t.Run("Technicians are returned with their user and role", func(t *testing.T) {
+		technician, err := repos.Technicians.FindByUserID(first, bob.UserID)
+		if err != nil {
+			t.Fatalf("Failed to find technician: %v", err)
+		}
+		if technician.ID != bob.ID || technician.User.Email != "bob@example.com" || technician.User.Role.Name != models.RoleTypeTechnician {
+			t.Errorf("Unexpected technician %+v", technician)
+		}
+		if technician.OrganizationID != organizations[0].ID {
+			t.Errorf("Expected the technician to be assigned to the context's organization, got %d", technician.OrganizationID)
+		}
+	})
+
+	t.Run("Technician lists are filtered, sorted and paginated", func(t *testing.T) {
+		technicians, total, err := repos.Technicians.List(first, repositories.TechnicianFilter{SortBy: "name"}, repositories.Page{Page: 1, PageSize: 1})
+		if err != nil {
+			t.Fatalf("Failed to list technicians: %v", err)
+		}
+		if total != 2 || len(technicians) != 1 || technicians[0].ID != alice.ID {
+			t.Errorf("Expected Alice on the first page of two, got %d %+v", total, technicians)
+		}
+
+		technicians, _, err = repos.Technicians.List(first, repositories.TechnicianFilter{SortBy: "name", SortDesc: true}, repositories.Page{Page: 1, PageSize: 10})
+		if err != nil || len(technicians) != 2 || technicians[0].ID != bob.ID {
+			t.Errorf("Expected Bob first when sorting by name descending, got %+v (%v)", technicians, err)
+		}
+
+		technicians, total, err = repos.Technicians.List(first, repositories.TechnicianFilter{
+			Statuses: []models.TechnicianStatus{models.TechnicianStatusOnBreak},
+			Search:   "BOB",
+		}, repositories.Page{Page: 1, PageSize: 10})
+		if err != nil || total != 1 || len(technicians) != 1 || technicians[0].ID != bob.ID {
+			t.Errorf("Expected only Bob, got %d %+v (%v)", total, technicians, err)
+		}
+	})
+
+	t.Run("Updates change only the provided fields", func(t *testing.T) {
+		status := models.TechnicianStatusOffDuty
+		if err := repos.Technicians.Update(first, alice.ID, repositories.TechnicianUpdate{Status: &status}); err != nil {
+			t.Fatalf("Failed to update technician: %v", err)
+		}
+		technician, err := repos.Technicians.FindByID(first, alice.ID)
+		if err != nil {
+			t.Fatalf("Failed to find technician: %v", err)
+		}
+		if technician.Status != status || technician.PhoneNumber != "555" {
+			t.Errorf("Unexpected technician after update %+v", technician)
+		}
+	})
+
+	t.Run("Routes and activities are scoped and ordered", func(t *testing.T) {
+		route := models.Route{
+			Name:         "Route",
+			TechnicianID: &bob.ID,
+			Status:       models.RouteStatusStarted,
+			Stops:        []models.RouteStop{{Name: "B", SequenceNum: 2}, {Name: "A", SequenceNum: 1}},
+		}
+		if err := repos.Routes.Create(first, &route); err != nil {
+			t.Fatalf("Failed to create route: %v", err)
+		}
+
+		loaded, err := repos.Routes.FindByID(first, route.ID)
+		if err != nil {
+			t.Fatalf("Failed to find route: %v", err)
+		}
+		if len(loaded.Stops) != 2 || loaded.Stops[0].Name != "A" || loaded.Technician == nil || loaded.Technician.User.Email != "bob@example.com" {
+			t.Errorf("Expected ordered stops and the technician's user, got %+v", loaded)
+		}
+		if _, err := repos.Routes.FindByID(second, route.ID); err != repositories.ErrNotFound {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+
+		count, err := repos.Routes.CountByTechnician(first, bob.ID, models.RouteStatusStarted, models.RouteStatusPaused)
+		if err != nil || count != 1 {
+			t.Errorf("Expected one route in progress, got %d (%v)", count, err)
+		}
+		if count, err := repos.Routes.CountByTechnician(first, bob.ID, models.RouteStatusCompleted); err != nil || count != 0 {
+			t.Errorf("Expected no completed routes, got %d (%v)", count, err)
+		}
+
+		// Recorded out of order, as offline devices sync late
+		for i, activityType := range []string{"complete", "arrive"} {
+			activity := models.RouteActivity{RouteID: route.ID, TechnicianID: bob.ID, ActivityType: activityType, Timestamp: loaded.CreatedAt.Add(-time.Duration(i) * time.Minute)}
+			if err := repos.Activities.Create(first, &activity); err != nil {
+				t.Fatalf("Failed to create activity: %v", err)
+			}
+		}
+		activities, total, err := repos.Activities.List(first, repositories.ActivityFilter{TechnicianID: &bob.ID}, repositories.Page{Page: 1, PageSize: 10})
+		if err != nil || total != 2 || len(activities) != 2 || activities[0].ActivityType != "arrive" {
+			t.Errorf("Expected the timeline in chronological order, got %d %+v (%v)", total, activities, err)
+		}
+		if _, total, err := repos.Activities.List(second, repositories.ActivityFilter{}, repositories.Page{Page: 1, PageSize: 10}); err != nil || total != 0 {
+			t.Errorf("Expected another organization's timeline to be empty, got %d (%v)", total, err)
+		}
+	})
+	t.Run("Routes are listed, updated and deleted", func(t *testing.T) {
+		scheduled := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+		var routes [2]models.Route
+		for i, name := range []string{"Harbour loop", "Airport run"} {
+			routes[i] = models.Route{
+				Name:          name,
+				ScheduledDate: &scheduled,
+				IsOptimized:   true,
+				Stops:         []models.RouteStop{{Name: "A", SequenceNum: 1}, {Name: "B", SequenceNum: 2}, {Name: "C", SequenceNum: 3}},
+			}
+			if err := repos.Routes.Create(first, &routes[i]); err != nil {
+				t.Fatalf("Failed to create route: %v", err)
+			}
+		}
+		harbour, airport := routes[0], routes[1]
+
+		listed, total, err := repos.Routes.List(first, repositories.RouteFilter{Search: "LOOP", DateFrom: &scheduled}, repositories.Page{Page: 1, PageSize: 10})
+		if err != nil || total != 1 || len(listed) != 1 || listed[0].ID != harbour.ID {
+			t.Fatalf("Expected only the harbour loop, got %d %+v (%v)", total, listed, err)
+		}
+		listed, _, err = repos.Routes.List(first, repositories.RouteFilter{SortBy: "name"}, repositories.Page{Page: 1, PageSize: 1})
+		if err != nil || len(listed) != 1 || listed[0].ID != airport.ID {
+			t.Errorf("Expected the airport run first when sorting by name, got %+v (%v)", listed, err)
+		}
+		if _, total, err := repos.Routes.List(second, repositories.RouteFilter{Search: "loop"}, repositories.Page{Page: 1, PageSize: 10}); err != nil || total != 0 {
+			t.Errorf("Expected another organization's list to be empty, got %d (%v)", total, err)
+		}
+
+		counts, err := repos.Routes.CountStops(first, []uint{harbour.ID, airport.ID})
+		if err != nil || counts[harbour.ID] != 3 || counts[airport.ID] != 3 {
+			t.Errorf("Expected three stops on each route, got %v (%v)", counts, err)
+		}
+
+		name, completed, toThird, toFirst := "Harbour loop (late)", true, 3, 1
+		err = repos.Routes.Update(first, harbour.ID, repositories.RouteUpdate{
+			Name:         &name,
+			TechnicianID: &alice.ID,
+			Stops: []repositories.RouteStopUpdate{
+				{ID: harbour.Stops[0].ID, SequenceNum: &toThird},
+				{ID: harbour.Stops[2].ID, SequenceNum: &toFirst, IsCompleted: &completed},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to update route: %v", err)
+		}
+		updated, err := repos.Routes.FindByID(first, harbour.ID)
+		if err != nil {
+			t.Fatalf("Failed to find route: %v", err)
+		}
+		if updated.Name != name || updated.TechnicianID == nil || *updated.TechnicianID != alice.ID || updated.IsOptimized {
+			t.Errorf("Unexpected route after update %+v", updated)
+		}
+		if updated.Stops[0].Name != "C" || !updated.Stops[0].IsCompleted || updated.Stops[0].CompletedAt == nil || updated.Stops[2].Name != "A" {
+			t.Errorf("Expected C completed and swapped with A, got %+v", updated.Stops)
+		}
+
+		err = repos.Routes.Update(first, harbour.ID, repositories.RouteUpdate{
+			UnassignTechnician: true,
+			Stops:              []repositories.RouteStopUpdate{{ID: harbour.Stops[2].ID, IsCompleted: &completed}},
+		})
+		if !errors.Is(err, repositories.ErrStopAlreadyCompleted) {
+			t.Errorf("Expected ErrStopAlreadyCompleted, got %v", err)
+		}
+		if err := repos.Routes.Update(first, harbour.ID, repositories.RouteUpdate{
+			Stops: []repositories.RouteStopUpdate{{ID: airport.Stops[0].ID, Name: &name}},
+		}); err != repositories.ErrNotFound {
+			t.Errorf("Expected ErrNotFound for another route's stop, got %v", err)
+		}
+		if err := repos.Routes.Update(second, harbour.ID, repositories.RouteUpdate{Name: &name}); err != repositories.ErrNotFound {
+			t.Errorf("Expected ErrNotFound for another organization, got %v", err)
+		}
+
+		if err := repos.Routes.Delete(second, airport.ID); err != repositories.ErrNotFound {
+			t.Errorf("Expected ErrNotFound for another organization, got %v", err)
+		}
+		if err := repos.Routes.Delete(first, airport.ID); err != nil {
+			t.Fatalf("Failed to delete route: %v", err)
+		}
+		if _, err := repos.Routes.FindByID(first, airport.ID); err != repositories.ErrNotFound {
+			t.Errorf("Expected the deleted route not to be found, got %v", err)
+		}
+		if counts, err := repos.Routes.CountStops(first, []uint{airport.ID}); err != nil || counts[airport.ID] != 0 {
+			t.Errorf("Expected the deleted route's stops to be gone, got %v (%v)", counts, err)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package unit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/config"
+	"routrapp-api/internal/revocation"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/utils/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// revocationStores returns the stores the deny list is tested with
+func revocationStores(t *testing.T) map[string]func() revocation.Store {
+	return map[string]func() revocation.Store{
+		"memory": func() revocation.Store { return revocation.NewMemoryStore() },
+		"database": func() revocation.Store {
+			db, err := tests.SetupTestDB()
+			if err != nil {
+				t.Fatalf("Failed to setup test database: %v", err)
+			}
+			return revocation.NewDatabaseStore(db)
+		},
+	}
+}
+
+// accessClaims returns the claims of an access token issued at the given time
+func accessClaims(userID uint, tokenID, familyID string, issuedAt time.Time) *auth.JWTClaims {
+	return &auth.JWTClaims{
+		UserID:    userID,
+		TokenType: "access",
+		FamilyID:  familyID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(15 * time.Minute)),
+		},
+	}
+}
+
+func isRevoked(t *testing.T, list *revocation.List, claims *auth.JWTClaims) bool {
+	t.Helper()
+	revoked, err := list.IsRevoked(context.Background(), claims)
+	if err != nil {
+		t.Fatalf("IsRevoked returned an error: %v", err)
+	}
+	return revoked
+}
+
+func TestList_Revocations(t *testing.T) {
+	for name, newStore := range revocationStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			clock := newTestClock()
+			list := revocation.NewListWithClock(newStore(), clock.Now)
+			issuedAt := clock.Now()
+
+			first := accessClaims(1, "token-1", "session-1", issuedAt)
+			second := accessClaims(1, "token-2", "session-2", issuedAt)
+			other := accessClaims(2, "token-3", "session-3", issuedAt)
+
+			// A single token
+			if err := list.RevokeToken(ctx, first.ID, first.ExpiresAt.Time); err != nil {
+				t.Fatalf("RevokeToken failed: %v", err)
+			}
+			if !isRevoked(t, list, first) || isRevoked(t, list, second) {
+				t.Fatalf("Expected only the revoked token to be rejected")
+			}
+
+			// Every token of a session
+			clock.Advance(time.Minute)
+			if err := list.RevokeSession(ctx, "session-2"); err != nil {
+				t.Fatalf("RevokeSession failed: %v", err)
+			}
+			if !isRevoked(t, list, second) || !isRevoked(t, list, accessClaims(1, "token-4", "session-2", clock.Now())) {
+				t.Errorf("Expected the tokens of the session to be rejected")
+			}
+			if isRevoked(t, list, other) {
+				t.Errorf("Expected the tokens of other sessions to stay valid")
+			}
+
+			// Every token issued to the user until the end of the current second
+			clock.Advance(30*time.Second + 500*time.Millisecond)
+			if err := list.RevokeUser(ctx, 2); err != nil {
+				t.Fatalf("RevokeUser failed: %v", err)
+			}
+			if !isRevoked(t, list, other) {
+				t.Errorf("Expected the user's earlier tokens to be rejected")
+			}
+			if !isRevoked(t, list, accessClaims(2, "token-5", "session-5", clock.Now().Truncate(time.Second))) {
+				t.Errorf("Expected tokens issued within the second of the revocation to be rejected")
+			}
+			if isRevoked(t, list, accessClaims(2, "token-7", "session-7", clock.Now().Truncate(time.Second).Add(time.Second))) {
+				t.Errorf("Expected tokens of a later login to stay valid")
+			}
+			if isRevoked(t, list, accessClaims(3, "token-6", "", issuedAt)) {
+				t.Errorf("Expected the tokens of other users to stay valid")
+			}
+
+			// Revocations end once the tokens they cover expired
+			clock.Advance(15 * time.Minute)
+			if isRevoked(t, list, first) || isRevoked(t, list, second) || isRevoked(t, list, other) {
+				t.Errorf("Expected expired revocations to be ignored")
+			}
+		})
+	}
+}
+
+func TestStore_RevocationsOnlyMoveForward(t *testing.T) {
+	for name, newStore := range revocationStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore()
+			now := newTestClock().Now()
+			key := revocation.UserKey(1)
+
+			if err := store.Revoke(ctx, key, now, now.Add(time.Hour)); err != nil {
+				t.Fatalf("Revoke failed: %v", err)
+			}
+			if err := store.Revoke(ctx, key, now.Add(-time.Minute), now.Add(time.Minute)); err != nil {
+				t.Fatalf("Revoke failed: %v", err)
+			}
+			notBefore, err := store.NotBefore(ctx, now.Add(30*time.Minute), key, revocation.TokenKey("unknown"))
+			if err != nil || !notBefore.Equal(now) {
+				t.Errorf("Expected an earlier revocation not to shorten the later one, got %s, %v", notBefore, err)
+			}
+
+			if err := store.Prune(ctx, now.Add(2*time.Hour)); err != nil {
+				t.Fatalf("Prune failed: %v", err)
+			}
+			if notBefore, err := store.NotBefore(ctx, now, key); err != nil || !notBefore.IsZero() {
+				t.Errorf("Expected the expired revocation to be pruned, got %s, %v", notBefore, err)
+			}
+		})
+	}
+}
+
+func TestList_Disabled(t *testing.T) {
+	var list *revocation.List
+	if err := list.RevokeUser(context.Background(), 1); err != nil {
+		t.Fatalf("RevokeUser on a nil list returned an error: %v", err)
+	}
+	if isRevoked(t, list, accessClaims(1, "token-1", "", time.Now().Add(-time.Minute))) {
+		t.Errorf("Expected a nil list to revoke nothing")
+	}
+
+	if _, err := revocation.New(config.RevocationConfig{Store: "redis"}, nil); err == nil {
+		t.Errorf("Expected an unsupported store to be rejected")
+	}
+}
@@ -0,0 +1,78 @@
+package unit_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"routrapp-api/internal/models"
+)
+
+func TestRouteStatus_Apply(t *testing.T) {
+	tests := []struct {
+		from   models.RouteStatus
+		action models.RouteAction
+		to     models.RouteStatus
+		ok     bool
+	}{
+		{models.RouteStatusPending, models.RouteActionAssign, models.RouteStatusAssigned, true},
+		{models.RouteStatusAssigned, models.RouteActionUnassign, models.RouteStatusPending, true},
+		{models.RouteStatusAssigned, models.RouteActionStart, models.RouteStatusStarted, true},
+		{models.RouteStatusStarted, models.RouteActionPause, models.RouteStatusPaused, true},
+		{models.RouteStatusPaused, models.RouteActionResume, models.RouteStatusStarted, true},
+		{models.RouteStatusStarted, models.RouteActionComplete, models.RouteStatusCompleted, true},
+		{models.RouteStatusPaused, models.RouteActionComplete, models.RouteStatusCompleted, true},
+		{models.RouteStatusPending, models.RouteActionCancel, models.RouteStatusCancelled, true},
+		{models.RouteStatusPaused, models.RouteActionCancel, models.RouteStatusCancelled, true},
+		{models.RouteStatusPending, models.RouteActionStart, models.RouteStatusPending, false},
+		{models.RouteStatusPaused, models.RouteActionStart, models.RouteStatusPaused, false},
+		{models.RouteStatusAssigned, models.RouteActionComplete, models.RouteStatusAssigned, false},
+		{models.RouteStatusCompleted, models.RouteActionCancel, models.RouteStatusCompleted, false},
+		{models.RouteStatusCancelled, models.RouteActionResume, models.RouteStatusCancelled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"_"+string(tt.action), func(t *testing.T) {
+			to, err := tt.from.Apply(tt.action)
+			if to != tt.to {
+				t.Errorf("Expected %s, got %s", tt.to, to)
+			}
+			if tt.ok && err != nil {
+				t.Errorf("Expected transition to be allowed, got %v", err)
+			}
+			if !tt.ok {
+				var transitionErr *models.RouteTransitionError
+				if !errors.As(err, &transitionErr) || transitionErr.From != tt.from || transitionErr.Action != tt.action {
+					t.Errorf("Expected a RouteTransitionError, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestRouteStatus_AllowedActions(t *testing.T) {
+	tests := map[models.RouteStatus][]models.RouteAction{
+		models.RouteStatusPending:   {models.RouteActionAssign, models.RouteActionCancel},
+		models.RouteStatusAssigned:  {models.RouteActionUnassign, models.RouteActionStart, models.RouteActionCancel},
+		models.RouteStatusStarted:   {models.RouteActionPause, models.RouteActionComplete, models.RouteActionCancel},
+		models.RouteStatusPaused:    {models.RouteActionResume, models.RouteActionComplete, models.RouteActionCancel},
+		models.RouteStatusCompleted: {},
+		models.RouteStatusCancelled: {},
+	}
+
+	for status, expected := range tests {
+		if actions := status.AllowedActions(); !reflect.DeepEqual(actions, expected) {
+			t.Errorf("Expected %v for %s, got %v", expected, status, actions)
+		}
+		if status.IsTerminal() != (len(expected) == 0) {
+			t.Errorf("Expected IsTerminal for %s to be %t", status, len(expected) == 0)
+		}
+	}
+
+	if !models.RouteStatusStarted.CanTransitionTo(models.RouteStatusPaused) || models.RouteStatusCompleted.CanTransitionTo(models.RouteStatusStarted) {
+		t.Error("Unexpected CanTransitionTo result")
+	}
+	if models.RouteStatus("archived").IsValid() {
+		t.Error("Expected unknown statuses to be invalid")
+	}
+}
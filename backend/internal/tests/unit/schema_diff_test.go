@@ -0,0 +1,93 @@
+package unit_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/repositories/postgres"
+)
+
+func TestSchemaDiffer(t *testing.T) {
+	db := openMigrationTestDB(t)
+	ctx := context.Background()
+
+	// An outdated schema: later tables are missing, a column was added to the models since and
+	// a column was removed from them
+	if err := db.AutoMigrate(&models.Organization{}, &models.Role{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	for _, statement := range []string{
+		"ALTER TABLE organizations DROP COLUMN logo_url",
+		"ALTER TABLE roles ADD COLUMN legacy_code TEXT",
+	} {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("Failed to alter schema: %v", err)
+		}
+	}
+
+	differ := postgres.NewSchemaDiffer(db, models.AllModels()...)
+	diff, err := differ.Diff(ctx)
+	if err != nil {
+		t.Fatalf("Failed to diff schema: %v", err)
+	}
+
+	up := diff.UpSQL()
+	for _, expected := range []string{"CREATE TABLE `users`", "ADD `logo_url`", "idx_roles_org_name", "idx_users_org_email", "idx_technician_locations_trail"} {
+		if !strings.Contains(up, expected) {
+			t.Errorf("Expected the up SQL to contain %q, got:\n%s", expected, up)
+		}
+	}
+	if strings.Contains(up, "CREATE TABLE `organizations`") || strings.Contains(up, "DROP") {
+		t.Errorf("Expected existing tables and columns to be kept, got:\n%s", up)
+	}
+	if !strings.Contains(up, "-- NOTE: column roles.legacy_code has no model field") {
+		t.Errorf("Expected the extra column to be reported, got:\n%s", up)
+	}
+
+	t.Run("Applying the up SQL brings the schema in line with the models", func(t *testing.T) {
+		if err := db.Exec(up).Error; err != nil {
+			t.Fatalf("Failed to apply up SQL: %v\n%s", err, up)
+		}
+		again, err := differ.Diff(ctx)
+		if err != nil {
+			t.Fatalf("Failed to diff schema: %v", err)
+		}
+		if !again.Empty() {
+			t.Errorf("Expected no differences, got:\n%s", again.UpSQL())
+		}
+	})
+
+	t.Run("Applying the down SQL restores the previous schema", func(t *testing.T) {
+		if err := db.Exec(diff.DownSQL()).Error; err != nil {
+			t.Fatalf("Failed to apply down SQL: %v\n%s", err, diff.DownSQL())
+		}
+		if db.Migrator().HasTable("users") || db.Migrator().HasColumn("organizations", "logo_url") || db.Migrator().HasIndex("roles", "idx_roles_org_name") {
+			t.Errorf("Expected the changes to be reverted")
+		}
+		if !db.Migrator().HasTable("roles") {
+			t.Errorf("Expected existing tables to be kept")
+		}
+	})
+
+	t.Run("Differences are written as a migration", func(t *testing.T) {
+		dir := t.TempDir()
+		manager := postgres.NewMigrationManager(dir)
+		upPath, downPath, err := manager.CreateMigrationWithSQL("sync models", diff.UpSQL(), diff.DownSQL())
+		if err != nil {
+			t.Fatalf("Failed to create migration: %v", err)
+		}
+		if !strings.HasSuffix(upPath, "001_sync_models.up.sql") || !strings.HasSuffix(downPath, "001_sync_models.down.sql") {
+			t.Errorf("Unexpected migration files %s and %s", upPath, downPath)
+		}
+
+		migrations, err := manager.LoadMigrations()
+		if err != nil || len(migrations) != 1 {
+			t.Fatalf("Expected one migration, got %d (%v)", len(migrations), err)
+		}
+		if !strings.Contains(migrations[0].UpSQL, "-- Direction: UP") || !strings.Contains(migrations[0].UpSQL, up) {
+			t.Errorf("Expected the header and the up SQL, got:\n%s", migrations[0].UpSQL)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package unit_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"routrapp-api/internal/storage"
+	"routrapp-api/internal/tests"
+	"routrapp-api/internal/utils/exif"
+)
+
+func TestLocalStorage_PutOpenDelete(t *testing.T) {
+	store, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	ctx := context.Background()
+
+	written, err := store.Put(ctx, "organizations/1/a.txt", strings.NewReader("hello"))
+	if err != nil || written != 5 {
+		t.Fatalf("Expected 5 bytes written, got %d (%v)", written, err)
+	}
+
+	blob, err := store.Open(ctx, "organizations/1/a.txt")
+	if err != nil {
+		t.Fatalf("Failed to open blob: %v", err)
+	}
+	content, _ := io.ReadAll(blob)
+	blob.Close()
+	if string(content) != "hello" {
+		t.Errorf("Expected hello, got %q", content)
+	}
+
+	if err := store.Delete(ctx, "organizations/1/a.txt"); err != nil {
+		t.Fatalf("Failed to delete blob: %v", err)
+	}
+	if _, err := store.Open(ctx, "organizations/1/a.txt"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if err := store.Delete(ctx, "organizations/1/a.txt"); err != nil {
+		t.Errorf("Expected deleting a missing blob to succeed, got %v", err)
+	}
+}
+
+func TestLocalStorage_RejectsKeysOutsideRoot(t *testing.T) {
+	store, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../escape", "a/../../escape", "a//b", `a\b`} {
+		if _, err := store.Put(context.Background(), key, strings.NewReader("x")); !errors.Is(err, storage.ErrInvalidKey) {
+			t.Errorf("Expected ErrInvalidKey for %q, got %v", key, err)
+		}
+	}
+}
+
+func TestURLSigner_Verify(t *testing.T) {
+	signer := storage.NewURLSigner("secret", time.Minute)
+	now := time.Now()
+
+	signed, expiresAt := signer.SignedURL("/api/v1/attachments/7/content", 7, now)
+	parsed, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("Failed to parse signed URL: %v", err)
+	}
+	expires, signature := parsed.Query().Get("expires"), parsed.Query().Get("signature")
+
+	if !signer.Verify(7, expires, signature, now) {
+		t.Error("Expected the signature to verify")
+	}
+	if signer.Verify(8, expires, signature, now) {
+		t.Error("Expected the signature not to verify for another object")
+	}
+	if signer.Verify(7, expires, signature, expiresAt.Add(time.Second)) {
+		t.Error("Expected the signature not to verify after expiry")
+	}
+	if storage.NewURLSigner("other", time.Minute).Verify(7, expires, signature, now) {
+		t.Error("Expected the signature not to verify with another secret")
+	}
+	if signer.Verify(7, "not-a-number", signature, now) {
+		t.Error("Expected malformed expiry to be rejected")
+	}
+}
+
+func TestExifDecode(t *testing.T) {
+	capturedAt := time.Date(2025, 8, 11, 14, 30, 5, 0, time.UTC)
+	image := tests.BuildTestJPEG(capturedAt, 40.748817, -73.985428)
+
+	metadata, err := exif.Decode(bytes.NewReader(image))
+	if err != nil {
+		t.Fatalf("Failed to decode EXIF: %v", err)
+	}
+	if metadata.CapturedAt == nil || !metadata.CapturedAt.Equal(capturedAt) {
+		t.Errorf("Expected capture time %s, got %v", capturedAt, metadata.CapturedAt)
+	}
+	if metadata.Lat == nil || math.Abs(*metadata.Lat-40.748817) > 1e-6 {
+		t.Errorf("Expected latitude 40.748817, got %v", metadata.Lat)
+	}
+	if metadata.Lng == nil || math.Abs(*metadata.Lng+73.985428) > 1e-6 {
+		t.Errorf("Expected longitude -73.985428, got %v", metadata.Lng)
+	}
+
+	if _, err := exif.Decode(bytes.NewReader([]byte{0xFF, 0xD8, 0xFF, 0xDA, 0x00, 0x02})); !errors.Is(err, exif.ErrNoExif) {
+		t.Errorf("Expected ErrNoExif for a JPEG without EXIF, got %v", err)
+	}
+	if _, err := exif.Decode(strings.NewReader("%PDF-1.4")); !errors.Is(err, exif.ErrNoExif) {
+		t.Errorf("Expected ErrNoExif for a non-JPEG, got %v", err)
+	}
+	if _, err := exif.Decode(bytes.NewReader(image[:40])); !errors.Is(err, exif.ErrNoExif) {
+		t.Errorf("Expected ErrNoExif for a truncated image, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package unit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"routrapp-api/internal/models"
+	"routrapp-api/internal/tenant"
+	"routrapp-api/internal/tests"
+)
+
+func TestTenantPlugin(t *testing.T) {
+	system, err := tests.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	first, err := tests.CreateTestOrganization(system)
+	if err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	second := models.Organization{Name: "Second Org", SubDomain: "second", ContactEmail: "second@example.com", Active: true}
+	if err := system.Create(&second).Error; err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	for _, orgID := range []uint{first.ID, second.ID} {
+		if _, err := tests.CreateTestRole(system, orgID, models.RoleTypeTechnician); err != nil {
+			t.Fatalf("Failed to create role: %v", err)
+		}
+	}
+
+	background := context.Background()
+	scoped := system.WithContext(tenant.WithOrganization(background, first.ID))
+	unscoped := system.WithContext(background)
+
+	t.Run("Statements without a tenant fail closed", func(t *testing.T) {
+		var roles []models.Role
+		if err := unscoped.Find(&roles).Error; !errors.Is(err, tenant.ErrMissingTenant) {
+			t.Errorf("Expected ErrMissingTenant for a query, got %v", err)
+		}
+		var count int64
+		if err := unscoped.Model(&models.Role{}).Count(&count).Error; !errors.Is(err, tenant.ErrMissingTenant) {
+			t.Errorf("Expected ErrMissingTenant for a count, got %v", err)
+		}
+		if err := unscoped.Model(&models.Role{}).Where("active = ?", true).Update("description", "changed").Error; !errors.Is(err, tenant.ErrMissingTenant) {
+			t.Errorf("Expected ErrMissingTenant for an update, got %v", err)
+		}
+		if err := unscoped.Where("active = ?", true).Delete(&models.Role{}).Error; !errors.Is(err, tenant.ErrMissingTenant) {
+			t.Errorf("Expected ErrMissingTenant for a delete, got %v", err)
+		}
+		role := models.Role{Base: models.Base{OrganizationID: first.ID}, Name: "dispatcher", DisplayName: "Dispatcher"}
+		if err := unscoped.Create(&role).Error; !errors.Is(err, tenant.ErrMissingTenant) {
+			t.Errorf("Expected ErrMissingTenant for a create, got %v", err)
+		}
+	})
+
+	t.Run("Models without a tenant are not scoped", func(t *testing.T) {
+		var organizations []models.Organization
+		if err := unscoped.Find(&organizations).Error; err != nil || len(organizations) != 2 {
+			t.Errorf("Expected both organizations, got %d (%v)", len(organizations), err)
+		}
+	})
+
+	t.Run("Queries only see the tenant's rows", func(t *testing.T) {
+		var roles []models.Role
+		if err := scoped.Find(&roles).Error; err != nil {
+			t.Fatalf("Failed to query roles: %v", err)
+		}
+		if len(roles) != 1 || roles[0].OrganizationID != first.ID {
+			t.Errorf("Expected only the first organization's role, got %+v", roles)
+		}
+
+		var other models.Role
+		if err := system.Where("organization_id = ?", second.ID).First(&other).Error; err != nil {
+			t.Fatalf("Failed to load the second organization's role: %v", err)
+		}
+		if err := scoped.First(&models.Role{}, other.ID).Error; err == nil {
+			t.Errorf("Expected another organization's role to be invisible")
+		}
+		if err := scoped.Preload("Role").Find(&[]models.User{}).Error; err != nil {
+			t.Errorf("Expected preloads to run within the tenant, got %v", err)
+		}
+	})
+
+	t.Run("Updates and deletes cannot reach other tenants", func(t *testing.T) {
+		var other models.Role
+		if err := system.Where("organization_id = ?", second.ID).First(&other).Error; err != nil {
+			t.Fatalf("Failed to load the second organization's role: %v", err)
+		}
+
+		result := scoped.Model(&models.Role{}).Where("id = ?", other.ID).Update("description", "hijacked")
+		if result.Error != nil || result.RowsAffected != 0 {
+			t.Errorf("Expected no rows updated, got %d (%v)", result.RowsAffected, result.Error)
+		}
+		result = scoped.Delete(&models.Role{}, other.ID)
+		if result.Error != nil || result.RowsAffected != 0 {
+			t.Errorf("Expected no rows deleted, got %d (%v)", result.RowsAffected, result.Error)
+		}
+	})
+
+	t.Run("Creates are assigned to the tenant", func(t *testing.T) {
+		role := models.Role{Name: "dispatcher", DisplayName: "Dispatcher", Active: true}
+		if err := scoped.Create(&role).Error; err != nil {
+			t.Fatalf("Failed to create role: %v", err)
+		}
+		if role.OrganizationID != first.ID {
+			t.Errorf("Expected organization %d, got %d", first.ID, role.OrganizationID)
+		}
+
+		batch := []models.Role{
+			{Name: "auditor", DisplayName: "Auditor"},
+			{Base: models.Base{OrganizationID: first.ID}, Name: "supervisor", DisplayName: "Supervisor"},
+		}
+		if err := scoped.Create(&batch).Error; err != nil {
+			t.Fatalf("Failed to create roles: %v", err)
+		}
+		if batch[0].OrganizationID != first.ID {
+			t.Errorf("Expected batch records to be assigned to organization %d, got %d", first.ID, batch[0].OrganizationID)
+		}
+
+		foreign := models.Role{Base: models.Base{OrganizationID: second.ID}, Name: "dispatcher", DisplayName: "Dispatcher"}
+		if err := scoped.Create(&foreign).Error; !errors.Is(err, tenant.ErrTenantMismatch) {
+			t.Errorf("Expected ErrTenantMismatch, got %v", err)
+		}
+	})
+
+	t.Run("System jobs can opt out of scoping", func(t *testing.T) {
+		var count int64
+		if err := system.Model(&models.Role{}).Count(&count).Error; err != nil {
+			t.Fatalf("Failed to count roles: %v", err)
+		}
+		if count != 5 {
+			t.Errorf("Expected roles of both organizations, got %d", count)
+		}
+
+		// An organization on the context takes precedence over the escape hatch
+		both := system.WithContext(tenant.WithOrganization(tenant.WithoutScope(background), second.ID))
+		if err := both.Model(&models.Role{}).Count(&count).Error; err != nil || count != 1 {
+			t.Errorf("Expected the second organization's role only, got %d (%v)", count, err)
+		}
+	})
+}
@@ -1,6 +1,9 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -16,73 +19,132 @@ type JWTClaims struct {
 	OrganizationID uint   `json:"organization_id"`
 	Email          string `json:"email"`
 	Role           string `json:"role"`
-	TokenType      string `json:"token_type"` // "access" or "refresh"
+	TokenType      string `json:"token_type"`    // "access", "refresh" or "mfa"
+	FamilyID       string `json:"sid,omitempty"` // user session the token was issued for
 	jwt.RegisteredClaims
 }
 
 // JWTService handles JWT token operations
 type JWTService struct {
 	secretKey []byte
+	keys      *KeyRing // signs with key pairs instead of the secret when set
 }
 
-// NewJWTService creates a new JWT service instance
+// NewJWTService creates a new JWT service instance signing tokens with HS256 and the secret key
 func NewJWTService(secretKey string) *JWTService {
 	return &JWTService{
 		secretKey: []byte(secretKey),
 	}
 }
 
-// GenerateAccessToken generates a new access token for the user
-func (j *JWTService) GenerateAccessToken(userID, organizationID uint, email, role string) (string, error) {
-	claims := JWTClaims{
-		UserID:         userID,
-		OrganizationID: organizationID,
-		Email:          email,
-		Role:           role,
-		TokenType:      "access",
-		RegisteredClaims: jwt.RegisteredClaims{
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(constants.JWT_ACCESS_TOKEN_EXPIRY) * time.Second)),
-			Subject:   fmt.Sprintf("%d", userID),
-			Issuer:    "routrapp-api",
-			Audience:  []string{"routrapp-frontend"},
-		},
+// NewJWTServiceWithKeys creates a JWT service signing tokens with the current key of the key ring.
+// Tokens carry the ID of their key, so that other services verify them with the public keys of JWKS.
+func NewJWTServiceWithKeys(keys *KeyRing) *JWTService {
+	return &JWTService{
+		keys: keys,
 	}
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.secretKey)
+// Algorithm returns the algorithm new tokens are signed with
+func (j *JWTService) Algorithm() string {
+	if j.keys == nil {
+		return AlgorithmHS256
+	}
+	return j.keys.Algorithm()
+}
+
+// Keys returns the key ring of the service, or nil when tokens are signed with the secret
+func (j *JWTService) Keys() *KeyRing {
+	return j.keys
+}
+
+// JWKS returns the public keys verifying the service's tokens. It is empty when tokens are signed
+// with the secret, which must never be published.
+func (j *JWTService) JWKS() JWKS {
+	if j.keys == nil {
+		return JWKS{Keys: []JWK{}}
+	}
+	return j.keys.JWKS()
+}
+
+// GenerateAccessToken generates a new access token for the user
+func (j *JWTService) GenerateAccessToken(userID, organizationID uint, email, role string) (string, error) {
+	return j.generateToken("access", "", userID, organizationID, email, role)
 }
 
 // GenerateRefreshToken generates a new refresh token for the user
 func (j *JWTService) GenerateRefreshToken(userID, organizationID uint, email, role string) (string, error) {
+	return j.generateToken("refresh", "", userID, organizationID, email, role)
+}
+
+// GenerateSessionTokens generates the access and refresh tokens of the user session identified by familyID
+func (j *JWTService) GenerateSessionTokens(familyID string, userID, organizationID uint, email, role string) (accessToken, refreshToken string, err error) {
+	accessToken, err = j.generateToken("access", familyID, userID, organizationID, email, role)
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, err = j.generateToken("refresh", familyID, userID, organizationID, email, role)
+	if err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
+
+// GenerateMFAToken generates the short-lived token a user whose password was verified exchanges,
+// together with a second factor, for session tokens
+func (j *JWTService) GenerateMFAToken(userID, organizationID uint, email, role string) (string, error) {
+	return j.generateToken("mfa", "", userID, organizationID, email, role)
+}
+
+// generateToken signs a token of the given type. Every token gets a random ID (jti), so that a
+// single token can be revoked and tokens rotated within the same second still differ.
+func (j *JWTService) generateToken(tokenType, familyID string, userID, organizationID uint, email, role string) (string, error) {
+	expiry := constants.JWT_ACCESS_TOKEN_EXPIRY
+	switch tokenType {
+	case "refresh":
+		expiry = constants.JWT_REFRESH_TOKEN_EXPIRY
+	case "mfa":
+		expiry = constants.JWT_MFA_TOKEN_EXPIRY
+	}
+	tokenID, err := NewRandomToken()
+	if err != nil {
+		return "", err
+	}
+
 	claims := JWTClaims{
 		UserID:         userID,
 		OrganizationID: organizationID,
 		Email:          email,
 		Role:           role,
-		TokenType:      "refresh",
+		TokenType:      tokenType,
+		FamilyID:       familyID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(constants.JWT_REFRESH_TOKEN_EXPIRY) * time.Second)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expiry) * time.Second)),
 			Subject:   fmt.Sprintf("%d", userID),
 			Issuer:    "routrapp-api",
 			Audience:  []string{"routrapp-frontend"},
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.secretKey)
+	if j.keys == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(j.secretKey)
+	}
+
+	key := j.keys.Current()
+	if key == nil {
+		return "", errors.New("no signing key available")
+	}
+	token := jwt.NewWithClaims(key.signingMethod(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
 }
 
 // ValidateToken validates and parses a JWT token
 func (j *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate the signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return j.secretKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, j.verificationKey)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -105,6 +167,29 @@ func (j *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
 	return claims, nil
 }
 
+// verificationKey returns the key verifying the token. With a key ring the token must name one of its
+// keys and be signed with that key's algorithm, so a token signed with HS256 and a published public key
+// as the secret is rejected.
+func (j *JWTService) verificationKey(token *jwt.Token) (interface{}, error) {
+	if j.keys == nil {
+		// Validate the signing method
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return j.secretKey, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := j.keys.Lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.PublicKey(), nil
+}
+
 // ExtractTokenFromHeader extracts the JWT token from the Authorization header
 func ExtractTokenFromHeader(authHeader string) (string, error) {
 	if authHeader == "" {
@@ -133,6 +218,11 @@ func (c *JWTClaims) IsRefreshToken() bool {
 	return c.TokenType == "refresh"
 }
 
+// IsMFAToken checks if the token is an MFA challenge token
+func (c *JWTClaims) IsMFAToken() bool {
+	return c.TokenType == "mfa"
+}
+
 // GetUserContext returns a simplified user context from JWT claims
 func (c *JWTClaims) GetUserContext() map[string]interface{} {
 	return map[string]interface{}{
@@ -143,6 +233,22 @@ func (c *JWTClaims) GetUserContext() map[string]interface{} {
 	}
 }
 
+// NewRandomToken returns a random 128-bit hex string, e.g. for identifiers that must not be guessable
+func NewRandomToken() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return hex.EncodeToString(random), nil
+}
+
+// HashToken returns the SHA-256 hex digest under which a token is stored. Tokens are random enough
+// that a fast hash suffices, unlike passwords.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // DefaultJWTService returns a JWT service instance with the default secret key
 func DefaultJWTService() *JWTService {
 	return NewJWTService(constants.JWT_SECRET())
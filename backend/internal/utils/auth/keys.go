@@ -0,0 +1,452 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"routrapp-api/internal/utils/constants"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algorithms tokens can be signed with
+const (
+	AlgorithmHS256 = "HS256" // HMAC with the shared secret
+	AlgorithmRS256 = "RS256" // RSA key pairs
+	AlgorithmEdDSA = "EdDSA" // Ed25519 key pairs
+)
+
+const (
+	rsaKeyBits = 2048
+
+	// keyReloadCooldown limits how often tokens with an unknown key ID reload the key directory
+	keyReloadCooldown = 10 * time.Second
+
+	// pemCreatedHeader records when a generated key was created in its PEM file
+	pemCreatedHeader = "Created"
+)
+
+// SigningKey is a key pair signing tokens. Its ID is sent in the kid header of the tokens it signs.
+type SigningKey struct {
+	ID        string
+	Algorithm string    // RS256 or EdDSA, following the type of the key
+	CreatedAt time.Time // when the key starts signing; the next key is published ahead of it
+	private   crypto.Signer
+}
+
+// GenerateSigningKey generates a new key pair for the algorithm, signing from now on
+func GenerateSigningKey(algorithm string, now time.Time) (*SigningKey, error) {
+	var private crypto.Signer
+	switch algorithm {
+	case AlgorithmRS256:
+		key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate RSA key: %w", err)
+		}
+		private = key
+	case AlgorithmEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate Ed25519 key: %w", err)
+		}
+		private = key
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+
+	id, err := NewRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	return newSigningKey(id, private, now.UTC().Truncate(time.Second))
+}
+
+// ParseSigningKey parses a PEM encoded PKCS #8 or PKCS #1 private key. Keys generated by the key ring
+// record their creation time; for others createdAt is used.
+func ParseSigningKey(id string, data []byte, createdAt time.Time) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if created, ok := block.Headers[pemCreatedHeader]; ok {
+		parsed, err := time.Parse(time.RFC3339, created)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s header: %w", pemCreatedHeader, err)
+		}
+		createdAt = parsed
+	}
+
+	var private crypto.PrivateKey
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return newSigningKey(id, private, createdAt)
+}
+
+func newSigningKey(id string, private crypto.PrivateKey, createdAt time.Time) (*SigningKey, error) {
+	key := &SigningKey{ID: id, CreatedAt: createdAt}
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm, key.private = AlgorithmRS256, private
+	case ed25519.PrivateKey:
+		key.Algorithm, key.private = AlgorithmEdDSA, private
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", private)
+	}
+	return key, nil
+}
+
+// PublicKey returns the public key verifying the tokens the key signed
+func (k *SigningKey) PublicKey() crypto.PublicKey {
+	return k.private.Public()
+}
+
+// MarshalPEM encodes the private key as PKCS #8, recording its creation time
+func (k *SigningKey) MarshalPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type:    "PRIVATE KEY",
+		Headers: map[string]string{pemCreatedHeader: k.CreatedAt.UTC().Format(time.RFC3339)},
+		Bytes:   der,
+	}), nil
+}
+
+func (k *SigningKey) signingMethod() jwt.SigningMethod {
+	if k.Algorithm == AlgorithmEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`   // RSA modulus
+	E         string `json:"e,omitempty"`   // RSA exponent
+	Curve     string `json:"crv,omitempty"` // OKP curve
+	X         string `json:"x,omitempty"`   // OKP public key
+}
+
+// JWKS is a JSON Web Key Set, the document other services verify tokens with
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK returns the public key of the signing key
+func (k *SigningKey) JWK() JWK {
+	jwk := JWK{KeyID: k.ID, Use: "sig", Algorithm: k.Algorithm}
+	switch public := k.PublicKey().(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+	return jwk
+}
+
+// KeyRing holds the key pairs tokens are signed and verified with. The newest started key of the ring's
+// algorithm signs new tokens; replaced keys keep verifying the tokens they signed until those expired.
+// The next key is published before it starts signing, so verifiers caching the keys know it by then.
+// Keys are kept in a directory as <kid>.pem files, so replicas sharing the directory share the keys.
+type KeyRing struct {
+	algorithm        string
+	dir              string        // empty keeps the keys in memory
+	rotationInterval time.Duration // how long a key signs before it is replaced; 0 never replaces keys
+	keyLifetime      time.Duration // how long a replaced key keeps verifying, the lifetime of the longest token
+	publishAhead     time.Duration // how long the next key is published before it starts signing
+	now              func() time.Time
+
+	mu             sync.RWMutex
+	keys           []*SigningKey // oldest first
+	lastMissReload time.Time     // when an unknown key ID last reloaded the directory
+}
+
+// NewKeyRing creates a key ring with the keys of dir, generating the first key when there is none
+func NewKeyRing(algorithm, dir string, rotationInterval time.Duration) (*KeyRing, error) {
+	return NewKeyRingWithClock(algorithm, dir, rotationInterval, time.Now)
+}
+
+// NewKeyRingWithClock creates a key ring picking the signing key by the time now returns
+func NewKeyRingWithClock(algorithm, dir string, rotationInterval time.Duration, now func() time.Time) (*KeyRing, error) {
+	if algorithm != AlgorithmRS256 && algorithm != AlgorithmEdDSA {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create key directory: %w", err)
+		}
+	}
+
+	ring := &KeyRing{
+		algorithm:        algorithm,
+		dir:              dir,
+		rotationInterval: rotationInterval,
+		keyLifetime:      time.Duration(constants.JWT_REFRESH_TOKEN_EXPIRY) * time.Second,
+		publishAhead:     constants.JWKSCacheMaxAge,
+		now:              now,
+	}
+	if _, err := ring.Rotate(now()); err != nil {
+		return nil, err
+	}
+	return ring, nil
+}
+
+// Algorithm returns the algorithm new keys are generated for
+func (r *KeyRing) Algorithm() string {
+	return r.algorithm
+}
+
+// Current returns the key signing new tokens
+func (r *KeyRing) Current() *SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current(r.now())
+}
+
+// Next returns the published key that starts signing later, or nil when there is none
+func (r *KeyRing) Next() *SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.next(r.now())
+}
+
+// current returns the newest key of the algorithm that started signing by now. Without one it falls
+// back to the newest started key, then to the oldest key.
+func (r *KeyRing) current(now time.Time) *SigningKey {
+	var started *SigningKey
+	for i := len(r.keys) - 1; i >= 0; i-- {
+		key := r.keys[i]
+		if key.CreatedAt.After(now) {
+			continue
+		}
+		if key.Algorithm == r.algorithm {
+			return key
+		}
+		if started == nil {
+			started = key
+		}
+	}
+	if started == nil && len(r.keys) > 0 {
+		return r.keys[0]
+	}
+	return started
+}
+
+func (r *KeyRing) next(now time.Time) *SigningKey {
+	for i := len(r.keys) - 1; i >= 0; i-- {
+		if r.keys[i].Algorithm == r.algorithm && r.keys[i].CreatedAt.After(now) {
+			return r.keys[i]
+		}
+	}
+	return nil
+}
+
+// Lookup returns the key with the ID. An unknown ID reloads the directory first, as another replica
+// may have generated the key.
+func (r *KeyRing) Lookup(id string) (*SigningKey, bool) {
+	if key, ok := r.find(id); ok || id == "" || r.dir == "" {
+		return key, ok
+	}
+
+	r.mu.Lock()
+	if time.Since(r.lastMissReload) >= keyReloadCooldown {
+		r.lastMissReload = time.Now()
+		// A failed reload keeps the keys loaded before
+		_ = r.reload()
+	}
+	r.mu.Unlock()
+	return r.find(id)
+}
+
+func (r *KeyRing) find(id string) (*SigningKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, key := range r.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// Keys returns the keys verifying tokens, oldest first
+func (r *KeyRing) Keys() []*SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]*SigningKey(nil), r.keys...)
+}
+
+// Rotate reloads the directory, generates the next key when the current one is due for replacement
+// within the publish period and removes the keys the tokens of which all expired. The next key starts
+// signing once the current one signed for the rotation interval, and never before it was published for
+// the whole publish period. It reports whether a key was generated.
+func (r *KeyRing) Rotate(now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.dir != "" {
+		if err := r.reload(); err != nil {
+			return false, err
+		}
+	}
+
+	rotated := false
+	startsAt := time.Time{}
+	current := r.current(now)
+	switch {
+	case current == nil || current.Algorithm != r.algorithm:
+		startsAt = now
+	case r.rotationInterval > 0 && r.next(now) == nil && now.Sub(current.CreatedAt) >= r.rotationInterval-r.publishAhead:
+		startsAt = current.CreatedAt.Add(r.rotationInterval)
+		if earliest := now.Add(r.publishAhead); startsAt.Before(earliest) {
+			startsAt = earliest
+		}
+	}
+	if !startsAt.IsZero() {
+		key, err := GenerateSigningKey(r.algorithm, startsAt)
+		if err != nil {
+			return false, err
+		}
+		if err := r.save(key); err != nil {
+			return false, err
+		}
+		r.keys = append(r.keys, key)
+		rotated = true
+	}
+
+	if r.rotationInterval > 0 {
+		if err := r.retire(now); err != nil {
+			return rotated, err
+		}
+	}
+	return rotated, nil
+}
+
+// retire removes the keys replaced longer than the key lifetime ago. A key stopped signing when the
+// next key started. Keys that can't be removed from the directory are kept.
+func (r *KeyRing) retire(now time.Time) error {
+	var kept []*SigningKey
+	var firstErr error
+	for i, key := range r.keys {
+		if i == len(r.keys)-1 || now.Sub(r.keys[i+1].CreatedAt) <= r.keyLifetime {
+			kept = append(kept, key)
+			continue
+		}
+		if r.dir != "" {
+			if err := os.Remove(filepath.Join(r.dir, key.ID+".pem")); err != nil && !os.IsNotExist(err) {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to remove retired key %s: %w", key.ID, err)
+				}
+				kept = append(kept, key)
+			}
+		}
+	}
+	r.keys = kept
+	return firstErr
+}
+
+// reload replaces the keys with those of the directory. The caller holds the write lock.
+func (r *KeyRing) reload() error {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read key directory: %w", err)
+	}
+
+	var keys []*SigningKey
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+		path := filepath.Join(r.dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read key %s: %w", path, err)
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to read key %s: %w", path, err)
+		}
+		key, err := ParseSigningKey(strings.TrimSuffix(entry.Name(), ".pem"), data, info.ModTime())
+		if err != nil {
+			return fmt.Errorf("failed to load key %s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	r.keys = keys
+	return nil
+}
+
+// save writes a generated key to the directory, through a temporary file so that other replicas
+// never read a partly written key
+func (r *KeyRing) save(key *SigningKey) error {
+	if r.dir == "" {
+		return nil
+	}
+	data, err := key.MarshalPEM()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(r.dir, ".key-*")
+	if err != nil {
+		return fmt.Errorf("failed to save key: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save key: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save key: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(r.dir, key.ID+".pem")); err != nil {
+		return fmt.Errorf("failed to save key: %w", err)
+	}
+	return nil
+}
+
+// JWKS returns the public keys of the ring
+func (r *KeyRing) JWKS() JWKS {
+	keys := r.Keys()
+	jwks := JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwks.Keys = append(jwks.Keys, key.JWK())
+	}
+	return jwks
+}
@@ -11,13 +11,17 @@ const (
 	API_VERSION = "v1"
 	
 	// Context keys
-	TENANT_CONTEXT_KEY = "tenant_context"
-	USER_CONTEXT_KEY   = "user_context"
+	TENANT_CONTEXT_KEY             = "tenant_context"
+	USER_CONTEXT_KEY               = "user_context"
+	PERMISSION_CHECKER_CONTEXT_KEY = "permission_checker"
+	REVOCATION_LIST_CONTEXT_KEY    = "revocation_list"
+	TENANT_RESOLVER_CONTEXT_KEY    = "tenant_resolver"
 	
 	// JWT settings - default values
 	DEFAULT_JWT_SECRET                = "dev-secret-key-change-in-production"
 	JWT_ACCESS_TOKEN_EXPIRY          = 15 * 60                // 15 minutes in seconds
 	JWT_REFRESH_TOKEN_EXPIRY         = 7 * 24 * 60 * 60       // 7 days in seconds
+	JWT_MFA_TOKEN_EXPIRY             = 5 * 60                 // 5 minutes in seconds
 )
 
 // JWT_SECRET returns the JWT secret from environment or default value
@@ -47,4 +51,56 @@ const (
 	DefaultDBMaxIdleConns = 10
 	DefaultDBMaxOpenConns = 100
 	DefaultDBConnMaxLife  = 30 // in seconds
+	DefaultDBMigrationsPath = "internal/repositories/postgres/migrations"
+
+	// Storage defaults
+	DefaultStorageDriver    = "local"
+	DefaultStorageLocalPath = "data/uploads"
+	DefaultStorageURLExpiry = 15 * time.Minute
+	DefaultMaxUploadSize    = 20 << 20 // 20 MiB
+	DefaultOrgStorageQuota  = 5 << 30  // 5 GiB
+
+	// RBAC defaults
+	DefaultPermissionCacheTTL = 5 * time.Minute
+
+	// Tenant resolution defaults
+	DefaultTenantBaseDomain = "localhost"
+	DefaultTenantCacheTTL   = time.Minute
+
+	// JWT signing defaults
+	DefaultJWTAlgorithm        = "HS256"
+	DefaultJWTKeysPath         = "data/jwt-keys"
+	DefaultJWTRotationInterval = 30 * 24 * time.Hour
+	JWTKeyCheckInterval        = time.Minute     // how often the signing keys are checked for rotation
+	JWKSCacheMaxAge            = 5 * time.Minute // how long verifiers may cache the published keys
+
+	// Two-factor authentication defaults
+	DefaultMFAIssuer  = "RoutrApp" // shown next to the account in authenticator apps
+	RecoveryCodeCount = 10
+
+	// Login lockout defaults
+	DefaultLockoutStore          = "memory"
+	DefaultLockoutMaxAttempts    = 10
+	DefaultLockoutBackoffAfter   = 3
+	DefaultLockoutIPMaxAttempts  = 100
+	DefaultLockoutIPBackoffAfter = 20
+	DefaultLockoutBaseDelay      = time.Second
+	DefaultLockoutMaxDelay       = time.Minute
+	DefaultLockoutDuration       = 15 * time.Minute
+	DefaultLockoutWindow         = time.Hour
+
+	// Access token revocation defaults
+	DefaultRevocationStore = "memory"
+
+	// Mail defaults
+	DefaultMailDriver     = "outbox"
+	DefaultMailFrom       = "RoutrApp <no-reply@localhost>"
+	DefaultMailOutboxPath = "data/outbox"
+	DefaultSMTPPort       = 587
+	MailSendTimeout       = 15 * time.Second
+	MailQueueSize         = 100 // emails waiting to be sent in the background
+
+	// Account email token lifetimes
+	PasswordResetTokenExpiry     = time.Hour
+	EmailVerificationTokenExpiry = 48 * time.Hour
 ) 
\ No newline at end of file
@@ -0,0 +1,240 @@
+// Package exif reads the capture time and GPS position from the EXIF block of JPEG images.
+// It only understands the handful of tags the API needs and ignores everything else.
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// Metadata is the subset of EXIF data recorded for uploaded photos
+type Metadata struct {
+	CapturedAt *time.Time
+	Lat        *float64
+	Lng        *float64
+}
+
+// ErrNoExif is returned when the image has no readable EXIF block
+var ErrNoExif = errors.New("exif: no exif data")
+
+// TIFF tags
+const (
+	tagDateTime           = 0x0132
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagGPSLatitudeRef     = 0x0001
+	tagGPSLatitude        = 0x0002
+	tagGPSLongitudeRef    = 0x0003
+	tagGPSLongitude       = 0x0004
+)
+
+// TIFF field types
+const (
+	typeASCII    = 2
+	typeRational = 5
+)
+
+const exifDateLayout = "2006:01:02 15:04:05"
+
+// Decode reads the EXIF block of a JPEG image. Timestamps without an offset tag are read as UTC.
+func Decode(r io.Reader) (*Metadata, error) {
+	segment, err := findExifSegment(r)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := newTIFF(segment)
+	if err != nil {
+		return nil, err
+	}
+
+	ifd0, err := t.readIFD(t.order.Uint32(segment[4:8]))
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &Metadata{}
+	dateTime := t.ascii(ifd0[tagDateTime])
+	offset := ""
+	if exifIFD, ok := ifd0[tagExifIFD]; ok {
+		if fields, err := t.readIFD(t.long(exifIFD)); err == nil {
+			if original := t.ascii(fields[tagDateTimeOriginal]); original != "" {
+				dateTime = original
+				offset = t.ascii(fields[tagOffsetTimeOriginal])
+			}
+		}
+	}
+	metadata.CapturedAt = parseDateTime(dateTime, offset)
+
+	if gpsIFD, ok := ifd0[tagGPSIFD]; ok {
+		if fields, err := t.readIFD(t.long(gpsIFD)); err == nil {
+			metadata.Lat = t.coordinate(fields[tagGPSLatitude], t.ascii(fields[tagGPSLatitudeRef]), "S", 90)
+			metadata.Lng = t.coordinate(fields[tagGPSLongitude], t.ascii(fields[tagGPSLongitudeRef]), "W", 180)
+		}
+	}
+
+	return metadata, nil
+}
+
+// findExifSegment walks the JPEG markers up to the image data and returns the TIFF payload of the APP1 Exif segment
+func findExifSegment(r io.Reader) ([]byte, error) {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi != [2]byte{0xFF, 0xD8} {
+		return nil, ErrNoExif
+	}
+
+	for {
+		var marker [4]byte
+		if _, err := io.ReadFull(r, marker[:]); err != nil || marker[0] != 0xFF {
+			return nil, ErrNoExif
+		}
+		// Start of scan: no metadata segments follow
+		if marker[1] == 0xDA {
+			return nil, ErrNoExif
+		}
+
+		length := int(binary.BigEndian.Uint16(marker[2:])) - 2
+		if length < 0 {
+			return nil, ErrNoExif
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, ErrNoExif
+		}
+		if marker[1] == 0xE1 && bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+			return payload[6:], nil
+		}
+	}
+}
+
+// field is a raw IFD entry
+type field struct {
+	typ   uint16
+	count uint32
+	value []byte // the 4 byte value or offset
+}
+
+// tiff is a parsed TIFF header over the Exif payload
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+func newTIFF(data []byte) (*tiff, error) {
+	if len(data) < 8 {
+		return nil, ErrNoExif
+	}
+	t := &tiff{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, ErrNoExif
+	}
+	if t.order.Uint16(data[2:4]) != 42 {
+		return nil, ErrNoExif
+	}
+	return t, nil
+}
+
+// readIFD reads the entries of the image file directory at offset
+func (t *tiff) readIFD(offset uint32) (map[uint16]field, error) {
+	if uint64(offset)+2 > uint64(len(t.data)) {
+		return nil, ErrNoExif
+	}
+	count := int(t.order.Uint16(t.data[offset:]))
+	start := int(offset) + 2
+	if start+count*12 > len(t.data) {
+		return nil, ErrNoExif
+	}
+
+	fields := make(map[uint16]field, count)
+	for i := 0; i < count; i++ {
+		entry := t.data[start+i*12 : start+(i+1)*12]
+		fields[t.order.Uint16(entry[0:2])] = field{
+			typ:   t.order.Uint16(entry[2:4]),
+			count: t.order.Uint32(entry[4:8]),
+			value: entry[8:12],
+		}
+	}
+	return fields, nil
+}
+
+// bytes returns the field's data, which is stored inline when it fits in 4 bytes
+func (t *tiff) bytes(f field, size uint32) []byte {
+	total := uint64(size) * uint64(f.count)
+	if total <= 4 {
+		return f.value[:total]
+	}
+	offset := uint64(t.order.Uint32(f.value))
+	if offset+total > uint64(len(t.data)) {
+		return nil
+	}
+	return t.data[offset : offset+total]
+}
+
+func (t *tiff) long(f field) uint32 {
+	return t.order.Uint32(f.value)
+}
+
+func (t *tiff) ascii(f field) string {
+	if f.typ != typeASCII {
+		return ""
+	}
+	return strings.TrimRight(string(t.bytes(f, 1)), "\x00 ")
+}
+
+// coordinate converts a degrees/minutes/seconds rational triple to signed decimal degrees
+func (t *tiff) coordinate(f field, ref, negativeRef string, limit float64) *float64 {
+	if f.typ != typeRational || f.count != 3 {
+		return nil
+	}
+	raw := t.bytes(f, 8)
+	if raw == nil {
+		return nil
+	}
+
+	var parts [3]float64
+	for i := range parts {
+		numerator := t.order.Uint32(raw[i*8:])
+		denominator := t.order.Uint32(raw[i*8+4:])
+		if denominator == 0 {
+			return nil
+		}
+		parts[i] = float64(numerator) / float64(denominator)
+	}
+
+	value := parts[0] + parts[1]/60 + parts[2]/3600
+	if strings.EqualFold(ref, negativeRef) {
+		value = -value
+	}
+	if value < -limit || value > limit {
+		return nil
+	}
+	return &value
+}
+
+// parseDateTime parses an EXIF timestamp with an optional "+02:00" style offset
+func parseDateTime(value, offset string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	if offset != "" {
+		if parsed, err := time.Parse(exifDateLayout+"-07:00", value+offset); err == nil {
+			return &parsed
+		}
+	}
+	parsed, err := time.Parse(exifDateLayout, value)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
@@ -4,12 +4,16 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"regexp"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
 
+// checklistKeyPattern matches valid checklist item keys
+var checklistKeyPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
 // ValidatorInstance is the global validator instance
 var ValidatorInstance *validator.Validate
 
@@ -282,4 +286,20 @@ func ValidateUpdateRouteStops(stops []RouteStopUpdateRequest) error {
 	}
 	
 	return nil
-} 
\ No newline at end of file
+} 
+
+// ValidateStopChecklist validates checklist item keys for format and uniqueness
+func ValidateStopChecklist(checklist StopChecklistRequest) error {
+	keys := make(map[string]bool)
+	for _, item := range checklist.Items {
+		if !checklistKeyPattern.MatchString(item.Key) {
+			return fmt.Errorf("invalid checklist key %q: use lowercase letters, digits and underscores", item.Key)
+		}
+		if keys[item.Key] {
+			return fmt.Errorf("duplicate checklist key: %s", item.Key)
+		}
+		keys[item.Key] = true
+	}
+
+	return nil
+}
@@ -17,8 +17,10 @@ type UserRegistrationRequest struct {
 
 // UserLoginRequest represents request for user login
 type UserLoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	SubDomain  string `json:"sub_domain,omitempty" binding:"omitempty,max=100,alphanum"` // Required when the email is registered with several organizations
+	DeviceName string `json:"device_name,omitempty" binding:"omitempty,max=100"`         // Shown in the user's list of sessions
 }
 
 // UserUpdateRequest represents request for updating user profile
@@ -29,12 +31,79 @@ type UserUpdateRequest struct {
 	Active    *bool             `json:"active,omitempty"`
 }
 
+// RoleCreateRequest represents request for creating a custom role
+type RoleCreateRequest struct {
+	Name        string   `json:"name" binding:"required,min=2,max=20"` // lowercase letters, digits and underscores, starting with a letter
+	DisplayName string   `json:"display_name" binding:"required,min=1,max=100"`
+	Description string   `json:"description,omitempty" binding:"omitempty,max=500"`
+	Permissions []string `json:"permissions" binding:"required,min=1,max=100,dive,required,max=100"`
+}
+
+// RoleUpdateRequest represents request for updating a role
+type RoleUpdateRequest struct {
+	DisplayName *string   `json:"display_name,omitempty" binding:"omitempty,min=1,max=100"`
+	Description *string   `json:"description,omitempty" binding:"omitempty,max=500"`
+	Permissions *[]string `json:"permissions,omitempty" binding:"omitempty,min=1,max=100,dive,required,max=100"`
+	Active      *bool     `json:"active,omitempty"`
+}
+
+// UserRoleAssignRequest represents request for moving a user to another role
+type UserRoleAssignRequest struct {
+	RoleID uint `json:"role_id" binding:"required,min=1"`
+}
+
 // ChangePasswordRequest represents request for changing user password
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" binding:"required"`
 	NewPassword     string `json:"new_password" binding:"required,min=8,max=255"`
 }
 
+// ForgotPasswordRequest represents request for a password reset link
+type ForgotPasswordRequest struct {
+	Email     string `json:"email" binding:"required,email"`
+	SubDomain string `json:"sub_domain,omitempty" binding:"omitempty,max=100,alphanum"` // Required when the email is registered with several organizations
+}
+
+// ResetPasswordRequest represents request for setting a new password with a reset token
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required,max=100"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=255"`
+}
+
+// VerifyEmailRequest represents request for confirming an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required,max=100"`
+}
+
+// MFAVerifyRequest represents the second step of a two-factor login; either the code or a recovery code is required
+type MFAVerifyRequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code,omitempty" binding:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code,omitempty" binding:"omitempty,max=20"`
+	DeviceName   string `json:"device_name,omitempty" binding:"omitempty,max=100"`
+}
+
+// MFAEnrollRequest represents request for a TOTP secret during a login that requires enrollment
+type MFAEnrollRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// MFACodeRequest represents request confirmed with a code from the authenticator app
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// MFADisableRequest represents request for turning off two-factor authentication
+type MFADisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// MFAPolicyRequest represents request for changing the organization's two-factor authentication policy
+type MFAPolicyRequest struct {
+	RequireMFA *bool `json:"require_mfa" binding:"required"`
+}
+
 // TenantCreateRequest represents request for creating a new tenant
 type TenantCreateRequest struct {
 	Name           string `json:"name" binding:"required,min=1,max=100"`
@@ -80,6 +149,13 @@ type RouteUpdateRequest struct {
 	Stops         []RouteStopUpdateRequest   `json:"stops,omitempty" binding:"omitempty,dive"`
 }
 
+// RouteTransitionRequest represents the optional body of a route lifecycle action (start, pause, ...)
+type RouteTransitionRequest struct {
+	Notes string   `json:"notes,omitempty" binding:"omitempty,max=1000"`
+	Lat   *float64 `json:"lat,omitempty" binding:"required_with=Lng,omitempty,latitude"`
+	Lng   *float64 `json:"lng,omitempty" binding:"required_with=Lat,omitempty,longitude"`
+}
+
 // RouteStopCreateRequest represents request for creating a route stop
 type RouteStopCreateRequest struct {
 	Name        string               `json:"name" binding:"required,min=1,max=100"`
@@ -108,6 +184,51 @@ type RouteStopUpdateRequest struct {
 	IsCompleted *bool                `json:"is_completed,omitempty"`
 }
 
+// RouteOptimizeRequest represents request for optimizing the stop sequence of a route
+type RouteOptimizeRequest struct {
+	PinFirst             bool       `json:"pin_first"` // keep the first stop as the starting depot
+	PinLast              bool       `json:"pin_last"`  // keep the last stop as the ending depot
+	AverageSpeedKmh      *float64   `json:"average_speed_kmh,omitempty" binding:"omitempty,gt=0,max=200"`
+	StartTime            *time.Time `json:"start_time,omitempty"` // arrival at the first stop; defaults to the route's scheduled date
+	TimeWindowMode       string     `json:"time_window_mode,omitempty" binding:"omitempty,oneof=ignore hard soft"`
+	LatePenaltyPerMinute *float64   `json:"late_penalty_per_minute,omitempty" binding:"omitempty,gt=0"` // soft windows only, in km per minute late
+	DryRun               bool       `json:"dry_run"`                                                    // return the result without saving it
+}
+
+// RouteScheduleRequest represents query parameters for previewing a route's schedule
+type RouteScheduleRequest struct {
+	StartTime       *time.Time `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	AverageSpeedKmh *float64   `form:"average_speed_kmh" binding:"omitempty,gt=0,max=200"`
+}
+
+// RoutePlanRequest represents request for splitting a pool of stops into one route per technician
+type RoutePlanRequest struct {
+	Name                 string                 `json:"name" binding:"required,min=1,max=60"` // prefix for the generated route names
+	ScheduledDate        *time.Time             `json:"scheduled_date,omitempty"`
+	Notes                string                 `json:"notes,omitempty" binding:"omitempty,max=1000"`
+	TechnicianIDs        []uint                 `json:"technician_ids,omitempty" binding:"omitempty,max=50,dive,min=1"` // defaults to every active technician
+	Stops                []RoutePlanStopRequest `json:"stops" binding:"required,min=1,max=500,dive"`
+	MaxStopsPerRoute     *int                   `json:"max_stops_per_route,omitempty" binding:"omitempty,min=1"`
+	AverageSpeedKmh      *float64               `json:"average_speed_kmh,omitempty" binding:"omitempty,gt=0,max=200"`
+	BalanceWeight        *float64               `json:"balance_weight,omitempty" binding:"omitempty,min=0,max=100"` // 0 minimises drive time only
+	StartTime            *time.Time             `json:"start_time,omitempty"`                                       // when technicians leave their location; defaults to the scheduled date
+	TimeWindowMode       string                 `json:"time_window_mode,omitempty" binding:"omitempty,oneof=ignore hard soft"`
+	LatePenaltyPerMinute *float64               `json:"late_penalty_per_minute,omitempty" binding:"omitempty,gt=0"` // soft windows only, in km per minute late
+	DryRun               bool                   `json:"dry_run"`                                                    // return the plan without creating routes
+}
+
+// RoutePlanStopRequest represents an unsequenced stop in a route plan
+type RoutePlanStopRequest struct {
+	Name       string             `json:"name" binding:"required,min=1,max=100"`
+	Address    string             `json:"address" binding:"required,min=1,max=255"`
+	Lat        float64            `json:"lat" binding:"required,latitude"`
+	Lng        float64            `json:"lng" binding:"required,longitude"`
+	StopType   string             `json:"stop_type" binding:"required,oneof=pickup delivery service maintenance"`
+	Duration   int                `json:"duration" binding:"required,min=1,max=1440"` // max 24 hours in minutes
+	Notes      string             `json:"notes,omitempty" binding:"omitempty,max=1000"`
+	TimeWindow *TimeWindowRequest `json:"time_window,omitempty"`
+}
+
 // TimeWindowRequest represents time window validation
 type TimeWindowRequest struct {
 	StartTime *time.Time `json:"start_time,omitempty"`
@@ -128,20 +249,74 @@ type TechnicianUpdateRequest struct {
 	Notes       *string                  `json:"notes,omitempty" binding:"omitempty,max=1000"`
 }
 
+// TechnicianSelfUpdateRequest represents request for technicians updating their own profile.
+// Deactivation and on_route are managed by owners and route progress, not by the technician.
+type TechnicianSelfUpdateRequest struct {
+	Status      *models.TechnicianStatus `json:"status,omitempty" binding:"omitempty,oneof=active on_break off_duty"`
+	PhoneNumber *string                  `json:"phone_number,omitempty" binding:"omitempty,min=10,max=20"`
+	Notes       *string                  `json:"notes,omitempty" binding:"omitempty,max=1000"`
+}
+
 // LocationUpdateRequest represents request for updating technician location
 type LocationUpdateRequest struct {
-	Lat float64 `json:"lat" binding:"required,latitude"`
-	Lng float64 `json:"lng" binding:"required,longitude"`
+	Lat        float64    `json:"lat" binding:"required,latitude"`
+	Lng        float64    `json:"lng" binding:"required,longitude"`
+	RecordedAt *time.Time `json:"recorded_at,omitempty"`                                  // defaults to the time the server receives the point
+	Accuracy   *float64   `json:"accuracy,omitempty" binding:"omitempty,min=0,max=100000"` // in meters
+	Speed      *float64   `json:"speed,omitempty" binding:"omitempty,min=0,max=150"`       // in meters per second
+	Heading    *float64   `json:"heading,omitempty" binding:"omitempty,min=0,lt=360"`      // in degrees clockwise from north
+}
+
+// LocationBatchRequest represents a batch of buffered location points, e.g. sent after a connectivity gap
+type LocationBatchRequest struct {
+	Points []LocationUpdateRequest `json:"points" binding:"omitempty,max=500,dive"`
+}
+
+// LocationHistoryRequest represents query parameters for a technician's location trail
+type LocationHistoryRequest struct {
+	From      *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"` // defaults to 24 hours before to
+	To        *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`   // defaults to now
+	RouteID   *uint      `form:"route_id" binding:"omitempty,min=1"`
+	MaxPoints int        `form:"max_points,default=500" binding:"min=2,max=5000"`
 }
 
 // RouteActivityCreateRequest represents request for creating route activity
 type RouteActivityCreateRequest struct {
-	RouteStopID  *uint   `json:"route_stop_id,omitempty" binding:"omitempty,min=1"`
-	ActivityType string  `json:"activity_type" binding:"required,oneof=start stop complete pause resume note photo"`
-	Notes        string  `json:"notes,omitempty" binding:"omitempty,max=1000"`
-	Lat          *float64 `json:"lat,omitempty" binding:"omitempty,latitude"`
-	Lng          *float64 `json:"lng,omitempty" binding:"omitempty,longitude"`
-	PhotoURL     string  `json:"photo_url,omitempty" binding:"omitempty,url,max=255"`
+	RouteStopID  *uint      `json:"route_stop_id,omitempty" binding:"omitempty,min=1"`
+	ActivityType string     `json:"activity_type" binding:"required,oneof=start stop complete pause resume note photo"`
+	Notes        string     `json:"notes,omitempty" binding:"omitempty,max=1000"`
+	Lat          *float64   `json:"lat,omitempty" binding:"omitempty,latitude"`
+	Lng          *float64   `json:"lng,omitempty" binding:"omitempty,longitude"`
+	PhotoURL     string     `json:"photo_url,omitempty" binding:"omitempty,url,max=255"`
+	Timestamp    *time.Time `json:"timestamp,omitempty"` // defaults to the time the server receives the activity
+}
+
+// StopCompletionRequest represents the proof-of-service payload of a stop attempt
+type StopCompletionRequest struct {
+	Outcome       string                 `json:"outcome" binding:"required,oneof=completed failed"`
+	RecipientName string                 `json:"recipient_name,omitempty" binding:"omitempty,max=100"`
+	Signature     string                 `json:"signature,omitempty"` // base64 encoded PNG or JPEG, optionally as a data URL
+	Checklist     map[string]interface{} `json:"checklist,omitempty"` // answers keyed by checklist item key
+	FailureReason string                 `json:"failure_reason,omitempty" binding:"omitempty,oneof=recipient_unavailable access_denied address_not_found refused damaged weather vehicle_issue other"`
+	Notes         string                 `json:"notes,omitempty" binding:"omitempty,max=1000"`
+	Lat           *float64               `json:"lat,omitempty" binding:"required_with=Lng,omitempty,latitude"`
+	Lng           *float64               `json:"lng,omitempty" binding:"required_with=Lat,omitempty,longitude"`
+	Timestamp     *time.Time             `json:"timestamp,omitempty"` // defaults to the time the server receives the request
+}
+
+// StopChecklistItemRequest represents a single checklist question
+type StopChecklistItemRequest struct {
+	Key      string `json:"key" binding:"required,min=1,max=50"` // lowercase letters, digits and underscores
+	Label    string `json:"label" binding:"required,min=1,max=255"`
+	Type     string `json:"type" binding:"required,oneof=boolean text number"`
+	Required bool   `json:"required"`
+}
+
+// StopChecklistRequest represents request for defining the checklist of a stop type
+type StopChecklistRequest struct {
+	RequireSignature     bool                       `json:"require_signature"`
+	RequireRecipientName bool                       `json:"require_recipient_name"`
+	Items                []StopChecklistItemRequest `json:"items" binding:"omitempty,max=50,dive"`
 }
 
 // PaginationRequest represents common pagination parameters
@@ -166,6 +341,15 @@ type RouteFilterRequest struct {
 	DateTo       *time.Time           `form:"date_to,omitempty"`
 }
 
+// RouteActivityFilterRequest represents route activity timeline filtering
+type RouteActivityFilterRequest struct {
+	ActivityType []string   `form:"activity_type,omitempty" binding:"omitempty,dive,min=1,max=50"`
+	RouteID      *uint      `form:"route_id,omitempty" binding:"omitempty,min=1"`
+	RouteStopID  *uint      `form:"route_stop_id,omitempty" binding:"omitempty,min=1"`
+	DateFrom     *time.Time `form:"date_from,omitempty"`
+	DateTo       *time.Time `form:"date_to,omitempty"`
+}
+
 // TechnicianFilterRequest represents technician-specific filtering  
 type TechnicianFilterRequest struct {
 	FilterRequest
@@ -190,4 +374,7 @@ type RegistrationRequest struct {
 	OrganizationName  string `json:"organization_name" binding:"required,min=1,max=100"`
 	OrganizationEmail string `json:"organization_email" binding:"required,email,max=100"`
 	SubDomain         string `json:"sub_domain" binding:"required,min=1,max=100,alphanum"`
+
+	// Session details
+	DeviceName string `json:"device_name,omitempty" binding:"omitempty,max=100"`
 }
@@ -32,11 +32,27 @@ type MetaData struct {
 // UserResponse represents a user in API responses
 type UserResponse struct {
 	BaseResponse
-	Email     string    `json:"email"`
-	FirstName string    `json:"first_name"`
-	LastName  string    `json:"last_name"`
-	Active    bool      `json:"active"`
-	Role      string    `json:"role,omitempty"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	MFAEnabled    bool   `json:"mfa_enabled"`
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+	Active        bool   `json:"active"`
+	Role          string `json:"role,omitempty"`
+}
+
+// RoleResponse represents a role in API responses
+type RoleResponse struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"display_name"`
+	Description string    `json:"description,omitempty"`
+	Permissions []string  `json:"permissions"`
+	Active      bool      `json:"active"`
+	BuiltIn     bool      `json:"built_in"`
+	UserCount   int64     `json:"user_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 // TenantResponse represents a tenant in API responses
@@ -51,6 +67,7 @@ type TenantResponse struct {
 	SecondaryColor string    `json:"secondary_color,omitempty"`
 	Active         bool      `json:"active"`
 	PlanType       string    `json:"plan_type"`
+	RequireMFA     bool      `json:"require_mfa"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
@@ -69,10 +86,57 @@ type RouteResponse struct {
 	ScheduledDate *time.Time           `json:"scheduled_date,omitempty"`
 	StartedAt     *time.Time           `json:"started_at,omitempty"`
 	CompletedAt   *time.Time           `json:"completed_at,omitempty"`
+	CancelledAt   *time.Time           `json:"cancelled_at,omitempty"`
+	IsOptimized   bool                 `json:"is_optimized"`
+	TotalDistance float64              `json:"total_distance"`
+	TotalDuration int                  `json:"total_duration"`
 	Notes         string               `json:"notes,omitempty"`
+	StopsCount    int                  `json:"stops_count"`
 	Stops         []RouteStopResponse  `json:"stops,omitempty"`
 }
 
+// RouteOptimizationResponse represents the outcome of optimizing a route
+type RouteOptimizationResponse struct {
+	Route            RouteResponse          `json:"route"`
+	PreviousDistance float64                `json:"previous_distance"`
+	DistanceSaved    float64                `json:"distance_saved"`
+	Schedule         *RouteScheduleResponse `json:"schedule,omitempty"`
+}
+
+// RoutePlanResponse represents the routes produced by splitting a stop pool across technicians
+type RoutePlanResponse struct {
+	Routes             []RouteResponse `json:"routes"`
+	UnassignedStops    []int           `json:"unassigned_stops"`    // indices into the requested stops
+	SkippedTechnicians []uint          `json:"skipped_technicians"` // not active or without a known location
+	LateStops          []int           `json:"late_stops"`          // indices into the requested stops that miss their time window
+	TotalDistance      float64         `json:"total_distance"`
+	MaxDuration        int             `json:"max_duration"` // longest route in seconds, including waiting
+}
+
+// RouteScheduleResponse represents simulated stop timings for a route
+type RouteScheduleResponse struct {
+	RouteID       uint                   `json:"route_id"`
+	StartTime     time.Time              `json:"start_time"`
+	EndTime       time.Time              `json:"end_time"`
+	TotalDuration int                    `json:"total_duration"` // in seconds, including waiting
+	Feasible      bool                   `json:"feasible"`
+	LateStops     []uint                 `json:"late_stops"`
+	Stops         []StopScheduleResponse `json:"stops"`
+}
+
+// StopScheduleResponse represents the simulated timings of a single stop
+type StopScheduleResponse struct {
+	StopID        uint      `json:"stop_id"`
+	SequenceNum   int       `json:"sequence_num"`
+	Name          string    `json:"name"`
+	ArrivalTime   time.Time `json:"arrival_time"`
+	ServiceStart  time.Time `json:"service_start"`
+	DepartureTime time.Time `json:"departure_time"`
+	WaitSeconds   int       `json:"wait_seconds"`
+	LateSeconds   int       `json:"late_seconds"`
+	IsLate        bool      `json:"is_late"`
+}
+
 // RouteStopResponse represents a route stop in API responses
 type RouteStopResponse struct {
 	ID          uint       `json:"id"`
@@ -84,13 +148,94 @@ type RouteStopResponse struct {
 	StopType    string     `json:"stop_type"`
 	Duration    int        `json:"duration"`
 	Notes       string     `json:"notes,omitempty"`
-	TimeWindow  string     `json:"time_window,omitempty"`
+	TimeWindow  *TimeWindowResponse `json:"time_window,omitempty"`
 	IsCompleted bool       `json:"is_completed"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	PhotosCount int        `json:"photos_count"`
+	NotesCount  int        `json:"notes_count"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
+// RouteActivityResponse represents a route activity in API responses
+type RouteActivityResponse struct {
+	ID           uint      `json:"id"`
+	RouteID      uint      `json:"route_id"`
+	RouteStopID  *uint     `json:"route_stop_id,omitempty"`
+	TechnicianID uint      `json:"technician_id"`
+	ActivityType string    `json:"activity_type"`
+	Notes        string    `json:"notes,omitempty"`
+	Lat          *float64  `json:"lat,omitempty"`
+	Lng          *float64  `json:"lng,omitempty"`
+	PhotoURL     string    `json:"photo_url,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RouteActivityResultResponse represents a recorded activity and the stop it updated
+type RouteActivityResultResponse struct {
+	Activity RouteActivityResponse `json:"activity"`
+	Stop     *RouteStopResponse    `json:"stop,omitempty"`
+}
+
+// StopAttachmentResponse represents an uploaded stop photo or document in API responses
+type StopAttachmentResponse struct {
+	ID           uint                  `json:"id"`
+	RouteID      uint                  `json:"route_id"`
+	RouteStopID  uint                  `json:"route_stop_id"`
+	UploadedByID uint                  `json:"uploaded_by_id"`
+	Kind         models.AttachmentKind `json:"kind"`
+	FileName     string                `json:"file_name"`
+	ContentType  string                `json:"content_type"`
+	Size         int64                 `json:"size"`
+	Checksum     string                `json:"checksum"`
+	CapturedAt   *time.Time            `json:"captured_at,omitempty"`
+	Lat          *float64              `json:"lat,omitempty"`
+	Lng          *float64              `json:"lng,omitempty"`
+	DownloadURL  string                `json:"download_url"`
+	URLExpiresAt time.Time             `json:"url_expires_at"`
+	CreatedAt    time.Time             `json:"created_at"`
+}
+
+// StopChecklistResponse represents the checklist of a stop type in API responses
+type StopChecklistResponse struct {
+	StopType             string                 `json:"stop_type"`
+	RequireSignature     bool                   `json:"require_signature"`
+	RequireRecipientName bool                   `json:"require_recipient_name"`
+	Items                []models.ChecklistItem `json:"items"`
+	UpdatedAt            time.Time              `json:"updated_at"`
+}
+
+// StopProofResponse represents the proof-of-service record of a stop attempt in API responses
+type StopProofResponse struct {
+	ID            uint                     `json:"id"`
+	RouteID       uint                     `json:"route_id"`
+	RouteStopID   uint                     `json:"route_stop_id"`
+	TechnicianID  uint                     `json:"technician_id"`
+	Outcome       models.StopOutcome       `json:"outcome"`
+	RecipientName string                   `json:"recipient_name,omitempty"`
+	Signature     *StopAttachmentResponse  `json:"signature,omitempty"`
+	Checklist     []models.ChecklistAnswer `json:"checklist"`
+	FailureReason models.FailureReason     `json:"failure_reason,omitempty"`
+	Notes         string                   `json:"notes,omitempty"`
+	Lat           *float64                 `json:"lat,omitempty"`
+	Lng           *float64                 `json:"lng,omitempty"`
+	RecordedAt    time.Time                `json:"recorded_at"`
+	CreatedAt     time.Time                `json:"created_at"`
+}
+
+// StopProofRecordResponse represents a stop with all of its attempts in API responses
+type StopProofRecordResponse struct {
+	Stop     RouteStopResponse   `json:"stop"`
+	Attempts []StopProofResponse `json:"attempts"`
+}
+
+// TimeWindowResponse represents a stop time window in API responses
+type TimeWindowResponse struct {
+	StartTime *time.Time `json:"start_time,omitempty"`
+	EndTime   *time.Time `json:"end_time,omitempty"`
+}
+
 // TechnicianResponse represents a technician in API responses
 type TechnicianResponse struct {
 	ID          uint                      `json:"id"`
@@ -105,13 +250,69 @@ type TechnicianResponse struct {
 	UpdatedAt   time.Time                 `json:"updated_at"`
 }
 
+// LocationIngestResponse represents the outcome of recording technician location points
+type LocationIngestResponse struct {
+	Accepted int        `json:"accepted"`
+	LastLat  *float64   `json:"last_lat,omitempty"`
+	LastLng  *float64   `json:"last_lng,omitempty"`
+	LastSeen *time.Time `json:"last_seen,omitempty"`
+}
+
+// LocationPointResponse represents a point in a technician's location trail
+type LocationPointResponse struct {
+	Lat        float64   `json:"lat"`
+	Lng        float64   `json:"lng"`
+	Accuracy   *float64  `json:"accuracy,omitempty"`
+	Speed      *float64  `json:"speed,omitempty"`
+	Heading    *float64  `json:"heading,omitempty"`
+	RouteID    *uint     `json:"route_id,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// LocationTrailResponse represents a technician's breadcrumb trail over a time range
+type LocationTrailResponse struct {
+	TechnicianID uint                    `json:"technician_id"`
+	From         time.Time               `json:"from"`
+	To           time.Time               `json:"to"`
+	TotalPoints  int                     `json:"total_points"`
+	Downsampled  bool                    `json:"downsampled"`
+	Distance     float64                 `json:"distance"` // in kilometers, over every recorded point
+	Points       []LocationPointResponse `json:"points"`
+}
+
 // LoginResponse represents the response for successful login
 type LoginResponse struct {
-	User         UserResponse `json:"user"`
-	AccessToken  string       `json:"access_token"`
-	RefreshToken string       `json:"refresh_token"`
-	TokenType    string       `json:"token_type"`
-	ExpiresIn    int          `json:"expires_in"`
+	User          UserResponse `json:"user"`
+	AccessToken   string       `json:"access_token"`
+	RefreshToken  string       `json:"refresh_token"`
+	TokenType     string       `json:"token_type"`
+	ExpiresIn     int          `json:"expires_in"`
+	RecoveryCodes []string     `json:"recovery_codes,omitempty"` // only when the login completed two-factor enrollment
+}
+
+// MFAChallengeResponse is returned by login when the user must provide a second factor. The MFA
+// token is exchanged for session tokens at /auth/mfa/verify.
+type MFAChallengeResponse struct {
+	MFARequired        bool   `json:"mfa_required"`
+	EnrollmentRequired bool   `json:"enrollment_required"` // the organization requires a second factor the user hasn't set up yet
+	MFAToken           string `json:"mfa_token"`
+	ExpiresIn          int    `json:"expires_in"`
+}
+
+// MFASetupResponse carries a new TOTP secret for the user's authenticator app
+type MFASetupResponse struct {
+	Secret          string `json:"secret"`           // for entering the secret by hand
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI to render as a QR code
+}
+
+// MFARecoveryCodesResponse carries recovery codes, which are only ever shown once
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAPolicyResponse represents the organization's two-factor authentication policy
+type MFAPolicyResponse struct {
+	RequireMFA bool `json:"require_mfa"`
 }
 
 // RegistrationResponse represents the response for successful registration
@@ -124,11 +325,37 @@ type RegistrationResponse struct {
 	ExpiresIn    int                  `json:"expires_in"`
 }
 
-// TokenResponse represents the response for token refresh
+// TokenResponse represents the response for token refresh; the refresh token replaces the one presented
 type TokenResponse struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
-	ExpiresIn   int    `json:"expires_in"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// SessionResponse represents a signed-in device in API responses
+type SessionResponse struct {
+	ID         uint      `json:"id"`
+	DeviceName string    `json:"device_name,omitempty"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	Current    bool      `json:"current"` // the session of the request's access token
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// AccountLockoutResponse represents a lockout of a user's account in API responses
+type AccountLockoutResponse struct {
+	ID             uint       `json:"id"`
+	UserID         uint       `json:"user_id"`
+	IPAddress      string     `json:"ip_address,omitempty"` // address of the failure that locked the account
+	FailedAttempts int        `json:"failed_attempts"`
+	Active         bool       `json:"active"`
+	LockedAt       time.Time  `json:"locked_at"`
+	LockedUntil    time.Time  `json:"locked_until"`
+	UnlockedAt     *time.Time `json:"unlocked_at,omitempty"`
+	UnlockedByID   *uint      `json:"unlocked_by_id,omitempty"`
 }
 
 // ValidationErrorResponse represents validation error details